@@ -254,7 +254,7 @@ run_test() {
     export JENKINS_TEST=1
     ulimit -n 65536
     echo -n "${TPATH}"
-    go test -cover -v -coverprofile=cover.output $(go list ./... | grep -v depends) | tee cubefs_unittest.output
+    go test -tags rocksdb -cover -v -coverprofile=cover.output $(go list ./... | grep -v depends) | tee cubefs_unittest.output
     ret=$?
     popd >/dev/null
     exit $ret
@@ -265,7 +265,7 @@ run_test_cover() {
     export JENKINS_TEST=1
     ulimit -n 65536
     echo -n "${TPATH}"
-    go test -trimpath -covermode=count --coverprofile coverage.txt $(go list ./... | grep -v depends)
+    go test -tags rocksdb -trimpath -covermode=count --coverprofile coverage.txt $(go list ./... | grep -v depends)
     ret=$?
     popd >/dev/null
     exit $ret
@@ -274,7 +274,7 @@ run_test_cover() {
 build_server() {
     pushd $SrcPath >/dev/null
     echo -n "build cfs-server   "
-    CGO_ENABLED=1 go build ${MODFLAGS} -gcflags=all=-trimpath=${SrcPath} -asmflags=all=-trimpath=${SrcPath} -ldflags="${LDFlags}" -o ${BuildBinPath}/cfs-server ${SrcPath}/cmd/*.go && echo "success" || echo "failed"
+    CGO_ENABLED=1 go build ${MODFLAGS} -tags rocksdb -gcflags=all=-trimpath=${SrcPath} -asmflags=all=-trimpath=${SrcPath} -ldflags="${LDFlags}" -o ${BuildBinPath}/cfs-server ${SrcPath}/cmd/*.go && echo "success" || echo "failed"
     popd >/dev/null
 }
 
@@ -356,6 +356,13 @@ build_snapshot() {
     popd >/dev/null
 }
 
+build_metastore() {
+    pushd $SrcPath >/dev/null
+    echo -n "build cfs-metastore   "
+    CGO_ENABLED=1 go build ${MODFLAGS} -tags rocksdb -gcflags=all=-trimpath=${SrcPath} -asmflags=all=-trimpath=${SrcPath} -ldflags="${LDFlags}" -o ${BuildBinPath}/cfs-metastore ${SrcPath}/metastore/*.go  && echo "success" || echo "failed"
+    popd >/dev/null
+}
+
 build_libsdkpre() {
     case `uname` in
         Linux)
@@ -503,6 +510,9 @@ case "$cmd" in
     "snapshot")
         build_snapshot
         ;;
+    "metastore")
+        build_metastore
+        ;;
     "libsdkpre")
         build_libsdkpre
         ;;
@@ -17,6 +17,7 @@ package cmd
 import (
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/sdk/master"
@@ -41,6 +42,8 @@ func newClusterCmd(client *master.MasterClient) *cobra.Command {
 		newClusterSetParasCmd(client),
 		newClusterDisableMpDecommissionCmd(client),
 		newClusterSetVolDeletionDelayTimeCmd(client),
+		newClusterRebalanceCmd(client),
+		newClusterAuditCmd(client),
 	)
 	return clusterCmd
 }
@@ -58,6 +61,12 @@ const (
 	nodeAutoRepairRateKey                  = "autoRepairRate"
 	nodeMaxDpCntLimit                      = "maxDpCntLimit"
 	cmdForbidMpDecommission                = "forbid meta partition decommission"
+	cmdClusterRebalanceShort               = "Rebalance the data partitions among the datanodes"
+	cmdClusterRebalanceStartShort          = "Start to move the data partitions from the over-full datanodes to the under-full ones"
+	cmdClusterRebalanceStopShort           = "Stop moving more data partitions, the moves in progress go on"
+	cmdClusterRebalanceStatusShort         = "Show the status of the data partition rebalance"
+	auditTimeLayout                        = "2006-01-02 15:04:05"
+	cmdClusterAuditShort                   = "List the mutating admin operations on the master"
 )
 
 func newClusterInfoCmd(client *master.MasterClient) *cobra.Command {
@@ -301,3 +310,151 @@ If 'forbid=true', MetaPartition decommission/migrate and MetaNode decommission i
 	}
 	return cmd
 }
+
+func newClusterRebalanceCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   CliOpRebalance,
+		Short: cmdClusterRebalanceShort,
+	}
+	cmd.AddCommand(
+		newClusterRebalanceStartCmd(client),
+		newClusterRebalanceStopCmd(client),
+		newClusterRebalanceStatusCmd(client),
+	)
+	return cmd
+}
+
+func newClusterRebalanceStartCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optZones    []string
+		optNodeSets []string
+		config      proto.RebalanceConfig
+	)
+	cmd := &cobra.Command{
+		Use:   CliOpStart,
+		Short: cmdClusterRebalanceStartShort,
+		Long: `Move the replicas of the data partitions from the most used datanode to the least used one
+of the same nodeset (or zone if the level is zone), until the usage ratios differ within the threshold.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				errout(err)
+			}()
+			config.Zones = optZones
+			for _, nodeSet := range optNodeSets {
+				var id uint64
+				if id, err = strconv.ParseUint(nodeSet, 10, 64); err != nil {
+					err = fmt.Errorf("Parse nodeset id [%v] failed: %v", nodeSet, err)
+					return
+				}
+				config.NodeSets = append(config.NodeSets, id)
+			}
+			if err = client.AdminAPI().StartDataRebalance(&config); err != nil {
+				return
+			}
+			stdout("Data partition rebalance has been started.\n")
+		},
+	}
+	cmd.Flags().StringSliceVar(&optZones, CliFlagZoneName, nil, "Rebalance the datanodes of the zones, all the zones if not specified")
+	cmd.Flags().StringSliceVar(&optNodeSets, "nodeset", nil, "Rebalance the datanodes of the nodesets, all the nodesets if not specified")
+	cmd.Flags().StringVar(&config.Level, "level", proto.RebalanceLevelNodeSet, "Move the replicas within the nodeset or the zone [nodeset|zone]")
+	cmd.Flags().Float64Var(&config.Threshold, CliFlagThreshold, 0.1, "Stop once the usage ratios of the datanodes differ within the threshold")
+	cmd.Flags().IntVar(&config.Concurrency, "concurrency", 5, "Max number of the data partitions moved at the same time")
+	cmd.Flags().Uint64Var(&config.Bandwidth, "bandwidth", 0, "Max bandwidth (MB/s) to move the data partitions, 0 for no limit")
+	return cmd
+}
+
+func newClusterRebalanceStopCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   CliOpStop,
+		Short: cmdClusterRebalanceStopShort,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				errout(err)
+			}()
+			if err = client.AdminAPI().StopDataRebalance(); err != nil {
+				return
+			}
+			stdout("Data partition rebalance has been stopped.\n")
+		},
+	}
+	return cmd
+}
+
+func newClusterRebalanceStatusCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     CliOpStatus,
+		Short:   cmdClusterRebalanceStatusShort,
+		Aliases: []string{"status"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err  error
+				view *proto.RebalanceView
+			)
+			defer func() {
+				errout(err)
+			}()
+			if view, err = client.AdminAPI().GetDataRebalance(); err != nil {
+				return
+			}
+			stdout("[Data partition rebalance]\n")
+			stdout(formatRebalanceView(view))
+		},
+	}
+	return cmd
+}
+
+func newClusterAuditCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optStart string
+		optEnd   string
+		optOp    string
+		optVol   string
+		optLimit int
+	)
+	cmd := &cobra.Command{
+		Use:   CliOpAudit,
+		Short: cmdClusterAuditShort,
+		Long: `List the mutating admin operations recorded by the master, the newest first.
+The operations of the last 24 hours are listed if no start time is specified.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err        error
+				start, end time.Time
+				records    []*proto.AdminAuditRecord
+			)
+			defer func() {
+				errout(err)
+			}()
+			start = time.Now().Add(-24 * time.Hour)
+			if optStart != "" {
+				if start, err = time.ParseInLocation(auditTimeLayout, optStart, time.Local); err != nil {
+					err = fmt.Errorf("Parse start time [%v] failed: %v", optStart, err)
+					return
+				}
+			}
+			var endUnix int64
+			if optEnd != "" {
+				if end, err = time.ParseInLocation(auditTimeLayout, optEnd, time.Local); err != nil {
+					err = fmt.Errorf("Parse end time [%v] failed: %v", optEnd, err)
+					return
+				}
+				endUnix = end.Unix()
+			}
+			if records, err = client.AdminAPI().ListAdminAudits(start.Unix(), endUnix, optOp, optVol, optLimit); err != nil {
+				return
+			}
+			stdoutln(adminAuditTableHeader)
+			for _, record := range records {
+				stdoutln(formatAdminAuditRecord(record))
+			}
+		},
+	}
+	cmd.Flags().StringVar(&optStart, "start", "", "List the operations since the time, e.g. \"2023-01-02 15:04:05\"")
+	cmd.Flags().StringVar(&optEnd, "end", "", "List the operations until the time, e.g. \"2023-01-02 15:04:05\"")
+	cmd.Flags().StringVar(&optOp, "op", "", "List the operations whose api path contains the string, e.g. \"/vol/delete\"")
+	cmd.Flags().StringVar(&optVol, "vol", "", "List the operations on the volume")
+	cmd.Flags().IntVar(&optLimit, "limit", 100, "Max number of the operations listed")
+	return cmd
+}
@@ -48,6 +48,8 @@ type Config struct {
 	MasterAddr  []string `json:"masterAddr"`
 	Timeout     uint16   `json:"timeout"`
 	ClientIDKey string   `json:"clientIDKey"`
+	AccessKey   string   `json:"accessKey"` // signs the requests to the master once RBAC is enabled
+	SecretKey   string   `json:"secretKey"`
 }
 
 func newConfigCmd() *cobra.Command {
@@ -68,6 +70,8 @@ const (
 func newConfigSetCmd() *cobra.Command {
 	var optMasterHosts string
 	var optTimeout string
+	var optAccessKey string
+	var optSecretKey string
 	cmd := &cobra.Command{
 		Use:   CliOpSet,
 		Short: cmdConfigSetShort,
@@ -93,7 +97,7 @@ func newConfigSetCmd() *cobra.Command {
 				return
 			}
 
-			if err = setConfig(optMasterHosts, timeOut); err != nil {
+			if err = setConfig(optMasterHosts, timeOut, optAccessKey, optSecretKey); err != nil {
 				return
 			}
 			stdout("Config has been set successfully!\n")
@@ -102,6 +106,8 @@ func newConfigSetCmd() *cobra.Command {
 	cmd.Flags().StringVar(&optMasterHosts, "addr", "",
 		"Specify master address {HOST}:{PORT}[,{HOST}:{PORT}]")
 	cmd.Flags().StringVar(&optTimeout, "timeout", "60", "Specify timeout for requests [Unit: s]")
+	cmd.Flags().StringVar(&optAccessKey, "access-key", "", "Specify the access key of the user signing the requests to the master")
+	cmd.Flags().StringVar(&optSecretKey, "secret-key", "", "Specify the secret key of the user signing the requests to the master")
 	return cmd
 }
 
@@ -126,9 +132,12 @@ func printConfigInfo(config *Config) {
 	stdout("Config info:\n")
 	stdout("  Master  Address    : %v\n", config.MasterAddr)
 	stdout("  Request Timeout [s]: %v\n", config.Timeout)
+	if config.AccessKey != "" {
+		stdout("  Access Key         : %v\n", config.AccessKey)
+	}
 }
 
-func setConfig(masterHosts string, timeout uint16) (err error) {
+func setConfig(masterHosts string, timeout uint16, accessKey, secretKey string) (err error) {
 	var config *Config
 	if config, err = LoadConfig(); err != nil {
 		return
@@ -140,6 +149,10 @@ func setConfig(masterHosts string, timeout uint16) (err error) {
 	if timeout != 0 {
 		config.Timeout = timeout
 	}
+	if accessKey != "" {
+		config.AccessKey = accessKey
+		config.SecretKey = secretKey
+	}
 	var configData []byte
 	if configData, err = json.Marshal(config); err != nil {
 		return
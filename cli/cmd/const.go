@@ -44,6 +44,15 @@ const (
 	CliOpGetDiscard              = "get-discard"
 	CliOpSetDiscard              = "set-discard"
 	CliOpForbidMpDecommission    = "forbid-mp-decommission"
+	CliOpDiff                    = "diff"
+	CliOpRollback                = "rollback"
+	CliOpRebalance               = "rebalance"
+	CliOpStart                   = "start"
+	CliOpStop                    = "stop"
+	CliOpSplit                   = "split"
+	CliOpMerge                   = "merge"
+	CliOpMaintenance             = "maintenance"
+	CliOpAudit                   = "audit"
 
 	// Shorthand format of operation name
 	CliOpDecommissionShortHand = "dec"
@@ -57,6 +66,7 @@ const (
 	CliResourceRaftNode      = "raftnode"
 	CliResourceDisk          = "disk"
 	CliResourceConfig        = "config"
+	CliResourceSnapshot      = "snapshot"
 
 	// Flags
 	CliFlagName                = "name"
@@ -117,9 +127,19 @@ const (
 	CliFlagMaxFiles            = "maxFiles"
 	CliFlagMaxBytes            = "maxBytes"
 	CliFlagMaxConcurrencyInode = "maxConcurrencyInode"
+	CliFlagSoftBytes           = "softBytes"
+	CliFlagHardBytes           = "hardBytes"
+	CliFlagSoftFiles           = "softFiles"
+	CliFlagHardFiles           = "hardFiles"
+	CliFlagGracePeriod         = "gracePeriod"
+	CliFlagQuotaType           = "type"
+	CliFlagNumeric             = "numeric"
 	CliFlagForceInode          = "forceInode"
 	CliFlagEnableQuota         = "enableQuota"
 	CliFlagDeleteLockTime      = "delete-lock-time"
+	CliFlagTrashRetention      = "trash-retention"
+	CliFlagMediaType           = "media-type"
+	CliFlagMediaFallback       = "media-fallback"
 	CliFlagClientIDKey         = "clientIDKey"
 
 	// CliFlagSetDataPartitionCount	= "count" use dp-count instead
@@ -17,6 +17,7 @@ package cmd
 import (
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/cubefs/cubefs/sdk/master"
 	"github.com/spf13/cobra"
@@ -38,6 +39,7 @@ func newDataNodeCmd(client *master.MasterClient) *cobra.Command {
 		newDataNodeInfoCmd(client),
 		newDataNodeDecommissionCmd(client),
 		newDataNodeMigrateCmd(client),
+		newDataNodeMaintenanceCmd(client),
 	)
 	return cmd
 }
@@ -46,6 +48,7 @@ const (
 	cmdDataNodeListShort             = "List information of data nodes"
 	cmdDataNodeInfoShort             = "Show information of a data node"
 	cmdDataNodeDecommissionInfoShort = "decommission partitions in a data node to others"
+	cmdDataNodeMaintenanceShort      = "Put a data node in maintenance for a planned restart, a zero TTL ends it"
 )
 
 func newDataNodeListCmd(client *master.MasterClient) *cobra.Command {
@@ -171,3 +174,30 @@ func newDataNodeMigrateCmd(client *master.MasterClient) *cobra.Command {
 	cmd.Flags().StringVar(&clientIDKey, CliFlagClientIDKey, client.ClientIDKey(), CliUsageClientIDKey)
 	return cmd
 }
+
+func newDataNodeMaintenanceCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   CliOpMaintenance + " [{HOST}:{PORT}] [TTL]",
+		Short: cmdDataNodeMaintenanceShort,
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ttl, err := time.ParseDuration(args[1])
+			if err != nil {
+				return err
+			}
+			view, err := client.NodeAPI().SetDataNodeMaintenance(args[0], ttl)
+			if err != nil {
+				return err
+			}
+			stdoutln(formatNodeMaintenanceView("Data", view))
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validDataNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
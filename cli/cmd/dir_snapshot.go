@@ -0,0 +1,434 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/master"
+	"github.com/cubefs/cubefs/sdk/meta"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdDirSnapshotUse           = "snapshot [COMMAND]"
+	cmdDirSnapshotShort         = "Manage named snapshots of directories"
+	cmdDirSnapshotCreateUse     = "create [VOLUME] [PATH] [NAME]"
+	cmdDirSnapshotCreateShort   = "Create a snapshot of a directory"
+	cmdDirSnapshotDeleteUse     = "delete [VOLUME] [PATH] [NAME]"
+	cmdDirSnapshotDeleteShort   = "Delete a snapshot of a directory"
+	cmdDirSnapshotListUse       = "list [VOLUME] [PATH]"
+	cmdDirSnapshotListShort     = "List the snapshots of a volume or a directory"
+	cmdDirSnapshotDiffUse       = "diff [MOUNTED DIR] [NAME]"
+	cmdDirSnapshotDiffShort     = "Show the changes of a mounted directory since a snapshot"
+	cmdDirSnapshotRollbackUse   = "rollback [MOUNTED DIR] [NAME]"
+	cmdDirSnapshotRollbackShort = "Roll back a mounted directory to a snapshot"
+
+	// the virtual directory of the fuse client to browse the snapshots
+	dirSnapshotBrowseName = ".snapshot"
+)
+
+func newDirSnapshotCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdDirSnapshotUse,
+		Short: cmdDirSnapshotShort,
+		Args:  cobra.MinimumNArgs(0),
+	}
+	cmd.AddCommand(
+		newDirSnapshotCreateCmd(client),
+		newDirSnapshotDeleteCmd(client),
+		newDirSnapshotListCmd(client),
+		newDirSnapshotDiffCmd(),
+		newDirSnapshotRollbackCmd(),
+	)
+	return cmd
+}
+
+func newDirSnapshotCreateCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdDirSnapshotCreateUse,
+		Short: cmdDirSnapshotCreateShort,
+		Args:  cobra.MinimumNArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				volName  = args[0]
+				fullPath = path.Clean(args[1])
+				name     = args[2]
+				info     *proto.DirSnapshotInfo
+				err      error
+			)
+			defer func() {
+				errout(err)
+			}()
+			if !strings.HasPrefix(fullPath, "/") {
+				err = fmt.Errorf("path %v does not start with /", fullPath)
+				return
+			}
+			metaWrapper, err := meta.NewMetaWrapper(&meta.MetaConfig{
+				Volume:  volName,
+				Masters: client.Nodes(),
+			})
+			if err != nil {
+				return
+			}
+			defer metaWrapper.Close()
+			ino, err := metaWrapper.LookupPath(fullPath)
+			if err != nil {
+				err = fmt.Errorf("get inode by path %v failed: %v", fullPath, err)
+				return
+			}
+			inodeInfo, err := metaWrapper.InodeGet_ll(ino)
+			if err != nil {
+				return
+			}
+			if !proto.IsDir(inodeInfo.Mode) {
+				err = fmt.Errorf("path %v is not a directory", fullPath)
+				return
+			}
+			if info, err = client.AdminAPI().CreateDirSnapshot(volName, name, fullPath, ino); err != nil {
+				return
+			}
+			stdout("%v\n", dirSnapshotTableHeader)
+			stdout("%v\n", formatDirSnapshotTableRow(info))
+		},
+	}
+	return cmd
+}
+
+// findDirSnapshot resolves a snapshot by the path it was taken on, which works even if the directory is gone.
+func findDirSnapshot(client *master.MasterClient, volName, fullPath, name string) (info *proto.DirSnapshotInfo, err error) {
+	infos, err := client.AdminAPI().ListDirSnapshots(volName, 0)
+	if err != nil {
+		return
+	}
+	for _, info = range infos {
+		if info.Name == name && info.Path == path.Clean(fullPath) {
+			return
+		}
+	}
+	return nil, fmt.Errorf("snapshot %v of %v not found", name, fullPath)
+}
+
+func newDirSnapshotDeleteCmd(client *master.MasterClient) *cobra.Command {
+	var optYes bool
+	cmd := &cobra.Command{
+		Use:   cmdDirSnapshotDeleteUse,
+		Short: cmdDirSnapshotDeleteShort,
+		Args:  cobra.MinimumNArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				volName  = args[0]
+				fullPath = args[1]
+				name     = args[2]
+				info     *proto.DirSnapshotInfo
+				err      error
+			)
+			defer func() {
+				errout(err)
+			}()
+			if info, err = findDirSnapshot(client, volName, fullPath, name); err != nil {
+				return
+			}
+			if !optYes {
+				stdout("Delete snapshot %v of %v, the files only kept by it can not be restored any more.\n", name, fullPath)
+				stdout("\nConfirm (yes/no)[yes]:")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					stdout("Abort by user.\n")
+					return
+				}
+			}
+			if err = client.AdminAPI().DeleteDirSnapshot(volName, name, info.Inode); err != nil {
+				return
+			}
+			stdout("Delete snapshot %v of %v success.\n", name, fullPath)
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func newDirSnapshotListCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdDirSnapshotListUse,
+		Short: cmdDirSnapshotListShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				volName = args[0]
+				infos   []*proto.DirSnapshotInfo
+				err     error
+			)
+			defer func() {
+				errout(err)
+			}()
+			if infos, err = client.AdminAPI().ListDirSnapshots(volName, 0); err != nil {
+				return
+			}
+			stdout("%v\n", dirSnapshotTableHeader)
+			for _, info := range infos {
+				if len(args) > 1 && info.Path != path.Clean(args[1]) {
+					continue
+				}
+				stdout("%v\n", formatDirSnapshotTableRow(info))
+			}
+		},
+	}
+	return cmd
+}
+
+type snapshotChange struct {
+	op   byte // '+' added, '-' deleted, 'M' modified since the snapshot
+	path string
+}
+
+// walkTree returns the entries under root keyed by the relative path.
+func walkTree(root string) (entries map[string]os.FileInfo, err error) {
+	entries = make(map[string]os.FileInfo)
+	err = filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		entries[rel] = info
+		return nil
+	})
+	return
+}
+
+func snapshotEntryChanged(old, cur os.FileInfo) bool {
+	if old.Mode() != cur.Mode() {
+		return true
+	}
+	if old.IsDir() {
+		return false
+	}
+	return old.Size() != cur.Size() || !old.ModTime().Equal(cur.ModTime())
+}
+
+// diffSnapshotTree compares the live directory with the snapshot directory, sorted by path.
+func diffSnapshotTree(liveDir, snapDir string) (changes []snapshotChange, err error) {
+	live, err := walkTree(liveDir)
+	if err != nil {
+		return
+	}
+	snap, err := walkTree(snapDir)
+	if err != nil {
+		return
+	}
+	for p, old := range snap {
+		cur, ok := live[p]
+		if !ok {
+			changes = append(changes, snapshotChange{op: '-', path: p})
+		} else if snapshotEntryChanged(old, cur) {
+			changes = append(changes, snapshotChange{op: 'M', path: p})
+		}
+	}
+	for p := range live {
+		if _, ok := snap[p]; !ok {
+			changes = append(changes, snapshotChange{op: '+', path: p})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].path < changes[j].path
+	})
+	return
+}
+
+func snapshotDirOf(dir, name string) (snapDir string, err error) {
+	snapDir = filepath.Join(dir, dirSnapshotBrowseName, name)
+	if _, err = os.Stat(snapDir); err != nil {
+		return "", fmt.Errorf("snapshot %v of %v is not accessible: %v", name, dir, err)
+	}
+	return
+}
+
+func newDirSnapshotDiffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdDirSnapshotDiffUse,
+		Short: cmdDirSnapshotDiffShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				dir     = filepath.Clean(args[0])
+				snapDir string
+				changes []snapshotChange
+				err     error
+			)
+			defer func() {
+				errout(err)
+			}()
+			if snapDir, err = snapshotDirOf(dir, args[1]); err != nil {
+				return
+			}
+			if changes, err = diffSnapshotTree(dir, snapDir); err != nil {
+				return
+			}
+			for _, change := range changes {
+				stdout("%c %v\n", change.op, change.path)
+			}
+		},
+	}
+	return cmd
+}
+
+func restoreSnapshotEntry(src, dst string, info os.FileInfo) (err error) {
+	switch {
+	case info.IsDir():
+		if cur, e := os.Lstat(dst); e == nil && !cur.IsDir() {
+			if err = os.Remove(dst); err != nil {
+				return
+			}
+		}
+		if err = os.MkdirAll(dst, info.Mode().Perm()); err != nil {
+			return
+		}
+		return os.Chmod(dst, info.Mode().Perm())
+	case info.Mode()&os.ModeSymlink != 0:
+		var target string
+		if target, err = os.Readlink(src); err != nil {
+			return
+		}
+		if err = os.RemoveAll(dst); err != nil {
+			return
+		}
+		return os.Symlink(target, dst)
+	}
+
+	if cur, e := os.Lstat(dst); e == nil && cur.IsDir() {
+		if err = os.RemoveAll(dst); err != nil {
+			return
+		}
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return
+	}
+	if err = out.Close(); err != nil {
+		return
+	}
+	if err = os.Chmod(dst, info.Mode().Perm()); err != nil {
+		return
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// rollbackSnapshotTree restores the deleted and modified entries from the snapshot,
+// and removes the entries created after it.
+func rollbackSnapshotTree(liveDir, snapDir string, changes []snapshotChange, dryRun bool) (err error) {
+	var removed []string
+	for _, change := range changes {
+		dst := filepath.Join(liveDir, change.path)
+		if change.op == '+' {
+			removed = append(removed, dst)
+			continue
+		}
+		stdout("restore %v\n", change.path)
+		if dryRun {
+			continue
+		}
+		src := filepath.Join(snapDir, change.path)
+		var info os.FileInfo
+		if info, err = os.Lstat(src); err != nil {
+			return
+		}
+		if err = restoreSnapshotEntry(src, dst, info); err != nil {
+			return fmt.Errorf("restore %v failed: %v", change.path, err)
+		}
+	}
+	// the changes are sorted, so a parent is always removed before its children
+	for i, p := range removed {
+		if i > 0 && strings.HasPrefix(p, removed[i-1]+string(filepath.Separator)) {
+			removed[i] = removed[i-1]
+			continue
+		}
+		stdout("remove %v\n", p)
+		if dryRun {
+			continue
+		}
+		if err = os.RemoveAll(p); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func newDirSnapshotRollbackCmd() *cobra.Command {
+	var (
+		optYes    bool
+		optDryRun bool
+	)
+	cmd := &cobra.Command{
+		Use:   cmdDirSnapshotRollbackUse,
+		Short: cmdDirSnapshotRollbackShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				dir     = filepath.Clean(args[0])
+				name    = args[1]
+				snapDir string
+				changes []snapshotChange
+				err     error
+			)
+			defer func() {
+				errout(err)
+			}()
+			if snapDir, err = snapshotDirOf(dir, name); err != nil {
+				return
+			}
+			if changes, err = diffSnapshotTree(dir, snapDir); err != nil {
+				return
+			}
+			if len(changes) == 0 {
+				stdout("%v is the same as snapshot %v.\n", dir, name)
+				return
+			}
+			if !optYes && !optDryRun {
+				stdout("Roll back %v to snapshot %v, %v entries will be restored or removed.\n", dir, name, len(changes))
+				stdout("\nConfirm (yes/no)[yes]:")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					stdout("Abort by user.\n")
+					return
+				}
+			}
+			err = rollbackSnapshotTree(dir, snapDir, changes, optDryRun)
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	cmd.Flags().BoolVar(&optDryRun, "dry-run", false, "Only print the entries to restore or remove")
+	return cmd
+}
@@ -0,0 +1,66 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, p, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+	require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+}
+
+func TestDirSnapshotDiffAndRollback(t *testing.T) {
+	liveDir := t.TempDir()
+	snapDir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(snapDir, "keep"), "keep")
+	writeTestFile(t, filepath.Join(snapDir, "deleted/a"), "a")
+	writeTestFile(t, filepath.Join(snapDir, "modified"), "old")
+
+	writeTestFile(t, filepath.Join(liveDir, "keep"), "keep")
+	writeTestFile(t, filepath.Join(liveDir, "modified"), "new content")
+	writeTestFile(t, filepath.Join(liveDir, "added/b"), "b")
+	info, err := os.Stat(filepath.Join(snapDir, "keep"))
+	require.NoError(t, err)
+	require.NoError(t, os.Chtimes(filepath.Join(liveDir, "keep"), info.ModTime(), info.ModTime()))
+
+	changes, err := diffSnapshotTree(liveDir, snapDir)
+	require.NoError(t, err)
+	require.Equal(t, []snapshotChange{
+		{op: '+', path: "added"},
+		{op: '+', path: filepath.Join("added", "b")},
+		{op: '-', path: "deleted"},
+		{op: '-', path: filepath.Join("deleted", "a")},
+		{op: 'M', path: "modified"},
+	}, changes)
+
+	require.NoError(t, rollbackSnapshotTree(liveDir, snapDir, changes, true))
+	_, err = os.Stat(filepath.Join(liveDir, "added"))
+	require.NoError(t, err)
+
+	require.NoError(t, rollbackSnapshotTree(liveDir, snapDir, changes, false))
+	changes, err = diffSnapshotTree(liveDir, snapDir)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+	data, err := os.ReadFile(filepath.Join(liveDir, "modified"))
+	require.NoError(t, err)
+	require.Equal(t, "old", string(data))
+}
@@ -17,6 +17,7 @@ package cmd
 import (
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -143,6 +144,7 @@ func formatSimpleVolView(svv *proto.SimpleVolView) string {
 	sb.WriteString(fmt.Sprintf("  Capacity                        : %v GB\n", svv.Capacity))
 	sb.WriteString(fmt.Sprintf("  Create time                     : %v\n", svv.CreateTime))
 	sb.WriteString(fmt.Sprintf("  DeleteLockTime                  : %v\n", svv.DeleteLockTime))
+	sb.WriteString(fmt.Sprintf("  TrashRetention                  : %v min\n", svv.TrashRetention))
 	sb.WriteString(fmt.Sprintf("  Cross zone                      : %v\n", formatEnabledDisabled(svv.CrossZone)))
 	sb.WriteString(fmt.Sprintf("  DefaultPriority                 : %v\n", svv.DefaultPriority))
 	sb.WriteString(fmt.Sprintf("  Dentry count                    : %v\n", svv.DentryCount))
@@ -168,6 +170,15 @@ func formatSimpleVolView(svv *proto.SimpleVolView) string {
 	sb.WriteString(fmt.Sprintf("  Forbidden                       : %v\n", svv.Forbidden))
 	sb.WriteString(fmt.Sprintf("  EnableAuditLog                  : %v\n", svv.EnableAuditLog))
 	sb.WriteString(fmt.Sprintf("  Quota                           : %v\n", formatEnabledDisabled(svv.EnableQuota)))
+	sb.WriteString(fmt.Sprintf("  Media type                      : %v\n", formatMediaType(svv.MediaType)))
+	sb.WriteString(fmt.Sprintf("  Media fallback                  : %v\n", formatEnabledDisabled(svv.MediaFallback)))
+	sb.WriteString(fmt.Sprintf("  Meta store type                 : %v\n", svv.MetaStoreType))
+	if svv.CloneSrc != "" {
+		sb.WriteString(fmt.Sprintf("  Cloned from                     : %v@%v\n", svv.CloneSrc, svv.CloneVerSeq))
+	}
+	if svv.PromotePath != "" {
+		sb.WriteString(fmt.Sprintf("  Promoted from                   : %v:%v\n", svv.CloneSrc, svv.PromotePath))
+	}
 	if svv.Forbidden && svv.Status == 1 {
 		sb.WriteString(fmt.Sprintf("  DeleteDelayTime                 : %v\n", time.Until(svv.DeleteExecTime)))
 	}
@@ -237,6 +248,46 @@ func formatVerInfoTableRow(verInfo *proto.VolVersionInfo) string {
 		verInfo.Ver, time.UnixMicro(int64(verInfo.Ver)).Local().Format(time.RFC1123), verInfo.Status, "")
 }
 
+var (
+	dirSnapshotTablePattern = "%-20v    %-12v    %-20v    %-30v    %v"
+	dirSnapshotTableHeader  = fmt.Sprintf(dirSnapshotTablePattern, "NAME", "INODE", "VER", "CTIME", "PATH")
+)
+
+func formatDirSnapshotTableRow(info *proto.DirSnapshotInfo) string {
+	return fmt.Sprintf(dirSnapshotTablePattern,
+		info.Name, info.Inode, info.VerSeq, time.Unix(info.CreateTime, 0).Local().Format(time.RFC1123), info.Path)
+}
+
+var (
+	trashTablePattern = "%-12v    %-12v    %-8v    %-12v    %-30v    %v"
+	trashTableHeader  = fmt.Sprintf(trashTablePattern, "INODE", "PARENT", "UID", "SIZE", "DELETE TIME", "NAME")
+)
+
+func formatTrashTableRow(entry *proto.TrashEntry) string {
+	return fmt.Sprintf(trashTablePattern,
+		entry.Inode, entry.ParentIno, entry.Uid, entry.Size, time.Unix(entry.DeleteTime, 0).Local().Format(time.RFC1123), entry.Name)
+}
+
+func formatMediaType(mediaType string) string {
+	if mediaType == "" {
+		return "any"
+	}
+	return mediaType
+}
+
+var (
+	mediaUsageTablePattern = "%-8v    %-10v    %-10v    %-10v"
+	mediaUsageTableHeader  = fmt.Sprintf(mediaUsageTablePattern, "MEDIA", "USED", "PARTITIONS", "WRITABLE")
+)
+
+func formatMediaUsageTableRow(usage *proto.MediaUsage) string {
+	mediaType := usage.MediaType
+	if mediaType == "" {
+		mediaType = "unknown"
+	}
+	return fmt.Sprintf(mediaUsageTablePattern, mediaType, formatSize(usage.UsedSize), usage.PartitionCnt, usage.RwPartitionCnt)
+}
+
 var (
 	dataPartitionTablePattern = "%-8v    %-8v    %-10v    %-10v     %-18v    %-18v"
 	dataPartitionTableHeader  = fmt.Sprintf(dataPartitionTablePattern,
@@ -632,6 +683,66 @@ func fixUnit(curSize float64, curUnitIndex int) (newSize float64, newUnitIndex i
 	return curSize, curUnitIndex
 }
 
+func formatVolReplicationView(view *proto.VolReplicationView) string {
+	if view.Config == nil {
+		return "Replication is not set.\n"
+	}
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("  Target masters      : %v\n", view.Config.TargetMasters))
+	sb.WriteString(fmt.Sprintf("  Target volume       : %v\n", view.Config.TargetVolume))
+	sb.WriteString(fmt.Sprintf("  Status              : %v\n", formatEnabledDisabled(view.Config.Enable)))
+	sb.WriteString(fmt.Sprintf("  Create time         : %v\n", formatTime(view.Config.CreateTime)))
+	if view.Task == nil {
+		sb.WriteString("  Task                : not assigned\n")
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("  LcNode              : %v\n", view.Task.LcNode))
+	if view.Task.UpdateTime != nil {
+		sb.WriteString(fmt.Sprintf("  Update time         : %v\n", formatTimeToString(*view.Task.UpdateTime)))
+	}
+	sb.WriteString(fmt.Sprintf("  Phase               : %v\n", view.Task.Phase))
+	sb.WriteString(fmt.Sprintf("  Lag                 : %v s\n", view.Task.LagSeconds))
+	sb.WriteString(fmt.Sprintf("  Pending changes     : %v\n", view.Task.PendingChanges))
+	sb.WriteString(fmt.Sprintf("  Synced entries      : %v\n", view.Task.SyncedEntries))
+	sb.WriteString(fmt.Sprintf("  Synced bytes        : %v\n", formatSize(uint64(view.Task.SyncedBytes))))
+	sb.WriteString(fmt.Sprintf("  Errors              : %v\n", view.Task.ErrorSkippedNum))
+	if view.Task.Result != "" {
+		sb.WriteString(fmt.Sprintf("  Result              : %v\n", view.Task.Result))
+	}
+	return sb.String()
+}
+
+func formatRebalanceView(view *proto.RebalanceView) string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("  Status              : %v\n", formatEnabledDisabled(view.Config.Enable)))
+	if !view.Config.StartTime.IsZero() {
+		sb.WriteString(fmt.Sprintf("  Start time          : %v\n", formatTimeToString(view.Config.StartTime)))
+	}
+	sb.WriteString(fmt.Sprintf("  Zones               : %v\n", view.Config.Zones))
+	sb.WriteString(fmt.Sprintf("  Nodesets            : %v\n", view.Config.NodeSets))
+	sb.WriteString(fmt.Sprintf("  Level               : %v\n", view.Config.Level))
+	sb.WriteString(fmt.Sprintf("  Threshold           : %v\n", view.Config.Threshold))
+	sb.WriteString(fmt.Sprintf("  Concurrency         : %v\n", view.Config.Concurrency))
+	sb.WriteString(fmt.Sprintf("  Bandwidth           : %v MB/s\n", view.Config.Bandwidth))
+	sb.WriteString(fmt.Sprintf("  Balanced            : %v\n", view.Balanced))
+	sb.WriteString(fmt.Sprintf("  Migrated partitions : %v\n", view.MigratedCount))
+	sb.WriteString(fmt.Sprintf("  Migrated bytes      : %v\n", formatSize(view.MigratedBytes)))
+	sb.WriteString(fmt.Sprintf("  Failed              : %v\n", view.FailedCount))
+	if view.LastError != "" {
+		sb.WriteString(fmt.Sprintf("  Last error          : %v\n", view.LastError))
+	}
+	sb.WriteString(fmt.Sprintf("  Migrating           : %v\n", len(view.Migrations)))
+	if len(view.Migrations) == 0 {
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("\n  %-12v %-24v %-24v %-12v %-10v %v\n", "ID", "SOURCE", "TARGET", "SIZE", "RECOVERING", "START TIME"))
+	for _, m := range view.Migrations {
+		sb.WriteString(fmt.Sprintf("  %-12v %-24v %-24v %-12v %-10v %v\n", m.PartitionID, m.SrcAddr, m.DstAddr,
+			formatSize(m.Size), m.Recovering, formatTimeToString(m.StartTime)))
+	}
+	return sb.String()
+}
+
 func formatSize(size uint64) string {
 	fixedSize, fixedUnitIndex := fixUnit(float64(size), 0)
 	return fmt.Sprintf("%.2f %v", fixedSize, units[fixedUnitIndex])
@@ -645,6 +756,44 @@ func formatTimeToString(t time.Time) string {
 	return t.Format("2006-01-02 15:04:05")
 }
 
+var (
+	adminAuditTablePattern = "%-19v    %-36v    %-15v    %-12v    %-16v    %-6v    %v"
+	adminAuditTableHeader  = fmt.Sprintf(adminAuditTablePattern, "TIME", "OP", "CLIENT", "USER", "VOLUME", "CODE", "PARAMS")
+)
+
+func formatAdminAuditRecord(record *proto.AdminAuditRecord) string {
+	keys := make([]string, 0, len(record.Params))
+	for key := range record.Params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	params := make([]string, 0, len(keys))
+	for _, key := range keys {
+		params = append(params, key+"="+record.Params[key])
+	}
+	row := fmt.Sprintf(adminAuditTablePattern, formatTime(record.Time), record.Op, record.ClientIP, record.User,
+		record.Vol, record.Code, strings.Join(params, ","))
+	if record.Code != proto.ErrCodeSuccess {
+		row += fmt.Sprintf("\n    -> %v", record.Msg)
+	}
+	return row
+}
+
+func formatMaintenance(expire int64) string {
+	if expire <= time.Now().Unix() {
+		return "No"
+	}
+	return "until " + formatTime(expire)
+}
+
+func formatNodeMaintenanceView(nodeType string, view *proto.NodeMaintenanceView) string {
+	if view.MaintenanceExpire <= time.Now().Unix() {
+		return fmt.Sprintf("%v node %v is out of maintenance", nodeType, view.Addr)
+	}
+	return fmt.Sprintf("%v node %v is in maintenance until %v, %v partitions are degraded by design: %v",
+		nodeType, view.Addr, formatTime(view.MaintenanceExpire), len(view.PartitionIDs), view.PartitionIDs)
+}
+
 var dataReplicaTableRowPattern = "%-65v    %-12v    %-12v    %-12v    %-12v    %-12v    %-12v    %-12v    %-18v    %-10v"
 
 func formatDataReplicaTableHeader() string {
@@ -754,6 +903,7 @@ func formatDataNodeDetail(dn *proto.DataNodeInfo, rowTable bool) string {
 	sb.WriteString(fmt.Sprintf("  Total               : %v\n", formatSize(dn.Total)))
 	sb.WriteString(fmt.Sprintf("  Zone                : %v\n", dn.ZoneName))
 	sb.WriteString(fmt.Sprintf("  IsActive            : %v\n", formatNodeStatus(dn.IsActive)))
+	sb.WriteString(fmt.Sprintf("  Maintenance         : %v\n", formatMaintenance(dn.MaintenanceExpire)))
 	sb.WriteString(fmt.Sprintf("  Report time         : %v\n", formatTimeToString(dn.ReportTime)))
 	sb.WriteString(fmt.Sprintf("  Partition count     : %v\n", dn.DataPartitionCount))
 	sb.WriteString(fmt.Sprintf("  Bad disks           : %v\n", dn.BadDisks))
@@ -786,6 +936,7 @@ func formatMetaNodeDetail(mn *proto.MetaNodeInfo, rowTable bool) string {
 	sb.WriteString(fmt.Sprintf("  Total               : %v\n", formatSize(mn.Total)))
 	sb.WriteString(fmt.Sprintf("  Zone                : %v\n", mn.ZoneName))
 	sb.WriteString(fmt.Sprintf("  IsActive            : %v\n", formatNodeStatus(mn.IsActive)))
+	sb.WriteString(fmt.Sprintf("  Maintenance         : %v\n", formatMaintenance(mn.MaintenanceExpire)))
 	sb.WriteString(fmt.Sprintf("  Report time         : %v\n", formatTimeToString(mn.ReportTime)))
 	sb.WriteString(fmt.Sprintf("  Partition count     : %v\n", mn.MetaPartitionCount))
 	sb.WriteString(fmt.Sprintf("  Persist partitions  : %v\n", mn.PersistenceMetaPartitions))
@@ -883,6 +1034,48 @@ func formatQuotaInfo(info *proto.QuotaInfo) string {
 	return ret
 }
 
+var userQuotaTableRowPattern = "%-16v %-2v    %-12v %-12v %-12v %-8v    %-10v %-10v %-10v %-8v"
+
+func formatUserQuotaTableHeader(quotaType proto.UserQuotaType) string {
+	return fmt.Sprintf(userQuotaTableRowPattern, strings.ToUpper(quotaType.String()), "",
+		"USEDBYTES", "SOFT", "HARD", "GRACE", "USEDFILES", "SOFT", "HARD", "GRACE")
+}
+
+// formatUserQuotaInfo prints a row like repquota, the flags tell whether the bytes and the files are over the soft limits.
+func formatUserQuotaInfo(info *proto.UserQuotaInfo, name string, now int64) string {
+	flag := func(over bool) string {
+		if over {
+			return "+"
+		}
+		return "-"
+	}
+	limit := func(value uint64, size bool) string {
+		if value == 0 {
+			return "0"
+		}
+		if size {
+			return formatSize(value)
+		}
+		return strconv.FormatUint(value, 10)
+	}
+	grace := func(expire int64) string {
+		if expire == 0 {
+			return ""
+		}
+		if expire <= now {
+			return "none"
+		}
+		left := time.Duration(expire-now) * time.Second
+		if left >= 24*time.Hour {
+			return fmt.Sprintf("%vdays", int64(left/(24*time.Hour)))
+		}
+		return fmt.Sprintf("%02d:%02d", int64(left/time.Hour), int64(left%time.Hour/time.Minute))
+	}
+	return fmt.Sprintf(userQuotaTableRowPattern, name, flag(info.IsOverSoftBytes())+flag(info.IsOverSoftFiles()),
+		formatSize(uint64(info.UsedInfo.UsedBytes)), limit(info.SoftBytes, true), limit(info.HardBytes, true), grace(info.BytesGraceExpire),
+		info.UsedInfo.UsedFiles, limit(info.SoftFiles, false), limit(info.HardFiles, false), grace(info.FilesGraceExpire))
+}
+
 func formatBadDisks(disks []proto.DiskInfo) string {
 	if len(disks) == 0 {
 		return ""
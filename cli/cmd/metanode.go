@@ -17,6 +17,7 @@ package cmd
 import (
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/sdk/master"
@@ -39,6 +40,7 @@ func newMetaNodeCmd(client *master.MasterClient) *cobra.Command {
 		newMetaNodeInfoCmd(client),
 		newMetaNodeDecommissionCmd(client),
 		newMetaNodeMigrateCmd(client),
+		newMetaNodeMaintenanceCmd(client),
 	)
 	return cmd
 }
@@ -48,6 +50,7 @@ const (
 	cmdMetaNodeInfoShort             = "Show information of meta nodes"
 	cmdMetaNodeDecommissionInfoShort = "Decommission partitions in a meta node to other nodes"
 	cmdMetaNodeMigrateInfoShort      = "Migrate partitions from a meta node to the other node"
+	cmdMetaNodeMaintenanceShort      = "Put a meta node in maintenance for a planned restart, a zero TTL ends it"
 )
 
 func newMetaNodeListCmd(client *master.MasterClient) *cobra.Command {
@@ -192,3 +195,30 @@ func newMetaNodeMigrateCmd(client *master.MasterClient) *cobra.Command {
 	cmd.Flags().StringVar(&clientIDKey, CliFlagClientIDKey, client.ClientIDKey(), CliUsageClientIDKey)
 	return cmd
 }
+
+func newMetaNodeMaintenanceCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   CliOpMaintenance + " [{HOST}:{PORT}] [TTL]",
+		Short: cmdMetaNodeMaintenanceShort,
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ttl, err := time.ParseDuration(args[1])
+			if err != nil {
+				return err
+			}
+			view, err := client.NodeAPI().SetMetaNodeMaintenance(args[0], ttl)
+			if err != nil {
+				return err
+			}
+			stdoutln(formatNodeMaintenanceView("Meta", view))
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validMetaNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
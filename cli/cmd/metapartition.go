@@ -40,6 +40,8 @@ func newMetaPartitionCmd(client *master.MasterClient) *cobra.Command {
 		newMetaPartitionDecommissionCmd(client),
 		newMetaPartitionReplicateCmd(client),
 		newMetaPartitionDeleteReplicaCmd(client),
+		newMetaPartitionSplitCmd(client),
+		newMetaPartitionMergeCmd(client),
 	)
 	return cmd
 }
@@ -50,6 +52,8 @@ const (
 	cmdMetaPartitionDecommissionShort  = "Decommission a replication of the meta partition to a new address"
 	cmdMetaPartitionReplicateShort     = "Add a replication of the meta partition on a new address"
 	cmdMetaPartitionDeleteReplicaShort = "Delete a replication of the meta partition on a fixed address"
+	cmdMetaPartitionSplitShort         = "Split the meta partition at an inode, or its median inode by default"
+	cmdMetaPartitionMergeShort         = "Merge the adjacent meta partition of the higher inode range into the meta partition"
 )
 
 func newMetaPartitionGetCmd(client *master.MasterClient) *cobra.Command {
@@ -352,3 +356,59 @@ func newMetaPartitionDeleteReplicaCmd(client *master.MasterClient) *cobra.Comman
 	cmd.Flags().StringVar(&clientIDKey, CliFlagClientIDKey, client.ClientIDKey(), CliUsageClientIDKey)
 	return cmd
 }
+
+func newMetaPartitionSplitCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   CliOpSplit + " [META PARTITION ID] [INODE]",
+		Short: cmdMetaPartitionSplitShort,
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+				inode       uint64
+			)
+			defer func() {
+				errout(err)
+			}()
+			if partitionID, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+				return
+			}
+			if len(args) > 1 {
+				if inode, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+					return
+				}
+			}
+			if err = client.AdminAPI().SplitMetaPartition(partitionID, inode); err != nil {
+				return
+			}
+			stdout("Split meta partition successfully\n")
+		},
+	}
+	return cmd
+}
+
+func newMetaPartitionMergeCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   CliOpMerge + " [META PARTITION ID]",
+		Short: cmdMetaPartitionMergeShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+			)
+			defer func() {
+				errout(err)
+			}()
+			if partitionID, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+				return
+			}
+			if err = client.AdminAPI().MergeMetaPartition(partitionID); err != nil {
+				return
+			}
+			stdout("Merge meta partition successfully\n")
+		},
+	}
+	return cmd
+}
@@ -17,9 +17,11 @@ package cmd
 import (
 	"fmt"
 	"math"
+	"os/user"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/sdk/master"
@@ -46,6 +48,12 @@ const (
 	cmdQuotaApplyShort    = "apply quota"
 	cmdQuotaRevokeUse     = "revoke [volname] [quotaId]"
 	cmdQuotaRevokeShort   = "revoke quota"
+	cmdQuotaSetUserUse    = "setuser [volname] [user|group] [id]"
+	cmdQuotaSetUserShort  = "set the quota of a uid or a gid"
+	cmdQuotaDelUserUse    = "deleteuser [volname] [user|group] [id]"
+	cmdQuotaDelUserShort  = "delete the quota of a uid or a gid"
+	cmdQuotaReportUse     = "report [volname]"
+	cmdQuotaReportShort   = "report the usage and the limits of the uids and gids, like repquota"
 )
 
 const (
@@ -70,6 +78,9 @@ func newQuotaCmd(client *master.MasterClient) *cobra.Command {
 		newQuotaListAllCmd(client),
 		newQuotaApplyCmd(client),
 		newQuotaRevokeCmd(client),
+		newQuotaSetUserCmd(client),
+		newQuotaDeleteUserCmd(client),
+		newQuotaReportCmd(client),
 	)
 	return cmd
 }
@@ -416,6 +427,133 @@ func newQuotaRevokeCmd(client *master.MasterClient) *cobra.Command {
 	return cmd
 }
 
+func parseUserQuotaArgs(args []string) (quotaType proto.UserQuotaType, id uint32, err error) {
+	if quotaType, err = proto.ParseUserQuotaType(args[1]); err != nil {
+		return
+	}
+	var value uint64
+	if value, err = strconv.ParseUint(args[2], 10, 32); err != nil {
+		err = fmt.Errorf("id %v is illegal", args[2])
+		return
+	}
+	id = uint32(value)
+	return
+}
+
+func newQuotaSetUserCmd(client *master.MasterClient) *cobra.Command {
+	quota := &proto.UserQuotaInfo{}
+	var gracePeriod time.Duration
+
+	cmd := &cobra.Command{
+		Use:   cmdQuotaSetUserUse,
+		Short: cmdQuotaSetUserShort,
+		Args:  cobra.MinimumNArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			volName := args[0]
+			if quota.Type, quota.Id, err = parseUserQuotaArgs(args); err != nil {
+				stdout("set user quota failed, %v\n", err)
+				return
+			}
+			quota.GracePeriod = int64(gracePeriod / time.Second)
+			if err = client.AdminAPI().SetUserQuota(volName, quota); err != nil {
+				stdout("volName %v %v %v quota set failed(%v)\n", volName, quota.Type, quota.Id, err)
+				return
+			}
+			stdout("setUserQuota: volName %v %v %v softBytes %v hardBytes %v softFiles %v hardFiles %v gracePeriod %v success.\n",
+				volName, quota.Type, quota.Id, quota.SoftBytes, quota.HardBytes, quota.SoftFiles, quota.HardFiles, gracePeriod)
+		},
+	}
+	cmd.Flags().Uint64Var(&quota.SoftBytes, CliFlagSoftBytes, 0, "Specify the soft limit of bytes, 0 is unlimited")
+	cmd.Flags().Uint64Var(&quota.HardBytes, CliFlagHardBytes, 0, "Specify the hard limit of bytes, 0 is unlimited")
+	cmd.Flags().Uint64Var(&quota.SoftFiles, CliFlagSoftFiles, 0, "Specify the soft limit of files, 0 is unlimited")
+	cmd.Flags().Uint64Var(&quota.HardFiles, CliFlagHardFiles, 0, "Specify the hard limit of files, 0 is unlimited")
+	cmd.Flags().DurationVar(&gracePeriod, CliFlagGracePeriod, 7*24*time.Hour, "Specify how long the soft limits may be exceeded")
+	return cmd
+}
+
+func newQuotaDeleteUserCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdQuotaDelUserUse,
+		Short: cmdQuotaDelUserShort,
+		Args:  cobra.MinimumNArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			volName := args[0]
+			quotaType, id, err := parseUserQuotaArgs(args)
+			if err != nil {
+				stdout("delete user quota failed, %v\n", err)
+				return
+			}
+			if err = client.AdminAPI().DeleteUserQuota(volName, quotaType, id); err != nil {
+				stdout("volName %v %v %v quota delete failed(%v)\n", volName, quotaType, id, err)
+				return
+			}
+			stdout("deleteUserQuota: volName %v %v %v success.\n", volName, quotaType, id)
+		},
+	}
+	return cmd
+}
+
+func newQuotaReportCmd(client *master.MasterClient) *cobra.Command {
+	var optType string
+	var optNumeric bool
+
+	cmd := &cobra.Command{
+		Use:   cmdQuotaReportUse,
+		Short: cmdQuotaReportShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			volName := args[0]
+			quotas, err := client.AdminAPI().ReportUserQuota(volName, optType)
+			if err != nil {
+				stdout("volName %v quota report failed(%v)\n", volName, err)
+				return
+			}
+			now := time.Now().Unix()
+			for _, quotaType := range []proto.UserQuotaType{proto.UserQuotaTypeUser, proto.UserQuotaTypeGroup} {
+				rows := make([]*proto.UserQuotaInfo, 0, len(quotas))
+				for _, quota := range quotas {
+					if quota.Type == quotaType {
+						rows = append(rows, quota)
+					}
+				}
+				if len(rows) == 0 {
+					continue
+				}
+				sort.Slice(rows, func(i, j int) bool {
+					return rows[i].Id < rows[j].Id
+				})
+				stdout("*** Report for %v quotas on volume %v\n", quotaType, volName)
+				stdout("%v\n", formatUserQuotaTableHeader(quotaType))
+				for _, quota := range rows {
+					stdout("%v\n", formatUserQuotaInfo(quota, userQuotaName(quota, optNumeric), now))
+				}
+				stdout("\n")
+			}
+		},
+	}
+	cmd.Flags().StringVar(&optType, CliFlagQuotaType, "", "Specify the quota type to report, user or group")
+	cmd.Flags().BoolVarP(&optNumeric, CliFlagNumeric, "n", false, "Do not translate the uids and gids to names")
+	return cmd
+}
+
+func userQuotaName(quota *proto.UserQuotaInfo, numeric bool) string {
+	id := strconv.FormatUint(uint64(quota.Id), 10)
+	if !numeric {
+		switch quota.Type {
+		case proto.UserQuotaTypeUser:
+			if u, err := user.LookupId(id); err == nil {
+				return u.Username
+			}
+		case proto.UserQuotaTypeGroup:
+			if g, err := user.LookupGroupId(id); err == nil {
+				return g.Name
+			}
+		}
+	}
+	return "#" + id
+}
+
 func checkNestedDirectories(paths []string) error {
 	for i, path := range paths {
 		for j := i + 1; j < len(paths); j++ {
@@ -0,0 +1,196 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/master"
+	"github.com/cubefs/cubefs/sdk/meta"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdTrashUse          = "trash [COMMAND]"
+	cmdTrashShort        = "Manage the deleted files kept in the trash of a volume"
+	cmdTrashListUse      = "list [VOLUME]"
+	cmdTrashListShort    = "List the files in the trash"
+	cmdTrashRestoreUse   = "restore [VOLUME] [INODE] [PARENT INODE] [NAME]"
+	cmdTrashRestoreShort = "Restore a file in the trash to the directory it is deleted from"
+	cmdTrashPurgeUse     = "purge [VOLUME] [INODE] [PARENT INODE] [NAME]"
+	cmdTrashPurgeShort   = "Delete a file in the trash before it expires"
+
+	CliFlagTrashUid = "uid"
+	CliFlagTrashAll = "all"
+)
+
+func newTrashCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdTrashUse,
+		Short: cmdTrashShort,
+		Args:  cobra.MinimumNArgs(0),
+	}
+	cmd.AddCommand(
+		newTrashListCmd(client),
+		newTrashRestoreCmd(client),
+		newTrashPurgeCmd(client),
+	)
+	return cmd
+}
+
+func newTrashMetaWrapper(client *master.MasterClient, volName string) (*meta.MetaWrapper, error) {
+	return meta.NewMetaWrapper(&meta.MetaConfig{
+		Volume:  volName,
+		Masters: client.Nodes(),
+	})
+}
+
+// parseTrashEntryArgs parses the inode, the parent inode and the name of a file in the trash.
+func parseTrashEntryArgs(args []string) (inode, parentID uint64, name string, err error) {
+	if inode, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+		err = fmt.Errorf("invalid inode %v: %v", args[1], err)
+		return
+	}
+	if parentID, err = strconv.ParseUint(args[2], 10, 64); err != nil {
+		err = fmt.Errorf("invalid parent inode %v: %v", args[2], err)
+		return
+	}
+	return inode, parentID, args[3], nil
+}
+
+func newTrashListCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optUid uint32
+		optAll bool
+	)
+	cmd := &cobra.Command{
+		Use:   cmdTrashListUse,
+		Short: cmdTrashListShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				volName = args[0]
+				entries []*proto.TrashEntry
+				err     error
+			)
+			defer func() {
+				errout(err)
+			}()
+			metaWrapper, err := newTrashMetaWrapper(client, volName)
+			if err != nil {
+				return
+			}
+			defer metaWrapper.Close()
+			if entries, err = metaWrapper.ListTrash(optUid, optAll); err != nil {
+				return
+			}
+			stdout("%v\n", trashTableHeader)
+			for _, entry := range entries {
+				stdout("%v\n", formatTrashTableRow(entry))
+			}
+		},
+	}
+	cmd.Flags().Uint32Var(&optUid, CliFlagTrashUid, 0, "List the trash of the user")
+	cmd.Flags().BoolVar(&optAll, CliFlagTrashAll, false, "List the trash of all the users")
+	return cmd
+}
+
+func newTrashRestoreCmd(client *master.MasterClient) *cobra.Command {
+	var optUid uint32
+	cmd := &cobra.Command{
+		Use:   cmdTrashRestoreUse,
+		Short: cmdTrashRestoreShort,
+		Args:  cobra.MinimumNArgs(4),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				volName  = args[0]
+				inode    uint64
+				parentID uint64
+				name     string
+				err      error
+			)
+			defer func() {
+				errout(err)
+			}()
+			if inode, parentID, name, err = parseTrashEntryArgs(args); err != nil {
+				return
+			}
+			metaWrapper, err := newTrashMetaWrapper(client, volName)
+			if err != nil {
+				return
+			}
+			defer metaWrapper.Close()
+			if err = metaWrapper.RestoreTrash(optUid, inode, parentID, name); err != nil {
+				err = fmt.Errorf("restore inode %v to %v/%v failed: %v", inode, parentID, name, err)
+				return
+			}
+			stdout("Restore inode %v to %v/%v success.\n", inode, parentID, name)
+		},
+	}
+	cmd.Flags().Uint32Var(&optUid, CliFlagTrashUid, 0, "Restore the file as the user, 0 for any user")
+	return cmd
+}
+
+func newTrashPurgeCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optUid uint32
+		optYes bool
+	)
+	cmd := &cobra.Command{
+		Use:   cmdTrashPurgeUse,
+		Short: cmdTrashPurgeShort,
+		Args:  cobra.MinimumNArgs(4),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				volName  = args[0]
+				inode    uint64
+				parentID uint64
+				name     string
+				err      error
+			)
+			defer func() {
+				errout(err)
+			}()
+			if inode, parentID, name, err = parseTrashEntryArgs(args); err != nil {
+				return
+			}
+			if !optYes {
+				stdout("Purge inode %v of %v/%v, it can not be restored any more.\n", inode, parentID, name)
+				stdout("\nConfirm (yes/no)[yes]:")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					stdout("Abort by user.\n")
+					return
+				}
+			}
+			metaWrapper, err := newTrashMetaWrapper(client, volName)
+			if err != nil {
+				return
+			}
+			defer metaWrapper.Close()
+			if err = metaWrapper.PurgeTrash(optUid, inode, parentID, name); err != nil {
+				err = fmt.Errorf("purge inode %v of %v/%v failed: %v", inode, parentID, name, err)
+				return
+			}
+			stdout("Purge inode %v of %v/%v success.\n", inode, parentID, name)
+		},
+	}
+	cmd.Flags().Uint32Var(&optUid, CliFlagTrashUid, 0, "Purge the file as the user, 0 for any user")
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
@@ -55,6 +55,7 @@ func newUserCreateCmd(client *master.MasterClient) *cobra.Command {
 	var optAccessKey string
 	var optSecretKey string
 	var optUserType string
+	var optRole string
 	var clientIDKey string
 	var optYes bool
 	cmd := &cobra.Command{
@@ -75,6 +76,11 @@ func newUserCreateCmd(client *master.MasterClient) *cobra.Command {
 				err = fmt.Errorf("Invalid user type. ")
 				return
 			}
+			role := proto.Role(optRole)
+			if !role.Valid() {
+				err = fmt.Errorf("Invalid role. ")
+				return
+			}
 
 			// ask user for confirm
 			if !optYes {
@@ -98,6 +104,7 @@ func newUserCreateCmd(client *master.MasterClient) *cobra.Command {
 				stdout("  Access Key: %v\n", displayAccessKey)
 				stdout("  Secret Key: %v\n", displaySecretKey)
 				stdout("  Type      : %v\n", displayUserType)
+				stdout("  Role      : %v\n", formatUserRole(role))
 				stdout("\nConfirm (yes/no)[yes]: ")
 				var userConfirm string
 				_, _ = fmt.Scanln(&userConfirm)
@@ -113,6 +120,7 @@ func newUserCreateCmd(client *master.MasterClient) *cobra.Command {
 				AccessKey: accessKey,
 				SecretKey: secretKey,
 				Type:      userType,
+				Role:      role,
 			}
 			var userInfo *proto.UserInfo
 			if userInfo, err = client.UserAPI().CreateUser(&param, clientIDKey); err != nil {
@@ -129,6 +137,7 @@ func newUserCreateCmd(client *master.MasterClient) *cobra.Command {
 	cmd.Flags().StringVar(&optAccessKey, "access-key", "", "Specify user access key for object storage interface authentication [16 digits & letters]")
 	cmd.Flags().StringVar(&optSecretKey, "secret-key", "", "Specify user secret key for object storage interface authentication [32 digits & letters]")
 	cmd.Flags().StringVar(&optUserType, "user-type", "normal", "Specify user type [normal | admin]")
+	cmd.Flags().StringVar(&optRole, "role", "", "Specify the role granting the master admin apis [read-only | operator | volume-admin | cluster-admin]")
 	cmd.Flags().StringVar(&clientIDKey, CliFlagClientIDKey, client.ClientIDKey(), CliUsageClientIDKey)
 	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
 	return cmd
@@ -143,6 +152,7 @@ func newUserUpdateCmd(client *master.MasterClient) *cobra.Command {
 	var optAccessKey string
 	var optSecretKey string
 	var optUserType string
+	var optRole string
 	var clientIDKey string
 	var optYes bool
 	cmd := &cobra.Command{
@@ -165,6 +175,14 @@ func newUserUpdateCmd(client *master.MasterClient) *cobra.Command {
 					return
 				}
 			}
+			var role *proto.Role
+			if cmd.Flags().Changed("role") {
+				role = (*proto.Role)(&optRole)
+				if !role.Valid() {
+					err = fmt.Errorf("Invalid role ")
+					return
+				}
+			}
 
 			if !optYes {
 				displayAccessKey := "[no change]"
@@ -184,6 +202,11 @@ func newUserUpdateCmd(client *master.MasterClient) *cobra.Command {
 				stdout("  Access Key: %v\n", displayAccessKey)
 				stdout("  Secret Key: %v\n", displaySecretKey)
 				stdout("  Type      : %v\n", displayUserType)
+				displayRole := "[no change]"
+				if role != nil {
+					displayRole = formatUserRole(*role)
+				}
+				stdout("  Role      : %v\n", displayRole)
 				stdout("\nConfirm (yes/no)[yes]: ")
 				var userConfirm string
 				_, _ = fmt.Scanln(&userConfirm)
@@ -192,7 +215,7 @@ func newUserUpdateCmd(client *master.MasterClient) *cobra.Command {
 					return
 				}
 			}
-			if accessKey == "" && secretKey == "" && optUserType == "" {
+			if accessKey == "" && secretKey == "" && optUserType == "" && role == nil {
 				err = fmt.Errorf("no update")
 				return
 			}
@@ -201,6 +224,7 @@ func newUserUpdateCmd(client *master.MasterClient) *cobra.Command {
 				AccessKey: accessKey,
 				SecretKey: secretKey,
 				Type:      userType,
+				Role:      role,
 			}
 			var userInfo *proto.UserInfo
 			if userInfo, err = client.UserAPI().UpdateUser(&param, clientIDKey); err != nil {
@@ -214,6 +238,7 @@ func newUserUpdateCmd(client *master.MasterClient) *cobra.Command {
 	cmd.Flags().StringVar(&optAccessKey, "access-key", "", "Update user access key")
 	cmd.Flags().StringVar(&optSecretKey, "secret-key", "", "Update user secret key")
 	cmd.Flags().StringVar(&optUserType, "user-type", "", "Update user type [normal | admin]")
+	cmd.Flags().StringVar(&optRole, "role", "", "Update the role granting the master admin apis [read-only | operator | volume-admin | cluster-admin], empty to revoke")
 	cmd.Flags().StringVar(&clientIDKey, CliFlagClientIDKey, client.ClientIDKey(), CliUsageClientIDKey)
 	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
 	return cmd
@@ -416,6 +441,7 @@ func printUserInfo(userInfo *proto.UserInfo) {
 	stdout("  Access Key : %v\n", userInfo.AccessKey)
 	stdout("  Secret Key : %v\n", userInfo.SecretKey)
 	stdout("  Type       : %v\n", userInfo.UserType)
+	stdout("  Role       : %v\n", formatUserRole(userInfo.Role))
 	stdout("  Create Time: %v\n", userInfo.CreateTime)
 	if userInfo.Policy == nil {
 		return
@@ -429,3 +455,10 @@ func printUserInfo(userInfo *proto.UserInfo) {
 		stdout("%-20v    %-12v\n", vol, strings.Join(perms, ","))
 	}
 }
+
+func formatUserRole(role proto.Role) string {
+	if role == proto.RoleNone {
+		return "none"
+	}
+	return string(role)
+}
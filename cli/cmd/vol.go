@@ -51,6 +51,10 @@ func newVolCmd(client *master.MasterClient) *cobra.Command {
 		newVolAddMPCmd(client),
 		newVolSetForbiddenCmd(client),
 		newVolSetAuditLogCmd(client),
+		newVolReplicationCmd(client),
+		newVolCloneCmd(client),
+		newVolRenameCmd(client),
+		newVolPromoteCmd(client),
 	)
 	return cmd
 }
@@ -286,7 +290,10 @@ func newVolUpdateCmd(client *master.MasterClient) *cobra.Command {
 	var optTxOpLimitVal int
 	var optReplicaNum string
 	var optDeleteLockTime int64
+	var optTrashRetention int64
 	var optEnableQuota string
+	var optMediaType string
+	var optMediaFallback string
 	confirmString := strings.Builder{}
 	var vv *proto.SimpleVolView
 	cmd := &cobra.Command{
@@ -405,6 +412,14 @@ func newVolUpdateCmd(client *master.MasterClient) *cobra.Command {
 				confirmString.WriteString(fmt.Sprintf("  DeleteLockTime            : %v h\n", vv.DeleteLockTime))
 			}
 
+			if optTrashRetention >= 0 && optTrashRetention != vv.TrashRetention {
+				isChange = true
+				confirmString.WriteString(fmt.Sprintf("  TrashRetention            : %v min -> %v min\n", vv.TrashRetention, optTrashRetention))
+				vv.TrashRetention = optTrashRetention
+			} else {
+				confirmString.WriteString(fmt.Sprintf("  TrashRetention            : %v min\n", vv.TrashRetention))
+			}
+
 			// var maskStr string
 			if optTxMask != "" {
 				var oldMask, newMask proto.TxOpMask
@@ -570,6 +585,32 @@ func newVolUpdateCmd(client *master.MasterClient) *cobra.Command {
 				confirmString.WriteString(fmt.Sprintf("  Vol readonly full : %v\n",
 					formatEnabledDisabled(vv.DpReadOnlyWhenVolFull)))
 			}
+			if optMediaType != "" {
+				isChange = true
+				mediaType := optMediaType
+				if mediaType == "any" {
+					mediaType = ""
+				} else if _, err = proto.ParseMediaType(mediaType); err != nil {
+					return
+				}
+				confirmString.WriteString(fmt.Sprintf("  Media type          : %v -> %v\n",
+					formatMediaType(vv.MediaType), formatMediaType(mediaType)))
+				vv.MediaType = mediaType
+			} else {
+				confirmString.WriteString(fmt.Sprintf("  Media type          : %v\n", formatMediaType(vv.MediaType)))
+			}
+			if optMediaFallback != "" {
+				isChange = true
+				var enable bool
+				if enable, err = strconv.ParseBool(optMediaFallback); err != nil {
+					return
+				}
+				confirmString.WriteString(fmt.Sprintf("  Media fallback      : %v -> %v\n",
+					formatEnabledDisabled(vv.MediaFallback), formatEnabledDisabled(enable)))
+				vv.MediaFallback = enable
+			} else {
+				confirmString.WriteString(fmt.Sprintf("  Media fallback      : %v\n", formatEnabledDisabled(vv.MediaFallback)))
+			}
 
 			if err != nil {
 				return
@@ -626,7 +667,10 @@ func newVolUpdateCmd(client *master.MasterClient) *cobra.Command {
 	cmd.Flags().IntVar(&optTxOpLimitVal, CliTxOpLimit, 0, "Specify limitation[Unit: second] for transaction(default 0 unlimited)")
 	cmd.Flags().StringVar(&optReplicaNum, CliFlagReplicaNum, "", "Specify data partition replicas number(default 3 for normal volume,1 for low volume)")
 	cmd.Flags().StringVar(&optEnableQuota, CliFlagEnableQuota, "", "Enable quota")
+	cmd.Flags().StringVar(&optMediaType, CliFlagMediaType, "", "Specify the preferred media of the data partitions: [ssd|hdd|any]")
+	cmd.Flags().StringVar(&optMediaFallback, CliFlagMediaFallback, "", "Place the data partitions on the other media when the preferred one is full")
 	cmd.Flags().Int64Var(&optDeleteLockTime, CliFlagDeleteLockTime, -1, "Specify delete lock time[Unit: hour] for volume")
+	cmd.Flags().Int64Var(&optTrashRetention, CliFlagTrashRetention, -1, "Specify the time[Unit: minute] the deleted files are kept in the trash, 0 disables the trash")
 	cmd.Flags().StringVar(&clientIDKey, CliFlagClientIDKey, client.ClientIDKey(), CliUsageClientIDKey)
 
 	return cmd
@@ -661,6 +705,22 @@ func newVolInfoCmd(client *master.MasterClient) *cobra.Command {
 			// print summary info
 			stdout("Summary:\n%s\n", formatSimpleVolView(svv))
 
+			// print usage per media class
+			if proto.IsHot(svv.VolType) {
+				var stat *proto.VolStatInfo
+				if stat, err = client.ClientAPI().GetVolumeStat(volumeName); err != nil {
+					err = fmt.Errorf("Get volume stat failed:\n%v\n", err)
+					return
+				}
+				if len(stat.MediaUsages) > 0 {
+					stdout("Media usage:\n")
+					stdout("%v\n", mediaUsageTableHeader)
+					for _, usage := range stat.MediaUsages {
+						stdout("%v\n", formatMediaUsageTableRow(usage))
+					}
+				}
+			}
+
 			// print metadata detail
 			if optMetaDetail {
 				var views []*proto.MetaPartitionView
@@ -1045,3 +1105,187 @@ func newVolSetAuditLogCmd(client *master.MasterClient) *cobra.Command {
 	}
 	return cmd
 }
+
+var (
+	cmdVolCloneUse   = "clone [VOLUME] [VERSION] [NEW VOLUME]"
+	cmdVolCloneShort = "Create a writable volume from a snapshot version of the volume"
+)
+
+func newVolCloneCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdVolCloneUse,
+		Short: cmdVolCloneShort,
+		Args:  cobra.MinimumNArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				errout(err)
+			}()
+			verSeq, err := strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				return
+			}
+			if err = client.AdminAPI().CloneVolume(args[0], verSeq, args[2]); err != nil {
+				return
+			}
+			stdout("Volume [%v] has been cloned from version [%v] of volume [%v] successfully.\n", args[2], verSeq, args[0])
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+const (
+	cmdVolRenameUse   = "rename [VOLUME] [NEW NAME]"
+	cmdVolRenameShort = "Rename the volume online"
+)
+
+func newVolRenameCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdVolRenameUse,
+		Short: cmdVolRenameShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				errout(err)
+			}()
+			if err = client.AdminAPI().RenameVolume(args[0], args[1]); err != nil {
+				return
+			}
+			stdout("Volume [%v] has been renamed to [%v] successfully, remount the clients with the new name.\n", args[0], args[1])
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+const (
+	cmdVolReplicationUse         = "replication [COMMAND]"
+	cmdVolReplicationShort       = "Manage the replication of the volume to another cluster"
+	cmdVolReplicationSetUse      = "set [VOLUME] [TARGET MASTERS]"
+	cmdVolReplicationSetShort    = "Replicate the volume to a volume of the cluster of the masters, separated by comma"
+	cmdVolReplicationStatusUse   = "stat [VOLUME]"
+	cmdVolReplicationStatusShort = "Show the replication and its progress"
+	cmdVolReplicationDeleteUse   = "delete [VOLUME]"
+	cmdVolReplicationDeleteShort = "Stop replicating the volume"
+)
+
+func newVolReplicationCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdVolReplicationUse,
+		Short: cmdVolReplicationShort,
+	}
+	cmd.AddCommand(
+		newVolReplicationSetCmd(client),
+		newVolReplicationStatusCmd(client),
+		newVolReplicationDeleteCmd(client),
+	)
+	return cmd
+}
+
+func newVolReplicationSetCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optTargetVol string
+		optEnable    bool
+	)
+	cmd := &cobra.Command{
+		Use:   cmdVolReplicationSetUse,
+		Short: cmdVolReplicationSetShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				errout(err)
+			}()
+			if err = client.AdminAPI().SetVolReplication(args[0], args[1], optTargetVol, optEnable); err != nil {
+				return
+			}
+			stdout("Volume replication has been set successfully, please wait few minutes for the settings to take effect.\n")
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().StringVar(&optTargetVol, "target-vol", "", "Target volume name, the same as the volume if not specified")
+	cmd.Flags().BoolVar(&optEnable, "enable", true, "Enable the replication, the checkpoint is kept if disabled")
+	return cmd
+}
+
+func newVolReplicationStatusCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     cmdVolReplicationStatusUse,
+		Short:   cmdVolReplicationStatusShort,
+		Aliases: []string{"status"},
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err  error
+				view *proto.VolReplicationView
+			)
+			defer func() {
+				errout(err)
+			}()
+			if view, err = client.AdminAPI().GetVolReplication(args[0]); err != nil {
+				return
+			}
+			stdout("%v", formatVolReplicationView(view))
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+func newVolReplicationDeleteCmd(client *master.MasterClient) *cobra.Command {
+	var optYes bool
+	cmd := &cobra.Command{
+		Use:   cmdVolReplicationDeleteUse,
+		Short: cmdVolReplicationDeleteShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				errout(err)
+			}()
+			if !optYes {
+				stdout("Stop replicating volume [%v] (yes/no)[no]:", args[0])
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			if err = client.AdminAPI().DeleteVolReplication(args[0]); err != nil {
+				return
+			}
+			stdout("Volume replication has been deleted successfully.\n")
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
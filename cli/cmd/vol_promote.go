@@ -0,0 +1,209 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/master"
+	"github.com/cubefs/cubefs/sdk/meta"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdVolPromoteUse   = "promote [VOLUME] [PATH] [NEW VOLUME]"
+	cmdVolPromoteShort = "Detach a directory of the volume into a new volume"
+)
+
+func newVolPromoteCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optYes        bool
+		optKeepSource bool
+	)
+	cmd := &cobra.Command{
+		Use:   cmdVolPromoteUse,
+		Short: cmdVolPromoteShort,
+		Args:  cobra.MinimumNArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				volName  = args[0]
+				fullPath = path.Clean(args[1])
+				newName  = args[2]
+				err      error
+			)
+			defer func() {
+				errout(err)
+			}()
+			if !strings.HasPrefix(fullPath, "/") || fullPath == "/" {
+				err = fmt.Errorf("path %v is not a directory under /", fullPath)
+				return
+			}
+			src, err := meta.NewMetaWrapper(&meta.MetaConfig{
+				Volume:  volName,
+				Masters: client.Nodes(),
+			})
+			if err != nil {
+				return
+			}
+			defer src.Close()
+			parentIno, err := src.LookupPath(path.Dir(fullPath))
+			if err != nil {
+				err = fmt.Errorf("get inode by path %v failed: %v", path.Dir(fullPath), err)
+				return
+			}
+			ino, mode, err := src.Lookup_ll(parentIno, path.Base(fullPath))
+			if err != nil {
+				err = fmt.Errorf("get inode by path %v failed: %v", fullPath, err)
+				return
+			}
+			if !proto.IsDir(mode) {
+				err = fmt.Errorf("path %v is not a directory", fullPath)
+				return
+			}
+			if !optYes {
+				stdout("Promote %v of volume %v to volume %v, the writes to it from now on are not promoted.\n", fullPath, volName, newName)
+				if !optKeepSource {
+					stdout("The directory will be removed from volume %v.\n", volName)
+				}
+				stdout("\nConfirm (yes/no)[yes]:")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					stdout("Abort by user.\n")
+					return
+				}
+			}
+
+			if err = client.AdminAPI().PromoteVolume(volName, fullPath, newName); err != nil {
+				return
+			}
+			dst, err := meta.NewMetaWrapper(&meta.MetaConfig{
+				Volume:  newName,
+				Masters: client.Nodes(),
+			})
+			if err != nil {
+				return
+			}
+			defer dst.Close()
+			if err = rehomePromotedDir(dst, fullPath, ino); err != nil {
+				err = fmt.Errorf("move %v to the root of volume %v failed, delete the volume and retry: %v", fullPath, newName, err)
+				return
+			}
+			if !optKeepSource {
+				dentry := proto.Dentry{Name: path.Base(fullPath), Inode: ino, Type: mode}
+				if err = removeTree(src, parentIno, dentry); err != nil {
+					err = fmt.Errorf("remove %v from volume %v failed: %v", fullPath, volName, err)
+					return
+				}
+			}
+			stdout("Directory %v of volume %v has been promoted to volume %v successfully.\n", fullPath, volName, newName)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	cmd.Flags().BoolVar(&optKeepSource, "keep-source", false, "Keep the directory in the source volume")
+	return cmd
+}
+
+// rehomePromotedDir turns the directory ino at fullPath into the root of the promoted volume,
+// which is cloned from the whole source volume. Everything out of the directory is removed and
+// its entries are moved to the root. Only the metadata is changed, the extents are shared with
+// the source volume and never deleted by the clone.
+func rehomePromotedDir(mw *meta.MetaWrapper, fullPath string, ino uint64) (err error) {
+	names := strings.Split(strings.TrimPrefix(fullPath, "/"), "/")
+	parent := proto.RootIno
+	for _, name := range names {
+		var dentries []proto.Dentry
+		if dentries, err = mw.ReadDir_ll(parent); err != nil {
+			return
+		}
+		next := uint64(0)
+		for _, dentry := range dentries {
+			if dentry.Name == name {
+				next = dentry.Inode
+				continue
+			}
+			if err = removeTree(mw, parent, dentry); err != nil {
+				return
+			}
+		}
+		if next == 0 {
+			return fmt.Errorf("%v is not found", name)
+		}
+		parent = next
+	}
+	if parent != ino {
+		return fmt.Errorf("inode %v of the promoted directory is not %v", parent, ino)
+	}
+
+	// the top directory is moved out of the way of the entries moved to the root
+	top := fmt.Sprintf(".promote-%v", ino)
+	if err = mw.Rename_ll(proto.RootIno, names[0], proto.RootIno, top, "", "", false); err != nil {
+		return
+	}
+	dentries, err := mw.ReadDir_ll(ino)
+	if err != nil {
+		return
+	}
+	for _, dentry := range dentries {
+		if err = mw.Rename_ll(ino, dentry.Name, proto.RootIno, dentry.Name, "", "", false); err != nil {
+			return
+		}
+	}
+	info, err := mw.InodeGet_ll(ino)
+	if err != nil {
+		return
+	}
+	if err = mw.Setattr(proto.RootIno, proto.AttrMode|proto.AttrUid|proto.AttrGid, info.Mode, info.Uid, info.Gid, 0, 0); err != nil {
+		return
+	}
+	topIno, mode, err := mw.Lookup_ll(proto.RootIno, top)
+	if err != nil {
+		return
+	}
+	return removeTree(mw, proto.RootIno, proto.Dentry{Name: top, Inode: topIno, Type: mode})
+}
+
+// removeTree removes the dentry and everything under it.
+func removeTree(mw *meta.MetaWrapper, parent uint64, dentry proto.Dentry) (err error) {
+	isDir := proto.IsDir(dentry.Type)
+	if isDir {
+		var children []proto.Dentry
+		if children, err = mw.ReadDir_ll(dentry.Inode); err != nil {
+			return
+		}
+		for _, child := range children {
+			if err = removeTree(mw, dentry.Inode, child); err != nil {
+				return
+			}
+		}
+	}
+	info, err := mw.Delete_ll(parent, dentry.Name, isDir, "")
+	if err != nil {
+		return
+	}
+	if info != nil && info.Nlink == 0 && !proto.IsDir(info.Mode) {
+		return mw.Evict(info.Inode, "")
+	}
+	return
+}
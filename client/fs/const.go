@@ -47,6 +47,11 @@ const (
 	DeleteExtentsTimeout = 600 * time.Second
 )
 
+const (
+	// SnapshotDirName is the virtual directory to browse the snapshots of a directory
+	SnapshotDirName = ".snapshot"
+)
+
 const (
 	MaxSizePutOnce = int64(1) << 23
 )
@@ -229,6 +229,7 @@ func (d *Dir) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (fs.Node, error
 	}
 
 	d.super.ic.Put(info)
+	d.super.inheritDirMediaType(d.info.Inode, info.Inode)
 	child := NewDir(d.super, info, d.info.Inode, req.Name)
 	newInode = info.Inode
 	d.super.fslock.Lock()
@@ -301,6 +302,10 @@ func (d *Dir) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse.Lo
 	log.LogDebugf("TRACE Lookup: parent(%v) req(%v)", d.info.Inode, req)
 	log.LogDebugf("TRACE Lookup: parent(%v) path(%v) d.super.bcacheDir(%v)", d.info.Inode, d.getCwd(), d.super.bcacheDir)
 
+	if req.Name == SnapshotDirName {
+		return d.lookupSnapRoot()
+	}
+
 	if d.needDentrycache() {
 		dcachev2 = true
 	}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"path"
 	"strings"
 	"sync"
@@ -41,30 +42,39 @@ type File struct {
 	idle      int32
 	parentIno uint64
 	name      string
-	sync.RWMutex
-	fReader *blobstore.Reader
-	fWriter *blobstore.Writer
+	mu        sync.RWMutex
+	fReader   *blobstore.Reader
+	fWriter   *blobstore.Writer
+	mediaOnce sync.Once
+	mediaType uint32 // preferred media class of the parent directory
 }
 
 // Functions that File needs to implement
 var (
-	_ fs.Node              = (*File)(nil)
-	_ fs.Handle            = (*File)(nil)
-	_ fs.NodeForgetter     = (*File)(nil)
-	_ fs.NodeOpener        = (*File)(nil)
-	_ fs.HandleReleaser    = (*File)(nil)
-	_ fs.HandleReader      = (*File)(nil)
-	_ fs.HandleWriter      = (*File)(nil)
-	_ fs.HandleFlusher     = (*File)(nil)
-	_ fs.NodeFsyncer       = (*File)(nil)
-	_ fs.NodeSetattrer     = (*File)(nil)
-	_ fs.NodeReadlinker    = (*File)(nil)
-	_ fs.NodeGetxattrer    = (*File)(nil)
-	_ fs.NodeListxattrer   = (*File)(nil)
-	_ fs.NodeSetxattrer    = (*File)(nil)
-	_ fs.NodeRemovexattrer = (*File)(nil)
+	_ fs.Node                 = (*File)(nil)
+	_ fs.Handle               = (*File)(nil)
+	_ fs.NodeForgetter        = (*File)(nil)
+	_ fs.NodeOpener           = (*File)(nil)
+	_ fs.HandleReleaser       = (*File)(nil)
+	_ fs.HandleReader         = (*File)(nil)
+	_ fs.HandleWriter         = (*File)(nil)
+	_ fs.HandleFlusher        = (*File)(nil)
+	_ fs.NodeFsyncer          = (*File)(nil)
+	_ fs.NodeSetattrer        = (*File)(nil)
+	_ fs.NodeReadlinker       = (*File)(nil)
+	_ fs.NodeGetxattrer       = (*File)(nil)
+	_ fs.NodeListxattrer      = (*File)(nil)
+	_ fs.NodeSetxattrer       = (*File)(nil)
+	_ fs.NodeRemovexattrer    = (*File)(nil)
+	_ fs.HandleFlockLocker    = (*File)(nil)
+	_ fs.HandlePOSIXLocker    = (*File)(nil)
+	_ fs.HandleFallocater     = (*File)(nil)
+	_ fs.HandleCopyFileRanger = (*File)(nil)
 )
 
+// the size of the buffer to copy the data if the extents can not be cloned
+const copyFileRangeChunk = 1 << 20
+
 // NewFile returns a new file.
 func NewFile(s *Super, i *proto.InodeInfo, flag uint32, pino uint64, filename string) fs.Node {
 	if proto.IsCold(s.volType) {
@@ -303,6 +313,12 @@ func (f *File) Release(ctx context.Context, req *fuse.ReleaseRequest) (err error
 	//	f.fWriter.Close()
 	//}
 
+	if f.super.enableLock && req.ReleaseFlags&fuse.ReleaseFlockUnlock != 0 {
+		if lockErr := f.super.mw.ReleaseLocks_ll(ino, uint64(req.LockOwner), true); lockErr != nil {
+			log.LogErrorf("Release: release flock failed, ino(%v) req(%v) err(%v)", ino, req, lockErr)
+		}
+	}
+
 	err = f.super.ec.CloseStream(ino)
 	if err != nil {
 		log.LogErrorf("Release: close writer failed, ino(%v) req(%v) err(%v)", ino, req, err)
@@ -438,7 +454,12 @@ func (f *File) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.Wri
 	}
 	var size int
 	if proto.IsHot(f.super.volType) {
-		f.super.ec.GetStreamer(ino).SetParentInode(f.parentIno)
+		f.mediaOnce.Do(func() {
+			f.mediaType = f.super.getDirMediaType(f.parentIno)
+		})
+		streamer := f.super.ec.GetStreamer(ino)
+		streamer.SetParentInode(f.parentIno)
+		streamer.SetMediaType(f.mediaType)
 		if size, err = f.super.ec.Write(ino, int(req.Offset), req.Data, flags, checkFunc); err == ParseError(syscall.ENOSPC) {
 			return
 		}
@@ -486,7 +507,18 @@ func (f *File) Flush(ctx context.Context, req *fuse.FlushRequest) (err error) {
 		stat.EndStat("Flush", err, bgTime, 1)
 	}()
 
+	if f.super.enableLock {
+		// the POSIX locks of the owner are released when any of its descriptors of the file is closed
+		if err = f.super.mw.ReleaseLocks_ll(f.info.Inode, req.LockOwner, false); err != nil {
+			log.LogErrorf("Flush: release locks failed, ino(%v) req(%v) err(%v)", f.info.Inode, req, err)
+			return ParseError(err)
+		}
+	}
 	if !f.super.fsyncOnClose {
+		if f.super.enableLock {
+			// the kernel stops sending flush requests once ENOSYS is returned
+			return nil
+		}
 		return fuse.ENOSYS
 	}
 	log.LogDebugf("TRACE Flush enter: ino(%v)", f.info.Inode)
@@ -499,9 +531,9 @@ func (f *File) Flush(ctx context.Context, req *fuse.FlushRequest) (err error) {
 	if proto.IsHot(f.super.volType) {
 		err = f.super.ec.Flush(f.info.Inode)
 	} else {
-		f.Lock()
+		f.mu.Lock()
 		err = f.fWriter.Flush(f.info.Inode, ctx)
-		f.Unlock()
+		f.mu.Unlock()
 	}
 	log.LogDebugf("TRACE Flush: ino(%v) err(%v)", f.info.Inode, err)
 	if err != nil {
@@ -546,6 +578,109 @@ func (f *File) Fsync(ctx context.Context, req *fuse.FsyncRequest) (err error) {
 	return nil
 }
 
+// Fallocate handles the fallocate request. The space is allocated on write, so only the size of
+// the file is extended, and the data of the range is released to punch holes or zero the range.
+func (f *File) Fallocate(ctx context.Context, req *fuse.FallocateRequest) (err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("Fallocate", err, bgTime, 1)
+	}()
+
+	ino := f.info.Inode
+	log.LogDebugf("TRACE Fallocate enter: ino(%v) req(%v)", ino, req)
+	if proto.IsCold(f.super.volType) {
+		return fuse.ENOTSUP
+	}
+	if req.Mode&(fuse.FallocateCollapseRange|fuse.FallocateInsertRange|fuse.FallocateUnshareRange) != 0 {
+		return fuse.ENOTSUP
+	}
+	if req.Mode&fuse.FallocatePunchHole != 0 && req.Mode&fuse.FallocateKeepSize == 0 {
+		return fuse.Errno(syscall.EINVAL)
+	}
+	defer f.super.ic.Delete(ino)
+
+	if req.Mode&(fuse.FallocatePunchHole|fuse.FallocateZeroRange) != 0 {
+		if err = f.super.ec.PunchHole(ino, int(req.Offset), int(req.Length)); err != nil {
+			log.LogErrorf("Fallocate: punch hole ino(%v) req(%v) err(%v)", ino, req, err)
+			return ParseError(err)
+		}
+	}
+	if req.Mode&fuse.FallocateKeepSize != 0 {
+		return nil
+	}
+
+	end := req.Offset + req.Length
+	if filesize, _ := f.fileSize(ino); end <= uint64(filesize) {
+		return nil
+	}
+	fullPath := path.Join(f.getParentPath(), f.name)
+	if err = f.super.ec.Truncate(f.super.mw, f.parentIno, ino, int(end), fullPath); err != nil {
+		log.LogErrorf("Fallocate: extend ino(%v) size(%v) err(%v)", ino, end, err)
+		return ParseError(err)
+	}
+	log.LogDebugf("TRACE Fallocate: ino(%v) req(%v)", ino, req)
+	return nil
+}
+
+// CopyFileRange handles the copy_file_range request. The extents of the range are shared with the
+// destination file if both files are in the same meta partition, otherwise the data is copied.
+func (f *File) CopyFileRange(ctx context.Context, req *fuse.CopyFileRangeRequest, out fs.Handle, resp *fuse.CopyFileRangeResponse) (err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("CopyFileRange", err, bgTime, 1)
+	}()
+
+	dst, ok := out.(*File)
+	if !ok || proto.IsCold(f.super.volType) {
+		// the kernel falls back to copy the data by itself
+		return fuse.ENOSYS
+	}
+	srcIno, dstIno := f.info.Inode, dst.info.Inode
+	log.LogDebugf("TRACE CopyFileRange enter: src ino(%v) dst ino(%v) req(%v)", srcIno, dstIno, req)
+	defer f.super.ic.Delete(dstIno)
+
+	size, err := f.super.ec.CloneExtents(srcIno, int(req.Offset), dstIno, int(req.OffsetOut), int(req.Len))
+	if err != nil {
+		log.LogWarnf("CopyFileRange: clone extents failed, copy the data instead, src ino(%v) dst ino(%v) req(%v) err(%v)",
+			srcIno, dstIno, req, err)
+		if size, err = f.copyRange(dstIno, int(req.Offset), int(req.OffsetOut), int(req.Len)); err != nil {
+			log.LogErrorf("CopyFileRange: src ino(%v) dst ino(%v) req(%v) copied(%v) err(%v)",
+				srcIno, dstIno, req, size, err)
+			return ParseError(err)
+		}
+	}
+	resp.Size = size
+	log.LogDebugf("TRACE CopyFileRange: src ino(%v) dst ino(%v) req(%v) size(%v)", srcIno, dstIno, req, size)
+	return nil
+}
+
+func (f *File) copyRange(dstIno uint64, srcOff, dstOff, size int) (copied int, err error) {
+	buf := make([]byte, copyFileRangeChunk)
+	for copied < size {
+		n := size - copied
+		if n > len(buf) {
+			n = len(buf)
+		}
+		var read, write int
+		read, err = f.super.ec.Read(f.info.Inode, buf, srcOff+copied, n)
+		if err != nil && err != io.EOF {
+			return
+		}
+		err = nil
+		if read <= 0 {
+			return
+		}
+		if write, err = f.super.ec.Write(dstIno, dstOff+copied, buf[:read], 0, nil); err != nil {
+			return
+		}
+		copied += write
+		if read < n {
+			return
+		}
+	}
+	return
+}
+
 // Setattr handles the setattr request.
 func (f *File) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse.SetattrResponse) error {
 	var err error
@@ -727,6 +862,123 @@ func (f *File) Removexattr(ctx context.Context, req *fuse.RemovexattrRequest) er
 	return nil
 }
 
+// Lock tries to acquire the advisory lock, EAGAIN is returned if a conflicting lock is held.
+func (f *File) Lock(ctx context.Context, req *fuse.LockRequest) (err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("Lock", err, bgTime, 1)
+	}()
+
+	ino := f.info.Inode
+	lock := fuseFileLock(req.LockOwner, req.Lock, req.LockFlags)
+	if err = f.super.mw.SetLock_ll(ino, lock, false); err != nil {
+		log.LogDebugf("Lock: ino(%v) lock(%v) err(%v)", ino, lock, err)
+		return ParseError(err)
+	}
+	log.LogDebugf("TRACE Lock: ino(%v) lock(%v)", ino, lock)
+	return nil
+}
+
+// LockWait acquires the advisory lock, waiting until the conflicting locks are released
+// or the request is interrupted.
+func (f *File) LockWait(ctx context.Context, req *fuse.LockWaitRequest) (err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("LockWait", err, bgTime, 1)
+	}()
+
+	ino := f.info.Inode
+	lock := fuseFileLock(req.LockOwner, req.Lock, req.LockFlags)
+	for {
+		// the metanode wakes up the request once the conflicting locks are released,
+		// and fails it after a while so that the interrupts are able to be checked
+		if err = f.super.mw.SetLock_ll(ino, lock, true); err != syscall.EAGAIN {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			log.LogDebugf("LockWait: interrupted, ino(%v) lock(%v)", ino, lock)
+			return fuse.EINTR
+		default:
+		}
+	}
+	if err != nil {
+		log.LogErrorf("LockWait: ino(%v) lock(%v) err(%v)", ino, lock, err)
+		return ParseError(err)
+	}
+	log.LogDebugf("TRACE LockWait: ino(%v) lock(%v)", ino, lock)
+	return nil
+}
+
+// Unlock releases the advisory lock of the range.
+func (f *File) Unlock(ctx context.Context, req *fuse.UnlockRequest) (err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("Unlock", err, bgTime, 1)
+	}()
+
+	ino := f.info.Inode
+	lock := fuseFileLock(req.LockOwner, req.Lock, req.LockFlags)
+	if err = f.super.mw.SetLock_ll(ino, lock, false); err != nil {
+		log.LogErrorf("Unlock: ino(%v) lock(%v) err(%v)", ino, lock, err)
+		return ParseError(err)
+	}
+	log.LogDebugf("TRACE Unlock: ino(%v) lock(%v)", ino, lock)
+	return nil
+}
+
+// QueryLock returns one of the locks conflicting with the requested one.
+func (f *File) QueryLock(ctx context.Context, req *fuse.QueryLockRequest, resp *fuse.QueryLockResponse) (err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("QueryLock", err, bgTime, 1)
+	}()
+
+	ino := f.info.Inode
+	lock := fuseFileLock(req.LockOwner, req.Lock, req.LockFlags)
+	conflict, err := f.super.mw.GetLock_ll(ino, lock)
+	if err != nil {
+		log.LogErrorf("QueryLock: ino(%v) lock(%v) err(%v)", ino, lock, err)
+		return ParseError(err)
+	}
+	if conflict != nil {
+		resp.Lock = fuse.FileLock{
+			Start: conflict.Start,
+			End:   conflict.End,
+			Type:  fuse.LockRead,
+			PID:   int32(conflict.Pid),
+		}
+		if conflict.Type == proto.FileLockWrite {
+			resp.Lock.Type = fuse.LockWrite
+		}
+	}
+	log.LogDebugf("TRACE QueryLock: ino(%v) lock(%v) conflict(%v)", ino, lock, conflict)
+	return nil
+}
+
+func fuseFileLock(owner fuse.LockOwner, lock fuse.FileLock, flags fuse.LockFlags) *proto.FileLock {
+	fl := &proto.FileLock{
+		Owner: uint64(owner),
+		Flock: flags&fuse.LockFlock != 0,
+		Start: lock.Start,
+		End:   lock.End,
+		Pid:   uint32(lock.PID),
+	}
+	switch lock.Type {
+	case fuse.LockRead:
+		fl.Type = proto.FileLockRead
+	case fuse.LockWrite:
+		fl.Type = proto.FileLockWrite
+	default:
+		fl.Type = proto.FileLockUnlock
+	}
+	if fl.Flock {
+		// flock locks the whole file
+		fl.Start, fl.End = 0, math.MaxUint64
+	}
+	return fl
+}
+
 func (f *File) fileSize(ino uint64) (size int, gen uint64) {
 	size, gen, valid := f.super.ec.FileSize(ino)
 	if !valid {
@@ -0,0 +1,253 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"context"
+	"syscall"
+
+	"github.com/cubefs/cubefs/depends/bazil.org/fuse"
+	"github.com/cubefs/cubefs/depends/bazil.org/fuse/fs"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// SnapRoot is the virtual ".snapshot" directory, which lists the snapshots taken on its parent.
+// It is hidden from readdir and only reachable by lookup.
+type SnapRoot struct {
+	super  *Super
+	parent *proto.InodeInfo
+}
+
+// SnapDir is a directory read at the sequence of a snapshot.
+// The attributes are kept as they were, and the modifications are rejected with EROFS.
+type SnapDir struct {
+	super   *Super
+	info    *proto.InodeInfo
+	readSeq uint64
+}
+
+// SnapFile is a file read at the sequence of a snapshot.
+type SnapFile struct {
+	super   *Super
+	info    *proto.InodeInfo
+	readSeq uint64
+}
+
+// Functions that the snapshot nodes need to implement
+var (
+	_ fs.Node               = (*SnapRoot)(nil)
+	_ fs.NodeStringLookuper = (*SnapRoot)(nil)
+	_ fs.HandleReadDirAller = (*SnapRoot)(nil)
+	_ fs.Node               = (*SnapDir)(nil)
+	_ fs.NodeStringLookuper = (*SnapDir)(nil)
+	_ fs.HandleReadDirAller = (*SnapDir)(nil)
+	_ fs.NodeCreater        = (*SnapDir)(nil)
+	_ fs.NodeMkdirer        = (*SnapDir)(nil)
+	_ fs.NodeRemover        = (*SnapDir)(nil)
+	_ fs.NodeRenamer        = (*SnapDir)(nil)
+	_ fs.NodeSetattrer      = (*SnapDir)(nil)
+	_ fs.Node               = (*SnapFile)(nil)
+	_ fs.NodeOpener         = (*SnapFile)(nil)
+	_ fs.HandleReader       = (*SnapFile)(nil)
+	_ fs.HandleReleaser     = (*SnapFile)(nil)
+	_ fs.NodeReadlinker     = (*SnapFile)(nil)
+	_ fs.NodeSetattrer      = (*SnapFile)(nil)
+)
+
+func (s *Super) newSnapNode(info *proto.InodeInfo, readSeq uint64) fs.Node {
+	if proto.IsDir(info.Mode) {
+		return &SnapDir{super: s, info: info, readSeq: readSeq}
+	}
+	return &SnapFile{super: s, info: info, readSeq: readSeq}
+}
+
+// lookupSnapRoot returns the ".snapshot" directory of the directory if it has any snapshot.
+func (d *Dir) lookupSnapRoot() (fs.Node, error) {
+	infos, err := d.super.mw.ListDirSnapshots(d.info.Inode)
+	if err != nil {
+		return nil, fuse.EIO
+	}
+	if len(infos) == 0 {
+		return nil, fuse.ENOENT
+	}
+	return &SnapRoot{super: d.super, parent: d.info}, nil
+}
+
+func (r *SnapRoot) Attr(ctx context.Context, a *fuse.Attr) error {
+	fillAttr(r.parent, a)
+	// let the fuse server pick a dynamic inode, the parent inode is in use
+	a.Inode = 0
+	return nil
+}
+
+func (r *SnapRoot) Lookup(ctx context.Context, name string) (fs.Node, error) {
+	infos, err := r.super.mw.ListDirSnapshots(r.parent.Inode)
+	if err != nil {
+		return nil, fuse.EIO
+	}
+	for _, snapshot := range infos {
+		if snapshot.Name != name {
+			continue
+		}
+		info, err := r.super.mw.InodeGetVer_ll(r.parent.Inode, snapshot.ReadSeq)
+		if err != nil {
+			log.LogErrorf("SnapRoot Lookup: ino(%v) snapshot(%v) err(%v)", r.parent.Inode, snapshot, err)
+			return nil, ParseError(err)
+		}
+		return &SnapDir{super: r.super, info: info, readSeq: snapshot.ReadSeq}, nil
+	}
+	return nil, fuse.ENOENT
+}
+
+func (r *SnapRoot) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
+	infos, err := r.super.mw.ListDirSnapshots(r.parent.Inode)
+	if err != nil {
+		return nil, fuse.EIO
+	}
+	dirents := make([]fuse.Dirent, 0, len(infos))
+	for _, snapshot := range infos {
+		dirents = append(dirents, fuse.Dirent{Type: fuse.DT_Dir, Name: snapshot.Name})
+	}
+	return dirents, nil
+}
+
+func (d *SnapDir) Attr(ctx context.Context, a *fuse.Attr) error {
+	fillAttr(d.info, a)
+	return nil
+}
+
+func (d *SnapDir) Lookup(ctx context.Context, name string) (fs.Node, error) {
+	ino, _, err := d.super.mw.LookupVer_ll(d.info.Inode, name, d.readSeq)
+	if err != nil {
+		return nil, ParseError(err)
+	}
+	info, err := d.super.mw.InodeGetVer_ll(ino, d.readSeq)
+	if err != nil {
+		log.LogErrorf("SnapDir Lookup: parent(%v) name(%v) ino(%v) readSeq(%v) err(%v)", d.info.Inode, name, ino, d.readSeq, err)
+		return nil, ParseError(err)
+	}
+	return d.super.newSnapNode(info, d.readSeq), nil
+}
+
+func (d *SnapDir) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
+	children, err := d.super.mw.ReadDirVer_ll(d.info.Inode, d.readSeq)
+	if err != nil {
+		log.LogErrorf("SnapDir ReadDirAll: ino(%v) readSeq(%v) err(%v)", d.info.Inode, d.readSeq, err)
+		return nil, ParseError(err)
+	}
+	dirents := make([]fuse.Dirent, 0, len(children))
+	for _, child := range children {
+		dirents = append(dirents, fuse.Dirent{Inode: child.Inode, Type: ParseType(child.Type), Name: child.Name})
+	}
+	return dirents, nil
+}
+
+func (d *SnapDir) Create(ctx context.Context, req *fuse.CreateRequest, resp *fuse.CreateResponse) (fs.Node, fs.Handle, error) {
+	return nil, nil, fuse.Errno(syscall.EROFS)
+}
+
+func (d *SnapDir) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (fs.Node, error) {
+	return nil, fuse.Errno(syscall.EROFS)
+}
+
+func (d *SnapDir) Remove(ctx context.Context, req *fuse.RemoveRequest) error {
+	return fuse.Errno(syscall.EROFS)
+}
+
+func (d *SnapDir) Rename(ctx context.Context, req *fuse.RenameRequest, newDir fs.Node) error {
+	return fuse.Errno(syscall.EROFS)
+}
+
+func (d *SnapDir) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse.SetattrResponse) error {
+	return fuse.Errno(syscall.EROFS)
+}
+
+func (f *SnapFile) Attr(ctx context.Context, a *fuse.Attr) error {
+	fillAttr(f.info, a)
+	return nil
+}
+
+func (f *SnapFile) Open(ctx context.Context, req *fuse.OpenRequest, resp *fuse.OpenResponse) (fs.Handle, error) {
+	if !req.Flags.IsReadOnly() {
+		return nil, fuse.Errno(syscall.EROFS)
+	}
+	if err := f.super.ec.OpenStream(f.info.Inode); err != nil {
+		log.LogErrorf("SnapFile Open: ino(%v) err(%v)", f.info.Inode, err)
+		return nil, ParseError(err)
+	}
+	// the content of a snapshot never changes
+	resp.Flags |= fuse.OpenKeepCache
+	return f, nil
+}
+
+func (f *SnapFile) Release(ctx context.Context, req *fuse.ReleaseRequest) error {
+	if err := f.super.ec.CloseStream(f.info.Inode); err != nil {
+		log.LogErrorf("SnapFile Release: ino(%v) err(%v)", f.info.Inode, err)
+		return ParseError(err)
+	}
+	return nil
+}
+
+// Read reads the extents of the snapshot version, the holes are left zero-filled.
+func (f *SnapFile) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadResponse) error {
+	_, fileSize, extents, err := f.super.mw.GetExtentsVer(f.info.Inode, f.readSeq)
+	if err != nil {
+		return ParseError(err)
+	}
+	start := uint64(req.Offset)
+	if start >= fileSize {
+		resp.Data = resp.Data[:fuse.OutHeaderSize]
+		return nil
+	}
+	end := start + uint64(req.Size)
+	if end > fileSize {
+		end = fileSize
+	}
+	data := resp.Data[fuse.OutHeaderSize : fuse.OutHeaderSize+int(end-start)]
+	for i := range data {
+		data[i] = 0
+	}
+
+	for i := range extents {
+		ek := &extents[i]
+		ekEnd := ek.FileOffset + uint64(ek.Size)
+		if ekEnd <= start || ek.FileOffset >= end {
+			continue
+		}
+		from, to := start, end
+		if ek.FileOffset > from {
+			from = ek.FileOffset
+		}
+		if ekEnd < to {
+			to = ekEnd
+		}
+		if _, err, _ = f.super.ec.ReadExtent(f.info.Inode, ek, data[from-start:to-start], int(from-ek.FileOffset), int(to-from)); err != nil {
+			log.LogErrorf("SnapFile Read: ino(%v) readSeq(%v) ek(%v) err(%v)", f.info.Inode, f.readSeq, ek, err)
+			return fuse.EIO
+		}
+	}
+	resp.Data = resp.Data[:fuse.OutHeaderSize+len(data)]
+	return nil
+}
+
+func (f *SnapFile) Readlink(ctx context.Context, req *fuse.ReadlinkRequest) (string, error) {
+	return string(f.info.Target), nil
+}
+
+func (f *SnapFile) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse.SetattrResponse) error {
+	return fuse.Errno(syscall.EROFS)
+}
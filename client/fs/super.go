@@ -64,6 +64,7 @@ type Super struct {
 	disableDcache bool
 	fsyncOnClose  bool
 	enableXattr   bool
+	enableLock    bool
 	rootIno       uint64
 
 	state     fs.FSStatType
@@ -159,6 +160,7 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 	s.disableDcache = opt.DisableDcache
 	s.fsyncOnClose = opt.FsyncOnClose
 	s.enableXattr = opt.EnableXattr
+	s.enableLock = opt.EnableFileLock
 	s.bcacheCheckInterval = opt.BcacheCheckIntervalS
 	s.bcacheFilterFiles = opt.BcacheFilterFiles
 	s.bcacheBatchCnt = opt.BcacheBatchCnt
@@ -228,6 +230,8 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 		OnSplitExtentKey:  s.mw.SplitExtentKey,
 		OnGetExtents:      s.mw.GetExtents,
 		OnTruncate:        s.mw.Truncate,
+		OnPunchHole:       s.mw.PunchHole,
+		OnCloneExtents:    s.mw.CloneExtents,
 		OnEvictIcache:     s.ic.Delete,
 		OnLoadBcache:      s.bc.Get,
 		OnCacheBcache:     s.bc.Put,
@@ -347,6 +351,34 @@ func (s *Super) Node(ino, pino uint64, mode uint32) (fs.Node, error) {
 	return node, nil
 }
 
+// getDirMediaType returns the media class preferred by the directory, unspecified if none is set.
+func (s *Super) getDirMediaType(ino uint64) uint32 {
+	info, err := s.mw.XAttrGet_ll(ino, proto.XAttrKeyMediaType)
+	if err != nil {
+		return proto.MediaTypeUnspecified
+	}
+	mediaType, err := proto.ParseMediaType(info.XAttrs[proto.XAttrKeyMediaType])
+	if err != nil {
+		log.LogWarnf("getDirMediaType: ino(%v) err(%v)", ino, err)
+		return proto.MediaTypeUnspecified
+	}
+	return mediaType
+}
+
+// inheritDirMediaType copies the preferred media class of the parent to the new sub directory.
+func (s *Super) inheritDirMediaType(parentIno, ino uint64) {
+	if !proto.IsHot(s.volType) {
+		return
+	}
+	mediaType := s.getDirMediaType(parentIno)
+	if mediaType == proto.MediaTypeUnspecified {
+		return
+	}
+	if err := s.mw.XAttrSet_ll(ino, []byte(proto.XAttrKeyMediaType), []byte(proto.MediaTypeString(mediaType))); err != nil {
+		log.LogWarnf("inheritDirMediaType: parent(%v) ino(%v) err(%v)", parentIno, ino, err)
+	}
+}
+
 // Statfs handles the Statfs request and returns a set of statistics.
 func (s *Super) Statfs(ctx context.Context, req *fuse.StatfsRequest, resp *fuse.StatfsResponse) error {
 	const defaultMaxMetaPartitionInodeID uint64 = 1<<63 - 1
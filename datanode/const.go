@@ -54,6 +54,7 @@ const (
 	ActionDecommissionPartition         = "ActionDecommissionPartition"
 	ActionAddDataPartitionRaftMember    = "ActionAddDataPartitionRaftMember"
 	ActionRemoveDataPartitionRaftMember = "ActionRemoveDataPartitionRaftMember"
+	ActionPromoteDataPartitionLearner   = "ActionPromoteDataPartitionLearner"
 	ActionDataPartitionTryToLeader      = "ActionDataPartitionTryToLeader"
 
 	ActionCreateDataPartition        = "ActionCreateDataPartition"
@@ -65,6 +66,7 @@ const (
 	ActionBatchMarkDelete            = "ActionBatchMarkDelete"
 	ActionUpdateVersion              = "ActionUpdateVersion"
 	ActionStopDataPartitionRepair    = "ActionStopDataPartitionRepair"
+	ActionUpdateDataPartitionVolName = "ActionUpdateDataPartitionVolName"
 )
 
 // Apply the raft log operation. Currently we only have the random write operation.
@@ -71,6 +71,7 @@ type Disk struct {
 	Status          int // disk status such as READONLY
 	ReservedSpace   uint64
 	DiskRdonlySpace uint64
+	MediaType       uint32 // media class of the disk such as ssd or hdd
 
 	RejectWrite                               bool
 	partitionMap                              map[uint64]*DataPartition
@@ -102,6 +102,7 @@ type DataPartition struct {
 	isRaftLeader    bool
 	path            string
 	used            int
+	physicalUsed    int // used space excluding the space saved by the compression
 	leaderSize      int
 	extentStore     *storage.ExtentStore
 	raftPartition   raftstore.Partition
@@ -590,6 +591,11 @@ func (dp *DataPartition) Used() int {
 	return dp.used
 }
 
+// PhysicalUsed returns the used space on the disk.
+func (dp *DataPartition) PhysicalUsed() int {
+	return dp.physicalUsed
+}
+
 // Available returns the available space.
 func (dp *DataPartition) Available() int {
 	return dp.partitionSize - dp.used
@@ -599,6 +605,21 @@ func (dp *DataPartition) ForceLoadHeader() {
 	dp.loadExtentHeaderStatus = FinishLoadDataPartitionExtentHeader
 }
 
+// updateVolName changes the name of the volume renamed on the master.
+func (dp *DataPartition) updateVolName(volName string) (err error) {
+	oldVolName := dp.config.VolName
+	if oldVolName == volName {
+		return
+	}
+	dp.config.VolName = volName
+	dp.volumeID = volName
+	if err = dp.PersistMetadata(); err != nil {
+		dp.config.VolName = oldVolName
+		dp.volumeID = oldVolName
+	}
+	return
+}
+
 // PersistMetadata persists the file metadata on the disk.
 func (dp *DataPartition) PersistMetadata() (err error) {
 	dp.persistMetaMutex.Lock()
@@ -659,6 +680,7 @@ func (dp *DataPartition) statusUpdateScheduler() {
 				dp.LaunchRepair(proto.TinyExtentType)
 				continue
 			}
+			dp.updateCompression()
 
 			index++
 			if index >= math.MaxUint32 {
@@ -713,6 +735,7 @@ func (dp *DataPartition) computeUsage() {
 		return
 	}
 	dp.used = int(dp.ExtentStore().GetStoreUsedSize())
+	dp.physicalUsed = int(dp.ExtentStore().GetStorePhysicalUsedSize())
 	dp.intervalToUpdatePartitionSize = time.Now().Unix()
 }
 
@@ -1036,6 +1059,12 @@ func (dp *DataPartition) ChangeRaftMember(changeType raftProto.ConfChangeType, p
 	return
 }
 
+// ReplaceRaftMember replaces the raft member through a joint consensus.
+func (dp *DataPartition) ReplaceRaftMember(peer, removePeer raftProto.Peer, context []byte) (resp interface{}, err error) {
+	resp, err = dp.raftPartition.ReplaceMember(peer, removePeer, context)
+	return
+}
+
 func (dp *DataPartition) canRemoveSelf() (canRemove bool, err error) {
 	var partition *proto.DataPartitionInfo
 	retry := 0
@@ -1136,6 +1165,21 @@ func (vo *VolMap) getSimpleVolView(VolumeID string) (vv *proto.SimpleVolView, er
 	return
 }
 
+// updateCompression applies the compression of the volume to the extent store.
+func (dp *DataPartition) updateCompression() {
+	vv, err := volViews.getSimpleVolView(dp.volumeID)
+	if err != nil {
+		log.LogWarnf("action[updateCompression] dp(%v) get vol(%v) err(%v)", dp.partitionID, dp.volumeID, err)
+		return
+	}
+	if !proto.IsHot(vv.VolType) {
+		return
+	}
+	if err = dp.extentStore.SetCompression(vv.Compression); err != nil {
+		log.LogErrorf("action[updateCompression] dp(%v) vol(%v) err(%v)", dp.partitionID, dp.volumeID, err)
+	}
+}
+
 func (dp *DataPartition) doExtentTtl(ttl int) {
 	if ttl <= 0 {
 		log.LogWarn("[doTTL] ttl is 0, set default 30", ttl)
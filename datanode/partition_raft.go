@@ -112,6 +112,15 @@ func (dp *DataPartition) StartRaft(isLoad bool) (err error) {
 			HeartbeatPort: heartbeatPort,
 			ReplicaPort:   replicaPort,
 		}
+		// the joint consensus of the replacement in progress is restored
+		switch {
+		case peer.IsLearner:
+			rp.Type = raftproto.PeerLearner
+		case peer.Joint == proto.PeerJointIncoming:
+			rp.Type = raftproto.PeerIncoming
+		case peer.Joint == proto.PeerJointOutgoing:
+			rp.Type = raftproto.PeerOutgoing
+		}
 		peers = append(peers, rp)
 	}
 	log.LogDebugf("start partition(%v) raft peers: %s path: %s",
@@ -391,6 +400,33 @@ func (dp *DataPartition) addRaftNode(req *proto.AddDataPartitionRaftMemberReques
 	return
 }
 
+// promoteRaftLearner turns the learner into a voter in the peers, or into the incoming peer of the
+// joint consensus if it replaces the peer ReplacePeer.
+func (dp *DataPartition) promoteRaftLearner(req *proto.PromoteDataPartitionLearnerRequest, index uint64) (isUpdated bool, err error) {
+	if req.ReplacePeer.ID != 0 {
+		isUpdated = proto.EnterJoint(dp.config.Peers, req.PromotePeer.ID, req.ReplacePeer.ID)
+	} else {
+		for i, peer := range dp.config.Peers {
+			if peer.ID == req.PromotePeer.ID && peer.IsLearner {
+				dp.config.Peers[i].IsLearner = false
+				isUpdated = true
+				break
+			}
+		}
+	}
+	log.LogInfof("promoteRaftLearner: partitionID(%v) nodeID(%v) index(%v) peer(%v) updated(%v)",
+		req.PartitionId, dp.config.NodeID, index, req.PromotePeer, isUpdated)
+	return
+}
+
+// CanPromoteLearner checks on the leader whether the learner has caught up with the log.
+func (dp *DataPartition) CanPromoteLearner(peer proto.Peer) error {
+	if dp.raftPartition == nil {
+		return fmt.Errorf("CanPromoteLearner (%v) not support", dp)
+	}
+	return raftstore.CheckLearnerCaughtUp(dp.raftPartition, peer.ID)
+}
+
 // Delete a raft node.
 func (dp *DataPartition) removeRaftNode(req *proto.RemoveDataPartitionRaftMemberRequest, index uint64) (isUpdated bool, err error) {
 	// cache or preload partition not support raft and repair.
@@ -429,7 +465,9 @@ func (dp *DataPartition) removeRaftNode(req *proto.RemoveDataPartitionRaftMember
 	if hostIndex != -1 {
 		dp.config.Hosts = append(dp.config.Hosts[:hostIndex], dp.config.Hosts[hostIndex+1:]...)
 	}
+	removed := dp.config.Peers[peerIndex]
 	dp.config.Peers = append(dp.config.Peers[:peerIndex], dp.config.Peers[peerIndex+1:]...)
+	proto.LeaveJoint(dp.config.Peers, removed)
 	// if ApplyMemberChange is timeout, master will keep old hosts. when meta node executing
 	// delete inode operation, will produce amount of dp not found error messages
 	// if dp.config.NodeID == req.RemovePeer.ID && !dp.isLoadingDataPartition && canRemoveSelf {
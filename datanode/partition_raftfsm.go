@@ -79,7 +79,7 @@ func (dp *DataPartition) ApplyMemberChange(confChange *raftproto.ConfChange, ind
 		isUpdated bool
 	)
 	switch confChange.Type {
-	case raftproto.ConfAddNode:
+	case raftproto.ConfAddNode, raftproto.ConfAddLearner:
 		req := &proto.AddDataPartitionRaftMemberRequest{}
 		if err = json.Unmarshal(confChange.Context, req); err != nil {
 			return
@@ -112,6 +112,15 @@ func (dp *DataPartition) ApplyMemberChange(confChange *raftproto.ConfChange, ind
 		}
 		log.LogInfof("action[ApplyMemberChange] ConfRemoveNode [%v], partitionId [%v]", req.RemovePeer, req.PartitionId)
 		isUpdated, err = dp.removeRaftNode(req, index)
+	case raftproto.ConfPromoteLearner, raftproto.ConfReplaceNode:
+		// the peer replaced stays in the peers until it is removed, which leaves the joint consensus,
+		// and the sides of the joint consensus are persisted with the peers
+		req := &proto.PromoteDataPartitionLearnerRequest{}
+		if err = json.Unmarshal(confChange.Context, req); err != nil {
+			return
+		}
+		log.LogInfof("action[ApplyMemberChange] %v [%v], partitionId [%v]", confChange.Type, req.PromotePeer, req.PartitionId)
+		isUpdated, err = dp.promoteRaftLearner(req, index)
 	case raftproto.ConfUpdateNode:
 		log.LogDebugf("[updateRaftNode]: not support.")
 	default:
@@ -79,6 +79,8 @@ const (
 	ConfigKeyPort          = "port"            // int
 	ConfigKeyMasterAddr    = "masterAddr"      // array
 	ConfigKeyZone          = "zoneName"        // string
+	ConfigKeyRack          = "rackName"        // string
+	ConfigKeyHost          = "hostName"        // string
 	ConfigKeyDisks         = "disks"           // array
 	ConfigKeyRaftDir       = "raftDir"         // string
 	ConfigKeyRaftHeartbeat = "raftHeartbeat"   // string
@@ -88,6 +90,8 @@ const (
 
 	ConfigKeyDiskPath         = "diskPath"            // string
 	configNameResolveInterval = "nameResolveInterval" // int
+	// media class of the disks which are not configured with one, such as ssd or hdd
+	ConfigKeyMediaType = "mediaType" // string
 
 	/*
 	 * Metrics Degrade Level
@@ -132,6 +136,8 @@ type DataNode struct {
 	space           *SpaceManager
 	port            string
 	zoneName        string
+	rackName        string
+	hostName        string
 	clusterID       string
 	localIP         string
 	bindIp          bool
@@ -346,6 +352,8 @@ func (s *DataNode) parseConfig(cfg *config.Config) (err error) {
 	if s.zoneName == "" {
 		s.zoneName = DefaultZoneName
 	}
+	s.rackName = cfg.GetString(ConfigKeyRack)
+	s.hostName = cfg.GetString(ConfigKeyHost)
 	s.metricsDegrade = cfg.GetInt64(CfgMetricsDegrade)
 
 	s.serviceIDKey = cfg.GetString(ConfigServiceIDKey)
@@ -362,6 +370,7 @@ func (s *DataNode) parseConfig(cfg *config.Config) (err error) {
 	log.LogDebugf("action[parseConfig] load masterAddrs(%v).", MasterClient.Nodes())
 	log.LogDebugf("action[parseConfig] load port(%v).", s.port)
 	log.LogDebugf("action[parseConfig] load zoneName(%v).", s.zoneName)
+	log.LogDebugf("action[parseConfig] load rackName(%v) hostName(%v).", s.rackName, s.hostName)
 	return
 }
 
@@ -404,6 +413,11 @@ func (s *DataNode) startSpaceManager(cfg *config.Config) (err error) {
 	diskEnableReadRepairExtentLimit := cfg.GetBoolWithDefault(ConfigEnableDiskReadExtentLimit, false)
 	log.LogInfof("startSpaceManager preReserveSpace %d", diskRdonlySpace)
 
+	defaultMediaType, err := proto.ParseMediaType(cfg.GetString(ConfigKeyMediaType))
+	if err != nil {
+		return err
+	}
+
 	paths := make([]string, 0)
 	diskPath := cfg.GetString(ConfigKeyDiskPath)
 	if diskPath != "" {
@@ -422,10 +436,16 @@ func (s *DataNode) startSpaceManager(cfg *config.Config) (err error) {
 	for _, d := range paths {
 		log.LogDebugf("action[startSpaceManager] load disk raw config(%v).", d)
 
-		// format "PATH:RESET_SIZE
+		// format "PATH:RESET_SIZE[:MEDIA_TYPE]"
 		arr := strings.Split(d, ":")
-		if len(arr) != 2 {
-			return errors.New("Invalid disk configuration. Example: PATH:RESERVE_SIZE")
+		if len(arr) != 2 && len(arr) != 3 {
+			return errors.New("Invalid disk configuration. Example: PATH:RESERVE_SIZE[:MEDIA_TYPE]")
+		}
+		mediaType := defaultMediaType
+		if len(arr) == 3 {
+			if mediaType, err = proto.ParseMediaType(arr[2]); err != nil {
+				return fmt.Errorf("Invalid disk media type. Error: %s", err.Error())
+			}
 		}
 		path := arr[0]
 		fileInfo, err := os.Stat(path)
@@ -452,10 +472,10 @@ func (s *DataNode) startSpaceManager(cfg *config.Config) (err error) {
 		}
 
 		wg.Add(1)
-		go func(wg *sync.WaitGroup, path string, reservedSpace uint64) {
+		go func(wg *sync.WaitGroup, path string, reservedSpace uint64, mediaType uint32) {
 			defer wg.Done()
-			s.space.LoadDisk(path, reservedSpace, diskRdonlySpace, DefaultDiskMaxErr, diskEnableReadRepairExtentLimit)
-		}(&wg, path, reservedSpace)
+			s.space.LoadDisk(path, reservedSpace, diskRdonlySpace, DefaultDiskMaxErr, diskEnableReadRepairExtentLimit, mediaType)
+		}(&wg, path, reservedSpace, mediaType)
 	}
 
 	wg.Wait()
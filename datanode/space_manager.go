@@ -227,7 +227,7 @@ func (manager *SpaceManager) Stats() *Stats {
 }
 
 func (manager *SpaceManager) LoadDisk(path string, reservedSpace, diskRdonlySpace uint64, maxErrCnt int,
-	diskEnableReadRepairExtentLimit bool) (err error) {
+	diskEnableReadRepairExtentLimit bool, mediaType uint32) (err error) {
 	var (
 		disk    *Disk
 		visitor PartitionVisitor
@@ -253,6 +253,7 @@ func (manager *SpaceManager) LoadDisk(path string, reservedSpace, diskRdonlySpac
 			log.LogErrorf("NewDisk fail err:[%v]", err)
 			return
 		}
+		disk.MediaType = mediaType
 		err = disk.RestorePartition(visitor)
 		if err != nil {
 			log.LogErrorf("RestorePartition fail err:[%v]", err)
@@ -317,9 +318,17 @@ func (manager *SpaceManager) updateMetrics() {
 		"partitionCnt(%v) maxCapacityToCreatePartition(%v) ", total, used, available, totalPartitionSize, remainingCapacityToCreatePartition, partitionCnt, maxCapacityToCreatePartition)
 	manager.stats.updateMetrics(total, used, available, totalPartitionSize,
 		remainingCapacityToCreatePartition, maxCapacityToCreatePartition, partitionCnt)
+
+	var effectiveUsed, physicalUsed uint64
+	manager.RangePartitions(func(dp *DataPartition) bool {
+		effectiveUsed += uint64(dp.Used())
+		physicalUsed += uint64(dp.PhysicalUsed())
+		return true
+	})
+	manager.stats.updateMetricPartitionsUsed(effectiveUsed, physicalUsed)
 }
 
-func (manager *SpaceManager) minPartitionCnt(decommissionedDisks []string) (d *Disk) {
+func (manager *SpaceManager) minPartitionCnt(decommissionedDisks []string, mediaType uint32) (d *Disk) {
 	manager.diskMutex.Lock()
 	defer manager.diskMutex.Unlock()
 	var (
@@ -339,6 +348,9 @@ func (manager *SpaceManager) minPartitionCnt(decommissionedDisks []string) (d *D
 		if disk.Status != proto.ReadWrite {
 			continue
 		}
+		if !proto.MatchMediaType(mediaType, disk.MediaType) {
+			continue
+		}
 		diskWeight := disk.getSelectWeight()
 		if diskWeight < minWeight {
 			minWeight = diskWeight
@@ -417,8 +429,11 @@ func (manager *SpaceManager) CreatePartition(request *proto.CreateDataPartitionR
 		}
 		return
 	}
-	disk := manager.minPartitionCnt(request.DecommissionedDisks)
+	disk := manager.minPartitionCnt(request.DecommissionedDisks, request.MediaType)
 	if disk == nil {
+		if request.MediaType != proto.MediaTypeUnspecified {
+			return nil, fmt.Errorf("%v on the %v disks", ErrNoSpaceToCreatePartition, proto.MediaTypeString(request.MediaType))
+		}
 		return nil, ErrNoSpaceToCreatePartition
 	}
 	if dp, err = CreateDataPartition(dpCfg, disk, request); err != nil {
@@ -456,12 +471,16 @@ func (s *DataNode) buildHeartBeatResponse(response *proto.DataNodeHeartbeatRespo
 	response.TotalPartitionSize = stat.TotalPartitionSize
 	response.MaxCapacity = stat.MaxCapacityToCreatePartition
 	response.RemainingCapacity = stat.RemainingCapacityToCreatePartition
+	response.EffectiveUsed = stat.EffectiveUsed
+	response.PhysicalUsed = stat.PhysicalUsed
 	response.BadDisks = make([]string, 0)
 	response.DiskStats = make([]proto.DiskStat, 0)
 	response.StartTime = s.startTime
 	stat.Unlock()
 
 	response.ZoneName = s.zoneName
+	response.RackName = s.rackName
+	response.HostName = s.hostName
 	response.PartitionReports = make([]*proto.DataPartitionReport, 0)
 	space := s.space
 	space.RangePartitions(func(partition *DataPartition) bool {
@@ -472,11 +491,13 @@ func (s *DataNode) buildHeartBeatResponse(response *proto.DataNodeHeartbeatRespo
 			PartitionStatus:            partition.Status(),
 			Total:                      uint64(partition.Size()),
 			Used:                       uint64(partition.Used()),
+			PhysicalUsed:               uint64(partition.PhysicalUsed()),
 			DiskPath:                   partition.Disk().Path,
 			IsLeader:                   isLeader,
 			ExtentCount:                partition.GetExtentCount(),
 			NeedCompare:                true,
 			DecommissionRepairProgress: partition.decommissionRepairProgress,
+			MediaType:                  partition.Disk().MediaType,
 		}
 		log.LogDebugf("action[Heartbeats] dpid(%v), status(%v) total(%v) used(%v) leader(%v) isLeader(%v).", vr.PartitionID, vr.PartitionStatus, vr.Total, vr.Used, leaderAddr, vr.IsLeader)
 		response.PartitionReports = append(response.PartitionReports, vr)
@@ -499,6 +520,8 @@ func (s *DataNode) buildHeartBeatResponse(response *proto.DataNodeHeartbeatRespo
 			TotalPartitionCnt: d.PartitionCount(),
 
 			DiskErrPartitionList: d.GetDiskErrPartitionList(),
+
+			MediaType: d.MediaType,
 		}
 		response.DiskStats = append(response.DiskStats, bds)
 	}
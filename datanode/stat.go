@@ -40,6 +40,8 @@ type Stats struct {
 	CreatedPartitionCnt                uint64
 	LackPartitionsInMem                uint64
 	LackPartitionsInDisk               uint64
+	EffectiveUsed                      uint64 // space used by the partitions before the compression
+	PhysicalUsed                       uint64 // space used by the partitions on the disks
 
 	// the maximum capacity among all the disks that can be used to create partition
 	MaxCapacityToCreatePartition uint64
@@ -84,6 +86,14 @@ func (s *Stats) updateMetrics(
 	s.CreatedPartitionCnt = dataPartitionCnt
 }
 
+func (s *Stats) updateMetricPartitionsUsed(effectiveUsed, physicalUsed uint64) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.EffectiveUsed = effectiveUsed
+	s.PhysicalUsed = physicalUsed
+}
+
 func (s *Stats) updateMetricLackPartitionsInMem(lackPartitionsInMem uint64) {
 	s.Lock()
 	defer s.Unlock()
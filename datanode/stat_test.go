@@ -36,6 +36,13 @@ func TestStats(t *testing.T) {
 	require.Equal(t, maxWeightsForCreatePartition, s.MaxCapacityToCreatePartition, "updateMetrics() error")
 	require.Equal(t, dataPartitionCnt, s.CreatedPartitionCnt, "updateMetrics() error")
 
+	// Test updateMetricPartitionsUsed
+	effectiveUsed := uint64(40)
+	physicalUsed := uint64(25)
+	s.updateMetricPartitionsUsed(effectiveUsed, physicalUsed)
+	require.Equal(t, effectiveUsed, s.EffectiveUsed, "updateMetricPartitionsUsed() error")
+	require.Equal(t, physicalUsed, s.PhysicalUsed, "updateMetricPartitionsUsed() error")
+
 	// Test updateMetricLackPartitionsInMem
 	lackPartitionsInMem := uint64(3)
 	s.updateMetricLackPartitionsInMem(lackPartitionsInMem)
@@ -170,6 +170,8 @@ func (s *DataNode) OperatePacket(p *repl.Packet, c net.Conn) (err error) {
 		s.handlePacketToAddDataPartitionRaftMember(p)
 	case proto.OpRemoveDataPartitionRaftMember:
 		s.handlePacketToRemoveDataPartitionRaftMember(p)
+	case proto.OpPromoteDataPartitionLearner:
+		s.handlePacketToPromoteDataPartitionLearner(p)
 	case proto.OpDataPartitionTryToLeader:
 		s.handlePacketToDataPartitionTryToLeader(p)
 	case proto.OpGetPartitionSize:
@@ -184,6 +186,8 @@ func (s *DataNode) OperatePacket(p *repl.Packet, c net.Conn) (err error) {
 		s.handleUpdateVerPacket(p)
 	case proto.OpStopDataPartitionRepair:
 		s.handlePacketToStopDataPartitionRepair(p)
+	case proto.OpUpdateDataPartitionVolName:
+		s.handlePacketToUpdateDataPartitionVolName(p)
 	default:
 		p.PackErrorBody(repl.ErrorUnknownOp.Error(), repl.ErrorUnknownOp.Error()+strconv.Itoa(int(p.Opcode)))
 	}
@@ -1236,7 +1240,11 @@ func (s *DataNode) handlePacketToDecommissionDataPartition(p *repl.Packet) {
 		return
 	}
 	if req.AddPeer.ID != 0 {
-		_, err = dp.ChangeRaftMember(raftProto.ConfAddNode, raftProto.Peer{ID: req.AddPeer.ID}, reqData)
+		changeType := raftProto.ConfAddNode
+		if req.AddPeer.IsLearner {
+			changeType = raftProto.ConfAddLearner
+		}
+		_, err = dp.ChangeRaftMember(changeType, raftProto.Peer{ID: req.AddPeer.ID}, reqData)
 		if err != nil {
 			return
 		}
@@ -1299,7 +1307,11 @@ func (s *DataNode) handlePacketToAddDataPartitionRaftMember(p *repl.Packet) {
 	log.LogInfof("action[handlePacketToAddDataPartitionRaftMember] before ChangeRaftMember %v which is sync. partition id %v", req.AddPeer, req.PartitionId)
 
 	if req.AddPeer.ID != 0 {
-		_, err = dp.ChangeRaftMember(raftProto.ConfAddNode, raftProto.Peer{ID: req.AddPeer.ID}, reqData)
+		changeType := raftProto.ConfAddNode
+		if req.AddPeer.IsLearner {
+			changeType = raftProto.ConfAddLearner
+		}
+		_, err = dp.ChangeRaftMember(changeType, raftProto.Peer{ID: req.AddPeer.ID}, reqData)
 		if err != nil {
 			return
 		}
@@ -1307,6 +1319,59 @@ func (s *DataNode) handlePacketToAddDataPartitionRaftMember(p *repl.Packet) {
 	log.LogInfof("action[handlePacketToAddDataPartitionRaftMember] after ChangeRaftMember %v, partition id %v", req.AddPeer, &req.PartitionId)
 }
 
+// handlePacketToPromoteDataPartitionLearner turns the learner into a voter once it has caught up with the log.
+func (s *DataNode) handlePacketToPromoteDataPartitionLearner(p *repl.Packet) {
+	var (
+		err          error
+		reqData      []byte
+		isRaftLeader bool
+		req          = &proto.PromoteDataPartitionLearnerRequest{}
+	)
+
+	defer func() {
+		if err != nil {
+			p.PackErrorBody(ActionPromoteDataPartitionLearner, err.Error())
+		} else if isRaftLeader {
+			// the reply of the leader is kept if the request is forwarded to it
+			p.PacketOkReply()
+		}
+	}()
+
+	adminTask := &proto.AdminTask{}
+	decode := json.NewDecoder(bytes.NewBuffer(p.Data))
+	decode.UseNumber()
+	if err = decode.Decode(adminTask); err != nil {
+		return
+	}
+	if reqData, err = json.Marshal(adminTask.Request); err != nil {
+		return
+	}
+	if err = json.Unmarshal(reqData, req); err != nil {
+		return
+	}
+	p.AddMesgLog(string(reqData))
+	dp := s.space.Partition(req.PartitionId)
+	if dp == nil {
+		err = proto.ErrDataPartitionNotExists
+		return
+	}
+	p.PartitionID = req.PartitionId
+	// the leader decides with the raft status, the peers of the local replica may be stale
+	isRaftLeader, err = s.forwardToRaftLeader(dp, p, false)
+	if !isRaftLeader {
+		return
+	}
+	if err = dp.CanPromoteLearner(req.PromotePeer); err != nil {
+		return
+	}
+	if req.ReplacePeer.ID != 0 {
+		_, err = dp.ReplaceRaftMember(raftProto.Peer{ID: req.PromotePeer.ID}, raftProto.Peer{ID: req.ReplacePeer.ID}, reqData)
+	} else {
+		_, err = dp.ChangeRaftMember(raftProto.ConfPromoteLearner, raftProto.Peer{ID: req.PromotePeer.ID}, reqData)
+	}
+	log.LogInfof("action[handlePacketToPromoteDataPartitionLearner] dp(%v) peer(%v) err(%v)", req.PartitionId, req.PromotePeer, err)
+}
+
 func (s *DataNode) handlePacketToRemoveDataPartitionRaftMember(p *repl.Packet) {
 	var (
 		err          error
@@ -1499,3 +1564,45 @@ func (s *DataNode) handlePacketToStopDataPartitionRepair(p *repl.Packet) {
 	dp.StopDecommissionRecover(request.Stop)
 	log.LogInfof("action[handlePacketToStopDataPartitionRepair] %v stop %v success", request.PartitionId, request.Stop)
 }
+
+func (s *DataNode) handlePacketToUpdateDataPartitionVolName(p *repl.Packet) {
+	task := &proto.AdminTask{}
+	err := json.Unmarshal(p.Data, task)
+	defer func() {
+		if err != nil {
+			p.PackErrorBody(ActionUpdateDataPartitionVolName, err.Error())
+		} else {
+			p.PacketOkReply()
+		}
+	}()
+	if err != nil {
+		return
+	}
+	request := &proto.UpdateDataPartitionVolNameRequest{}
+	if task.OpCode != proto.OpUpdateDataPartitionVolName {
+		err = fmt.Errorf("action[handlePacketToUpdateDataPartitionVolName] illegal opcode ")
+		log.LogWarnf("action[handlePacketToUpdateDataPartitionVolName] illegal opcode ")
+		return
+	}
+
+	bytes, _ := json.Marshal(task.Request)
+	p.AddMesgLog(string(bytes))
+	if err = json.Unmarshal(bytes, request); err != nil {
+		return
+	}
+	if request.VolName == "" {
+		err = fmt.Errorf("action[handlePacketToUpdateDataPartitionVolName] dp %v empty vol name", request.PartitionId)
+		return
+	}
+	dp := s.space.Partition(request.PartitionId)
+	if dp == nil {
+		err = proto.ErrDataPartitionNotExists
+		log.LogWarnf("action[handlePacketToUpdateDataPartitionVolName] cannot find dp %v", request.PartitionId)
+		return
+	}
+	if err = dp.updateVolName(request.VolName); err != nil {
+		log.LogErrorf("action[handlePacketToUpdateDataPartitionVolName] dp %v vol %v err %v", request.PartitionId, request.VolName, err)
+		return
+	}
+	log.LogInfof("action[handlePacketToUpdateDataPartitionVolName] dp %v vol name is %v", request.PartitionId, request.VolName)
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cubefs/cubefs/depends/tiglabs/raft"
+	raftProto "github.com/cubefs/cubefs/depends/tiglabs/raft/proto"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/repl"
+	raftstoremock "github.com/cubefs/cubefs/util/mocktest/raftstore"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func newRaftMemberTestNode(ctrl *gomock.Controller) (*DataNode, *raftstoremock.MockPartition) {
+	dp := mockMakeDp("")
+	dp.raftStatus = RaftStatusRunning
+	dp.config.NodeID = 1
+	dp.config.Peers = []proto.Peer{{ID: 1, Addr: "127.0.0.1:17310"}}
+	dp.replicas = []string{"127.0.0.1:17310"}
+	raftPartition := raftstoremock.NewMockPartition(ctrl)
+	raftPartition.EXPECT().LeaderTerm().Return(uint64(1), uint64(1)).AnyTimes()
+	dp.raftPartition = raftPartition
+	space := &SpaceManager{partitions: map[uint64]*DataPartition{dp.partitionID: dp}}
+	return &DataNode{space: space}, raftPartition
+}
+
+func newAdminTaskPacket(t *testing.T, opcode uint8, req interface{}) *repl.Packet {
+	data, err := json.Marshal(&proto.AdminTask{Request: req})
+	require.NoError(t, err)
+	p := repl.NewPacket()
+	p.Opcode = opcode
+	p.Data = data
+	p.Size = uint32(len(data))
+	return p
+}
+
+func TestAddDataPartitionRaftMember(t *testing.T) {
+	for _, isLearner := range []bool{false, true} {
+		ctrl := gomock.NewController(t)
+		s, raftPartition := newRaftMemberTestNode(ctrl)
+		changeType := raftProto.ConfAddNode
+		if isLearner {
+			changeType = raftProto.ConfAddLearner
+		}
+		raftPartition.EXPECT().ChangeMember(changeType, raftProto.Peer{ID: 2}, gomock.Any()).Return(nil, nil).Times(1)
+
+		req := &proto.AddDataPartitionRaftMemberRequest{
+			PartitionId: 1,
+			AddPeer:     proto.Peer{ID: 2, Addr: "127.0.0.1:17320", IsLearner: isLearner},
+		}
+		p := newAdminTaskPacket(t, proto.OpAddDataPartitionRaftMember, req)
+		s.handlePacketToAddDataPartitionRaftMember(p)
+		require.Equal(t, proto.OpOk, p.ResultCode, string(p.Data))
+		ctrl.Finish()
+	}
+}
+
+func TestPromoteDataPartitionLearner(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s, raftPartition := newRaftMemberTestNode(ctrl)
+	status := &raft.Status{NodeID: 1, Leader: 1, Commit: 10, Replicas: map[uint64]*raft.ReplicaStatus{
+		1: {Match: 10},
+	}}
+	raftPartition.EXPECT().Status().Return(status).AnyTimes()
+	// the peers of the local replica do not tell the learner, the leader decides with the raft status
+	req := &proto.PromoteDataPartitionLearnerRequest{
+		PartitionId: 1,
+		PromotePeer: proto.Peer{ID: 2, Addr: "127.0.0.1:17320"},
+	}
+
+	// the learner unknown by the raft is not promoted
+	p := newAdminTaskPacket(t, proto.OpPromoteDataPartitionLearner, req)
+	s.handlePacketToPromoteDataPartitionLearner(p)
+	require.NotEqual(t, proto.OpOk, p.ResultCode)
+
+	status.Replicas[2] = &raft.ReplicaStatus{Match: 10, IsLearner: true}
+	raftPartition.EXPECT().ChangeMember(raftProto.ConfPromoteLearner, raftProto.Peer{ID: 2}, gomock.Any()).Return(nil, nil).Times(1)
+	p = newAdminTaskPacket(t, proto.OpPromoteDataPartitionLearner, req)
+	s.handlePacketToPromoteDataPartitionLearner(p)
+	require.Equal(t, proto.OpOk, p.ResultCode, string(p.Data))
+}
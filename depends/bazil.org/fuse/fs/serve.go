@@ -294,11 +294,60 @@ func nodeAttr(ctx context.Context, n Node, attr *fuse.Attr) error {
 // Other FUSE requests can be handled by implementing methods from the
 // Handle* interfaces. The most common to implement are HandleReader,
 // HandleReadDirer, and HandleWriter.
-//
-// TODO implement methods: Getlk, Setlk, Setlkw
 type Handle interface {
 }
 
+// HandleLocker contains the common operations for all kinds of file
+// locks. See also lock family specific interfaces: HandleFlockLocker,
+// HandlePOSIXLocker.
+type HandleLocker interface {
+	// Lock tries to acquire a lock on a byte range of the node. If a
+	// conflicting lock is already held, returns syscall.EAGAIN.
+	//
+	// LockRequest.LockOwner is a file-unique identifier for this
+	// lock, and will be seen in calls releasing this lock
+	// (UnlockRequest, ReleaseRequest, FlushRequest) and also
+	// in e.g. ReadRequest, WriteRequest.
+	Lock(ctx context.Context, req *fuse.LockRequest) error
+
+	// LockWait acquires a lock on a byte range of the node, waiting
+	// until the lock can be obtained (or context is canceled).
+	LockWait(ctx context.Context, req *fuse.LockWaitRequest) error
+
+	// Unlock releases the lock on a byte range of the node. Locks can
+	// also be released also as a side effect, see HandleFlockLocker
+	// and HandlePOSIXLocker.
+	Unlock(ctx context.Context, req *fuse.UnlockRequest) error
+
+	// QueryLock returns the current state of locks held for the byte
+	// range of the node.
+	//
+	// See QueryLockRequest for details on how to respond.
+	QueryLock(ctx context.Context, req *fuse.QueryLockRequest, resp *fuse.QueryLockResponse) error
+}
+
+// HandleFlockLocker describes locking behavior unique to flock (BSD)
+// locks. See HandleLocker.
+type HandleFlockLocker interface {
+	HandleLocker
+
+	// Flock unlocking can also happen implicitly as part of Release,
+	// in which case Unlock is not called, and Release will have
+	// ReleaseFlags bit ReleaseFlockUnlock set.
+	HandleReleaser
+}
+
+// HandlePOSIXLocker describes locking behavior unique to POSIX (fcntl
+// F_SETLK) locks. See HandleLocker.
+type HandlePOSIXLocker interface {
+	HandleLocker
+
+	// POSIX unlocking can also happen implicitly as part of Flush,
+	// in which case Unlock is not called, and all the locks owned by
+	// FlushRequest.LockOwner should be released.
+	HandleFlusher
+}
+
 type HandleFlusher interface {
 	// Flush is called each time the file or directory is closed.
 	// Because there can be multiple file descriptors referring to a
@@ -350,6 +399,19 @@ type HandleReleaser interface {
 	Release(ctx context.Context, req *fuse.ReleaseRequest) error
 }
 
+type HandleFallocater interface {
+	// Fallocate manipulates the allocated space of the file, see
+	// fallocate(2). The modes not supported should return
+	// syscall.EOPNOTSUPP.
+	Fallocate(ctx context.Context, req *fuse.FallocateRequest) error
+}
+
+type HandleCopyFileRanger interface {
+	// CopyFileRange copies the data of the handle to the handle out,
+	// store the amount of data copied in resp.Size.
+	CopyFileRange(ctx context.Context, req *fuse.CopyFileRangeRequest, out Handle, resp *fuse.CopyFileRangeResponse) error
+}
+
 type Config struct {
 	// Function to send debug log messages to. If nil, use fuse.Debug.
 	// Note that changing this or fuse.Debug may not affect existing
@@ -1929,6 +1991,112 @@ func (c *Server) handleRequest(ctx context.Context, node Node, snode *serveNode,
 		r.Respond()
 		return nil
 
+	case *fuse.FallocateRequest:
+		shandle := c.getHandle(r.Handle)
+		if shandle == nil {
+			return fuse.ESTALE
+		}
+		h, ok := shandle.handle.(HandleFallocater)
+		if !ok {
+			return fuse.ENOSYS
+		}
+		if err := h.Fallocate(ctx, r); err != nil {
+			return err
+		}
+		done(nil)
+		r.Respond()
+		return nil
+
+	case *fuse.CopyFileRangeRequest:
+		shandle := c.getHandle(r.Handle)
+		if shandle == nil {
+			return fuse.ESTALE
+		}
+		outHandle := c.getHandle(r.HandleOut)
+		if outHandle == nil {
+			return fuse.ESTALE
+		}
+		h, ok := shandle.handle.(HandleCopyFileRanger)
+		if !ok {
+			return fuse.ENOSYS
+		}
+		resp := &fuse.CopyFileRangeResponse{}
+		if err := h.CopyFileRange(ctx, r, outHandle.handle, resp); err != nil {
+			return err
+		}
+		done(resp)
+		r.Respond(resp)
+		return nil
+
+	case *fuse.LockRequest:
+		shandle := c.getHandle(r.Handle)
+		if shandle == nil {
+			return fuse.ESTALE
+		}
+		h, ok := shandle.handle.(HandleLocker)
+		if !ok {
+			return fuse.ENOTSUP
+		}
+		if err := h.Lock(ctx, r); err != nil {
+			return err
+		}
+		done(nil)
+		r.Respond()
+		return nil
+
+	case *fuse.LockWaitRequest:
+		shandle := c.getHandle(r.Handle)
+		if shandle == nil {
+			return fuse.ESTALE
+		}
+		h, ok := shandle.handle.(HandleLocker)
+		if !ok {
+			return fuse.ENOTSUP
+		}
+		if err := h.LockWait(ctx, r); err != nil {
+			return err
+		}
+		done(nil)
+		r.Respond()
+		return nil
+
+	case *fuse.UnlockRequest:
+		shandle := c.getHandle(r.Handle)
+		if shandle == nil {
+			return fuse.ESTALE
+		}
+		h, ok := shandle.handle.(HandleLocker)
+		if !ok {
+			return fuse.ENOTSUP
+		}
+		if err := h.Unlock(ctx, r); err != nil {
+			return err
+		}
+		done(nil)
+		r.Respond()
+		return nil
+
+	case *fuse.QueryLockRequest:
+		shandle := c.getHandle(r.Handle)
+		if shandle == nil {
+			return fuse.ESTALE
+		}
+		resp := &fuse.QueryLockResponse{
+			Lock: fuse.FileLock{
+				Type: fuse.LockUnlock,
+			},
+		}
+		h, ok := shandle.handle.(HandleLocker)
+		if !ok {
+			return fuse.ENOTSUP
+		}
+		if err := h.QueryLock(ctx, r, resp); err != nil {
+			return err
+		}
+		done(resp)
+		r.Respond(resp)
+		return nil
+
 	case *fuse.ReleaseRequest:
 		shandle := c.getHandle(r.Handle)
 		if shandle == nil {
@@ -2028,9 +2196,6 @@ func (c *Server) handleRequest(ctx context.Context, node Node, snode *serveNode,
 		/*	case *FsyncdirRequest:
 				return ENOSYS
 
-			case *GetlkRequest, *SetlkRequest, *SetlkwRequest:
-				return ENOSYS
-
 			case *BmapRequest:
 				return ENOSYS
 
@@ -878,7 +878,7 @@ loop:
 			Handle:       HandleID(in.Fh),
 			Flags:        openFlags(in.Flags),
 			ReleaseFlags: ReleaseFlags(in.ReleaseFlags),
-			LockOwner:    in.LockOwner,
+			LockOwner:    LockOwner(in.LockOwner),
 		}
 
 	case opFsync, opFsyncdir:
@@ -981,11 +981,48 @@ loop:
 		}
 
 	case opGetlk:
-		panic("opGetlk")
-	case opSetlk:
-		panic("opSetlk")
-	case opSetlkw:
-		panic("opSetlkw")
+		in := (*lkIn)(m.data())
+		if m.len() < lkInSize(c.proto) {
+			goto corrupt
+		}
+		req = &QueryLockRequest{
+			Header:    m.Header(),
+			Handle:    HandleID(in.Fh),
+			LockOwner: LockOwner(in.Owner),
+			Lock: FileLock{
+				Start: in.Lk.Start,
+				End:   in.Lk.End,
+				Type:  LockType(in.Lk.Type),
+				PID:   int32(in.Lk.Pid),
+			},
+			LockFlags: LockFlags(in.LkFlags),
+		}
+
+	case opSetlk, opSetlkw:
+		in := (*lkIn)(m.data())
+		if m.len() < lkInSize(c.proto) {
+			goto corrupt
+		}
+		tmp := LockRequest{
+			Header:    m.Header(),
+			Handle:    HandleID(in.Fh),
+			LockOwner: LockOwner(in.Owner),
+			Lock: FileLock{
+				Start: in.Lk.Start,
+				End:   in.Lk.End,
+				Type:  LockType(in.Lk.Type),
+				PID:   int32(in.Lk.Pid),
+			},
+			LockFlags: LockFlags(in.LkFlags),
+		}
+		switch {
+		case tmp.Lock.Type == LockUnlock:
+			req = (*UnlockRequest)(&tmp)
+		case m.hdr.Opcode == opSetlkw:
+			req = (*LockWaitRequest)(&tmp)
+		default:
+			req = &tmp
+		}
 
 	case opAccess:
 		in := (*accessIn)(m.data())
@@ -1032,6 +1069,35 @@ loop:
 	case opBmap:
 		panic("opBmap")
 
+	case opFallocate:
+		in := (*fallocateIn)(m.data())
+		if m.len() < unsafe.Sizeof(*in) {
+			goto corrupt
+		}
+		req = &FallocateRequest{
+			Header: m.Header(),
+			Handle: HandleID(in.Fh),
+			Offset: in.Offset,
+			Length: in.Length,
+			Mode:   FallocateFlags(in.Mode),
+		}
+
+	case opCopyFileRange:
+		in := (*copyFileRangeIn)(m.data())
+		if m.len() < unsafe.Sizeof(*in) {
+			goto corrupt
+		}
+		req = &CopyFileRangeRequest{
+			Header:    m.Header(),
+			Handle:    HandleID(in.FhIn),
+			Offset:    in.OffIn,
+			NodeOut:   NodeID(in.NodeIdOut),
+			HandleOut: HandleID(in.FhOut),
+			OffsetOut: in.OffOut,
+			Len:       in.Len,
+			Flags:     in.Flags,
+		}
+
 	case opDestroy:
 		req = &DestroyRequest{
 			Header: m.Header(),
@@ -1825,13 +1891,13 @@ type ReleaseRequest struct {
 	Handle       HandleID
 	Flags        OpenFlags // flags from OpenRequest
 	ReleaseFlags ReleaseFlags
-	LockOwner    uint32
+	LockOwner    LockOwner
 }
 
 var _ = Request(&ReleaseRequest{})
 
 func (r *ReleaseRequest) String() string {
-	return fmt.Sprintf("Release [%s] %v fl=%v rfl=%v owner=%#x", &r.Header, r.Handle, r.Flags, r.ReleaseFlags, r.LockOwner)
+	return fmt.Sprintf("Release [%s] %v fl=%v rfl=%v owner=%v", &r.Header, r.Handle, r.Flags, r.ReleaseFlags, r.LockOwner)
 }
 
 // Respond replies to the request, indicating that the handle has been released.
@@ -2125,6 +2191,156 @@ func (r *FlushRequest) Respond() {
 	r.respond(buf)
 }
 
+// LockOwner is a file-local opaque identifier assigned by the kernel
+// to identify the owner of a particular lock.
+type LockOwner uint64
+
+func (o LockOwner) String() string {
+	if o == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%016x", uint64(o))
+}
+
+// LockType is the type of the lock, which is the same as the l_type of
+// struct flock in fcntl.
+type LockType uint32
+
+const (
+	LockRead   LockType = syscall.F_RDLCK
+	LockWrite  LockType = syscall.F_WRLCK
+	LockUnlock LockType = syscall.F_UNLCK
+)
+
+var lockTypeNames = map[LockType]string{
+	LockRead:   "LockRead",
+	LockWrite:  "LockWrite",
+	LockUnlock: "LockUnlock",
+}
+
+func (l LockType) String() string {
+	s, ok := lockTypeNames[l]
+	if ok {
+		return s
+	}
+	return fmt.Sprintf("LockType(%d)", l)
+}
+
+// FileLock is the range and type of a lock, the End is inclusive.
+type FileLock struct {
+	Start uint64
+	End   uint64
+	Type  LockType
+	PID   int32
+}
+
+func (l FileLock) String() string {
+	return fmt.Sprintf("%v[%d-%d]pid=%d", l.Type, l.Start, l.End, l.PID)
+}
+
+// LockRequest asks to try acquire a byte range lock on a node. The
+// response should be immediate, do not wait to obtain lock.
+//
+// Unlocking can be
+//
+//   - an explicit unlock of the range, which is seen as UnlockRequest
+//   - the ReleaseFlockUnlock flag of the ReleaseRequest for flock locks
+//   - a FlushRequest of the lock owner for POSIX locks
+type LockRequest struct {
+	Header    `json:"-"`
+	Handle    HandleID
+	LockOwner LockOwner
+	Lock      FileLock
+	LockFlags LockFlags
+}
+
+var _ = Request(&LockRequest{})
+
+func (r *LockRequest) String() string {
+	return fmt.Sprintf("Lock [%s] %v owner=%v range=%v fl=%v", &r.Header, r.Handle, r.LockOwner, r.Lock, r.LockFlags)
+}
+
+// Respond replies to the request, indicating that the lock was acquired.
+func (r *LockRequest) Respond() {
+	buf := newBuffer(0)
+	r.respond(buf)
+}
+
+// LockWaitRequest asks to acquire a byte range lock on a node,
+// delaying response until lock can be obtained (or the request is
+// interrupted).
+type LockWaitRequest LockRequest
+
+var _ = Request(&LockWaitRequest{})
+
+func (r *LockWaitRequest) String() string {
+	return fmt.Sprintf("LockWait [%s] %v owner=%v range=%v fl=%v", &r.Header, r.Handle, r.LockOwner, r.Lock, r.LockFlags)
+}
+
+// Respond replies to the request, indicating that the lock was acquired.
+func (r *LockWaitRequest) Respond() {
+	buf := newBuffer(0)
+	r.respond(buf)
+}
+
+// UnlockRequest asks to release a lock on a byte range on a node.
+type UnlockRequest LockRequest
+
+var _ = Request(&UnlockRequest{})
+
+func (r *UnlockRequest) String() string {
+	return fmt.Sprintf("Unlock [%s] %v owner=%v range=%v fl=%v", &r.Header, r.Handle, r.LockOwner, r.Lock, r.LockFlags)
+}
+
+// Respond replies to the request, indicating that the lock was released.
+func (r *UnlockRequest) Respond() {
+	buf := newBuffer(0)
+	r.respond(buf)
+}
+
+// QueryLockRequest queries the lock status.
+//
+// If the lock could be placed, set response Lock.Type to
+// LockUnlock.
+//
+// If there are conflicting locks, the response should describe one of
+// them. For Open File Description locks, set PID to -1.
+type QueryLockRequest struct {
+	Header    `json:"-"`
+	Handle    HandleID
+	LockOwner LockOwner
+	Lock      FileLock
+	LockFlags LockFlags
+}
+
+var _ = Request(&QueryLockRequest{})
+
+func (r *QueryLockRequest) String() string {
+	return fmt.Sprintf("QueryLock [%s] %v owner=%v range=%v fl=%v", &r.Header, r.Handle, r.LockOwner, r.Lock, r.LockFlags)
+}
+
+// Respond replies to the request, describing the conflicting lock if any.
+func (r *QueryLockRequest) Respond(resp *QueryLockResponse) {
+	buf := newBuffer(unsafe.Sizeof(lkOut{}))
+	out := (*lkOut)(buf.alloc(unsafe.Sizeof(lkOut{})))
+	out.Lk = fileLock{
+		Start: resp.Lock.Start,
+		End:   resp.Lock.End,
+		Type:  uint32(resp.Lock.Type),
+		Pid:   uint32(resp.Lock.PID),
+	}
+	r.respond(buf)
+}
+
+// A QueryLockResponse is the response to a QueryLockRequest.
+type QueryLockResponse struct {
+	Lock FileLock
+}
+
+func (r *QueryLockResponse) String() string {
+	return fmt.Sprintf("QueryLock range=%v", r.Lock)
+}
+
 // A RemoveRequest asks to remove a file or directory from the
 // directory r.Node.
 type RemoveRequest struct {
@@ -2335,3 +2551,62 @@ func (r *ExchangeDataRequest) Respond() {
 	buf := newBuffer(0)
 	r.respond(buf)
 }
+
+// A FallocateRequest asks to manipulate the allocated space of a file, see fallocate(2).
+type FallocateRequest struct {
+	Header `json:"-"`
+	Handle HandleID
+	Offset uint64
+	Length uint64
+	Mode   FallocateFlags
+}
+
+var _ = Request(&FallocateRequest{})
+
+func (r *FallocateRequest) String() string {
+	return fmt.Sprintf("Fallocate [%s] %v %d @%d mode=%v", &r.Header, r.Handle, r.Length, r.Offset, r.Mode)
+}
+
+// Respond replies to the request, indicating that the fallocate succeeded.
+func (r *FallocateRequest) Respond() {
+	buf := newBuffer(0)
+	r.respond(buf)
+}
+
+// A CopyFileRangeRequest asks to copy the data of a range of the file to
+// another file, which is identified by NodeOut and HandleOut, see
+// copy_file_range(2).
+type CopyFileRangeRequest struct {
+	Header    `json:"-"`
+	Handle    HandleID
+	Offset    uint64
+	NodeOut   NodeID
+	HandleOut HandleID
+	OffsetOut uint64
+	Len       uint64
+	Flags     uint64
+}
+
+var _ = Request(&CopyFileRangeRequest{})
+
+func (r *CopyFileRangeRequest) String() string {
+	return fmt.Sprintf("CopyFileRange [%s] %v @%d to %v %v @%d len=%d fl=%#x",
+		&r.Header, r.Handle, r.Offset, r.NodeOut, r.HandleOut, r.OffsetOut, r.Len, r.Flags)
+}
+
+// Respond replies to the request with the number of bytes copied.
+func (r *CopyFileRangeRequest) Respond(resp *CopyFileRangeResponse) {
+	buf := newBuffer(unsafe.Sizeof(writeOut{}))
+	out := (*writeOut)(buf.alloc(unsafe.Sizeof(writeOut{})))
+	out.Size = uint32(resp.Size)
+	r.respond(buf)
+}
+
+// A CopyFileRangeResponse replies to a copy_file_range indicating how many bytes were copied.
+type CopyFileRangeResponse struct {
+	Size int
+}
+
+func (r *CopyFileRangeResponse) String() string {
+	return fmt.Sprintf("CopyFileRange %d", r.Size)
+}
@@ -339,7 +339,8 @@ func flagString(f uint32, names []flagName) string {
 type ReleaseFlags uint32
 
 const (
-	ReleaseFlush ReleaseFlags = 1 << 0
+	ReleaseFlush       ReleaseFlags = 1 << 0
+	ReleaseFlockUnlock ReleaseFlags = 1 << 1
 )
 
 func (fl ReleaseFlags) String() string {
@@ -348,6 +349,23 @@ func (fl ReleaseFlags) String() string {
 
 var releaseFlagNames = []flagName{
 	{uint32(ReleaseFlush), "ReleaseFlush"},
+	{uint32(ReleaseFlockUnlock), "ReleaseFlockUnlock"},
+}
+
+// The LockFlags are passed in LockRequest or LockWaitRequest.
+type LockFlags uint32
+
+const (
+	// BSD-style flock lock (not POSIX lock)
+	LockFlock LockFlags = 1 << 0
+)
+
+func (fl LockFlags) String() string {
+	return flagString(uint32(fl), lockFlagNames)
+}
+
+var lockFlagNames = []flagName{
+	{uint32(LockFlock), "LockFlock"},
 }
 
 // Opcodes
@@ -391,6 +409,9 @@ const (
 	opIoctl       = 39 // Linux?
 	opPoll        = 40 // Linux?
 
+	opFallocate     = 43 // Linux
+	opCopyFileRange = 47 // Linux
+
 	// OS X
 	opSetvolname = 61
 	opGetxtimes  = 62
@@ -549,7 +570,7 @@ type releaseIn struct {
 	Fh           uint64
 	Flags        uint32
 	ReleaseFlags uint32
-	LockOwner    uint32
+	LockOwner    uint64
 }
 
 type flushIn struct {
@@ -692,6 +713,49 @@ type lkOut struct {
 	Lk fileLock
 }
 
+type fallocateIn struct {
+	Fh     uint64
+	Offset uint64
+	Length uint64
+	Mode   uint32
+	_      uint32
+}
+
+// The FallocateFlags are passed in FallocateRequest, see fallocate(2).
+type FallocateFlags uint32
+
+const (
+	FallocateKeepSize      FallocateFlags = 0x1
+	FallocatePunchHole     FallocateFlags = 0x2
+	FallocateCollapseRange FallocateFlags = 0x8
+	FallocateZeroRange     FallocateFlags = 0x10
+	FallocateInsertRange   FallocateFlags = 0x20
+	FallocateUnshareRange  FallocateFlags = 0x40
+)
+
+var fallocateFlagNames = []flagName{
+	{uint32(FallocateKeepSize), "FallocateKeepSize"},
+	{uint32(FallocatePunchHole), "FallocatePunchHole"},
+	{uint32(FallocateCollapseRange), "FallocateCollapseRange"},
+	{uint32(FallocateZeroRange), "FallocateZeroRange"},
+	{uint32(FallocateInsertRange), "FallocateInsertRange"},
+	{uint32(FallocateUnshareRange), "FallocateUnshareRange"},
+}
+
+func (fl FallocateFlags) String() string {
+	return flagString(uint32(fl), fallocateFlagNames)
+}
+
+type copyFileRangeIn struct {
+	FhIn      uint64
+	OffIn     uint64
+	NodeIdOut uint64
+	FhOut     uint64
+	OffOut    uint64
+	Len       uint64
+	Flags     uint64
+}
+
 type accessIn struct {
 	Mask uint32
 	_    uint32
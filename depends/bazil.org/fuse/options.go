@@ -236,6 +236,29 @@ func AsyncRead() MountOption {
 	}
 }
 
+// LockingFlock enables flock-based (BSD-style) locking. This is mostly
+// useful for distributed filesystems with global locking. Without
+// this, kernel manages local locking automatically.
+func LockingFlock() MountOption {
+	return func(conf *mountConfig) error {
+		conf.initFlags |= InitFlockLocks
+		return nil
+	}
+}
+
+// LockingPOSIX enables POSIX-style record locking (fcntl). This is
+// mostly useful for distributed filesystems with global locking.
+// Without this, kernel manages local locking automatically.
+//
+// Beware POSIX locks are a broken API with unintuitive behavior for
+// callers.
+func LockingPOSIX() MountOption {
+	return func(conf *mountConfig) error {
+		conf.initFlags |= InitPosixLocks
+		return nil
+	}
+}
+
 // WritebackCache enables the kernel to buffer writes before sending
 // them to the FUSE server. Without this, writethrough caching is
 // used.
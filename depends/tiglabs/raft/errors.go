@@ -27,6 +27,7 @@ var (
 	ErrStopped       = errors.New("raft is already shutdown.")
 	ErrSnapping      = errors.New("raft is doing snapshot.")
 	ErrRetryLater    = errors.New("retry later")
+	ErrNotReplaced   = errors.New("raft member is not replaced.")
 )
 
 type FatalError struct {
@@ -46,6 +46,7 @@ func (f *poolFactory) getApply() *apply {
 	a.command = nil
 	a.future = nil
 	a.readIndexes = nil
+	a.err = nil
 	return a
 }
 
@@ -62,11 +62,19 @@ func (c *HardState) Size() uint64 {
 
 // ConfChange codec
 func (c *ConfChange) Encode() []byte {
-	datas := make([]byte, 1+peer_size+uint64(len(c.Context)))
+	// the peer to remove follows the peer to add for ConfReplaceNode
+	header := 1 + peer_size
+	if c.Type == ConfReplaceNode {
+		header += peer_size
+	}
+	datas := make([]byte, header+uint64(len(c.Context)))
 	datas[0] = byte(c.Type)
 	c.Peer.Encode(datas[1:])
+	if c.Type == ConfReplaceNode {
+		c.RemovePeer.Encode(datas[peer_size+1:])
+	}
 	if len(c.Context) > 0 {
-		copy(datas[peer_size+1:], c.Context)
+		copy(datas[header:], c.Context)
 	}
 	return datas
 }
@@ -74,14 +82,23 @@ func (c *ConfChange) Encode() []byte {
 func (c *ConfChange) Decode(datas []byte) {
 	c.Type = ConfChangeType(datas[0])
 	c.Peer.Decode(datas[1:])
-	if uint64(len(datas)) > peer_size+1 {
-		c.Context = append([]byte{}, datas[peer_size+1:]...)
+	header := 1 + peer_size
+	if c.Type == ConfReplaceNode {
+		c.RemovePeer.Decode(datas[header:])
+		header += peer_size
+	}
+	if uint64(len(datas)) > header {
+		c.Context = append([]byte{}, datas[header:]...)
 	}
 }
 
 // SnapshotMeta codec
 func (m *SnapshotMeta) Size() uint64 {
-	return snapmeta_header + peer_size*uint64(len(m.Peers))
+	size := snapmeta_header + peer_size*uint64(len(m.Peers))
+	if m.Resumable {
+		size += 1
+	}
+	return size
 }
 
 func (m *SnapshotMeta) Encode(w io.Writer) error {
@@ -101,6 +118,12 @@ func (m *SnapshotMeta) Encode(w io.Writer) error {
 			return err
 		}
 	}
+	// the flag is appended, so it is ignored by the old versions
+	if m.Resumable {
+		if _, err := w.Write([]byte{1}); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -114,6 +137,7 @@ func (m *SnapshotMeta) Decode(datas []byte) {
 		m.Peers[i].Decode(datas[start:])
 		start = start + peer_size
 	}
+	m.Resumable = uint64(len(datas)) > start && datas[start] == 1
 }
 
 // Entry codec
@@ -48,12 +48,24 @@ const (
 	ConfAddNode    ConfChangeType = 0
 	ConfRemoveNode ConfChangeType = 1
 	ConfUpdateNode ConfChangeType = 2
+	// ConfAddLearner adds a learner which receives the log without voting.
+	ConfAddLearner ConfChangeType = 3
+	// ConfPromoteLearner turns a learner into a voter.
+	ConfPromoteLearner ConfChangeType = 4
+	// ConfReplaceNode enters a joint consensus which replaces RemovePeer with Peer,
+	// the joint consensus is left by removing RemovePeer.
+	ConfReplaceNode ConfChangeType = 5
 
 	EntryNormal     EntryType = 0
 	EntryConfChange EntryType = 1
 
 	PeerNormal  PeerType = 0
 	PeerArbiter PeerType = 1
+	PeerLearner PeerType = 2
+	// PeerIncoming votes in the new configuration of a joint consensus only.
+	PeerIncoming PeerType = 3
+	// PeerOutgoing votes in the old configuration of a joint consensus only.
+	PeerOutgoing PeerType = 4
 )
 
 // The Snapshot interface is supplied by the application to access the snapshot data of application.
@@ -68,10 +80,29 @@ type SnapIterator interface {
 	Next() ([]byte, error)
 }
 
+// The ResumableSnapshot interface is supplied by the application whose snapshot can be sent from a
+// block, the follower failed to apply the snapshot asks for the blocks it has not applied.
+type ResumableSnapshot interface {
+	Snapshot
+	// Skip skips the first n blocks of the snapshot.
+	Skip(n uint64) error
+}
+
+// ResumableSnapIterator is the SnapIterator of the snapshot sent by a ResumableSnapshot.
+type ResumableSnapIterator interface {
+	SnapIterator
+	// Source returns the leader sending the snapshot and its meta, the snapshots of the same source
+	// have the same blocks. ok is false if the snapshot can't be resumed.
+	Source() (from uint64, meta SnapshotMeta, ok bool)
+	// Resume asks the leader to send the snapshot from the block, it must be called before Next.
+	Resume(block uint64) error
+}
+
 type SnapshotMeta struct {
-	Index uint64
-	Term  uint64
-	Peers []Peer
+	Index     uint64
+	Term      uint64
+	Peers     []Peer
+	Resumable bool // whether the leader sends the snapshot from the block asked by the follower
 }
 
 type Peer struct {
@@ -115,6 +146,12 @@ type Message struct {
 	Snapshot     Snapshot // No need for codec
 }
 
+// The features supported by the node, they are carried by RejectHint of the heartbeat responses which
+// is not used otherwise, so the nodes of the earlier versions support none.
+const (
+	FeatureResumableSnapshot uint64 = 1 << iota
+)
+
 func (m *Message) ToString() (mesg string) {
 	return fmt.Sprintf("Mesg:[%v] type(%v) ForceVote(%v) Reject(%v) RejectHint(%v) "+
 		"From(%v) To(%v) Term(%v) LogTrem(%v) Index(%v) Commit(%v)", m.ID, m.Type.String(), m.ForceVote,
@@ -122,9 +159,10 @@ func (m *Message) ToString() (mesg string) {
 }
 
 type ConfChange struct {
-	Type    ConfChangeType
-	Peer    Peer
-	Context []byte
+	Type       ConfChangeType
+	Peer       Peer
+	RemovePeer Peer // the peer replaced by Peer, only for ConfReplaceNode
+	Context    []byte
 }
 
 type HeartbeatContext []uint64
@@ -185,6 +223,12 @@ func (t ConfChangeType) String() string {
 		return "ConfRemoveNode"
 	case 2:
 		return "ConfUpdateNode"
+	case 3:
+		return "ConfAddLearner"
+	case 4:
+		return "ConfPromoteLearner"
+	case 5:
+		return "ConfReplaceNode"
 	}
 	return "unknown"
 }
@@ -195,16 +239,40 @@ func (t PeerType) String() string {
 		return "PeerNormal"
 	case 1:
 		return "PeerArbiter"
+	case 2:
+		return "PeerLearner"
+	case 3:
+		return "PeerIncoming"
+	case 4:
+		return "PeerOutgoing"
 	}
 	return "unknown"
 }
 
+// IsLearner tells whether the peer receives the log without voting.
+func (p Peer) IsLearner() bool {
+	return p.Type == PeerLearner
+}
+
+// VotesInOld tells whether the peer votes in the configuration before the joint consensus.
+func (p Peer) VotesInOld() bool {
+	return p.Type != PeerLearner && p.Type != PeerIncoming
+}
+
+// VotesInNew tells whether the peer votes in the configuration after the joint consensus.
+func (p Peer) VotesInNew() bool {
+	return p.Type != PeerLearner && p.Type != PeerOutgoing
+}
+
 func (p Peer) String() string {
 	return fmt.Sprintf(`"nodeID":"%v","peerID":"%v","priority":"%v","type":"%v"`,
 		p.ID, p.PeerID, p.Priority, p.Type.String())
 }
 
 func (cc *ConfChange) String() string {
+	if cc.Type == ConfReplaceNode {
+		return fmt.Sprintf(`{"type":"%v",%v,"remove":{%v}}`, cc.Type, cc.Peer.String(), cc.RemovePeer.String())
+	}
 	return fmt.Sprintf(`{"type":"%v",%v}`, cc.Type, cc.Peer.String())
 }
 
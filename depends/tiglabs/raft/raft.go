@@ -39,6 +39,7 @@ type apply struct {
 	future      *Future
 	command     interface{}
 	readIndexes []*Future
+	err         error
 }
 
 // handle user's get log entries request
@@ -70,13 +71,52 @@ func (s *peerState) change(c *proto.ConfChange) {
 	case proto.ConfAddNode:
 		s.peers[c.Peer.ID] = c.Peer
 	case proto.ConfRemoveNode:
-		delete(s.peers, c.Peer.ID)
+		if p, ok := s.peers[c.Peer.ID]; ok {
+			delete(s.peers, c.Peer.ID)
+			s.leaveJoint(p)
+		}
 	case proto.ConfUpdateNode:
 		s.peers[c.Peer.ID] = c.Peer
+	case proto.ConfAddLearner:
+		p := c.Peer
+		p.Type = proto.PeerLearner
+		s.peers[c.Peer.ID] = p
+	case proto.ConfPromoteLearner:
+		if p, ok := s.peers[c.Peer.ID]; ok {
+			p.Type = proto.PeerNormal
+			s.peers[c.Peer.ID] = p
+		}
+	case proto.ConfReplaceNode:
+		if p, ok := s.peers[c.RemovePeer.ID]; ok {
+			p.Type = proto.PeerOutgoing
+			s.peers[p.ID] = p
+			p = c.Peer
+			p.Type = proto.PeerIncoming
+			s.peers[p.ID] = p
+		}
 	}
 	s.mu.Unlock()
 }
 
+// leaveJoint follows the raftFsm once the outgoing or the incoming peer is removed.
+func (s *peerState) leaveJoint(removed proto.Peer) {
+	var from proto.PeerType
+	switch removed.Type {
+	case proto.PeerOutgoing:
+		from = proto.PeerIncoming
+	case proto.PeerIncoming:
+		from = proto.PeerOutgoing
+	default:
+		return
+	}
+	for id, p := range s.peers {
+		if p.Type == from {
+			p.Type = proto.PeerNormal
+			s.peers[id] = p
+		}
+	}
+}
+
 func (s *peerState) replace(peers []proto.Peer) {
 	s.mu.Lock()
 	s.peers = nil
@@ -227,6 +267,11 @@ func (s *raft) runApply() {
 			)
 			switch cmd := apply.command.(type) {
 			case *proto.ConfChange:
+				// the replace ignored by the raft is not applied to the state machine
+				if apply.err != nil {
+					err = apply.err
+					break
+				}
 				resp, err = s.raftConfig.StateMachine.ApplyMemberChange(cmd, apply.index)
 				if cmd.Type == proto.ConfRemoveNode && err == nil {
 					s.raftFsm.mo.RemovePeer(s.raftFsm.id, cmd.Peer)
@@ -663,7 +708,11 @@ func (s *raft) apply() {
 				}
 			}
 
-			s.peerState.change(cc)
+			if cc.Type != proto.ConfReplaceNode || worked {
+				s.peerState.change(cc)
+			} else {
+				apply.err = ErrNotReplaced
+			}
 			if logger.IsEnableWarn() {
 				logger.Warn("raft[%v] applying configuration change %v.", s.raftFsm.id, cc)
 			}
@@ -768,6 +817,7 @@ func (s *raft) getStatus() *Status {
 				Active:      p.active,
 				LastActive:  p.lastActive,
 				Inflight:    p.count,
+				IsLearner:   p.peer.IsLearner(),
 			}
 		}
 	}
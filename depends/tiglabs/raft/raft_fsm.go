@@ -416,6 +416,14 @@ func (r *raftFsm) recoverCommit() error {
 			case proto.EntryConfChange:
 				cc := new(proto.ConfChange)
 				cc.Decode(entry.Data)
+				if cc.Type == proto.ConfReplaceNode {
+					if r.applyConfChange(cc) {
+						if _, err := r.sm.ApplyMemberChange(cc, entry.Index); err != nil {
+							return err
+						}
+					}
+					continue
+				}
 				if _, err := r.sm.ApplyMemberChange(cc, entry.Index); err != nil {
 					return err
 				}
@@ -442,6 +450,14 @@ func (r *raftFsm) applyConfChange(cc *proto.ConfChange) (ok bool) {
 		return r.removePeer(cc.Peer)
 	case proto.ConfUpdateNode:
 		r.updatePeer(cc.Peer)
+	case proto.ConfAddLearner:
+		peer := cc.Peer
+		peer.Type = proto.PeerLearner
+		r.addPeer(peer)
+	case proto.ConfPromoteLearner:
+		r.promoteLearner(cc.Peer)
+	case proto.ConfReplaceNode:
+		return r.replacePeer(cc.Peer, cc.RemovePeer)
 	}
 	return
 }
@@ -473,6 +489,7 @@ func (r *raftFsm) removePeer(peer proto.Peer) (ok bool) {
 
 	delete(r.replicas, peer.ID)
 	ok = true
+	r.maybeLeaveJoint(replica.peer)
 
 	if peer.ID == r.config.NodeID {
 		r.becomeFollower(r.term, NoLeader)
@@ -491,8 +508,158 @@ func (r *raftFsm) updatePeer(peer proto.Peer) {
 	}
 }
 
+// promoteLearner turns the learner into a voter, the quorum grows with it.
+func (r *raftFsm) promoteLearner(peer proto.Peer) {
+	r.pendingConf = false
+	replica, ok := r.replicas[peer.ID]
+	if !ok || !replica.peer.IsLearner() {
+		if logger.IsEnableInfo() {
+			logger.Info("raft[%v] ignore promote peer[%v], not a learner", r.id, peer.String())
+		}
+		return
+	}
+	replica.peer.Type = proto.PeerNormal
+}
+
+// replacePeer enters the joint consensus, in which the commit and the election need the majority
+// of both the old and the new configuration. The new peer may be added as a learner before.
+func (r *raftFsm) replacePeer(peer, removePeer proto.Peer) (ok bool) {
+	r.pendingConf = false
+	old, exist := r.replicas[removePeer.ID]
+	// the replacement in progress is proposed again by the retry
+	if exist && old.peer.PeerID == removePeer.PeerID && old.peer.Type == proto.PeerOutgoing {
+		if replica, ok := r.replicas[peer.ID]; ok && replica.peer.Type == proto.PeerIncoming {
+			return true
+		}
+	}
+	if !exist || old.peer.PeerID != removePeer.PeerID || old.peer.Type != proto.PeerNormal || r.inJoint() {
+		if logger.IsEnableInfo() {
+			logger.Info("raft[%v] ignore replace peer[%v] with peer[%v]", r.id, removePeer.String(), peer.String())
+		}
+		return
+	}
+	if replica, exist := r.replicas[peer.ID]; exist {
+		if !replica.peer.IsLearner() {
+			if logger.IsEnableInfo() {
+				logger.Info("raft[%v] ignore replace peer[%v] with voter[%v]", r.id, removePeer.String(), peer.String())
+			}
+			return
+		}
+		replica.peer.Type = proto.PeerIncoming
+	} else {
+		peer.Type = proto.PeerIncoming
+		r.addPeer(peer)
+	}
+	old.peer.Type = proto.PeerOutgoing
+	return true
+}
+
+// maybeLeaveJoint leaves the joint consensus once the outgoing or the incoming peer is removed,
+// the latter one aborts the replacement.
+func (r *raftFsm) maybeLeaveJoint(removed proto.Peer) {
+	var from proto.PeerType
+	switch removed.Type {
+	case proto.PeerOutgoing:
+		from = proto.PeerIncoming
+	case proto.PeerIncoming:
+		from = proto.PeerOutgoing
+	default:
+		return
+	}
+	for _, replica := range r.replicas {
+		if replica.peer.Type == from {
+			replica.peer.Type = proto.PeerNormal
+		}
+	}
+}
+
+func (r *raftFsm) inJoint() bool {
+	for _, replica := range r.replicas {
+		if replica.peer.Type == proto.PeerIncoming || replica.peer.Type == proto.PeerOutgoing {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *raftFsm) isLearner(id uint64) bool {
+	replica, ok := r.replicas[id]
+	return ok && replica.peer.IsLearner()
+}
+
+// voters returns the number of the replicas which vote, the learners are excluded.
+func (r *raftFsm) voters() (n int) {
+	for _, replica := range r.replicas {
+		if !replica.peer.IsLearner() {
+			n++
+		}
+	}
+	return
+}
+
 func (r *raftFsm) quorum() int {
-	return len(r.replicas)/2 + 1
+	return r.voters()/2 + 1
+}
+
+// jointConfig returns the voters of the old and the new configuration, which are the same
+// out of the joint consensus.
+func (r *raftFsm) jointConfig() (olds, news []uint64) {
+	olds = make([]uint64, 0, len(r.replicas))
+	news = make([]uint64, 0, len(r.replicas))
+	for id, replica := range r.replicas {
+		if replica.peer.VotesInOld() {
+			olds = append(olds, id)
+		}
+		if replica.peer.VotesInNew() {
+			news = append(news, id)
+		}
+	}
+	return
+}
+
+// hasQuorum tells whether the replicas accepted are the majority of both the configurations.
+func (r *raftFsm) hasQuorum(accepted func(id uint64) bool) bool {
+	olds, news := r.jointConfig()
+	return isMajority(olds, accepted) && isMajority(news, accepted)
+}
+
+func isMajority(voters []uint64, accepted func(id uint64) bool) bool {
+	n := 0
+	for _, id := range voters {
+		if accepted(id) {
+			n++
+		}
+	}
+	return n >= len(voters)/2+1
+}
+
+type voteResult int
+
+const (
+	votePending voteResult = iota
+	voteWon
+	voteLost
+)
+
+// voteResult tells whether the election is won by the majority of both the configurations,
+// or lost as the rejections leave no majority in either of them.
+func (r *raftFsm) voteResult() voteResult {
+	olds, news := r.jointConfig()
+	granted := func(id uint64) bool {
+		v, ok := r.votes[id]
+		return ok && v
+	}
+	notRejected := func(id uint64) bool {
+		v, ok := r.votes[id]
+		return !ok || v
+	}
+	if isMajority(olds, granted) && isMajority(news, granted) {
+		return voteWon
+	}
+	if !isMajority(olds, notRejected) || !isMajority(news, notRejected) {
+		return voteLost
+	}
+	return votePending
 }
 
 func (r *raftFsm) send(m *proto.Message) {
@@ -84,11 +84,11 @@ func stepCandidate(r *raftFsm, m *proto.Message) {
 		if logger.IsEnableDebug() {
 			logger.Debug("raft[%v] [q:%d] has received %d votes and %d vote rejections.", r.id, r.quorum(), gr, len(r.votes)-gr)
 		}
-		switch r.quorum() {
-		case gr:
+		switch r.voteResult() {
+		case voteWon:
 			r.becomeLeader()
 			r.bcastAppend()
-		case len(r.votes) - gr:
+		case voteLost:
 			r.becomeFollower(r.term, NoLeader)
 		}
 	}
@@ -106,7 +106,7 @@ func (r *raftFsm) campaign(force bool, t CampaignType) {
 		msgType = proto.ReqMsgVote
 	}
 
-	if r.quorum() == r.poll(r.config.NodeID, true) {
+	if r.poll(r.config.NodeID, true); r.voteResult() == voteWon {
 		if t == campaignPreElection {
 			r.campaign(force, campaignElection)
 		} else {
@@ -115,8 +115,8 @@ func (r *raftFsm) campaign(force bool, t CampaignType) {
 		return
 	}
 
-	for id := range r.replicas {
-		if id == r.config.NodeID {
+	for id, pr := range r.replicas {
+		if id == r.config.NodeID || pr.peer.IsLearner() {
 			continue
 		}
 		li, lt := r.raftLog.lastIndexAndTerm()
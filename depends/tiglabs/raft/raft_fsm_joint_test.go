@@ -0,0 +1,117 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cubefs/cubefs/depends/tiglabs/raft/proto"
+)
+
+// newJointNetwork returns a network of 4 nodes in which node 4 replaces node 3 through a joint consensus.
+func newJointNetwork() *network {
+	nt := newNetwork(nil, nil, nil, nil)
+	for _, p := range nt.peers {
+		p.(*raftFsm).replicas[4].peer.Type = proto.PeerLearner
+	}
+	nt.send(proto.Message{From: 1, To: 1, Type: proto.LocalMsgHup})
+	cc := &proto.ConfChange{Type: proto.ConfReplaceNode, Peer: proto.Peer{ID: 4, PeerID: 4}, RemovePeer: proto.Peer{ID: 3, PeerID: 3}}
+	for _, p := range nt.peers {
+		p.(*raftFsm).applyConfChange(cc)
+	}
+	return nt
+}
+
+func TestReplaceNodeCodec(t *testing.T) {
+	cc := &proto.ConfChange{
+		Type:       proto.ConfReplaceNode,
+		Peer:       proto.Peer{ID: 4, Priority: 1},
+		RemovePeer: proto.Peer{ID: 3},
+		Context:    []byte("context"),
+	}
+	decoded := new(proto.ConfChange)
+	decoded.Decode(cc.Encode())
+	if decoded.Type != cc.Type || decoded.Peer != cc.Peer || decoded.RemovePeer != cc.RemovePeer ||
+		!bytes.Equal(decoded.Context, cc.Context) {
+		t.Fatalf("decoded = %v, want %v", decoded, cc)
+	}
+}
+
+func TestReplaceNodeCommitNeedsBothConfigs(t *testing.T) {
+	nt := newJointNetwork()
+	leader := nt.peers[1].(*raftFsm)
+	if leader.state != stateLeader || !leader.inJoint() {
+		t.Fatalf("state = %s joint = %v, want leader in joint", leader.state, leader.inJoint())
+	}
+	committed := leader.raftLog.committed
+
+	// node 1 and 3 are the majority of the old configuration only
+	nt.isolate(2)
+	nt.isolate(4)
+	nt.send(proto.Message{From: 1, To: 1, Type: proto.LocalMsgProp, Entries: []*proto.Entry{{Index: 2, Term: 1, Data: []byte("some data")}}})
+	if leader.raftLog.committed != committed {
+		t.Fatalf("committed = %d, want %d", leader.raftLog.committed, committed)
+	}
+
+	// node 1, 3 and 4 are the majority of both the configurations
+	nt.recover()
+	nt.isolate(2)
+	nt.send(proto.Message{From: 1, To: 1, Type: proto.LocalMsgProp, Entries: []*proto.Entry{{Index: 3, Term: 1, Data: []byte("some data")}}})
+	if leader.raftLog.committed != leader.raftLog.lastIndex() {
+		t.Fatalf("committed = %d, want %d", leader.raftLog.committed, leader.raftLog.lastIndex())
+	}
+}
+
+func TestReplaceNodeVoteNeedsBothConfigs(t *testing.T) {
+	nt := newJointNetwork()
+	r := nt.peers[1].(*raftFsm)
+	r.becomeFollower(r.term, NoLeader)
+	r.becomeCandidate()
+
+	r.poll(1, true)
+	r.poll(3, true)
+	if res := r.voteResult(); res != votePending {
+		t.Fatalf("vote result = %v, want %v", res, votePending)
+	}
+	r.poll(4, false)
+	r.poll(2, false)
+	if res := r.voteResult(); res != voteLost {
+		t.Fatalf("vote result = %v, want %v", res, voteLost)
+	}
+}
+
+func TestRemoveOutgoingLeavesJoint(t *testing.T) {
+	nt := newJointNetwork()
+	leader := nt.peers[1].(*raftFsm)
+	if !leader.replicas[4].peer.VotesInNew() || leader.replicas[4].peer.VotesInOld() {
+		t.Fatalf("node 4 type = %v, want %v", leader.replicas[4].peer.Type, proto.PeerIncoming)
+	}
+	// the group in the joint consensus can not be replaced again
+	if leader.replacePeer(proto.Peer{ID: 5, PeerID: 5}, proto.Peer{ID: 2, PeerID: 2}) {
+		t.Fatalf("replace in the joint consensus succeeded")
+	}
+
+	leader.applyConfChange(&proto.ConfChange{Type: proto.ConfRemoveNode, Peer: proto.Peer{ID: 3, PeerID: 3}})
+	if leader.inJoint() {
+		t.Fatalf("joint = true, want false")
+	}
+	if _, ok := leader.replicas[3]; ok {
+		t.Fatalf("node 3 is not removed")
+	}
+	if q := leader.quorum(); q != 2 {
+		t.Fatalf("quorum = %d, want 2", q)
+	}
+}
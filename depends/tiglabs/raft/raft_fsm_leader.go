@@ -256,6 +256,7 @@ func stepLeader(r *raftFsm, m *proto.Message) {
 
 		pr.active = true
 		pr.lastActive = time.Now()
+		pr.resumable = m.RejectHint&proto.FeatureResumableSnapshot != 0
 		if pr.state != replicaStateSnapshot {
 			pr.pending = false
 		}
@@ -307,7 +308,7 @@ func stepLeader(r *raftFsm, m *proto.Message) {
 		if logger.IsEnableDebug() {
 			logger.Debug("raft[%d] recv check quorum resp from %d, index=%d", r.id, m.From, m.Index)
 		}
-		r.readOnly.recvAck(m.Index, m.From, r.quorum())
+		r.readOnly.recvAck(m.Index, m.From, r.readIndexQuorum)
 		proto.ReturnMessage(m)
 		return
 	}
@@ -363,7 +364,7 @@ func stepPreCandidate(r *raftFsm, m *proto.Message) {
 		if logger.IsEnableDebug() {
 			logger.Debug("raft[%d] recv check quorum resp from %d, index=%d", r.id, m.From, m.Index)
 		}
-		r.readOnly.recvAck(m.Index, m.From, r.quorum())
+		r.readOnly.recvAck(m.Index, m.From, r.readIndexQuorum)
 		proto.ReturnMessage(m)
 		return
 
@@ -381,10 +382,10 @@ func stepPreCandidate(r *raftFsm, m *proto.Message) {
 		if logger.IsEnableDebug() {
 			logger.Debug("raft[%v] [q:%d] stepPreCandidate has received %d votes and %d vote rejections.", r.id, r.quorum(), gr, len(r.votes)-gr)
 		}
-		switch r.quorum() {
-		case gr:
+		switch r.voteResult() {
+		case voteWon:
 			r.campaign(false, campaignElection)
-		case len(r.votes) - gr:
+		case voteLost:
 			r.becomeFollower(r.term, NoLeader)
 		}
 		return
@@ -436,31 +437,37 @@ func (r *raftFsm) tickElectionAck() {
 }
 
 func (r *raftFsm) checkLeaderLease() bool {
-	var act int
+	act := make(map[uint64]bool, len(r.replicas))
 	for id, peer := range r.replicas {
+		if peer.peer.IsLearner() {
+			continue
+		}
 		if id == r.config.NodeID || peer.state == replicaStateSnapshot {
-			act++
+			act[id] = true
 			continue
 		}
 
 		if peer.active {
 			peer.active = false
-			act++
+			act[id] = true
 		} else {
 			r.monitorZombie(peer)
 		}
 	}
 
-	return act >= r.quorum()
+	return r.hasQuorum(func(id uint64) bool { return act[id] })
 }
 
 func (r *raftFsm) maybeCommit() bool {
-	mis := make(util.Uint64Slice, 0, len(r.replicas))
-	for _, rp := range r.replicas {
-		mis = append(mis, rp.match)
+	// the learners don't count in the quorum, and the joint consensus needs both the configurations
+	olds, news := r.jointConfig()
+	if len(olds) == 0 || len(news) == 0 {
+		return false
+	}
+	mci := r.quorumMatch(olds)
+	if nmci := r.quorumMatch(news); nmci < mci {
+		mci = nmci
 	}
-	sort.Sort(sort.Reverse(mis))
-	mci := mis[r.quorum()-1]
 	isCommit := r.raftLog.maybeCommit(mci, r.term)
 	if r.state == stateLeader && r.replicas[r.config.NodeID] != nil {
 		r.replicas[r.config.NodeID].committed = r.raftLog.committed
@@ -476,6 +483,27 @@ func (r *raftFsm) maybeCommit() bool {
 	return isCommit
 }
 
+// quorumMatch returns the largest index matched by the majority of the voters.
+func (r *raftFsm) quorumMatch(voters []uint64) uint64 {
+	if len(voters) == 0 {
+		return 0
+	}
+	mis := make(util.Uint64Slice, 0, len(voters))
+	for _, id := range voters {
+		mis = append(mis, r.replicas[id].match)
+	}
+	sort.Sort(sort.Reverse(mis))
+	return mis[len(voters)/2]
+}
+
+// readIndexQuorum tells whether the read index is acked by the quorum, including the local node.
+func (r *raftFsm) readIndexQuorum(acks map[uint64]struct{}) bool {
+	return r.hasQuorum(func(id uint64) bool {
+		_, ok := acks[id]
+		return ok || id == r.config.NodeID
+	})
+}
+
 func (r *raftFsm) bcastAppend() {
 	for id := range r.replicas {
 		if id == r.config.NodeID {
@@ -528,6 +556,9 @@ func (r *raftFsm) sendAppend(to uint64) {
 		for _, p := range r.replicas {
 			snapMeta.Peers = append(snapMeta.Peers, p.peer)
 		}
+		// the follower of an earlier version never asks for the block to send from
+		_, resumable := snapshot.(proto.ResumableSnapshot)
+		snapMeta.Resumable = resumable && pr.resumable
 		m.SnapshotMeta = snapMeta
 		pr.becomeSnapshot(snapMeta.Index)
 
@@ -694,6 +694,49 @@ func TestProvideSnap(t *testing.T) {
 	}
 }
 
+type resumableTestSnapshot struct {
+	testSnapshot
+}
+
+func (ts *resumableTestSnapshot) Skip(n uint64) error { return nil }
+
+type resumableTestStateMachine struct {
+	testFsmStateMachine
+}
+
+func (sm *resumableTestStateMachine) Snapshot() (proto.Snapshot, error) {
+	return &resumableTestSnapshot{testSnapshot{applyIndex: sm.applyIndex}}, nil
+}
+
+func TestProvideResumableSnap(t *testing.T) {
+	// the snapshot is resumable only if the follower tells it supports by the heartbeat response
+	for _, features := range []uint64{0, proto.FeatureResumableSnapshot} {
+		peers := []proto.Peer{{ID: 1, PeerID: 1}, {ID: 2, PeerID: 2}}
+		meta := proto.SnapshotMeta{Index: 11, Term: 11, Peers: peers}
+		s := stor.DefaultMemoryStorage()
+		s.ApplySnapshot(meta)
+		cfg := newTestRaftConfig(1, withStorage(s), withPeers(1))
+		sm := newTestRaftFsm(5, 1, cfg)
+		sm.restore(meta)
+		sm.sm = &resumableTestStateMachine{testFsmStateMachine{applyIndex: 13}}
+		sm.becomeCandidate()
+		sm.becomeLeader()
+		sm.readMessages()
+
+		sm.Step(&proto.Message{From: 2, To: 1, Type: proto.RespMsgHeartBeat, RejectHint: features})
+		sm.readMessages()
+		sm.replicas[2].next = sm.raftLog.firstIndex()
+		sm.Step(&proto.Message{From: 2, To: 1, Type: proto.RespMsgAppend, Index: sm.replicas[2].next - 1, Reject: true})
+		msgs := sm.readMessages()
+		if len(msgs) != 1 || msgs[0].Type != proto.ReqMsgSnapShot {
+			t.Fatalf("msgs = %v, want a snapshot", msgs)
+		}
+		if resumable := features != 0; msgs[0].SnapshotMeta.Resumable != resumable {
+			t.Errorf("features %v: resumable = %v, want %v", features, msgs[0].SnapshotMeta.Resumable, resumable)
+		}
+	}
+}
+
 func TestIgnoreProvidingSnap(t *testing.T) {
 	// restore the state machine from a snapshot so it has a compacted log and a snapshot
 	peers := make([]proto.Peer, 0)
@@ -0,0 +1,104 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/depends/tiglabs/raft/proto"
+)
+
+// newLearnerNetwork returns a network of 3 nodes in which node 3 is a learner.
+func newLearnerNetwork() *network {
+	nt := newNetwork(nil, nil, nil)
+	for _, p := range nt.peers {
+		p.(*raftFsm).replicas[3].peer.Type = proto.PeerLearner
+	}
+	return nt
+}
+
+func TestLearnerCannotCampaign(t *testing.T) {
+	nt := newLearnerNetwork()
+	learner := nt.peers[3].(*raftFsm)
+	if learner.promotable() {
+		t.Fatalf("promotable = true, want false")
+	}
+
+	nt.send(proto.Message{From: 3, To: 3, Type: proto.LocalMsgHup})
+	if learner.state != stateFollower || learner.term != 0 {
+		t.Fatalf("learner state = %s term = %d, want %s term 0", learner.state, learner.term, stateFollower)
+	}
+
+	nt.send(proto.Message{From: 1, To: 1, Type: proto.LocalMsgHup})
+	leader := nt.peers[1].(*raftFsm)
+	if leader.state != stateLeader {
+		t.Fatalf("state = %s, want %s", leader.state, stateLeader)
+	}
+	if q := leader.quorum(); q != 2 {
+		t.Fatalf("quorum = %d, want 2", q)
+	}
+}
+
+func TestLearnerNotCountedInCommit(t *testing.T) {
+	nt := newLearnerNetwork()
+	nt.send(proto.Message{From: 1, To: 1, Type: proto.LocalMsgHup})
+	leader := nt.peers[1].(*raftFsm)
+	learner := nt.peers[3].(*raftFsm)
+	committed := leader.raftLog.committed
+
+	// the entry replicated to the learner only is not committed
+	nt.isolate(2)
+	nt.send(proto.Message{From: 1, To: 1, Type: proto.LocalMsgProp, Entries: []*proto.Entry{{Index: 2, Term: 1, Data: []byte("some data")}}})
+	if leader.raftLog.committed != committed {
+		t.Fatalf("committed = %d, want %d", leader.raftLog.committed, committed)
+	}
+	if leader.replicas[3].match != leader.raftLog.lastIndex() {
+		t.Fatalf("learner match = %d, want %d", leader.replicas[3].match, leader.raftLog.lastIndex())
+	}
+
+	// the learner promoted counts in the quorum
+	cc := &proto.ConfChange{Type: proto.ConfPromoteLearner, Peer: proto.Peer{ID: 3, PeerID: 3}}
+	leader.applyConfChange(cc)
+	learner.applyConfChange(cc)
+	if leader.isLearner(3) || !learner.promotable() {
+		t.Fatalf("node 3 is not promoted")
+	}
+	nt.send(proto.Message{From: 1, To: 1, Type: proto.LocalMsgProp, Entries: []*proto.Entry{{Index: 3, Term: 1, Data: []byte("some data")}}})
+	if leader.raftLog.committed != leader.raftLog.lastIndex() {
+		t.Fatalf("committed = %d, want %d", leader.raftLog.committed, leader.raftLog.lastIndex())
+	}
+}
+
+func TestAddLearner(t *testing.T) {
+	nt := newNetwork(nil, nil)
+	nt.send(proto.Message{From: 1, To: 1, Type: proto.LocalMsgHup})
+	leader := nt.peers[1].(*raftFsm)
+
+	leader.applyConfChange(&proto.ConfChange{Type: proto.ConfAddLearner, Peer: proto.Peer{ID: 3, PeerID: 3}})
+	if !leader.isLearner(3) {
+		t.Fatalf("node 3 is not a learner")
+	}
+	if q := leader.quorum(); q != 2 {
+		t.Fatalf("quorum = %d, want 2", q)
+	}
+	// the learner is not asked for the votes
+	leader.becomeFollower(leader.term, NoLeader)
+	leader.campaign(false, campaignElection)
+	for _, m := range leader.msgs {
+		if m.Type == proto.ReqMsgVote && m.To == 3 {
+			t.Fatalf("vote request sent to the learner")
+		}
+	}
+}
@@ -31,6 +31,7 @@ type replica struct {
 	peer                                proto.Peer
 	state                               replicaState
 	paused, active, pending             bool
+	resumable                           bool // the replica receives the resumable snapshots, told by its heartbeat responses
 	match, next, committed, pendingSnap uint64
 
 	lastActive time.Time
@@ -19,6 +19,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 
 	"github.com/cubefs/cubefs/depends/tiglabs/raft/logger"
 	"github.com/cubefs/cubefs/depends/tiglabs/raft/proto"
@@ -44,11 +45,14 @@ type snapshotRequest struct {
 	header *proto.Message
 }
 
-func newSnapshotRequest(m *proto.Message, r *util.BufferReader) *snapshotRequest {
+func newSnapshotRequest(m *proto.Message, r *util.BufferReader, w io.Writer) *snapshotRequest {
 	f := &snapshotRequest{
 		header:         m,
 		snapshotReader: snapshotReader{reader: r},
 	}
+	if m.SnapshotMeta.Resumable {
+		f.writer = w
+	}
 	f.init()
 	return f
 }
@@ -57,15 +61,54 @@ func (r *snapshotRequest) response() error {
 	return <-r.error()
 }
 
+func (r *snapshotRequest) Source() (from uint64, meta proto.SnapshotMeta, ok bool) {
+	return r.header.From, r.header.SnapshotMeta, r.writer != nil
+}
+
+// snapshotSkipped is sent to the leader of the resumable snapshot not read by the follower.
+const snapshotSkipped = math.MaxUint64
+
 type snapshotReader struct {
-	reader *util.BufferReader
-	err    error
+	reader  *util.BufferReader
+	writer  io.Writer // sends the block to resume from, nil if the snapshot is not resumable
+	resumed bool
+	err     error
+}
+
+func (r *snapshotReader) Resume(block uint64) error {
+	if r.writer == nil {
+		return fmt.Errorf("the snapshot is not resumable")
+	}
+	if r.resumed {
+		return fmt.Errorf("the snapshot is resumed")
+	}
+	r.resumed = true
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, block)
+	if _, r.err = r.writer.Write(buf); r.err != nil {
+		return r.err
+	}
+	return nil
+}
+
+// skip tells the leader the snapshot is not read, if it has not been resumed.
+func (r *snapshotReader) skip() error {
+	if r.writer == nil || r.resumed {
+		return nil
+	}
+	return r.Resume(snapshotSkipped)
 }
 
 func (r *snapshotReader) Next() ([]byte, error) {
 	if r.err != nil {
 		return nil, r.err
 	}
+	if r.writer != nil && !r.resumed {
+		// sent from the first block if the state machine does not resume it
+		if err := r.Resume(0); err != nil {
+			return nil, err
+		}
+	}
 
 	// read size header
 	// r.reader.Reset()
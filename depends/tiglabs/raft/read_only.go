@@ -134,14 +134,13 @@ func (r *readOnly) lastPending() uint64 {
 	return 0
 }
 
-func (r *readOnly) recvAck(index uint64, from uint64, quorum int) {
+func (r *readOnly) recvAck(index uint64, from uint64, hasQuorum func(acks map[uint64]struct{}) bool) {
 	status, ok := r.pendings[index]
 	if !ok {
 		return
 	}
 	status.acks[from] = struct{}{}
-	// add one to include an ack from local node
-	if len(status.acks)+1 >= quorum {
+	if hasQuorum(status.acks) {
 		r.advance(index)
 	}
 }
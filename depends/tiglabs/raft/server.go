@@ -218,6 +218,22 @@ func (rs *RaftServer) ChangeMember(id uint64, changeType proto.ConfChangeType, p
 	return
 }
 
+// ReplaceMember replaces the voter removePeer with peer through a joint consensus, which is left
+// by removing removePeer with ChangeMember.
+func (rs *RaftServer) ReplaceMember(id uint64, peer, removePeer proto.Peer, context []byte) (future *Future) {
+	rs.mu.RLock()
+	raft, ok := rs.rafts[id]
+	rs.mu.RUnlock()
+
+	future = newFuture()
+	if !ok {
+		future.respond(nil, ErrRaftNotExists)
+		return
+	}
+	raft.proposeMemberChange(&proto.ConfChange{Type: proto.ConfReplaceNode, Peer: peer, RemovePeer: removePeer, Context: context}, future)
+	return
+}
+
 func (rs *RaftServer) IsRestoring(id uint64) bool {
 	rs.mu.RLock()
 	defer rs.mu.RUnlock()
@@ -461,6 +477,7 @@ func (rs *RaftServer) handleHeartbeat(m *proto.Message) {
 	msg.Type = proto.RespMsgHeartBeat
 	msg.From = rs.config.NodeID
 	msg.To = m.From
+	msg.RejectHint = proto.FeatureResumableSnapshot
 	msg.Context = proto.EncodeHBConext(respCtx)
 	rs.config.transport.Send(msg)
 }
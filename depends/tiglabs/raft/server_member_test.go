@@ -0,0 +1,230 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/depends/tiglabs/raft/proto"
+	"github.com/cubefs/cubefs/depends/tiglabs/raft/storage/wal"
+	"github.com/stretchr/testify/require"
+)
+
+const memberRaftID = 21
+
+// memberStateMachine records the member changes applied to the state machine.
+type memberStateMachine struct {
+	sync.Mutex
+	changes []proto.ConfChangeType
+}
+
+func (sm *memberStateMachine) Apply(command []byte, index uint64) (interface{}, error) {
+	return nil, nil
+}
+
+func (sm *memberStateMachine) ApplyMemberChange(cc *proto.ConfChange, index uint64) (interface{}, error) {
+	sm.Lock()
+	sm.changes = append(sm.changes, cc.Type)
+	sm.Unlock()
+	return nil, nil
+}
+
+func (sm *memberStateMachine) Snapshot() (proto.Snapshot, error) {
+	return nil, nil
+}
+
+func (sm *memberStateMachine) ApplySnapshot(peers []proto.Peer, iter proto.SnapIterator) error {
+	return nil
+}
+
+func (sm *memberStateMachine) HandleLeaderChange(leader uint64) {}
+
+func (sm *memberStateMachine) HandleFatalEvent(err *FatalError) {}
+
+func (sm *memberStateMachine) appliedChanges() []proto.ConfChangeType {
+	sm.Lock()
+	defer sm.Unlock()
+	return append([]proto.ConfChangeType(nil), sm.changes...)
+}
+
+type nopMonitor struct{}
+
+func (nopMonitor) MonitorZombie(id uint64, peer proto.Peer, replicasMsg string, du time.Duration) {}
+
+func (nopMonitor) MonitorElection(id uint64, replicaMsg string, du time.Duration) {}
+
+func (nopMonitor) RemovePeer(id uint64, peer proto.Peer) {}
+
+func (nopMonitor) RemovePartition(id uint64, peers []proto.Peer) {}
+
+// startMemberTestServer starts the raft server of the node, the raft keeps its log in dir and
+// starts from the applied index with the peers given.
+func startMemberTestServer(t *testing.T, resolver *testResolver, id uint64, dir string, applied uint64,
+	peers []proto.Peer) (*RaftServer, *memberStateMachine) {
+	addr, _ := resolver.NodeAddress(id, HeartBeat)
+	replicateAddr, _ := resolver.NodeAddress(id, Replicate)
+	server, err := NewRaftServer(&Config{
+		NodeID:          id,
+		TransportConfig: TransportConfig{HeartbeatAddr: addr, ReplicateAddr: replicateAddr, Resolver: resolver},
+	})
+	require.NoError(t, err)
+	t.Cleanup(server.Stop)
+
+	storage, err := wal.NewStorage(dir, &wal.Config{})
+	require.NoError(t, err)
+	sm := new(memberStateMachine)
+	require.NoError(t, server.CreateRaft(&RaftConfig{ID: memberRaftID, Applied: applied, Peers: peers,
+		Storage: storage, StateMachine: sm, Monitor: nopMonitor{}}))
+	return server, sm
+}
+
+// newMemberTestServers starts 4 raft servers, node 1, 2 and 3 are the voters of the raft
+// and node 4 is the replica to be added as a learner. It returns the log dirs of the nodes too.
+func newMemberTestServers(t *testing.T) (*testResolver, []*RaftServer, []*memberStateMachine, []string) {
+	resolver := newTestResolver()
+	for i := 1; i <= 4; i++ {
+		resolver.AddNodeWithPort(uint64(i), "127.0.0.1", 19030+i, 19040+i)
+	}
+	voters := []proto.Peer{{ID: 1}, {ID: 2}, {ID: 3}}
+	servers := make([]*RaftServer, 0, 4)
+	sms := make([]*memberStateMachine, 0, 4)
+	dirs := make([]string, 0, 4)
+	for i := 1; i <= 4; i++ {
+		peers := voters
+		if i == 4 {
+			peers = append(append([]proto.Peer(nil), voters...), proto.Peer{ID: 4, Type: proto.PeerLearner})
+		}
+		dir := t.TempDir()
+		server, sm := startMemberTestServer(t, resolver, uint64(i), dir, 0, peers)
+		servers = append(servers, server)
+		sms = append(sms, sm)
+		dirs = append(dirs, dir)
+	}
+	return resolver, servers, sms, dirs
+}
+
+func waitMemberTestLeader(t *testing.T, servers []*RaftServer) int {
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		for i, server := range servers {
+			if server.IsLeader(memberRaftID) {
+				return i
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("no leader is elected")
+	return -1
+}
+
+func TestReplaceMemberThroughJoint(t *testing.T) {
+	_, servers, sms, _ := newMemberTestServers(t)
+	leaderIdx := waitMemberTestLeader(t, servers)
+	leader := servers[leaderIdx]
+	// the voters other than the leader
+	var followers []uint64
+	for i := 0; i < 3; i++ {
+		if i != leaderIdx {
+			followers = append(followers, uint64(i+1))
+		}
+	}
+
+	_, err := leader.ChangeMember(memberRaftID, proto.ConfAddLearner, proto.Peer{ID: 4}, nil).Response()
+	require.NoError(t, err)
+	require.True(t, leader.Status(memberRaftID).Replicas[4].IsLearner)
+
+	// the voter can not replace another one, the state machine is not told of it
+	_, err = leader.ReplaceMember(memberRaftID, proto.Peer{ID: followers[0]}, proto.Peer{ID: followers[1]}, nil).Response()
+	require.Equal(t, ErrNotReplaced, err)
+	require.Equal(t, []proto.ConfChangeType{proto.ConfAddLearner}, sms[leaderIdx].appliedChanges())
+
+	_, err = leader.ReplaceMember(memberRaftID, proto.Peer{ID: 4}, proto.Peer{ID: followers[0]}, nil).Response()
+	require.NoError(t, err)
+	require.False(t, leader.Status(memberRaftID).Replicas[4].IsLearner)
+	// the replacement retried in the joint consensus succeeds as well
+	_, err = leader.ReplaceMember(memberRaftID, proto.Peer{ID: 4}, proto.Peer{ID: followers[0]}, nil).Response()
+	require.NoError(t, err)
+	// the joint consensus refuses any other replacement
+	_, err = leader.ReplaceMember(memberRaftID, proto.Peer{ID: 4}, proto.Peer{ID: followers[1]}, nil).Response()
+	require.Equal(t, ErrNotReplaced, err)
+
+	_, err = leader.ChangeMember(memberRaftID, proto.ConfRemoveNode, proto.Peer{ID: followers[0]}, nil).Response()
+	require.NoError(t, err)
+	replicas := leader.Status(memberRaftID).Replicas
+	require.Len(t, replicas, 3)
+	require.Contains(t, replicas, uint64(4))
+	require.NotContains(t, replicas, followers[0])
+
+	// the learner follows the member changes of the raft as well
+	want := []proto.ConfChangeType{proto.ConfAddLearner, proto.ConfReplaceNode, proto.ConfReplaceNode, proto.ConfRemoveNode}
+	require.Equal(t, want, sms[leaderIdx].appliedChanges())
+	require.Eventually(t, func() bool {
+		changes := sms[3].appliedChanges()
+		return len(changes) == len(want)
+	}, 10*time.Second, 100*time.Millisecond)
+	require.Equal(t, want, sms[3].appliedChanges())
+}
+
+func TestReplaceMemberAfterRestart(t *testing.T) {
+	resolver, servers, _, dirs := newMemberTestServers(t)
+	leaderIdx := waitMemberTestLeader(t, servers)
+	leader := servers[leaderIdx]
+	replaced := uint64((leaderIdx+1)%3 + 1)
+
+	_, err := leader.ChangeMember(memberRaftID, proto.ConfAddLearner, proto.Peer{ID: 4}, nil).Response()
+	require.NoError(t, err)
+	_, err = leader.ReplaceMember(memberRaftID, proto.Peer{ID: 4}, proto.Peer{ID: replaced}, nil).Response()
+	require.NoError(t, err)
+	applied := make([]uint64, len(servers))
+	require.Eventually(t, func() bool {
+		for i, server := range servers {
+			applied[i] = server.Status(memberRaftID).Applied
+		}
+		for _, index := range applied {
+			if index != applied[leaderIdx] {
+				return false
+			}
+		}
+		return true
+	}, 10*time.Second, 100*time.Millisecond)
+
+	// the nodes restart in the joint consensus with the peers persisted by the partitions,
+	// the replacement applied before is not replayed then
+	for _, server := range servers {
+		server.Stop()
+	}
+	peers := []proto.Peer{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4, Type: proto.PeerIncoming}}
+	peers[replaced-1].Type = proto.PeerOutgoing
+	for i := range servers {
+		servers[i], _ = startMemberTestServer(t, resolver, uint64(i+1), dirs[i], applied[i], peers)
+	}
+	leaderIdx = waitMemberTestLeader(t, servers)
+	leader = servers[leaderIdx]
+
+	// the replacement retried by the master succeeds, the replaced peer is removed then
+	_, err = leader.ReplaceMember(memberRaftID, proto.Peer{ID: 4}, proto.Peer{ID: replaced}, nil).Response()
+	require.NoError(t, err)
+	_, err = leader.ChangeMember(memberRaftID, proto.ConfRemoveNode, proto.Peer{ID: replaced}, nil).Response()
+	require.NoError(t, err)
+	replicas := leader.Status(memberRaftID).Replicas
+	require.Len(t, replicas, 3)
+	require.Contains(t, replicas, uint64(4))
+	require.NotContains(t, replicas, replaced)
+	for _, replica := range replicas {
+		require.False(t, replica.IsLearner)
+	}
+}
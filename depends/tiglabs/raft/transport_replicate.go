@@ -132,6 +132,24 @@ func (t *replicateTransport) sendSnapshot(m *proto.Message, rs *snapshotStatus)
 	if err = bufWr.Flush(); err != nil {
 		return
 	}
+	if m.SnapshotMeta.Resumable {
+		// the follower asks for the block to send from
+		blockBuf := make([]byte, 8)
+		if _, err = io.ReadFull(conn, blockBuf); err != nil {
+			return
+		}
+		block := binary.BigEndian.Uint64(blockBuf)
+		if block == snapshotSkipped {
+			err = waitSnapshotAck(conn)
+			return
+		}
+		if block > 0 {
+			logger.Info("[Transport] %v resume snapshot to %v from block %d.", m.ID, m.To, block)
+			if err = m.Snapshot.(proto.ResumableSnapshot).Skip(block); err != nil {
+				return
+			}
+		}
+	}
 
 	// send snapshot data
 	var (
@@ -175,12 +193,16 @@ func (t *replicateTransport) sendSnapshot(m *proto.Message, rs *snapshotStatus)
 	}
 
 	// wait response
-	err = nil
+	err = waitSnapshotAck(conn)
+}
+
+func waitSnapshotAck(conn *util.ConnTimeout) error {
 	resp := make([]byte, 1)
 	io.ReadFull(conn, resp)
 	if resp[0] != 1 {
-		err = fmt.Errorf("follower response failed.")
+		return fmt.Errorf("follower response failed.")
 	}
+	return nil
 }
 
 func (t *replicateTransport) start() {
@@ -240,12 +262,17 @@ func (t *replicateTransport) handleSnapshot(m *proto.Message, conn *util.ConnTim
 	conn.SetReadTimeout(time.Minute)
 	conn.SetWriteTimeout(15 * time.Second)
 	bufRd.Grow(1 * MB)
-	req := newSnapshotRequest(m, bufRd)
+	req := newSnapshotRequest(m, bufRd, conn)
 	t.raftServer.reciveSnapshot(req)
 
 	// wait snapshot result
 	if err := req.response(); err != nil {
 		logger.Error("[Transport] handle snapshot request from %v error: %v.", m.From, err)
+		// the leader waits for the block to resume from until the snapshot is skipped
+		req.skip()
+		return err
+	}
+	if err := req.skip(); err != nil {
 		return err
 	}
 
@@ -0,0 +1,54 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/cubefs/cubefs/depends/tiglabs/raft/proto"
+	"github.com/cubefs/cubefs/depends/tiglabs/raft/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleSnapshotAnswersResume(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	leader, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer leader.Close()
+	follower, err := listener.Accept()
+	require.NoError(t, err)
+	conn := util.NewConnTimeout(follower)
+	defer conn.Close()
+
+	// the follower has no raft of the snapshot, the leader is still told the snapshot is skipped
+	trans := &replicateTransport{raftServer: &RaftServer{rafts: make(map[uint64]*raft)}}
+	m := &proto.Message{Type: proto.ReqMsgSnapShot, ID: 1, From: 1, To: 2, SnapshotMeta: proto.SnapshotMeta{Resumable: true}}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- trans.handleSnapshot(m, conn, util.NewBufferReader(conn, 16*KB))
+	}()
+	block := make([]byte, 8)
+	_, err = io.ReadFull(leader, block)
+	require.NoError(t, err)
+	require.Equal(t, uint64(snapshotSkipped), binary.BigEndian.Uint64(block))
+	require.Equal(t, ErrRaftNotExists, <-errc)
+	conn.Close()
+	require.Error(t, waitSnapshotAck(util.NewConnTimeout(leader)))
+}
@@ -16,6 +16,7 @@ package lcnode
 
 import (
 	"regexp"
+	"time"
 
 	"github.com/cubefs/cubefs/proto"
 	"golang.org/x/time/rate"
@@ -51,6 +52,13 @@ const (
 	defaultUnboundedChanInitCapacity = 10000
 	defaultLcNodeTaskCountLimit      = 1
 	maxLcNodeTaskCountLimit          = 20
+
+	defaultTransitionBlockSize = 8 * 1024 * 1024
+
+	replicationInterval           = 5 * time.Second
+	replicationCheckpointInterval = 30 * time.Second
+	replicationBatchLimit         = 1000
+	replicationBlockSize          = 4 * 1024 * 1024
 )
 
 var (
@@ -64,4 +72,7 @@ var (
 
 	snapshotRoutineNumPerTask int
 	lcNodeTaskCountLimit      int
+
+	// the data of an inode is written to the blobstore in blocks of the size when transitioned
+	transitionBlockSize = defaultTransitionBlockSize
 )
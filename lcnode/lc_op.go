@@ -39,6 +39,7 @@ func (l *LcNode) opMasterHeartbeat(conn net.Conn, p *proto.Packet, remoteAddr st
 		resp = &proto.LcNodeHeartbeatResponse{
 			LcScanningTasks:       make(map[string]*proto.LcNodeRuleTaskResponse),
 			SnapshotScanningTasks: make(map[string]*proto.SnapshotVerDelTaskResponse),
+			ReplicationTasks:      make(map[string]*proto.ReplicationTaskResponse),
 		}
 		adminTask = &proto.AdminTask{
 			Request: req,
@@ -67,6 +68,7 @@ func (l *LcNode) opMasterHeartbeat(conn net.Conn, p *proto.Packet, remoteAddr st
 					FileScannedNum:       atomic.LoadInt64(&scanner.currentStat.FileScannedNum),
 					DirScannedNum:        atomic.LoadInt64(&scanner.currentStat.DirScannedNum),
 					ExpiredNum:           atomic.LoadInt64(&scanner.currentStat.ExpiredNum),
+					TransitionNum:        atomic.LoadInt64(&scanner.currentStat.TransitionNum),
 					ErrorSkippedNum:      atomic.LoadInt64(&scanner.currentStat.ErrorSkippedNum),
 				},
 			}
@@ -88,6 +90,18 @@ func (l *LcNode) opMasterHeartbeat(conn net.Conn, p *proto.Packet, remoteAddr st
 			}
 			resp.SnapshotScanningTasks[scanner.ID] = info
 		}
+		for _, r := range l.replicators {
+			now := time.Now()
+			startTime := r.startTime
+			resp.ReplicationTasks[r.Volume] = &proto.ReplicationTaskResponse{
+				ID:                    r.ID,
+				LcNode:                l.localServerAddr,
+				StartTime:             &startTime,
+				UpdateTime:            &now,
+				Status:                proto.TaskRunning,
+				ReplicationStatistics: r.Statistics(),
+			}
+		}
 		l.scannerMutex.RUnlock()
 
 		resp.LcTaskCountLimit = lcNodeTaskCountLimit
@@ -180,3 +194,35 @@ func (l *LcNode) opSnapshotVerDel(conn net.Conn, p *proto.Packet) (err error) {
 
 	return
 }
+
+func (l *LcNode) opReplicate(conn net.Conn, p *proto.Packet) (err error) {
+	go func() {
+		p.PacketOkReply()
+		if err := p.WriteToConn(conn); err != nil {
+			log.LogErrorf("ack master response: %s", err.Error())
+		}
+	}()
+	data := p.Data
+	var (
+		req       = &proto.ReplicationTaskRequest{}
+		resp      = &proto.ReplicationTaskResponse{}
+		adminTask = &proto.AdminTask{
+			Request: req,
+		}
+	)
+
+	decoder := json.NewDecoder(bytes.NewBuffer(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(adminTask); err != nil {
+		resp.Status = proto.TaskFailed
+		resp.Result = err.Error()
+		adminTask.Response = resp
+		l.respondToMaster(adminTask)
+		return
+	}
+
+	l.startReplication(adminTask)
+	l.respondToMaster(adminTask)
+
+	return
+}
@@ -38,6 +38,8 @@ type LcScanner struct {
 	ID            string
 	Volume        string
 	mw            MetaWrapper
+	ec            ExtentReader
+	ebs           BlobWriter
 	lcnode        *LcNode
 	adminTask     *proto.AdminTask
 	rule          *proto.Rule
@@ -69,11 +71,24 @@ func NewS3Scanner(adminTask *proto.AdminTask, l *LcNode) (*LcScanner, error) {
 		return nil, err
 	}
 
+	var (
+		ec  ExtentReader
+		ebs BlobWriter
+	)
+	if len(scanTask.Rule.Transitions) > 0 {
+		if ec, ebs, err = l.newTransitionClients(scanTask.VolName, metaWrapper); err != nil {
+			metaWrapper.Close()
+			return nil, err
+		}
+	}
+
 	scanner := &LcScanner{
 		ID:            scanTask.Id,
 		Volume:        scanTask.VolName,
 		lcnode:        l,
 		mw:            metaWrapper,
+		ec:            ec,
+		ebs:           ebs,
 		adminTask:     adminTask,
 		rule:          scanTask.Rule,
 		dirChan:       unboundedchan.NewUnboundedChan(defaultUnboundedChanInitCapacity),
@@ -319,6 +334,7 @@ func (s *LcScanner) batchHandleFile() {
 	dentries, inodes := s.batchDentries.BatchGetAndClear()
 
 	var expiredDentries []*proto.ScanDentry
+	transitions := make(map[uint64]string)
 	inodesInfo := s.mw.BatchInodeGet(inodes)
 	for _, info := range inodesInfo {
 		if s.inodeExpired(info, s.rule.Expire) {
@@ -326,6 +342,12 @@ func (s *LcScanner) batchHandleFile() {
 			if d != nil {
 				expiredDentries = append(expiredDentries, d)
 			}
+			continue
+		}
+		if s.ebs != nil {
+			if storageClass := s.inodeTransition(info, s.rule.Transitions); storageClass != "" {
+				transitions[info.Inode] = storageClass
+			}
 		}
 	}
 
@@ -350,6 +372,19 @@ func (s *LcScanner) batchHandleFile() {
 		}
 	}
 	atomic.AddInt64(&s.currentStat.ExpiredNum, int64(len(expiredDentries)))
+
+	for ino, storageClass := range transitions {
+		s.limiter.Wait(context.Background())
+		transitioned, err := s.transitionInode(ino, storageClass)
+		if err != nil {
+			atomic.AddInt64(&s.currentStat.ErrorSkippedNum, 1)
+			log.LogWarnf("batchHandleFile transitionInode err: %v, volume: %v, inode: %v, skip it", err, s.Volume, ino)
+			continue
+		}
+		if transitioned {
+			atomic.AddInt64(&s.currentStat.TransitionNum, 1)
+		}
+	}
 }
 
 func (s *LcScanner) inodeExpired(inode *proto.InodeInfo, cond *proto.ExpirationConfig) bool {
@@ -525,6 +560,7 @@ func (s *LcScanner) checkScanning() {
 					response.Volume = s.Volume
 					response.RuleId = s.rule.ID
 					response.ExpiredNum = s.currentStat.ExpiredNum
+					response.TransitionNum = s.currentStat.TransitionNum
 					response.FileScannedNum = s.currentStat.FileScannedNum
 					response.DirScannedNum = s.currentStat.DirScannedNum
 					response.TotalInodeScannedNum = s.currentStat.TotalInodeScannedNum
@@ -557,5 +593,8 @@ func (s *LcScanner) Stop() {
 	close(s.dirChan.In)
 	close(s.fileChan.In)
 	s.mw.Close()
+	if s.ec != nil {
+		s.ec.Close()
+	}
 	log.LogInfof("scanner(%v) stopped", s.ID)
 }
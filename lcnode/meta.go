@@ -24,5 +24,9 @@ type MetaWrapper interface {
 	DeleteWithCond_ll(parentID, cond uint64, name string, isDir bool, fullPath string) (inode *proto.InodeInfo, err error)
 	Evict(inode uint64, fullPath string) error
 	ReadDirLimit_ll(parentID uint64, from string, limit uint64) ([]proto.Dentry, error)
+	GetObjExtents(inode uint64) (gen uint64, size uint64, extents []proto.ExtentKey, objExtents []proto.ObjExtentKey, err error)
+	AppendObjExtentKeys(inode uint64, eks []proto.ObjExtentKey) error
+	XAttrSet_ll(inode uint64, name, value []byte) error
+	InodeClearPreloadCache_ll(inode uint64) error
 	Close() error
 }
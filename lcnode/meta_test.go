@@ -50,6 +50,22 @@ func (*MockMetaWrapper) ReadDirLimit_ll(parentID uint64, from string, limit uint
 	return nil, nil
 }
 
+func (*MockMetaWrapper) GetObjExtents(inode uint64) (gen uint64, size uint64, extents []proto.ExtentKey, objExtents []proto.ObjExtentKey, err error) {
+	return
+}
+
+func (*MockMetaWrapper) AppendObjExtentKeys(inode uint64, eks []proto.ObjExtentKey) error {
+	return nil
+}
+
+func (*MockMetaWrapper) XAttrSet_ll(inode uint64, name, value []byte) error {
+	return nil
+}
+
+func (*MockMetaWrapper) InodeClearPreloadCache_ll(inode uint64) error {
+	return nil
+}
+
 func (*MockMetaWrapper) Close() error {
 	return nil
 }
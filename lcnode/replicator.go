@@ -0,0 +1,810 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/data/stream"
+	"github.com/cubefs/cubefs/sdk/meta"
+	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	// the source inode of a replicated inode, kept as an xattr of the inode in the target volume
+	replicationSourceKey = "cfs.replication.src"
+	// the cursors of the meta partitions replicated, kept as an xattr of the target root
+	replicationCheckpointKey = "cfs.replication.checkpoint"
+
+	replicationLagMetric = "replication_lag_seconds"
+)
+
+var (
+	errChangesTruncated     = errors.New("metadata changes are truncated")
+	errParentNotReplicated  = errors.New("parent is not replicated yet")
+	errReplicationCancelled = errors.New("replication is cancelled")
+)
+
+// ReplicaSource reads the metadata changes and the namespace of the replicated volume.
+type ReplicaSource interface {
+	GetPartitionIDs() []uint64
+	ReadMetaChanges(mpID, from uint64, limit uint32) (*proto.ReadMetaChangesResponse, error)
+	Lookup_ll(parentID uint64, name string) (inode uint64, mode uint32, err error)
+	InodeGet_ll(inode uint64) (*proto.InodeInfo, error)
+	ReadDirLimit_ll(parentID uint64, from string, limit uint64) ([]proto.Dentry, error)
+	Close() error
+}
+
+// ReplicaTarget updates the namespace of the volume in the target cluster.
+type ReplicaTarget interface {
+	Create_ll(parentID uint64, name string, mode, uid, gid uint32, target []byte, fullPath string) (*proto.InodeInfo, error)
+	Lookup_ll(parentID uint64, name string) (inode uint64, mode uint32, err error)
+	Link(parentID uint64, name string, ino uint64, fullPath string) (*proto.InodeInfo, error)
+	Rename_ll(srcParentID uint64, srcName string, dstParentID uint64, dstName string, srcFullPath string, dstFullPath string, overwritten bool) error
+	Delete_ll(parentID uint64, name string, isDir bool, fullPath string) (*proto.InodeInfo, error)
+	Evict(inode uint64, fullPath string) error
+	InodeGet_ll(inode uint64) (*proto.InodeInfo, error)
+	Setattr(inode uint64, valid, mode, uid, gid uint32, atime, mtime int64) error
+	Truncate(inode, size uint64, fullPath string) error
+	ReadDirLimit_ll(parentID uint64, from string, limit uint64) ([]proto.Dentry, error)
+	XAttrSet_ll(inode uint64, name, value []byte) error
+	XAttrGet_ll(inode uint64, name string) (*proto.XAttrInfo, error)
+	Close() error
+}
+
+// ExtentWriter writes the data of the replicated inodes to the target volume.
+type ExtentWriter interface {
+	OpenStream(inode uint64) error
+	CloseStream(inode uint64) error
+	Write(inode uint64, offset int, data []byte, flags int, checkFunc func() error) (write int, err error)
+	Flush(inode uint64) error
+	Close() error
+}
+
+// replicaInode is where a source inode is replicated to, the location is only tracked for
+// the directories which have a single link and are moved by renaming.
+type replicaInode struct {
+	ino    uint64
+	parent uint64
+	name   string
+}
+
+// Replicator replicates a volume to a volume of another cluster. It first copies the whole
+// namespace, then tails the changes of the meta partitions from the cursors taken before the
+// copy. The changes only tell what is modified, the current state of the source is always
+// read and applied, so that a change can be applied more than once.
+type Replicator struct {
+	ID          string
+	Volume      string
+	config      *proto.VolReplication
+	lcnode      *LcNode
+	adminTask   *proto.AdminTask
+	src         ReplicaSource
+	tgt         ReplicaTarget
+	srcData     ExtentReader
+	tgtData     ExtentWriter
+	inodes      map[uint64]*replicaInode // source inode -> target inode
+	sources     map[uint64]uint64        // target inode -> source inode
+	cursors     map[uint64]uint64        // meta partition -> the raft index replicated
+	currentStat *proto.ReplicationStatistics
+	startTime   time.Time
+	prepared    bool
+	phase       atomic.Value
+	caughtUp    time.Time
+	checkpoint  time.Time
+	lagMetric   *exporter.Gauge
+	now         func() time.Time
+	stopC       chan bool
+	stopOnce    sync.Once
+}
+
+func NewReplicator(adminTask *proto.AdminTask, l *LcNode) (r *Replicator, err error) {
+	request := adminTask.Request.(*proto.ReplicationTaskRequest)
+	task := request.Task
+
+	var srcMw, tgtMw *meta.MetaWrapper
+	if srcMw, err = meta.NewMetaWrapper(&meta.MetaConfig{
+		Volume:        task.VolName,
+		Masters:       l.masters,
+		Authenticate:  false,
+		ValidateOwner: false,
+	}); err != nil {
+		return
+	}
+	if tgtMw, err = meta.NewMetaWrapper(&meta.MetaConfig{
+		Volume:        task.Config.TargetVolume,
+		Masters:       strings.Split(task.Config.TargetMasters, ","),
+		Authenticate:  false,
+		ValidateOwner: false,
+	}); err != nil {
+		srcMw.Close()
+		return
+	}
+
+	var srcEc, tgtEc *stream.ExtentClient
+	if srcEc, err = stream.NewExtentClient(&stream.ExtentConfig{
+		Volume:            task.VolName,
+		Masters:           l.masters,
+		FollowerRead:      true,
+		OnAppendExtentKey: srcMw.AppendExtentKey,
+		OnSplitExtentKey:  srcMw.SplitExtentKey,
+		OnGetExtents:      srcMw.GetExtents,
+		OnTruncate:        srcMw.Truncate,
+	}); err != nil {
+		srcMw.Close()
+		tgtMw.Close()
+		return
+	}
+	if tgtEc, err = stream.NewExtentClient(&stream.ExtentConfig{
+		Volume:            task.Config.TargetVolume,
+		Masters:           strings.Split(task.Config.TargetMasters, ","),
+		OnAppendExtentKey: tgtMw.AppendExtentKey,
+		OnSplitExtentKey:  tgtMw.SplitExtentKey,
+		OnGetExtents:      tgtMw.GetExtents,
+		OnTruncate:        tgtMw.Truncate,
+	}); err != nil {
+		srcEc.Close()
+		srcMw.Close()
+		tgtMw.Close()
+		return
+	}
+
+	r = newReplicator(task, srcMw, tgtMw, srcEc, tgtEc)
+	r.lcnode = l
+	r.adminTask = adminTask
+	return
+}
+
+func newReplicator(task *proto.ReplicationTask, src ReplicaSource, tgt ReplicaTarget, srcData ExtentReader, tgtData ExtentWriter) *Replicator {
+	return &Replicator{
+		ID:          task.Id,
+		Volume:      task.VolName,
+		config:      task.Config,
+		src:         src,
+		tgt:         tgt,
+		srcData:     srcData,
+		tgtData:     tgtData,
+		inodes:      make(map[uint64]*replicaInode),
+		sources:     make(map[uint64]uint64),
+		cursors:     make(map[uint64]uint64),
+		currentStat: &proto.ReplicationStatistics{VolName: task.VolName},
+		startTime:   time.Now(),
+		lagMetric:   exporter.NewGauge(replicationLagMetric),
+		now:         time.Now,
+		stopC:       make(chan bool),
+	}
+}
+
+func (l *LcNode) startReplication(adminTask *proto.AdminTask) (err error) {
+	request := adminTask.Request.(*proto.ReplicationTaskRequest)
+	log.LogInfof("startReplication: replication task(%v) received!", request.Task)
+	response := &proto.ReplicationTaskResponse{
+		ID:     request.Task.Id,
+		LcNode: l.localServerAddr,
+	}
+	response.VolName = request.Task.VolName
+	adminTask.Response = response
+
+	l.scannerMutex.Lock()
+	defer l.scannerMutex.Unlock()
+	if r, ok := l.replicators[request.Task.VolName]; ok {
+		if !request.Task.Stop && *r.config == *request.Task.Config {
+			log.LogInfof("startReplication: replication task(%v) is already running!", request.Task)
+			response.Status = proto.TaskRunning
+			return
+		}
+		r.Stop()
+		delete(l.replicators, request.Task.VolName)
+		log.LogInfof("startReplication: replication of volume(%v) stopped", request.Task.VolName)
+	}
+	if request.Task.Stop {
+		response.Status = proto.TaskSucceeds
+		return
+	}
+
+	var r *Replicator
+	if r, err = NewReplicator(adminTask, l); err != nil {
+		log.LogErrorf("startReplication: NewReplicator err(%v)", err)
+		response.Status = proto.TaskFailed
+		response.Result = err.Error()
+		return
+	}
+	l.replicators[request.Task.VolName] = r
+	response.Status = proto.TaskRunning
+	go r.Start()
+	return
+}
+
+func (r *Replicator) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopC)
+	})
+}
+
+func (r *Replicator) stopped() bool {
+	select {
+	case <-r.stopC:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *Replicator) close() {
+	r.srcData.Close()
+	r.tgtData.Close()
+	r.src.Close()
+	r.tgt.Close()
+	log.LogInfof("replicator(%v) closed", r.ID)
+}
+
+// Start replicates the volume until the replicator is stopped.
+func (r *Replicator) Start() {
+	defer r.close()
+
+	var err error
+	for !r.stopped() {
+		if !r.prepared {
+			err = r.prepare()
+		} else {
+			err = r.replicate()
+		}
+		if err == errReplicationCancelled {
+			return
+		}
+		if err == errChangesTruncated {
+			log.LogWarnf("replicator(%v): changes are truncated, resync the volume in full", r.ID)
+			r.prepared = false
+			continue
+		}
+		if err != nil {
+			atomic.AddInt64(&r.currentStat.ErrorSkippedNum, 1)
+			log.LogErrorf("replicator(%v): err(%v)", r.ID, err)
+		}
+		select {
+		case <-r.stopC:
+			return
+		case <-time.After(replicationInterval):
+		}
+	}
+}
+
+// Statistics returns the current state of the replication.
+func (r *Replicator) Statistics() proto.ReplicationStatistics {
+	return proto.ReplicationStatistics{
+		VolName:         r.Volume,
+		Phase:           r.getPhase(),
+		SyncedEntries:   atomic.LoadInt64(&r.currentStat.SyncedEntries),
+		SyncedBytes:     atomic.LoadInt64(&r.currentStat.SyncedBytes),
+		ErrorSkippedNum: atomic.LoadInt64(&r.currentStat.ErrorSkippedNum),
+		PendingChanges:  atomic.LoadUint64(&r.currentStat.PendingChanges),
+		LagSeconds:      atomic.LoadInt64(&r.currentStat.LagSeconds),
+	}
+}
+
+func (r *Replicator) getPhase() string {
+	phase, _ := r.phase.Load().(string)
+	return phase
+}
+
+func (r *Replicator) setPhase(phase string) {
+	r.phase.Store(phase)
+}
+
+// prepare resumes from the checkpoint kept in the target volume, the namespace is copied
+// in full if there is no checkpoint or the changes after it are not kept any more.
+func (r *Replicator) prepare() (err error) {
+	r.inodes = map[uint64]*replicaInode{proto.RootIno: {ino: proto.RootIno}}
+	r.sources = map[uint64]uint64{proto.RootIno: proto.RootIno}
+
+	var cursors map[uint64]uint64
+	if cursors, err = r.loadCheckpoint(); err != nil {
+		return
+	}
+	if cursors != nil {
+		if err = r.loadInodes(proto.RootIno); err != nil {
+			return
+		}
+		r.cursors = cursors
+		r.prepared = true
+		r.setPhase(proto.ReplicationPhaseIncremental)
+		log.LogInfof("replicator(%v): resume from checkpoint(%v), inodes(%v)", r.ID, cursors, len(r.inodes))
+		return
+	}
+	return r.fullSync()
+}
+
+// fullSync copies the whole namespace, the cursors are taken before the copy so that
+// nothing modified during the copy is missed.
+func (r *Replicator) fullSync() (err error) {
+	r.setPhase(proto.ReplicationPhaseFull)
+	log.LogInfof("replicator(%v): full sync start", r.ID)
+
+	cursors := make(map[uint64]uint64)
+	for _, mpID := range r.src.GetPartitionIDs() {
+		var resp *proto.ReadMetaChangesResponse
+		if resp, err = r.src.ReadMetaChanges(mpID, 0, 1); err != nil {
+			return
+		}
+		// all the changes are kept if the partition is never truncated, replay them from the start
+		if resp.Truncated {
+			cursors[mpID] = resp.Applied
+		}
+	}
+
+	if err = r.syncDir(proto.RootIno, proto.RootIno, true); err != nil {
+		return
+	}
+	r.cursors = cursors
+	if err = r.saveCheckpoint(); err != nil {
+		return
+	}
+	r.prepared = true
+	r.setPhase(proto.ReplicationPhaseIncremental)
+	log.LogInfof("replicator(%v): full sync finish, inodes(%v)", r.ID, len(r.inodes))
+	return
+}
+
+// replicate applies the changes of all the meta partitions once.
+func (r *Replicator) replicate() (err error) {
+	caughtUp := true
+	var pending uint64
+	for _, mpID := range r.src.GetPartitionIDs() {
+		if r.stopped() {
+			return errReplicationCancelled
+		}
+		cursor := r.cursors[mpID]
+		var resp *proto.ReadMetaChangesResponse
+		if resp, err = r.src.ReadMetaChanges(mpID, cursor, replicationBatchLimit); err != nil {
+			log.LogWarnf("replicator(%v): read changes of mp(%v) from(%v) err(%v)", r.ID, mpID, cursor, err)
+			caughtUp = false
+			continue
+		}
+		if resp.Truncated {
+			return errChangesTruncated
+		}
+
+		next, applyErr := r.applyChanges(resp.Changes, cursor)
+		if applyErr == errReplicationCancelled {
+			return applyErr
+		}
+		if applyErr == nil {
+			next = resp.Applied
+		} else {
+			err = applyErr
+		}
+		if next > cursor {
+			r.cursors[mpID] = next
+		}
+		if r.cursors[mpID] < resp.Last {
+			caughtUp = false
+			pending += resp.Last - r.cursors[mpID]
+		}
+	}
+
+	now := r.now()
+	if caughtUp {
+		r.caughtUp = now
+	}
+	if r.caughtUp.IsZero() {
+		r.caughtUp = now
+	}
+	lag := int64(now.Sub(r.caughtUp) / time.Second)
+	atomic.StoreUint64(&r.currentStat.PendingChanges, pending)
+	atomic.StoreInt64(&r.currentStat.LagSeconds, lag)
+	r.lagMetric.SetWithLabels(float64(lag), map[string]string{exporter.Vol: r.Volume})
+
+	if now.Sub(r.checkpoint) >= replicationCheckpointInterval {
+		if saveErr := r.saveCheckpoint(); saveErr != nil {
+			log.LogWarnf("replicator(%v): save checkpoint err(%v)", r.ID, saveErr)
+		}
+	}
+	return
+}
+
+// applyChanges returns the cursor up to which the changes are applied. On error, the changes
+// of the raft index failed are applied again next time, since they are read and skipped together.
+func (r *Replicator) applyChanges(changes []*proto.MetaChange, cursor uint64) (next uint64, err error) {
+	next = cursor
+	synced := make(map[uint64]bool)
+	for _, change := range changes {
+		if r.stopped() {
+			return next, errReplicationCancelled
+		}
+		if err = r.applyChange(change, synced); err != nil {
+			log.LogWarnf("replicator(%v): apply change(%v) err(%v)", r.ID, change, err)
+			return change.Index - 1, err
+		}
+	}
+	if len(changes) > 0 {
+		next = changes[len(changes)-1].Index
+	}
+	return
+}
+
+func (r *Replicator) applyChange(change *proto.MetaChange, synced map[uint64]bool) (err error) {
+	switch change.Type {
+	case proto.MetaChangeDentryCreate, proto.MetaChangeDentryUpdate:
+		// the dentry might be removed or replaced after the change, which is replicated later
+		var ino uint64
+		if ino, _, err = r.src.Lookup_ll(change.ParentIno, change.Name); err == syscall.ENOENT || (err == nil && ino != change.Inode) {
+			return nil
+		}
+		if err != nil {
+			return
+		}
+		parent, ok := r.inodes[change.ParentIno]
+		if !ok {
+			return errParentNotReplicated
+		}
+		err = r.syncEntry(parent.ino, change.Name, change.Inode, false)
+		synced[change.Inode] = true
+	case proto.MetaChangeDentryDelete:
+		parent, ok := r.inodes[change.ParentIno]
+		if !ok {
+			return nil
+		}
+		var ino uint64
+		if ino, _, err = r.src.Lookup_ll(change.ParentIno, change.Name); err == nil && ino == change.Inode {
+			return nil
+		}
+		if ino, _, err = r.tgt.Lookup_ll(parent.ino, change.Name); err == syscall.ENOENT {
+			return nil
+		}
+		if err != nil {
+			return
+		}
+		// the name might be taken by another inode in the target already
+		if r.sources[ino] != change.Inode {
+			return nil
+		}
+		err = r.removeEntry(parent.ino, change.Name)
+	case proto.MetaChangeInodeUpdate:
+		replica, ok := r.inodes[change.Inode]
+		if !ok || synced[change.Inode] {
+			return nil
+		}
+		var info *proto.InodeInfo
+		if info, err = r.src.InodeGet_ll(change.Inode); err == syscall.ENOENT {
+			return nil
+		}
+		if err != nil {
+			return
+		}
+		if err = r.syncInode(info, replica.ino); err == nil {
+			synced[change.Inode] = true
+		}
+	case proto.MetaChangeInodeCreate, proto.MetaChangeInodeUnlink, proto.MetaChangeInodeEvict:
+		// the inodes are created and removed in the target with the dentries
+	default:
+		log.LogWarnf("replicator(%v): unknown change(%v)", r.ID, change)
+	}
+	return
+}
+
+// syncDir makes the entries of the target directory the same as the source directory.
+// The subdirectories are synced recursively if recursive is set.
+func (r *Replicator) syncDir(srcDir, tgtDir uint64, recursive bool) (err error) {
+	names := make(map[string]bool)
+	err = r.readDir(r.src.ReadDirLimit_ll, srcDir, func(dentry proto.Dentry) error {
+		names[dentry.Name] = true
+		return r.syncEntry(tgtDir, dentry.Name, dentry.Inode, recursive)
+	})
+	if err != nil {
+		return
+	}
+
+	extra := make([]string, 0)
+	if err = r.readDir(r.tgt.ReadDirLimit_ll, tgtDir, func(dentry proto.Dentry) error {
+		if !names[dentry.Name] {
+			extra = append(extra, dentry.Name)
+		}
+		return nil
+	}); err != nil {
+		return
+	}
+	for _, name := range extra {
+		if err = r.removeEntry(tgtDir, name); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (r *Replicator) readDir(readDir func(parentID uint64, from string, limit uint64) ([]proto.Dentry, error),
+	parent uint64, fn func(dentry proto.Dentry) error) (err error) {
+	var from string
+	for {
+		if r.stopped() {
+			return errReplicationCancelled
+		}
+		var children []proto.Dentry
+		if children, err = readDir(parent, from, defaultReadDirLimit); err != nil {
+			return
+		}
+		for _, child := range children {
+			if child.Name == from {
+				continue
+			}
+			if err = fn(child); err != nil {
+				return
+			}
+		}
+		if len(children) < defaultReadDirLimit {
+			return
+		}
+		from = children[len(children)-1].Name
+	}
+}
+
+// syncEntry makes the entry of the target directory refer to the replica of the source inode.
+func (r *Replicator) syncEntry(tgtParent uint64, name string, srcIno uint64, recursive bool) (err error) {
+	var info *proto.InodeInfo
+	if info, err = r.src.InodeGet_ll(srcIno); err == syscall.ENOENT {
+		return nil
+	}
+	if err != nil {
+		return
+	}
+
+	replica := r.inodes[srcIno]
+	ino, _, err := r.tgt.Lookup_ll(tgtParent, name)
+	if err != nil && err != syscall.ENOENT {
+		return
+	}
+	exist := err == nil
+	if exist && (replica == nil || replica.ino != ino) {
+		if err = r.removeEntry(tgtParent, name); err != nil {
+			return
+		}
+		exist = false
+	}
+
+	created := false
+	if !exist {
+		if replica, created, err = r.linkEntry(tgtParent, name, srcIno, info, replica); err != nil {
+			return
+		}
+	}
+	if err = r.syncInode(info, replica.ino); err != nil {
+		return
+	}
+	atomic.AddInt64(&r.currentStat.SyncedEntries, 1)
+
+	if proto.IsDir(info.Mode) && (recursive || created) {
+		err = r.syncDir(srcIno, replica.ino, true)
+	}
+	return
+}
+
+// linkEntry links the replica to the target directory, the directories are moved instead
+// since they have a single link. A new replica is created if the source is not replicated yet.
+func (r *Replicator) linkEntry(tgtParent uint64, name string, srcIno uint64, info *proto.InodeInfo,
+	replica *replicaInode) (_ *replicaInode, created bool, err error) {
+	if replica != nil {
+		if proto.IsDir(info.Mode) {
+			err = r.tgt.Rename_ll(replica.parent, replica.name, tgtParent, name, "", "", false)
+			if err == nil {
+				replica.parent, replica.name = tgtParent, name
+				return replica, false, nil
+			}
+		} else if _, err = r.tgt.Link(tgtParent, name, replica.ino, ""); err == nil {
+			return replica, false, nil
+		}
+		if err != syscall.ENOENT {
+			return
+		}
+		r.forget(replica.ino)
+	}
+
+	var tgtInfo *proto.InodeInfo
+	if tgtInfo, err = r.tgt.Create_ll(tgtParent, name, info.Mode, info.Uid, info.Gid, info.Target, ""); err != nil {
+		return
+	}
+	if err = r.tgt.XAttrSet_ll(tgtInfo.Inode, []byte(replicationSourceKey), []byte(strconv.FormatUint(srcIno, 10))); err != nil {
+		return
+	}
+	replica = &replicaInode{ino: tgtInfo.Inode, parent: tgtParent, name: name}
+	r.inodes[srcIno] = replica
+	r.sources[tgtInfo.Inode] = srcIno
+	return replica, true, nil
+}
+
+// removeEntry removes the entry from the target directory, the directories are removed with all their entries.
+func (r *Replicator) removeEntry(tgtParent uint64, name string) (err error) {
+	ino, mode, err := r.tgt.Lookup_ll(tgtParent, name)
+	if err == syscall.ENOENT {
+		return nil
+	}
+	if err != nil {
+		return
+	}
+	isDir := proto.IsDir(mode)
+	if isDir {
+		names := make([]string, 0)
+		if err = r.readDir(r.tgt.ReadDirLimit_ll, ino, func(dentry proto.Dentry) error {
+			names = append(names, dentry.Name)
+			return nil
+		}); err != nil {
+			return
+		}
+		for _, child := range names {
+			if err = r.removeEntry(ino, child); err != nil {
+				return
+			}
+		}
+	}
+
+	var info *proto.InodeInfo
+	if info, err = r.tgt.Delete_ll(tgtParent, name, isDir, ""); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+	if info == nil {
+		return
+	}
+	if isDir || info.Nlink == 0 {
+		r.forget(info.Inode)
+	}
+	if !isDir && info.Nlink == 0 {
+		if err = r.tgt.Evict(info.Inode, ""); err != nil {
+			log.LogWarnf("replicator(%v): evict target inode(%v) err(%v)", r.ID, info.Inode, err)
+			err = nil
+		}
+	}
+	return
+}
+
+func (r *Replicator) forget(tgtIno uint64) {
+	if srcIno, ok := r.sources[tgtIno]; ok {
+		delete(r.inodes, srcIno)
+		delete(r.sources, tgtIno)
+	}
+}
+
+// syncInode copies the attributes and the data of the source inode to the replica. The data
+// is copied in full if the size or the modification time of the replica is not the same.
+func (r *Replicator) syncInode(info *proto.InodeInfo, tgtIno uint64) (err error) {
+	var tgtInfo *proto.InodeInfo
+	if tgtInfo, err = r.tgt.InodeGet_ll(tgtIno); err != nil {
+		return
+	}
+	modified := tgtInfo.Size != info.Size || tgtInfo.ModifyTime.Unix() != info.ModifyTime.Unix()
+	if proto.IsRegular(info.Mode) && modified {
+		if err = r.copyData(info, tgtIno); err != nil {
+			return
+		}
+	}
+	if !modified && tgtInfo.Mode == info.Mode && tgtInfo.Uid == info.Uid && tgtInfo.Gid == info.Gid {
+		return
+	}
+	valid := proto.AttrMode | proto.AttrUid | proto.AttrGid | proto.AttrModifyTime | proto.AttrAccessTime
+	return r.tgt.Setattr(tgtIno, valid, info.Mode, info.Uid, info.Gid, info.AccessTime.Unix(), info.ModifyTime.Unix())
+}
+
+func (r *Replicator) copyData(info *proto.InodeInfo, tgtIno uint64) (err error) {
+	if err = r.tgt.Truncate(tgtIno, 0, ""); err != nil {
+		return
+	}
+	if info.Size == 0 {
+		return
+	}
+	if err = r.srcData.OpenStream(info.Inode); err != nil {
+		return
+	}
+	defer r.srcData.CloseStream(info.Inode)
+	if err = r.tgtData.OpenStream(tgtIno); err != nil {
+		return
+	}
+	defer r.tgtData.CloseStream(tgtIno)
+
+	buf := make([]byte, replicationBlockSize)
+	for offset := uint64(0); offset < info.Size; {
+		if r.stopped() {
+			return errReplicationCancelled
+		}
+		n := uint64(replicationBlockSize)
+		if info.Size-offset < n {
+			n = info.Size - offset
+		}
+		var readN int
+		readN, err = r.srcData.Read(info.Inode, buf[:n], int(offset), int(n))
+		if err != nil && err != io.EOF {
+			return
+		}
+		// the source is truncated during the copy, the change is replicated later
+		if readN == 0 {
+			break
+		}
+		if _, err = r.tgtData.Write(tgtIno, int(offset), buf[:readN], 0, nil); err != nil {
+			return
+		}
+		offset += uint64(readN)
+		atomic.AddInt64(&r.currentStat.SyncedBytes, int64(readN))
+	}
+	return r.tgtData.Flush(tgtIno)
+}
+
+// loadInodes rebuilds the map of the replicas from the source inodes kept in the target volume.
+func (r *Replicator) loadInodes(tgtDir uint64) (err error) {
+	dirs := make([]uint64, 0)
+	if err = r.readDir(r.tgt.ReadDirLimit_ll, tgtDir, func(dentry proto.Dentry) error {
+		if _, ok := r.sources[dentry.Inode]; !ok {
+			xattr, err := r.tgt.XAttrGet_ll(dentry.Inode, replicationSourceKey)
+			if err != nil {
+				return err
+			}
+			srcIno, err := strconv.ParseUint(xattr.XAttrs[replicationSourceKey], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid source of target inode(%v): %v", dentry.Inode, err)
+			}
+			r.inodes[srcIno] = &replicaInode{ino: dentry.Inode, parent: tgtDir, name: dentry.Name}
+			r.sources[dentry.Inode] = srcIno
+		}
+		if proto.IsDir(dentry.Type) {
+			dirs = append(dirs, dentry.Inode)
+		}
+		return nil
+	}); err != nil {
+		return
+	}
+	for _, dir := range dirs {
+		if err = r.loadInodes(dir); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (r *Replicator) loadCheckpoint() (cursors map[uint64]uint64, err error) {
+	var xattr *proto.XAttrInfo
+	if xattr, err = r.tgt.XAttrGet_ll(proto.RootIno, replicationCheckpointKey); err != nil {
+		return
+	}
+	value := xattr.XAttrs[replicationCheckpointKey]
+	if value == "" {
+		return
+	}
+	if err = json.Unmarshal([]byte(value), &cursors); err != nil {
+		log.LogWarnf("replicator(%v): invalid checkpoint(%v) err(%v), resync in full", r.ID, value, err)
+		return nil, nil
+	}
+	return
+}
+
+func (r *Replicator) saveCheckpoint() (err error) {
+	var value []byte
+	if value, err = json.Marshal(r.cursors); err != nil {
+		return
+	}
+	if err = r.tgt.XAttrSet_ll(proto.RootIno, []byte(replicationCheckpointKey), value); err != nil {
+		return
+	}
+	r.checkpoint = r.now()
+	return
+}
@@ -0,0 +1,358 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"os"
+	"path"
+	"sort"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+type memInode struct {
+	info     proto.InodeInfo
+	data     []byte
+	xattrs   map[string]string
+	children map[string]uint64
+}
+
+// memVolume is a volume in memory with a single meta partition, which records the changes.
+type memVolume struct {
+	inodes    map[uint64]*memInode
+	nextIno   uint64
+	index     uint64
+	changes   []*proto.MetaChange
+	truncated uint64
+}
+
+func newMemVolume() *memVolume {
+	v := &memVolume{inodes: make(map[uint64]*memInode), nextIno: proto.RootIno + 1}
+	v.inodes[proto.RootIno] = &memInode{
+		info:     proto.InodeInfo{Inode: proto.RootIno, Mode: proto.Mode(os.ModeDir | 0o755), Nlink: 2},
+		xattrs:   make(map[string]string),
+		children: make(map[string]uint64),
+	}
+	return v
+}
+
+func (v *memVolume) record(tp uint8, ino, parent uint64, name string) {
+	v.index++
+	v.changes = append(v.changes, &proto.MetaChange{Index: v.index, Type: tp, Inode: ino, ParentIno: parent, Name: name})
+}
+
+func (v *memVolume) mkdir(parent uint64, name string) uint64 {
+	info, _ := v.Create_ll(parent, name, proto.Mode(os.ModeDir|0o755), 0, 0, nil, "")
+	v.record(proto.MetaChangeDentryCreate, info.Inode, parent, name)
+	return info.Inode
+}
+
+func (v *memVolume) createFile(parent uint64, name string, data string) uint64 {
+	info, _ := v.Create_ll(parent, name, 0o644, 0, 0, nil, "")
+	v.record(proto.MetaChangeDentryCreate, info.Inode, parent, name)
+	v.writeFile(info.Inode, data)
+	return info.Inode
+}
+
+func (v *memVolume) writeFile(ino uint64, data string) {
+	v.inodes[ino].data = []byte(data)
+	v.inodes[ino].info.ModifyTime = v.inodes[ino].info.ModifyTime.Add(time.Second)
+	v.record(proto.MetaChangeInodeUpdate, ino, 0, "")
+}
+
+func (v *memVolume) rename(srcParent uint64, srcName string, dstParent uint64, dstName string) {
+	ino := v.inodes[srcParent].children[srcName]
+	v.inodes[dstParent].children[dstName] = ino
+	v.record(proto.MetaChangeDentryCreate, ino, dstParent, dstName)
+	delete(v.inodes[srcParent].children, srcName)
+	v.record(proto.MetaChangeDentryDelete, ino, srcParent, srcName)
+}
+
+func (v *memVolume) remove(parent uint64, name string) {
+	ino := v.inodes[parent].children[name]
+	v.Delete_ll(parent, name, false, "")
+	v.record(proto.MetaChangeDentryDelete, ino, parent, name)
+}
+
+// dump returns the content of the files and the directories by path.
+func (v *memVolume) dump(dir uint64, prefix string, tree map[string]string) map[string]string {
+	for name, ino := range v.inodes[dir].children {
+		p := path.Join(prefix, name)
+		inode := v.inodes[ino]
+		if proto.IsDir(inode.info.Mode) {
+			tree[p] = "/"
+			v.dump(ino, p, tree)
+		} else {
+			tree[p] = string(inode.data)
+		}
+	}
+	return tree
+}
+
+func (v *memVolume) GetPartitionIDs() []uint64 {
+	return []uint64{1}
+}
+
+func (v *memVolume) ReadMetaChanges(mpID, from uint64, limit uint32) (*proto.ReadMetaChangesResponse, error) {
+	resp := &proto.ReadMetaChangesResponse{Applied: v.index, Last: v.index}
+	if from < v.truncated {
+		resp.Truncated = true
+		return resp, nil
+	}
+	for _, change := range v.changes {
+		if change.Index > from {
+			resp.Changes = append(resp.Changes, change)
+		}
+	}
+	return resp, nil
+}
+
+func (v *memVolume) Lookup_ll(parentID uint64, name string) (uint64, uint32, error) {
+	parent, ok := v.inodes[parentID]
+	if !ok {
+		return 0, 0, syscall.ENOENT
+	}
+	ino, ok := parent.children[name]
+	if !ok {
+		return 0, 0, syscall.ENOENT
+	}
+	return ino, v.inodes[ino].info.Mode, nil
+}
+
+func (v *memVolume) InodeGet_ll(ino uint64) (*proto.InodeInfo, error) {
+	inode, ok := v.inodes[ino]
+	if !ok {
+		return nil, syscall.ENOENT
+	}
+	info := inode.info
+	info.Size = uint64(len(inode.data))
+	return &info, nil
+}
+
+func (v *memVolume) ReadDirLimit_ll(parentID uint64, from string, limit uint64) ([]proto.Dentry, error) {
+	parent, ok := v.inodes[parentID]
+	if !ok {
+		return nil, syscall.ENOENT
+	}
+	names := make([]string, 0)
+	for name := range parent.children {
+		if name >= from {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if uint64(len(names)) > limit {
+		names = names[:limit]
+	}
+	dentries := make([]proto.Dentry, 0, len(names))
+	for _, name := range names {
+		ino := parent.children[name]
+		dentries = append(dentries, proto.Dentry{Name: name, Inode: ino, Type: v.inodes[ino].info.Mode})
+	}
+	return dentries, nil
+}
+
+func (v *memVolume) Create_ll(parentID uint64, name string, mode, uid, gid uint32, target []byte, fullPath string) (*proto.InodeInfo, error) {
+	parent, ok := v.inodes[parentID]
+	if !ok {
+		return nil, syscall.ENOENT
+	}
+	if _, ok = parent.children[name]; ok {
+		return nil, syscall.EEXIST
+	}
+	ino := v.nextIno
+	v.nextIno++
+	inode := &memInode{
+		info:   proto.InodeInfo{Inode: ino, Mode: mode, Uid: uid, Gid: gid, Nlink: 1, Target: target},
+		xattrs: make(map[string]string),
+	}
+	if proto.IsDir(mode) {
+		inode.info.Nlink = 2
+		inode.children = make(map[string]uint64)
+	}
+	v.inodes[ino] = inode
+	parent.children[name] = ino
+	return v.InodeGet_ll(ino)
+}
+
+func (v *memVolume) Link(parentID uint64, name string, ino uint64, fullPath string) (*proto.InodeInfo, error) {
+	inode, ok := v.inodes[ino]
+	if !ok {
+		return nil, syscall.ENOENT
+	}
+	inode.info.Nlink++
+	v.inodes[parentID].children[name] = ino
+	return v.InodeGet_ll(ino)
+}
+
+func (v *memVolume) Rename_ll(srcParentID uint64, srcName string, dstParentID uint64, dstName string, srcFullPath string, dstFullPath string, overwritten bool) error {
+	ino, _, err := v.Lookup_ll(srcParentID, srcName)
+	if err != nil {
+		return err
+	}
+	delete(v.inodes[srcParentID].children, srcName)
+	v.inodes[dstParentID].children[dstName] = ino
+	return nil
+}
+
+func (v *memVolume) Delete_ll(parentID uint64, name string, isDir bool, fullPath string) (*proto.InodeInfo, error) {
+	ino, _, err := v.Lookup_ll(parentID, name)
+	if err != nil {
+		return nil, err
+	}
+	inode := v.inodes[ino]
+	if isDir && len(inode.children) > 0 {
+		return nil, syscall.ENOTEMPTY
+	}
+	delete(v.inodes[parentID].children, name)
+	inode.info.Nlink--
+	if isDir {
+		inode.info.Nlink = 0
+	}
+	info, _ := v.InodeGet_ll(ino)
+	if inode.info.Nlink == 0 {
+		delete(v.inodes, ino)
+	}
+	return info, nil
+}
+
+func (v *memVolume) Evict(inode uint64, fullPath string) error {
+	return nil
+}
+
+func (v *memVolume) Setattr(ino uint64, valid, mode, uid, gid uint32, atime, mtime int64) error {
+	inode, ok := v.inodes[ino]
+	if !ok {
+		return syscall.ENOENT
+	}
+	inode.info.Mode, inode.info.Uid, inode.info.Gid = mode, uid, gid
+	inode.info.ModifyTime = time.Unix(mtime, 0)
+	return nil
+}
+
+func (v *memVolume) Truncate(ino, size uint64, fullPath string) error {
+	v.inodes[ino].data = v.inodes[ino].data[:size]
+	return nil
+}
+
+func (v *memVolume) XAttrSet_ll(ino uint64, name, value []byte) error {
+	v.inodes[ino].xattrs[string(name)] = string(value)
+	return nil
+}
+
+func (v *memVolume) XAttrGet_ll(ino uint64, name string) (*proto.XAttrInfo, error) {
+	return &proto.XAttrInfo{Inode: ino, XAttrs: map[string]string{name: v.inodes[ino].xattrs[name]}}, nil
+}
+
+func (v *memVolume) OpenStream(ino uint64) error {
+	return nil
+}
+
+func (v *memVolume) CloseStream(ino uint64) error {
+	return nil
+}
+
+func (v *memVolume) Read(ino uint64, data []byte, offset int, size int) (int, error) {
+	return copy(data[:size], v.inodes[ino].data[offset:]), nil
+}
+
+func (v *memVolume) Write(ino uint64, offset int, data []byte, flags int, checkFunc func() error) (int, error) {
+	inode := v.inodes[ino]
+	if end := offset + len(data); end > len(inode.data) {
+		inode.data = append(inode.data, make([]byte, end-len(inode.data))...)
+	}
+	return copy(inode.data[offset:], data), nil
+}
+
+func (v *memVolume) Flush(ino uint64) error {
+	return nil
+}
+
+func (v *memVolume) Close() error {
+	return nil
+}
+
+func newTestReplicator(src, tgt *memVolume) *Replicator {
+	task := &proto.ReplicationTask{Id: "vol", VolName: "vol", Config: &proto.VolReplication{TargetVolume: "vol"}}
+	return newReplicator(task, src, tgt, src, tgt)
+}
+
+func TestReplicatorFullSync(t *testing.T) {
+	src, tgt := newMemVolume(), newMemVolume()
+	a := src.mkdir(proto.RootIno, "a")
+	src.createFile(a, "f1", "hello")
+	f2 := src.createFile(proto.RootIno, "f2", "world")
+	_, err := src.Link(a, "f2", f2, "")
+	require.NoError(t, err)
+	// the stale entries of the target are removed
+	tgt.mkdir(proto.RootIno, "stale")
+	src.truncated = src.index
+
+	r := newTestReplicator(src, tgt)
+	require.NoError(t, r.prepare())
+	require.Equal(t, src.dump(proto.RootIno, "", map[string]string{}), tgt.dump(proto.RootIno, "", map[string]string{}))
+	require.Equal(t, uint64(src.index), r.cursors[1])
+	require.Equal(t, proto.ReplicationPhaseIncremental, r.Statistics().Phase)
+
+	// the hard links share the replica
+	ino1, _, err := tgt.Lookup_ll(proto.RootIno, "f2")
+	require.NoError(t, err)
+	ino2, _, err := tgt.Lookup_ll(r.inodes[a].ino, "f2")
+	require.NoError(t, err)
+	require.Equal(t, ino1, ino2)
+}
+
+func TestReplicatorIncremental(t *testing.T) {
+	src, tgt := newMemVolume(), newMemVolume()
+	a := src.mkdir(proto.RootIno, "a")
+	f1 := src.createFile(a, "f1", "hello")
+	b := src.mkdir(proto.RootIno, "b")
+
+	r := newTestReplicator(src, tgt)
+	require.NoError(t, r.prepare())
+
+	src.writeFile(f1, "hello world")
+	src.createFile(b, "f2", "new")
+	src.rename(proto.RootIno, "a", b, "a")
+	src.createFile(proto.RootIno, "f3", "removed")
+	src.remove(proto.RootIno, "f3")
+	require.NoError(t, r.replicate())
+	require.Equal(t, src.dump(proto.RootIno, "", map[string]string{}), tgt.dump(proto.RootIno, "", map[string]string{}))
+	require.Equal(t, src.index, r.cursors[1])
+	require.Zero(t, r.Statistics().PendingChanges)
+
+	// the moved directory is renamed in the target instead of copied
+	ino, _, err := tgt.Lookup_ll(r.inodes[b].ino, "a")
+	require.NoError(t, err)
+	require.Equal(t, r.inodes[a].ino, ino)
+
+	// resume from the checkpoint kept in the target
+	require.NoError(t, r.saveCheckpoint())
+	resumed := newTestReplicator(src, tgt)
+	require.NoError(t, resumed.prepare())
+	require.Equal(t, r.cursors, resumed.cursors)
+	require.Equal(t, r.inodes[f1].ino, resumed.inodes[f1].ino)
+
+	src.remove(b, "f2")
+	require.NoError(t, resumed.replicate())
+	require.Equal(t, src.dump(proto.RootIno, "", map[string]string{}), tgt.dump(proto.RootIno, "", map[string]string{}))
+
+	src.truncated = src.index + 1
+	require.Equal(t, errChangesTruncated, resumed.replicate())
+}
@@ -51,12 +51,14 @@ type LcNode struct {
 	control          common.Control
 	lcScanners       map[string]*LcScanner
 	snapshotScanners map[string]*SnapshotScanner
+	replicators      map[string]*Replicator
 }
 
 func NewServer() *LcNode {
 	return &LcNode{
 		lcScanners:       make(map[string]*LcScanner),
 		snapshotScanners: make(map[string]*SnapshotScanner),
+		replicators:      make(map[string]*Replicator),
 	}
 }
 
@@ -336,6 +338,8 @@ func (l *LcNode) handlePacket(conn net.Conn, p *proto.Packet, remoteAddr string)
 		err = l.opLcScan(conn, p)
 	case proto.OpLcNodeSnapshotVerDel:
 		err = l.opSnapshotVerDel(conn, p)
+	case proto.OpLcNodeReplicate:
+		err = l.opReplicate(conn, p)
 	default:
 		err = fmt.Errorf("%s unknown Opcode: %d, reqId: %d", remoteAddr,
 			p.Opcode, p.GetReqID())
@@ -370,4 +374,8 @@ func (l *LcNode) stopScanners() {
 		s.Stop()
 		delete(l.snapshotScanners, s.ID)
 	}
+	for volName, r := range l.replicators {
+		r.Stop()
+		delete(l.replicators, volName)
+	}
 }
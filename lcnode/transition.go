@@ -0,0 +1,230 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/cubefs/cubefs/blobstore/api/access"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/data/blobstore"
+	"github.com/cubefs/cubefs/sdk/data/stream"
+	"github.com/cubefs/cubefs/sdk/meta"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+var (
+	errBlobstoreUnavailable = errors.New("blobstore is not available in the cluster")
+	errInodeModified        = errors.New("inode is modified during transition")
+)
+
+// ExtentReader reads the data of the inodes from the replica data partitions.
+type ExtentReader interface {
+	OpenStream(inode uint64) error
+	CloseStream(inode uint64) error
+	Read(inode uint64, data []byte, offset int, size int) (read int, err error)
+	Close() error
+}
+
+// BlobWriter writes the data of the transitioned inodes to the blobstore.
+type BlobWriter interface {
+	Write(ctx context.Context, volName string, data []byte, size uint32) (access.Location, error)
+	Delete(oeks []proto.ObjExtentKey) error
+}
+
+// newTransitionClients creates the clients to move the data of a replica volume to the
+// blobstore, nothing is created for the volumes whose data is in the blobstore already.
+func (l *LcNode) newTransitionClients(volName string, mw *meta.MetaWrapper) (ec ExtentReader, ebs BlobWriter, err error) {
+	var volumeInfo *proto.SimpleVolView
+	if volumeInfo, err = l.mc.AdminAPI().GetVolumeSimpleInfo(volName); err != nil {
+		return
+	}
+	if proto.IsCold(volumeInfo.VolType) {
+		log.LogInfof("newTransitionClients: volume(%v) is stored in blobstore, skip transitions", volName)
+		return
+	}
+	var clusterInfo *proto.ClusterInfo
+	if clusterInfo, err = l.mc.AdminAPI().GetClusterInfo(); err != nil {
+		return
+	}
+	if clusterInfo.EbsAddr == "" {
+		err = errBlobstoreUnavailable
+		return
+	}
+
+	var ebsClient *blobstore.BlobStoreClient
+	if ebsClient, err = blobstore.NewEbsClient(access.Config{
+		ConnMode: access.NoLimitConnMode,
+		Consul: access.ConsulConfig{
+			Address: clusterInfo.EbsAddr,
+		},
+		MaxSizePutOnce: int64(transitionBlockSize),
+		Logger: &access.Logger{
+			Filename: path.Join(log.LogDir, "ebs.log"),
+		},
+	}); err != nil {
+		return
+	}
+
+	var extentClient *stream.ExtentClient
+	if extentClient, err = stream.NewExtentClient(&stream.ExtentConfig{
+		Volume:            volName,
+		Masters:           l.masters,
+		FollowerRead:      true,
+		OnAppendExtentKey: mw.AppendExtentKey,
+		OnSplitExtentKey:  mw.SplitExtentKey,
+		OnGetExtents:      mw.GetExtents,
+		OnTruncate:        mw.Truncate,
+	}); err != nil {
+		return
+	}
+	return extentClient, ebsClient, nil
+}
+
+// inodeTransition returns the storage class the inode should be transitioned to,
+// an empty string is returned if none of the transitions is due.
+func (s *LcScanner) inodeTransition(inode *proto.InodeInfo, transitions []*proto.TransitionConfig) string {
+	if inode == nil {
+		return ""
+	}
+
+	now := s.now.Unix()
+	for _, cond := range transitions {
+		if cond.Days > 0 && now-inode.CreateTime.Unix() < int64(cond.Days*24*60*60) {
+			continue
+		}
+		if cond.Date != nil && now < cond.Date.Unix() {
+			continue
+		}
+		return cond.StorageClass
+	}
+	return ""
+}
+
+// transitionInode moves the data of the inode from the replica data partitions to the
+// blobstore. The inode keeps both the extents and the object extents once the object
+// extents are appended, the extents are released after the storage class is recorded,
+// so that the readers never see an inode without data.
+func (s *LcScanner) transitionInode(inode uint64, storageClass string) (transitioned bool, err error) {
+	gen, size, extents, objExtents, err := s.mw.GetObjExtents(inode)
+	if err != nil {
+		return
+	}
+	if size == 0 {
+		return
+	}
+	if len(objExtents) > 0 {
+		// resume the transition which is interrupted after the object extents are appended
+		last := objExtents[len(objExtents)-1]
+		if len(extents) == 0 || last.FileOffset+last.Size != size {
+			return
+		}
+		err = s.finishTransition(inode, storageClass)
+		return err == nil, err
+	}
+
+	if err = s.ec.OpenStream(inode); err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := s.ec.CloseStream(inode); closeErr != nil {
+			log.LogWarnf("transitionInode: close stream fail: volume(%v) inode(%v) err(%v)", s.Volume, inode, closeErr)
+		}
+	}()
+
+	oeks := make([]proto.ObjExtentKey, 0, size/uint64(transitionBlockSize)+1)
+	defer func() {
+		if err != nil && len(oeks) > 0 {
+			if delErr := s.ebs.Delete(oeks); delErr != nil {
+				log.LogErrorf("transitionInode: delete written blobs fail: volume(%v) inode(%v) oeks(%v) err(%v)",
+					s.Volume, inode, oeks, delErr)
+			}
+		}
+	}()
+
+	buf := make([]byte, transitionBlockSize)
+	for offset := uint64(0); offset < size; {
+		n := uint64(transitionBlockSize)
+		if size-offset < n {
+			n = size - offset
+		}
+		var readN int
+		readN, err = s.ec.Read(inode, buf[:n], int(offset), int(n))
+		if err != nil && err != io.EOF {
+			return
+		}
+		if uint64(readN) != n {
+			err = fmt.Errorf("read %v bytes at offset %v, expected %v: %w", readN, offset, n, io.ErrUnexpectedEOF)
+			return
+		}
+		var location access.Location
+		if location, err = s.ebs.Write(context.Background(), s.Volume, buf[:n], uint32(n)); err != nil {
+			return
+		}
+		oeks = append(oeks, locationToObjExtentKey(location, offset))
+		offset += n
+	}
+
+	// the inode must not be written during the transition, or the new data is lost
+	var newGen, newSize uint64
+	if newGen, newSize, _, _, err = s.mw.GetObjExtents(inode); err != nil {
+		return
+	}
+	if newGen != gen || newSize != size {
+		err = errInodeModified
+		return
+	}
+	if err = s.mw.AppendObjExtentKeys(inode, oeks); err != nil {
+		return
+	}
+	// the object extents are owned by the inode from now on
+	oeks = nil
+	if err = s.finishTransition(inode, storageClass); err != nil {
+		return
+	}
+	return true, nil
+}
+
+func (s *LcScanner) finishTransition(inode uint64, storageClass string) (err error) {
+	if err = s.mw.XAttrSet_ll(inode, []byte(proto.XAttrKeyStorageClass), []byte(storageClass)); err != nil {
+		return
+	}
+	return s.mw.InodeClearPreloadCache_ll(inode)
+}
+
+func locationToObjExtentKey(location access.Location, fileOffset uint64) proto.ObjExtentKey {
+	blobs := make([]proto.Blob, 0, len(location.Blobs))
+	for _, info := range location.Blobs {
+		blobs = append(blobs, proto.Blob{
+			MinBid: uint64(info.MinBid),
+			Count:  uint64(info.Count),
+			Vid:    uint64(info.Vid),
+		})
+	}
+	return proto.ObjExtentKey{
+		Cid:        uint64(location.ClusterID),
+		CodeMode:   uint8(location.CodeMode),
+		Size:       location.Size,
+		BlobSize:   location.BlobSize,
+		Blobs:      blobs,
+		BlobsLen:   uint32(len(blobs)),
+		FileOffset: fileOffset,
+		Crc:        location.Crc,
+	}
+}
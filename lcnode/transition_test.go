@@ -0,0 +1,171 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/blobstore/api/access"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+type transitionMetaWrapper struct {
+	MockMetaWrapper
+	gen        uint64
+	size       uint64
+	extents    []proto.ExtentKey
+	objExtents []proto.ObjExtentKey
+	xattrs     map[string]string
+	// writeOnRead simulates a write to the inode during the transition
+	writeOnRead bool
+	reads       int
+}
+
+func (m *transitionMetaWrapper) GetObjExtents(inode uint64) (uint64, uint64, []proto.ExtentKey, []proto.ObjExtentKey, error) {
+	m.reads++
+	if m.writeOnRead && m.reads > 1 {
+		m.gen++
+	}
+	return m.gen, m.size, m.extents, m.objExtents, nil
+}
+
+func (m *transitionMetaWrapper) AppendObjExtentKeys(inode uint64, eks []proto.ObjExtentKey) error {
+	m.objExtents = append(m.objExtents, eks...)
+	return nil
+}
+
+func (m *transitionMetaWrapper) XAttrSet_ll(inode uint64, name, value []byte) error {
+	m.xattrs[string(name)] = string(value)
+	return nil
+}
+
+func (m *transitionMetaWrapper) InodeClearPreloadCache_ll(inode uint64) error {
+	m.extents = nil
+	return nil
+}
+
+type mockExtentReader struct {
+	data []byte
+}
+
+func (*mockExtentReader) OpenStream(inode uint64) error {
+	return nil
+}
+
+func (*mockExtentReader) CloseStream(inode uint64) error {
+	return nil
+}
+
+func (r *mockExtentReader) Read(inode uint64, data []byte, offset int, size int) (int, error) {
+	return copy(data[:size], r.data[offset:]), nil
+}
+
+func (*mockExtentReader) Close() error {
+	return nil
+}
+
+type mockBlobWriter struct {
+	blobs   [][]byte
+	deleted []proto.ObjExtentKey
+}
+
+func (w *mockBlobWriter) Write(ctx context.Context, volName string, data []byte, size uint32) (access.Location, error) {
+	w.blobs = append(w.blobs, append([]byte{}, data[:size]...))
+	return access.Location{Size: uint64(size), Blobs: []access.SliceInfo{{MinBid: 1, Vid: 1, Count: 1}}}, nil
+}
+
+func (w *mockBlobWriter) Delete(oeks []proto.ObjExtentKey) error {
+	w.deleted = append(w.deleted, oeks...)
+	return nil
+}
+
+func TestInodeTransition(t *testing.T) {
+	now := time.Now()
+	scanner := &LcScanner{now: now}
+	info := &proto.InodeInfo{CreateTime: now.Add(-48 * time.Hour)}
+
+	transitions := []*proto.TransitionConfig{{Days: 3, StorageClass: proto.StorageClassCold}}
+	require.Equal(t, "", scanner.inodeTransition(info, transitions))
+	transitions[0].Days = 2
+	require.Equal(t, proto.StorageClassCold, scanner.inodeTransition(info, transitions))
+
+	future, past := now.Add(time.Hour), now.Add(-time.Hour)
+	transitions = []*proto.TransitionConfig{{Date: &future, StorageClass: proto.StorageClassCold}}
+	require.Equal(t, "", scanner.inodeTransition(info, transitions))
+	transitions[0].Date = &past
+	require.Equal(t, proto.StorageClassCold, scanner.inodeTransition(info, transitions))
+	require.Equal(t, "", scanner.inodeTransition(nil, transitions))
+}
+
+func TestTransitionInode(t *testing.T) {
+	blockSize := transitionBlockSize
+	transitionBlockSize = 4
+	defer func() {
+		transitionBlockSize = blockSize
+	}()
+
+	data := []byte("0123456789")
+	mw := &transitionMetaWrapper{
+		gen:     1,
+		size:    uint64(len(data)),
+		extents: []proto.ExtentKey{{FileOffset: 0, Size: uint32(len(data))}},
+		xattrs:  make(map[string]string),
+	}
+	ebs := &mockBlobWriter{}
+	scanner := &LcScanner{Volume: "vol", mw: mw, ec: &mockExtentReader{data: data}, ebs: ebs}
+
+	transitioned, err := scanner.transitionInode(1, proto.StorageClassCold)
+	require.NoError(t, err)
+	require.True(t, transitioned)
+	require.Equal(t, [][]byte{[]byte("0123"), []byte("4567"), []byte("89")}, ebs.blobs)
+	require.Len(t, mw.objExtents, 3)
+	for i, oek := range mw.objExtents {
+		require.Equal(t, uint64(i*4), oek.FileOffset)
+		require.Equal(t, uint32(1), oek.BlobsLen)
+	}
+	require.Equal(t, proto.StorageClassCold, mw.xattrs[proto.XAttrKeyStorageClass])
+	require.Empty(t, mw.extents)
+	require.Empty(t, ebs.deleted)
+
+	// the transitioned inode is skipped
+	transitioned, err = scanner.transitionInode(1, proto.StorageClassCold)
+	require.NoError(t, err)
+	require.False(t, transitioned)
+	require.Len(t, ebs.blobs, 3)
+}
+
+func TestTransitionInodeModified(t *testing.T) {
+	data := []byte("0123456789")
+	mw := &transitionMetaWrapper{
+		gen:         1,
+		size:        uint64(len(data)),
+		extents:     []proto.ExtentKey{{FileOffset: 0, Size: uint32(len(data))}},
+		xattrs:      make(map[string]string),
+		writeOnRead: true,
+	}
+	ebs := &mockBlobWriter{}
+	scanner := &LcScanner{Volume: "vol", mw: mw, ec: &mockExtentReader{data: data}, ebs: ebs}
+
+	transitioned, err := scanner.transitionInode(1, proto.StorageClassCold)
+	require.Equal(t, errInodeModified, err)
+	require.False(t, transitioned)
+	require.Len(t, ebs.deleted, 1)
+	require.Empty(t, mw.objExtents)
+	require.Empty(t, mw.xattrs)
+	require.NotEmpty(t, mw.extents)
+}
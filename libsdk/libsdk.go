@@ -1255,6 +1255,8 @@ func (c *client) checkPermission() (err error) {
 	// checkPermission
 	mc := masterSDK.NewMasterClientFromString(c.masterAddr, false)
 	var userInfo *proto.UserInfo
+	// the master replies the secret key only to the user itself once RBAC is enabled
+	mc.SetCredential(c.accessKey, c.secretKey)
 	if userInfo, err = mc.UserAPI().GetAKInfo(c.accessKey); err != nil {
 		return
 	}
@@ -0,0 +1,390 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// The mutating admin api calls served by the leader are recorded through the raft fsm, so that
+// the records survive leader changes. The records older than the retention are cleaned by the leader.
+
+const (
+	adminAuditRetention      = 30 * 24 * time.Hour
+	adminAuditCleanInterval  = time.Hour
+	adminAuditCleanBatch     = 1000
+	defaultAdminAuditLimit   = 100
+	maxAdminAuditBodyCapture = 64 * 1024
+)
+
+// auditedAdminAPIs maps the audited api to the param carrying the volume name, empty if none.
+var auditedAdminAPIs = map[string]string{
+	// cluster
+	proto.AdminSetApiQpsLimit:                "",
+	proto.AdminRemoveApiQpsLimit:             "",
+	proto.AdminSetClusterInfo:                "",
+	proto.AdminClusterFreeze:                 "",
+	proto.AdminClusterForbidMpDecommission:   "",
+	proto.AdminSetCheckDataReplicasEnable:    "",
+	proto.AdminSetMetaNodeThreshold:          "",
+	proto.AdminSetMasterVolDeletionDelayTime: "",
+	proto.AdminSetNodeInfo:                   "",
+	proto.AdminSetNodeRdOnly:                 "",
+	proto.AdminSetDpRdOnly:                   "",
+	proto.AdminSetConfig:                     "",
+	proto.AdminChangeMasterLeader:            "",
+	proto.AdminUpdateDecommissionLimit:       "",
+	proto.AdminUpdateDecommissionDiskFactor:  "",
+	proto.AdminEnableAutoDecommissionDisk:    "",
+	proto.AdminSetFileStats:                  "",
+	proto.AdminSetClusterUuidEnable:          "",
+	proto.AdminGenerateClusterUuid:           "",
+	proto.AdminSetDpDiscard:                  "",
+	proto.AdminSetConLcNodeNum:               "",
+	proto.AdminUpdateNodeSetCapcity:          "",
+	proto.AdminUpdateNodeSetId:               "",
+	proto.AdminUpdateNodeSetNodeSelector:     "",
+	proto.AdminUpdateDomainDataUseRatio:      "",
+	proto.AdminUpdateZoneExcludeRatio:        "",
+	proto.UpdateZone:                         "",
+	proto.UpdateNodeSet:                      "",
+	proto.AddRaftNode:                        "",
+	proto.RemoveRaftNode:                     "",
+	proto.QosUpdate:                          "",
+	proto.QosUpdateMagnify:                   "",
+	proto.QosUpdateClientParam:               "",
+	proto.QosUpdateZoneLimit:                 "",
+	proto.QosUpdateMasterLimit:               "",
+	proto.S3QoSSet:                           "",
+	proto.S3QoSDelete:                        "",
+
+	// volume
+	proto.AdminCreateVol:           nameKey,
+	proto.AdminDeleteVol:           nameKey,
+	proto.AdminUpdateVol:           nameKey,
+	proto.AdminVolShrink:           nameKey,
+	proto.AdminVolExpand:           nameKey,
+	proto.AdminVolForbidden:        nameKey,
+	proto.AdminVolEnableAuditLog:   nameKey,
+	proto.AdminSetVolReplication:   nameKey,
+	proto.AdminDelVolReplication:   nameKey,
+	proto.AdminCloneVol:            nameKey,
+	proto.AdminRenameVol:           nameKey,
+	proto.AdminPromoteVol:          nameKey,
+	proto.AdminACL:                 nameKey,
+	proto.AdminUid:                 nameKey,
+	proto.AdminCreateVersion:       nameKey,
+	proto.AdminDelVersion:          nameKey,
+	proto.AdminSetVerStrategy:      nameKey,
+	proto.AdminCreateDirSnapshot:   nameKey,
+	proto.AdminDelDirSnapshot:      nameKey,
+	proto.QuotaCreate:              nameKey,
+	proto.QuotaUpdate:              nameKey,
+	proto.QuotaDelete:              nameKey,
+	proto.UserQuotaSet:             nameKey,
+	proto.UserQuotaDelete:          nameKey,
+	proto.AdminCreateDataPartition: nameKey,
+	proto.AdminCreateMetaPartition: nameKey,
+
+	// partition
+	proto.AdminDecommissionDataPartition:            "",
+	proto.AdminResetDataPartitionDecommissionStatus: "",
+	proto.AdminDataPartitionChangeLeader:            "",
+	proto.AdminAddDataReplica:                       "",
+	proto.AdminDeleteDataReplica:                    "",
+	proto.AdminDecommissionMetaPartition:            "",
+	proto.AdminChangeMetaPartitionLeader:            "",
+	proto.AdminBalanceMetaPartitionLeader:           "",
+	proto.AdminSplitMetaPartition:                   "",
+	proto.AdminMergeMetaPartition:                   "",
+	proto.AdminAddMetaReplica:                       "",
+	proto.AdminDeleteMetaReplica:                    "",
+	proto.AdminStartDataRebalance:                   "",
+	proto.AdminStopDataRebalance:                    "",
+
+	// node and disk
+	proto.AddDataNode:                        "",
+	proto.DecommissionDataNode:               "",
+	proto.MigrateDataNode:                    "",
+	proto.PauseDecommissionDataNode:          "",
+	proto.CancelDecommissionDataNode:         "",
+	proto.SetDataNodeMaintenance:             "",
+	proto.AdminUpdateDataNode:                "",
+	proto.DecommissionDisk:                   "",
+	proto.RecommissionDisk:                   "",
+	proto.MarkDecoDiskFixed:                  "",
+	proto.PauseDecommissionDisk:              "",
+	proto.RestoreStoppedAutoDecommissionDisk: "",
+	proto.AddMetaNode:                        "",
+	proto.DecommissionMetaNode:               "",
+	proto.MigrateMetaNode:                    "",
+	proto.SetMetaNodeMaintenance:             "",
+	proto.AdminUpdateMetaNode:                "",
+
+	// user
+	proto.UserCreate:          "",
+	proto.UserDelete:          "",
+	proto.UserUpdate:          "",
+	proto.UserUpdatePolicy:    "volume",
+	proto.UserRemovePolicy:    "volume",
+	proto.UserDeleteVolPolicy: nameKey,
+	proto.UserTransferVol:     "volume",
+}
+
+// the params naming the caller, in order of preference
+var adminAuditUserKeys = []string{userKey, volOwnerKey, "user_id", "user_src"}
+
+// the params never recorded in clear
+var adminAuditSecretKeys = map[string]bool{
+	strings.ToLower(ClientIDKey): true,
+	"pwd":                        true,
+	"password":                   true,
+	"sk":                         true,
+	"secret_key":                 true,
+	"secretkey":                  true,
+}
+
+var lastAdminAuditID uint64
+
+// nextAdminAuditID returns the unix nano time, bumped to stay unique among the calls of the same nanosecond.
+func nextAdminAuditID() uint64 {
+	for {
+		last := atomic.LoadUint64(&lastAdminAuditID)
+		id := uint64(time.Now().UnixNano())
+		if id <= last {
+			id = last + 1
+		}
+		if atomic.CompareAndSwapUint64(&lastAdminAuditID, last, id) {
+			return id
+		}
+	}
+}
+
+// adminAuditWriter keeps the status and the head of the reply for the audit record.
+type adminAuditWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *adminAuditWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *adminAuditWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if rest := maxAdminAuditBodyCapture - w.body.Len(); rest > 0 {
+		if len(b) < rest {
+			rest = len(b)
+		}
+		w.body.Write(b[:rest])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// serveAndAudit serves the request and records it if the api is audited.
+func (m *Server) serveAndAudit(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	volKey, ok := auditedAdminAPIs[r.URL.Path]
+	if !ok || !m.partition.IsRaftLeader() {
+		next.ServeHTTP(w, r)
+		return
+	}
+	bodyParams := captureAdminAuditBody(r)
+	aw := &adminAuditWriter{ResponseWriter: w}
+	next.ServeHTTP(aw, r)
+
+	record := newAdminAuditRecord(r, volKey, bodyParams)
+	record.Master = m.leaderInfo.addr
+	if accessKey := r.Header.Get(proto.HeaderAccessKey); accessKey != "" {
+		if akUser, err := m.user.getAKUser(accessKey); err == nil {
+			record.User = akUser.UserID
+		}
+	}
+	record.Status = aw.status
+	reply := &proto.HTTPReply{}
+	if err := json.Unmarshal(aw.body.Bytes(), reply); err == nil {
+		record.Code = reply.Code
+		record.Msg = reply.Msg
+	} else if aw.status != http.StatusOK {
+		record.Code = proto.ErrCodeInternalError
+		record.Msg = strings.TrimSpace(aw.body.String())
+	}
+	if err := m.cluster.syncAddAdminAudit(record); err != nil {
+		log.LogErrorf("action[serveAndAudit] record op[%v] client[%v] failed, err[%v]", record.Op, record.ClientIP, err)
+	}
+}
+
+// captureAdminAuditBody returns the top level fields of a json body, the body is left readable for the handler.
+func captureAdminAuditBody(r *http.Request) map[string]string {
+	if r.Body == nil || r.Method != http.MethodPost ||
+		strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		return nil
+	}
+	head, err := io.ReadAll(io.LimitReader(r.Body, maxAdminAuditBodyCapture))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+	if err != nil || len(head) == 0 {
+		return nil
+	}
+	fields := make(map[string]interface{})
+	if err = json.Unmarshal(head, &fields); err != nil {
+		return nil
+	}
+	params := make(map[string]string, len(fields))
+	for key, value := range fields {
+		if s, ok := value.(string); ok {
+			params[key] = s
+			continue
+		}
+		raw, _ := json.Marshal(value)
+		params[key] = string(raw)
+	}
+	return params
+}
+
+func newAdminAuditRecord(r *http.Request, volKey string, bodyParams map[string]string) *proto.AdminAuditRecord {
+	id := nextAdminAuditID()
+	record := &proto.AdminAuditRecord{
+		ID:       id,
+		Time:     time.Unix(0, int64(id)).Unix(),
+		Op:       r.URL.Path,
+		ClientIP: adminAuditClientIP(r),
+		Params:   make(map[string]string),
+	}
+	values := r.Form
+	if values == nil {
+		values = r.URL.Query()
+	}
+	for key := range values {
+		record.Params[key] = values.Get(key)
+	}
+	for key, value := range bodyParams {
+		record.Params[key] = value
+	}
+	for key, value := range record.Params {
+		if key == volAuthKey {
+			record.AuthKey = maskAdminAuditSecret(value)
+			record.Params[key] = record.AuthKey
+		} else if adminAuditSecretKeys[strings.ToLower(key)] {
+			record.Params[key] = maskAdminAuditSecret(value)
+		}
+	}
+	for _, key := range adminAuditUserKeys {
+		if record.Params[key] != "" {
+			record.User = record.Params[key]
+			break
+		}
+	}
+	if volKey != "" {
+		record.Vol = record.Params[volKey]
+	}
+	return record
+}
+
+// adminAuditClientIP returns the caller, the followers proxy the calls with X-Forwarded-For set.
+func adminAuditClientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func maskAdminAuditSecret(secret string) string {
+	if len(secret) <= 4 {
+		return "****"
+	}
+	return secret[:4] + "****"
+}
+
+// listAdminAudits returns the records within [start, end] matching op and vol, the newest first.
+func (c *Cluster) listAdminAudits(start, end int64, op, vol string, limit int) (records []*proto.AdminAuditRecord, err error) {
+	all, err := c.loadAdminAudits()
+	if err != nil {
+		return
+	}
+	records = make([]*proto.AdminAuditRecord, 0)
+	for _, record := range all {
+		if (start > 0 && record.Time < start) || (end > 0 && record.Time > end) {
+			continue
+		}
+		if (op != "" && !strings.Contains(record.Op, op)) || (vol != "" && record.Vol != vol) {
+			continue
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID > records[j].ID })
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return
+}
+
+func (c *Cluster) scheduleToCleanAdminAudits() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.cleanAdminAudits(time.Now().Add(-adminAuditRetention))
+			}
+			time.Sleep(adminAuditCleanInterval)
+		}
+	}()
+}
+
+// cleanAdminAudits deletes the records older than expire.
+func (c *Cluster) cleanAdminAudits(expire time.Time) {
+	result, err := c.fsm.store.SeekForPrefix([]byte(adminAuditPrefix))
+	if err != nil {
+		log.LogErrorf("action[cleanAdminAudits] seek failed, err[%v]", err)
+		return
+	}
+	expireKey := adminAuditKey(uint64(expire.UnixNano()))
+	keys := make([]string, 0)
+	for key := range result {
+		if key < expireKey {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for len(keys) > 0 {
+		batch := keys
+		if len(batch) > adminAuditCleanBatch {
+			batch = keys[:adminAuditCleanBatch]
+		}
+		if err = c.syncDeleteAdminAudits(batch); err != nil {
+			log.LogErrorf("action[cleanAdminAudits] delete %v records failed, err[%v]", len(batch), err)
+			return
+		}
+		keys = keys[len(batch):]
+	}
+}
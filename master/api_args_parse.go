@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/storage"
 	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/compressor"
 	"github.com/cubefs/cubefs/util/cryptoutil"
@@ -178,6 +179,48 @@ func parseRequestForAddNode(r *http.Request) (nodeAddr, zoneName string, err err
 	return
 }
 
+func parseRequestForNodeMaintenance(r *http.Request) (nodeAddr string, ttl time.Duration, err error) {
+	if nodeAddr, err = parseAndExtractNodeAddr(r); err != nil {
+		return
+	}
+	var value string
+	if value = r.FormValue(maintenanceTTLKey); value == "" {
+		err = keyNotFound(maintenanceTTLKey)
+		return
+	}
+	if ttl, err = time.ParseDuration(value); err != nil || ttl < 0 {
+		err = unmatchedKey(maintenanceTTLKey)
+	}
+	return
+}
+
+func parseRequestForListAdminAudit(r *http.Request) (start, end int64, op, vol string, limit int, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if value := r.FormValue(startKey); value != "" {
+		if start, err = strconv.ParseInt(value, 10, 64); err != nil {
+			err = unmatchedKey(startKey)
+			return
+		}
+	}
+	if value := r.FormValue(endKey); value != "" {
+		if end, err = strconv.ParseInt(value, 10, 64); err != nil {
+			err = unmatchedKey(endKey)
+			return
+		}
+	}
+	op = r.FormValue(OperateKey)
+	vol = r.FormValue(nameKey)
+	limit = defaultAdminAuditLimit
+	if value := r.FormValue(Limit); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			err = unmatchedKey(Limit)
+		}
+	}
+	return
+}
+
 func parseDecomNodeReq(r *http.Request) (nodeAddr string, limit int, err error) {
 	nodeAddr, err = parseAndExtractNodeAddr(r)
 	if err != nil {
@@ -408,6 +451,10 @@ type updateVolReq struct {
 	coldArgs                *coldVolArgs
 	dpReadOnlyWhenVolFull   bool
 	enableQuota             bool
+	compression             string
+	mediaType               uint32
+	mediaFallback           bool
+	trashRetention          int64
 }
 
 func parseColdVolUpdateArgs(r *http.Request, vol *Vol) (args *coldVolArgs, err error) {
@@ -506,6 +553,22 @@ func parseVolUpdateReq(r *http.Request, vol *Vol, req *updateVolReq) (err error)
 		return
 	}
 
+	if req.compression, err = extractCompression(r, vol.VolType, vol.compression); err != nil {
+		return
+	}
+
+	if req.mediaType, err = extractMediaType(r, vol.mediaType); err != nil {
+		return
+	}
+
+	if req.mediaFallback, err = extractBoolWithDefault(r, mediaFallbackKey, vol.mediaFallback); err != nil {
+		return
+	}
+
+	if req.trashRetention, err = extractInt64WithDefault(r, trashRetentionKey, vol.trashRetention); err != nil {
+		return
+	}
+
 	var txTimeout int64
 	if txTimeout, err = extractTxTimeout(r); err != nil {
 		return
@@ -673,10 +736,17 @@ type createVolReq struct {
 	DpReadOnlyWhenVolFull                bool
 	enableTransaction                    proto.TxOpMask
 	enableQuota                          bool
+	compression                          string
+	mediaType                            uint32
+	mediaFallback                        bool
+	metaStoreType                        proto.MetaStoreType
 	txTimeout                            int64
 	txConflictRetryNum                   int64
 	txConflictRetryInterval              int64
 	qosLimitArgs                         *qosArgs
+	cloneSrc                             *Vol   // the volume to clone the meta partitions from
+	cloneVerSeq                          uint64 // the version of cloneSrc to clone
+	promotePath                          string // the directory of cloneSrc to promote
 	clientReqPeriod, clientHitTriggerCnt uint32
 	// cold vol args
 	coldArgs coldVolArgs
@@ -848,10 +918,52 @@ func parseRequestToCreateVol(r *http.Request, req *createVolReq) (err error) {
 		return
 	}
 
+	if req.compression, err = extractCompression(r, req.volType, ""); err != nil {
+		return
+	}
+
+	if req.mediaType, err = extractMediaType(r, proto.MediaTypeUnspecified); err != nil {
+		return
+	}
+
+	if req.mediaFallback, err = extractBoolWithDefault(r, mediaFallbackKey, true); err != nil {
+		return
+	}
+
+	if req.metaStoreType, err = proto.ParseMetaStoreType(r.FormValue(metaStoreTypeKey)); err != nil {
+		return
+	}
+
 	return
 }
 
-func parseRequestToCreateDataPartition(r *http.Request) (count int, name string, err error) {
+// extractCompression extracts the block compression of the datanode extents, "none" disables it.
+func extractCompression(r *http.Request, volType int, def string) (compression string, err error) {
+	if compression = extractStrWithDefault(r, compressionKey, def); compression == compressionNone {
+		compression = ""
+	}
+	if !storage.IsValidCompression(compression) {
+		return "", fmt.Errorf("compression [%v] is not supported", compression)
+	}
+	if compression != "" && !proto.IsHot(volType) {
+		return "", fmt.Errorf("compression is only supported by hot volume")
+	}
+	return
+}
+
+// extractMediaType extracts the preferred media class of the data partitions, "any" clears it.
+func extractMediaType(r *http.Request, def uint32) (mediaType uint32, err error) {
+	name := r.FormValue(mediaTypeKey)
+	if name == "" {
+		return def, nil
+	}
+	if name == mediaTypeAny {
+		return proto.MediaTypeUnspecified, nil
+	}
+	return proto.ParseMediaType(name)
+}
+
+func parseRequestToCreateDataPartition(r *http.Request) (count int, name string, mediaType uint32, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
 	}
@@ -865,6 +977,9 @@ func parseRequestToCreateDataPartition(r *http.Request) (count int, name string,
 	if name, err = extractName(r); err != nil {
 		return
 	}
+	if mediaType, err = extractMediaType(r, proto.MediaTypeUnspecified); err != nil {
+		return
+	}
 	return
 }
 
@@ -880,6 +995,62 @@ func parseRequestToGetConcurrentLcNode(r *http.Request) (count uint64, err error
 	return
 }
 
+func parseRequestToCloneVol(r *http.Request) (name string, verSeq uint64, cloneName string, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if name, err = extractName(r); err != nil {
+		return
+	}
+	if verSeq, err = extractUint64(r, verSeqKey); err != nil {
+		return
+	}
+	if cloneName = r.FormValue(cloneNameKey); cloneName == "" {
+		err = keyNotFound(cloneNameKey)
+		return
+	}
+	if !volNameRegexp.MatchString(cloneName) {
+		err = errors.New("clone name can only be number and letters")
+	}
+	return
+}
+
+func parseRequestToRenameVol(r *http.Request) (name, newName string, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if name, err = extractName(r); err != nil {
+		return
+	}
+	newName, err = extractNewVolName(r)
+	return
+}
+
+func parseRequestToPromoteVol(r *http.Request) (name, dirPath, newName string, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if name, err = extractName(r); err != nil {
+		return
+	}
+	if dirPath, err = extractPath(r); err != nil {
+		return
+	}
+	newName, err = extractNewVolName(r)
+	return
+}
+
+func extractNewVolName(r *http.Request) (newName string, err error) {
+	if newName = r.FormValue(newNameKey); newName == "" {
+		err = keyNotFound(newNameKey)
+		return
+	}
+	if !volNameRegexp.MatchString(newName) {
+		err = errors.New("new name can only be number and letters")
+	}
+	return
+}
+
 func parseRequestToGetDataPartition(r *http.Request) (ID uint64, volName string, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
@@ -901,6 +1072,38 @@ func parseRequestToBalanceMetaPartition(r *http.Request) (zones string, nodeSetI
 	return
 }
 
+func parseRequestToStartDataRebalance(r *http.Request) (config proto.RebalanceConfig, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if zones := r.FormValue(zoneNameKey); zones != "" {
+		config.Zones = strings.Split(zones, commaSplit)
+	}
+	if nodeSetIds := r.FormValue(nodesetIdKey); nodeSetIds != "" {
+		for _, str := range strings.Split(nodeSetIds, commaSplit) {
+			var id uint64
+			if id, err = strconv.ParseUint(str, 10, 64); err != nil {
+				return
+			}
+			config.NodeSets = append(config.NodeSets, id)
+		}
+	}
+	config.Level = r.FormValue(levelKey)
+	if str := r.FormValue(thresholdKey); str != "" {
+		if config.Threshold, err = strconv.ParseFloat(str, 64); err != nil {
+			err = fmt.Errorf("args [%s] is not legal, val %s", thresholdKey, str)
+			return
+		}
+	}
+	if config.Concurrency, err = extractUint(r, concurrencyKey); err != nil {
+		return
+	}
+	if config.Bandwidth, err = extractUint64(r, bandwidthKey); err != nil {
+		return
+	}
+	return
+}
+
 func parseRequestToLoadDataPartition(r *http.Request) (ID uint64, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
@@ -911,6 +1114,17 @@ func parseRequestToLoadDataPartition(r *http.Request) (ID uint64, err error) {
 	return
 }
 
+func parseRequestToSplitMetaPartition(r *http.Request) (ID uint64, key uint64, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if ID, err = extractMetaPartitionID(r); err != nil {
+		return
+	}
+	key, err = extractUint64(r, inodeKey)
+	return
+}
+
 func parseRequestToAddMetaReplica(r *http.Request) (ID uint64, addr string, err error) {
 	return extractMetaPartitionIDAndAddr(r)
 }
@@ -1281,6 +1495,19 @@ func parseAndExtractSetNodeInfoParams(r *http.Request) (params map[string]interf
 		params[maxDpCntLimitKey] = val
 	}
 
+	for _, key := range []string{mpSplitInodeCountKey, mpMergeInodeCountKey} {
+		if value = r.FormValue(key); value != "" {
+			noParams = false
+			val := uint64(0)
+			val, err = strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				err = unmatchedKey(key)
+				return
+			}
+			params[key] = val
+		}
+	}
+
 	if value = r.FormValue(nodeDpRepairTimeOutKey); value != "" {
 		noParams = false
 		val := uint64(0)
@@ -1817,6 +2044,90 @@ func parseGetQuotaParam(r *http.Request) (volName string, quotaId uint32, err er
 	return
 }
 
+func extractUserQuotaKey(r *http.Request) (key proto.UserQuotaKey, err error) {
+	var value string
+	if value = r.FormValue(userQuotaTypeKey); value == "" {
+		err = keyNotFound(userQuotaTypeKey)
+		return
+	}
+	if key.Type, err = proto.ParseUserQuotaType(value); err != nil {
+		return
+	}
+	if value = r.FormValue(idKey); value == "" {
+		err = keyNotFound(idKey)
+		return
+	}
+	var id uint64
+	if id, err = strconv.ParseUint(value, 10, 32); err != nil {
+		err = fmt.Errorf("args [%s] is not legal, val %s", idKey, value)
+		return
+	}
+	key.Id = uint32(id)
+	return
+}
+
+func parseSetUserQuotaParam(r *http.Request) (volName string, req *proto.UserQuotaInfo, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if volName, err = extractName(r); err != nil {
+		return
+	}
+	var key proto.UserQuotaKey
+	if key, err = extractUserQuotaKey(r); err != nil {
+		return
+	}
+	req = &proto.UserQuotaInfo{Type: key.Type, Id: key.Id}
+	if req.SoftBytes, err = extractUint64(r, softBytesKey); err != nil {
+		return
+	}
+	if req.HardBytes, err = extractUint64(r, hardBytesKey); err != nil {
+		return
+	}
+	if req.SoftFiles, err = extractUint64(r, softFilesKey); err != nil {
+		return
+	}
+	if req.HardFiles, err = extractUint64(r, hardFilesKey); err != nil {
+		return
+	}
+	if req.GracePeriod, err = extractInt64WithDefault(r, gracePeriodKey, int64(defaultUserQuotaGracePeriod/time.Second)); err != nil {
+		return
+	}
+	if !req.HasLimit() {
+		err = fmt.Errorf("at least one of %v, %v, %v and %v should be set", softBytesKey, hardBytesKey, softFilesKey, hardFilesKey)
+		return
+	}
+	return
+}
+
+func parseDeleteUserQuotaParam(r *http.Request) (volName string, key proto.UserQuotaKey, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if volName, err = extractName(r); err != nil {
+		return
+	}
+	key, err = extractUserQuotaKey(r)
+	return
+}
+
+func parseUserQuotaReportParam(r *http.Request) (volName string, quotaType *proto.UserQuotaType, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if volName, err = extractName(r); err != nil {
+		return
+	}
+	if value := r.FormValue(userQuotaTypeKey); value != "" {
+		var t proto.UserQuotaType
+		if t, err = proto.ParseUserQuotaType(value); err != nil {
+			return
+		}
+		quotaType = &t
+	}
+	return
+}
+
 func extractPath(r *http.Request) (fullPath string, err error) {
 	if fullPath = r.FormValue(fullPathKey); fullPath == "" {
 		err = keyNotFound(nameKey)
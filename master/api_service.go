@@ -80,7 +80,8 @@ type InvalidNodeView struct {
 
 // TopologyView provides the view of the topology view of the cluster
 type TopologyView struct {
-	Zones []*ZoneView
+	Zones      []*ZoneView
+	Violations []*proto.FailureDomainViolation `json:",omitempty"`
 }
 
 type NodeSetView struct {
@@ -316,6 +317,116 @@ func (m *Server) setEnableAuditLogForVolume(w http.ResponseWriter, r *http.Reque
 	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set volume audit log to (%v) success", status)))
 }
 
+func (m *Server) setVolReplication(w http.ResponseWriter, r *http.Request) {
+	var (
+		name   string
+		enable bool
+		err    error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminSetVolReplication))
+	defer func() {
+		doStatAndMetric(proto.AdminSetVolReplication, metric, err, map[string]string{exporter.Vol: name})
+	}()
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	replication := &proto.VolReplication{
+		TargetMasters: r.FormValue(targetMastersKey),
+		TargetVolume:  r.FormValue(targetVolumeKey),
+		Enable:        true,
+		CreateTime:    time.Now().Unix(),
+	}
+	if replication.TargetMasters == "" {
+		err = keyNotFound(targetMastersKey)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if replication.TargetVolume == "" {
+		replication.TargetVolume = name
+	}
+	if r.FormValue(enableKey) != "" {
+		if enable, err = extractStatus(r); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+			return
+		}
+		replication.Enable = enable
+	}
+	vol, err := m.cluster.getVol(name)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	old := vol.getReplication()
+	if old != nil && old.TargetMasters == replication.TargetMasters && old.TargetVolume == replication.TargetVolume {
+		replication.CreateTime = old.CreateTime
+	}
+	vol.setReplication(replication)
+	if err = m.cluster.syncUpdateVol(vol); err != nil {
+		vol.setReplication(old)
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	log.LogInfof("action[setVolReplication] vol(%v) replication(%v)", name, *replication)
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set replication of volume(%v) to(%v) of(%v) success",
+		name, replication.TargetVolume, replication.TargetMasters)))
+}
+
+func (m *Server) getVolReplication(w http.ResponseWriter, r *http.Request) {
+	var (
+		name string
+		err  error
+	)
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	vol, err := m.cluster.getVol(name)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	view := &proto.VolReplicationView{
+		Config: vol.getReplication(),
+		Task:   m.cluster.replicationMgr.getTask(name),
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(view))
+}
+
+func (m *Server) deleteVolReplication(w http.ResponseWriter, r *http.Request) {
+	var (
+		name string
+		err  error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminDelVolReplication))
+	defer func() {
+		doStatAndMetric(proto.AdminDelVolReplication, metric, err, map[string]string{exporter.Vol: name})
+	}()
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	vol, err := m.cluster.getVol(name)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	old := vol.getReplication()
+	if old == nil {
+		err = fmt.Errorf("replication of volume(%v) is not set", name)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	vol.setReplication(nil)
+	if err = m.cluster.syncUpdateVol(vol); err != nil {
+		vol.setReplication(old)
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	log.LogInfof("action[deleteVolReplication] vol(%v) replication(%v) deleted", name, *old)
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("delete replication of volume(%v) success", name)))
+}
+
 func (m *Server) setupForbidMetaPartitionDecommission(w http.ResponseWriter, r *http.Request) {
 	var (
 		status bool
@@ -368,6 +479,7 @@ func (m *Server) getTopology(w http.ResponseWriter, r *http.Request) {
 				nsView.DataNodes = append(nsView.DataNodes, proto.NodeView{
 					ID: dataNode.ID, Addr: dataNode.Addr,
 					DomainAddr: dataNode.DomainAddr, IsActive: dataNode.isActive, IsWritable: dataNode.isWriteAble(),
+					RackName: dataNode.RackName, HostName: dataNode.HostName,
 				})
 				return true
 			})
@@ -376,11 +488,13 @@ func (m *Server) getTopology(w http.ResponseWriter, r *http.Request) {
 				nsView.MetaNodes = append(nsView.MetaNodes, proto.NodeView{
 					ID: metaNode.ID, Addr: metaNode.Addr,
 					DomainAddr: metaNode.DomainAddr, IsActive: metaNode.IsActive, IsWritable: metaNode.isWritable(),
+					RackName: metaNode.RackName, HostName: metaNode.HostName,
 				})
 				return true
 			})
 		}
 	}
+	tv.Violations = m.cluster.failureDomainViolations()
 	sendOkReply(w, r, newSuccessHTTPReply(tv))
 }
 
@@ -1446,6 +1560,7 @@ func (m *Server) createDataPartition(w http.ResponseWriter, r *http.Request) {
 		volName                    string
 		vol                        *Vol
 		reqCreateCount             int
+		mediaType                  uint32
 		lastTotalDataPartitions    int
 		clusterTotalDataPartitions int
 		err                        error
@@ -1455,7 +1570,7 @@ func (m *Server) createDataPartition(w http.ResponseWriter, r *http.Request) {
 		doStatAndMetric(proto.AdminCreateDataPartition, metric, err, map[string]string{exporter.Vol: volName})
 	}()
 
-	if reqCreateCount, volName, err = parseRequestToCreateDataPartition(r); err != nil {
+	if reqCreateCount, volName, mediaType, err = parseRequestToCreateDataPartition(r); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
@@ -1472,7 +1587,10 @@ func (m *Server) createDataPartition(w http.ResponseWriter, r *http.Request) {
 
 	lastTotalDataPartitions = len(vol.dataPartitions.partitions)
 	clusterTotalDataPartitions = m.cluster.getDataPartitionCount()
-	err = m.cluster.batchCreateDataPartition(vol, reqCreateCount, false)
+	if mediaType == proto.MediaTypeUnspecified {
+		mediaType = vol.mediaType
+	}
+	err = m.cluster.batchCreateDataPartitionOnMedia(vol, reqCreateCount, false, mediaType)
 	rstMsg = fmt.Sprintf(" createDataPartition succeeeds. "+
 		"clusterLastTotalDataPartitions[%v],vol[%v] has %v data partitions previously and %v data partitions now",
 		clusterTotalDataPartitions, volName, lastTotalDataPartitions, len(vol.dataPartitions.partitions))
@@ -1541,12 +1659,19 @@ func (m *Server) getDataPartition(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if volName != "" {
-		if vol, err = m.cluster.getVol(volName); err != nil {
+		if vol, err = m.cluster.getClientVol(volName); err != nil {
 			sendErrReply(w, r, newErrHTTPReply(proto.ErrDataPartitionNotExists))
 			return
 		}
 		if dp, err = vol.getDataPartitionByID(partitionID); err != nil {
-			sendErrReply(w, r, newErrHTTPReply(proto.ErrDataPartitionNotExists))
+			// the extents of a clone may still be on the partitions of its source
+			if dp, err = m.cluster.getCloneSourceDataPartition(vol, partitionID); err != nil {
+				sendErrReply(w, r, newErrHTTPReply(proto.ErrDataPartitionNotExists))
+				return
+			}
+			info := dp.buildDpInfo(m.cluster)
+			info.Status = proto.ReadOnly
+			sendOkReply(w, r, newSuccessHTTPReply(info))
 			return
 		}
 	} else {
@@ -1679,6 +1804,90 @@ func (m *Server) deleteDataReplica(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
 }
 
+// split a meta partition online at the given inode, or its median inode if no inode is given
+func (m *Server) splitMetaPartition(w http.ResponseWriter, r *http.Request) {
+	var (
+		vol         *Vol
+		mp          *MetaPartition
+		child       *MetaPartition
+		partitionID uint64
+		key         uint64
+		err         error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminSplitMetaPartition))
+	defer func() {
+		doStatAndMetric(proto.AdminSplitMetaPartition, metric, err, nil)
+	}()
+
+	if partitionID, key, err = parseRequestToSplitMetaPartition(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if mp, err = m.cluster.getMetaPartitionByID(partitionID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrMetaPartitionNotExists))
+		return
+	}
+	if vol, err = m.cluster.getVol(mp.volName); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	if child, err = m.cluster.splitMetaPartitionByInode(vol, mp, key); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf("meta partition[%v] is split to [%v] at inode[%v] successfully", partitionID, child.PartitionID, child.Start)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+// merge a meta partition with the adjacent partition of the higher inode range
+func (m *Server) mergeMetaPartition(w http.ResponseWriter, r *http.Request) {
+	var (
+		vol         *Vol
+		mp          *MetaPartition
+		next        *MetaPartition
+		partitionID uint64
+		err         error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminMergeMetaPartition))
+	defer func() {
+		doStatAndMetric(proto.AdminMergeMetaPartition, metric, err, nil)
+	}()
+
+	if err = r.ParseForm(); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if partitionID, err = extractMetaPartitionID(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if mp, err = m.cluster.getMetaPartitionByID(partitionID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrMetaPartitionNotExists))
+		return
+	}
+	if vol, err = m.cluster.getVol(mp.volName); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	for _, p := range vol.cloneMetaPartitionMap() {
+		if p.Start == mp.End+1 {
+			next = p
+			break
+		}
+	}
+	if next == nil {
+		err = fmt.Errorf("meta partition[%v] has no adjacent partition to merge", partitionID)
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	if err = m.cluster.mergeMetaPartitions(vol, mp, next); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf("meta partition[%v] is merged into [%v] successfully", next.PartitionID, partitionID)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
 func (m *Server) addMetaReplica(w http.ResponseWriter, r *http.Request) {
 	var (
 		msg         string
@@ -1842,6 +2051,43 @@ func (m *Server) balanceMetaPartitionLeader(w http.ResponseWriter, r *http.Reque
 	_ = sendOkReply(w, r, newSuccessHTTPReply(rstMsg))
 }
 
+func (m *Server) startDataRebalance(w http.ResponseWriter, r *http.Request) {
+	var (
+		config proto.RebalanceConfig
+		err    error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminStartDataRebalance))
+	defer func() {
+		doStatAndMetric(proto.AdminStartDataRebalance, metric, err, nil)
+	}()
+	if config, err = parseRequestToStartDataRebalance(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.rebalanceMgr.start(config); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply("start data partition rebalance successfully"))
+}
+
+func (m *Server) stopDataRebalance(w http.ResponseWriter, r *http.Request) {
+	var err error
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminStopDataRebalance))
+	defer func() {
+		doStatAndMetric(proto.AdminStopDataRebalance, metric, err, nil)
+	}()
+	if err = m.cluster.rebalanceMgr.stop(); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply("stop data partition rebalance successfully"))
+}
+
+func (m *Server) getDataRebalance(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.rebalanceMgr.getView()))
+}
+
 // Decommission a data partition. This usually happens when disk error has been reported.
 // This function needs to be called manually by the admin.
 func (m *Server) decommissionDataPartition(w http.ResponseWriter, r *http.Request) {
@@ -1977,6 +2223,13 @@ func (m *Server) diagnoseDataPartition(w http.ResponseWriter, r *http.Request) {
 		excessReplicaDpIDs = append(excessReplicaDpIDs, dp.PartitionID)
 	}
 
+	// the partitions on the nodes in maintenance are degraded by design, they are reported on their own
+	maintenanceNodes, degradedDpIDs := m.cluster.degradedByDesign(TypeDataPartition)
+	inactiveNodes = withoutMaintenanceNodes(inactiveNodes, maintenanceNodes)
+	corruptDpIDs = withoutDegradedByDesign(corruptDpIDs, degradedDpIDs)
+	lackReplicaDpIDs = withoutDegradedByDesign(lackReplicaDpIDs, degradedDpIDs)
+	badReplicaDpIDs = withoutDegradedByDesign(badReplicaDpIDs, degradedDpIDs)
+
 	// badDataPartitions = m.cluster.getBadDataPartitionsView()
 	badDataPartitionInfos = m.cluster.getBadDataPartitionsRepairView()
 	rstMsg = &proto.DataPartitionDiagnosis{
@@ -1988,6 +2241,8 @@ func (m *Server) diagnoseDataPartition(w http.ResponseWriter, r *http.Request) {
 		RepFileCountDifferDpIDs:     repFileCountDifferDpIDs,
 		RepUsedSizeDifferDpIDs:      repUsedSizeDifferDpIDs,
 		ExcessReplicaDpIDs:          excessReplicaDpIDs,
+		MaintenanceDataNodes:        maintenanceNodes,
+		DegradedByDesignDpIDs:       degradedDpIDs,
 	}
 	log.LogInfof("diagnose dataPartition[%v] inactiveNodes:[%v], corruptDpIDs:[%v], "+
 		"lackReplicaDpIDs:[%v], BadReplicaDataPartitionIDs[%v], "+
@@ -2294,6 +2549,10 @@ func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 	newArgs.txConflictRetryInterval = req.txConflictRetryInterval
 	newArgs.txOpLimit = req.txOpLimit
 	newArgs.enableQuota = req.enableQuota
+	newArgs.compression = req.compression
+	newArgs.mediaType = req.mediaType
+	newArgs.mediaFallback = req.mediaFallback
+	newArgs.trashRetention = req.trashRetention
 	if req.coldArgs != nil {
 		newArgs.coldArgs = req.coldArgs
 	}
@@ -2537,6 +2796,106 @@ func (m *Server) createVol(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
 }
 
+func (m *Server) cloneVol(w http.ResponseWriter, r *http.Request) {
+	var (
+		name      string
+		cloneName string
+		verSeq    uint64
+		src       *Vol
+		vol       *Vol
+		err       error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminCloneVol))
+	defer func() {
+		doStatAndMetric(proto.AdminCloneVol, metric, err, map[string]string{exporter.Vol: name})
+	}()
+
+	if name, verSeq, cloneName, err = parseRequestToCloneVol(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if src, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	if vol, err = m.cluster.cloneVol(src, verSeq, cloneName); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	if err = m.associateVolWithUser(vol.Owner, vol.Name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+
+	msg := fmt.Sprintf("clone vol[%v] from version[%v] of vol[%v] successfully, has allocate [%v] data partitions",
+		cloneName, verSeq, name, len(vol.dataPartitions.partitions))
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) renameVol(w http.ResponseWriter, r *http.Request) {
+	var (
+		name    string
+		newName string
+		err     error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminRenameVol))
+	defer func() {
+		doStatAndMetric(proto.AdminRenameVol, metric, err, map[string]string{exporter.Vol: name})
+	}()
+
+	if name, newName, err = parseRequestToRenameVol(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if _, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	if err = m.cluster.renameVol(name, newName, m.user); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+
+	msg := fmt.Sprintf("rename vol[%v] to [%v] successfully", name, newName)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) promoteVol(w http.ResponseWriter, r *http.Request) {
+	var (
+		name    string
+		dirPath string
+		newName string
+		src     *Vol
+		vol     *Vol
+		err     error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminPromoteVol))
+	defer func() {
+		doStatAndMetric(proto.AdminPromoteVol, metric, err, map[string]string{exporter.Vol: name})
+	}()
+
+	if name, dirPath, newName, err = parseRequestToPromoteVol(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if src, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	if vol, err = m.cluster.promoteVol(src, dirPath, newName); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	if err = m.associateVolWithUser(vol.Owner, vol.Name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+
+	msg := fmt.Sprintf("promote [%v] of vol[%v] to vol[%v] at version[%v] successfully",
+		dirPath, name, newName, vol.cloneVerSeq)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
 func (m *Server) qosUpload(w http.ResponseWriter, r *http.Request) {
 	var (
 		err   error
@@ -2607,7 +2966,7 @@ func (m *Server) getVolSimpleInfo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if vol, err = m.cluster.getVol(name); err != nil {
+	if vol, err = m.cluster.getClientVol(name); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
 		return
 	}
@@ -2645,6 +3004,14 @@ func newSimpleView(vol *Vol) (view *proto.SimpleVolView) {
 		FollowerRead:            vol.FollowerRead,
 		EnablePosixAcl:          vol.enablePosixAcl,
 		EnableQuota:             vol.enableQuota,
+		Compression:             vol.compression,
+		MediaType:               proto.MediaTypeString(vol.mediaType),
+		MediaFallback:           vol.mediaFallback,
+		TrashRetention:          vol.trashRetention,
+		CloneSrc:                vol.cloneSrc,
+		CloneVerSeq:             vol.cloneVerSeq,
+		PromotePath:             vol.promotePath,
+		MetaStoreType:           vol.metaStoreType.String(),
 		EnableTransaction:       proto.GetMaskString(vol.enableTransaction),
 		TxTimeout:               vol.txTimeout,
 		TxConflictRetryNum:      vol.txConflictRetryNum,
@@ -2751,6 +3118,14 @@ func (m *Server) addDataNode(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
+	var dataNode *DataNode
+	if dataNode, err = m.cluster.dataNode(nodeAddr); err == nil {
+		err = m.cluster.updateDataNodeLabels(dataNode, r.FormValue(rackNameKey), r.FormValue(hostNameKey))
+	}
+	if err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
 	sendOkReply(w, r, newSuccessHTTPReply(id))
 }
 
@@ -2784,6 +3159,9 @@ func (m *Server) getDataNode(w http.ResponseWriter, r *http.Request) {
 		AvailableSpace:            dataNode.AvailableSpace,
 		ID:                        dataNode.ID,
 		ZoneName:                  dataNode.ZoneName,
+		RackName:                  dataNode.RackName,
+		HostName:                  dataNode.HostName,
+		MaintenanceExpire:         dataNode.MaintenanceExpire,
 		Addr:                      dataNode.Addr,
 		DomainAddr:                dataNode.DomainAddr,
 		ReportTime:                dataNode.ReportTime,
@@ -2843,6 +3221,29 @@ func (m *Server) decommissionDataNode(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(rstMsg))
 }
 
+func (m *Server) setDataNodeMaintenance(w http.ResponseWriter, r *http.Request) {
+	var (
+		nodeAddr string
+		ttl      time.Duration
+		view     *proto.NodeMaintenanceView
+		err      error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.SetDataNodeMaintenance))
+	defer func() {
+		doStatAndMetric(proto.SetDataNodeMaintenance, metric, err, nil)
+	}()
+
+	if nodeAddr, ttl, err = parseRequestForNodeMaintenance(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if view, err = m.cluster.setDataNodeMaintenance(nodeAddr, ttl); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(view))
+}
+
 func (m *Server) migrateDataNodeHandler(w http.ResponseWriter, r *http.Request) {
 	var (
 		srcAddr, targetAddr string
@@ -3056,6 +3457,24 @@ func (m *Server) setNodeInfoHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if val, ok := params[mpSplitInodeCountKey]; ok {
+		if v, ok := val.(uint64); ok {
+			if err = m.cluster.setMetaPartitionSplitInodeCount(v); err != nil {
+				sendErrReply(w, r, newErrHTTPReply(err))
+				return
+			}
+		}
+	}
+
+	if val, ok := params[mpMergeInodeCountKey]; ok {
+		if v, ok := val.(uint64); ok {
+			if err = m.cluster.setMetaPartitionMergeInodeCount(v); err != nil {
+				sendErrReply(w, r, newErrHTTPReply(err))
+				return
+			}
+		}
+	}
+
 	if val, ok := params[clusterCreateTimeKey]; ok {
 		if createTimeParam, ok := val.(string); ok {
 			var createTime time.Time
@@ -3875,6 +4294,8 @@ func (m *Server) getNodeInfoHandler(w http.ResponseWriter, r *http.Request) {
 	resp[nodeDpMaxRepairErrCntKey] = fmt.Sprintf("%v", m.cluster.cfg.DpMaxRepairErrCnt)
 	resp[clusterLoadFactorKey] = fmt.Sprintf("%v", m.cluster.cfg.ClusterLoadFactor)
 	resp[maxDpCntLimitKey] = fmt.Sprintf("%v", m.cluster.cfg.MaxDpCntLimit)
+	resp[mpSplitInodeCountKey] = fmt.Sprintf("%v", atomic.LoadUint64(&m.cluster.cfg.MetaPartitionSplitInodeCount))
+	resp[mpMergeInodeCountKey] = fmt.Sprintf("%v", atomic.LoadUint64(&m.cluster.cfg.MetaPartitionMergeInodeCount))
 
 	sendOkReply(w, r, newSuccessHTTPReply(resp))
 }
@@ -3943,6 +4364,12 @@ func (m *Server) diagnoseMetaPartition(w http.ResponseWriter, r *http.Request) {
 	for _, mp := range dentryCountNotEqualReplicaMps {
 		dentryCountNotEqualReplicaMpIDs = append(dentryCountNotEqualReplicaMpIDs, mp.PartitionID)
 	}
+	maintenanceNodes, degradedMpIDs := m.cluster.degradedByDesign(TypeMetaPartition)
+	inactiveNodes = withoutMaintenanceNodes(inactiveNodes, maintenanceNodes)
+	corruptMpIDs = withoutDegradedByDesign(corruptMpIDs, degradedMpIDs)
+	lackReplicaMpIDs = withoutDegradedByDesign(lackReplicaMpIDs, degradedMpIDs)
+	badReplicaMpIDs = withoutDegradedByDesign(badReplicaMpIDs, degradedMpIDs)
+
 	badMetaPartitions = m.cluster.getBadMetaPartitionsView()
 	rstMsg = &proto.MetaPartitionDiagnosis{
 		InactiveMetaNodes:                          inactiveNodes,
@@ -3954,6 +4381,8 @@ func (m *Server) diagnoseMetaPartition(w http.ResponseWriter, r *http.Request) {
 		InodeCountNotEqualReplicaMetaPartitionIDs:  inodeCountNotEqualReplicaMpIDs,
 		MaxInodeNotEqualReplicaMetaPartitionIDs:    maxInodeNotEqualReplicaMpIDs,
 		DentryCountNotEqualReplicaMetaPartitionIDs: dentryCountNotEqualReplicaMpIDs,
+		MaintenanceMetaNodes:                       maintenanceNodes,
+		DegradedByDesignMetaPartitionIDs:           degradedMpIDs,
 	}
 	log.LogInfof("diagnose metaPartition cluster[%v], inactiveNodes:[%v], corruptMpIDs:[%v], "+
 		"lackReplicaMpIDs:[%v], badReplicaMpIDs:[%v], excessReplicaDpIDs[%v] "+
@@ -4288,9 +4717,40 @@ func (m *Server) addMetaNode(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
+	var metaNode *MetaNode
+	if metaNode, err = m.cluster.metaNode(nodeAddr); err == nil {
+		err = m.cluster.updateMetaNodeLabels(metaNode, r.FormValue(rackNameKey), r.FormValue(hostNameKey))
+	}
+	if err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
 	sendOkReply(w, r, newSuccessHTTPReply(id))
 }
 
+func (m *Server) setMetaNodeMaintenance(w http.ResponseWriter, r *http.Request) {
+	var (
+		nodeAddr string
+		ttl      time.Duration
+		view     *proto.NodeMaintenanceView
+		err      error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.SetMetaNodeMaintenance))
+	defer func() {
+		doStatAndMetric(proto.SetMetaNodeMaintenance, metric, err, nil)
+	}()
+
+	if nodeAddr, ttl, err = parseRequestForNodeMaintenance(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if view, err = m.cluster.setMetaNodeMaintenance(nodeAddr, ttl); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(view))
+}
+
 func (m *Server) checkInvalidIDNodes(w http.ResponseWriter, r *http.Request) {
 	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminGetInvalidNodes))
 	defer func() {
@@ -4374,6 +4834,9 @@ func (m *Server) getMetaNode(w http.ResponseWriter, r *http.Request) {
 		IsActive:                  metaNode.IsActive,
 		IsWriteAble:               metaNode.isWritable(),
 		ZoneName:                  metaNode.ZoneName,
+		RackName:                  metaNode.RackName,
+		HostName:                  metaNode.HostName,
+		MaintenanceExpire:         metaNode.MaintenanceExpire,
 		MaxMemAvailWeight:         metaNode.MaxMemAvailWeight,
 		Total:                     metaNode.Total,
 		Used:                      metaNode.Used,
@@ -4766,7 +5229,7 @@ func (m *Server) getMetaPartitions(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
-	if vol, err = m.cluster.getVol(name); err != nil {
+	if vol, err = m.cluster.getClientVol(name); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
 		return
 	}
@@ -4849,7 +5312,7 @@ func (m *Server) getDataPartitions(w http.ResponseWriter, r *http.Request) {
 		send(w, r, body)
 		return
 	}
-	if vol, err = m.cluster.getVol(name); err != nil {
+	if vol, err = m.cluster.getClientVol(name); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
 		return
 	}
@@ -4922,7 +5385,7 @@ func (m *Server) getVol(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	volName = param.name
-	if vol, err = m.cluster.getVol(param.name); err != nil {
+	if vol, err = m.cluster.getClientVol(param.name); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
 		return
 	}
@@ -4974,7 +5437,7 @@ func (m *Server) getVolStatInfo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if vol, err = m.cluster.getVol(name); err != nil {
+	if vol, err = m.cluster.getClientVol(name); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
 		return
 	}
@@ -5010,6 +5473,7 @@ func volStat(vol *Vol, countByMeta bool) (stat *proto.VolStatInfo) {
 
 	log.LogDebugf("total[%v],usedSize[%v]", stat.TotalSize, stat.UsedSize)
 	if proto.IsHot(vol.VolType) {
+		stat.MediaUsages = vol.dataPartitions.mediaUsages()
 		return
 	}
 
@@ -5263,6 +5727,20 @@ func (m *Server) DelVersion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	vol.VersionMgr.RLock()
+	kept := vol.VersionMgr.isDirSnapshotVer(verSeq)
+	vol.VersionMgr.RUnlock()
+	if kept {
+		err = fmt.Errorf("version %v is kept by directory snapshots, delete the snapshots instead", verSeq)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVersionOpError, Msg: err.Error()})
+		return
+	}
+	if m.cluster.isClonedVer(name, verSeq) {
+		err = fmt.Errorf("version %v is shared by the clones of vol %v, delete the clones first", verSeq, name)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVersionOpError, Msg: err.Error()})
+		return
+	}
+
 	if _, err = vol.VersionMgr.createVer2PhaseTask(m.cluster, verSeq, proto.DeleteVersion, force); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVersionOpError, Msg: err.Error()})
 		return
@@ -5332,6 +5810,123 @@ func (m *Server) GetAllVersionInfo(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(verList))
 }
 
+func (m *Server) CreateDirSnapshot(w http.ResponseWriter, r *http.Request) {
+	var (
+		err          error
+		vol          *Vol
+		name         string
+		snapshotName string
+		dirPath      string
+		ino          uint64
+		value        string
+		force        bool
+		info         *proto.DirSnapshotInfo
+	)
+	if err = r.ParseForm(); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrParamError))
+		return
+	}
+	if name, err = extractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if snapshotName = r.FormValue(snapshotNameKey); snapshotName == "" {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: keyNotFound(snapshotNameKey).Error()})
+		return
+	}
+	if dirPath, err = extractPath(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if ino, err = extractInodeId(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if value = r.FormValue(forceKey); value != "" {
+		force, _ = strconv.ParseBool(value)
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+
+	if info, err = vol.VersionMgr.createDirSnapshot(m.cluster, snapshotName, dirPath, ino, force); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVersionOpError, Msg: err.Error()})
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(info))
+}
+
+func (m *Server) DelDirSnapshot(w http.ResponseWriter, r *http.Request) {
+	var (
+		err          error
+		vol          *Vol
+		name         string
+		snapshotName string
+		ino          uint64
+		value        string
+		force        bool
+	)
+	if err = r.ParseForm(); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrParamError))
+		return
+	}
+	if name, err = extractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if snapshotName = r.FormValue(snapshotNameKey); snapshotName == "" {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: keyNotFound(snapshotNameKey).Error()})
+		return
+	}
+	if ino, err = extractInodeId(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if value = r.FormValue(forceKey); value != "" {
+		force, _ = strconv.ParseBool(value)
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+
+	if err = vol.VersionMgr.deleteDirSnapshot(m.cluster, snapshotName, ino, force); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVersionOpError, Msg: err.Error()})
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("delete snapshot [%v] of vol [%v] success", snapshotName, name)))
+}
+
+func (m *Server) ListDirSnapshots(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		vol  *Vol
+		name string
+		ino  uint64
+	)
+	if err = r.ParseForm(); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrParamError))
+		return
+	}
+	if name, err = extractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if r.FormValue(inodeKey) != "" {
+		if ino, err = extractInodeId(r); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+			return
+		}
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+
+	sendOkReply(w, r, newSuccessHTTPReply(vol.VersionMgr.listDirSnapshots(ino)))
+}
+
 func (m *Server) SetVerStrategy(w http.ResponseWriter, r *http.Request) {
 	var (
 		err      error
@@ -5649,6 +6244,30 @@ func (m *Server) queryAutoDecommissionDisk(w http.ResponseWriter, r *http.Reques
 	sendOkReply(w, r, newSuccessHTTPReply(enable))
 }
 
+func (m *Server) listAdminAudits(w http.ResponseWriter, r *http.Request) {
+	var (
+		start, end int64
+		op, vol    string
+		limit      int
+		records    []*proto.AdminAuditRecord
+		err        error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminListAudit))
+	defer func() {
+		doStatAndMetric(proto.AdminListAudit, metric, err, nil)
+	}()
+
+	if start, end, op, vol, limit, err = parseRequestForListAdminAudit(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if records, err = m.cluster.listAdminAudits(start, end, op, vol, limit); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(records))
+}
+
 func (m *Server) queryDisableDisk(w http.ResponseWriter, r *http.Request) {
 	var (
 		node     *DataNode
@@ -6079,6 +6698,93 @@ func (m *Server) GetQuota(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+func (m *Server) SetUserQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		vol  *Vol
+		name string
+		req  *proto.UserQuotaInfo
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.UserQuotaSet))
+	defer func() {
+		doStatAndMetric(proto.UserQuotaSet, metric, err, map[string]string{exporter.Vol: name})
+	}()
+
+	if name, req, err = parseSetUserQuotaParam(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+
+	if err = vol.userQuotaManager.setQuota(req); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+
+	msg := fmt.Sprintf("set %v quota of id %v successfully, vol [%v]", req.Type, req.Id, name)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) DeleteUserQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		vol  *Vol
+		name string
+		key  proto.UserQuotaKey
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.UserQuotaDelete))
+	defer func() {
+		doStatAndMetric(proto.UserQuotaDelete, metric, err, map[string]string{exporter.Vol: name})
+	}()
+
+	if name, key, err = parseDeleteUserQuotaParam(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+
+	if err = vol.userQuotaManager.deleteQuota(key); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+
+	msg := fmt.Sprintf("delete %v quota of id %v successfully, vol [%v]", key.Type, key.Id, name)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) ReportUserQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		vol       *Vol
+		name      string
+		quotaType *proto.UserQuotaType
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.UserQuotaReport))
+	defer func() {
+		doStatAndMetric(proto.UserQuotaReport, metric, err, map[string]string{exporter.Vol: name})
+	}()
+
+	if name, quotaType, err = parseUserQuotaReportParam(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+
+	sendOkReply(w, r, newSuccessHTTPReply(vol.userQuotaManager.report(quotaType)))
+}
+
 // func (m *Server) BatchModifyQuotaFullPath(w http.ResponseWriter, r *http.Request) {
 // 	var (
 // 		name              string
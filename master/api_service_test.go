@@ -23,6 +23,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -30,9 +31,12 @@ import (
 
 	"github.com/cubefs/cubefs/master/mocktest"
 	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/compressor"
 	"github.com/cubefs/cubefs/util/config"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -280,6 +284,183 @@ func TestSetMetaNodeThreshold(t *testing.T) {
 	}
 }
 
+func TestAdminAudit(t *testing.T) {
+	volName := "auditNotExistVol"
+	req := map[string]interface{}{nameKey: volName, volAuthKey: "0123456789abcdef", ClientIDKey: "secret"}
+	processWithFatalV2(proto.AdminDeleteVol, false, req, t)
+
+	listAudits := func(req map[string]interface{}) (records []*proto.AdminAuditRecord) {
+		reply := processWithFatalV2(proto.AdminListAudit, true, req, t)
+		require.NoError(t, json.Unmarshal(reply.Data, &records))
+		return
+	}
+	records := listAudits(map[string]interface{}{OperateKey: proto.AdminDeleteVol, nameKey: volName})
+	require.Len(t, records, 1)
+	record := records[0]
+	require.Equal(t, proto.AdminDeleteVol, record.Op)
+	require.Equal(t, volName, record.Vol)
+	require.Equal(t, "0123****", record.AuthKey)
+	require.Equal(t, "secr****", record.Params[ClientIDKey])
+	require.Equal(t, "127.0.0.1", record.ClientIP)
+	require.NotEqual(t, proto.ErrCodeSuccess, record.Code)
+
+	// the queries are not audited
+	require.Empty(t, listAudits(map[string]interface{}{OperateKey: proto.AdminListAudit}))
+	require.Empty(t, listAudits(map[string]interface{}{OperateKey: proto.AdminDeleteVol, nameKey: volName,
+		startKey: record.Time + 1}))
+
+	server.cluster.cleanAdminAudits(time.Unix(record.Time+1, 0))
+	require.Empty(t, listAudits(map[string]interface{}{nameKey: volName}))
+}
+
+func TestRBAC(t *testing.T) {
+	createUser := func(id string, role proto.Role) *proto.UserInfo {
+		userInfo, err := server.user.createKey(&proto.UserCreateParam{ID: id, Type: proto.UserTypeNormal, Role: role})
+		require.NoError(t, err)
+		return userInfo
+	}
+	reader := createUser("rbacReader", proto.RoleReadOnly)
+	operator := createUser("rbacOperator", proto.RoleOperator)
+	server.config.enableRBAC = true
+	defer func() {
+		server.config.enableRBAC = false
+	}()
+
+	newRequest := func(user *proto.UserInfo, secretKey, path string, req map[string]interface{}) *http.Request {
+		httpReq, err := http.NewRequest(http.MethodGet, buildUrl(hostAddr, path, req), nil)
+		require.NoError(t, err)
+		if user != nil {
+			date := strconv.FormatInt(time.Now().Unix(), 10)
+			nonce := uuid.NewString()
+			httpReq.Header.Set(proto.HeaderAccessKey, user.AccessKey)
+			httpReq.Header.Set(proto.HeaderDate, date)
+			httpReq.Header.Set(proto.HeaderNonce, nonce)
+			httpReq.Header.Set(proto.HeaderSignature,
+				proto.SignAdminRequest(secretKey, http.MethodGet, httpReq.URL.Path, httpReq.URL.RawQuery, date, nonce, nil))
+		}
+		return httpReq
+	}
+	send := func(httpReq *http.Request) *proto.HTTPReply {
+		resp, err := http.DefaultClient.Do(httpReq)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		reply := &proto.HTTPReply{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(reply))
+		return reply
+	}
+	request := func(user *proto.UserInfo, secretKey, path string, req map[string]interface{}) *proto.HTTPReply {
+		return send(newRequest(user, secretKey, path, req))
+	}
+	maintenance := map[string]interface{}{addrKey: mds1Addr, maintenanceTTLKey: "0s"}
+
+	// the mutating apis need a signed request of a user granted the access
+	require.EqualValues(t, proto.ErrCodeNoPermission, request(nil, "", proto.SetDataNodeMaintenance, maintenance).Code)
+	require.EqualValues(t, proto.ErrCodeNoPermission, request(reader, reader.SecretKey, proto.SetDataNodeMaintenance, maintenance).Code)
+	require.EqualValues(t, proto.ErrCodeNoPermission, request(operator, reader.SecretKey, proto.SetDataNodeMaintenance, maintenance).Code)
+	require.EqualValues(t, proto.ErrCodeSuccess, request(operator, operator.SecretKey, proto.SetDataNodeMaintenance, maintenance).Code)
+	threshold := map[string]interface{}{thresholdKey: 0.5}
+	require.EqualValues(t, proto.ErrCodeNoPermission, request(operator, operator.SecretKey, proto.AdminSetMetaNodeThreshold, threshold).Code)
+
+	// the query apis need a signed request as well, the signed request is not replayed
+	require.EqualValues(t, proto.ErrCodeNoPermission, request(nil, "", proto.AdminListVols, nil).Code)
+	listVols := newRequest(reader, reader.SecretKey, proto.AdminListVols, nil)
+	require.EqualValues(t, proto.ErrCodeSuccess, send(listVols).Code)
+	require.EqualValues(t, proto.ErrCodeNoPermission, send(listVols).Code)
+
+	// the public apis stay open, but a signature is verified if present
+	require.EqualValues(t, proto.ErrCodeSuccess, request(nil, "", proto.AdminGetCluster, nil).Code)
+	require.EqualValues(t, proto.ErrCodeSuccess, request(reader, reader.SecretKey, proto.AdminGetCluster, nil).Code)
+	require.EqualValues(t, proto.ErrCodeNoPermission, request(reader, operator.SecretKey, proto.AdminGetCluster, nil).Code)
+	aclCheck := map[string]interface{}{nameKey: commonVolName, OperateKey: util.AclCheckIP, IPKey: "127.0.0.1"}
+	require.EqualValues(t, proto.ErrCodeSuccess, request(nil, "", proto.AdminACL, aclCheck).Code)
+	aclAdd := map[string]interface{}{nameKey: commonVolName, OperateKey: util.AclAddIP, IPKey: "127.0.0.1"}
+	require.EqualValues(t, proto.ErrCodeNoPermission, request(nil, "", proto.AdminACL, aclAdd).Code)
+	require.EqualValues(t, proto.ErrCodeNoPermission, request(nil, "", proto.AdminCreatePreLoadDataPartition, map[string]interface{}{nameKey: commonVolName}).Code)
+	require.EqualValues(t, proto.ErrCodeNoPermission, request(nil, "", proto.AdminLcNode, nil).Code)
+
+	// the users are read by a signed request, the secret key is replied to the user itself and the cluster admins
+	admin := createUser("rbacAdmin", proto.RoleClusterAdmin)
+	rootInfo := map[string]interface{}{"user": RootUserID}
+	require.EqualValues(t, proto.ErrCodeNoPermission, request(nil, "", proto.UserGetInfo, rootInfo).Code)
+	require.EqualValues(t, proto.ErrCodeNoPermission, request(nil, "", proto.UserGetAKInfo, map[string]interface{}{"ak": reader.AccessKey}).Code)
+	require.EqualValues(t, proto.ErrCodeNoPermission, request(nil, "", proto.UserList, nil).Code)
+	secretKey := func(reply *proto.HTTPReply) string {
+		require.EqualValues(t, proto.ErrCodeSuccess, reply.Code, reply.Msg)
+		return reply.Data.(map[string]interface{})["secret_key"].(string)
+	}
+	require.Empty(t, secretKey(request(reader, reader.SecretKey, proto.UserGetInfo, rootInfo)))
+	require.NotEmpty(t, secretKey(request(admin, admin.SecretKey, proto.UserGetInfo, rootInfo)))
+	require.Equal(t, reader.SecretKey, secretKey(request(reader, reader.SecretKey, proto.UserGetAKInfo, map[string]interface{}{"ak": reader.AccessKey})))
+	require.Empty(t, secretKey(request(reader, reader.SecretKey, proto.UserGetAKInfo, map[string]interface{}{"ak": admin.AccessKey})))
+	user := createUser("rbacUser", proto.RoleNone)
+	require.Equal(t, user.SecretKey, secretKey(request(user, user.SecretKey, proto.UserGetInfo, map[string]interface{}{"user": user.UserID})))
+	require.EqualValues(t, proto.ErrCodeNoPermission, request(user, user.SecretKey, proto.UserGetInfo, rootInfo).Code)
+}
+
+func TestRBACAccessOfRoutes(t *testing.T) {
+	router := mux.NewRouter()
+	server.registerAPIRoutes(router)
+	// every api is either public or granted to the roles, the api missed is denied to all but the cluster admins
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		require.NoError(t, err)
+		_, granted := adminAPIAccess[path]
+		levels := 0
+		for _, ok := range []bool{granted, publicAPIs[path], userInfoAPIs[path]} {
+			if ok {
+				levels++
+			}
+		}
+		require.Equal(t, 1, levels, "access level of %v", path)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestUserQuota(t *testing.T) {
+	quotaReq := map[string]interface{}{nameKey: commonVolName, userQuotaTypeKey: "user", idKey: 1000}
+	setReq := map[string]interface{}{
+		nameKey: commonVolName, userQuotaTypeKey: "user", idKey: 1000,
+		softBytesKey: 100, hardBytesKey: 200, softFilesKey: 10, gracePeriodKey: 60,
+	}
+	processWithFatalV2(proto.UserQuotaSet, true, setReq, t)
+	setReq[softBytesKey] = 300
+	processWithFatalV2(proto.UserQuotaSet, false, setReq, t)
+
+	uqMgr := commonVol.userQuotaManager
+	key := proto.UserQuotaKey{Type: proto.UserQuotaTypeUser, Id: 1000}
+	quotaInfo := uqMgr.QuotaInfoMap[key]
+	require.EqualValues(t, 60, quotaInfo.GracePeriod)
+
+	// the soft limit is only enforced after the grace period
+	now := time.Now().Unix()
+	quotaInfo.UsedInfo = proto.QuotaUsedInfo{UsedBytes: 150, UsedFiles: 5}
+	require.True(t, uqMgr.checkLimited(quotaInfo, now))
+	require.Equal(t, now+60, quotaInfo.BytesGraceExpire)
+	require.False(t, quotaInfo.LimitedInfo.LimitedBytes)
+	require.False(t, uqMgr.checkLimited(quotaInfo, now+60))
+	require.True(t, quotaInfo.LimitedInfo.LimitedBytes)
+	quotaInfo.UsedInfo.UsedBytes = 50
+	require.True(t, uqMgr.checkLimited(quotaInfo, now+60))
+	require.Zero(t, quotaInfo.BytesGraceExpire)
+	require.False(t, quotaInfo.LimitedInfo.LimitedBytes)
+	// the hard limit is enforced at once
+	quotaInfo.UsedInfo = proto.QuotaUsedInfo{UsedBytes: 250, UsedFiles: 5}
+	uqMgr.checkLimited(quotaInfo, now)
+	require.True(t, quotaInfo.LimitedInfo.LimitedBytes)
+	require.False(t, quotaInfo.LimitedInfo.LimitedFiles)
+
+	reply := processWithFatalV2(proto.UserQuotaReport, true, map[string]interface{}{nameKey: commonVolName}, t)
+	resp := &proto.UserQuotaReportResponse{}
+	require.NoError(t, json.Unmarshal(reply.Data, resp))
+	require.Len(t, resp.Quotas, 1)
+	require.EqualValues(t, 1000, resp.Quotas[0].Id)
+
+	processWithFatalV2(proto.UserQuotaDelete, true, quotaReq, t)
+	processWithFatalV2(proto.UserQuotaDelete, false, quotaReq, t)
+	require.Empty(t, uqMgr.getQuotaHbInfos())
+}
+
 func TestSetDisableAutoAlloc(t *testing.T) {
 	req := map[string]interface{}{"enable": true}
 	processWithFatalV2(proto.AdminClusterFreeze, true, req, t)
@@ -771,6 +952,21 @@ func TestSetNodeMaxDpCntLimit(t *testing.T) {
 	assert.True(t, dataNodeLimit == limit)
 }
 
+func TestSetMetaPartitionSplitMergeInodeCount(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v?%v=%v&%v=%v", hostAddr, proto.AdminSetNodeInfo,
+		mpSplitInodeCountKey, 1<<24, mpMergeInodeCountKey, 1000)
+	process(reqURL, t)
+	defer func() {
+		reqURL = fmt.Sprintf("%v%v?%v=0&%v=0", hostAddr, proto.AdminSetNodeInfo, mpSplitInodeCountKey, mpMergeInodeCountKey)
+		process(reqURL, t)
+	}()
+	reqURL = fmt.Sprintf("%v%v", hostAddr, proto.AdminGetNodeInfo)
+	reply := process(reqURL, t)
+	data := reply.Data.(map[string]interface{})
+	assert.Equal(t, fmt.Sprint(1<<24), data[mpSplitInodeCountKey])
+	assert.Equal(t, "1000", data[mpMergeInodeCountKey])
+}
+
 func TestAddDataReplica(t *testing.T) {
 	partition := commonVol.dataPartitions.partitions[0]
 	dsAddr := mds7Addr
@@ -862,6 +1058,42 @@ func TestRemoveMetaReplica(t *testing.T) {
 	partition.RUnlock()
 }
 
+func TestSplitAndMergeMetaPartition(t *testing.T) {
+	name := "splitMergeTestVol"
+	createVol(map[string]interface{}{nameKey: name}, t)
+	vol, err := server.cluster.getVol(name)
+	require.NoError(t, err)
+	defer delVol(name, t)
+	server.cluster.checkMetaNodeHeartbeat()
+	time.Sleep(2 * time.Second)
+
+	maxPartitionID := vol.maxPartitionID()
+	mp, err := vol.metaPartition(maxPartitionID)
+	require.NoError(t, err)
+	start, end := mp.Start, mp.End
+	key := start + 100
+
+	reqURL := fmt.Sprintf("%v%v?id=%v&inode=%v", hostAddr, proto.AdminSplitMetaPartition, mp.PartitionID, key)
+	process(reqURL, t)
+	require.EqualValues(t, key-1, mp.End)
+	childID := vol.maxPartitionID()
+	require.NotEqual(t, mp.PartitionID, childID)
+	child, err := vol.metaPartition(childID)
+	require.NoError(t, err)
+	require.EqualValues(t, key, child.Start)
+	require.EqualValues(t, end, child.End)
+	require.ElementsMatch(t, mp.Hosts, child.Hosts)
+	server.cluster.checkMetaNodeHeartbeat()
+	time.Sleep(2 * time.Second)
+
+	reqURL = fmt.Sprintf("%v%v?id=%v", hostAddr, proto.AdminMergeMetaPartition, mp.PartitionID)
+	process(reqURL, t)
+	require.EqualValues(t, end, mp.End)
+	require.Equal(t, mp.PartitionID, vol.maxPartitionID())
+	_, err = vol.metaPartition(childID)
+	require.Error(t, err)
+}
+
 func TestClusterStat(t *testing.T) {
 	reqUrl := fmt.Sprintf("%v%v", hostAddr, proto.AdminClusterStat)
 	process(reqUrl, t)
@@ -1484,3 +1716,64 @@ func TestVolumeEnableAuditLog(t *testing.T) {
 	require.True(t, vol.EnableAuditLog)
 	require.True(t, checkVolAuditLog(name, true))
 }
+
+func TestVolumeReplication(t *testing.T) {
+	name := "replicationVol"
+	createVol(map[string]interface{}{nameKey: name}, t)
+	vol, err := server.cluster.getVol(name)
+	require.NoError(t, err)
+	defer func() {
+		reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v", hostAddr, proto.AdminDeleteVol, name, buildAuthKey(testOwner))
+		process(reqURL, t)
+	}()
+
+	reqURL := fmt.Sprintf("%v%v?name=%v&%v=%v", hostAddr, proto.AdminSetVolReplication, name, targetMastersKey, "10.0.0.1:17010")
+	process(reqURL, t)
+	replication := vol.getReplication()
+	require.NotNil(t, replication)
+	require.True(t, replication.Enable)
+	require.Equal(t, name, replication.TargetVolume)
+
+	// the running replication reported by the lcnode is adopted
+	mgr := server.cluster.replicationMgr
+	require.Nil(t, mgr.getTask(name))
+	require.True(t, mgr.updateTask("127.0.0.1:17510", &proto.ReplicationTaskResponse{
+		ReplicationStatistics: proto.ReplicationStatistics{VolName: name, LagSeconds: 10},
+	}))
+	require.False(t, mgr.updateTask("127.0.0.2:17510", &proto.ReplicationTaskResponse{
+		ReplicationStatistics: proto.ReplicationStatistics{VolName: name},
+	}))
+	task := mgr.getTask(name)
+	require.NotNil(t, task)
+	require.Equal(t, int64(10), task.LagSeconds)
+
+	reqURL = fmt.Sprintf("%v%v?name=%v", hostAddr, proto.AdminDelVolReplication, name)
+	process(reqURL, t)
+	require.Nil(t, vol.getReplication())
+	mgr.checkReplication()
+	require.Nil(t, mgr.getTask(name))
+}
+
+func TestDataRebalance(t *testing.T) {
+	mgr := server.cluster.rebalanceMgr
+	processWithFatalV2(proto.AdminStartDataRebalance, false, map[string]interface{}{levelKey: "rack"}, t)
+	require.False(t, mgr.getConfig().Enable)
+
+	reqURL := fmt.Sprintf("%v%v?%v=%v&%v=%v&%v=%v&%v=%v", hostAddr, proto.AdminStartDataRebalance,
+		zoneNameKey, testZone1, thresholdKey, 0.2, concurrencyKey, 2, bandwidthKey, 100)
+	process(reqURL, t)
+	config := mgr.getConfig()
+	require.True(t, config.Enable)
+	require.Equal(t, []string{testZone1}, config.Zones)
+	require.Equal(t, proto.RebalanceLevelNodeSet, config.Level)
+	require.Equal(t, 0.2, config.Threshold)
+	require.Equal(t, 2, config.Concurrency)
+	require.Equal(t, uint64(100), config.Bandwidth)
+
+	reqURL = fmt.Sprintf("%v%v", hostAddr, proto.AdminGetDataRebalance)
+	process(reqURL, t)
+
+	reqURL = fmt.Sprintf("%v%v", hostAddr, proto.AdminStopDataRebalance)
+	process(reqURL, t)
+	require.False(t, mgr.getConfig().Enable)
+}
@@ -120,6 +120,10 @@ func (m *Server) getUserAKInfo(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
+	if userInfo, err = m.userInfoForSigner(r, userInfo); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeNoPermission, Msg: err.Error()})
+		return
+	}
 	sendOkReply(w, r, newSuccessHTTPReply(userInfo))
 }
 
@@ -142,6 +146,10 @@ func (m *Server) getUserInfo(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
+	if userInfo, err = m.userInfoForSigner(r, userInfo); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeNoPermission, Msg: err.Error()})
+		return
+	}
 	sendOkReply(w, r, newSuccessHTTPReply(userInfo))
 }
 
@@ -292,6 +300,12 @@ func (m *Server) getAllUsers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	users = m.user.getAllUserInfo(keywords)
+	for i := range users {
+		if users[i], err = m.userInfoForSigner(r, users[i]); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeNoPermission, Msg: err.Error()})
+			return
+		}
+	}
 	sendOkReply(w, r, newSuccessHTTPReply(users))
 }
 
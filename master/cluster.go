@@ -69,6 +69,7 @@ type Cluster struct {
 	domainManager                *DomainManager
 	BadDataPartitionIds          *sync.Map
 	BadMetaPartitionIds          *sync.Map
+	mergedMetaPartitions         sync.Map // id of the merged meta partition -> time its replicas are found
 	DisableAutoAllocate          bool
 	ForbidMpDecommission         bool
 	FaultDomain                  bool
@@ -101,6 +102,8 @@ type Cluster struct {
 	lcNodes                      sync.Map
 	lcMgr                        *lifecycleManager
 	snapshotMgr                  *snapshotDelManager
+	replicationMgr               *replicationManager
+	rebalanceMgr                 *rebalanceManager
 	DecommissionDiskFactor       float64
 	S3ApiQosQuota                *sync.Map // (api,uid,limtType) -> limitQuota
 }
@@ -363,6 +366,9 @@ func newCluster(name string, leaderInfo *LeaderInfo, fsm *MetadataFsm, partition
 	c.lcMgr.cluster = c
 	c.snapshotMgr = newSnapshotManager()
 	c.snapshotMgr.cluster = c
+	c.replicationMgr = newReplicationManager()
+	c.replicationMgr.cluster = c
+	c.rebalanceMgr = newRebalanceManager(c)
 	c.S3ApiQosQuota = new(sync.Map)
 	return
 }
@@ -376,6 +382,7 @@ func (c *Cluster) scheduleTask() {
 	c.scheduleToCheckMetaPartitions()
 	c.scheduleToUpdateStatInfo()
 	c.scheduleToManageDp()
+	c.scheduleToRebalanceDp()
 	c.scheduleToCheckVolStatus()
 	c.scheduleToCheckVolQos()
 	c.scheduleToCheckDiskRecoveryProgress()
@@ -389,7 +396,9 @@ func (c *Cluster) scheduleTask() {
 	c.scheduleToCheckDataReplicas()
 	c.scheduleToLcScan()
 	c.scheduleToSnapshotDelVerScan()
+	c.scheduleToCheckReplication()
 	c.scheduleToBadDisk()
+	c.scheduleToCleanAdminAudits()
 }
 
 func (c *Cluster) masterAddr() (addr string) {
@@ -471,6 +480,18 @@ func (c *Cluster) scheduleToManageDp() {
 	}()
 }
 
+// scheduleToRebalanceDp moves the data partitions to level the disk usage of the datanodes once it is started.
+func (c *Cluster) scheduleToRebalanceDp() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.rebalanceMgr.checkRebalance()
+			}
+			time.Sleep(rebalanceCheckInterval)
+		}
+	}()
+}
+
 func (c *Cluster) scheduleToCheckDelayDeleteVols() {
 	go func() {
 		ticker := time.NewTicker(5 * time.Second)
@@ -763,6 +784,9 @@ func (c *Cluster) checkMetaNodeHeartbeat() {
 					hbReq.QuotaHbInfos = append(hbReq.QuotaHbInfos, quotaHbInfos...)
 				}
 			}
+			if vol.userQuotaManager != nil {
+				hbReq.UserQuotaHbInfos = append(hbReq.UserQuotaHbInfos, vol.userQuotaManager.getQuotaHbInfos()...)
+			}
 
 			hbReq.TxInfo = append(hbReq.TxInfo, &proto.TxInfo{
 				Volume:     vol.Name,
@@ -883,6 +907,10 @@ func (c *Cluster) checkDataReplicas() {
 
 	successCnt := 0
 	for _, dp := range lackReplicaDataPartitions {
+		if c.hasReplicaInMaintenance(TypeDataPartition, dp.Hosts) {
+			log.LogInfof("action[checkDataReplicas] dp[%v] is degraded by the maintenance of its hosts", dp.PartitionID)
+			continue
+		}
 		if success, _ := c.autoAddDataReplica(dp); success {
 			successCnt += 1
 		}
@@ -1404,6 +1432,10 @@ func (c *Cluster) markDeleteVol(name, authKey string, force bool, isNotCancel bo
 		return
 	}
 
+	if clones := c.getVolClones(name); len(clones) > 0 {
+		return fmt.Errorf("vol %s is shared by its clone %s, delete the clones first", name, clones[0].Name)
+	}
+
 	if !c.cfg.volForceDeletion {
 		volDentryCount := uint64(0)
 		mpsCopy := vol.cloneMetaPartitionMap()
@@ -1454,7 +1486,7 @@ func (c *Cluster) batchCreatePreLoadDataPartition(vol *Vol, preload *DataPartiti
 		log.LogInfof("create preload data partition (%v) total (%v)", i, reqCreateCount)
 
 		var dp *DataPartition
-		if dp, err = c.createDataPartition(vol.Name, preload); err != nil {
+		if dp, err = c.createDataPartition(vol.Name, preload, vol.mediaType); err != nil {
 			log.LogErrorf("create preload data partition fail: volume(%v) err(%v)", vol.Name, err)
 			return err, nil
 		}
@@ -1466,6 +1498,12 @@ func (c *Cluster) batchCreatePreLoadDataPartition(vol *Vol, preload *DataPartiti
 }
 
 func (c *Cluster) batchCreateDataPartition(vol *Vol, reqCount int, init bool) (err error) {
+	return c.batchCreateDataPartitionOnMedia(vol, reqCount, init, vol.mediaType)
+}
+
+// batchCreateDataPartitionOnMedia creates the data partitions on the disks of the given media class,
+// the partitions are placed on any media if it is unspecified.
+func (c *Cluster) batchCreateDataPartitionOnMedia(vol *Vol, reqCount int, init bool, mediaType uint32) (err error) {
 	if !init {
 		if _, err = vol.needCreateDataPartition(); err != nil {
 			log.LogWarnf("action[batchCreateDataPartition] create data partition failed, err[%v]", err)
@@ -1483,7 +1521,7 @@ func (c *Cluster) batchCreateDataPartition(vol *Vol, reqCount int, init bool) (e
 			return fmt.Errorf("volume is forbidden")
 		}
 
-		if _, err = c.createDataPartition(vol.Name, nil); err != nil {
+		if _, err = c.createDataPartition(vol.Name, nil, mediaType); err != nil {
 			log.LogErrorf("action[batchCreateDataPartition] after create [%v] data partition,occurred error,err[%v]", i, err)
 			break
 		}
@@ -1524,7 +1562,7 @@ func (c *Cluster) isFaultDomain(vol *Vol) bool {
 // - If succeeded, replicate the data through raft and persist it to RocksDB.
 // - Otherwise, throw errors
 
-func (c *Cluster) createDataPartition(volName string, preload *DataPartitionPreLoad) (dp *DataPartition, err error) {
+func (c *Cluster) createDataPartition(volName string, preload *DataPartitionPreLoad, mediaType uint32) (dp *DataPartition, err error) {
 	log.LogInfof("action[createDataPartition] preload [%v]", preload)
 	var (
 		vol          *Vol
@@ -1566,13 +1604,23 @@ func (c *Cluster) createDataPartition(volName string, preload *DataPartitionPreL
 	errChannel := make(chan error, dpReplicaNum)
 
 	if c.isFaultDomain(vol) {
+		// the fault domain does not place by media, the partition takes the media of the disks it lands on
+		mediaType = proto.MediaTypeUnspecified
 		if targetHosts, targetPeers, err = c.getHostFromDomainZone(vol.domainId, TypeDataPartition, dpReplicaNum); err != nil {
 			goto errHandler
 		}
 	} else {
 		zoneNum := c.decideZoneNum(vol.crossZone)
-		if targetHosts, targetPeers, err = c.getHostFromNormalZone(TypeDataPartition, nil, nil, nil,
-			int(dpReplicaNum), zoneNum, zoneName); err != nil {
+		targetHosts, targetPeers, err = c.getHostFromNormalZone(TypeDataPartition, nil, nil, nil,
+			int(dpReplicaNum), zoneNum, zoneName, mediaType)
+		if err != nil && mediaType != proto.MediaTypeUnspecified && vol.mediaFallback {
+			log.LogWarnf("action[createDataPartition] vol[%v] no space on the %v disks, fall back to any media, err[%v]",
+				volName, proto.MediaTypeString(mediaType), err)
+			mediaType = proto.MediaTypeUnspecified
+			targetHosts, targetPeers, err = c.getHostFromNormalZone(TypeDataPartition, nil, nil, nil,
+				int(dpReplicaNum), zoneNum, zoneName, mediaType)
+		}
+		if err != nil {
 			goto errHandler
 		}
 	}
@@ -1583,8 +1631,10 @@ func (c *Cluster) createDataPartition(volName string, preload *DataPartitionPreL
 	dp = newDataPartition(partitionID, dpReplicaNum, volName, vol.ID, proto.GetDpType(vol.VolType, isPreload), partitionTTL)
 	dp.Hosts = targetHosts
 	dp.Peers = targetPeers
+	dp.MediaType = mediaType
 
-	log.LogInfof("action[createDataPartition] partitionID [%v] get host [%v]", partitionID, targetHosts)
+	log.LogInfof("action[createDataPartition] partitionID [%v] get host [%v] media [%v]",
+		partitionID, targetHosts, proto.MediaTypeString(mediaType))
 
 	for _, host := range targetHosts {
 		wg.Add(1)
@@ -1677,10 +1727,10 @@ func (c *Cluster) syncCreateDataPartitionToDataNode(host string, size uint64, dp
 	return string(resp.Data), nil
 }
 
-func (c *Cluster) syncCreateMetaPartitionToMetaNode(host string, mp *MetaPartition) (err error) {
+func (c *Cluster) syncCreateMetaPartitionToMetaNode(host string, mp *MetaPartition, storeType proto.MetaStoreType) (err error) {
 	hosts := make([]string, 0)
 	hosts = append(hosts, host)
-	tasks := mp.buildNewMetaPartitionTasks(hosts, mp.Peers, mp.volName)
+	tasks := mp.buildNewMetaPartitionTasks(hosts, mp.Peers, mp.volName, storeType)
 	metaNode, err := c.metaNode(host)
 	if err != nil {
 		return
@@ -1717,7 +1767,7 @@ func (c *Cluster) decideZoneNum(crossZone bool) (zoneNum int) {
 }
 
 func (c *Cluster) chooseZone2Plus1(zones []*Zone, excludeNodeSets []uint64, excludeHosts []string,
-	nodeType uint32, replicaNum int) (hosts []string, peers []proto.Peer, err error,
+	nodeType uint32, replicaNum int, mediaType uint32) (hosts []string, peers []proto.Peer, err error,
 ) {
 	if replicaNum < 2 || replicaNum > 3 {
 		return nil, nil, fmt.Errorf("action[chooseZone2Plus1] replicaNum [%v]", replicaNum)
@@ -1747,7 +1797,7 @@ func (c *Cluster) chooseZone2Plus1(zones []*Zone, excludeNodeSets []uint64, excl
 
 	num := 1
 	for _, zone := range zoneList {
-		selectedHosts, selectedPeers, e := zone.getAvailNodeHosts(nodeType, excludeNodeSets, excludeHosts, num)
+		selectedHosts, selectedPeers, e := zone.getAvailNodeHosts(nodeType, excludeNodeSets, excludeHosts, num, mediaType)
 		if e != nil {
 			log.LogErrorf("action[getHostFromNormalZone] error [%v]", e)
 			return nil, nil, e
@@ -1755,6 +1805,7 @@ func (c *Cluster) chooseZone2Plus1(zones []*Zone, excludeNodeSets []uint64, excl
 
 		hosts = append(hosts, selectedHosts...)
 		peers = append(peers, selectedPeers...)
+		excludeHosts = append(excludeHosts, c.excludeFailureDomains(nodeType, selectedHosts)...)
 		log.LogInfof("action[chooseZone2Plus1] zone [%v] left [%v] get hosts[%v]",
 			zone.name, zone.getSpaceLeft(nodeType), selectedHosts)
 
@@ -1766,7 +1817,7 @@ func (c *Cluster) chooseZone2Plus1(zones []*Zone, excludeNodeSets []uint64, excl
 }
 
 func (c *Cluster) chooseZoneNormal(zones []*Zone, excludeNodeSets []uint64, excludeHosts []string,
-	nodeType uint32, replicaNum int) (hosts []string, peers []proto.Peer, err error) {
+	nodeType uint32, replicaNum int, mediaType uint32) (hosts []string, peers []proto.Peer, err error) {
 	log.LogInfof("action[chooseZoneNormal] zones[%s] nodeType[%d] replicaNum[%d]", printZonesName(zones), nodeType, replicaNum)
 
 	c.zoneIdxMux.Lock()
@@ -1775,7 +1826,7 @@ func (c *Cluster) chooseZoneNormal(zones []*Zone, excludeNodeSets []uint64, excl
 	for i := 0; i < replicaNum; i++ {
 		zone := zones[c.lastZoneIdxForNode]
 		c.lastZoneIdxForNode = (c.lastZoneIdxForNode + 1) % len(zones)
-		selectedHosts, selectedPeers, err := zone.getAvailNodeHosts(nodeType, excludeNodeSets, excludeHosts, 1)
+		selectedHosts, selectedPeers, err := zone.getAvailNodeHosts(nodeType, excludeNodeSets, excludeHosts, 1, mediaType)
 		if err != nil {
 			log.LogErrorf("action[chooseZoneNormal] error [%v]", err)
 			return nil, nil, err
@@ -1783,6 +1834,8 @@ func (c *Cluster) chooseZoneNormal(zones []*Zone, excludeNodeSets []uint64, excl
 
 		hosts = append(hosts, selectedHosts...)
 		peers = append(peers, selectedPeers...)
+		// a zone may be chosen again when there are less zones than replicas
+		excludeHosts = append(excludeHosts, c.excludeFailureDomains(nodeType, selectedHosts)...)
 	}
 
 	return
@@ -1790,13 +1843,14 @@ func (c *Cluster) chooseZoneNormal(zones []*Zone, excludeNodeSets []uint64, excl
 
 func (c *Cluster) getHostFromNormalZone(nodeType uint32, excludeZones []string, excludeNodeSets []uint64,
 	excludeHosts []string, replicaNum int,
-	zoneNum int, specifiedZone string) (hosts []string, peers []proto.Peer, err error,
+	zoneNum int, specifiedZone string, mediaType uint32) (hosts []string, peers []proto.Peer, err error,
 ) {
 	var zones []*Zone
 	zones = make([]*Zone, 0)
 	if replicaNum <= zoneNum {
 		zoneNum = replicaNum
 	}
+	excludeHosts = c.excludeFailureDomains(nodeType, excludeHosts)
 	// when creating vol,user specified a zone,we reset zoneNum to 1,to be created partition with specified zone,
 	// if specified zone is not writable,we choose a zone randomly
 	if specifiedZone != "" {
@@ -1815,7 +1869,7 @@ func (c *Cluster) getHostFromNormalZone(nodeType uint32, excludeZones []string,
 		}
 	} else {
 		if nodeType == TypeDataPartition {
-			if zones, err = c.t.allocZonesForDataNode(zoneNum, replicaNum, excludeZones); err != nil {
+			if zones, err = c.t.allocZonesForDataNode(zoneNum, replicaNum, excludeZones, mediaType); err != nil {
 				return
 			}
 		} else {
@@ -1827,7 +1881,7 @@ func (c *Cluster) getHostFromNormalZone(nodeType uint32, excludeZones []string,
 
 	if len(zones) == 1 {
 		log.LogInfof("action[getHostFromNormalZone] zones [%v]", zones[0].name)
-		if hosts, peers, err = zones[0].getAvailNodeHosts(nodeType, excludeNodeSets, excludeHosts, replicaNum); err != nil {
+		if hosts, peers, err = zones[0].getAvailNodeHosts(nodeType, excludeNodeSets, excludeHosts, replicaNum, mediaType); err != nil {
 			log.LogErrorf("action[getHostFromNormalZone],err[%v]", err)
 			return
 		}
@@ -1841,11 +1895,11 @@ func (c *Cluster) getHostFromNormalZone(nodeType uint32, excludeZones []string,
 	}
 
 	if c.cfg.DefaultNormalZoneCnt == defaultNormalCrossZoneCnt && len(zones) >= defaultNormalCrossZoneCnt {
-		if hosts, peers, err = c.chooseZoneNormal(zones, excludeNodeSets, excludeHosts, nodeType, replicaNum); err != nil {
+		if hosts, peers, err = c.chooseZoneNormal(zones, excludeNodeSets, excludeHosts, nodeType, replicaNum, mediaType); err != nil {
 			return
 		}
 	} else {
-		if hosts, peers, err = c.chooseZone2Plus1(zones, excludeNodeSets, excludeHosts, nodeType, replicaNum); err != nil {
+		if hosts, peers, err = c.chooseZone2Plus1(zones, excludeNodeSets, excludeHosts, nodeType, replicaNum, mediaType); err != nil {
 			return
 		}
 	}
@@ -2055,6 +2109,10 @@ func (c *Cluster) migrateDataNode(srcAddr, targetAddr string, raftForce bool, li
 	if err != nil {
 		return
 	}
+	if err = c.checkNodeMaintenance(TypeDataPartition, srcAddr); err != nil {
+		log.LogWarnf("action[migrateDataNode] %v", err)
+		return
+	}
 
 	if !srcNode.canMarkDecommission() {
 		err = fmt.Errorf("migrate src(%v) is still on working, please wait,check or cancel if abnormal:%v",
@@ -2093,10 +2151,10 @@ func (c *Cluster) decommissionSingleDp(dp *DataPartition, newAddr, offlineAddr s
 	defer func() {
 		ticker.Stop()
 	}()
-	// 1. add new replica first
+	// 1. add new replica first, it is promoted after the repair
 	if dp.GetSpecialReplicaDecommissionStep() == SpecialDecommissionEnter {
-		if err = c.addDataReplica(dp, newAddr, false); err != nil {
-			err = fmt.Errorf("action[decommissionSingleDp] dp %v addDataReplica fail err %v", dp.PartitionID, err)
+		if _, err = c.addDataLearner(dp, newAddr, false); err != nil {
+			err = fmt.Errorf("action[decommissionSingleDp] dp %v addDataLearner fail err %v", dp.PartitionID, err)
 			goto ERR
 		}
 		// if addDataReplica is success, can add to BadDataPartitionIds
@@ -2208,8 +2266,12 @@ func (c *Cluster) decommissionSingleDp(dp *DataPartition, newAddr, offlineAddr s
 		dp.SetSpecialReplicaDecommissionStep(SpecialDecommissionRemoveOld)
 		c.syncUpdateDataPartition(dp)
 	}
-	// 3.delete offline replica
+	// 3.replace offline replica with the new one, and delete it
 	if dp.GetSpecialReplicaDecommissionStep() == SpecialDecommissionRemoveOld {
+		if err = c.promoteDataReplica(dp, newAddr, offlineAddr); err != nil {
+			err = fmt.Errorf("action[decommissionSingleDp] dp %v err %v", dp.PartitionID, err)
+			goto ERR
+		}
 		if err = c.removeDataReplica(dp, offlineAddr, false, false); err != nil {
 			err = fmt.Errorf("action[decommissionSingleDp] dp %v err %v", dp.PartitionID, err)
 			goto ERR
@@ -2266,7 +2328,7 @@ func (c *Cluster) autoAddDataReplica(dp *DataPartition) (success bool, err error
 
 	if vol.crossZone {
 		zones := dp.getZones()
-		if targetHosts, _, err = c.getHostFromNormalZone(TypeDataPartition, zones, nil, dp.Hosts, 1, 1, ""); err != nil {
+		if targetHosts, _, err = c.getHostFromNormalZone(TypeDataPartition, zones, nil, dp.Hosts, 1, 1, "", dp.MediaType); err != nil {
 			goto errHandler
 		}
 	} else {
@@ -2284,7 +2346,7 @@ func (c *Cluster) autoAddDataReplica(dp *DataPartition) (success bool, err error
 		if ns, err = zone.getNodeSet(nodeSets[0]); err != nil {
 			goto errHandler
 		}
-		if targetHosts, _, err = ns.getAvailDataNodeHosts(dp.Hosts, 1); err != nil {
+		if targetHosts, _, err = ns.getAvailDataNodeHosts(c.excludeFailureDomains(TypeDataPartition, dp.Hosts), 1, dp.MediaType); err != nil {
 			goto errHandler
 		}
 	}
@@ -2338,6 +2400,10 @@ func (c *Cluster) migrateDataPartition(srcAddr, targetAddr string, dp *DataParti
 		zones           []string
 	)
 	log.LogDebugf("[migrateDataPartition] src %v target %v raftForce %v", srcAddr, targetAddr, raftForce)
+	if err = c.checkNodeMaintenance(TypeDataPartition, srcAddr); err != nil {
+		log.LogWarnf("action[migrateDataPartition] dp[%v] %v", dp.PartitionID, err)
+		return
+	}
 	dp.RLock()
 	if ok := dp.hasHost(srcAddr); !ok {
 		dp.RUnlock()
@@ -2385,7 +2451,7 @@ func (c *Cluster) migrateDataPartition(srcAddr, targetAddr string, dp *DataParti
 
 	if targetAddr != "" {
 		targetHosts = []string{targetAddr}
-	} else if targetHosts, _, err = ns.getAvailDataNodeHosts(dp.Hosts, 1); err != nil {
+	} else if targetHosts, _, err = ns.getAvailDataNodeHosts(c.excludeFailureDomainsForMove(TypeDataPartition, dp.Hosts, srcAddr), 1, dp.MediaType); err != nil {
 		if _, ok := c.vols[dp.VolName]; !ok {
 			log.LogWarnf("clusterID[%v] partitionID:%v  on node:%v offline failed,PersistenceHosts:[%v]",
 				c.Name, dp.PartitionID, srcAddr, dp.Hosts)
@@ -2398,7 +2464,8 @@ func (c *Cluster) migrateDataPartition(srcAddr, targetAddr string, dp *DataParti
 		}
 		// select data nodes from the other node set in same zone
 		excludeNodeSets = append(excludeNodeSets, ns.ID)
-		if targetHosts, _, err = zone.getAvailNodeHosts(TypeDataPartition, excludeNodeSets, dp.Hosts, 1); err != nil {
+		if targetHosts, _, err = zone.getAvailNodeHosts(TypeDataPartition, excludeNodeSets,
+			c.excludeFailureDomainsForMove(TypeDataPartition, dp.Hosts, srcAddr), 1, dp.MediaType); err != nil {
 			// select data nodes from the other zone
 			zones = dp.getLiveZones(srcAddr)
 			var excludeZone []string
@@ -2407,7 +2474,7 @@ func (c *Cluster) migrateDataPartition(srcAddr, targetAddr string, dp *DataParti
 			} else {
 				excludeZone = append(excludeZone, zones[0])
 			}
-			if targetHosts, _, err = c.getHostFromNormalZone(TypeDataPartition, excludeZone, excludeNodeSets, dp.Hosts, 1, 1, ""); err != nil {
+			if targetHosts, _, err = c.getHostFromNormalZone(TypeDataPartition, excludeZone, excludeNodeSets, dp.Hosts, 1, 1, "", dp.MediaType); err != nil {
 				goto errHandler
 			}
 		}
@@ -2436,10 +2503,7 @@ func (c *Cluster) migrateDataPartition(srcAddr, targetAddr string, dp *DataParti
 			goto errHandler
 		}
 	} else {
-		if err = c.removeDataReplica(dp, srcAddr, false, raftForce); err != nil {
-			goto errHandler
-		}
-		if err = c.addDataReplica(dp, newAddr, false); err != nil {
+		if err = c.replaceDataReplica(dp, srcAddr, newAddr, raftForce); err != nil {
 			goto errHandler
 		}
 
@@ -2528,7 +2592,18 @@ func (c *Cluster) addDataReplica(dp *DataPartition, addr string, ignoreDecommiss
 		}
 	}()
 
-	log.LogInfof("action[addDataReplica]  dp %v enter %v", dp.PartitionID, ignoreDecommissionDisk)
+	if _, err = c.addDataLearner(dp, addr, ignoreDecommissionDisk); err != nil {
+		return
+	}
+
+	log.LogInfof("action[addDataReplica] dp %v addr %v try promote learner", dp.PartitionID, addr)
+	return c.promoteDataReplica(dp, addr, "")
+}
+
+// addDataLearner adds the replica which receives the raft log without voting,
+// it votes only after it is promoted.
+func (c *Cluster) addDataLearner(dp *DataPartition, addr string, ignoreDecommissionDisk bool) (addPeer proto.Peer, err error) {
+	log.LogInfof("action[addDataLearner]  dp %v enter %v", dp.PartitionID, ignoreDecommissionDisk)
 
 	dp.addReplicaMutex.Lock()
 	defer dp.addReplicaMutex.Unlock()
@@ -2538,28 +2613,139 @@ func (c *Cluster) addDataReplica(dp *DataPartition, addr string, ignoreDecommiss
 		return
 	}
 
-	addPeer := proto.Peer{ID: dataNode.ID, Addr: addr}
+	addPeer = proto.Peer{ID: dataNode.ID, Addr: addr, IsLearner: true}
 
 	if !proto.IsNormalDp(dp.PartitionType) {
-		return fmt.Errorf("action[addDataReplica] [%d] is not normal dp, not support add or delete replica", dp.PartitionID)
+		err = fmt.Errorf("action[addDataLearner] [%d] is not normal dp, not support add or delete replica", dp.PartitionID)
+		return
 	}
 
-	log.LogInfof("action[addDataReplica] dp %v dst addr %v try add raft member, node id %v", dp.PartitionID, addr, dataNode.ID)
+	log.LogInfof("action[addDataLearner] dp %v dst addr %v try add raft member, node id %v", dp.PartitionID, addr, dataNode.ID)
 	if err = c.addDataPartitionRaftMember(dp, addPeer); err != nil {
-		log.LogWarnf("action[addDataReplica] dp %v addr %v try add raft member err [%v]", dp.PartitionID, addr, err)
+		log.LogWarnf("action[addDataLearner] dp %v addr %v try add raft member err [%v]", dp.PartitionID, addr, err)
 		return
 	}
 
-	log.LogInfof("action[addDataReplica] dp %v addr %v try create data replica ignoreDecommissionDisk %v",
+	log.LogInfof("action[addDataLearner] dp %v addr %v try create data replica ignoreDecommissionDisk %v",
 		dp.PartitionID, addr, ignoreDecommissionDisk)
 	if err = c.createDataReplica(dp, addPeer, ignoreDecommissionDisk); err != nil {
 		c.removeHostMember(dp, addPeer)
-		log.LogWarnf("action[addDataReplica] dp %v addr %v createDataReplica err [%v]", dp.PartitionID, addr, err)
+		log.LogWarnf("action[addDataLearner] dp %v addr %v createDataReplica err [%v]", dp.PartitionID, addr, err)
+		return
+	}
+	return
+}
+
+// promoteDataReplica promotes the learner on addr, which replaces the voter on replaceAddr through a joint
+// consensus if it is set. The joint consensus is left by removing the replica on replaceAddr.
+// The learner is removed if it fails to be promoted.
+func (c *Cluster) promoteDataReplica(dp *DataPartition, addr, replaceAddr string) (err error) {
+	dp.RLock()
+	learner, ok := dp.getPeer(addr)
+	// the replica on replaceAddr has been removed by the last attempt
+	if !dp.hasHost(replaceAddr) {
+		replaceAddr = ""
+	}
+	dp.RUnlock()
+	if !ok {
+		return fmt.Errorf("dp %v has no replica on %v", dp.PartitionID, addr)
+	}
+	// the replacement is sent again if the last attempt failed before removing the replaced one,
+	// the raft refuses it unless the replacement is in progress
+	if !learner.IsLearner && replaceAddr == "" {
+		return
+	}
+	var replacePeer proto.Peer
+	if replaceAddr != "" {
+		var dataNode *DataNode
+		if dataNode, err = c.dataNode(replaceAddr); err != nil {
+			return
+		}
+		replacePeer = proto.Peer{ID: dataNode.ID, Addr: replaceAddr}
+	}
+	if err = c.promoteDataPartitionLearner(dp, learner, replacePeer); err != nil {
+		log.LogWarnf("action[promoteDataReplica] dp %v addr %v promote learner err [%v]", dp.PartitionID, addr, err)
+		if removeErr := c.removeDataReplica(dp, addr, false, false); removeErr != nil {
+			log.LogErrorf("action[promoteDataReplica] dp %v addr %v remove learner err [%v]", dp.PartitionID, addr, removeErr)
+		}
+	}
+	return
+}
+
+// promoteDataPartitionLearner turns the learner into a voter once it has caught up with the leader,
+// the datanode refuses the promotion until then.
+func (c *Cluster) promoteDataPartitionLearner(dp *DataPartition, promotePeer, replacePeer proto.Peer) (err error) {
+	deadline := time.Now().Add(promoteLearnerTimeout)
+	for {
+		if err = c.buildPromoteDataPartitionLearnerTaskAndSyncSendTask(dp, promotePeer, replacePeer); err == nil {
+			break
+		}
+		if isNotReplaced(err) {
+			return fmt.Errorf("dp %v replace %v with %v err [%v]", dp.PartitionID, replacePeer.Addr, promotePeer.Addr, err)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("dp %v promote learner %v timeout, last err [%v]", dp.PartitionID, promotePeer.Addr, err)
+		}
+		time.Sleep(retrySendSyncTaskInternal)
+	}
+
+	dp.Lock()
+	defer dp.Unlock()
+	newPeers := make([]proto.Peer, 0, len(dp.Peers))
+	for _, peer := range dp.Peers {
+		if peer.ID == promotePeer.ID && peer.Addr == promotePeer.Addr {
+			peer.IsLearner = false
+		}
+		newPeers = append(newPeers, peer)
+	}
+	return dp.update("promoteDataPartitionLearner", dp.VolName, newPeers, dp.Hosts, c)
+}
+
+func (c *Cluster) buildPromoteDataPartitionLearnerTaskAndSyncSendTask(dp *DataPartition, promotePeer, replacePeer proto.Peer) (err error) {
+	leaderAddr := dp.getLeaderAddrWithLock()
+	if leaderAddr == "" {
+		// the datanode forwards the request to the raft leader
+		dp.RLock()
+		leaderAddr = dp.Hosts[0]
+		dp.RUnlock()
+	}
+	task, err := dp.createTaskToPromoteLearner(promotePeer, replacePeer, leaderAddr)
+	if err != nil {
 		return
 	}
+	leaderDataNode, err := c.dataNode(leaderAddr)
+	if err != nil {
+		return
+	}
+	_, err = leaderDataNode.TaskManager.syncSendAdminTask(task)
 	return
 }
 
+// replaceDataReplica replaces the old replica with the new one through a joint consensus after the new one
+// catches up as a learner, so the partition keeps its fault tolerance during the replacement. The old replica
+// is removed first if it is forced out of the raft group, as the rest of the replicas may have lost the quorum.
+func (c *Cluster) replaceDataReplica(dp *DataPartition, srcAddr, targetAddr string, raftForce bool) (err error) {
+	if raftForce {
+		if err = c.removeDataReplica(dp, srcAddr, false, raftForce); err != nil {
+			return
+		}
+		return c.addDataReplica(dp, targetAddr, false)
+	}
+	dp.RLock()
+	added := dp.hasHost(targetAddr)
+	dp.RUnlock()
+	// the new replica may be added by the last attempt which failed to remove the old one
+	if !added {
+		if _, err = c.addDataLearner(dp, targetAddr, false); err != nil {
+			return
+		}
+	}
+	if err = c.promoteDataReplica(dp, targetAddr, srcAddr); err != nil {
+		return
+	}
+	return c.removeDataReplica(dp, srcAddr, false, raftForce)
+}
+
 // update datanode size with to replica size
 func (c *Cluster) updateDataNodeSize(addr string, dp *DataPartition) error {
 	leaderSize := dp.Replicas[0].Used
@@ -3010,6 +3196,10 @@ func (c *Cluster) migrateMetaNode(srcAddr, targetAddr string, limit int) (err er
 	if err != nil {
 		return err
 	}
+	if err = c.checkNodeMaintenance(TypeMetaPartition, srcAddr); err != nil {
+		log.LogWarnf("action[migrateMetaNode] %v", err)
+		return err
+	}
 
 	metaNode.MigrateLock.Lock()
 	defer metaNode.MigrateLock.Unlock()
@@ -3265,11 +3455,17 @@ func (c *Cluster) createVol(req *createVolReq) (vol *Vol, err error) {
 	vol.aclMgr.init(c, vol)
 	vol.initUidSpaceManager(c)
 	vol.initQuotaManager(c)
+	vol.initUserQuotaManager(c)
 	if err = vol.VersionMgr.init(c); err != nil {
 		log.LogError("init dataPartition error in verMgr init", err.Error())
 	}
 
-	if err = vol.initMetaPartitions(c, req.mpCount); err != nil {
+	if req.cloneSrc != nil {
+		err = vol.initCloneMetaPartitions(c, req.cloneSrc, req.cloneVerSeq)
+	} else {
+		err = vol.initMetaPartitions(c, req.mpCount)
+	}
+	if err != nil {
 
 		vol.Status = proto.VolStatusMarkDelete
 		if e := vol.deleteVolFromStore(c); e != nil {
@@ -3359,6 +3555,10 @@ func (c *Cluster) doCreateVol(req *createVolReq) (vol *Vol, err error) {
 		Description:             req.description,
 		EnablePosixAcl:          req.enablePosixAcl,
 		EnableQuota:             req.enableQuota,
+		Compression:             req.compression,
+		MediaType:               req.mediaType,
+		MediaFallback:           req.mediaFallback,
+		MetaStoreType:           req.metaStoreType,
 		EnableTransaction:       req.enableTransaction,
 		TxTimeout:               req.txTimeout,
 		TxConflictRetryNum:      req.txConflictRetryNum,
@@ -3383,6 +3583,11 @@ func (c *Cluster) doCreateVol(req *createVolReq) (vol *Vol, err error) {
 
 		DpReadOnlyWhenVolFull: req.DpReadOnlyWhenVolFull,
 	}
+	if req.cloneSrc != nil {
+		vv.CloneSrc = req.cloneSrc.Name
+		vv.CloneVerSeq = req.cloneVerSeq
+		vv.PromotePath = req.promotePath
+	}
 
 	log.LogInfof("[doCreateVol] volView, %v", vv)
 
@@ -3390,6 +3595,10 @@ func (c *Cluster) doCreateVol(req *createVolReq) (vol *Vol, err error) {
 		err = proto.ErrDuplicateVol
 		goto errHandler
 	}
+	if _, err = c.getVolByFormerName(req.name); err == nil {
+		err = proto.ErrDuplicateVol
+		goto errHandler
+	}
 
 	vv.ID, err = c.idAlloc.allocateCommonID()
 	if err != nil {
@@ -3497,6 +3706,7 @@ func (c *Cluster) allDataNodes() (dataNodes []proto.NodeView) {
 		dataNodes = append(dataNodes, proto.NodeView{
 			Addr: dataNode.Addr, DomainAddr: dataNode.DomainAddr,
 			IsActive: dataNode.isActive, ID: dataNode.ID, IsWritable: dataNode.isWriteAble(),
+			InMaintenance: dataNode.inMaintenance(),
 		})
 		return true
 	})
@@ -3510,6 +3720,7 @@ func (c *Cluster) allMetaNodes() (metaNodes []proto.NodeView) {
 		metaNodes = append(metaNodes, proto.NodeView{
 			ID: metaNode.ID, Addr: metaNode.Addr, DomainAddr: metaNode.DomainAddr,
 			IsActive: metaNode.IsActive, IsWritable: metaNode.isWritable(),
+			InMaintenance: metaNode.inMaintenance(),
 		})
 		return true
 	})
@@ -3813,6 +4024,30 @@ func (c *Cluster) setMaxDpCntLimit(val uint64) (err error) {
 	return
 }
 
+func (c *Cluster) setMetaPartitionSplitInodeCount(val uint64) (err error) {
+	oldVal := atomic.LoadUint64(&c.cfg.MetaPartitionSplitInodeCount)
+	atomic.StoreUint64(&c.cfg.MetaPartitionSplitInodeCount, val)
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setMetaPartitionSplitInodeCount] err[%v]", err)
+		atomic.StoreUint64(&c.cfg.MetaPartitionSplitInodeCount, oldVal)
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
+
+func (c *Cluster) setMetaPartitionMergeInodeCount(val uint64) (err error) {
+	oldVal := atomic.LoadUint64(&c.cfg.MetaPartitionMergeInodeCount)
+	atomic.StoreUint64(&c.cfg.MetaPartitionMergeInodeCount, val)
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setMetaPartitionMergeInodeCount] err[%v]", err)
+		atomic.StoreUint64(&c.cfg.MetaPartitionMergeInodeCount, oldVal)
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
+
 func (c *Cluster) setClusterCreateTime(createTime int64) (err error) {
 	oldVal := c.CreateTime
 	c.CreateTime = createTime
@@ -4103,6 +4338,10 @@ func (c *Cluster) TryDecommissionDataNode(dataNode *DataNode) {
 func (c *Cluster) migrateDisk(nodeAddr, diskPath, dstPath string, raftForce bool, limit int, diskDisable bool, migrateType uint32) (err error) {
 	var disk *DecommissionDisk
 	key := fmt.Sprintf("%s_%s", nodeAddr, diskPath)
+	if err = c.checkNodeMaintenance(TypeDataPartition, nodeAddr); err != nil {
+		log.LogWarnf("action[migrateDisk] disk[%v] %v", key, err)
+		return
+	}
 
 	if value, ok := c.DecommissionDisks.Load(key); ok {
 		disk = value.(*DecommissionDisk)
@@ -4142,6 +4381,10 @@ func (c *Cluster) migrateDisk(nodeAddr, diskPath, dstPath string, raftForce bool
 func (c *Cluster) restoreStoppedAutoDecommissionDisk(nodeAddr, diskPath string) (err error) {
 	var disk *DecommissionDisk
 	key := fmt.Sprintf("%s_%s", nodeAddr, diskPath)
+	if err = c.checkNodeMaintenance(TypeDataPartition, nodeAddr); err != nil {
+		log.LogWarnf("action[migrateDisk] disk[%v] %v", key, err)
+		return
+	}
 
 	if value, ok := c.DecommissionDisks.Load(key); !ok {
 		disk = value.(*DecommissionDisk)
@@ -4578,6 +4821,7 @@ func (c *Cluster) clearLcNodes() {
 func (c *Cluster) delLcNode(nodeAddr string) (err error) {
 	c.lcMgr.lcNodeStatus.RemoveNode(nodeAddr)
 	c.snapshotMgr.lcNodeStatus.RemoveNode(nodeAddr)
+	c.replicationMgr.removeNode(nodeAddr)
 
 	lcNode, err := c.lcNode(nodeAddr)
 	if err != nil {
@@ -4640,6 +4884,26 @@ func (c *Cluster) scheduleToSnapshotDelVerScan() {
 	}()
 }
 
+func (c *Cluster) scheduleToCheckReplication() {
+	// make sure the running replications are reported by the lcnodes before checking
+	waitTime := time.Second * defaultIntervalToCheck
+	waited := false
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				if !waited {
+					time.Sleep(waitTime)
+					waited = true
+				}
+				c.replicationMgr.checkReplication()
+			} else {
+				waited = false
+			}
+			time.Sleep(waitTime)
+		}
+	}()
+}
+
 func (c *Cluster) getSnapshotDelVer() {
 	if c.partition == nil || !c.partition.IsRaftLeader() {
 		log.LogWarn("getSnapshotDelVer: master is not leader")
@@ -114,14 +114,18 @@ func fixedPoint(x float64, scale int) float64 {
 
 func (c *Cluster) updateDataNodeStatInfo() {
 	var (
-		total uint64
-		used  uint64
-		avail uint64
+		total         uint64
+		used          uint64
+		avail         uint64
+		effectiveUsed uint64
+		physicalUsed  uint64
 	)
 	c.dataNodes.Range(func(addr, node interface{}) bool {
 		dataNode := node.(*DataNode)
 		total = total + dataNode.Total
 		used = used + dataNode.Used
+		effectiveUsed = effectiveUsed + dataNode.EffectiveUsed
+		physicalUsed = physicalUsed + dataNode.PhysicalUsed
 
 		if dataNode.isActive {
 			avail = avail + dataNode.AvailableSpace
@@ -142,6 +146,8 @@ func (c *Cluster) updateDataNodeStatInfo() {
 	c.dataNodeStatInfo.IncreasedGB = int64(usedGB) - int64(c.dataNodeStatInfo.UsedGB)
 	c.dataNodeStatInfo.UsedGB = usedGB
 	c.dataNodeStatInfo.UsedRatio = strconv.FormatFloat(usedRate, 'f', 3, 32)
+	c.dataNodeStatInfo.EffectiveUsedGB = effectiveUsed / util.GB
+	c.dataNodeStatInfo.PhysicalUsedGB = physicalUsed / util.GB
 }
 
 func (c *Cluster) updateMetaNodeStatInfo() {
@@ -195,6 +201,11 @@ func (c *Cluster) updateVolStatInfo() {
 		}
 		vol.mpsLock.RUnlock()
 
-		c.volStatInfo.Store(vol.Name, newVolStatInfo(vol.Name, total, used, cacheTotal, cacheUsed, inodeCount))
+		stat := newVolStatInfo(vol.Name, total, used, cacheTotal, cacheUsed, inodeCount)
+		if proto.IsHot(vol.VolType) {
+			stat.PhysicalUsedSize = vol.dataPartitions.totalPhysicalUsedSpace()
+			stat.MediaUsages = vol.dataPartitions.mediaUsages()
+		}
+		c.volStatInfo.Store(vol.Name, stat)
 	}
 }
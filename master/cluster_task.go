@@ -109,6 +109,11 @@ func (c *Cluster) migrateMetaPartition(srcAddr, targetAddr string, mp *MetaParti
 	log.LogWarnf("action[migrateMetaPartition],volName[%v], migrate from src[%s] to target[%s],partitionID[%v] begin",
 		mp.volName, srcAddr, targetAddr, mp.PartitionID)
 
+	if err = c.checkNodeMaintenance(TypeMetaPartition, srcAddr); err != nil {
+		log.LogWarnf("action[migrateMetaPartition] mp[%v] %v", mp.PartitionID, err)
+		return
+	}
+
 	mp.RLock()
 	if !contains(mp.Hosts, srcAddr) {
 		mp.RUnlock()
@@ -139,7 +144,7 @@ func (c *Cluster) migrateMetaPartition(srcAddr, targetAddr string, mp *MetaParti
 		newPeers = []proto.Peer{{
 			Addr: targetAddr,
 		}}
-	} else if _, newPeers, err = ns.getAvailMetaNodeHosts(oldHosts, 1); err != nil {
+	} else if _, newPeers, err = ns.getAvailMetaNodeHosts(c.excludeFailureDomainsForMove(TypeMetaPartition, oldHosts, srcAddr), 1); err != nil {
 		if _, ok := c.vols[mp.volName]; !ok {
 			log.LogWarnf("[migrateMetaPartition] clusterID[%v] partitionID:%v  on node:[%v]",
 				c.Name, mp.PartitionID, mp.Hosts)
@@ -152,7 +157,8 @@ func (c *Cluster) migrateMetaPartition(srcAddr, targetAddr string, mp *MetaParti
 		}
 		// choose a meta node in other node set in the same zone
 		excludeNodeSets = append(excludeNodeSets, ns.ID)
-		if _, newPeers, err = zone.getAvailNodeHosts(TypeMetaPartition, excludeNodeSets, oldHosts, 1); err != nil {
+		if _, newPeers, err = zone.getAvailNodeHosts(TypeMetaPartition, excludeNodeSets,
+			c.excludeFailureDomainsForMove(TypeMetaPartition, oldHosts, srcAddr), 1, proto.MediaTypeUnspecified); err != nil {
 			zones = mp.getLiveZones(srcAddr)
 			var excludeZone []string
 			if len(zones) == 0 {
@@ -161,17 +167,15 @@ func (c *Cluster) migrateMetaPartition(srcAddr, targetAddr string, mp *MetaParti
 				excludeZone = append(excludeZone, zones[0])
 			}
 			// choose a meta node in other zone
-			if _, newPeers, err = c.getHostFromNormalZone(TypeMetaPartition, excludeZone, excludeNodeSets, oldHosts, 1, 1, ""); err != nil {
+			if _, newPeers, err = c.getHostFromNormalZone(TypeMetaPartition, excludeZone, excludeNodeSets, oldHosts, 1, 1, "", proto.MediaTypeUnspecified); err != nil {
 				goto errHandler
 			}
 		}
 	}
 
-	if err = c.deleteMetaReplica(mp, srcAddr, false, false); err != nil {
-		goto errHandler
-	}
-
-	if err = c.addMetaReplica(mp, newPeers[0].Addr); err != nil {
+	// the new replica catches up as a learner, then replaces the old one through a joint consensus,
+	// which is left by removing the old one
+	if err = c.replaceMetaReplica(mp, srcAddr, newPeers[0].Addr); err != nil {
 		goto errHandler
 	}
 
@@ -487,6 +491,42 @@ func (c *Cluster) addMetaReplica(partition *MetaPartition, addr string) (err err
 			log.LogErrorf("action[addMetaReplica],vol[%v],data partition[%v],err[%v]", partition.volName, partition.PartitionID, err)
 		}
 	}()
+	addPeer, err := c.addMetaLearner(partition, addr)
+	if err != nil {
+		return
+	}
+	return c.promoteMetaReplica(partition, addPeer, proto.Peer{})
+}
+
+func (c *Cluster) replaceMetaReplica(partition *MetaPartition, srcAddr, targetAddr string) (err error) {
+	metaNode, err := c.metaNode(srcAddr)
+	if err != nil {
+		return
+	}
+	addPeer, err := c.addMetaLearner(partition, targetAddr)
+	if err != nil {
+		return
+	}
+	if err = c.promoteMetaReplica(partition, addPeer, proto.Peer{ID: metaNode.ID, Addr: srcAddr}); err != nil {
+		return
+	}
+	return c.deleteMetaReplica(partition, srcAddr, false, false)
+}
+
+// promoteMetaReplica promotes the learner, which replaces the voter replacePeer through a joint consensus
+// if its ID is set. The learner is removed if it fails to be promoted.
+func (c *Cluster) promoteMetaReplica(partition *MetaPartition, learner, replacePeer proto.Peer) (err error) {
+	if err = c.promoteMetaPartitionLearner(partition, learner, replacePeer); err != nil {
+		if removeErr := c.deleteMetaReplica(partition, learner.Addr, false, false); removeErr != nil {
+			log.LogErrorf("action[promoteMetaReplica] vol[%v],mp[%v] remove learner[%v] err[%v]",
+				partition.volName, partition.PartitionID, learner.Addr, removeErr)
+		}
+	}
+	return
+}
+
+// addMetaLearner adds the replica which receives the raft log without voting.
+func (c *Cluster) addMetaLearner(partition *MetaPartition, addr string) (addPeer proto.Peer, err error) {
 	partition.Lock()
 	defer partition.Unlock()
 	if contains(partition.Hosts, addr) {
@@ -497,7 +537,7 @@ func (c *Cluster) addMetaReplica(partition *MetaPartition, addr string) (err err
 	if err != nil {
 		return
 	}
-	addPeer := proto.Peer{ID: metaNode.ID, Addr: addr}
+	addPeer = proto.Peer{ID: metaNode.ID, Addr: addr, IsLearner: true}
 	if err = c.addMetaPartitionRaftMember(partition, addPeer); err != nil {
 		return
 	}
@@ -517,8 +557,58 @@ func (c *Cluster) addMetaReplica(partition *MetaPartition, addr string) (err err
 	return
 }
 
+// promoteMetaPartitionLearner turns the learner into a voter, the metanode refuses the
+// promotion until the learner has caught up with the leader.
+func (c *Cluster) promoteMetaPartitionLearner(partition *MetaPartition, promotePeer, replacePeer proto.Peer) (err error) {
+	deadline := time.Now().Add(promoteLearnerTimeout)
+	for {
+		if err = c.buildPromoteMetaPartitionLearnerTaskAndSyncSend(partition, promotePeer, replacePeer); err == nil {
+			break
+		}
+		if isNotReplaced(err) {
+			return fmt.Errorf("mp[%v] replace[%v] with[%v] err[%v]", partition.PartitionID, replacePeer.Addr, promotePeer.Addr, err)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("mp[%v] promote learner[%v] timeout, last err[%v]", partition.PartitionID, promotePeer.Addr, err)
+		}
+		time.Sleep(retrySendSyncTaskInternal)
+	}
+
+	partition.Lock()
+	defer partition.Unlock()
+	newPeers := make([]proto.Peer, 0, len(partition.Peers))
+	for _, peer := range partition.Peers {
+		if peer.Addr == promotePeer.Addr && peer.ID == promotePeer.ID {
+			peer.IsLearner = false
+		}
+		newPeers = append(newPeers, peer)
+	}
+	return partition.persistToRocksDB("promoteMetaPartitionLearner", partition.volName, partition.Hosts, newPeers, c)
+}
+
+func (c *Cluster) buildPromoteMetaPartitionLearnerTaskAndSyncSend(mp *MetaPartition, promotePeer, replacePeer proto.Peer) (err error) {
+	mp.RLock()
+	leaderAddr := mp.Hosts[0]
+	// the metanode proxies the request to the raft leader
+	if mr, leaderErr := mp.getMetaReplicaLeader(); leaderErr == nil {
+		leaderAddr = mr.Addr
+	}
+	t := mp.createTaskToPromoteLearner(promotePeer, replacePeer, leaderAddr)
+	mp.RUnlock()
+	leaderMetaNode, err := c.metaNode(leaderAddr)
+	if err != nil {
+		return
+	}
+	_, err = leaderMetaNode.Sender.syncSendAdminTask(t)
+	return
+}
+
 func (c *Cluster) createMetaReplica(partition *MetaPartition, addPeer proto.Peer) (err error) {
-	task, err := partition.createTaskToCreateReplica(addPeer.Addr)
+	vol, err := c.getVol(partition.volName)
+	if err != nil {
+		return
+	}
+	task, err := partition.createTaskToCreateReplica(addPeer.Addr, vol.metaStoreType)
 	if err != nil {
 		return
 	}
@@ -828,6 +918,9 @@ func (c *Cluster) dealMetaNodeHeartbeatResp(nodeAddr string, resp *proto.MetaNod
 		c.adjustMetaNode(metaNode)
 		log.LogWarnf("metaNode zone changed from [%v] to [%v]", oldZoneName, resp.ZoneName)
 	}
+	if err = c.updateMetaNodeLabels(metaNode, resp.RackName, resp.HostName); err != nil {
+		log.LogErrorf("action[dealMetaNodeHeartbeatResp] metaNode[%v] update labels err[%v]", metaNode.Addr, err)
+	}
 
 	// change cpu util and io used
 	metaNode.CpuUtil.Store(resp.CpuUtil)
@@ -1013,6 +1106,9 @@ func (c *Cluster) handleDataNodeHeartbeatResp(nodeAddr string, resp *proto.DataN
 		c.adjustDataNode(dataNode)
 		log.LogWarnf("dataNode [%v] zone changed from [%v] to [%v]", dataNode.Addr, oldZoneName, resp.ZoneName)
 	}
+	if err = c.updateDataNodeLabels(dataNode, resp.RackName, resp.HostName); err != nil {
+		log.LogErrorf("action[handleDataNodeHeartbeatResp] dataNode[%v] update labels err[%v]", dataNode.Addr, err)
+	}
 	// change cpu util and io used
 	dataNode.CpuUtil.Store(resp.CpuUtil)
 	dataNode.SetIoUtils(resp.IoUtils)
@@ -1074,12 +1170,22 @@ func (c *Cluster) adjustDataNode(dataNode *DataNode) {
 
 /*if node report data partition infos,so range data partition infos,then update data partition info*/
 func (c *Cluster) updateDataNode(dataNode *DataNode, dps []*proto.DataPartitionReport) {
+	var renamed []*DataPartition
+	defer func() {
+		// send the volume name to the replicas reporting the former one
+		if len(renamed) > 0 {
+			go c.syncDataReplicaVolName(dataNode, renamed)
+		}
+	}()
 	for _, vr := range dps {
 		if vr == nil {
 			continue
 		}
 		if vr.VolName != "" {
-			vol, err := c.getVol(vr.VolName)
+			vol, err := c.getReportedVol(vr.VolName, func(vol *Vol) bool {
+				_, err := vol.getDataPartitionByID(vr.PartitionID)
+				return err == nil
+			})
 			if err != nil {
 				continue
 			}
@@ -1088,6 +1194,9 @@ func (c *Cluster) updateDataNode(dataNode *DataNode, dps []*proto.DataPartitionR
 			//}
 			if dp, err := vol.getDataPartitionByID(vr.PartitionID); err == nil {
 				dp.updateMetric(vr, dataNode, c)
+				if vr.VolName != vol.Name {
+					renamed = append(renamed, dp)
+				}
 			}
 		} else {
 			if dp, err := c.getDataPartitionByID(vr.PartitionID); err == nil {
@@ -1109,7 +1218,14 @@ func (c *Cluster) updateMetaNode(metaNode *MetaNode, metaPartitions []*proto.Met
 		var mp *MetaPartition
 		if mr.VolName != "" {
 
-			vol, err = c.getVol(mr.VolName)
+			vol, err = c.getReportedVol(mr.VolName, func(vol *Vol) bool {
+				for _, id := range []uint64{mr.PartitionID, mr.SplitFrom, mr.MergeTo} {
+					if _, err := vol.metaPartition(id); id != 0 && err == nil {
+						return true
+					}
+				}
+				return false
+			})
 			if err != nil {
 				continue
 			}
@@ -1118,6 +1234,9 @@ func (c *Cluster) updateMetaNode(metaNode *MetaNode, metaPartitions []*proto.Met
 			//	continue
 			//}
 
+			if c.reconcileMetaPartitionReport(vol, metaNode, mr) {
+				continue
+			}
 			mp, err = vol.metaPartition(mr.PartitionID)
 			if err != nil {
 				continue
@@ -1130,14 +1249,17 @@ func (c *Cluster) updateMetaNode(metaNode *MetaNode, metaPartitions []*proto.Met
 			}
 		}
 
-		// send latest end to replica metanode, including updating the end after MaxMP split when the old MaxMP is unavailable
-		if mr.End != mp.End {
+		// send latest end and volume name to replica metanode, including updating the end after MaxMP split when the old MaxMP is unavailable
+		if mr.End != mp.End || (mr.VolName != "" && mr.VolName != mp.volName) {
 			mp.addUpdateMetaReplicaTask(c)
 		}
 
 		mp.updateMetaPartition(mr, metaNode)
 		vol.uidSpaceManager.volUidUpdate(mr)
 		vol.quotaManager.quotaUpdate(mr)
+		if vol.userQuotaManager != nil {
+			vol.userQuotaManager.quotaUpdate(mr)
+		}
 		c.updateInodeIDUpperBound(mp, mr, threshold, metaNode)
 	}
 }
@@ -1153,7 +1275,7 @@ func (c *Cluster) updateInodeIDUpperBound(mp *MetaPartition, mr *proto.MetaParti
 	}
 
 	maxPartitionID := vol.maxPartitionID()
-	if mr.PartitionID < maxPartitionID {
+	if mr.PartitionID != maxPartitionID {
 		return
 	}
 	var end uint64
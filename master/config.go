@@ -52,6 +52,7 @@ const (
 
 	cfgVolForceDeletion           = "volForceDeletion"
 	cfgVolDeletionDentryThreshold = "volDeletionDentryThreshold"
+	cfgEnableRBAC                 = "enableRBAC"
 )
 
 // default value
@@ -140,10 +141,13 @@ type clusterConfig struct {
 	MonitorPushAddr                     string
 	IntervalToScanS3Expiration          int64
 	MaxConcurrentLcNodes                uint64
+	MetaPartitionSplitInodeCount        uint64 // split the meta partition online if its inode count reaches it, 0 means disabled
+	MetaPartitionMergeInodeCount        uint64 // merge the adjacent meta partitions if their inode count is below it, 0 means disabled
 
 	volForceDeletion           bool   // when delete a volume, ignore it's dentry count or not
 	volDeletionDentryThreshold uint64 // in case of volForceDeletion is set to false, define the dentry count threshold to allow volume deletion
 	volDelayDeleteTimeHour     int64
+	enableRBAC                 bool // the mutating admin apis need a request signed by a user with the role granting it
 }
 
 func newClusterConfig() (cfg *clusterConfig) {
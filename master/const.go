@@ -29,6 +29,7 @@ const (
 	idKey                   = "id"
 	countKey                = "count"
 	startKey                = "start"
+	endKey                  = "end"
 	enableKey               = "enable"
 	thresholdKey            = "threshold"
 	volDeletionDelayTimeKey = "volDeletionDelayTime"
@@ -39,6 +40,7 @@ const (
 	dataPartitionCountKey   = "dpCount"
 	volCapacityKey          = "capacity"
 	volDeleteLockTimeKey    = "deleteLockTime"
+	trashRetentionKey       = "trashRetention"
 	volTypeKey              = "volType"
 	cacheRuleKey            = "cacheRuleKey"
 	emptyCacheRuleKey       = "emptyCacheRule"
@@ -69,6 +71,9 @@ const (
 	akKey                      = "ak"
 	keywordsKey                = "keywords"
 	zoneNameKey                = "zoneName"
+	rackNameKey                = "rackName"
+	hostNameKey                = "hostName"
+	maintenanceTTLKey          = "ttl"
 	nodesetIdKey               = "nodesetId"
 	crossZoneKey               = "crossZone"
 	normalZonesFirstKey        = "normalZonesFirst"
@@ -82,6 +87,8 @@ const (
 	nodeDpMaxRepairErrCntKey   = "dpMaxRepairErrCnt"
 	clusterLoadFactorKey       = "loadFactor"
 	maxDpCntLimitKey           = "maxDpCntLimit"
+	mpSplitInodeCountKey       = "mpSplitInodeCount"
+	mpMergeInodeCountKey       = "mpMergeInodeCount"
 	clusterCreateTimeKey       = "clusterCreateTime"
 	descriptionKey             = "description"
 	dpSelectorNameKey          = "dpSelectorName"
@@ -126,11 +133,29 @@ const (
 	fullPathKey                = "fullPath"
 	inodeKey                   = "inode"
 	quotaKey                   = "quotaId"
+	userQuotaTypeKey           = "type"
+	softBytesKey               = "softBytes"
+	hardBytesKey               = "hardBytes"
+	softFilesKey               = "softFiles"
+	hardFilesKey               = "hardFiles"
+	gracePeriodKey             = "gracePeriod"
 	enableQuota                = "enableQuota"
+	compressionKey             = "compression"
+	mediaTypeKey               = "mediaType"
+	mediaFallbackKey           = "mediaFallback"
+	metaStoreTypeKey           = "metaStoreType"
 	dpDiscardKey               = "dpDiscard"
 	ignoreDiscardKey           = "ignoreDiscard"
 	ClientIDKey                = "clientIDKey"
 	verSeqKey                  = "verSeq"
+	cloneNameKey               = "cloneName"
+	newNameKey                 = "newName"
+	snapshotNameKey            = "snapshotName"
+	targetMastersKey           = "targetMasters"
+	targetVolumeKey            = "targetVolume"
+	levelKey                   = "level"
+	concurrencyKey             = "concurrency"
+	bandwidthKey               = "bandwidth"
 	Periodic                   = "periodic"
 	DecommissionType           = "decommissionType"
 	decommissionDiskFactor     = "decommissionDiskFactor"
@@ -154,6 +179,8 @@ const (
 
 const (
 	underlineSeparator = "_"
+	compressionNone    = "none"
+	mediaTypeAny       = "any"
 )
 
 const (
@@ -191,6 +218,7 @@ const (
 	EmptyCrcValue                         uint32 = 4045511210
 	DefaultZoneName                              = proto.DefaultZoneName
 	retrySendSyncTaskInternal                    = 3 * time.Second
+	promoteLearnerTimeout                        = 10 * time.Minute
 	defaultRangeOfCountDifferencesAllowed        = 50
 	defaultMinusOfMaxInodeID                     = 1000
 	defaultNodeSetGrpBatchCnt                    = 3
@@ -206,6 +234,7 @@ const (
 	defaultClientReqPeriodSeconds                = 1
 	defaultMaxQuotaNumPerVol                     = 100
 	defaultVolDelayDeleteTimeHour                = 48
+	volFormerNameKeepTime                        = 24 * time.Hour // the clients mounted with the former name of a renamed volume are served for the time
 )
 
 const (
@@ -282,13 +311,18 @@ const (
 	opSyncAcl          uint32 = 0x36
 	opSyncUid          uint32 = 0x37
 
-	opSyncAllocQuotaID uint32 = 0x40
-	opSyncSetQuota     uint32 = 0x41
-	opSyncDeleteQuota  uint32 = 0x42
-	opSyncMulitVersion uint32 = 0x53
+	opSyncAllocQuotaID    uint32 = 0x40
+	opSyncSetQuota        uint32 = 0x41
+	opSyncDeleteQuota     uint32 = 0x42
+	opSyncSetUserQuota    uint32 = 0x43
+	opSyncDeleteUserQuota uint32 = 0x44
+	opSyncMulitVersion    uint32 = 0x53
 
 	opSyncS3QosSet    uint32 = 0x60
 	opSyncS3QosDelete uint32 = 0x61
+
+	opSyncAddAdminAudit    uint32 = 0x70
+	opSyncDeleteAdminAudit uint32 = 0x71
 )
 
 const (
@@ -339,9 +373,11 @@ const (
 	volWarnUsedRatio = 0.9
 	volCachePrefix   = keySeparator + volNameAcronym + keySeparator
 	quotaPrefix      = keySeparator + "quota" + keySeparator
+	userQuotaPrefix  = keySeparator + "userquota" + keySeparator
 	lcNodePrefix     = keySeparator + lcNodeAcronym + keySeparator
 	lcConfPrefix     = keySeparator + lcConfigurationAcronym + keySeparator
 	S3QoSPrefix      = keySeparator + S3QoS + keySeparator
+	adminAuditPrefix = keySeparator + "audit" + keySeparator
 )
 
 // selector enum
@@ -31,8 +31,13 @@ type DataNode struct {
 	Total                     uint64 `json:"TotalWeight"`
 	Used                      uint64 `json:"UsedWeight"`
 	AvailableSpace            uint64
+	EffectiveUsed             uint64 // space used by the partitions before the compression
+	PhysicalUsed              uint64 // space used by the partitions on the disks
 	ID                        uint64
 	ZoneName                  string `json:"Zone"`
+	RackName                  string // optional rack label, the replicas are spread across racks
+	HostName                  string // optional label of the physical host running the node
+	MaintenanceExpire         int64  // unix time the maintenance ends, the node is not repaired before it
 	Addr                      string
 	DomainAddr                string
 	ReportTime                time.Time
@@ -143,6 +148,8 @@ func (dataNode *DataNode) updateNodeMetric(resp *proto.DataNodeHeartbeatResponse
 	dataNode.DomainAddr = util.ParseIpAddrToDomainAddr(dataNode.Addr)
 	dataNode.Total = resp.Total
 	dataNode.Used = resp.Used
+	dataNode.EffectiveUsed = resp.EffectiveUsed
+	dataNode.PhysicalUsed = resp.PhysicalUsed
 	if dataNode.AvailableSpace > resp.Available ||
 		time.Since(dataNode.LastUpdateTime) > defaultNodeTimeOutSec*time.Second {
 		dataNode.AvailableSpace = resp.Available
@@ -212,6 +219,30 @@ func (dataNode *DataNode) canAllocDp() bool {
 	return true
 }
 
+// getMediaAvailableSpace returns the available space of the writable disks of the media class,
+// which is the available space of the whole node if the media class is unspecified.
+func (dataNode *DataNode) getMediaAvailableSpace(mediaType uint32) (space uint64) {
+	dataNode.RLock()
+	defer dataNode.RUnlock()
+	if mediaType == proto.MediaTypeUnspecified {
+		return dataNode.AvailableSpace
+	}
+	for _, disk := range dataNode.DiskStats {
+		if disk.MediaType == mediaType && disk.Status == proto.ReadWrite {
+			space += disk.Available
+		}
+	}
+	return
+}
+
+// canAllocDpOnMedia returns whether a data partition can be created on the disks of the media class.
+func (dataNode *DataNode) canAllocDpOnMedia(mediaType uint32) bool {
+	if !dataNode.canAllocDp() {
+		return false
+	}
+	return mediaType == proto.MediaTypeUnspecified || dataNode.getMediaAvailableSpace(mediaType) > 10*util.GB
+}
+
 func (dataNode *DataNode) GetDpCntLimit() uint32 {
 	return uint32(dataNode.DpCntLimit.GetCntLimit())
 }
@@ -243,6 +274,18 @@ func (dataNode *DataNode) GetAddr() string {
 	return dataNode.Addr
 }
 
+func (dataNode *DataNode) GetFailureDomain() string {
+	dataNode.RLock()
+	defer dataNode.RUnlock()
+	return failureDomain(dataNode.ZoneName, dataNode.RackName, dataNode.HostName, dataNode.Addr)
+}
+
+func (dataNode *DataNode) inMaintenance() bool {
+	dataNode.RLock()
+	defer dataNode.RUnlock()
+	return maintenanceActive(dataNode.MaintenanceExpire)
+}
+
 // SelectNodeForWrite implements "SelectNodeForWrite" in the Node interface
 func (dataNode *DataNode) SelectNodeForWrite() {
 	dataNode.Lock()
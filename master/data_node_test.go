@@ -44,3 +44,20 @@ func decommissionDataNode(addr string, t *testing.T) {
 	reqURL := fmt.Sprintf("%v%v?addr=%v", hostAddr, proto.DecommissionDataNode, addr)
 	process(reqURL, t)
 }
+
+func TestDataNodeMaintenance(t *testing.T) {
+	addr := mds1Addr
+	reqURL := fmt.Sprintf("%v%v?addr=%v&ttl=%v", hostAddr, proto.SetDataNodeMaintenance, addr, time.Hour)
+	process(reqURL, t)
+	if err := server.cluster.checkNodeMaintenance(TypeDataPartition, addr); err == nil {
+		t.Errorf("dataNode[%v] is not in maintenance", addr)
+	}
+	if err := server.cluster.migrateDataNode(addr, "", false, 0); err == nil {
+		t.Errorf("dataNode[%v] in maintenance is decommissioned", addr)
+	}
+	reqURL = fmt.Sprintf("%v%v?addr=%v&ttl=0", hostAddr, proto.SetDataNodeMaintenance, addr)
+	process(reqURL, t)
+	if err := server.cluster.checkNodeMaintenance(TypeDataPartition, addr); err != nil {
+		t.Errorf("dataNode[%v] maintenance is not ended: %v", addr, err)
+	}
+}
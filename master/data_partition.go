@@ -34,6 +34,7 @@ type DataPartition struct {
 	PartitionType    int
 	PartitionTTL     int64
 	LastLoadedTime   int64
+	MediaType        uint32 // media class of the disks holding the replicas
 	ReplicaNum       uint8
 	Status           int8
 	isRecover        bool
@@ -46,6 +47,7 @@ type DataPartition struct {
 
 	total                   uint64
 	used                    uint64
+	physicalUsed            uint64
 	MissingNodes            map[string]int64 // key: address of the missing node, value: when the node is missing
 	VolName                 string
 	VolID                   uint64
@@ -233,6 +235,12 @@ func (partition *DataPartition) createTaskToAddRaftMember(addPeer proto.Peer, le
 	return
 }
 
+func (partition *DataPartition) createTaskToPromoteLearner(promotePeer, replacePeer proto.Peer, leaderAddr string) (task *proto.AdminTask, err error) {
+	task = proto.NewAdminTask(proto.OpPromoteDataPartitionLearner, leaderAddr, newPromoteDataPartitionLearnerRequest(partition.PartitionID, promotePeer, replacePeer))
+	partition.resetTaskID(task)
+	return
+}
+
 func (partition *DataPartition) createTaskToRemoveRaftMember(c *Cluster, removePeer proto.Peer, force bool) (err error) {
 	doWork := func(leaderAddr string) error {
 		log.LogInfof("action[createTaskToRemoveRaftMember] vol[%v],data partition[%v] removePeer %v leaderAddr %v", partition.VolName, partition.PartitionID, removePeer, leaderAddr)
@@ -284,7 +292,7 @@ func (partition *DataPartition) createTaskToCreateDataPartition(addr string, dat
 	task = proto.NewAdminTask(proto.OpCreateDataPartition, addr, newCreateDataPartitionRequest(
 		partition.VolName, partition.PartitionID, int(partition.ReplicaNum),
 		peers, int(dataPartitionSize), leaderSize, hosts, createType,
-		partitionType, decommissionedDisks, partition.VerSeq))
+		partitionType, decommissionedDisks, partition.VerSeq, partition.MediaType))
 	partition.resetTaskID(task)
 	return
 }
@@ -452,6 +460,7 @@ func (partition *DataPartition) convertToDataPartitionResponse() (dpr *proto.Dat
 	dpr.LeaderAddr = partition.getLeaderAddr()
 	dpr.IsRecover = partition.isRecover
 	dpr.IsDiscard = partition.IsDiscard
+	dpr.MediaType = partition.MediaType
 
 	return
 }
@@ -604,6 +613,16 @@ func (partition *DataPartition) hasHost(addr string) (ok bool) {
 	return
 }
 
+// getPeer returns the peer on the address.
+func (partition *DataPartition) getPeer(addr string) (peer proto.Peer, ok bool) {
+	for _, p := range partition.Peers {
+		if p.Addr == addr {
+			return p, true
+		}
+	}
+	return
+}
+
 func (partition *DataPartition) liveReplicas(timeOutSec int64) (replicas []*DataReplica) {
 	replicas = make([]*DataReplica, 0)
 	for i := 0; i < len(partition.Replicas); i++ {
@@ -735,6 +754,7 @@ func (partition *DataPartition) updateMetric(vr *proto.DataPartitionReport, data
 	replica.Status = int8(vr.PartitionStatus)
 	replica.Total = vr.Total
 	replica.Used = vr.Used
+	replica.PhysicalUsed = vr.PhysicalUsed
 	partition.setMaxUsed()
 	replica.FileCount = uint32(vr.ExtentCount)
 	replica.setAlive()
@@ -744,6 +764,14 @@ func (partition *DataPartition) updateMetric(vr *proto.DataPartitionReport, data
 	}
 	replica.NeedsToCompare = vr.NeedCompare
 	replica.DecommissionRepairProgress = vr.DecommissionRepairProgress
+	// the partition placed on any media, e.g. created before the media classes or after falling back,
+	// takes the media class of its leader
+	if replica.IsLeader && partition.MediaType == proto.MediaTypeUnspecified && vr.MediaType != proto.MediaTypeUnspecified {
+		partition.MediaType = vr.MediaType
+		if err = c.syncUpdateDataPartition(partition); err != nil {
+			partition.MediaType = proto.MediaTypeUnspecified
+		}
+	}
 	if replica.DiskPath != vr.DiskPath && vr.DiskPath != "" {
 		oldDiskPath := replica.DiskPath
 		replica.DiskPath = vr.DiskPath
@@ -760,19 +788,32 @@ func (partition *DataPartition) updateMetric(vr *proto.DataPartitionReport, data
 }
 
 func (partition *DataPartition) setMaxUsed() {
-	var maxUsed uint64
+	var maxUsed, maxPhysicalUsed uint64
 	for _, r := range partition.Replicas {
 		if r.Used > maxUsed {
 			maxUsed = r.Used
 		}
+		physicalUsed := r.PhysicalUsed
+		if physicalUsed == 0 {
+			// reported by the datanode without the compression
+			physicalUsed = r.Used
+		}
+		if physicalUsed > maxPhysicalUsed {
+			maxPhysicalUsed = physicalUsed
+		}
 	}
 	partition.used = maxUsed
+	partition.physicalUsed = maxPhysicalUsed
 }
 
 func (partition *DataPartition) getMaxUsedSpace() uint64 {
 	return partition.used
 }
 
+func (partition *DataPartition) getMaxPhysicalUsedSpace() uint64 {
+	return partition.physicalUsed
+}
+
 func (partition *DataPartition) afterCreation(nodeAddr, diskPath string, c *Cluster) (err error) {
 	dataNode, err := c.dataNode(nodeAddr)
 	if err != nil {
@@ -1070,6 +1111,10 @@ func (partition *DataPartition) MarkDecommissionStatus(srcAddr, dstAddr, srcDisk
 			partition.PartitionID, partition.GetDecommissionStatus())
 		return false
 	}
+	if err := c.checkNodeMaintenance(TypeDataPartition, srcAddr); err != nil {
+		log.LogWarnf("action[MarkDecommissionStatus] dp[%v] %v", partition.PartitionID, err)
+		return false
+	}
 
 	if partition.IsDecommissionPaused() {
 		if !partition.pauseReplicaRepair(partition.DecommissionDstAddr, false, c) {
@@ -1228,10 +1273,7 @@ func (partition *DataPartition) Decommission(c *Cluster) bool {
 			goto errHandler
 		}
 	} else {
-		if err = c.removeDataReplica(partition, srcAddr, false, partition.DecommissionRaftForce); err != nil {
-			goto errHandler
-		}
-		if err = c.addDataReplica(partition, targetAddr, false); err != nil {
+		if err = c.replaceDataReplica(partition, srcAddr, targetAddr, partition.DecommissionRaftForce); err != nil {
 			goto errHandler
 		}
 		newReplica, _ := partition.getReplica(targetAddr)
@@ -1535,6 +1577,12 @@ func (partition *DataPartition) createTaskToStopDataPartitionRepair(addr string,
 	return
 }
 
+func (partition *DataPartition) createTaskToUpdateVolName(addr string, volName string) (task *proto.AdminTask) {
+	task = proto.NewAdminTask(proto.OpUpdateDataPartitionVolName, addr, newUpdateDataPartitionVolNameRequest(partition.PartitionID, volName))
+	partition.resetTaskID(task)
+	return
+}
+
 func (partition *DataPartition) TryAcquireDecommissionToken(c *Cluster) bool {
 	var (
 		zone            *Zone
@@ -1566,7 +1614,8 @@ func (partition *DataPartition) TryAcquireDecommissionToken(c *Cluster) bool {
 				partition.PartitionID, err.Error())
 			goto errHandler
 		}
-		targetHosts, _, err = ns.getAvailDataNodeHosts(partition.Hosts, 1)
+		targetHosts, _, err = ns.getAvailDataNodeHosts(
+			c.excludeFailureDomainsForMove(TypeDataPartition, partition.Hosts, partition.DecommissionSrcAddr), 1, partition.MediaType)
 		if err != nil {
 			log.LogWarnf("action[TryAcquireDecommissionToken] dp %v choose from src nodeset failed:%v",
 				partition.PartitionID, err.Error())
@@ -1582,7 +1631,8 @@ func (partition *DataPartition) TryAcquireDecommissionToken(c *Cluster) bool {
 				goto errHandler
 			}
 			excludeNodeSets = append(excludeNodeSets, ns.ID)
-			if targetHosts, _, err = zone.getAvailNodeHosts(TypeDataPartition, excludeNodeSets, partition.Hosts, 1); err != nil {
+			if targetHosts, _, err = zone.getAvailNodeHosts(TypeDataPartition, excludeNodeSets,
+				c.excludeFailureDomainsForMove(TypeDataPartition, partition.Hosts, partition.DecommissionSrcAddr), 1, partition.MediaType); err != nil {
 				// select data nodes from the other zone
 				zones = partition.getLiveZones(partition.DecommissionSrcAddr)
 				var excludeZone []string
@@ -1591,7 +1641,7 @@ func (partition *DataPartition) TryAcquireDecommissionToken(c *Cluster) bool {
 				} else {
 					excludeZone = append(excludeZone, zones[0])
 				}
-				if targetHosts, _, err = c.getHostFromNormalZone(TypeDataPartition, excludeZone, excludeNodeSets, partition.Hosts, 1, 1, ""); err != nil {
+				if targetHosts, _, err = c.getHostFromNormalZone(TypeDataPartition, excludeZone, excludeNodeSets, partition.Hosts, 1, 1, "", partition.MediaType); err != nil {
 					log.LogWarnf("action[TryAcquireDecommissionToken] dp %v getHostFromNormalZone failed:%v",
 						partition.PartitionID, err.Error())
 					goto errHandler
@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
 
@@ -313,6 +314,38 @@ func (dpMap *DataPartitionMap) totalUsedSpace() (totalUsed uint64) {
 	return
 }
 
+func (dpMap *DataPartitionMap) totalPhysicalUsedSpace() (totalUsed uint64) {
+	dpMap.RLock()
+	defer dpMap.RUnlock()
+	for _, dp := range dpMap.partitions {
+		totalUsed = totalUsed + dp.getMaxPhysicalUsedSpace()
+	}
+	return
+}
+
+// mediaUsages sums the used space and the partitions per media class, the partitions whose media
+// is not known yet are counted under an empty media type.
+func (dpMap *DataPartitionMap) mediaUsages() (usages []*proto.MediaUsage) {
+	dpMap.RLock()
+	defer dpMap.RUnlock()
+	byMedia := make(map[uint32]*proto.MediaUsage)
+	for _, dp := range dpMap.partitions {
+		usage, ok := byMedia[dp.MediaType]
+		if !ok {
+			usage = &proto.MediaUsage{MediaType: proto.MediaTypeString(dp.MediaType)}
+			byMedia[dp.MediaType] = usage
+			usages = append(usages, usage)
+		}
+		usage.UsedSize += dp.getMaxUsedSpace()
+		usage.PartitionCnt++
+		if dp.Status == proto.ReadWrite {
+			usage.RwPartitionCnt++
+		}
+	}
+	sort.Slice(usages, func(i, j int) bool { return usages[i].MediaType < usages[j].MediaType })
+	return
+}
+
 func (dpMap *DataPartitionMap) setAllDataPartitionsToReadOnly() {
 	dpMap.Lock()
 	defer dpMap.Unlock()
@@ -0,0 +1,155 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+func checkDirSnapshotName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return fmt.Errorf("invalid snapshot name [%v]", name)
+	}
+	return nil
+}
+
+// getDirSnapshot returns the snapshot of the directory with the name, the caller must hold the lock.
+func (verMgr *VolVersionManager) getDirSnapshot(name string, ino uint64) (idx int, info *proto.DirSnapshotInfo) {
+	for idx, info = range verMgr.dirSnapshots {
+		if info.Name == name && info.Inode == ino {
+			return
+		}
+	}
+	return -1, nil
+}
+
+// isDirSnapshotVer checks if the version keeps any directory snapshot, the caller must hold the lock.
+func (verMgr *VolVersionManager) isDirSnapshotVer(verSeq uint64) bool {
+	for _, info := range verMgr.dirSnapshots {
+		if info.VerSeq == verSeq {
+			return true
+		}
+	}
+	return false
+}
+
+func (verMgr *VolVersionManager) createDirSnapshot(c *Cluster, name, dirPath string, ino uint64, force bool) (info *proto.DirSnapshotInfo, err error) {
+	if err = checkDirSnapshotName(name); err != nil {
+		return
+	}
+	if !path.IsAbs(dirPath) {
+		return nil, fmt.Errorf("path [%v] should be absolute", dirPath)
+	}
+	verMgr.RLock()
+	_, exist := verMgr.getDirSnapshot(name, ino)
+	verMgr.RUnlock()
+	if exist != nil {
+		return nil, fmt.Errorf("snapshot [%v] of [%v] already exists", name, dirPath)
+	}
+
+	var ver *proto.VolVersionInfo
+	if ver, err = verMgr.createVer2PhaseTask(c, uint64(time.Now().UnixMicro()), proto.CreateVersion, force); err != nil {
+		return
+	}
+	if ver == nil {
+		return nil, fmt.Errorf("version of snapshot [%v] is not committed", name)
+	}
+
+	info = &proto.DirSnapshotInfo{
+		Name:       name,
+		Path:       path.Clean(dirPath),
+		Inode:      ino,
+		VerSeq:     ver.Ver,
+		CreateTime: time.Now().Unix(),
+	}
+	verMgr.Lock()
+	defer verMgr.Unlock()
+	if _, exist = verMgr.getDirSnapshot(name, ino); exist != nil {
+		return nil, fmt.Errorf("snapshot [%v] of [%v] already exists", name, dirPath)
+	}
+	verMgr.dirSnapshots = append(verMgr.dirSnapshots, info)
+	if err = verMgr.Persist(); err != nil {
+		verMgr.dirSnapshots = verMgr.dirSnapshots[:len(verMgr.dirSnapshots)-1]
+		log.LogErrorf("action[createDirSnapshot] vol %v snapshot %v err %v", verMgr.vol.Name, info, err)
+		return nil, err
+	}
+	log.LogInfof("action[createDirSnapshot] vol %v snapshot %v created", verMgr.vol.Name, info)
+	return
+}
+
+func (verMgr *VolVersionManager) deleteDirSnapshot(c *Cluster, name string, ino uint64, force bool) (err error) {
+	verMgr.RLock()
+	_, info := verMgr.getDirSnapshot(name, ino)
+	shared := false
+	if info != nil {
+		for _, other := range verMgr.dirSnapshots {
+			if other != info && other.VerSeq == info.VerSeq {
+				shared = true
+				break
+			}
+		}
+	}
+	verMgr.RUnlock()
+	if info == nil {
+		return fmt.Errorf("snapshot [%v] of inode [%v] not found", name, ino)
+	}
+
+	if !shared && !c.isClonedVer(verMgr.vol.Name, info.VerSeq) {
+		if _, err = verMgr.createVer2PhaseTask(c, info.VerSeq, proto.DeleteVersion, force); err != nil {
+			return
+		}
+	}
+
+	verMgr.Lock()
+	defer verMgr.Unlock()
+	if idx, _ := verMgr.getDirSnapshot(name, ino); idx >= 0 {
+		verMgr.dirSnapshots = append(verMgr.dirSnapshots[:idx], verMgr.dirSnapshots[idx+1:]...)
+	}
+	if err = verMgr.Persist(); err != nil {
+		log.LogErrorf("action[deleteDirSnapshot] vol %v snapshot %v err %v", verMgr.vol.Name, info, err)
+		return
+	}
+	log.LogInfof("action[deleteDirSnapshot] vol %v snapshot %v deleted", verMgr.vol.Name, info)
+	return
+}
+
+// listDirSnapshots returns the snapshots of the directory, or all the snapshots if the inode is zero.
+// The sequence to read a snapshot is the one before the next version, as the clients do.
+func (verMgr *VolVersionManager) listDirSnapshots(ino uint64) (infos []*proto.DirSnapshotInfo) {
+	verMgr.RLock()
+	defer verMgr.RUnlock()
+
+	infos = make([]*proto.DirSnapshotInfo, 0)
+	for _, info := range verMgr.dirSnapshots {
+		if ino != 0 && info.Inode != ino {
+			continue
+		}
+		idx, found := verMgr.getLayInfo(info.VerSeq)
+		if !found || idx == len(verMgr.multiVersionList)-1 || verMgr.multiVersionList[idx].Status != proto.VersionNormal {
+			log.LogWarnf("action[listDirSnapshots] vol %v snapshot %v version is not readable", verMgr.vol.Name, info)
+			continue
+		}
+		snapshot := *info
+		snapshot.ReadSeq = verMgr.multiVersionList[idx+1].Ver - 1
+		infos = append(infos, &snapshot)
+	}
+	return
+}
@@ -0,0 +1,206 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// failureDomain returns the failure domain of a node, which is its rack if it is labelled with one,
+// otherwise its host. A node without labels is a failure domain by itself. The racks and hosts are
+// scoped by the zone, so the same label in two zones is two domains.
+func failureDomain(zoneName, rackName, hostName, addr string) string {
+	if rackName != "" {
+		return zoneName + "/rack/" + rackName
+	}
+	if hostName != "" {
+		return zoneName + "/host/" + hostName
+	}
+	return addr
+}
+
+// failureDomains records the failure domains taken by the replicas selected for a partition.
+type failureDomains map[string]struct{}
+
+func (domains failureDomains) taken(node Node) bool {
+	_, ok := domains[node.GetFailureDomain()]
+	return ok
+}
+
+func (domains failureDomains) take(node Node) {
+	domains[node.GetFailureDomain()] = struct{}{}
+}
+
+func (c *Cluster) getNodeMap(nodeType uint32) *sync.Map {
+	if nodeType == TypeDataPartition {
+		return &c.dataNodes
+	}
+	return &c.metaNodes
+}
+
+func (c *Cluster) getFailureDomain(nodeType uint32, addr string) string {
+	value, ok := c.getNodeMap(nodeType).Load(addr)
+	if !ok {
+		return addr
+	}
+	return value.(Node).GetFailureDomain()
+}
+
+// excludeFailureDomains returns the hosts together with the nodes sharing a rack or host with them,
+// so that a new replica is not placed in a failure domain already holding one.
+func (c *Cluster) excludeFailureDomains(nodeType uint32, hosts []string) []string {
+	domains := make(map[string]struct{})
+	for _, host := range hosts {
+		if domain := c.getFailureDomain(nodeType, host); domain != host {
+			domains[domain] = struct{}{}
+		}
+	}
+	if len(domains) == 0 {
+		return hosts
+	}
+	excludes := append(make([]string, 0, len(hosts)), hosts...)
+	c.getNodeMap(nodeType).Range(func(key, value interface{}) bool {
+		node := value.(Node)
+		if _, ok := domains[node.GetFailureDomain()]; ok && !contains(excludes, node.GetAddr()) {
+			excludes = append(excludes, node.GetAddr())
+		}
+		return true
+	})
+	return excludes
+}
+
+// updateDataNodeLabels sets the rack and host labels of the datanode, the empty labels are left unchanged.
+func (c *Cluster) updateDataNodeLabels(dataNode *DataNode, rackName, hostName string) (err error) {
+	dataNode.Lock()
+	oldRack, oldHost := dataNode.RackName, dataNode.HostName
+	if rackName != "" {
+		dataNode.RackName = rackName
+	}
+	if hostName != "" {
+		dataNode.HostName = hostName
+	}
+	changed := oldRack != dataNode.RackName || oldHost != dataNode.HostName
+	dataNode.Unlock()
+	if !changed {
+		return
+	}
+	if err = c.syncUpdateDataNode(dataNode); err != nil {
+		dataNode.Lock()
+		dataNode.RackName, dataNode.HostName = oldRack, oldHost
+		dataNode.Unlock()
+		return
+	}
+	log.LogInfof("action[updateDataNodeLabels] dataNode[%v] rack[%v] host[%v]", dataNode.Addr, dataNode.RackName, dataNode.HostName)
+	return
+}
+
+// updateMetaNodeLabels sets the rack and host labels of the metanode, the empty labels are left unchanged.
+func (c *Cluster) updateMetaNodeLabels(metaNode *MetaNode, rackName, hostName string) (err error) {
+	metaNode.Lock()
+	oldRack, oldHost := metaNode.RackName, metaNode.HostName
+	if rackName != "" {
+		metaNode.RackName = rackName
+	}
+	if hostName != "" {
+		metaNode.HostName = hostName
+	}
+	changed := oldRack != metaNode.RackName || oldHost != metaNode.HostName
+	metaNode.Unlock()
+	if !changed {
+		return
+	}
+	if err = c.syncUpdateMetaNode(metaNode); err != nil {
+		metaNode.Lock()
+		metaNode.RackName, metaNode.HostName = oldRack, oldHost
+		metaNode.Unlock()
+		return
+	}
+	log.LogInfof("action[updateMetaNodeLabels] metaNode[%v] rack[%v] host[%v]", metaNode.Addr, metaNode.RackName, metaNode.HostName)
+	return
+}
+
+// checkFailureDomains returns the replicas sharing a failure domain, keyed by the domain.
+func (c *Cluster) checkFailureDomains(nodeType uint32, hosts []string) map[string][]string {
+	var shared map[string][]string
+	byDomain := make(map[string][]string, len(hosts))
+	for _, host := range hosts {
+		domain := c.getFailureDomain(nodeType, host)
+		byDomain[domain] = append(byDomain[domain], host)
+		if len(byDomain[domain]) > 1 {
+			if shared == nil {
+				shared = make(map[string][]string)
+			}
+			shared[domain] = byDomain[domain]
+		}
+	}
+	return shared
+}
+
+// failureDomainViolations lists the partitions with more than one replica in a rack or host.
+func (c *Cluster) failureDomainViolations() (violations []*proto.FailureDomainViolation) {
+	violations = make([]*proto.FailureDomainViolation, 0)
+	report := func(id uint64, partitionType, volName string, shared map[string][]string) {
+		for domain, hosts := range shared {
+			violations = append(violations, &proto.FailureDomainViolation{
+				PartitionID:   id,
+				PartitionType: partitionType,
+				VolName:       volName,
+				FailureDomain: domain,
+				Hosts:         hosts,
+			})
+		}
+	}
+	for _, vol := range c.copyVols() {
+		for _, dp := range vol.dataPartitions.clonePartitions() {
+			dp.RLock()
+			hosts := append([]string(nil), dp.Hosts...)
+			dp.RUnlock()
+			report(dp.PartitionID, "data", vol.Name, c.checkFailureDomains(TypeDataPartition, hosts))
+		}
+		for _, mp := range vol.cloneMetaPartitionMap() {
+			mp.RLock()
+			hosts := append([]string(nil), mp.Hosts...)
+			mp.RUnlock()
+			report(mp.PartitionID, "meta", vol.Name, c.checkFailureDomains(TypeMetaPartition, hosts))
+		}
+	}
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].PartitionType != violations[j].PartitionType {
+			return violations[i].PartitionType < violations[j].PartitionType
+		}
+		return violations[i].PartitionID < violations[j].PartitionID
+	})
+	return
+}
+
+// excludeFailureDomainsForMove is excludeFailureDomains for moving the replica off srcAddr, whose
+// failure domain is left free to take the new replica.
+func (c *Cluster) excludeFailureDomainsForMove(nodeType uint32, hosts []string, srcAddr string) []string {
+	others := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if host != srcAddr {
+			others = append(others, host)
+		}
+	}
+	excludes := c.excludeFailureDomains(nodeType, others)
+	if contains(hosts, srcAddr) && !contains(excludes, srcAddr) {
+		excludes = append(excludes, srcAddr)
+	}
+	return excludes
+}
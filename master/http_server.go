@@ -38,6 +38,7 @@ func (m *Server) startHTTPService(modulename string, cfg *config.Config) {
 	router := mux.NewRouter().SkipClean(true)
 	m.registerAPIRoutes(router)
 	m.registerAPIMiddleware(router)
+	m.registerRBACMiddleware(router)
 	if m.cluster.authenticate {
 		m.registerAuthenticationMiddleware(router)
 	}
@@ -125,7 +126,7 @@ func (m *Server) registerAPIMiddleware(route *mux.Router) {
 				if m.partition.IsRaftLeader() || isFollowerRead {
 					if m.metaReady || isFollowerRead {
 						log.LogDebugf("action[interceptor] request, method[%v] path[%v] query[%v]", r.Method, r.URL.Path, r.URL.Query())
-						next.ServeHTTP(w, r)
+						m.serveAndAudit(next, w, r)
 						return
 					}
 					log.LogWarnf("action[interceptor] leader meta has not ready")
@@ -294,6 +295,24 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminVolEnableAuditLog).
 		HandlerFunc(m.setEnableAuditLogForVolume)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSetVolReplication).
+		HandlerFunc(m.setVolReplication)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetVolReplication).
+		HandlerFunc(m.getVolReplication)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminDelVolReplication).
+		HandlerFunc(m.deleteVolReplication)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminCloneVol).
+		HandlerFunc(m.cloneVol)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminRenameVol).
+		HandlerFunc(m.renameVol)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminPromoteVol).
+		HandlerFunc(m.promoteVol)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminClusterForbidMpDecommission).
 		HandlerFunc(m.setupForbidMetaPartitionDecommission)
@@ -356,6 +375,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminQueryAutoDecommissionDisk).
 		HandlerFunc(m.queryAutoDecommissionDisk)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminListAudit).
+		HandlerFunc(m.listAdminAudits)
 
 	// volume management APIs
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
@@ -408,6 +430,15 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.AdminGetAllVersionInfo).
 		HandlerFunc(m.GetAllVersionInfo)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminCreateDirSnapshot).
+		HandlerFunc(m.CreateDirSnapshot)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminDelDirSnapshot).
+		HandlerFunc(m.DelDirSnapshot)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminListDirSnapshot).
+		HandlerFunc(m.ListDirSnapshots)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminGetVolVer).
 		HandlerFunc(m.getVolVer)
@@ -456,6 +487,15 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminBalanceMetaPartitionLeader).
 		HandlerFunc(m.balanceMetaPartitionLeader)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminStartDataRebalance).
+		HandlerFunc(m.startDataRebalance)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminStopDataRebalance).
+		HandlerFunc(m.stopDataRebalance)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetDataRebalance).
+		HandlerFunc(m.getDataRebalance)
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.ClientMetaPartitions).
 		HandlerFunc(m.getMetaPartitions)
@@ -492,6 +532,12 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminCreateMetaPartition).
 		HandlerFunc(m.createMetaPartition)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSplitMetaPartition).
+		HandlerFunc(m.splitMetaPartition)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminMergeMetaPartition).
+		HandlerFunc(m.mergeMetaPartition)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminAddMetaReplica).
 		HandlerFunc(m.addMetaReplica)
@@ -550,6 +596,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.GetMetaNode).
 		HandlerFunc(m.getMetaNode)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.SetMetaNodeMaintenance).
+		HandlerFunc(m.setMetaNodeMaintenance)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminSetMetaNodeThreshold).
 		HandlerFunc(m.setMetaNodeThreshold)
@@ -595,6 +644,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.CancelDecommissionDataNode).
 		HandlerFunc(m.cancelDecommissionDataNode)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.SetDataNodeMaintenance).
+		HandlerFunc(m.setDataNodeMaintenance)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.QueryDataNodeDecoFailedDps).
 		HandlerFunc(m.queryDataNodeDecoFailedDps)
@@ -753,6 +805,15 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.QuotaListAll).
 		HandlerFunc(m.ListQuotaAll)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.UserQuotaSet).
+		HandlerFunc(m.SetUserQuota)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.UserQuotaDelete).
+		HandlerFunc(m.DeleteUserQuota)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.UserQuotaReport).
+		HandlerFunc(m.ReportUserQuota)
 
 	// S3 API QoS Manager
 	router.NewRoute().Methods(http.MethodPut, http.MethodPost).
@@ -84,3 +84,17 @@ func (lcNode *LcNode) createSnapshotVerDelTask(masterAddr string, sTask *proto.S
 	task = proto.NewAdminTaskEx(proto.OpLcNodeSnapshotVerDel, lcNode.Addr, request, request.Task.Id)
 	return
 }
+
+func (lcNode *LcNode) createReplicationTask(masterAddr string, rTask *proto.ReplicationTask) (task *proto.AdminTask) {
+	request := &proto.ReplicationTaskRequest{
+		MasterAddr: masterAddr,
+		LcNodeAddr: lcNode.Addr,
+		Task:       rTask,
+	}
+	reqID := rTask.Id
+	if rTask.Stop {
+		reqID += "_stop"
+	}
+	task = proto.NewAdminTaskEx(proto.OpLcNodeReplicate, lcNode.Addr, request, reqID)
+	return
+}
@@ -51,6 +51,9 @@ func (c *Cluster) handleLcNodeTaskResponse(nodeAddr string, task *proto.AdminTas
 	case proto.OpLcNodeSnapshotVerDel:
 		response := task.Response.(*proto.SnapshotVerDelTaskResponse)
 		err = c.handleLcNodeSnapshotScanResp(task.OperatorAddr, response)
+	case proto.OpLcNodeReplicate:
+		response := task.Response.(*proto.ReplicationTaskResponse)
+		err = c.handleLcNodeReplicateResp(task.OperatorAddr, response)
 	default:
 		err = fmt.Errorf(fmt.Sprintf("lc unknown operate code %v", task.OpCode))
 		goto errHandler
@@ -135,6 +138,20 @@ func (c *Cluster) handleLcNodeHeartbeatResp(nodeAddr string, resp *proto.LcNodeH
 		}
 	}
 
+	// handle ReplicationTasks
+	stopTasks := make([]*proto.AdminTask, 0)
+	for volName, taskRsp := range resp.ReplicationTasks {
+		if !c.replicationMgr.updateTask(nodeAddr, taskRsp) {
+			log.LogWarnf("action[handleLcNodeHeartbeatResp], lcNode[%v] replication of vol[%v] is not assigned, stop it", nodeAddr, volName)
+			stopTasks = append(stopTasks, lcNode.createReplicationTask(c.masterAddr(), &proto.ReplicationTask{
+				Id:      volName,
+				VolName: volName,
+				Stop:    true,
+			}))
+		}
+	}
+	c.addLcNodeTasks(stopTasks)
+
 	log.LogInfof("action[handleLcNodeHeartbeatResp], lcNode[%v], heartbeat success", nodeAddr)
 	return
 }
@@ -192,3 +209,13 @@ func (c *Cluster) handleLcNodeSnapshotScanResp(nodeAddr string, resp *proto.Snap
 
 	return
 }
+
+func (c *Cluster) handleLcNodeReplicateResp(nodeAddr string, resp *proto.ReplicationTaskResponse) (err error) {
+	switch resp.Status {
+	case proto.TaskFailed:
+		c.replicationMgr.handleTaskFailed(nodeAddr, resp)
+	default:
+		log.LogInfof("action[handleLcNodeReplicateResp] replication received, lcNode[%v] resp(%v)", nodeAddr, resp)
+	}
+	return
+}
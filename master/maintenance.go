@@ -0,0 +1,212 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// A node is put in maintenance before a planned restart. Until the maintenance expires the
+// partitions with a replica on it are degraded by design: they are neither repaired nor
+// decommissioned, and the node is not moved by the rebalancer.
+
+func maintenanceActive(expire int64) bool {
+	return expire > time.Now().Unix()
+}
+
+func maintenanceExpire(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return time.Now().Add(ttl).Unix()
+}
+
+// setDataNodeMaintenance puts the datanode in maintenance for ttl, a zero ttl ends the maintenance.
+func (c *Cluster) setDataNodeMaintenance(addr string, ttl time.Duration) (view *proto.NodeMaintenanceView, err error) {
+	var dataNode *DataNode
+	if dataNode, err = c.dataNode(addr); err != nil {
+		return
+	}
+	dataNode.Lock()
+	oldExpire := dataNode.MaintenanceExpire
+	dataNode.MaintenanceExpire = maintenanceExpire(ttl)
+	dataNode.Unlock()
+	if err = c.syncUpdateDataNode(dataNode); err != nil {
+		dataNode.Lock()
+		dataNode.MaintenanceExpire = oldExpire
+		dataNode.Unlock()
+		return
+	}
+	view = c.dataNodeMaintenanceView(dataNode)
+	log.LogWarnf("action[setDataNodeMaintenance] dataNode[%v] maintenance expire[%v] degraded partitions[%v]",
+		addr, view.MaintenanceExpire, view.PartitionIDs)
+	return
+}
+
+// setMetaNodeMaintenance puts the metanode in maintenance for ttl, a zero ttl ends the maintenance.
+func (c *Cluster) setMetaNodeMaintenance(addr string, ttl time.Duration) (view *proto.NodeMaintenanceView, err error) {
+	var metaNode *MetaNode
+	if metaNode, err = c.metaNode(addr); err != nil {
+		return
+	}
+	metaNode.Lock()
+	oldExpire := metaNode.MaintenanceExpire
+	metaNode.MaintenanceExpire = maintenanceExpire(ttl)
+	metaNode.Unlock()
+	if err = c.syncUpdateMetaNode(metaNode); err != nil {
+		metaNode.Lock()
+		metaNode.MaintenanceExpire = oldExpire
+		metaNode.Unlock()
+		return
+	}
+	view = c.metaNodeMaintenanceView(metaNode)
+	log.LogWarnf("action[setMetaNodeMaintenance] metaNode[%v] maintenance expire[%v] degraded partitions[%v]",
+		addr, view.MaintenanceExpire, view.PartitionIDs)
+	return
+}
+
+func (c *Cluster) dataNodeMaintenanceView(dataNode *DataNode) *proto.NodeMaintenanceView {
+	view := &proto.NodeMaintenanceView{Addr: dataNode.Addr, PartitionIDs: make([]uint64, 0)}
+	if !dataNode.inMaintenance() {
+		return view
+	}
+	dataNode.RLock()
+	view.MaintenanceExpire = dataNode.MaintenanceExpire
+	dataNode.RUnlock()
+	view.PartitionIDs = c.getAllDataPartitionIDByDatanode(dataNode.Addr)
+	return view
+}
+
+func (c *Cluster) metaNodeMaintenanceView(metaNode *MetaNode) *proto.NodeMaintenanceView {
+	view := &proto.NodeMaintenanceView{Addr: metaNode.Addr, PartitionIDs: make([]uint64, 0)}
+	if !metaNode.inMaintenance() {
+		return view
+	}
+	metaNode.RLock()
+	view.MaintenanceExpire = metaNode.MaintenanceExpire
+	metaNode.RUnlock()
+	view.PartitionIDs = c.getAllMetaPartitionIDByMetaNode(metaNode.Addr)
+	return view
+}
+
+// checkNodeMaintenance returns an error if the node is in maintenance, the data on it must not be moved.
+func (c *Cluster) checkNodeMaintenance(nodeType uint32, addr string) error {
+	value, ok := c.getNodeMap(nodeType).Load(addr)
+	if !ok {
+		return nil
+	}
+	var expire int64
+	switch node := value.(type) {
+	case *DataNode:
+		if !node.inMaintenance() {
+			return nil
+		}
+		node.RLock()
+		expire = node.MaintenanceExpire
+		node.RUnlock()
+	case *MetaNode:
+		if !node.inMaintenance() {
+			return nil
+		}
+		node.RLock()
+		expire = node.MaintenanceExpire
+		node.RUnlock()
+	default:
+		return nil
+	}
+	return fmt.Errorf("node[%v] is in maintenance until %v", addr, time.Unix(expire, 0).Format(time.RFC3339))
+}
+
+// hasReplicaInMaintenance returns whether any of the hosts is in maintenance.
+func (c *Cluster) hasReplicaInMaintenance(nodeType uint32, hosts []string) bool {
+	for _, host := range hosts {
+		if c.checkNodeMaintenance(nodeType, host) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// maintenanceNodes returns the sorted addresses of the nodes in maintenance.
+func (c *Cluster) maintenanceNodes(nodeType uint32) (addrs []string) {
+	addrs = make([]string, 0)
+	c.getNodeMap(nodeType).Range(func(key, value interface{}) bool {
+		if c.checkNodeMaintenance(nodeType, key.(string)) != nil {
+			addrs = append(addrs, key.(string))
+		}
+		return true
+	})
+	sort.Strings(addrs)
+	return
+}
+
+// degradedByDesign returns the nodes in maintenance and the partitions with a replica on them.
+func (c *Cluster) degradedByDesign(nodeType uint32) (nodes []string, partitionIDs []uint64) {
+	nodes = c.maintenanceNodes(nodeType)
+	partitionIDs = make([]uint64, 0)
+	for _, addr := range nodes {
+		if nodeType == TypeDataPartition {
+			partitionIDs = append(partitionIDs, c.getAllDataPartitionIDByDatanode(addr)...)
+		} else {
+			partitionIDs = append(partitionIDs, c.getAllMetaPartitionIDByMetaNode(addr)...)
+		}
+	}
+	sort.Slice(partitionIDs, func(i, j int) bool { return partitionIDs[i] < partitionIDs[j] })
+	return nodes, uniqueIDs(partitionIDs)
+}
+
+func uniqueIDs(sorted []uint64) []uint64 {
+	ids := sorted[:0]
+	for _, id := range sorted {
+		if len(ids) == 0 || id != ids[len(ids)-1] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// withoutDegradedByDesign removes the partitions degraded by the maintenance from ids.
+func withoutDegradedByDesign(ids []uint64, degraded []uint64) []uint64 {
+	if len(degraded) == 0 {
+		return ids
+	}
+	set := make(map[uint64]struct{}, len(degraded))
+	for _, id := range degraded {
+		set[id] = struct{}{}
+	}
+	rest := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := set[id]; !ok {
+			rest = append(rest, id)
+		}
+	}
+	return rest
+}
+
+// withoutMaintenanceNodes removes the nodes in maintenance from addrs.
+func withoutMaintenanceNodes(addrs []string, maintenance []string) []string {
+	rest := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if !contains(maintenance, addr) {
+			rest = append(rest, addr)
+		}
+	}
+	return rest
+}
@@ -0,0 +1,283 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const defaultUserQuotaGracePeriod = 7 * 24 * time.Hour
+
+// MasterUserQuotaManager keeps the uid and gid quotas of a volume. The metanodes report the usage
+// of every uid and gid, the manager sums them up and decides which ids are limited.
+type MasterUserQuotaManager struct {
+	MpUsedInfoMap map[uint64][]*proto.UserQuotaReportInfo
+	QuotaInfoMap  map[proto.UserQuotaKey]*proto.UserQuotaInfo
+	UsedInfoMap   map[proto.UserQuotaKey]proto.QuotaUsedInfo
+	vol           *Vol
+	c             *Cluster
+
+	sync.RWMutex
+}
+
+func newMasterUserQuotaManager(c *Cluster, vol *Vol) *MasterUserQuotaManager {
+	return &MasterUserQuotaManager{
+		MpUsedInfoMap: make(map[uint64][]*proto.UserQuotaReportInfo),
+		QuotaInfoMap:  make(map[proto.UserQuotaKey]*proto.UserQuotaInfo),
+		UsedInfoMap:   make(map[proto.UserQuotaKey]proto.QuotaUsedInfo),
+		c:             c,
+		vol:           vol,
+	}
+}
+
+func (uqMgr *MasterUserQuotaManager) quotaKey(key proto.UserQuotaKey) string {
+	return userQuotaPrefix + strconv.FormatUint(uqMgr.vol.ID, 10) + keySeparator +
+		key.Type.String() + keySeparator + strconv.FormatUint(uint64(key.Id), 10)
+}
+
+func (uqMgr *MasterUserQuotaManager) syncQuota(op uint32, quotaInfo *proto.UserQuotaInfo) (err error) {
+	var value []byte
+	if value, err = json.Marshal(quotaInfo); err != nil {
+		return
+	}
+	metadata := new(RaftCmd)
+	metadata.Op = op
+	metadata.K = uqMgr.quotaKey(quotaInfo.Key())
+	metadata.V = value
+	return uqMgr.c.submit(metadata)
+}
+
+func (uqMgr *MasterUserQuotaManager) setQuota(req *proto.UserQuotaInfo) (err error) {
+	uqMgr.Lock()
+	defer uqMgr.Unlock()
+
+	key := req.Key()
+	quotaInfo, isFind := uqMgr.QuotaInfoMap[key]
+	if !isFind && len(uqMgr.QuotaInfoMap) >= gConfig.MaxQuotaNumPerVol {
+		err = errors.NewErrorf("the number of user quota has reached the upper limit %v", len(uqMgr.QuotaInfoMap))
+		return
+	}
+	if req.SoftBytes != 0 && req.HardBytes != 0 && req.SoftBytes > req.HardBytes {
+		err = errors.NewErrorf("soft bytes limit %v is larger than the hard limit %v", req.SoftBytes, req.HardBytes)
+		return
+	}
+	if req.SoftFiles != 0 && req.HardFiles != 0 && req.SoftFiles > req.HardFiles {
+		err = errors.NewErrorf("soft files limit %v is larger than the hard limit %v", req.SoftFiles, req.HardFiles)
+		return
+	}
+
+	newInfo := &proto.UserQuotaInfo{
+		VolName:     uqMgr.vol.Name,
+		Type:        req.Type,
+		Id:          req.Id,
+		CTime:       time.Now().Unix(),
+		SoftBytes:   req.SoftBytes,
+		HardBytes:   req.HardBytes,
+		SoftFiles:   req.SoftFiles,
+		HardFiles:   req.HardFiles,
+		GracePeriod: req.GracePeriod,
+	}
+	if isFind {
+		newInfo.CTime = quotaInfo.CTime
+	}
+	newInfo.UsedInfo = uqMgr.UsedInfoMap[key]
+	uqMgr.checkLimited(newInfo, time.Now().Unix())
+
+	if err = uqMgr.syncQuota(opSyncSetUserQuota, newInfo); err != nil {
+		log.LogErrorf("set user quota [%v] submit fail [%v].", newInfo, err)
+		return
+	}
+	uqMgr.QuotaInfoMap[key] = newInfo
+	log.LogInfof("set user quota [%v] success.", newInfo)
+	return
+}
+
+func (uqMgr *MasterUserQuotaManager) deleteQuota(key proto.UserQuotaKey) (err error) {
+	uqMgr.Lock()
+	defer uqMgr.Unlock()
+
+	quotaInfo, isFind := uqMgr.QuotaInfoMap[key]
+	if !isFind {
+		err = fmt.Errorf("%v quota of id %v is not exist", key.Type, key.Id)
+		return
+	}
+	if err = uqMgr.syncQuota(opSyncDeleteUserQuota, quotaInfo); err != nil {
+		log.LogErrorf("delete user quota [%v] submit fail [%v].", quotaInfo, err)
+		return
+	}
+	delete(uqMgr.QuotaInfoMap, key)
+	log.LogInfof("delete user quota [%v] success.", quotaInfo)
+	return
+}
+
+// report returns the quotas and the usage of the ids without a quota, like repquota.
+func (uqMgr *MasterUserQuotaManager) report(quotaType *proto.UserQuotaType) (resp *proto.UserQuotaReportResponse) {
+	uqMgr.RLock()
+	defer uqMgr.RUnlock()
+
+	resp = &proto.UserQuotaReportResponse{Quotas: make([]*proto.UserQuotaInfo, 0)}
+	for key, quotaInfo := range uqMgr.QuotaInfoMap {
+		if quotaType != nil && key.Type != *quotaType {
+			continue
+		}
+		info := *quotaInfo
+		resp.Quotas = append(resp.Quotas, &info)
+	}
+	for key, usedInfo := range uqMgr.UsedInfoMap {
+		if quotaType != nil && key.Type != *quotaType {
+			continue
+		}
+		if _, isFind := uqMgr.QuotaInfoMap[key]; isFind {
+			continue
+		}
+		resp.Quotas = append(resp.Quotas, &proto.UserQuotaInfo{
+			VolName:  uqMgr.vol.Name,
+			Type:     key.Type,
+			Id:       key.Id,
+			UsedInfo: usedInfo,
+		})
+	}
+	return
+}
+
+// checkLimited applies the hard limits at once and the soft limits after their grace period,
+// it returns whether the grace of the quota is changed.
+func (uqMgr *MasterUserQuotaManager) checkLimited(quotaInfo *proto.UserQuotaInfo, now int64) (graceChanged bool) {
+	checkGrace := func(overSoft bool, expire *int64) (limited bool) {
+		if !overSoft {
+			if *expire != 0 {
+				*expire = 0
+				graceChanged = true
+			}
+			return false
+		}
+		if *expire == 0 {
+			*expire = now + quotaInfo.GracePeriod
+			graceChanged = true
+		}
+		return now >= *expire
+	}
+
+	quotaInfo.LimitedInfo.LimitedBytes = checkGrace(quotaInfo.IsOverSoftBytes(), &quotaInfo.BytesGraceExpire) ||
+		quotaInfo.IsOverHardBytes()
+	quotaInfo.LimitedInfo.LimitedFiles = checkGrace(quotaInfo.IsOverSoftFiles(), &quotaInfo.FilesGraceExpire) ||
+		quotaInfo.IsOverHardFiles()
+	return
+}
+
+func (uqMgr *MasterUserQuotaManager) quotaUpdate(report *proto.MetaPartitionReport) {
+	if !report.IsLeader {
+		return
+	}
+
+	mpIds := make(map[uint64]bool)
+	uqMgr.vol.mpsLock.RLock()
+	for id := range uqMgr.vol.MetaPartitions {
+		mpIds[id] = true
+	}
+	uqMgr.vol.mpsLock.RUnlock()
+
+	graceChanged := make([]*proto.UserQuotaInfo, 0)
+	uqMgr.Lock()
+	uqMgr.MpUsedInfoMap[report.PartitionID] = report.UserQuotaReports
+	allReported := true
+	for id := range mpIds {
+		if _, isFind := uqMgr.MpUsedInfoMap[id]; !isFind {
+			allReported = false
+		}
+	}
+	for id := range uqMgr.MpUsedInfoMap {
+		if !mpIds[id] {
+			delete(uqMgr.MpUsedInfoMap, id)
+		}
+	}
+
+	usedInfoMap := make(map[proto.UserQuotaKey]proto.QuotaUsedInfo, len(uqMgr.UsedInfoMap))
+	for _, reportInfos := range uqMgr.MpUsedInfoMap {
+		for _, info := range reportInfos {
+			key := proto.UserQuotaKey{Type: info.Type, Id: info.Id}
+			usedInfo := usedInfoMap[key]
+			usedInfo.Add(&info.UsedInfo)
+			usedInfoMap[key] = usedInfo
+		}
+	}
+	uqMgr.UsedInfoMap = usedInfoMap
+
+	now := time.Now().Unix()
+	for key, quotaInfo := range uqMgr.QuotaInfoMap {
+		quotaInfo.UsedInfo = usedInfoMap[key]
+		// the usage is partial until every partition has reported, e.g. after a change of the leader
+		if !allReported {
+			continue
+		}
+		if uqMgr.checkLimited(quotaInfo, now) {
+			info := *quotaInfo
+			graceChanged = append(graceChanged, &info)
+		}
+	}
+	uqMgr.Unlock()
+
+	// persist the grace so that it survives a change of the master leader
+	for _, quotaInfo := range graceChanged {
+		if err := uqMgr.syncQuota(opSyncSetUserQuota, quotaInfo); err != nil {
+			log.LogWarnf("[quotaUpdate] vol [%v] persist user quota [%v] grace fail [%v]", uqMgr.vol.Name, quotaInfo, err)
+		}
+	}
+}
+
+func (uqMgr *MasterUserQuotaManager) getQuotaHbInfos() (infos []*proto.UserQuotaHeartBeatInfo) {
+	uqMgr.RLock()
+	defer uqMgr.RUnlock()
+	for key, quotaInfo := range uqMgr.QuotaInfoMap {
+		infos = append(infos, &proto.UserQuotaHeartBeatInfo{
+			VolName:     uqMgr.vol.Name,
+			Type:        key.Type,
+			Id:          key.Id,
+			LimitedInfo: quotaInfo.LimitedInfo,
+		})
+	}
+	return
+}
+
+func (vol *Vol) initUserQuotaManager(c *Cluster) {
+	vol.userQuotaManager = newMasterUserQuotaManager(c, vol)
+}
+
+func (vol *Vol) loadUserQuotaManager(c *Cluster) (err error) {
+	vol.userQuotaManager = newMasterUserQuotaManager(c, vol)
+
+	result, err := c.fsm.store.SeekForPrefix([]byte(userQuotaPrefix + strconv.FormatUint(vol.ID, 10) + keySeparator))
+	if err != nil {
+		err = fmt.Errorf("loadUserQuotaManager get user quota failed, err [%v]", err)
+		return
+	}
+	for _, value := range result {
+		quotaInfo := &proto.UserQuotaInfo{}
+		if err = json.Unmarshal(value, quotaInfo); err != nil {
+			log.LogErrorf("loadUserQuotaManager Unmarshal fail err [%v]", err)
+			return
+		}
+		vol.userQuotaManager.QuotaInfoMap[quotaInfo.Key()] = quotaInfo
+	}
+	return
+}
@@ -32,6 +32,9 @@ type MetaNode struct {
 	IsActive                  bool
 	Sender                    *AdminTaskManager `graphql:"-"`
 	ZoneName                  string            `json:"Zone"`
+	RackName                  string            // optional rack label, the replicas are spread across racks
+	HostName                  string            // optional label of the physical host running the node
+	MaintenanceExpire         int64             // unix time the maintenance ends, the node is not repaired before it
 	MaxMemAvailWeight         uint64            `json:"MaxMemAvailWeight"`
 	Total                     uint64            `json:"TotalWeight"`
 	Used                      uint64            `json:"UsedWeight"`
@@ -76,6 +79,18 @@ func (metaNode *MetaNode) GetAddr() string {
 	return metaNode.Addr
 }
 
+func (metaNode *MetaNode) GetFailureDomain() string {
+	metaNode.RLock()
+	defer metaNode.RUnlock()
+	return failureDomain(metaNode.ZoneName, metaNode.RackName, metaNode.HostName, metaNode.Addr)
+}
+
+func (metaNode *MetaNode) inMaintenance() bool {
+	metaNode.RLock()
+	defer metaNode.RUnlock()
+	return maintenanceActive(metaNode.MaintenanceExpire)
+}
+
 // SelectNodeForWrite implements the Node interface
 func (metaNode *MetaNode) SelectNodeForWrite() {
 	metaNode.Lock()
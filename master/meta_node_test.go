@@ -31,3 +31,17 @@ func decommissionMetaNode(addr string, t *testing.T) {
 	reqURL := fmt.Sprintf("%v%v?addr=%v", hostAddr, proto.DecommissionMetaNode, addr)
 	process(reqURL, t)
 }
+
+func TestMetaNodeMaintenance(t *testing.T) {
+	addr := mms1Addr
+	reqURL := fmt.Sprintf("%v%v?addr=%v&ttl=%v", hostAddr, proto.SetMetaNodeMaintenance, addr, time.Hour)
+	process(reqURL, t)
+	if nodes, _ := server.cluster.degradedByDesign(TypeMetaPartition); !contains(nodes, addr) {
+		t.Errorf("metaNode[%v] is not in maintenance, nodes %v", addr, nodes)
+	}
+	reqURL = fmt.Sprintf("%v%v?addr=%v&ttl=0", hostAddr, proto.SetMetaNodeMaintenance, addr)
+	process(reqURL, t)
+	if err := server.cluster.checkNodeMaintenance(TypeMetaPartition, addr); err != nil {
+		t.Errorf("metaNode[%v] maintenance is not ended: %v", addr, err)
+	}
+}
@@ -75,6 +75,7 @@ type MetaPartition struct {
 	EqualCheckPass   bool
 	VerSeq           uint64
 	heartBeatDone    bool
+	mergeTo          uint64 // the partition this one is frozen to be merged into
 
 	sync.RWMutex
 }
@@ -211,7 +212,7 @@ func (mp *MetaPartition) dataSize() uint64 {
 }
 
 func (mp *MetaPartition) checkEnd(c *Cluster, maxPartitionID uint64) {
-	if mp.PartitionID < maxPartitionID {
+	if mp.PartitionID != maxPartitionID {
 		return
 	}
 	vol, err := c.getVol(mp.volName)
@@ -342,7 +343,7 @@ func (mp *MetaPartition) checkStatus(clusterID string, writeLog bool, replicaNum
 		}
 	}
 
-	if mp.PartitionID >= maxPartitionID && mp.Status == proto.ReadOnly && !forbiddenVol {
+	if mp.PartitionID == maxPartitionID && mp.Status == proto.ReadOnly && !forbiddenVol {
 		mp.Status = proto.ReadWrite
 	}
 
@@ -420,6 +421,9 @@ func (mp *MetaPartition) updateMetaPartition(mgr *proto.MetaPartitionReport, met
 	if mr.IsLeader {
 		mp.LeaderReportTime = time.Now().Unix()
 	}
+	if mgr.MergeTo != 0 {
+		mp.mergeTo = mgr.MergeTo
+	}
 	mp.setMaxInodeID()
 	mp.setInodeCount()
 	mp.setDentryCount()
@@ -598,7 +602,7 @@ func (mp *MetaPartition) replicaCreationTasks(clusterID, volName string) (tasks
 	return
 }
 
-func (mp *MetaPartition) buildNewMetaPartitionTasks(specifyAddrs []string, peers []proto.Peer, volName string) (tasks []*proto.AdminTask) {
+func (mp *MetaPartition) buildNewMetaPartitionTasks(specifyAddrs []string, peers []proto.Peer, volName string, storeType proto.MetaStoreType) (tasks []*proto.AdminTask) {
 	tasks = make([]*proto.AdminTask, 0)
 	hosts := make([]string, 0)
 
@@ -609,6 +613,7 @@ func (mp *MetaPartition) buildNewMetaPartitionTasks(specifyAddrs []string, peers
 		Members:     peers,
 		VolName:     volName,
 		VerSeq:      mp.VerSeq,
+		StoreType:   storeType,
 	}
 	if specifyAddrs == nil {
 		hosts = mp.Hosts
@@ -662,7 +667,7 @@ func (mp *MetaPartition) createTaskToTryToChangeLeader(addr string) (task *proto
 	return
 }
 
-func (mp *MetaPartition) createTaskToCreateReplica(host string) (t *proto.AdminTask, err error) {
+func (mp *MetaPartition) createTaskToCreateReplica(host string, storeType proto.MetaStoreType) (t *proto.AdminTask, err error) {
 	req := &proto.CreateMetaPartitionRequest{
 		Start:       mp.Start,
 		End:         mp.End,
@@ -670,6 +675,7 @@ func (mp *MetaPartition) createTaskToCreateReplica(host string) (t *proto.AdminT
 		Members:     mp.Peers,
 		VolName:     mp.volName,
 		VerSeq:      mp.VerSeq,
+		StoreType:   storeType,
 	}
 	t = proto.NewAdminTask(proto.OpCreateMetaPartition, host, req)
 	resetMetaPartitionTaskID(t, mp.PartitionID)
@@ -683,6 +689,13 @@ func (mp *MetaPartition) createTaskToAddRaftMember(addPeer proto.Peer, leaderAdd
 	return
 }
 
+func (mp *MetaPartition) createTaskToPromoteLearner(promotePeer, replacePeer proto.Peer, leaderAddr string) (t *proto.AdminTask) {
+	req := &proto.PromoteMetaPartitionLearnerRequest{PartitionId: mp.PartitionID, PromotePeer: promotePeer, ReplacePeer: replacePeer}
+	t = proto.NewAdminTask(proto.OpPromoteMetaPartitionLearner, leaderAddr, req)
+	resetMetaPartitionTaskID(t, mp.PartitionID)
+	return
+}
+
 func (mp *MetaPartition) createTaskToRemoveRaftMember(removePeer proto.Peer) (t *proto.AdminTask, err error) {
 	mr, err := mp.getMetaReplicaLeader()
 	if err != nil {
@@ -0,0 +1,436 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	// a partition is split if it takes more than this ratio of the memory of a metanode that reaches the threshold
+	defaultMetaPartitionSplitMemRatio = 0.3
+	// the replicas of a merged partition are kept for a while for the clients holding the old view
+	defaultMergedMetaPartitionDeleteDelay = 10 * time.Minute
+)
+
+// splitMetaPartitionByInode splits mp online into two raft groups at key, the inodes not less than key
+// are moved to a new partition on the same metanodes. The median inode of mp is used if key is 0.
+func (c *Cluster) splitMetaPartitionByInode(vol *Vol, mp *MetaPartition, key uint64) (child *MetaPartition, err error) {
+	vol.createMpMutex.Lock()
+	defer vol.createMpMutex.Unlock()
+
+	mp.RLock()
+	start, end, mergeTo := mp.Start, mp.End, mp.mergeTo
+	leader, err := mp.getMetaReplicaLeader()
+	replicaCnt := len(mp.Replicas)
+	mp.RUnlock()
+	if err != nil {
+		return
+	}
+	if mergeTo != 0 {
+		return nil, fmt.Errorf("meta partition[%v] is being merged into [%v]", mp.PartitionID, mergeTo)
+	}
+	if mp.IsRecover || replicaCnt != int(mp.ReplicaNum) {
+		return nil, fmt.Errorf("meta partition[%v] is recovering or lacks replicas", mp.PartitionID)
+	}
+	if key != 0 && (key <= start || key > end) {
+		return nil, fmt.Errorf("split inode[%v] is out of the range of meta partition[%v] [%v,%v]", key, mp.PartitionID, start, end)
+	}
+
+	partitionID, err := c.idAlloc.allocateMetaPartitionID()
+	if err != nil {
+		return nil, errors.NewError(err)
+	}
+	req := &proto.SplitMetaPartitionRequest{
+		PartitionID:    mp.PartitionID,
+		NewPartitionID: partitionID,
+		VolName:        vol.Name,
+		SplitKey:       key,
+	}
+	task := proto.NewAdminTask(proto.OpSplitMetaPartition, leader.Addr, req)
+	resetMetaPartitionTaskID(task, mp.PartitionID)
+	packet, err := leader.metaNode.Sender.syncSendAdminTask(task)
+	if err != nil {
+		return
+	}
+	resp := &proto.SplitMetaPartitionResponse{}
+	if err = json.Unmarshal(packet.Data, resp); err != nil {
+		return
+	}
+	if resp.Status != proto.TaskSucceeds {
+		return nil, fmt.Errorf("split meta partition[%v] failed: %v", mp.PartitionID, resp.Result)
+	}
+	log.LogWarnf("action[splitMetaPartitionByInode] vol[%v] partition[%v] is split at inode[%v] to [%v]",
+		vol.Name, mp.PartitionID, resp.SplitKey, partitionID)
+	return c.addSplitMetaPartition(vol, mp, partitionID, resp.SplitKey, end)
+}
+
+// addSplitMetaPartition records that the range [key, end] of parent has been moved to the partition id.
+// The caller must hold vol.createMpMutex.
+func (c *Cluster) addSplitMetaPartition(vol *Vol, parent *MetaPartition, id, key, end uint64) (child *MetaPartition, err error) {
+	if child, err = vol.metaPartition(id); err == nil {
+		return
+	}
+
+	parent.Lock()
+	oldEnd := parent.End
+	parent.End = key - 1
+	child = newMetaPartition(id, key, end, vol.mpReplicaNum, vol.Name, vol.ID, vol.VersionMgr.getLatestVer())
+	child.setHosts(append([]string{}, parent.Hosts...))
+	child.setPeers(append([]proto.Peer{}, parent.Peers...))
+
+	cmdMap := make(map[string]*RaftCmd)
+	updateMpRaftCmd, err := c.buildMetaPartitionRaftCmd(opSyncUpdateMetaPartition, parent)
+	if err != nil {
+		parent.End = oldEnd
+		parent.Unlock()
+		return nil, err
+	}
+	cmdMap[updateMpRaftCmd.K] = updateMpRaftCmd
+	addMpRaftCmd, err := c.buildMetaPartitionRaftCmd(opSyncAddMetaPartition, child)
+	if err != nil {
+		parent.End = oldEnd
+		parent.Unlock()
+		return nil, err
+	}
+	cmdMap[addMpRaftCmd.K] = addMpRaftCmd
+	if err = c.syncBatchCommitCmd(cmdMap); err != nil {
+		parent.End = oldEnd
+		parent.Unlock()
+		return nil, errors.NewError(err)
+	}
+	parent.updateInodeIDRangeForAllReplicas()
+	parent.Unlock()
+
+	child.Lock()
+	for _, host := range child.Hosts {
+		if err = child.afterCreation(host, c); err != nil {
+			log.LogWarnf("action[addSplitMetaPartition] partition[%v] host[%v] err[%v]", id, host, err)
+		}
+	}
+	child.Status = proto.ReadWrite
+	child.Unlock()
+	vol.addMetaPartition(child)
+	log.LogWarnf("action[addSplitMetaPartition] vol[%v] partition[%v] range[%v,%v] is split to partition[%v] range[%v,%v]",
+		vol.Name, parent.PartitionID, parent.Start, parent.End, id, key, end)
+	return child, nil
+}
+
+// mergeMetaPartitions merges the partition right into its adjacent partition left. The right partition
+// is frozen first so that no more writes are accepted, then all its items are moved to left in one
+// raft entry. The right partition is left frozen if the merge fails and the merge can be retried.
+func (c *Cluster) mergeMetaPartitions(vol *Vol, left, right *MetaPartition) (err error) {
+	vol.createMpMutex.Lock()
+	defer vol.createMpMutex.Unlock()
+
+	left.RLock()
+	leftEnd, leftMergeTo := left.End, left.mergeTo
+	leftHosts := append([]string{}, left.Hosts...)
+	leftLeader, err := left.getMetaReplicaLeader()
+	left.RUnlock()
+	if err != nil {
+		return
+	}
+	right.RLock()
+	rightStart, rightMergeTo := right.Start, right.mergeTo
+	rightHosts := append([]string{}, right.Hosts...)
+	rightLeader, err := right.getMetaReplicaLeader()
+	right.RUnlock()
+	if err != nil {
+		return
+	}
+
+	if leftEnd+1 != rightStart {
+		return fmt.Errorf("meta partition[%v] and [%v] are not adjacent", left.PartitionID, right.PartitionID)
+	}
+	if leftMergeTo != 0 {
+		return fmt.Errorf("meta partition[%v] is being merged into [%v]", left.PartitionID, leftMergeTo)
+	}
+	if rightMergeTo != 0 && rightMergeTo != left.PartitionID {
+		return fmt.Errorf("meta partition[%v] is being merged into [%v]", right.PartitionID, rightMergeTo)
+	}
+	if !sameHosts(leftHosts, rightHosts) {
+		return fmt.Errorf("meta partition[%v] and [%v] are not on the same metanodes", left.PartitionID, right.PartitionID)
+	}
+
+	freezeReq := &proto.FreezeMetaPartitionRequest{
+		PartitionID: right.PartitionID,
+		VolName:     vol.Name,
+		MergeTo:     left.PartitionID,
+		Frozen:      true,
+	}
+	task := proto.NewAdminTask(proto.OpFreezeMetaPartition, rightLeader.Addr, freezeReq)
+	resetMetaPartitionTaskID(task, right.PartitionID)
+	packet, err := rightLeader.metaNode.Sender.syncSendAdminTask(task)
+	if err != nil {
+		return
+	}
+	freezeResp := &proto.FreezeMetaPartitionResponse{}
+	if err = json.Unmarshal(packet.Data, freezeResp); err != nil {
+		return
+	}
+	if freezeResp.Status != proto.TaskSucceeds {
+		return fmt.Errorf("freeze meta partition[%v] failed: %v", right.PartitionID, freezeResp.Result)
+	}
+	right.Lock()
+	right.mergeTo = left.PartitionID
+	right.Unlock()
+
+	mergeReq := &proto.MergeMetaPartitionRequest{
+		PartitionID:   left.PartitionID,
+		VolName:       vol.Name,
+		SourceID:      right.PartitionID,
+		SourceApplyID: freezeResp.ApplyID,
+	}
+	task = proto.NewAdminTask(proto.OpMergeMetaPartition, leftLeader.Addr, mergeReq)
+	resetMetaPartitionTaskID(task, left.PartitionID)
+	if packet, err = leftLeader.metaNode.Sender.syncSendAdminTask(task); err != nil {
+		return
+	}
+	mergeResp := &proto.MergeMetaPartitionResponse{}
+	if err = json.Unmarshal(packet.Data, mergeResp); err != nil {
+		return
+	}
+	if mergeResp.Status != proto.TaskSucceeds {
+		return fmt.Errorf("merge meta partition[%v] into [%v] failed: %v", right.PartitionID, left.PartitionID, mergeResp.Result)
+	}
+	return c.completeMetaPartitionMerge(vol, left, right)
+}
+
+// completeMetaPartitionMerge extends the range of left to cover right and removes right from the volume.
+// The caller must hold vol.createMpMutex.
+func (c *Cluster) completeMetaPartitionMerge(vol *Vol, left, right *MetaPartition) (err error) {
+	left.Lock()
+	oldEnd := left.End
+	left.End = right.End
+	if err = c.syncUpdateMetaPartition(left); err != nil {
+		left.End = oldEnd
+		left.Unlock()
+		return
+	}
+	left.updateInodeIDRangeForAllReplicas()
+	left.Unlock()
+
+	if err = c.syncDeleteMetaPartition(right); err != nil {
+		return
+	}
+	vol.deleteMetaPartition(right.PartitionID)
+	c.mergedMetaPartitions.Store(right.PartitionID, time.Now())
+	log.LogWarnf("action[completeMetaPartitionMerge] vol[%v] partition[%v] is merged into [%v], range[%v,%v]",
+		vol.Name, right.PartitionID, left.PartitionID, left.Start, left.End)
+	return
+}
+
+// reconcileMetaPartitionReport catches up with the splits and merges that completed on the metanodes
+// but were not recorded by the master, e.g. the master failed over or the request timed out.
+// It returns true if the report is handled and needs no further processing.
+func (c *Cluster) reconcileMetaPartitionReport(vol *Vol, metaNode *MetaNode, mr *proto.MetaPartitionReport) (handled bool) {
+	mp, err := vol.metaPartition(mr.PartitionID)
+	if err != nil {
+		if mr.MergeTo != 0 {
+			c.deleteMergedMetaPartition(vol, metaNode, mr)
+			return true
+		}
+		if mr.SplitFrom == 0 {
+			return true
+		}
+		parent, err := vol.metaPartition(mr.SplitFrom)
+		if err != nil {
+			return true
+		}
+		vol.createMpMutex.Lock()
+		defer vol.createMpMutex.Unlock()
+		parent.RLock()
+		valid := parent.Start < mr.Start && mr.Start <= parent.End && mr.End == parent.End
+		parent.RUnlock()
+		if !valid {
+			log.LogWarnf("action[reconcileMetaPartitionReport] vol[%v] split partition[%v] range[%v,%v] mismatches parent[%v]",
+				vol.Name, mr.PartitionID, mr.Start, mr.End, mr.SplitFrom)
+			return true
+		}
+		if _, err = c.addSplitMetaPartition(vol, parent, mr.PartitionID, mr.Start, mr.End); err != nil {
+			log.LogErrorf("action[reconcileMetaPartitionReport] add split partition[%v] err[%v]", mr.PartitionID, err)
+		}
+		return true
+	}
+
+	if mr.End <= mp.End {
+		return false
+	}
+	// the metanode has merged the next partition frozen for it
+	var next *MetaPartition
+	for _, candidate := range vol.cloneMetaPartitionMap() {
+		if candidate.isMergedInto(mp, mr.End) {
+			next = candidate
+			break
+		}
+	}
+	if next == nil {
+		return false
+	}
+	vol.createMpMutex.Lock()
+	defer vol.createMpMutex.Unlock()
+	if _, err = vol.metaPartition(next.PartitionID); err != nil || !next.isMergedInto(mp, mr.End) {
+		return false
+	}
+	if err = c.completeMetaPartitionMerge(vol, mp, next); err != nil {
+		log.LogErrorf("action[reconcileMetaPartitionReport] merge partition[%v] into [%v] err[%v]",
+			next.PartitionID, mp.PartitionID, err)
+	}
+	return false
+}
+
+// isMergedInto reports whether mp is frozen for target and target has grown to cover it.
+func (mp *MetaPartition) isMergedInto(target *MetaPartition, end uint64) bool {
+	target.RLock()
+	targetEnd := target.End
+	target.RUnlock()
+	mp.RLock()
+	defer mp.RUnlock()
+	return mp.mergeTo == target.PartitionID && mp.Start == targetEnd+1 && mp.End == end
+}
+
+// deleteMergedMetaPartition deletes the replica of a merged partition after the delay.
+func (c *Cluster) deleteMergedMetaPartition(vol *Vol, metaNode *MetaNode, mr *proto.MetaPartitionReport) {
+	target, err := vol.metaPartition(mr.MergeTo)
+	if err != nil {
+		return
+	}
+	target.RLock()
+	covered := target.Start <= mr.Start && mr.End <= target.End
+	target.RUnlock()
+	if !covered {
+		return
+	}
+	value, _ := c.mergedMetaPartitions.LoadOrStore(mr.PartitionID, time.Now())
+	if time.Since(value.(time.Time)) < defaultMergedMetaPartitionDeleteDelay {
+		return
+	}
+	req := &proto.DeleteMetaPartitionRequest{PartitionID: mr.PartitionID}
+	task := proto.NewAdminTask(proto.OpDeleteMetaPartition, metaNode.Addr, req)
+	resetMetaPartitionTaskID(task, mr.PartitionID)
+	c.addMetaNodeTasks([]*proto.AdminTask{task})
+	log.LogWarnf("action[deleteMergedMetaPartition] vol[%v] delete merged partition[%v] on [%v]", vol.Name, mr.PartitionID, metaNode.Addr)
+}
+
+func (mp *MetaPartition) needSplitByLoad(splitInodeCount uint64) bool {
+	mp.RLock()
+	defer mp.RUnlock()
+	if mp.InodeCount < 2 {
+		return false
+	}
+	if splitInodeCount > 0 && mp.InodeCount >= splitInodeCount {
+		return true
+	}
+	for _, mr := range mp.Replicas {
+		if mr.metaNode == nil || !mr.metaNode.reachesThreshold() {
+			continue
+		}
+		if float64(mr.dataSize) > float64(mr.metaNode.Total)*defaultMetaPartitionSplitMemRatio {
+			return true
+		}
+	}
+	return false
+}
+
+// checkMetaPartitionLoad splits the meta partitions holding too many inodes or too much memory, and merges
+// the adjacent partitions that are nearly empty. At most one partition of the volume is changed at a time.
+func (vol *Vol) checkMetaPartitionLoad(c *Cluster) {
+	splitInodeCount := atomic.LoadUint64(&c.cfg.MetaPartitionSplitInodeCount)
+	mergeInodeCount := atomic.LoadUint64(&c.cfg.MetaPartitionMergeInodeCount)
+	if splitInodeCount == 0 && mergeInodeCount == 0 {
+		return
+	}
+	if c.DisableAutoAllocate || vol.Forbidden || vol.status() == proto.VolStatusMarkDelete {
+		return
+	}
+
+	mps := make([]*MetaPartition, 0)
+	for _, mp := range vol.cloneMetaPartitionMap() {
+		mps = append(mps, mp)
+	}
+	sort.Slice(mps, func(i, j int) bool { return mps[i].Start < mps[j].Start })
+
+	// retry the merges that failed halfway
+	for i := 1; i < len(mps); i++ {
+		if mps[i].mergeTo != 0 && mps[i].mergeTo == mps[i-1].PartitionID {
+			if err := c.mergeMetaPartitions(vol, mps[i-1], mps[i]); err != nil {
+				log.LogWarnf("action[checkMetaPartitionLoad] vol[%v] merge partition[%v] into [%v] err[%v]",
+					vol.Name, mps[i].PartitionID, mps[i-1].PartitionID, err)
+			}
+			return
+		}
+	}
+
+	if splitInodeCount > 0 {
+		var hot *MetaPartition
+		for _, mp := range mps {
+			if mp.needSplitByLoad(splitInodeCount) && (hot == nil || mp.InodeCount > hot.InodeCount) {
+				hot = mp
+			}
+		}
+		if hot != nil {
+			if _, err := c.splitMetaPartitionByInode(vol, hot, 0); err != nil {
+				Warn(c.Name, fmt.Sprintf("cluster[%v],vol[%v],meta partition[%v] online split failed,err[%v]",
+					c.Name, vol.Name, hot.PartitionID, err))
+			}
+			return
+		}
+	}
+
+	// the partition of the highest range keeps growing and is never merged
+	if mergeInodeCount == 0 || len(mps) <= lowerLimitRWMetaPartition+1 {
+		return
+	}
+	for i := 1; i < len(mps)-1; i++ {
+		left, right := mps[i-1], mps[i]
+		if left.InodeCount+right.InodeCount >= mergeInodeCount || left.End+1 != right.Start {
+			continue
+		}
+		left.RLock()
+		right.RLock()
+		mergeable := sameHosts(left.Hosts, right.Hosts)
+		right.RUnlock()
+		left.RUnlock()
+		if !mergeable {
+			continue
+		}
+		if err := c.mergeMetaPartitions(vol, left, right); err != nil {
+			log.LogWarnf("action[checkMetaPartitionLoad] vol[%v] merge partition[%v] into [%v] err[%v]",
+				vol.Name, right.PartitionID, left.PartitionID, err)
+		}
+		return
+	}
+}
+
+func sameHosts(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, host := range a {
+		if !contains(b, host) {
+			return false
+		}
+	}
+	return true
+}
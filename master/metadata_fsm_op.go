@@ -62,6 +62,9 @@ type clusterValue struct {
 	EnableAutoDecommissionDisk  bool
 	DecommissionDiskFactor      float64
 	VolDeletionDelayTimeHour    int64
+	Rebalance                   *bsProto.RebalanceConfig
+	MpSplitInodeCount           uint64
+	MpMergeInodeCount           uint64
 }
 
 func newClusterValue(c *Cluster) (cv *clusterValue) {
@@ -93,6 +96,12 @@ func newClusterValue(c *Cluster) (cv *clusterValue) {
 		EnableAutoDecommissionDisk:  c.EnableAutoDecommissionDisk,
 		DecommissionDiskFactor:      c.DecommissionDiskFactor,
 		VolDeletionDelayTimeHour:    c.cfg.volDelayDeleteTimeHour,
+		MpSplitInodeCount:           atomic.LoadUint64(&c.cfg.MetaPartitionSplitInodeCount),
+		MpMergeInodeCount:           atomic.LoadUint64(&c.cfg.MetaPartitionMergeInodeCount),
+	}
+	if c.rebalanceMgr != nil {
+		config := c.rebalanceMgr.getConfig()
+		cv.Rebalance = &config
 	}
 	return cv
 }
@@ -141,6 +150,7 @@ type dataPartitionValue struct {
 	IsRecover                      bool
 	PartitionType                  int
 	PartitionTTL                   int64
+	MediaType                      uint32
 	RdOnly                         bool
 	IsDiscard                      bool
 	DecommissionRetry              int
@@ -174,6 +184,7 @@ func (dpv *dataPartitionValue) Restore(c *Cluster) (dp *DataPartition) {
 	dp.isRecover = dpv.IsRecover
 	dp.RdOnly = dpv.RdOnly
 	dp.IsDiscard = dpv.IsDiscard
+	dp.MediaType = dpv.MediaType
 	dp.DecommissionRaftForce = dpv.DecommissionRaftForce
 	dp.DecommissionDstAddr = dpv.DecommissionDstAddr
 	dp.DecommissionSrcAddr = dpv.DecommissionSrcAddr
@@ -215,6 +226,7 @@ func newDataPartitionValue(dp *DataPartition) (dpv *dataPartitionValue) {
 		IsRecover:                      dp.isRecover,
 		PartitionType:                  dp.PartitionType,
 		PartitionTTL:                   dp.PartitionTTL,
+		MediaType:                      dp.MediaType,
 		RdOnly:                         dp.RdOnly,
 		IsDiscard:                      dp.IsDiscard,
 		DecommissionRetry:              dp.DecommissionRetry,
@@ -280,8 +292,19 @@ type volValue struct {
 	CacheLRUInterval int
 	CacheRule        string
 
-	EnablePosixAcl bool
-	EnableQuota    bool
+	EnablePosixAcl   bool
+	EnableQuota      bool
+	Compression      string
+	MediaType        uint32
+	MediaFallback    bool
+	TrashRetention   int64
+	MetaStoreType    bsProto.MetaStoreType
+	CloneSrc         string
+	CloneVerSeq      uint64
+	PromotePath      string
+	FormerName       string
+	FormerNameExpire int64
+	Replication      *bsProto.VolReplication
 
 	EnableTransaction       bsProto.TxOpMask
 	TxTimeout               int64
@@ -328,6 +351,17 @@ func newVolValue(vol *Vol) (vv *volValue) {
 		DefaultPriority:         vol.defaultPriority,
 		EnablePosixAcl:          vol.enablePosixAcl,
 		EnableQuota:             vol.enableQuota,
+		Compression:             vol.compression,
+		MediaType:               vol.mediaType,
+		MediaFallback:           vol.mediaFallback,
+		TrashRetention:          vol.trashRetention,
+		MetaStoreType:           vol.metaStoreType,
+		CloneSrc:                vol.cloneSrc,
+		CloneVerSeq:             vol.cloneVerSeq,
+		PromotePath:             vol.promotePath,
+		FormerName:              vol.formerName,
+		FormerNameExpire:        vol.formerNameExpire,
+		Replication:             vol.replication,
 		EnableTransaction:       vol.enableTransaction,
 		TxTimeout:               vol.txTimeout,
 		TxConflictRetryNum:      vol.txConflictRetryNum,
@@ -380,6 +414,9 @@ type dataNodeValue struct {
 	NodeSetID                uint64
 	Addr                     string
 	ZoneName                 string
+	RackName                 string
+	HostName                 string
+	MaintenanceExpire        int64
 	RdOnly                   bool
 	DecommissionedDisks      []string
 	DecommissionStatus       uint32
@@ -399,6 +436,9 @@ func newDataNodeValue(dataNode *DataNode) *dataNodeValue {
 		NodeSetID:                dataNode.NodeSetID,
 		Addr:                     dataNode.Addr,
 		ZoneName:                 dataNode.ZoneName,
+		RackName:                 dataNode.RackName,
+		HostName:                 dataNode.HostName,
+		MaintenanceExpire:        dataNode.MaintenanceExpire,
 		RdOnly:                   dataNode.RdOnly,
 		DecommissionedDisks:      dataNode.getDecommissionedDisks(),
 		DecommissionStatus:       atomic.LoadUint32(&dataNode.DecommissionStatus),
@@ -414,20 +454,26 @@ func newDataNodeValue(dataNode *DataNode) *dataNodeValue {
 }
 
 type metaNodeValue struct {
-	ID        uint64
-	NodeSetID uint64
-	Addr      string
-	ZoneName  string
-	RdOnly    bool
+	ID                uint64
+	NodeSetID         uint64
+	Addr              string
+	ZoneName          string
+	RackName          string
+	HostName          string
+	MaintenanceExpire int64
+	RdOnly            bool
 }
 
 func newMetaNodeValue(metaNode *MetaNode) *metaNodeValue {
 	return &metaNodeValue{
-		ID:        metaNode.ID,
-		NodeSetID: metaNode.NodeSetID,
-		Addr:      metaNode.Addr,
-		ZoneName:  metaNode.ZoneName,
-		RdOnly:    metaNode.RdOnly,
+		ID:                metaNode.ID,
+		NodeSetID:         metaNode.NodeSetID,
+		Addr:              metaNode.Addr,
+		ZoneName:          metaNode.ZoneName,
+		RackName:          metaNode.RackName,
+		HostName:          metaNode.HostName,
+		MaintenanceExpire: metaNode.MaintenanceExpire,
+		RdOnly:            metaNode.RdOnly,
 	}
 }
 
@@ -1157,9 +1203,12 @@ func (c *Cluster) loadClusterValue() (err error) {
 		c.updateInodeIdStep(cv.MetaPartitionInodeIdStep)
 
 		c.updateMaxConcurrentLcNodes(cv.MaxConcurrentLcNodes)
+		atomic.StoreUint64(&c.cfg.MetaPartitionSplitInodeCount, cv.MpSplitInodeCount)
+		atomic.StoreUint64(&c.cfg.MetaPartitionMergeInodeCount, cv.MpMergeInodeCount)
 		log.LogInfof("action[loadClusterValue], metaNodeThreshold[%v]", cv.Threshold)
 
 		c.checkDataReplicasEnable = cv.CheckDataReplicasEnable
+		c.rebalanceMgr.loadConfig(cv.Rebalance)
 	}
 	return
 }
@@ -1373,6 +1422,9 @@ func (c *Cluster) loadDataNodes() (err error) {
 		dataNode.DpCntLimit = newDpCountLimiter(&c.cfg.MaxDpCntLimit)
 		dataNode.ID = dnv.ID
 		dataNode.NodeSetID = dnv.NodeSetID
+		dataNode.RackName = dnv.RackName
+		dataNode.HostName = dnv.HostName
+		dataNode.MaintenanceExpire = dnv.MaintenanceExpire
 		dataNode.RdOnly = dnv.RdOnly
 		for _, disk := range dnv.DecommissionedDisks {
 			dataNode.addDecommissionedDisk(disk)
@@ -1423,6 +1475,9 @@ func (c *Cluster) loadMetaNodes() (err error) {
 		metaNode := newMetaNode(mnv.Addr, mnv.ZoneName, c.Name)
 		metaNode.ID = mnv.ID
 		metaNode.NodeSetID = mnv.NodeSetID
+		metaNode.RackName = mnv.RackName
+		metaNode.HostName = mnv.HostName
+		metaNode.MaintenanceExpire = mnv.MaintenanceExpire
 		metaNode.RdOnly = mnv.RdOnly
 
 		oldmn, ok := c.metaNodes.Load(metaNode.Addr)
@@ -1509,7 +1564,7 @@ func (c *Cluster) loadMetaPartitions() (err error) {
 			err = fmt.Errorf("action[loadMetaPartitions],value:%v,unmarshal err:%v", string(value), err)
 			return err
 		}
-		vol, err1 := c.getVol(mpv.VolName)
+		vol, err1 := c.getPartitionVol(mpv.VolName, mpv.VolID)
 		if err1 != nil {
 			log.LogErrorf("action[loadMetaPartitions] err:%v", err1.Error())
 			continue
@@ -1557,7 +1612,7 @@ func (c *Cluster) loadDataPartitions() (err error) {
 			err = fmt.Errorf("action[loadDataPartitions],value:%v,unmarshal err:%v", string(value), err)
 			return err
 		}
-		vol, err1 := c.getVol(dpv.VolName)
+		vol, err1 := c.getPartitionVol(dpv.VolName, dpv.VolID)
 		if err1 != nil {
 			log.LogErrorf("action[loadDataPartitions] err:%v %v", dpv.VolName, err1.Error())
 			continue
@@ -1568,6 +1623,7 @@ func (c *Cluster) loadDataPartitions() (err error) {
 		}
 
 		dp := dpv.Restore(c)
+		dp.VolName = vol.Name
 		vol.dataPartitions.put(dp)
 		c.addBadDataPartitionIdMap(dp)
 		// add to nodeset decommission list
@@ -1585,6 +1641,10 @@ func (c *Cluster) loadQuota() (err error) {
 			log.LogErrorf("loadQuota loadQuotaManager vol [%v] fail err [%v]", name, err.Error())
 			return err
 		}
+		if err = vol.loadUserQuotaManager(c); err != nil {
+			log.LogErrorf("loadQuota loadUserQuotaManager vol [%v] fail err [%v]", name, err.Error())
+			return err
+		}
 	}
 	return
 }
@@ -1823,3 +1883,45 @@ func (c *Cluster) loadLcConfs() (err error) {
 	}
 	return
 }
+
+// key=#audit#id,value=json.Marshal(record), id is zero padded to keep the records ordered by time
+func adminAuditKey(id uint64) string {
+	return fmt.Sprintf("%s%020d", adminAuditPrefix, id)
+}
+
+func (c *Cluster) syncAddAdminAudit(record *bsProto.AdminAuditRecord) (err error) {
+	metadata := new(RaftCmd)
+	metadata.Op = opSyncAddAdminAudit
+	metadata.K = adminAuditKey(record.ID)
+	metadata.V, err = json.Marshal(record)
+	if err != nil {
+		return errors.New(err.Error())
+	}
+	return c.submit(metadata)
+}
+
+func (c *Cluster) syncDeleteAdminAudits(keys []string) (err error) {
+	cmdMap := make(map[string]*RaftCmd, len(keys))
+	for _, key := range keys {
+		cmdMap[key] = &RaftCmd{Op: opSyncDeleteAdminAudit, K: key}
+	}
+	return c.syncBatchCommitCmd(cmdMap)
+}
+
+func (c *Cluster) loadAdminAudits() (records []*bsProto.AdminAuditRecord, err error) {
+	result, err := c.fsm.store.SeekForPrefix([]byte(adminAuditPrefix))
+	if err != nil {
+		err = fmt.Errorf("action[loadAdminAudits],err:%v", err.Error())
+		return
+	}
+	records = make([]*bsProto.AdminAuditRecord, 0, len(result))
+	for _, value := range result {
+		record := &bsProto.AdminAuditRecord{}
+		if err = json.Unmarshal(value, record); err != nil {
+			err = fmt.Errorf("action[loadAdminAudits],value:%v,unmarshal err:%v", string(value), err)
+			return
+		}
+		records = append(records, record)
+	}
+	return
+}
@@ -142,9 +142,15 @@ func (mds *MockDataServer) serveConn(rc net.Conn) {
 	case proto.OpRemoveDataPartitionRaftMember:
 		err = mds.handleRemoveDataPartitionRaftMember(conn, req, adminTask)
 		Printf("data node [%v] remove data partition raft member,id[%v],err:%v\n", mds.TcpAddr, adminTask.ID, err)
+	case proto.OpPromoteDataPartitionLearner:
+		err = mds.handlePromoteDataPartitionLearner(conn, req, adminTask)
+		Printf("data node [%v] promote data partition learner,id[%v],err:%v\n", mds.TcpAddr, adminTask.ID, err)
 	case proto.OpDataPartitionTryToLeader:
 		err = mds.handleTryToLeader(conn, req, adminTask)
 		Printf("data node [%v] try to leader,id[%v],err:%v\n", mds.TcpAddr, adminTask.ID, err)
+	case proto.OpUpdateDataPartitionVolName:
+		err = mds.handleUpdateVolName(conn, req, adminTask)
+		Printf("data node [%v] update vol name,id[%v],err:%v\n", mds.TcpAddr, adminTask.ID, err)
 	default:
 		fmt.Printf("unknown code [%v]\n", req.Opcode)
 	}
@@ -160,6 +166,37 @@ func (mds *MockDataServer) handleRemoveDataPartitionRaftMember(conn net.Conn, p
 	return
 }
 
+func (mds *MockDataServer) handlePromoteDataPartitionLearner(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
+	responseAckOKToMaster(conn, p, nil)
+	return
+}
+
+func (mds *MockDataServer) handleUpdateVolName(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
+	defer func() {
+		if err != nil {
+			responseAckErrToMaster(conn, p, err)
+		} else {
+			responseAckOKToMaster(conn, p, nil)
+		}
+	}()
+	requestJson, err := json.Marshal(adminTask.Request)
+	if err != nil {
+		return
+	}
+	req := &proto.UpdateDataPartitionVolNameRequest{}
+	if err = json.Unmarshal(requestJson, req); err != nil {
+		return
+	}
+	mds.Lock()
+	defer mds.Unlock()
+	for _, dp := range mds.partitions {
+		if dp.PartitionID == req.PartitionId {
+			dp.VolName = req.VolName
+		}
+	}
+	return
+}
+
 func (mds *MockDataServer) handleTryToLeader(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
 	responseAckOKToMaster(conn, p, nil)
 	return
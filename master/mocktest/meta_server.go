@@ -132,9 +132,21 @@ func (mms *MockMetaServer) serveConn(rc net.Conn) {
 	case proto.OpRemoveMetaPartitionRaftMember:
 		err = mms.handleRemoveMetaPartitionRaftMember(conn, req, adminTask)
 		Printf("meta node [%v] remove data partition raft member,id[%v],err:%v\n", mms.TcpAddr, adminTask.ID, err)
+	case proto.OpPromoteMetaPartitionLearner:
+		err = mms.handlePromoteMetaPartitionLearner(conn, req, adminTask)
+		Printf("meta node [%v] promote meta partition learner,id[%v],err:%v\n", mms.TcpAddr, adminTask.ID, err)
 	case proto.OpMetaPartitionTryToLeader:
 		err = mms.handleTryToLeader(conn, req, adminTask)
 		Printf("meta node [%v] try to leader,id[%v],err:%v\n", mms.TcpAddr, adminTask.ID, err)
+	case proto.OpSplitMetaPartition:
+		err = mms.handleSplitMetaPartition(conn, req, adminTask)
+		Printf("meta node [%v] split meta partition,id[%v],err:%v\n", mms.TcpAddr, adminTask.ID, err)
+	case proto.OpFreezeMetaPartition:
+		err = mms.handleFreezeMetaPartition(conn, req, adminTask)
+		Printf("meta node [%v] freeze meta partition,id[%v],err:%v\n", mms.TcpAddr, adminTask.ID, err)
+	case proto.OpMergeMetaPartition:
+		err = mms.handleMergeMetaPartition(conn, req, adminTask)
+		Printf("meta node [%v] merge meta partition,id[%v],err:%v\n", mms.TcpAddr, adminTask.ID, err)
 	default:
 		fmt.Printf("unknown code [%v]\n", req.Opcode)
 	}
@@ -145,6 +157,11 @@ func (mms *MockMetaServer) handleAddMetaPartitionRaftMember(conn net.Conn, p *pr
 	return
 }
 
+func (mms *MockMetaServer) handlePromoteMetaPartitionLearner(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
+	responseAckOKToMaster(conn, p, nil)
+	return
+}
+
 func (mms *MockMetaServer) handleRemoveMetaPartitionRaftMember(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
 	responseAckOKToMaster(conn, p, nil)
 	return
@@ -321,6 +338,101 @@ func (mms *MockMetaServer) handleDeleteMetaPartition(conn net.Conn, p *proto.Pac
 	return mms.postResponseToMaster(adminTask, resp)
 }
 
+func (mms *MockMetaServer) handleSplitMetaPartition(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
+	req := &proto.SplitMetaPartitionRequest{}
+	if err = decodeAdminTaskRequest(adminTask, req); err != nil {
+		responseAckErrToMaster(conn, p, err)
+		return
+	}
+	mms.Lock()
+	partition, ok := mms.partitions[req.PartitionID]
+	if !ok {
+		mms.Unlock()
+		err = fmt.Errorf("meta partition %v not exists", req.PartitionID)
+		responseAckErrToMaster(conn, p, err)
+		return
+	}
+	key := req.SplitKey
+	if key == 0 {
+		key = partition.Start + (partition.Cursor-partition.Start)/2 + 1
+	}
+	child := &MockMetaPartition{
+		PartitionID: req.NewPartitionID,
+		VolName:     partition.VolName,
+		Start:       key,
+		End:         partition.End,
+		Cursor:      key,
+		Members:     partition.Members,
+		Replicas:    partition.Replicas,
+	}
+	mms.partitions[child.PartitionID] = child
+	partition.End = key - 1
+	mms.Unlock()
+	resp := &proto.SplitMetaPartitionResponse{
+		PartitionID:    req.PartitionID,
+		NewPartitionID: req.NewPartitionID,
+		SplitKey:       key,
+		End:            key - 1,
+		Status:         proto.TaskSucceeds,
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		responseAckErrToMaster(conn, p, err)
+		return
+	}
+	return responseAckOKToMaster(conn, p, data)
+}
+
+func (mms *MockMetaServer) handleFreezeMetaPartition(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
+	req := &proto.FreezeMetaPartitionRequest{}
+	if err = decodeAdminTaskRequest(adminTask, req); err != nil {
+		responseAckErrToMaster(conn, p, err)
+		return
+	}
+	resp := &proto.FreezeMetaPartitionResponse{PartitionID: req.PartitionID, Status: proto.TaskSucceeds}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		responseAckErrToMaster(conn, p, err)
+		return
+	}
+	return responseAckOKToMaster(conn, p, data)
+}
+
+func (mms *MockMetaServer) handleMergeMetaPartition(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
+	req := &proto.MergeMetaPartitionRequest{}
+	if err = decodeAdminTaskRequest(adminTask, req); err != nil {
+		responseAckErrToMaster(conn, p, err)
+		return
+	}
+	mms.Lock()
+	partition, ok := mms.partitions[req.PartitionID]
+	source, srcOk := mms.partitions[req.SourceID]
+	if !ok || !srcOk {
+		mms.Unlock()
+		err = fmt.Errorf("meta partition %v or %v not exists", req.PartitionID, req.SourceID)
+		responseAckErrToMaster(conn, p, err)
+		return
+	}
+	partition.End = source.End
+	delete(mms.partitions, req.SourceID)
+	mms.Unlock()
+	resp := &proto.MergeMetaPartitionResponse{PartitionID: req.PartitionID, End: source.End, Status: proto.TaskSucceeds}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		responseAckErrToMaster(conn, p, err)
+		return
+	}
+	return responseAckOKToMaster(conn, p, data)
+}
+
+func decodeAdminTaskRequest(adminTask *proto.AdminTask, req interface{}) (err error) {
+	reqData, err := json.Marshal(adminTask.Request)
+	if err != nil {
+		return
+	}
+	return json.Unmarshal(reqData, req)
+}
+
 func (mms *MockMetaServer) handleUpdateMetaPartition(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
 	responseAckOKToMaster(conn, p, nil)
 	req := &proto.UpdateMetaPartitionRequest{}
@@ -88,6 +88,7 @@ const (
 	MetricLcTotalFileScanned         = "lc_total_file_scanned"
 	MetricLcTotalDirScanned          = "lc_total_dirs_scanned"
 	MetricLcTotalExpired             = "lc_total_expired"
+	MetricLcTotalTransitioned        = "lc_total_transitioned"
 )
 
 var WarnMetrics *warningMetrics
@@ -149,6 +150,7 @@ type monitorMetrics struct {
 	lcTotalFileScanned *exporter.GaugeVec
 	lcTotalDirScanned  *exporter.GaugeVec
 	lcTotalExpired     *exporter.GaugeVec
+	lcTotalTransition  *exporter.GaugeVec
 }
 
 func newMonitorMetrics(c *Cluster) *monitorMetrics {
@@ -477,6 +479,7 @@ func (mm *monitorMetrics) start() {
 	mm.lcTotalFileScanned = exporter.NewGaugeVec(MetricLcTotalFileScanned, "", []string{"volName", "type"})
 	mm.lcTotalDirScanned = exporter.NewGaugeVec(MetricLcTotalDirScanned, "", []string{"volName", "type"})
 	mm.lcTotalExpired = exporter.NewGaugeVec(MetricLcTotalExpired, "", []string{"volName", "type"})
+	mm.lcTotalTransition = exporter.NewGaugeVec(MetricLcTotalTransitioned, "", []string{"volName", "type"})
 	go mm.statMetrics()
 }
 
@@ -902,6 +905,7 @@ func (mm *monitorMetrics) deleteS3LcVolMetric(volName string) {
 	mm.lcTotalFileScanned.DeleteLabelValues(volName, "file")
 	mm.lcTotalDirScanned.DeleteLabelValues(volName, "dir")
 	mm.lcTotalExpired.DeleteLabelValues(volName, "expired")
+	mm.lcTotalTransition.DeleteLabelValues(volName, "transitioned")
 }
 
 func (mm *monitorMetrics) setLcMetrics() {
@@ -923,6 +927,7 @@ func (mm *monitorMetrics) setLcMetrics() {
 		mm.lcTotalFileScanned.SetWithLabelValues(float64(stat.FileScannedNum), key, "file")
 		mm.lcTotalDirScanned.SetWithLabelValues(float64(stat.DirScannedNum), key, "dir")
 		mm.lcTotalExpired.SetWithLabelValues(float64(stat.ExpiredNum), key, "expired")
+		mm.lcTotalTransition.SetWithLabelValues(float64(stat.TransitionNum), key, "transitioned")
 	}
 }
 
@@ -39,6 +39,7 @@ type VolVersionPersist struct {
 	MultiVersionList []*proto.VolVersionInfo
 	Strategy         proto.VolumeVerStrategy
 	VerSeq           uint64
+	DirSnapshots     []*proto.DirSnapshotInfo `json:",omitempty"`
 }
 
 type VolVersionManager struct {
@@ -56,6 +57,9 @@ type VolVersionManager struct {
 	checkStatus      int32
 	c                *Cluster
 	enableMiddleOp   bool
+	// named versions scoped to directories, exposed by the clients as <dir>/.snapshot/<name>,
+	// a version is deleted with the last snapshot named it
+	dirSnapshots []*proto.DirSnapshotInfo
 	sync.RWMutex
 }
 
@@ -82,6 +86,7 @@ func (verMgr *VolVersionManager) Persist() (err error) {
 		MultiVersionList: verMgr.multiVersionList,
 		Strategy:         verMgr.strategy,
 		VerSeq:           verMgr.verSeq,
+		DirSnapshots:     verMgr.dirSnapshots,
 	}
 	var val []byte
 	if val, err = json.Marshal(persistInfo); err != nil {
@@ -105,6 +110,7 @@ func (verMgr *VolVersionManager) loadMultiVersion(c *Cluster, val []byte) (err e
 	verMgr.multiVersionList = persistInfo.MultiVersionList
 	verMgr.verSeq = persistInfo.VerSeq
 	verMgr.strategy = persistInfo.Strategy
+	verMgr.dirSnapshots = persistInfo.DirSnapshots
 	return nil
 }
 
@@ -269,6 +275,18 @@ func (verMgr *VolVersionManager) checkDeleteStrategy(c *Cluster) {
 			verMgr.RUnlock()
 			return
 		}
+		if verMgr.isDirSnapshotVer(verMgr.multiVersionList[0].Ver) {
+			log.LogDebugf("checkSnapshotStrategy.vol %v oldest ver %v is kept by directory snapshot",
+				verMgr.vol.Name, verMgr.multiVersionList[0].Ver)
+			verMgr.RUnlock()
+			return
+		}
+		if c.isClonedVer(verMgr.vol.Name, verMgr.multiVersionList[0].Ver) {
+			log.LogDebugf("checkSnapshotStrategy.vol %v oldest ver %v is shared by clones",
+				verMgr.vol.Name, verMgr.multiVersionList[0].Ver)
+			verMgr.RUnlock()
+			return
+		}
 		verMgr.RUnlock()
 		if _, err := verMgr.createVer2PhaseTask(c, verMgr.multiVersionList[0].Ver, proto.DeleteVersion, verMgr.strategy.ForceUpdate); err != nil {
 			return
@@ -48,9 +48,11 @@ func (ns *nodeSet) getNodes(nodeType NodeType) *sync.Map {
 	}
 }
 
+// NodeSelector selects the nodes of the nodeset to place the replicas of a partition. The datanodes
+// are only selected if they have enough space on the disks of mediaType, which is ignored by the metanodes.
 type NodeSelector interface {
 	GetName() string
-	Select(ns *nodeSet, excludeHosts []string, replicaNum int) (newHosts []string, peers []proto.Peer, err error)
+	Select(ns *nodeSet, excludeHosts []string, replicaNum int, mediaType uint32) (newHosts []string, peers []proto.Peer, err error)
 }
 
 type weightedNode struct {
@@ -65,6 +67,8 @@ type Node interface {
 	SelectNodeForWrite()
 	GetID() uint64
 	GetAddr() string
+	// GetFailureDomain returns the rack or host of the node, no two replicas are placed in the same one.
+	GetFailureDomain() string
 }
 
 // SortedWeightedNodes defines an array sorted by carry
@@ -82,11 +86,11 @@ func (nodes SortedWeightedNodes) Swap(i, j int) {
 	nodes[i], nodes[j] = nodes[j], nodes[i]
 }
 
-func canAllocPartition(node interface{}, nodeType NodeType) bool {
+func canAllocPartition(node interface{}, nodeType NodeType, mediaType uint32) bool {
 	switch nodeType {
 	case DataNodeType:
 		dataNode := node.(*DataNode)
-		return dataNode.canAlloc() && dataNode.canAllocDp()
+		return dataNode.canAlloc() && dataNode.canAllocDpOnMedia(mediaType)
 	case MetaNodeType:
 		metaNode := node.(*MetaNode)
 		return metaNode.isWritable()
@@ -183,7 +187,7 @@ func (s *CarryWeightNodeSelector) getTotalMax(nodes *sync.Map) (total uint64) {
 	return
 }
 
-func (s *CarryWeightNodeSelector) getCarryDataNodes(maxTotal uint64, excludeHosts []string, dataNodes *sync.Map) (nodeTabs SortedWeightedNodes, availCount int) {
+func (s *CarryWeightNodeSelector) getCarryDataNodes(maxTotal uint64, excludeHosts []string, dataNodes *sync.Map, mediaType uint32) (nodeTabs SortedWeightedNodes, availCount int) {
 	nodeTabs = make(SortedWeightedNodes, 0)
 	dataNodes.Range(func(key, value interface{}) bool {
 		dataNode := value.(*DataNode)
@@ -191,9 +195,9 @@ func (s *CarryWeightNodeSelector) getCarryDataNodes(maxTotal uint64, excludeHost
 			// log.LogDebugf("[getAvailCarryDataNodeTab] dataNode [%v] is excludeHosts", dataNode.Addr)
 			return true
 		}
-		if !dataNode.canAllocDp() {
-			log.LogDebugf("[getAvailCarryDataNodeTab] dataNode [%v] is not writeable, offline %v, dpCnt %d",
-				dataNode.Addr, dataNode.ToBeOffline, dataNode.DataPartitionCount)
+		if !dataNode.canAllocDpOnMedia(mediaType) {
+			log.LogDebugf("[getAvailCarryDataNodeTab] dataNode [%v] is not writeable, offline %v, dpCnt %d, mediaType %v",
+				dataNode.Addr, dataNode.ToBeOffline, dataNode.DataPartitionCount, proto.MediaTypeString(mediaType))
 			return true
 		}
 
@@ -207,7 +211,7 @@ func (s *CarryWeightNodeSelector) getCarryDataNodes(maxTotal uint64, excludeHost
 
 		nt := new(weightedNode)
 		nt.Carry = s.carry[dataNode.ID]
-		nt.Weight = float64(dataNode.AvailableSpace) / float64(maxTotal)
+		nt.Weight = float64(dataNode.getMediaAvailableSpace(mediaType)) / float64(maxTotal)
 		nt.Ptr = dataNode
 		nodeTabs = append(nodeTabs, nt)
 		return true
@@ -238,10 +242,10 @@ func (s *CarryWeightNodeSelector) getCarryMetaNodes(maxTotal uint64, excludeHost
 	return
 }
 
-func (s *CarryWeightNodeSelector) getCarryNodes(nset *nodeSet, maxTotal uint64, excludeHosts []string) (SortedWeightedNodes, int) {
+func (s *CarryWeightNodeSelector) getCarryNodes(nset *nodeSet, maxTotal uint64, excludeHosts []string, mediaType uint32) (SortedWeightedNodes, int) {
 	switch s.nodeType {
 	case DataNodeType:
-		return s.getCarryDataNodes(maxTotal, excludeHosts, nset.dataNodes)
+		return s.getCarryDataNodes(maxTotal, excludeHosts, nset.dataNodes, mediaType)
 	case MetaNodeType:
 		return s.getCarryMetaNodes(maxTotal, excludeHosts, nset.metaNodes)
 	default:
@@ -274,7 +278,7 @@ func (s *CarryWeightNodeSelector) selectNodeForWrite(node Node) {
 	s.carry[node.GetID()] -= 1.0
 }
 
-func (s *CarryWeightNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNum int) (newHosts []string, peers []proto.Peer, err error) {
+func (s *CarryWeightNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNum int, mediaType uint32) (newHosts []string, peers []proto.Peer, err error) {
 	nodes := ns.getNodes(s.nodeType)
 	total := s.getTotalMax(nodes)
 	// prepare carry for every nodes
@@ -287,7 +291,7 @@ func (s *CarryWeightNodeSelector) Select(ns *nodeSet, excludeHosts []string, rep
 		return
 	}
 	// if we cannot get enough writable nodes, return error
-	weightedNodes, count := s.getCarryNodes(ns, total, excludeHosts)
+	weightedNodes, count := s.getCarryNodes(ns, total, excludeHosts, mediaType)
 	if len(weightedNodes) < replicaNum {
 		err = fmt.Errorf("action[%vNodeSelector::Select] no enough writable hosts,replicaNum:%v  MatchNodeCount:%v  ",
 			s.GetName(), replicaNum, len(weightedNodes))
@@ -298,14 +302,24 @@ func (s *CarryWeightNodeSelector) Select(ns *nodeSet, excludeHosts []string, rep
 	s.setNodeCarry(weightedNodes, count, replicaNum)
 	// sort nodes by weight
 	sort.Sort(weightedNodes)
-	// pick first N nodes
-	for i := 0; i < replicaNum; i++ {
+	// pick first N nodes in distinct failure domains
+	domains := make(failureDomains)
+	for i := 0; i < len(weightedNodes) && len(orderHosts) < replicaNum; i++ {
 		node := weightedNodes[i].Ptr
+		if domains.taken(node) {
+			continue
+		}
+		domains.take(node)
 		s.selectNodeForWrite(node)
 		orderHosts = append(orderHosts, node.GetAddr())
 		peer := proto.Peer{ID: node.GetID(), Addr: node.GetAddr()}
 		peers = append(peers, peer)
 	}
+	if len(orderHosts) < replicaNum {
+		err = fmt.Errorf("action[%vNodeSelector::Select] no enough failure domains,replicaNum:%v  MatchNodeCount:%v  ",
+			s.GetName(), replicaNum, len(orderHosts))
+		return
+	}
 	log.LogInfof("action[%vNodeSelector::Select] peers[%v]", s.GetName(), peers)
 	// reshuffle for primary-backup replication
 	if newHosts, err = reshuffleHosts(orderHosts); err != nil {
@@ -326,11 +340,11 @@ type AvailableSpaceFirstNodeSelector struct {
 	nodeType NodeType
 }
 
-func (s *AvailableSpaceFirstNodeSelector) getNodeAvailableSpace(node interface{}) uint64 {
+func (s *AvailableSpaceFirstNodeSelector) getNodeAvailableSpace(node interface{}, mediaType uint32) uint64 {
 	switch s.nodeType {
 	case DataNodeType:
 		dataNode := node.(*DataNode)
-		return dataNode.AvailableSpace
+		return dataNode.getMediaAvailableSpace(mediaType)
 	case MetaNodeType:
 		metaNode := node.(*MetaNode)
 		return metaNode.Total - metaNode.Used
@@ -343,7 +357,7 @@ func (s *AvailableSpaceFirstNodeSelector) GetName() string {
 	return AvailableSpaceFirstNodeSelectorName
 }
 
-func (s *AvailableSpaceFirstNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNum int) (newHosts []string, peers []proto.Peer, err error) {
+func (s *AvailableSpaceFirstNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNum int, mediaType uint32) (newHosts []string, peers []proto.Peer, err error) {
 	newHosts = make([]string, 0)
 	peers = make([]proto.Peer, 0)
 	// if replica == 0, return
@@ -365,9 +379,10 @@ func (s *AvailableSpaceFirstNodeSelector) Select(ns *nodeSet, excludeHosts []str
 	}
 	// sort nodes by available space
 	sort.Slice(sortedNodes, func(i, j int) bool {
-		return s.getNodeAvailableSpace(sortedNodes[i]) > s.getNodeAvailableSpace(sortedNodes[j])
+		return s.getNodeAvailableSpace(sortedNodes[i], mediaType) > s.getNodeAvailableSpace(sortedNodes[j], mediaType)
 	})
 	nodeIndex := 0
+	domains := make(failureDomains)
 	// pick first N nodes
 	for i := 0; i < replicaNum && nodeIndex < len(sortedNodes); i++ {
 		selectedIndex := len(sortedNodes)
@@ -375,7 +390,7 @@ func (s *AvailableSpaceFirstNodeSelector) Select(ns *nodeSet, excludeHosts []str
 		for nodeIndex < len(sortedNodes) {
 			node := sortedNodes[nodeIndex]
 			nodeIndex += 1
-			if canAllocPartition(node, s.nodeType) {
+			if canAllocPartition(node, s.nodeType, mediaType) && !domains.taken(node) {
 				if excludeHosts == nil || !contains(excludeHosts, node.GetAddr()) {
 					selectedIndex = nodeIndex - 1
 					break
@@ -385,6 +400,7 @@ func (s *AvailableSpaceFirstNodeSelector) Select(ns *nodeSet, excludeHosts []str
 		// if we get a writable node, append it to host list
 		if selectedIndex != len(sortedNodes) {
 			node := sortedNodes[selectedIndex]
+			domains.take(node)
 			node.SelectNodeForWrite()
 			orderHosts = append(orderHosts, node.GetAddr())
 			peer := proto.Peer{ID: node.GetID(), Addr: node.GetAddr()}
@@ -422,7 +438,7 @@ func (s *RoundRobinNodeSelector) GetName() string {
 	return RoundRobinNodeSelectorName
 }
 
-func (s *RoundRobinNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNum int) (newHosts []string, peers []proto.Peer, err error) {
+func (s *RoundRobinNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNum int, mediaType uint32) (newHosts []string, peers []proto.Peer, err error) {
 	newHosts = make([]string, 0)
 	peers = make([]proto.Peer, 0)
 	// if replica == 0, return
@@ -447,6 +463,7 @@ func (s *RoundRobinNodeSelector) Select(ns *nodeSet, excludeHosts []string, repl
 		return sortedNodes[i].GetID() < sortedNodes[j].GetID()
 	})
 	nodeIndex := 0
+	domains := make(failureDomains)
 	// pick first N nodes
 	for i := 0; i < replicaNum && nodeIndex < len(sortedNodes); i++ {
 		selectedIndex := len(sortedNodes)
@@ -454,7 +471,7 @@ func (s *RoundRobinNodeSelector) Select(ns *nodeSet, excludeHosts []string, repl
 		for nodeIndex < len(sortedNodes) {
 			node := sortedNodes[(nodeIndex+s.index)%len(sortedNodes)]
 			nodeIndex += 1
-			if canAllocPartition(node, s.nodeType) {
+			if canAllocPartition(node, s.nodeType, mediaType) && !domains.taken(node) {
 				if excludeHosts == nil || !contains(excludeHosts, node.GetAddr()) {
 					selectedIndex = nodeIndex - 1
 					break
@@ -464,6 +481,7 @@ func (s *RoundRobinNodeSelector) Select(ns *nodeSet, excludeHosts []string, repl
 		// if we get a writable node, append it to host list
 		if selectedIndex != len(sortedNodes) {
 			node := sortedNodes[(selectedIndex+s.index)%len(sortedNodes)]
+			domains.take(node)
 			orderHosts = append(orderHosts, node.GetAddr())
 			node.SelectNodeForWrite()
 			peer := proto.Peer{ID: node.GetID(), Addr: node.GetAddr()}
@@ -507,11 +525,11 @@ func (s *StrawNodeSelector) GetName() string {
 	return StrawNodeSelectorName
 }
 
-func (s *StrawNodeSelector) getWeight(node Node) float64 {
+func (s *StrawNodeSelector) getWeight(node Node, mediaType uint32) float64 {
 	switch s.nodeType {
 	case DataNodeType:
 		dataNode := node.(*DataNode)
-		return float64(dataNode.AvailableSpace) / util.GB
+		return float64(dataNode.getMediaAvailableSpace(mediaType)) / util.GB
 	case MetaNodeType:
 		metaNode := node.(*MetaNode)
 		return float64(metaNode.Total-metaNode.Used) / util.GB
@@ -520,12 +538,12 @@ func (s *StrawNodeSelector) getWeight(node Node) float64 {
 	}
 }
 
-func (s *StrawNodeSelector) selectOneNode(nodes []Node) (index int, maxNode Node) {
+func (s *StrawNodeSelector) selectOneNode(nodes []Node, mediaType uint32) (index int, maxNode Node) {
 	maxStraw := float64(0)
 	index = -1
 	for i, node := range nodes {
 		straw := float64(s.rand.Intn(StrawNodeSelectorRandMax))
-		straw = math.Log(straw/float64(StrawNodeSelectorRandMax)) / s.getWeight(node)
+		straw = math.Log(straw/float64(StrawNodeSelectorRandMax)) / s.getWeight(node, mediaType)
 		if index == -1 || straw > maxStraw {
 			maxStraw = straw
 			maxNode = node
@@ -535,7 +553,7 @@ func (s *StrawNodeSelector) selectOneNode(nodes []Node) (index int, maxNode Node
 	return
 }
 
-func (s *StrawNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNum int) (newHosts []string, peers []proto.Peer, err error) {
+func (s *StrawNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNum int, mediaType uint32) (newHosts []string, peers []proto.Peer, err error) {
 	nodes := make([]Node, 0)
 	ns.getNodes(s.nodeType).Range(func(key, value interface{}) bool {
 		node := asNodeWrap(value, s.nodeType)
@@ -545,18 +563,20 @@ func (s *StrawNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNu
 		return true
 	})
 	orderHosts := make([]string, 0)
+	domains := make(failureDomains)
 	for len(orderHosts) < replicaNum {
 		if len(nodes)+len(orderHosts) < replicaNum {
 			break
 		}
-		index, node := s.selectOneNode(nodes)
+		index, node := s.selectOneNode(nodes, mediaType)
 		if index != 0 {
 			nodes[0], nodes[index] = node, nodes[0]
 		}
 		nodes = nodes[1:]
-		if !canAllocPartition(node, s.nodeType) {
+		if !canAllocPartition(node, s.nodeType, mediaType) || domains.taken(node) {
 			continue
 		}
+		domains.take(node)
 		orderHosts = append(orderHosts, node.GetAddr())
 		node.SelectNodeForWrite()
 		peer := proto.Peer{ID: node.GetID(), Addr: node.GetAddr()}
@@ -605,14 +625,14 @@ func (ns *nodeSet) getAvailMetaNodeHosts(excludeHosts []string, replicaNum int)
 	// we need a read lock to block the modify of node selector
 	ns.metaNodeSelectorLock.RLock()
 	defer ns.metaNodeSelectorLock.RUnlock()
-	return ns.metaNodeSelector.Select(ns, excludeHosts, replicaNum)
+	return ns.metaNodeSelector.Select(ns, excludeHosts, replicaNum, proto.MediaTypeUnspecified)
 }
 
-func (ns *nodeSet) getAvailDataNodeHosts(excludeHosts []string, replicaNum int) (hosts []string, peers []proto.Peer, err error) {
+func (ns *nodeSet) getAvailDataNodeHosts(excludeHosts []string, replicaNum int, mediaType uint32) (hosts []string, peers []proto.Peer, err error) {
 	ns.nodeSelectLock.Lock()
 	defer ns.nodeSelectLock.Unlock()
 	// we need a read lock to block the modify of node selector
 	ns.dataNodeSelectorLock.Lock()
 	defer ns.dataNodeSelectorLock.Unlock()
-	return ns.dataNodeSelector.Select(ns, excludeHosts, replicaNum)
+	return ns.dataNodeSelector.Select(ns, excludeHosts, replicaNum, mediaType)
 }
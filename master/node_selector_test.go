@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/cubefs/cubefs/master/mocktest"
+	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util"
 )
 
@@ -161,7 +162,7 @@ func DataNodeSelectorTest(t *testing.T, selector NodeSelector, expectedNode *Dat
 	nset := nsc[0]
 	mocktest.Log(t, "List datanodes of nodeset", nset.ID)
 	printNodesetAndDataNodes(t, nset)
-	_, peer, err := selector.Select(nset, nil, 1)
+	_, peer, err := selector.Select(nset, nil, 1, proto.MediaTypeUnspecified)
 	if err != nil {
 		t.Errorf("%v failed to select nodes %v", selector.GetName(), err)
 		return nil
@@ -202,7 +203,7 @@ func MetaNodeSelectorTest(t *testing.T, selector NodeSelector, expectedNode *Met
 	nset := nsc[0]
 	mocktest.Log(t, "List metanodes of nodeset", nset.ID)
 	printNodesetAndMetaNodes(t, nset)
-	_, peer, err := selector.Select(nset, nil, 1)
+	_, peer, err := selector.Select(nset, nil, 1, proto.MediaTypeUnspecified)
 	if err != nil {
 		t.Errorf("%v failed to select nodes %v", selector.GetName(), err)
 		return nil
@@ -475,7 +476,7 @@ func prepareMetaNodesForBench(count int, initTotal uint64, grow uint64) (ns *nod
 func nodeSelectorBench(selector NodeSelector, nset *nodeSet, onSelect func(addr string)) (map[uint64]int, error) {
 	times := make(map[uint64]int)
 	for i := 0; i < loopNodeSelectorTestCount; i++ {
-		_, peers, err := selector.Select(nset, nil, 1)
+		_, peers, err := selector.Select(nset, nil, 1, proto.MediaTypeUnspecified)
 		if err != nil {
 			return nil, err
 		}
@@ -546,3 +547,76 @@ func TestBenchStrawNodeSelector(t *testing.T) {
 	selector = NewStrawNodeSelector(MetaNodeType)
 	metaNodeSelectorBench(t, selector)
 }
+
+func TestMediaTypeNodeSelector(t *testing.T) {
+	nset := prepareDataNodesForBench(4, 100*util.GB, 0)
+	nset.dataNodes.Range(func(key, value interface{}) bool {
+		node := value.(*DataNode)
+		mediaType := uint32(proto.MediaTypeHDD)
+		if node.ID%2 == 0 {
+			mediaType = proto.MediaTypeSSD
+		}
+		node.DiskStats = []proto.DiskStat{
+			{Status: proto.ReadWrite, DiskPath: "/cfs", Available: 100 * util.GB, MediaType: mediaType},
+		}
+		return true
+	})
+	selectors := []NodeSelector{
+		NewCarryWeightNodeSelector(DataNodeType),
+		NewRoundRobinNodeSelector(DataNodeType),
+		NewAvailableSpaceFirstNodeSelector(DataNodeType),
+		NewStrawNodeSelector(DataNodeType),
+	}
+	for _, selector := range selectors {
+		for i := 0; i < 10; i++ {
+			_, peers, err := selector.Select(nset, nil, 2, proto.MediaTypeSSD)
+			if err != nil {
+				t.Errorf("%v failed to select ssd nodes %v", selector.GetName(), err)
+				return
+			}
+			for _, peer := range peers {
+				if peer.ID%2 != 0 {
+					t.Errorf("%v selected node %v without ssd disks", selector.GetName(), peer.ID)
+					return
+				}
+			}
+		}
+		if _, _, err := selector.Select(nset, nil, 3, proto.MediaTypeSSD); err == nil {
+			t.Errorf("%v selected 3 nodes with only 2 ssd nodes", selector.GetName())
+			return
+		}
+	}
+}
+
+func TestFailureDomainNodeSelector(t *testing.T) {
+	nset := prepareDataNodesForBench(4, 100*util.GB, 0)
+	nset.dataNodes.Range(func(key, value interface{}) bool {
+		node := value.(*DataNode)
+		node.ZoneName = testZone1
+		node.RackName = fmt.Sprintf("rack-%v", node.ID%2)
+		return true
+	})
+	selectors := []NodeSelector{
+		NewCarryWeightNodeSelector(DataNodeType),
+		NewRoundRobinNodeSelector(DataNodeType),
+		NewAvailableSpaceFirstNodeSelector(DataNodeType),
+		NewStrawNodeSelector(DataNodeType),
+	}
+	for _, selector := range selectors {
+		for i := 0; i < 10; i++ {
+			_, peers, err := selector.Select(nset, nil, 2, proto.MediaTypeUnspecified)
+			if err != nil {
+				t.Errorf("%v failed to select nodes in 2 racks %v", selector.GetName(), err)
+				return
+			}
+			if peers[0].ID%2 == peers[1].ID%2 {
+				t.Errorf("%v selected nodes %v and %v in the same rack", selector.GetName(), peers[0].ID, peers[1].ID)
+				return
+			}
+		}
+		if _, _, err := selector.Select(nset, nil, 3, proto.MediaTypeUnspecified); err == nil {
+			t.Errorf("%v selected 3 nodes in only 2 racks", selector.GetName())
+			return
+		}
+	}
+}
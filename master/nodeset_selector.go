@@ -53,11 +53,11 @@ func (ns *nodeSet) getMetaNodeTotalSpace() (toalSpace uint64) {
 	return
 }
 
-func (ns *nodeSet) getDataNodeTotalAvailableSpace() (space uint64) {
+func (ns *nodeSet) getDataNodeTotalAvailableSpace(mediaType uint32) (space uint64) {
 	ns.dataNodes.Range(func(key, value interface{}) bool {
 		dataNode := value.(*DataNode)
 		if !dataNode.ToBeOffline {
-			space += dataNode.AvailableSpace
+			space += dataNode.getMediaAvailableSpace(mediaType)
 		}
 		return true
 	})
@@ -75,10 +75,10 @@ func (ns *nodeSet) getMetaNodeTotalAvailableSpace() (space uint64) {
 	return
 }
 
-func (ns *nodeSet) canWriteFor(nodeType NodeType, replica int) bool {
+func (ns *nodeSet) canWriteFor(nodeType NodeType, replica int, mediaType uint32) bool {
 	switch nodeType {
 	case DataNodeType:
-		return ns.canWriteForDataNode(replica)
+		return ns.canWriteForDataNode(replica, mediaType)
 	case MetaNodeType:
 		return ns.canWriteForMetaNode(replica)
 	default:
@@ -97,10 +97,10 @@ func (ns *nodeSet) getTotalSpaceOf(nodeType NodeType) uint64 {
 	}
 }
 
-func (ns *nodeSet) getTotalAvailableSpaceOf(nodeType NodeType) uint64 {
+func (ns *nodeSet) getTotalAvailableSpaceOf(nodeType NodeType, mediaType uint32) uint64 {
 	switch nodeType {
 	case DataNodeType:
-		return ns.getDataNodeTotalAvailableSpace()
+		return ns.getDataNodeTotalAvailableSpace(mediaType)
 	case MetaNodeType:
 		return ns.getMetaNodeTotalAvailableSpace()
 	default:
@@ -108,9 +108,11 @@ func (ns *nodeSet) getTotalAvailableSpaceOf(nodeType NodeType) uint64 {
 	}
 }
 
+// NodesetSelector selects a nodeset with enough writable nodes for a partition, the datanodes
+// are only counted if they have enough space on the disks of mediaType.
 type NodesetSelector interface {
 	GetName() string
-	Select(nsc nodeSetCollection, excludeNodeSets []uint64, replicaNum uint8) (ns *nodeSet, err error)
+	Select(nsc nodeSetCollection, excludeNodeSets []uint64, replicaNum uint8, mediaType uint32) (ns *nodeSet, err error)
 }
 
 type RoundRobinNodesetSelector struct {
@@ -119,7 +121,7 @@ type RoundRobinNodesetSelector struct {
 	nodeType NodeType
 }
 
-func (s *RoundRobinNodesetSelector) Select(nsc nodeSetCollection, excludeNodeSets []uint64, replicaNum uint8) (ns *nodeSet, err error) {
+func (s *RoundRobinNodesetSelector) Select(nsc nodeSetCollection, excludeNodeSets []uint64, replicaNum uint8, mediaType uint32) (ns *nodeSet, err error) {
 	// sort nodesets by id, so we can get a node list that is as stable as possible
 	sort.Slice(nsc, func(i, j int) bool {
 		return nsc[i].ID < nsc[j].ID
@@ -136,7 +138,7 @@ func (s *RoundRobinNodesetSelector) Select(nsc nodeSetCollection, excludeNodeSet
 		if containsID(excludeNodeSets, ns.ID) {
 			continue
 		}
-		if ns.canWriteFor(s.nodeType, int(replicaNum)) {
+		if ns.canWriteFor(s.nodeType, int(replicaNum), mediaType) {
 			return
 		}
 	}
@@ -183,21 +185,21 @@ func (s *CarryWeightNodesetSelector) getMaxTotal(nsc nodeSetCollection) uint64 {
 	return total
 }
 
-func (s *CarryWeightNodesetSelector) prepareCarry(nsc nodeSetCollection, total uint64) {
+func (s *CarryWeightNodesetSelector) prepareCarry(nsc nodeSetCollection, total uint64, mediaType uint32) {
 	for _, nodeset := range nsc {
 		id := nodeset.ID
 		if _, ok := s.carrys[id]; !ok {
 			// use total available space to calculate initial weight
-			s.carrys[id] = float64(nodeset.getTotalAvailableSpaceOf(s.nodeType)) / float64(total)
+			s.carrys[id] = float64(nodeset.getTotalAvailableSpaceOf(s.nodeType, mediaType)) / float64(total)
 		}
 	}
 }
 
-func (s *CarryWeightNodesetSelector) getAvailNodesets(nsc nodeSetCollection, excludeNodeSets []uint64, replicaNum uint8) (newNsc nodeSetCollection) {
+func (s *CarryWeightNodesetSelector) getAvailNodesets(nsc nodeSetCollection, excludeNodeSets []uint64, replicaNum uint8, mediaType uint32) (newNsc nodeSetCollection) {
 	newNsc = make(nodeSetCollection, 0, nsc.Len())
 	for i := 0; i < nsc.Len(); i++ {
 		ns := nsc[i]
-		if ns.canWriteFor(s.nodeType, int(replicaNum)) && !containsID(excludeNodeSets, ns.ID) {
+		if ns.canWriteFor(s.nodeType, int(replicaNum), mediaType) && !containsID(excludeNodeSets, ns.ID) {
 			newNsc = append(newNsc, ns)
 		}
 	}
@@ -214,13 +216,13 @@ func (s *CarryWeightNodesetSelector) getCarryCount(nsc nodeSetCollection) (count
 	return
 }
 
-func (s *CarryWeightNodesetSelector) setNodesetCarry(nsc nodeSetCollection, total uint64) int {
+func (s *CarryWeightNodesetSelector) setNodesetCarry(nsc nodeSetCollection, total uint64, mediaType uint32) int {
 	count := s.getCarryCount(nsc)
 	for count < 1 {
 		count = 0
 		for i := 0; i < nsc.Len(); i++ {
 			nset := nsc[i]
-			weight := float64(nset.getTotalAvailableSpaceOf(s.nodeType)) / float64(total)
+			weight := float64(nset.getTotalAvailableSpaceOf(s.nodeType, mediaType)) / float64(total)
 			s.carrys[nset.ID] += weight
 			if s.carrys[nset.ID] >= 1.0 {
 				count += 1
@@ -234,16 +236,16 @@ func (s *CarryWeightNodesetSelector) setNodesetCarry(nsc nodeSetCollection, tota
 	return count
 }
 
-func (s *CarryWeightNodesetSelector) Select(nsc nodeSetCollection, excludeNodeSets []uint64, replicaNum uint8) (ns *nodeSet, err error) {
+func (s *CarryWeightNodesetSelector) Select(nsc nodeSetCollection, excludeNodeSets []uint64, replicaNum uint8, mediaType uint32) (ns *nodeSet, err error) {
 	total := s.getMaxTotal(nsc)
 	// prepare weight of evert nodesets
-	s.prepareCarry(nsc, total)
-	nsc = s.getAvailNodesets(nsc, excludeNodeSets, replicaNum)
+	s.prepareCarry(nsc, total, mediaType)
+	nsc = s.getAvailNodesets(nsc, excludeNodeSets, replicaNum, mediaType)
 	avaliCount := 0
 	if len(nsc) < 1 {
 		goto err
 	}
-	avaliCount = s.setNodesetCarry(nsc, total)
+	avaliCount = s.setNodesetCarry(nsc, total, mediaType)
 	// sort nodesets by weight
 	sort.Slice(nsc, func(i, j int) bool {
 		return s.carrys[nsc[i].ID] > s.carrys[nsc[j].ID]
@@ -251,12 +253,12 @@ func (s *CarryWeightNodesetSelector) Select(nsc nodeSetCollection, excludeNodeSe
 	// pick the first nodeset than has N writable node
 	for i := 0; i < avaliCount; i++ {
 		ns = nsc[i]
-		if ns.canWriteFor(s.nodeType, int(replicaNum)) && !containsID(excludeNodeSets, ns.ID) {
+		if ns.canWriteFor(s.nodeType, int(replicaNum), mediaType) && !containsID(excludeNodeSets, ns.ID) {
 			break
 		}
 	}
 	if ns != nil {
-		if !ns.canWriteFor(s.nodeType, int(replicaNum)) || containsID(excludeNodeSets, ns.ID) {
+		if !ns.canWriteFor(s.nodeType, int(replicaNum), mediaType) || containsID(excludeNodeSets, ns.ID) {
 			goto err
 		}
 		s.carrys[ns.ID] -= 1.0
@@ -289,15 +291,15 @@ func (s *AvailableSpaceFirstNodesetSelector) GetName() string {
 	return AvailableSpaceFirstNodesetSelectorName
 }
 
-func (s *AvailableSpaceFirstNodesetSelector) Select(nsc nodeSetCollection, excludeNodeSets []uint64, replicaNum uint8) (ns *nodeSet, err error) {
+func (s *AvailableSpaceFirstNodesetSelector) Select(nsc nodeSetCollection, excludeNodeSets []uint64, replicaNum uint8, mediaType uint32) (ns *nodeSet, err error) {
 	// sort nodesets by available space
 	sort.Slice(nsc, func(i, j int) bool {
-		return nsc[i].getTotalAvailableSpaceOf(s.nodeType) > nsc[j].getTotalAvailableSpaceOf(s.nodeType)
+		return nsc[i].getTotalAvailableSpaceOf(s.nodeType, mediaType) > nsc[j].getTotalAvailableSpaceOf(s.nodeType, mediaType)
 	})
 	// pick the first nodeset that has N writable nodes
 	for i := 0; i < nsc.Len(); i++ {
 		ns = nsc[i]
-		if ns.canWriteFor(s.nodeType, int(replicaNum)) && !containsID(excludeNodeSets, ns.ID) {
+		if ns.canWriteFor(s.nodeType, int(replicaNum), mediaType) && !containsID(excludeNodeSets, ns.ID) {
 			return
 		}
 	}
@@ -332,14 +334,14 @@ func (s *StrawNodesetSelector) GetName() string {
 	return StrawNodesetSelectorName
 }
 
-func (s *StrawNodesetSelector) getWeight(ns *nodeSet) float64 {
-	return float64(ns.getTotalAvailableSpaceOf(s.nodeType) / util.GB)
+func (s *StrawNodesetSelector) getWeight(ns *nodeSet, mediaType uint32) float64 {
+	return float64(ns.getTotalAvailableSpaceOf(s.nodeType, mediaType) / util.GB)
 }
 
-func (s *StrawNodesetSelector) Select(nsc nodeSetCollection, excludeNodeSets []uint64, replicaNum uint8) (ns *nodeSet, err error) {
+func (s *StrawNodesetSelector) Select(nsc nodeSetCollection, excludeNodeSets []uint64, replicaNum uint8, mediaType uint32) (ns *nodeSet, err error) {
 	tmp := make(nodeSetCollection, 0)
 	for _, nodeset := range nsc {
-		if nodeset.canWriteFor(s.nodeType, int(replicaNum)) && !containsID(excludeNodeSets, nodeset.ID) {
+		if nodeset.canWriteFor(s.nodeType, int(replicaNum), mediaType) && !containsID(excludeNodeSets, nodeset.ID) {
 			tmp = append(tmp, nodeset)
 		}
 	}
@@ -358,7 +360,7 @@ func (s *StrawNodesetSelector) Select(nsc nodeSetCollection, excludeNodeSets []u
 	maxStraw := float64(0)
 	for _, nodeset := range nsc {
 		straw := float64(s.rand.Intn(StrawNodesetSelectorRandMax))
-		straw = math.Log(straw/float64(StrawNodesetSelectorRandMax)) / s.getWeight(nodeset)
+		straw = math.Log(straw/float64(StrawNodesetSelectorRandMax)) / s.getWeight(nodeset, mediaType)
 		if ns == nil || straw > maxStraw {
 			ns = nodeset
 			maxStraw = straw
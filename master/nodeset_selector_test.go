@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/cubefs/cubefs/master/mocktest"
+	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util"
 )
 
@@ -29,7 +30,7 @@ func writeNodeset(sb *strings.Builder, nset *nodeSet) {
 	sb.WriteString(fmt.Sprintf("Nodeset %v\n", nset.ID))
 	sb.WriteString(fmt.Sprintf("\tTotal Data Space:%v GB\n", nset.getDataNodeTotalSpace()/util.GB))
 	sb.WriteString(fmt.Sprintf("\tTotal Meta Space:%v GB\n", nset.getMetaNodeTotalSpace()/util.GB))
-	sb.WriteString(fmt.Sprintf("\tTotal Data Available Space:%v GB\n", nset.getDataNodeTotalAvailableSpace()/util.GB))
+	sb.WriteString(fmt.Sprintf("\tTotal Data Available Space:%v GB\n", nset.getDataNodeTotalAvailableSpace(proto.MediaTypeUnspecified)/util.GB))
 	sb.WriteString(fmt.Sprintf("\tTotal Meta Available Space:%v GB\n", nset.getMetaNodeTotalAvailableSpace()/util.GB))
 }
 
@@ -56,7 +57,7 @@ func NodesetSelectorTest(t *testing.T, selector NodesetSelector) {
 	}
 	printNodesetsOfZone(t, zone)
 	nsc := zone.getAllNodeSet()
-	ns, err := selector.Select(nsc, nil, 1)
+	ns, err := selector.Select(nsc, nil, 1, proto.MediaTypeUnspecified)
 	if err != nil {
 		t.Errorf("%v failed to select nodeset %v", selector.GetName(), err)
 		return
@@ -124,7 +125,7 @@ const loopNodesetSelectorTestCount = 100
 func nodesetSelectorBench(selector NodesetSelector, nsc nodeSetCollection, onSelect func(id uint64)) (map[uint64]int, error) {
 	times := make(map[uint64]int)
 	for i := 0; i < loopNodeSelectorTestCount; i++ {
-		ns, err := selector.Select(nsc, nil, 1)
+		ns, err := selector.Select(nsc, nil, 1, proto.MediaTypeUnspecified)
 		if err != nil {
 			return nil, err
 		}
@@ -23,6 +23,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/cubefs/cubefs/depends/tiglabs/raft"
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/exporter"
@@ -31,7 +32,7 @@ import (
 
 func newCreateDataPartitionRequest(volName string, ID uint64, replicaNum int, members []proto.Peer,
 	dataPartitionSize, leaderSize int, hosts []string, createType int, partitionType int,
-	decommissionedDisks []string, verSeq uint64) (req *proto.CreateDataPartitionRequest) {
+	decommissionedDisks []string, verSeq uint64, mediaType uint32) (req *proto.CreateDataPartitionRequest) {
 	req = &proto.CreateDataPartitionRequest{
 		PartitionTyp:        partitionType,
 		PartitionId:         ID,
@@ -44,6 +45,7 @@ func newCreateDataPartitionRequest(volName string, ID uint64, replicaNum int, me
 		LeaderSize:          leaderSize,
 		DecommissionedDisks: decommissionedDisks,
 		VerSeq:              verSeq,
+		MediaType:           mediaType,
 	}
 	return
 }
@@ -63,6 +65,15 @@ func newAddDataPartitionRaftMemberRequest(ID uint64, addPeer proto.Peer) (req *p
 	return
 }
 
+func newPromoteDataPartitionLearnerRequest(ID uint64, promotePeer, replacePeer proto.Peer) (req *proto.PromoteDataPartitionLearnerRequest) {
+	req = &proto.PromoteDataPartitionLearnerRequest{
+		PartitionId: ID,
+		PromotePeer: promotePeer,
+		ReplacePeer: replacePeer,
+	}
+	return
+}
+
 func newRemoveDataPartitionRaftMemberRequest(ID uint64, removePeer proto.Peer) (req *proto.RemoveDataPartitionRaftMemberRequest) {
 	req = &proto.RemoveDataPartitionRaftMemberRequest{
 		PartitionId: ID,
@@ -86,6 +97,14 @@ func newStopDataPartitionRepairRequest(ID uint64, stop bool) (req *proto.StopDat
 	return
 }
 
+func newUpdateDataPartitionVolNameRequest(ID uint64, volName string) (req *proto.UpdateDataPartitionVolNameRequest) {
+	req = &proto.UpdateDataPartitionVolNameRequest{
+		PartitionId: ID,
+		VolName:     volName,
+	}
+	return
+}
+
 func unmarshalTaskResponse(task *proto.AdminTask) (err error) {
 	bytes, err := json.Marshal(task.Response)
 	if err != nil {
@@ -117,6 +136,8 @@ func unmarshalTaskResponse(task *proto.AdminTask) (err error) {
 		response = &proto.LcNodeRuleTaskResponse{}
 	case proto.OpLcNodeSnapshotVerDel:
 		response = &proto.SnapshotVerDelTaskResponse{}
+	case proto.OpLcNodeReplicate:
+		response = &proto.ReplicationTaskResponse{}
 
 	default:
 		log.LogError(fmt.Sprintf("unknown operate code(%v)", task.OpCode))
@@ -146,6 +167,11 @@ func contains(arr []string, element string) (ok bool) {
 	return
 }
 
+// isNotReplaced tells whether the raft refused to replace the member, which fails the same way if it is retried.
+func isNotReplaced(err error) bool {
+	return err != nil && strings.Contains(err.Error(), raft.ErrNotReplaced.Error())
+}
+
 func containsID(arr []uint64, element uint64) bool {
 	if arr == nil || len(arr) == 0 {
 		return false
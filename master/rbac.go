@@ -0,0 +1,492 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/log"
+	"github.com/gorilla/mux"
+)
+
+// With RBAC enabled, the apis need a request signed with the access key and secret key of a user
+// whose role grants the access listed below, an api not listed needs the cluster access. Only the
+// public apis called by the nodes and the clients stay open, a signed request to them is still
+// verified. The volume apis may also be called unsigned with the authKey of the volume owner as
+// before. The apis reading the users need a signed request of any user, the secret keys are
+// replied to the user itself and the cluster admins only.
+// A signed request is accepted once within the clock skew, its nonce is rejected afterwards.
+
+const rbacMaxClockSkew = 5 * time.Minute
+
+var adminAPIAccess = map[string]proto.AdminAccess{
+	// read
+	proto.AdminClusterStat:                          proto.AdminAccessRead,
+	proto.AdminDiagnoseDataPartition:                proto.AdminAccessRead,
+	proto.AdminDiagnoseMetaPartition:                proto.AdminAccessRead,
+	proto.AdminGetAllNodeSetGrpInfo:                 proto.AdminAccessRead,
+	proto.AdminGetNodeSetGrpInfo:                    proto.AdminAccessRead,
+	proto.AdminGetApiQpsLimit:                       proto.AdminAccessRead,
+	proto.AdminGetClusterUuid:                       proto.AdminAccessRead,
+	proto.AdminGetClusterValue:                      proto.AdminAccessRead,
+	proto.AdminGetConfig:                            proto.AdminAccessRead,
+	proto.AdminGetDataRebalance:                     proto.AdminAccessRead,
+	proto.AdminGetDiscardDp:                         proto.AdminAccessRead,
+	proto.AdminGetFileStats:                         proto.AdminAccessRead,
+	proto.AdminGetInvalidNodes:                      proto.AdminAccessRead,
+	proto.AdminGetIsDomainOn:                        proto.AdminAccessRead,
+	proto.AdminGetMasterApiList:                     proto.AdminAccessRead,
+	proto.AdminGetVolReplication:                    proto.AdminAccessRead,
+	proto.AdminListAudit:                            proto.AdminAccessRead,
+	proto.AdminListVols:                             proto.AdminAccessRead,
+	proto.AdminQueryAutoDecommissionDisk:            proto.AdminAccessRead,
+	proto.AdminQueryDataPartitionDecommissionStatus: proto.AdminAccessRead,
+	proto.AdminQueryDecommissionDiskLimit:           proto.AdminAccessRead,
+	proto.AdminQueryDecommissionLimit:               proto.AdminAccessRead,
+	proto.AdminQueryDecommissionToken:               proto.AdminAccessRead,
+	proto.GetAllNodeSets:                            proto.AdminAccessRead,
+	proto.GetAllZones:                               proto.AdminAccessRead,
+	proto.GetNodeSet:                                proto.AdminAccessRead,
+	proto.GetTopologyView:                           proto.AdminAccessRead,
+	proto.QosGetClientsLimitInfo:                    proto.AdminAccessRead,
+	proto.QosGetStatus:                              proto.AdminAccessRead,
+	proto.QosGetZoneLimitInfo:                       proto.AdminAccessRead,
+	proto.QueryAllDecommissionDisk:                  proto.AdminAccessRead,
+	proto.QueryBadDisks:                             proto.AdminAccessRead,
+	proto.QueryDataNodeDecoFailedDps:                proto.AdminAccessRead,
+	proto.QueryDataNodeDecoProgress:                 proto.AdminAccessRead,
+	proto.QueryDecommissionDiskDecoFailedDps:        proto.AdminAccessRead,
+	proto.QueryDisableDisk:                          proto.AdminAccessRead,
+	proto.QueryDiskDecoProgress:                     proto.AdminAccessRead,
+	proto.QueryDiskDetail:                           proto.AdminAccessRead,
+	proto.QueryDisks:                                proto.AdminAccessRead,
+	proto.QuotaGet:                                  proto.AdminAccessRead,
+	proto.QuotaListAll:                              proto.AdminAccessRead,
+	proto.RaftStatus:                                proto.AdminAccessRead,
+	proto.UserQuotaReport:                           proto.AdminAccessRead,
+	proto.UsersOfVol:                                proto.AdminAccessRead,
+
+	// cluster
+	proto.AdminClusterAPI:                    proto.AdminAccessCluster,
+	proto.AdminUserAPI:                       proto.AdminAccessCluster,
+	proto.AdminSetApiQpsLimit:                proto.AdminAccessCluster,
+	proto.AdminRemoveApiQpsLimit:             proto.AdminAccessCluster,
+	proto.AdminSetClusterInfo:                proto.AdminAccessCluster,
+	proto.AdminClusterFreeze:                 proto.AdminAccessCluster,
+	proto.AdminClusterForbidMpDecommission:   proto.AdminAccessCluster,
+	proto.AdminSetCheckDataReplicasEnable:    proto.AdminAccessCluster,
+	proto.AdminSetMetaNodeThreshold:          proto.AdminAccessCluster,
+	proto.AdminSetMasterVolDeletionDelayTime: proto.AdminAccessCluster,
+	proto.AdminSetNodeInfo:                   proto.AdminAccessCluster,
+	proto.AdminSetNodeRdOnly:                 proto.AdminAccessCluster,
+	proto.AdminSetDpRdOnly:                   proto.AdminAccessCluster,
+	proto.AdminSetConfig:                     proto.AdminAccessCluster,
+	proto.AdminChangeMasterLeader:            proto.AdminAccessCluster,
+	proto.AdminUpdateDecommissionLimit:       proto.AdminAccessCluster,
+	proto.AdminUpdateDecommissionDiskFactor:  proto.AdminAccessCluster,
+	proto.AdminEnableAutoDecommissionDisk:    proto.AdminAccessCluster,
+	proto.AdminSetFileStats:                  proto.AdminAccessCluster,
+	proto.AdminSetClusterUuidEnable:          proto.AdminAccessCluster,
+	proto.AdminGenerateClusterUuid:           proto.AdminAccessCluster,
+	proto.AdminSetDpDiscard:                  proto.AdminAccessCluster,
+	proto.AdminSetConLcNodeNum:               proto.AdminAccessCluster,
+	proto.AdminUpdateNodeSetCapcity:          proto.AdminAccessCluster,
+	proto.AdminUpdateNodeSetId:               proto.AdminAccessCluster,
+	proto.AdminUpdateNodeSetNodeSelector:     proto.AdminAccessCluster,
+	proto.AdminUpdateDomainDataUseRatio:      proto.AdminAccessCluster,
+	proto.AdminUpdateZoneExcludeRatio:        proto.AdminAccessCluster,
+	proto.UpdateZone:                         proto.AdminAccessCluster,
+	proto.UpdateNodeSet:                      proto.AdminAccessCluster,
+	proto.AddRaftNode:                        proto.AdminAccessCluster,
+	proto.RemoveRaftNode:                     proto.AdminAccessCluster,
+	proto.QosUpdate:                          proto.AdminAccessCluster,
+	proto.QosUpdateMagnify:                   proto.AdminAccessCluster,
+	proto.QosUpdateClientParam:               proto.AdminAccessCluster,
+	proto.QosUpdateZoneLimit:                 proto.AdminAccessCluster,
+	proto.QosUpdateMasterLimit:               proto.AdminAccessCluster,
+	proto.S3QoSSet:                           proto.AdminAccessCluster,
+	proto.S3QoSDelete:                        proto.AdminAccessCluster,
+	proto.AdminOpFollowerPartitionsRead:      proto.AdminAccessCluster,
+	proto.AdminLcNode:                        proto.AdminAccessCluster,
+	proto.UserCreate:                         proto.AdminAccessCluster,
+	proto.UserDelete:                         proto.AdminAccessCluster,
+	proto.UserUpdate:                         proto.AdminAccessCluster,
+
+	// volume
+	proto.AdminCreateVol:                  proto.AdminAccessVolume,
+	proto.AdminDeleteVol:                  proto.AdminAccessVolume,
+	proto.AdminUpdateVol:                  proto.AdminAccessVolume,
+	proto.AdminVolShrink:                  proto.AdminAccessVolume,
+	proto.AdminVolExpand:                  proto.AdminAccessVolume,
+	proto.AdminVolForbidden:               proto.AdminAccessVolume,
+	proto.AdminVolEnableAuditLog:          proto.AdminAccessVolume,
+	proto.AdminSetVolReplication:          proto.AdminAccessVolume,
+	proto.AdminDelVolReplication:          proto.AdminAccessVolume,
+	proto.AdminCloneVol:                   proto.AdminAccessVolume,
+	proto.AdminRenameVol:                  proto.AdminAccessVolume,
+	proto.AdminPromoteVol:                 proto.AdminAccessVolume,
+	proto.AdminACL:                        proto.AdminAccessVolume,
+	proto.AdminUid:                        proto.AdminAccessVolume,
+	proto.AdminCreateVersion:              proto.AdminAccessVolume,
+	proto.AdminDelVersion:                 proto.AdminAccessVolume,
+	proto.AdminSetVerStrategy:             proto.AdminAccessVolume,
+	proto.AdminCreateDirSnapshot:          proto.AdminAccessVolume,
+	proto.AdminDelDirSnapshot:             proto.AdminAccessVolume,
+	proto.QuotaCreate:                     proto.AdminAccessVolume,
+	proto.QuotaUpdate:                     proto.AdminAccessVolume,
+	proto.QuotaDelete:                     proto.AdminAccessVolume,
+	proto.UserQuotaSet:                    proto.AdminAccessVolume,
+	proto.UserQuotaDelete:                 proto.AdminAccessVolume,
+	proto.AdminCreateDataPartition:        proto.AdminAccessVolume,
+	proto.AdminCreateMetaPartition:        proto.AdminAccessVolume,
+	proto.AdminCreatePreLoadDataPartition: proto.AdminAccessVolume,
+	proto.SetBucketLifecycle:              proto.AdminAccessVolume,
+	proto.DeleteBucketLifecycle:           proto.AdminAccessVolume,
+	proto.UserUpdatePolicy:                proto.AdminAccessVolume,
+	proto.UserRemovePolicy:                proto.AdminAccessVolume,
+	proto.UserDeleteVolPolicy:             proto.AdminAccessVolume,
+	proto.UserTransferVol:                 proto.AdminAccessVolume,
+
+	// operate
+	proto.AdminDecommissionDataPartition:            proto.AdminAccessOperate,
+	proto.AdminResetDataPartitionDecommissionStatus: proto.AdminAccessOperate,
+	proto.AdminDataPartitionChangeLeader:            proto.AdminAccessOperate,
+	proto.AdminLoadDataPartition:                    proto.AdminAccessOperate,
+	proto.AdminLoadMetaPartition:                    proto.AdminAccessOperate,
+	proto.AdminAddDataReplica:                       proto.AdminAccessOperate,
+	proto.AdminDeleteDataReplica:                    proto.AdminAccessOperate,
+	proto.AdminDecommissionMetaPartition:            proto.AdminAccessOperate,
+	proto.AdminChangeMetaPartitionLeader:            proto.AdminAccessOperate,
+	proto.AdminBalanceMetaPartitionLeader:           proto.AdminAccessOperate,
+	proto.AdminSplitMetaPartition:                   proto.AdminAccessOperate,
+	proto.AdminMergeMetaPartition:                   proto.AdminAccessOperate,
+	proto.AdminAddMetaReplica:                       proto.AdminAccessOperate,
+	proto.AdminDeleteMetaReplica:                    proto.AdminAccessOperate,
+	proto.AdminStartDataRebalance:                   proto.AdminAccessOperate,
+	proto.AdminStopDataRebalance:                    proto.AdminAccessOperate,
+	proto.DecommissionDataNode:                      proto.AdminAccessOperate,
+	proto.MigrateDataNode:                           proto.AdminAccessOperate,
+	proto.PauseDecommissionDataNode:                 proto.AdminAccessOperate,
+	proto.CancelDecommissionDataNode:                proto.AdminAccessOperate,
+	proto.SetDataNodeMaintenance:                    proto.AdminAccessOperate,
+	proto.AdminUpdateDataNode:                       proto.AdminAccessOperate,
+	proto.DecommissionDisk:                          proto.AdminAccessOperate,
+	proto.RecommissionDisk:                          proto.AdminAccessOperate,
+	proto.MarkDecoDiskFixed:                         proto.AdminAccessOperate,
+	proto.PauseDecommissionDisk:                     proto.AdminAccessOperate,
+	proto.RestoreStoppedAutoDecommissionDisk:        proto.AdminAccessOperate,
+	proto.DecommissionMetaNode:                      proto.AdminAccessOperate,
+	proto.MigrateMetaNode:                           proto.AdminAccessOperate,
+	proto.SetMetaNodeMaintenance:                    proto.AdminAccessOperate,
+	proto.AdminUpdateMetaNode:                       proto.AdminAccessOperate,
+}
+
+var userInfoAPIs = map[string]bool{
+	proto.UserGetInfo:   true,
+	proto.UserGetAKInfo: true,
+	proto.UserList:      true,
+}
+
+// publicAPIs are called by the nodes, the clients and the other masters.
+var publicAPIs = map[string]bool{
+	// nodes
+	proto.AddDataNode:             true,
+	proto.AddMetaNode:             true,
+	proto.AddLcNode:               true,
+	proto.GetDataNode:             true,
+	proto.GetMetaNode:             true,
+	proto.GetDataNodeTaskResponse: true,
+	proto.GetMetaNodeTaskResponse: true,
+	proto.GetLcNodeTaskResponse:   true,
+	proto.GetBucketLifecycle:      true,
+	proto.QuotaList:               true,
+	proto.AdminPutDataPartitions:  true,
+	exporter.PromHandlerPattern:   true,
+	proto.AdminGetMonitorPushAddr: true,
+	proto.QosUpload:               true,
+	proto.S3QoSGet:                true,
+	proto.AdminGetIP:              true,
+	proto.AdminGetCluster:         true,
+	proto.AdminGetNodeInfo:        true,
+	proto.AdminGetDataPartition:   true,
+
+	// clients
+	proto.ClientVol:                true,
+	proto.ClientVolStat:            true,
+	proto.ClientMetaPartition:      true,
+	proto.ClientMetaPartitions:     true,
+	proto.ClientDataPartitions:     true,
+	proto.ClientDiskDataPartitions: true,
+	proto.AdminGetVol:              true,
+	proto.AdminGetVolVer:           true,
+	proto.AdminGetVersionInfo:      true,
+	proto.AdminGetAllVersionInfo:   true,
+	proto.AdminListDirSnapshot:     true,
+}
+
+func (m *Server) registerRBACMiddleware(router *mux.Router) {
+	rbacInterceptor := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if m.config.enableRBAC {
+					signer, err := m.checkAdminAccess(r)
+					if err != nil {
+						log.LogWarnf("action[rbacInterceptor] deny, remote[%v] path[%v] err[%v]", r.RemoteAddr, r.URL.Path, err)
+						sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeNoPermission, Msg: err.Error()})
+						return
+					}
+					if signer != nil {
+						r = r.WithContext(context.WithValue(r.Context(), proto.UserInfoKey, signer))
+					}
+				}
+				next.ServeHTTP(w, r)
+			})
+	}
+	router.Use(rbacInterceptor)
+}
+
+// checkAdminAccess verifies the signature of the request and the access of the signer to the api,
+// it returns the signer of a signed request.
+func (m *Server) checkAdminAccess(r *http.Request) (signer *proto.UserInfo, err error) {
+	access, restricted := adminAPIRestricted(r)
+	signed := r.Header.Get(proto.HeaderAccessKey) != ""
+	if !restricted && !signed && !userInfoAPIs[r.URL.Path] {
+		return
+	}
+	var body []byte
+	if body, err = readAndRestoreBody(r); err != nil {
+		return
+	}
+	if !signed {
+		if access == proto.AdminAccessVolume && m.matchVolAuthKey(r) {
+			return
+		}
+		if !restricted {
+			return nil, fmt.Errorf("%v needs a signed request", r.URL.Path)
+		}
+		return nil, fmt.Errorf("%v needs a request signed by a user granted the %v access", r.URL.Path, access)
+	}
+
+	if signer, err = m.verifyAdminSignature(r, body); err != nil || !restricted {
+		return
+	}
+	signer.Mu.RLock()
+	userID, role := signer.UserID, userRole(signer)
+	signer.Mu.RUnlock()
+	if !role.Allows(access) {
+		return nil, fmt.Errorf("user[%v] role[%v] has no %v access to %v", userID, role, access, r.URL.Path)
+	}
+	if role == proto.RoleVolumeAdmin {
+		volName := adminAPIVolName(r, body)
+		if !m.ownsVol(signer, volName, r) {
+			return nil, fmt.Errorf("user[%v] role[%v] does not own vol[%v]", userID, role, volName)
+		}
+	}
+	return
+}
+
+// adminAPIRestricted returns the access the api needs, restricted is false if the api stays open.
+// The acl and uid apis stay open to list and check, the clients check their ips by them.
+func adminAPIRestricted(r *http.Request) (access proto.AdminAccess, restricted bool) {
+	if publicAPIs[r.URL.Path] || userInfoAPIs[r.URL.Path] {
+		return
+	}
+	var ok bool
+	if access, ok = adminAPIAccess[r.URL.Path]; !ok {
+		access = proto.AdminAccessCluster
+	}
+	restricted = true
+	switch r.URL.Path {
+	case proto.AdminACL:
+		op := r.URL.Query().Get(OperateKey)
+		restricted = op != strconv.Itoa(util.AclListIP) && op != strconv.Itoa(util.AclCheckIP)
+	case proto.AdminUid:
+		op := r.URL.Query().Get(OperateKey)
+		restricted = op != strconv.Itoa(util.UidLimitList) && op != strconv.Itoa(util.UidGetLimit)
+	default:
+	}
+	return
+}
+
+// userInfoForSigner returns the user info replied to the signer of the request. The secret key is
+// left out unless the signer is the user itself or a cluster admin, and the other users are only
+// read by a role granting the read access. The user info is replied as is without RBAC.
+func (m *Server) userInfoForSigner(r *http.Request, userInfo *proto.UserInfo) (*proto.UserInfo, error) {
+	if !m.config.enableRBAC {
+		return userInfo, nil
+	}
+	signer, ok := r.Context().Value(proto.UserInfoKey).(*proto.UserInfo)
+	if !ok {
+		return nil, fmt.Errorf("%v needs a signed request", r.URL.Path)
+	}
+	signer.Mu.RLock()
+	signerID, role := signer.UserID, userRole(signer)
+	signer.Mu.RUnlock()
+
+	userInfo.Mu.RLock()
+	defer userInfo.Mu.RUnlock()
+	if signerID == userInfo.UserID || role == proto.RoleClusterAdmin {
+		return userInfo, nil
+	}
+	if !role.Allows(proto.AdminAccessRead) {
+		return nil, fmt.Errorf("user[%v] role[%v] has no %v access to user[%v]", signerID, role, proto.AdminAccessRead, userInfo.UserID)
+	}
+	return &proto.UserInfo{
+		UserID: userInfo.UserID, AccessKey: userInfo.AccessKey, Policy: userInfo.Policy, UserType: userInfo.UserType,
+		Role: userInfo.Role, CreateTime: userInfo.CreateTime, Description: userInfo.Description,
+	}, nil
+}
+
+// verifyAdminSignature returns the user who signed the request.
+func (m *Server) verifyAdminSignature(r *http.Request, body []byte) (userInfo *proto.UserInfo, err error) {
+	accessKey := r.Header.Get(proto.HeaderAccessKey)
+	date := r.Header.Get(proto.HeaderDate)
+	signTime, err := strconv.ParseInt(date, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v[%v]", proto.HeaderDate, date)
+	}
+	if skew := time.Since(time.Unix(signTime, 0)); skew > rbacMaxClockSkew || skew < -rbacMaxClockSkew {
+		return nil, fmt.Errorf("request signed at %v is expired", time.Unix(signTime, 0).Format(time.RFC3339))
+	}
+	nonce := r.Header.Get(proto.HeaderNonce)
+	if nonce == "" {
+		return nil, fmt.Errorf("request signed without %v", proto.HeaderNonce)
+	}
+	if userInfo, err = m.user.getKeyInfo(accessKey); err != nil {
+		return nil, fmt.Errorf("access key[%v]: %v", accessKey, err)
+	}
+	userInfo.Mu.RLock()
+	secretKey := userInfo.SecretKey
+	userInfo.Mu.RUnlock()
+	expected := proto.SignAdminRequest(secretKey, r.Method, r.URL.Path, r.URL.RawQuery, date, nonce, body)
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get(proto.HeaderSignature))) {
+		return nil, fmt.Errorf("signature of access key[%v] does not match", accessKey)
+	}
+	if !m.signNonces.add(accessKey, nonce, signTime) {
+		return nil, fmt.Errorf("request of access key[%v] with nonce[%v] is replayed", accessKey, nonce)
+	}
+	return
+}
+
+// signNonces remembers the nonces of the signed requests until they expire with the clock skew.
+// The requests are served by the leader, the followers forward them to it.
+type signNonces struct {
+	sync.Mutex
+	expires   map[string]int64
+	lastPrune int64
+}
+
+func newSignNonces() *signNonces {
+	return &signNonces{expires: make(map[string]int64)}
+}
+
+// add returns false if the nonce is used by the user before.
+func (s *signNonces) add(accessKey, nonce string, signTime int64) bool {
+	now := time.Now().Unix()
+	skew := int64(rbacMaxClockSkew / time.Second)
+	s.Lock()
+	defer s.Unlock()
+	if now-s.lastPrune > skew {
+		for key, expire := range s.expires {
+			if expire < now {
+				delete(s.expires, key)
+			}
+		}
+		s.lastPrune = now
+	}
+	key := accessKey + "/" + nonce
+	if expire, ok := s.expires[key]; ok && expire >= now {
+		return false
+	}
+	s.expires[key] = signTime + skew
+	return true
+}
+
+// userRole returns the role of the user, the root user is always a cluster admin.
+func userRole(userInfo *proto.UserInfo) proto.Role {
+	if userInfo.UserType == proto.UserTypeRoot {
+		return proto.RoleClusterAdmin
+	}
+	return userInfo.Role
+}
+
+// ownsVol returns whether the user owns the vol, or is the owner of the vol to create.
+func (m *Server) ownsVol(userInfo *proto.UserInfo, volName string, r *http.Request) bool {
+	if r.URL.Path == proto.AdminCreateVol {
+		return r.FormValue(volOwnerKey) == userInfo.UserID
+	}
+	if volName == "" {
+		return false
+	}
+	if userInfo.Policy != nil && userInfo.Policy.IsOwn(volName) {
+		return true
+	}
+	vol, err := m.cluster.getVol(volName)
+	return err == nil && vol.Owner == userInfo.UserID
+}
+
+// matchVolAuthKey returns whether the request carries the authKey of the vol owner.
+func (m *Server) matchVolAuthKey(r *http.Request) bool {
+	if auditedAdminAPIs[r.URL.Path] != nameKey {
+		return false
+	}
+	vol, err := m.cluster.getVol(r.FormValue(nameKey))
+	if err != nil {
+		return false
+	}
+	return matchKey(vol.Owner, r.FormValue(volAuthKey))
+}
+
+// adminAPIVolName returns the vol the api works on, from the params or the json body.
+func adminAPIVolName(r *http.Request, body []byte) string {
+	key := auditedAdminAPIs[r.URL.Path]
+	if key == "" {
+		return ""
+	}
+	if name := r.FormValue(key); name != "" {
+		return name
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return ""
+	}
+	name, _ := fields[key].(string)
+	return name
+}
+
+// readAndRestoreBody reads the body and leaves it readable for the handler.
+func readAndRestoreBody(r *http.Request) (body []byte, err error) {
+	if r.Body == nil {
+		return
+	}
+	if body, err = io.ReadAll(r.Body); err != nil {
+		return
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return
+}
@@ -0,0 +1,488 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	defaultRebalanceThreshold   = 0.1
+	defaultRebalanceConcurrency = 5
+	rebalanceCheckInterval      = time.Minute
+	// a moved replica is given up to be tracked if it is not recovered for the time
+	rebalanceMigrationTimeout = 12 * time.Hour
+)
+
+// rebalanceNode is the usage of a datanode taken to plan the rebalance, the used space of the
+// node and its disks is updated as the partitions are planned to move.
+type rebalanceNode struct {
+	addr       string
+	total      uint64
+	used       uint64
+	available  uint64
+	writable   bool
+	exhausted  bool
+	disks      map[string]*proto.DiskStat
+	partitions []*proto.DataPartitionReport
+}
+
+func (n *rebalanceNode) ratio() float64 {
+	if n.total == 0 {
+		return 0
+	}
+	return float64(n.used) / float64(n.total)
+}
+
+func subUsage(used, size uint64) uint64 {
+	if used < size {
+		return 0
+	}
+	return used - size
+}
+
+func diskRatio(disk *proto.DiskStat) float64 {
+	if disk.Total == 0 {
+		return 0
+	}
+	return float64(disk.Used) / float64(disk.Total)
+}
+
+// planRebalance plans at most count moves of the replicas within the group of datanodes. A replica
+// is moved from the most used datanode to the least used one if their usage ratios differ by more
+// than threshold. The replica is taken from the most used disk of the source, and it is no larger than
+// half of the difference so that the datanodes never swap their roles. The partitions which are not
+// allowed to be moved to the target are filtered by movable.
+func planRebalance(nodes []*rebalanceNode, threshold float64, count int,
+	movable func(report *proto.DataPartitionReport, dst string) bool,
+) (plans []*proto.RebalanceMigration) {
+	planned := make(map[uint64]bool)
+	for len(plans) < count {
+		candidates := make([]*rebalanceNode, 0, len(nodes))
+		for _, node := range nodes {
+			if node.total > 0 {
+				candidates = append(candidates, node)
+			}
+		}
+		if len(candidates) < 2 {
+			return
+		}
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].ratio() > candidates[j].ratio() })
+
+		var src, dst *rebalanceNode
+		for _, node := range candidates {
+			if !node.exhausted {
+				src = node
+				break
+			}
+		}
+		for i := len(candidates) - 1; i >= 0; i-- {
+			if candidates[i].writable {
+				dst = candidates[i]
+				break
+			}
+		}
+		if src == nil || dst == nil || src == dst || src.ratio()-dst.ratio() <= threshold {
+			return
+		}
+
+		minTotal := src.total
+		if dst.total < minTotal {
+			minTotal = dst.total
+		}
+		limit := uint64((src.ratio() - dst.ratio()) / 2 * float64(minTotal))
+		if limit > dst.available {
+			limit = dst.available
+		}
+		plan := pickRebalancePartition(src, dst, limit, planned, movable)
+		if plan == nil {
+			src.exhausted = true
+			continue
+		}
+		planned[plan.PartitionID] = true
+		plans = append(plans, plan)
+		src.used = subUsage(src.used, plan.Size)
+		if disk, ok := src.disks[plan.SrcDisk]; ok {
+			disk.Used = subUsage(disk.Used, plan.Size)
+		}
+		dst.used += plan.Size
+		dst.available = subUsage(dst.available, plan.Size)
+	}
+	return
+}
+
+// pickRebalancePartition returns the largest partition of the most used disk of the source which
+// is movable and no larger than limit, the less used disks are tried if there is none.
+func pickRebalancePartition(src, dst *rebalanceNode, limit uint64, planned map[uint64]bool,
+	movable func(report *proto.DataPartitionReport, dst string) bool,
+) *proto.RebalanceMigration {
+	disks := make([]*proto.DiskStat, 0, len(src.disks))
+	for _, disk := range src.disks {
+		disks = append(disks, disk)
+	}
+	sort.Slice(disks, func(i, j int) bool { return diskRatio(disks[i]) > diskRatio(disks[j]) })
+
+	for _, disk := range disks {
+		var picked *proto.DataPartitionReport
+		for _, report := range src.partitions {
+			if report.DiskPath != disk.DiskPath || planned[report.PartitionID] {
+				continue
+			}
+			if report.Used == 0 || report.Used > limit {
+				continue
+			}
+			if picked != nil && report.Used <= picked.Used {
+				continue
+			}
+			if movable(report, dst.addr) {
+				picked = report
+			}
+		}
+		if picked != nil {
+			return &proto.RebalanceMigration{
+				PartitionID: picked.PartitionID,
+				VolName:     picked.VolName,
+				SrcAddr:     src.addr,
+				SrcDisk:     picked.DiskPath,
+				DstAddr:     dst.addr,
+				Size:        picked.Used,
+			}
+		}
+	}
+	return nil
+}
+
+// rebalanceManager levels the disk usage of the datanodes by moving the replicas of the data
+// partitions from the over-full datanodes to the under-full ones, which is typically needed
+// after the cluster is expanded. The config is persisted with the cluster so that the new
+// master leader goes on with it, the moves in progress are only tracked in the memory.
+type rebalanceManager struct {
+	sync.RWMutex
+	cluster       *Cluster
+	config        proto.RebalanceConfig
+	migrations    map[uint64]*proto.RebalanceMigration
+	nextStart     time.Time
+	migratedCount uint64
+	migratedBytes uint64
+	failedCount   uint64
+	lastError     string
+	lastCheck     time.Time
+	balanced      bool
+}
+
+func newRebalanceManager(c *Cluster) *rebalanceManager {
+	return &rebalanceManager{
+		cluster:    c,
+		migrations: make(map[uint64]*proto.RebalanceMigration),
+	}
+}
+
+func (m *rebalanceManager) getConfig() proto.RebalanceConfig {
+	m.RLock()
+	defer m.RUnlock()
+	return m.config
+}
+
+func (m *rebalanceManager) loadConfig(config *proto.RebalanceConfig) {
+	if config == nil {
+		return
+	}
+	m.Lock()
+	m.config = *config
+	m.Unlock()
+}
+
+func (m *rebalanceManager) setConfig(config proto.RebalanceConfig) (err error) {
+	m.Lock()
+	oldConfig := m.config
+	m.config = config
+	m.Unlock()
+	if err = m.cluster.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setRebalanceConfig] err[%v]", err)
+		m.Lock()
+		m.config = oldConfig
+		m.Unlock()
+		return proto.ErrPersistenceByRaft
+	}
+	return
+}
+
+func (m *rebalanceManager) start(config proto.RebalanceConfig) (err error) {
+	if config.Level == "" {
+		config.Level = proto.RebalanceLevelNodeSet
+	}
+	if config.Level != proto.RebalanceLevelNodeSet && config.Level != proto.RebalanceLevelZone {
+		return fmt.Errorf("invalid rebalance level %v", config.Level)
+	}
+	if config.Threshold <= 0 {
+		config.Threshold = defaultRebalanceThreshold
+	}
+	if config.Threshold >= 1 {
+		return fmt.Errorf("invalid rebalance threshold %v", config.Threshold)
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = defaultRebalanceConcurrency
+	}
+	config.Enable = true
+	config.StartTime = time.Now()
+	if err = m.setConfig(config); err != nil {
+		return
+	}
+	m.Lock()
+	m.nextStart = time.Time{}
+	m.balanced = false
+	m.Unlock()
+	log.LogWarnf("action[startRebalance] config(%+v)", config)
+	return
+}
+
+// stop stops moving more partitions, the moves in progress are not cancelled.
+func (m *rebalanceManager) stop() (err error) {
+	config := m.getConfig()
+	if !config.Enable {
+		return
+	}
+	config.Enable = false
+	if err = m.setConfig(config); err != nil {
+		return
+	}
+	log.LogWarnf("action[stopRebalance] stopped")
+	return
+}
+
+func (m *rebalanceManager) getView() *proto.RebalanceView {
+	m.RLock()
+	defer m.RUnlock()
+	view := &proto.RebalanceView{
+		Config:        m.config,
+		Migrations:    make([]*proto.RebalanceMigration, 0, len(m.migrations)),
+		MigratedCount: m.migratedCount,
+		MigratedBytes: m.migratedBytes,
+		FailedCount:   m.failedCount,
+		LastError:     m.lastError,
+		LastCheckTime: m.lastCheck,
+		Balanced:      m.balanced,
+		NextStartTime: m.nextStart,
+	}
+	for _, migration := range m.migrations {
+		mig := *migration
+		view.Migrations = append(view.Migrations, &mig)
+	}
+	sort.Slice(view.Migrations, func(i, j int) bool {
+		return view.Migrations[i].PartitionID < view.Migrations[j].PartitionID
+	})
+	return view
+}
+
+// checkRebalance tracks the moves in progress and starts more moves if the budget allows.
+func (m *rebalanceManager) checkRebalance() {
+	m.checkMigrations()
+
+	m.Lock()
+	defer m.Unlock()
+	now := time.Now()
+	m.lastCheck = now
+	if !m.config.Enable {
+		return
+	}
+	count := m.config.Concurrency - len(m.migrations)
+	if count <= 0 || now.Before(m.nextStart) {
+		return
+	}
+
+	plans := make([]*proto.RebalanceMigration, 0)
+	for _, nodes := range m.groupNodes() {
+		plans = append(plans, planRebalance(nodes, m.config.Threshold, count-len(plans), m.movable)...)
+		if len(plans) >= count {
+			break
+		}
+	}
+	m.balanced = len(plans) == 0 && len(m.migrations) == 0
+	for _, plan := range plans {
+		if now.Before(m.nextStart) {
+			break
+		}
+		plan.StartTime = now
+		m.migrations[plan.PartitionID] = plan
+		if m.config.Bandwidth > 0 {
+			m.nextStart = now.Add(time.Duration(plan.Size/(m.config.Bandwidth*util.MB)) * time.Second)
+		}
+		log.LogWarnf("action[checkRebalance] move dp(%v) vol(%v) size(%v) from(%v:%v) to(%v)",
+			plan.PartitionID, plan.VolName, plan.Size, plan.SrcAddr, plan.SrcDisk, plan.DstAddr)
+		go m.migrate(plan)
+	}
+}
+
+func (m *rebalanceManager) migrate(plan *proto.RebalanceMigration) {
+	c := m.cluster
+	dp, err := c.getDataPartitionByID(plan.PartitionID)
+	if err == nil {
+		err = c.migrateDataPartition(plan.SrcAddr, plan.DstAddr, dp, false, "rebalance")
+	}
+	m.Lock()
+	defer m.Unlock()
+	if err != nil {
+		m.failMigration(plan, err)
+		return
+	}
+	plan.Recovering = true
+}
+
+// failMigration drops the move, the lock must be held.
+func (m *rebalanceManager) failMigration(plan *proto.RebalanceMigration, err error) {
+	delete(m.migrations, plan.PartitionID)
+	m.failedCount++
+	m.lastError = fmt.Sprintf("dp(%v) from(%v) to(%v): %v", plan.PartitionID, plan.SrcAddr, plan.DstAddr, err)
+	log.LogWarnf("action[rebalance] move dp(%v) from(%v) to(%v) failed, err(%v)", plan.PartitionID, plan.SrcAddr, plan.DstAddr, err)
+}
+
+// checkMigrations finishes the moves whose new replicas are recovered.
+func (m *rebalanceManager) checkMigrations() {
+	c := m.cluster
+	m.Lock()
+	defer m.Unlock()
+	for _, plan := range m.migrations {
+		if !plan.Recovering {
+			continue
+		}
+		dp, err := c.getDataPartitionByID(plan.PartitionID)
+		if err != nil {
+			m.failMigration(plan, err)
+			continue
+		}
+		dp.RLock()
+		moved := dp.hasHost(plan.DstAddr) && !dp.hasHost(plan.SrcAddr)
+		recovering := dp.isRecover
+		dp.RUnlock()
+		if !moved {
+			m.failMigration(plan, fmt.Errorf("replica is not moved, hosts %v", dp.Hosts))
+			continue
+		}
+		if recovering {
+			if time.Since(plan.StartTime) > rebalanceMigrationTimeout {
+				m.failMigration(plan, fmt.Errorf("not recovered after %v", rebalanceMigrationTimeout))
+			}
+			continue
+		}
+		delete(m.migrations, plan.PartitionID)
+		m.migratedCount++
+		m.migratedBytes += plan.Size
+		log.LogInfof("action[rebalance] dp(%v) moved from(%v) to(%v)", plan.PartitionID, plan.SrcAddr, plan.DstAddr)
+	}
+}
+
+// movable returns whether the replica is allowed to be moved to the datanode, the lock must be held.
+func (m *rebalanceManager) movable(report *proto.DataPartitionReport, dst string) bool {
+	if _, ok := m.migrations[report.PartitionID]; ok {
+		return false
+	}
+	dp, err := m.cluster.getDataPartitionByID(report.PartitionID)
+	if err != nil {
+		return false
+	}
+	if _, err = m.cluster.getVol(dp.VolName); err != nil {
+		return false
+	}
+	dp.RLock()
+	defer dp.RUnlock()
+	if !proto.IsNormalDp(dp.PartitionType) || dp.isRecover || dp.ReplicaNum < 3 || len(dp.Hosts) != int(dp.ReplicaNum) {
+		return false
+	}
+	if !dp.IsDecommissionInitial() || dp.hasHost(dst) || m.cluster.hasReplicaInMaintenance(TypeDataPartition, dp.Hosts) {
+		return false
+	}
+	if dp.MediaType != proto.MediaTypeUnspecified {
+		// keep the replica on its media class
+		dstNode, err := m.cluster.dataNode(dst)
+		if err != nil || !dstNode.canAllocDpOnMedia(dp.MediaType) {
+			return false
+		}
+	}
+	return true
+}
+
+// groupNodes groups the datanodes selected by the config at the configured level, the lock must be held.
+func (m *rebalanceManager) groupNodes() (groups [][]*rebalanceNode) {
+	zones := make(map[string]bool)
+	for _, zone := range m.config.Zones {
+		zones[zone] = true
+	}
+	nodeSets := make(map[uint64]bool)
+	for _, id := range m.config.NodeSets {
+		nodeSets[id] = true
+	}
+
+	for _, zone := range m.cluster.t.getAllZones() {
+		if len(zones) > 0 && !zones[zone.name] {
+			continue
+		}
+		var zoneNodes []*rebalanceNode
+		for _, ns := range zone.getAllNodeSet() {
+			if len(nodeSets) > 0 && !nodeSets[ns.ID] {
+				continue
+			}
+			nodes := make([]*rebalanceNode, 0)
+			ns.dataNodes.Range(func(key, value interface{}) bool {
+				if node := newRebalanceNode(value.(*DataNode)); node != nil {
+					nodes = append(nodes, node)
+				}
+				return true
+			})
+			if m.config.Level == proto.RebalanceLevelZone {
+				zoneNodes = append(zoneNodes, nodes...)
+			} else {
+				groups = append(groups, nodes)
+			}
+		}
+		if m.config.Level == proto.RebalanceLevelZone {
+			groups = append(groups, zoneNodes)
+		}
+	}
+	return
+}
+
+// newRebalanceNode takes the usage of the datanode, nil is returned if it is not available to rebalance.
+func newRebalanceNode(dataNode *DataNode) *rebalanceNode {
+	dataNode.RLock()
+	defer dataNode.RUnlock()
+	if !dataNode.isActive || dataNode.ToBeOffline || dataNode.DecommissionStatus != DecommissionInitial ||
+		maintenanceActive(dataNode.MaintenanceExpire) {
+		return nil
+	}
+	node := &rebalanceNode{
+		addr:       dataNode.Addr,
+		total:      dataNode.Total,
+		used:       dataNode.Used,
+		available:  dataNode.AvailableSpace,
+		writable:   !dataNode.RdOnly && dataNode.AvailableSpace > 10*util.GB,
+		disks:      make(map[string]*proto.DiskStat, len(dataNode.DiskStats)),
+		partitions: dataNode.DataPartitionReports,
+	}
+	for _, disk := range dataNode.DiskStats {
+		if disk.Status == proto.Unavailable {
+			continue
+		}
+		d := disk
+		node.disks[disk.DiskPath] = &d
+	}
+	return node
+}
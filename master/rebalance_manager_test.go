@@ -0,0 +1,79 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRebalanceNode(addr string, total uint64, disks map[string]uint64, partitions ...*proto.DataPartitionReport) *rebalanceNode {
+	node := &rebalanceNode{
+		addr:       addr,
+		total:      total,
+		available:  total,
+		writable:   true,
+		disks:      make(map[string]*proto.DiskStat),
+		partitions: partitions,
+	}
+	for path, used := range disks {
+		node.disks[path] = &proto.DiskStat{DiskPath: path, Total: total / uint64(len(disks)), Used: used}
+		node.used += used
+	}
+	node.available -= node.used
+	return node
+}
+
+func TestPlanRebalance(t *testing.T) {
+	gb := uint64(util.GB)
+	full := newTestRebalanceNode("full", 1000*gb, map[string]uint64{"/d1": 450 * gb, "/d2": 350 * gb},
+		&proto.DataPartitionReport{PartitionID: 1, DiskPath: "/d1", Used: 300 * gb},
+		&proto.DataPartitionReport{PartitionID: 2, DiskPath: "/d1", Used: 100 * gb},
+		&proto.DataPartitionReport{PartitionID: 3, DiskPath: "/d1", Used: 50 * gb},
+		&proto.DataPartitionReport{PartitionID: 4, DiskPath: "/d2", Used: 200 * gb},
+		&proto.DataPartitionReport{PartitionID: 5, DiskPath: "/d2", Used: 150 * gb},
+	)
+	empty := newTestRebalanceNode("empty", 1000*gb, map[string]uint64{"/d1": 0, "/d2": 0})
+	balanced := newTestRebalanceNode("balanced", 1000*gb, map[string]uint64{"/d1": 250 * gb, "/d2": 250 * gb})
+
+	movable := func(report *proto.DataPartitionReport, dst string) bool {
+		return report.PartitionID != 2
+	}
+	plans := planRebalance([]*rebalanceNode{full, empty, balanced}, 0.1, 10, movable)
+	// the most used disk is moved first, and the replicas larger than half of the difference are never moved
+	require.Len(t, plans, 2)
+	require.Equal(t, uint64(1), plans[0].PartitionID)
+	require.Equal(t, uint64(3), plans[1].PartitionID)
+	for _, plan := range plans {
+		require.Equal(t, "full", plan.SrcAddr)
+		require.Equal(t, "/d1", plan.SrcDisk)
+		require.Equal(t, "empty", plan.DstAddr)
+	}
+	require.Equal(t, 450*gb, full.used)
+	require.Equal(t, 350*gb, empty.used)
+
+	// no more plans once the usage is within the threshold
+	require.Empty(t, planRebalance([]*rebalanceNode{full, empty, balanced}, 0.1, 10, movable))
+
+	// the read-only datanode is not a target
+	full = newTestRebalanceNode("full", 1000*gb, map[string]uint64{"/d1": 800 * gb},
+		&proto.DataPartitionReport{PartitionID: 1, DiskPath: "/d1", Used: 100 * gb})
+	empty = newTestRebalanceNode("empty", 1000*gb, map[string]uint64{"/d1": 0})
+	empty.writable = false
+	require.Empty(t, planRebalance([]*rebalanceNode{full, empty}, 0.1, 10, movable))
+}
@@ -0,0 +1,199 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// the replication task is assigned to another lcnode if it is not reported for the time
+const replicationTaskTimeout = 3 * time.Minute
+
+type volReplicationTask struct {
+	config proto.VolReplication
+	status *proto.ReplicationTaskResponse
+}
+
+// replicationManager assigns each volume to be replicated to a single lcnode,
+// which keeps replicating the volume and reports the progress by heartbeat.
+type replicationManager struct {
+	sync.RWMutex
+	cluster *Cluster
+	tasks   map[string]*volReplicationTask
+}
+
+func newReplicationManager() *replicationManager {
+	log.LogInfof("action[newReplicationManager] construct")
+	return &replicationManager{
+		tasks: make(map[string]*volReplicationTask),
+	}
+}
+
+// getTask returns the state of the replication task of the volume, nil is returned if it is not assigned.
+func (m *replicationManager) getTask(volName string) *proto.ReplicationTaskResponse {
+	m.RLock()
+	defer m.RUnlock()
+	task, ok := m.tasks[volName]
+	if !ok {
+		return nil
+	}
+	status := *task.status
+	return &status
+}
+
+// checkReplication assigns the volumes not replicated to the idle lcnodes,
+// and stops the replication of the volumes which are disabled or deleted.
+func (m *replicationManager) checkReplication() {
+	c := m.cluster
+	tasks := make([]*proto.AdminTask, 0)
+	m.Lock()
+	vols := c.allVols()
+	for volName, task := range m.tasks {
+		if vol, ok := vols[volName]; ok {
+			if config := vol.getReplication(); config != nil && config.Enable {
+				continue
+			}
+		}
+		if node, err := c.lcNode(task.status.LcNode); err == nil {
+			tasks = append(tasks, node.createReplicationTask(c.masterAddr(), &proto.ReplicationTask{
+				Id:      volName,
+				VolName: volName,
+				Stop:    true,
+			}))
+		}
+		delete(m.tasks, volName)
+		log.LogInfof("action[checkReplication] stop replication of vol(%v) on lcnode(%v)", volName, task.status.LcNode)
+	}
+
+	for volName, vol := range vols {
+		config := vol.getReplication()
+		if config == nil || !config.Enable {
+			continue
+		}
+		task, ok := m.tasks[volName]
+		if ok && task.config == *config && time.Since(*task.status.UpdateTime) < replicationTaskTimeout {
+			continue
+		}
+		node := m.getIdleNode()
+		if node == nil {
+			log.LogWarnf("action[checkReplication] no lcnode to replicate vol(%v)", volName)
+			break
+		}
+		if ok && task.status.LcNode != node.Addr {
+			// the replicator which is not reported might be still running
+			if oldNode, err := c.lcNode(task.status.LcNode); err == nil {
+				tasks = append(tasks, oldNode.createReplicationTask(c.masterAddr(), &proto.ReplicationTask{
+					Id:      volName,
+					VolName: volName,
+					Stop:    true,
+				}))
+			}
+		}
+		tasks = append(tasks, node.createReplicationTask(c.masterAddr(), &proto.ReplicationTask{
+			Id:      volName,
+			VolName: volName,
+			Config:  config,
+		}))
+		now := time.Now()
+		m.tasks[volName] = &volReplicationTask{
+			config: *config,
+			status: &proto.ReplicationTaskResponse{
+				ID:                    volName,
+				LcNode:                node.Addr,
+				StartTime:             &now,
+				UpdateTime:            &now,
+				Status:                proto.TaskStart,
+				ReplicationStatistics: proto.ReplicationStatistics{VolName: volName},
+			},
+		}
+		log.LogInfof("action[checkReplication] replicate vol(%v) to(%v) on lcnode(%v)", volName, config.TargetVolume, node.Addr)
+	}
+	m.Unlock()
+	c.addLcNodeTasks(tasks)
+}
+
+// getIdleNode returns the active lcnode with the fewest replication tasks, the lock must be held.
+func (m *replicationManager) getIdleNode() (node *LcNode) {
+	counts := make(map[string]int)
+	for _, task := range m.tasks {
+		counts[task.status.LcNode]++
+	}
+	m.cluster.lcNodes.Range(func(addr, value interface{}) bool {
+		lcNode := value.(*LcNode)
+		lcNode.RLock()
+		active := lcNode.IsActive
+		lcNode.RUnlock()
+		if active && (node == nil || counts[lcNode.Addr] < counts[node.Addr]) {
+			node = lcNode
+		}
+		return true
+	})
+	return
+}
+
+// updateTask records the progress reported by the lcnode, it returns false if
+// the volume is replicated by another lcnode so that the reported one has to stop.
+func (m *replicationManager) updateTask(nodeAddr string, resp *proto.ReplicationTaskResponse) bool {
+	m.Lock()
+	defer m.Unlock()
+	t := time.Now()
+	resp.UpdateTime = &t
+	resp.LcNode = nodeAddr
+	task, ok := m.tasks[resp.VolName]
+	if !ok {
+		// the master leader is changed, adopt the running replication
+		vol, err := m.cluster.getVol(resp.VolName)
+		if err != nil {
+			return false
+		}
+		config := vol.getReplication()
+		if config == nil || !config.Enable {
+			return false
+		}
+		m.tasks[resp.VolName] = &volReplicationTask{config: *config, status: resp}
+		return true
+	}
+	if task.status.LcNode != nodeAddr {
+		return false
+	}
+	task.status = resp
+	return true
+}
+
+func (m *replicationManager) handleTaskFailed(nodeAddr string, resp *proto.ReplicationTaskResponse) {
+	m.Lock()
+	defer m.Unlock()
+	task, ok := m.tasks[resp.VolName]
+	if !ok || task.status.LcNode != nodeAddr {
+		return
+	}
+	// assign the volume again at the next check
+	delete(m.tasks, resp.VolName)
+	log.LogWarnf("action[handleTaskFailed] replication of vol(%v) failed on lcnode(%v), result(%v)", resp.VolName, nodeAddr, resp.Result)
+}
+
+func (m *replicationManager) removeNode(nodeAddr string) {
+	m.Lock()
+	defer m.Unlock()
+	for volName, task := range m.tasks {
+		if task.status.LcNode == nodeAddr {
+			delete(m.tasks, volName)
+		}
+	}
+}
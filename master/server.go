@@ -130,6 +130,7 @@ type Server struct {
 	reverseProxy    *httputil.ReverseProxy
 	metaReady       bool
 	apiServer       *http.Server
+	signNonces      *signNonces
 }
 
 // NewServer creates a new server
@@ -143,6 +144,7 @@ func (m *Server) Start(cfg *config.Config) (err error) {
 	gConfig = m.config
 	m.leaderInfo = &LeaderInfo{}
 	m.reverseProxy = m.newReverseProxy()
+	m.signNonces = newSignNonces()
 	if err = m.checkConfig(cfg); err != nil {
 		log.LogError(errors.Stack(err))
 		return
@@ -376,6 +378,8 @@ func (m *Server) checkConfig(cfg *config.Config) (err error) {
 
 	enableDirectDeleteVol = cfg.GetBoolWithDefault(cfgEnableDirectDeleteVol, true)
 
+	m.config.enableRBAC = cfg.GetBool(cfgEnableRBAC)
+
 	return
 }
 
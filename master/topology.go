@@ -546,7 +546,7 @@ func (nsgm *DomainManager) getHostFromNodeSetGrpSpecific(domainGrpManager *Domai
 				}
 
 				if createType == TypeDataPartition {
-					if host, peer, err = ns.getAvailDataNodeHosts(nil, needNum); err != nil {
+					if host, peer, err = ns.getAvailDataNodeHosts(nil, needNum, proto.MediaTypeUnspecified); err != nil {
 						log.LogErrorf("action[getHostFromNodeSetGrpSpecific] ns[%v] zone[%v] TypeDataPartition err[%v]", ns.ID, ns.zoneName, err)
 						// nsg.status = dataNodesUnAvailable
 						continue
@@ -650,7 +650,7 @@ func (nsgm *DomainManager) getHostFromNodeSetGrp(domainId uint64, replicaNum uin
 					log.LogWarnf("action[getHostFromNodeSetGrp] ns[%v] zone[%v] dataNodesUnAvailable", ns.ID, ns.zoneName)
 					continue
 				}
-				if host, peer, err = ns.getAvailDataNodeHosts(hosts, 1); err != nil {
+				if host, peer, err = ns.getAvailDataNodeHosts(hosts, 1, proto.MediaTypeUnspecified); err != nil {
 					log.LogWarnf("action[getHostFromNodeSetGrp] ns[%v] zone[%v] TypeDataPartition err[%v]", ns.ID, ns.zoneName, err)
 					// nsg.status = dataNodesUnAvailable
 					continue
@@ -1042,11 +1042,13 @@ func (ns *nodeSet) deleteMetaNode(metaNode *MetaNode) {
 	ns.metaNodes.Delete(metaNode.Addr)
 }
 
-func (ns *nodeSet) canWriteForDataNode(replicaNum int) bool {
+func (ns *nodeSet) canWriteForDataNode(replicaNum int, mediaType uint32) bool {
 	var count int
+	domains := make(failureDomains)
 	ns.dataNodes.Range(func(key, value interface{}) bool {
 		node := value.(*DataNode)
-		if node.isWriteAble() && node.dpCntInLimit() {
+		if node.isWriteAble() && node.dpCntInLimit() && node.getMediaAvailableSpace(mediaType) > 10*util.GB && !domains.taken(node) {
+			domains.take(node)
 			count++
 		}
 		if count >= replicaNum {
@@ -1054,16 +1056,18 @@ func (ns *nodeSet) canWriteForDataNode(replicaNum int) bool {
 		}
 		return true
 	})
-	log.LogInfof("canWriteForDataNode zone[%v], ns[%v],count[%v], replicaNum[%v]",
-		ns.zoneName, ns.ID, count, replicaNum)
+	log.LogInfof("canWriteForDataNode zone[%v], ns[%v],count[%v], replicaNum[%v], mediaType[%v]",
+		ns.zoneName, ns.ID, count, replicaNum, proto.MediaTypeString(mediaType))
 	return count >= replicaNum
 }
 
 func (ns *nodeSet) canWriteForMetaNode(replicaNum int) bool {
 	var count int
+	domains := make(failureDomains)
 	ns.metaNodes.Range(func(key, value interface{}) bool {
 		node := value.(*MetaNode)
-		if node.isWritable() {
+		if node.isWritable() && !domains.taken(node) {
+			domains.take(node)
 			count++
 		}
 		if count >= replicaNum {
@@ -1353,7 +1357,7 @@ func (t *topology) allocZonesForMetaNode(zoneNum, replicaNum int, excludeZone []
 	return
 }
 
-func (t *topology) allocZonesForDataNode(zoneNum, replicaNum int, excludeZone []string) (zones []*Zone, err error) {
+func (t *topology) allocZonesForDataNode(zoneNum, replicaNum int, excludeZone []string, mediaType uint32) (zones []*Zone, err error) {
 	// domain enabled and have old zones to be used
 	if len(t.domainExcludeZones) > 0 {
 		zones = t.getDomainExcludeZones()
@@ -1387,7 +1391,7 @@ func (t *topology) allocZonesForDataNode(zoneNum, replicaNum int, excludeZone []
 		if contains(excludeZone, zone.name) {
 			continue
 		}
-		if zone.canWriteForDataNode(uint8(demandWriteNodes)) {
+		if zone.canWriteForDataNode(uint8(demandWriteNodes), mediaType) {
 			candidateZones = append(candidateZones, zone)
 		}
 		if len(candidateZones) >= zoneNum {
@@ -1694,7 +1698,7 @@ func (zone *Zone) deleteMetaNode(metaNode *MetaNode) (err error) {
 	return
 }
 
-func (zone *Zone) allocNodeSetForDataNode(excludeNodeSets []uint64, replicaNum uint8) (ns *nodeSet, err error) {
+func (zone *Zone) allocNodeSetForDataNode(excludeNodeSets []uint64, replicaNum uint8, mediaType uint32) (ns *nodeSet, err error) {
 	nset := zone.getAllNodeSet()
 	if nset == nil {
 		return nil, errors.NewError(proto.ErrNoNodeSetToCreateDataPartition)
@@ -1706,7 +1710,7 @@ func (zone *Zone) allocNodeSetForDataNode(excludeNodeSets []uint64, replicaNum u
 	zone.dataNodesetSelectorLock.RLock()
 	defer zone.dataNodesetSelectorLock.RUnlock()
 
-	ns, err = zone.dataNodesetSelector.Select(nset, excludeNodeSets, replicaNum)
+	ns, err = zone.dataNodesetSelector.Select(nset, excludeNodeSets, replicaNum, mediaType)
 
 	if err != nil {
 		log.LogErrorf("action[allocNodeSetForDataNode],nset len[%v],excludeNodeSets[%v],rNum[%v] err:%v",
@@ -1727,7 +1731,7 @@ func (zone *Zone) allocNodeSetForMetaNode(excludeNodeSets []uint64, replicaNum u
 	// we need a read lock to block the modify of nodeset selector
 	zone.metaNodesetSelectorLock.RLock()
 	defer zone.metaNodesetSelectorLock.RUnlock()
-	ns, err = zone.metaNodesetSelector.Select(nset, excludeNodeSets, replicaNum)
+	ns, err = zone.metaNodesetSelector.Select(nset, excludeNodeSets, replicaNum, proto.MediaTypeUnspecified)
 
 	if err != nil {
 		log.LogError(fmt.Sprintf("action[allocNodeSetForMetaNode],zone[%v],excludeNodeSets[%v],rNum[%v],err:%v",
@@ -1737,7 +1741,7 @@ func (zone *Zone) allocNodeSetForMetaNode(excludeNodeSets []uint64, replicaNum u
 	return ns, nil
 }
 
-func (zone *Zone) canWriteForDataNode(replicaNum uint8) (can bool) {
+func (zone *Zone) canWriteForDataNode(replicaNum uint8, mediaType uint32) (can bool) {
 	zone.RLock()
 	defer zone.RUnlock()
 	var leastAlive uint8
@@ -1746,7 +1750,7 @@ func (zone *Zone) canWriteForDataNode(replicaNum uint8) (can bool) {
 		if !dataNode.dpCntInLimit() {
 			return true
 		}
-		if dataNode.isActive && dataNode.isWriteAbleWithSize(30*util.GB) {
+		if dataNode.isActive && dataNode.isWriteAbleWithSize(30*util.GB) && dataNode.getMediaAvailableSpace(mediaType) > 30*util.GB {
 			leastAlive++
 		}
 		if leastAlive >= replicaNum {
@@ -1876,7 +1880,7 @@ func (zone *Zone) getDataNodeMaxTotal() (maxTotal uint64) {
 	return
 }
 
-func (zone *Zone) getAvailNodeHosts(nodeType uint32, excludeNodeSets []uint64, excludeHosts []string, replicaNum int) (newHosts []string, peers []proto.Peer, err error) {
+func (zone *Zone) getAvailNodeHosts(nodeType uint32, excludeNodeSets []uint64, excludeHosts []string, replicaNum int, mediaType uint32) (newHosts []string, peers []proto.Peer, err error) {
 	if replicaNum == 0 {
 		return
 	}
@@ -1884,11 +1888,11 @@ func (zone *Zone) getAvailNodeHosts(nodeType uint32, excludeNodeSets []uint64, e
 	log.LogDebugf("[x] get node host, zone(%s), nodeType(%d)", zone.name, nodeType)
 
 	if nodeType == TypeDataPartition {
-		ns, err := zone.allocNodeSetForDataNode(excludeNodeSets, uint8(replicaNum))
+		ns, err := zone.allocNodeSetForDataNode(excludeNodeSets, uint8(replicaNum), mediaType)
 		if err != nil {
 			return nil, nil, errors.Trace(err, "zone[%v] alloc node set,replicaNum[%v]", zone.name, replicaNum)
 		}
-		return ns.getAvailDataNodeHosts(excludeHosts, replicaNum)
+		return ns.getAvailDataNodeHosts(excludeHosts, replicaNum, mediaType)
 	}
 
 	ns, err := zone.allocNodeSetForMetaNode(excludeNodeSets, uint8(replicaNum))
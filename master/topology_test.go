@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util"
 )
 
@@ -41,7 +42,7 @@ func TestSingleZone(t *testing.T) {
 	// single zone exclude,if it is a single zone excludeZones don't take effect
 	excludeZones := make([]string, 0)
 	excludeZones = append(excludeZones, zoneName)
-	zones, err := topo.allocZonesForDataNode(replicaNum, replicaNum, excludeZones)
+	zones, err := topo.allocZonesForDataNode(replicaNum, replicaNum, excludeZones, proto.MediaTypeUnspecified)
 	if err != nil {
 		t.Error(err)
 		return
@@ -52,12 +53,12 @@ func TestSingleZone(t *testing.T) {
 	}
 
 	// single zone normal
-	zones, err = topo.allocZonesForDataNode(replicaNum, replicaNum, nil)
+	zones, err = topo.allocZonesForDataNode(replicaNum, replicaNum, nil, proto.MediaTypeUnspecified)
 	if err != nil {
 		t.Error(err)
 		return
 	}
-	newHosts, _, err := zones[0].getAvailNodeHosts(TypeDataPartition, nil, nil, replicaNum)
+	newHosts, _, err := zones[0].getAvailNodeHosts(TypeDataPartition, nil, nil, replicaNum, proto.MediaTypeUnspecified)
 	if err != nil {
 		t.Error(err)
 		return
@@ -104,7 +105,7 @@ func TestAllocZones(t *testing.T) {
 	}
 	// only pass replica num
 	replicaNum := 2
-	zones, err := topo.allocZonesForDataNode(replicaNum, replicaNum, nil)
+	zones, err := topo.allocZonesForDataNode(replicaNum, replicaNum, nil, proto.MediaTypeUnspecified)
 	if err != nil {
 		t.Error(err)
 		return
@@ -120,14 +121,14 @@ func TestAllocZones(t *testing.T) {
 	cluster.cfg = newClusterConfig()
 
 	// don't cross zone
-	hosts, _, err := cluster.getHostFromNormalZone(TypeDataPartition, nil, nil, nil, replicaNum, 1, "")
+	hosts, _, err := cluster.getHostFromNormalZone(TypeDataPartition, nil, nil, nil, replicaNum, 1, "", proto.MediaTypeUnspecified)
 	if err != nil {
 		t.Error(err)
 		return
 	}
 
 	// cross zone
-	hosts, _, err = cluster.getHostFromNormalZone(TypeDataPartition, nil, nil, nil, replicaNum, 2, "")
+	hosts, _, err = cluster.getHostFromNormalZone(TypeDataPartition, nil, nil, nil, replicaNum, 2, "", proto.MediaTypeUnspecified)
 	if err != nil {
 		t.Error(err)
 		return
@@ -138,7 +139,7 @@ func TestAllocZones(t *testing.T) {
 	excludeZones := make([]string, 0)
 	excludeZones = append(excludeZones, zoneName3)
 
-	zones, err = topo.allocZonesForDataNode(2, replicaNum, excludeZones)
+	zones, err = topo.allocZonesForDataNode(2, replicaNum, excludeZones, proto.MediaTypeUnspecified)
 	if err != nil {
 		t.Logf("allocZonesForDataNode failed,err[%v]", err)
 	}
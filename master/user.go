@@ -54,6 +54,10 @@ func (u *User) createKey(param *proto.UserCreateParam) (userInfo *proto.UserInfo
 		err = proto.ErrInvalidUserType
 		return
 	}
+	if !param.Role.Valid() {
+		err = proto.ErrInvalidRole
+		return
+	}
 
 	userID := param.ID
 	password := param.Password
@@ -97,7 +101,7 @@ func (u *User) createKey(param *proto.UserCreateParam) (userInfo *proto.UserInfo
 	userPolicy = proto.NewUserPolicy()
 	userInfo = &proto.UserInfo{
 		UserID: userID, AccessKey: accessKey, SecretKey: secretKey, Policy: userPolicy,
-		UserType: userType, CreateTime: time.Unix(time.Now().Unix(), 0).Format(proto.TimeFormat), Description: description,
+		UserType: userType, Role: param.Role, CreateTime: time.Unix(time.Now().Unix(), 0).Format(proto.TimeFormat), Description: description,
 	}
 	AKUser = &proto.AKUser{AccessKey: accessKey, UserID: userID, Password: encodingPassword(password)}
 	if err = u.syncAddUserInfo(userInfo); err != nil {
@@ -211,6 +215,10 @@ func (u *User) updateKey(param *proto.UserUpdateParam) (userInfo *proto.UserInfo
 	if param.Description != "" {
 		describeMark = 1
 	}
+	if param.Role != nil && !param.Role.Valid() {
+		err = proto.ErrInvalidRole
+		return
+	}
 
 	var akUserBef *proto.AKUser
 	var akUserAft *proto.AKUser
@@ -233,6 +241,9 @@ func (u *User) updateKey(param *proto.UserUpdateParam) (userInfo *proto.UserInfo
 	if describeMark == 1 {
 		userInfo.Description = param.Description
 	}
+	if param.Role != nil {
+		userInfo.Role = *param.Role
+	}
 
 	if len(strings.TrimSpace(param.Password)) != 0 {
 		akUserBef.Password = encodingPassword(param.Password)
@@ -410,6 +421,63 @@ func (u *User) deleteVolPolicy(volName string) (err error) {
 	return
 }
 
+// renameVolPolicy renames the volume in the policies of its users and in the index of them. The
+// changes are committed together with the commands of the caller by commit.
+func (u *User) renameVolPolicy(volName, newName string, cmds map[string]*RaftCmd,
+	commit func(map[string]*RaftCmd) error) (err error) {
+	u.volUserMutex.Lock()
+	defer u.volUserMutex.Unlock()
+	value, exist := u.volUser.Load(volName)
+	if !exist {
+		return commit(cmds)
+	}
+	volUser := value.(*proto.VolUser)
+	volUser.Mu.Lock()
+	defer volUser.Mu.Unlock()
+
+	users := make([]*proto.UserInfo, 0, len(volUser.UserIDs))
+	defer func() {
+		for _, userInfo := range users {
+			if err != nil {
+				userInfo.Policy.RenameVol(newName, volName)
+			}
+			userInfo.Mu.Unlock()
+		}
+	}()
+	for _, userID := range volUser.UserIDs {
+		var userInfo *proto.UserInfo
+		if userInfo, err = u.getUserInfo(userID); err != nil {
+			if err == proto.ErrUserNotExists {
+				continue
+			}
+			return
+		}
+		userInfo.Mu.Lock()
+		users = append(users, userInfo)
+		userInfo.Policy.RenameVol(volName, newName)
+		var cmd *RaftCmd
+		if cmd, err = buildUserInfoRaftCmd(opSyncUpdateUserInfo, userInfo); err != nil {
+			return
+		}
+		cmds[cmd.K] = cmd
+	}
+	renamed := &proto.VolUser{Vol: newName, UserIDs: append([]string{}, volUser.UserIDs...)}
+	for op, v := range map[uint32]*proto.VolUser{opSyncDeleteVolUser: volUser, opSyncAddVolUser: renamed} {
+		var cmd *RaftCmd
+		if cmd, err = buildVolUserRaftCmd(op, v); err != nil {
+			return
+		}
+		cmds[cmd.K] = cmd
+	}
+	if err = commit(cmds); err != nil {
+		return
+	}
+	u.volUser.Delete(volName)
+	u.volUser.Store(newName, renamed)
+	log.LogInfof("action[renameVolPolicy], volName: %v, newName: %v, users: %v", volName, newName, len(users))
+	return
+}
+
 func (u *User) transferVol(params *proto.UserTransferVolParam) (targetUserInfo *proto.UserInfo, err error) {
 	var userInfo *proto.UserInfo
 	userInfo, err = u.getUserInfo(params.UserSrc)
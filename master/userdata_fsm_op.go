@@ -49,16 +49,21 @@ func (u *User) syncUpdateUserInfo(userInfo *proto.UserInfo) (err error) {
 }
 
 func (u *User) syncPutUserInfo(opType uint32, userInfo *proto.UserInfo) (err error) {
-	raftCmd := new(RaftCmd)
-	raftCmd.Op = opType
-	raftCmd.K = userPrefix + userInfo.UserID
-	raftCmd.V, err = json.Marshal(userInfo)
+	raftCmd, err := buildUserInfoRaftCmd(opType, userInfo)
 	if err != nil {
 		return errors.New(err.Error())
 	}
 	return u.submit(raftCmd)
 }
 
+func buildUserInfoRaftCmd(opType uint32, userInfo *proto.UserInfo) (raftCmd *RaftCmd, err error) {
+	raftCmd = new(RaftCmd)
+	raftCmd.Op = opType
+	raftCmd.K = userPrefix + userInfo.UserID
+	raftCmd.V, err = json.Marshal(userInfo)
+	return
+}
+
 // key = #user#userid, value = userInfo
 func (u *User) syncAddAKUser(akUser *proto.AKUser) (err error) {
 	return u.syncPutAKUser(opSyncAddAKUser, akUser)
@@ -93,16 +98,21 @@ func (u *User) syncUpdateVolUser(volUser *proto.VolUser) (err error) {
 }
 
 func (u *User) syncPutVolUser(opType uint32, volUser *proto.VolUser) (err error) {
-	userInfo := new(RaftCmd)
-	userInfo.Op = opType
-	userInfo.K = volUserPrefix + volUser.Vol
-	userInfo.V, err = json.Marshal(volUser)
+	userInfo, err := buildVolUserRaftCmd(opType, volUser)
 	if err != nil {
 		return errors.New(err.Error())
 	}
 	return u.submit(userInfo)
 }
 
+func buildVolUserRaftCmd(opType uint32, volUser *proto.VolUser) (raftCmd *RaftCmd, err error) {
+	raftCmd = new(RaftCmd)
+	raftCmd.Op = opType
+	raftCmd.K = volUserPrefix + volUser.Vol
+	raftCmd.V, err = json.Marshal(volUser)
+	return
+}
+
 func (u *User) loadUserStore() (err error) {
 	result, err := u.fsm.store.SeekForPrefix([]byte(userPrefix))
 	if err != nil {
@@ -45,6 +45,10 @@ type VolVarargs struct {
 	enablePosixAcl          bool
 	dpReadOnlyWhenVolFull   bool
 	enableQuota             bool
+	compression             string
+	mediaType               uint32
+	mediaFallback           bool
+	trashRetention          int64 // min
 	enableTransaction       proto.TxOpMask
 	txTimeout               int64
 	txConflictRetryNum      int64
@@ -109,11 +113,23 @@ type Vol struct {
 	uidSpaceManager         *UidSpaceManager
 	volLock                 sync.RWMutex
 	quotaManager            *MasterQuotaManager
+	userQuotaManager        *MasterUserQuotaManager
 	enableQuota             bool
+	compression             string // block compression of the datanode extents
+	mediaType               uint32 // preferred media class of the data partitions
+	mediaFallback           bool   // place the data partitions on the other media if the preferred one is full
+	trashRetention          int64  // minutes the unlinked files are kept in the trash, 0 disables the trash
+	cloneSrc                string // the volume this one is cloned from, whose extents are shared
+	cloneVerSeq             uint64 // the version of cloneSrc this one is cloned from
+	promotePath             string // the directory of cloneSrc this one is promoted from
+	formerName              string // the name before the volume is renamed, an alias for the clients mounted
+	formerNameExpire        int64  // the unix time the former name stops being an alias
+	metaStoreType           proto.MetaStoreType
 	VersionMgr              *VolVersionManager
 	Forbidden               bool
 	mpsLock                 *mpsLockManager
 	EnableAuditLog          bool
+	replication             *proto.VolReplication // replicate to a volume of another cluster
 	preloadCapacity         uint64
 	authKey                 string
 	DeleteExecTime          time.Time
@@ -148,6 +164,17 @@ func newVol(vv volValue) (vol *Vol) {
 	vol.domainId = vv.DomainId
 	vol.enablePosixAcl = vv.EnablePosixAcl
 	vol.enableQuota = vv.EnableQuota
+	vol.compression = vv.Compression
+	vol.mediaType = vv.MediaType
+	vol.mediaFallback = vv.MediaFallback
+	vol.trashRetention = vv.TrashRetention
+	vol.metaStoreType = vv.MetaStoreType
+	vol.cloneSrc = vv.CloneSrc
+	vol.cloneVerSeq = vv.CloneVerSeq
+	vol.promotePath = vv.PromotePath
+	vol.formerName = vv.FormerName
+	vol.formerNameExpire = vv.FormerNameExpire
+	vol.replication = vv.Replication
 	vol.enableTransaction = vv.EnableTransaction
 	vol.txTimeout = vv.TxTimeout
 	vol.txConflictRetryNum = vv.TxConflictRetryNum
@@ -424,6 +451,12 @@ func (vol *Vol) addMetaPartition(mp *MetaPartition) {
 	vol.MetaPartitions[mp.PartitionID] = mp
 }
 
+func (vol *Vol) deleteMetaPartition(partitionID uint64) {
+	vol.mpsLock.Lock()
+	defer vol.mpsLock.UnLock()
+	delete(vol.MetaPartitions, partitionID)
+}
+
 func (vol *Vol) metaPartition(partitionID uint64) (mp *MetaPartition, err error) {
 	vol.mpsLock.RLock()
 	defer vol.mpsLock.RUnlock()
@@ -434,12 +467,15 @@ func (vol *Vol) metaPartition(partitionID uint64) (mp *MetaPartition, err error)
 	return
 }
 
+// maxPartitionID returns the id of the meta partition holding the highest inode range. It is not
+// always the largest id since the partitions split in the middle of the range get larger ids.
 func (vol *Vol) maxPartitionID() (maxPartitionID uint64) {
 	vol.mpsLock.RLock()
 	defer vol.mpsLock.RUnlock()
-	for id := range vol.MetaPartitions {
-		if id > maxPartitionID {
-			maxPartitionID = id
+	var maxStart uint64
+	for id, mp := range vol.MetaPartitions {
+		if maxPartitionID == 0 || mp.Start > maxStart {
+			maxPartitionID, maxStart = id, mp.Start
 		}
 	}
 	return
@@ -761,6 +797,7 @@ func (vol *Vol) checkMetaPartitions(c *Cluster) {
 	}
 	c.addMetaNodeTasks(tasks)
 	vol.checkSplitMetaPartition(c, metaPartitionInodeIdStep)
+	vol.checkMetaPartitionLoad(c)
 }
 
 func (vol *Vol) checkSplitMetaPartition(c *Cluster, metaPartitionInodeStep uint64) {
@@ -1108,6 +1145,7 @@ func (vol *Vol) updateViewCache(c *Cluster) {
 	// dpResps := vol.dataPartitions.getDataPartitionsView(0)
 	// view.DataPartitions = dpResps
 	view.DomainOn = vol.domainOn
+	view.TrashRetention = vol.trashRetention
 	viewReply := newSuccessHTTPReply(view)
 	body, err := json.Marshal(viewReply)
 	if err != nil {
@@ -1478,7 +1516,8 @@ func (vol *Vol) doCreateMetaPartition(c *Cluster, start, end uint64) (mp *MetaPa
 		var excludeZone []string
 		zoneNum := c.decideZoneNum(vol.crossZone)
 
-		if hosts, peers, err = c.getHostFromNormalZone(TypeMetaPartition, excludeZone, nil, nil, int(vol.mpReplicaNum), zoneNum, vol.zoneName); err != nil {
+		if hosts, peers, err = c.getHostFromNormalZone(TypeMetaPartition, excludeZone, nil, nil, int(vol.mpReplicaNum), zoneNum, vol.zoneName,
+			proto.MediaTypeUnspecified); err != nil {
 			log.LogErrorf("action[doCreateMetaPartition] getHostFromNormalZone err[%v]", err)
 			return nil, errors.NewError(err)
 		}
@@ -1500,7 +1539,7 @@ func (vol *Vol) doCreateMetaPartition(c *Cluster, start, end uint64) (mp *MetaPa
 			defer func() {
 				wg.Done()
 			}()
-			if err = c.syncCreateMetaPartitionToMetaNode(host, mp); err != nil {
+			if err = c.syncCreateMetaPartitionToMetaNode(host, mp, vol.metaStoreType); err != nil {
 				errChannel <- err
 				return
 			}
@@ -1541,6 +1580,22 @@ func (vol *Vol) doCreateMetaPartition(c *Cluster, start, end uint64) (mp *MetaPa
 	return
 }
 
+func (vol *Vol) getReplication() *proto.VolReplication {
+	vol.volLock.RLock()
+	defer vol.volLock.RUnlock()
+	if vol.replication == nil {
+		return nil
+	}
+	replication := *vol.replication
+	return &replication
+}
+
+func (vol *Vol) setReplication(replication *proto.VolReplication) {
+	vol.volLock.Lock()
+	defer vol.volLock.Unlock()
+	vol.replication = replication
+}
+
 func setVolFromArgs(args *VolVarargs, vol *Vol) {
 	vol.zoneName = args.zoneName
 	vol.Capacity = args.capacity
@@ -1550,6 +1605,10 @@ func setVolFromArgs(args *VolVarargs, vol *Vol) {
 	vol.enablePosixAcl = args.enablePosixAcl
 	vol.DpReadOnlyWhenVolFull = args.dpReadOnlyWhenVolFull
 	vol.enableQuota = args.enableQuota
+	vol.compression = args.compression
+	vol.mediaType = args.mediaType
+	vol.mediaFallback = args.mediaFallback
+	vol.trashRetention = args.trashRetention
 	vol.enableTransaction = args.enableTransaction
 	vol.txTimeout = args.txTimeout
 	vol.txConflictRetryNum = args.txConflictRetryNum
@@ -1600,6 +1659,10 @@ func getVolVarargs(vol *Vol) *VolVarargs {
 		dpSelectorParm:          vol.dpSelectorParm,
 		enablePosixAcl:          vol.enablePosixAcl,
 		enableQuota:             vol.enableQuota,
+		compression:             vol.compression,
+		mediaType:               vol.mediaType,
+		mediaFallback:           vol.mediaFallback,
+		trashRetention:          vol.trashRetention,
 		dpReplicaNum:            vol.dpReplicaNum,
 		enableTransaction:       vol.enableTransaction,
 		txTimeout:               vol.txTimeout,
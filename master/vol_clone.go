@@ -0,0 +1,279 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// cloneVol creates the volume name as a writable clone of the version verSeq of the volume src.
+// The meta partitions of the clone are built by the metanodes of the source partitions, and the
+// data extents are shared with the source until they are overwritten.
+func (c *Cluster) cloneVol(src *Vol, verSeq uint64, name string) (vol *Vol, err error) {
+	req, err := newCloneVolReq(src, verSeq, name)
+	if err != nil {
+		return
+	}
+	return c.createVol(req)
+}
+
+// promoteVol creates the volume name from the directory dirPath of the volume src. The volume is
+// cloned from a version taken at once, the clients move the subtree of the directory to the root
+// of the new volume and remove the rest of it, see cli vol promote.
+func (c *Cluster) promoteVol(src *Vol, dirPath string, name string) (vol *Vol, err error) {
+	if err = checkCloneSrc(src); err != nil {
+		return
+	}
+	if !path.IsAbs(dirPath) || path.Clean(dirPath) == "/" {
+		return nil, fmt.Errorf("path [%v] should be an absolute path of a directory other than the root", dirPath)
+	}
+	if _, err = c.getVol(name); err == nil {
+		return nil, fmt.Errorf("vol [%v] already exists", name)
+	}
+
+	ver, err := src.VersionMgr.createVer2PhaseTask(c, uint64(time.Now().UnixMicro()), proto.CreateVersion, false)
+	if err != nil {
+		return
+	}
+	if ver == nil {
+		return nil, fmt.Errorf("version of vol [%v] to promote [%v] is not committed", src.Name, dirPath)
+	}
+	req, err := newCloneVolReq(src, ver.Ver, name)
+	if err != nil {
+		return
+	}
+	req.promotePath = path.Clean(dirPath)
+	return c.createVol(req)
+}
+
+func checkCloneSrc(src *Vol) error {
+	if !proto.IsHot(src.VolType) {
+		return fmt.Errorf("vol [%v] is not a hot volume", src.Name)
+	}
+	if src.Status != proto.VolStatusNormal {
+		return fmt.Errorf("vol [%v] is being deleted", src.Name)
+	}
+	return nil
+}
+
+// newCloneVolReq builds the request to create the volume name with the settings of the volume src.
+func newCloneVolReq(src *Vol, verSeq uint64, name string) (req *createVolReq, err error) {
+	if err = checkCloneSrc(src); err != nil {
+		return
+	}
+	if _, err = src.VersionMgr.getCloneReadSeq(verSeq); err != nil {
+		return
+	}
+
+	req = &createVolReq{
+		name:                    name,
+		owner:                   src.Owner,
+		dpSize:                  int(src.dataPartitionSize / util.GB),
+		dpReplicaNum:            src.dpReplicaNum,
+		capacity:                int(src.Capacity),
+		followerRead:            src.FollowerRead,
+		authenticate:            src.authenticate,
+		crossZone:               src.crossZone,
+		normalZonesFirst:        src.defaultPriority,
+		domainId:                src.domainId,
+		zoneName:                src.zoneName,
+		description:             src.description,
+		volType:                 src.VolType,
+		enablePosixAcl:          src.enablePosixAcl,
+		DpReadOnlyWhenVolFull:   src.DpReadOnlyWhenVolFull,
+		enableTransaction:       src.enableTransaction,
+		enableQuota:             src.enableQuota,
+		compression:             src.compression,
+		mediaType:               src.mediaType,
+		mediaFallback:           src.mediaFallback,
+		txTimeout:               src.txTimeout,
+		txConflictRetryNum:      src.txConflictRetryNum,
+		txConflictRetryInterval: src.txConflictRetryInterval,
+		qosLimitArgs:            &qosArgs{},
+		cloneSrc:                src,
+		cloneVerSeq:             verSeq,
+	}
+	return
+}
+
+// getCloneReadSeq returns the sequence to read the items of the version verSeq at. Only a
+// committed version which is not the current one of the volume can be cloned.
+func (verMgr *VolVersionManager) getCloneReadSeq(verSeq uint64) (readSeq uint64, err error) {
+	verMgr.RLock()
+	defer verMgr.RUnlock()
+
+	idx, found := verMgr.getLayInfo(verSeq)
+	if !found {
+		return 0, fmt.Errorf("version [%v] of vol [%v] not found", verSeq, verMgr.vol.Name)
+	}
+	if idx == len(verMgr.multiVersionList)-1 {
+		return 0, fmt.Errorf("version [%v] is the current version of vol [%v], create a snapshot to clone it", verSeq, verMgr.vol.Name)
+	}
+	if status := verMgr.multiVersionList[idx].Status; status != proto.VersionNormal {
+		return 0, fmt.Errorf("version [%v] of vol [%v] is in status [%v]", verSeq, verMgr.vol.Name, status)
+	}
+	return verMgr.multiVersionList[idx+1].Ver - 1, nil
+}
+
+// initCloneMetaPartitions creates a meta partition of the clone for every partition of the
+// source, on the same metanodes, since every replica clones the items of its local partition.
+func (vol *Vol) initCloneMetaPartitions(c *Cluster, src *Vol, verSeq uint64) (err error) {
+	readSeq, err := src.VersionMgr.getCloneReadSeq(verSeq)
+	if err != nil {
+		return
+	}
+
+	src.createMpMutex.Lock()
+	defer src.createMpMutex.Unlock()
+	vol.createMpMutex.Lock()
+	defer vol.createMpMutex.Unlock()
+
+	srcMps := make([]*MetaPartition, 0)
+	for _, mp := range src.cloneMetaPartitionMap() {
+		if mp.mergeTo != 0 {
+			return fmt.Errorf("meta partition [%v] of vol [%v] is being merged", mp.PartitionID, src.Name)
+		}
+		srcMps = append(srcMps, mp)
+	}
+	sort.Slice(srcMps, func(i, j int) bool { return srcMps[i].Start < srcMps[j].Start })
+
+	for _, srcMp := range srcMps {
+		var mp *MetaPartition
+		if mp, err = vol.doCreateCloneMetaPartition(c, srcMp, readSeq); err != nil {
+			log.LogErrorf("action[initCloneMetaPartitions] vol[%v] clone meta partition[%v] err[%v]",
+				vol.Name, srcMp.PartitionID, err)
+			return
+		}
+		if err = c.syncAddMetaPartition(mp); err != nil {
+			return errors.NewError(err)
+		}
+		vol.addMetaPartition(mp)
+	}
+	log.LogInfof("action[initCloneMetaPartitions] vol[%v] cloned [%v] meta partitions of vol[%v] at version[%v]",
+		vol.Name, len(srcMps), src.Name, verSeq)
+	return
+}
+
+func (vol *Vol) doCreateCloneMetaPartition(c *Cluster, srcMp *MetaPartition, readSeq uint64) (mp *MetaPartition, err error) {
+	var (
+		partitionID uint64
+		wg          sync.WaitGroup
+	)
+	if partitionID, err = c.idAlloc.allocateMetaPartitionID(); err != nil {
+		return nil, errors.NewError(err)
+	}
+
+	srcMp.RLock()
+	hosts := append([]string{}, srcMp.Hosts...)
+	peers := append([]proto.Peer{}, srcMp.Peers...)
+	srcMp.RUnlock()
+
+	mp = newMetaPartition(partitionID, srcMp.Start, srcMp.End, vol.mpReplicaNum, vol.Name, vol.ID, vol.VersionMgr.getLatestVer())
+	mp.setHosts(hosts)
+	mp.setPeers(peers)
+
+	errChannel := make(chan error, len(hosts))
+	for _, task := range mp.buildNewMetaPartitionTasks(nil, peers, vol.Name, vol.metaStoreType) {
+		req := *task.Request.(*proto.CreateMetaPartitionRequest)
+		req.CloneFrom = srcMp.PartitionID
+		req.CloneVerSeq = readSeq
+		task.Request = &req
+
+		wg.Add(1)
+		go func(task *proto.AdminTask) {
+			defer wg.Done()
+			metaNode, err := c.metaNode(task.OperatorAddr)
+			if err != nil {
+				errChannel <- err
+				return
+			}
+			if _, err = metaNode.Sender.syncSendAdminTask(task); err != nil {
+				errChannel <- err
+				return
+			}
+			mp.Lock()
+			defer mp.Unlock()
+			if err = mp.afterCreation(task.OperatorAddr, c); err != nil {
+				errChannel <- err
+			}
+		}(task)
+	}
+	wg.Wait()
+
+	select {
+	case err = <-errChannel:
+		for _, host := range hosts {
+			mr, e := mp.getMetaReplica(host)
+			if e != nil {
+				continue
+			}
+			c.addMetaNodeTasks([]*proto.AdminTask{mr.createTaskToDeleteReplica(mp.PartitionID)})
+		}
+		return nil, errors.NewError(err)
+	default:
+		mp.Status = proto.ReadWrite
+	}
+	log.LogInfof("action[doCreateCloneMetaPartition] success,volName[%v],partition[%v] cloned from[%v],start[%v],end[%v]",
+		vol.Name, partitionID, srcMp.PartitionID, mp.Start, mp.End)
+	return
+}
+
+// getVolClones returns the volumes cloned from the volume name, including the ones being deleted
+// since their extents are still shared.
+func (c *Cluster) getVolClones(name string) (clones []*Vol) {
+	for _, vol := range c.copyVols() {
+		if vol.cloneSrc == name {
+			clones = append(clones, vol)
+		}
+	}
+	return
+}
+
+// isClonedVer checks if any clone is created from the version verSeq of the volume name, such a
+// version keeps the extents of the clones and can not be deleted.
+func (c *Cluster) isClonedVer(name string, verSeq uint64) bool {
+	for _, clone := range c.getVolClones(name) {
+		if clone.cloneVerSeq == verSeq {
+			return true
+		}
+	}
+	return false
+}
+
+// getCloneSourceDataPartition looks up a data partition shared by a clone from its source
+// volumes. The partition is read only to the clone.
+func (c *Cluster) getCloneSourceDataPartition(vol *Vol, partitionID uint64) (dp *DataPartition, err error) {
+	err = proto.ErrDataPartitionNotExists
+	for src := vol.cloneSrc; src != ""; {
+		var srcVol *Vol
+		if srcVol, err = c.getVol(src); err != nil {
+			return
+		}
+		if dp, err = srcVol.getDataPartitionByID(partitionID); err == nil {
+			return
+		}
+		src = srcVol.cloneSrc
+	}
+	return
+}
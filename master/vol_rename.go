@@ -0,0 +1,196 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// renameVol renames the volume name to newName online. The volume, its lifecycle configuration,
+// the volumes cloned from it and the policies of its users are renamed by a single raft command.
+// The partitions are persisted by the id of the volume and learn the new name in memory, the
+// meta and data nodes are told the new name by the heartbeats and the nodes reporting the former
+// name are resolved by the partitions. The former name stays an alias of the volume for the
+// clients mounted with it for volFormerNameKeepTime.
+func (c *Cluster) renameVol(name, newName string, u *User) (err error) {
+	c.createVolMutex.Lock()
+	defer c.createVolMutex.Unlock()
+
+	vol, err := c.getVol(name)
+	if err != nil {
+		return
+	}
+	if _, err = c.getVol(newName); err == nil {
+		return fmt.Errorf("vol [%v] already exists", newName)
+	}
+	if aliased, err := c.getVolByFormerName(newName); err == nil && aliased != vol {
+		return fmt.Errorf("vol [%v] is the former name of vol [%v]", newName, aliased.Name)
+	}
+	if vol.Status != proto.VolStatusNormal {
+		return fmt.Errorf("vol [%v] is being deleted", name)
+	}
+
+	cmds := make(map[string]*RaftCmd)
+	clones := c.getVolClones(name)
+	formerName, formerNameExpire := vol.formerName, vol.formerNameExpire
+	defer func() {
+		if err != nil {
+			vol.Name = name
+			vol.formerName, vol.formerNameExpire = formerName, formerNameExpire
+			for _, clone := range clones {
+				clone.cloneSrc = name
+			}
+		}
+	}()
+	vol.Name = newName
+	vol.formerName, vol.formerNameExpire = name, time.Now().Add(volFormerNameKeepTime).Unix()
+	for _, v := range append(clones, vol) {
+		if v != vol {
+			v.cloneSrc = newName
+		}
+		var cmd *RaftCmd
+		if cmd, err = c.buildVolInfoRaftCmd(opSyncUpdateVol, v); err != nil {
+			return
+		}
+		cmds[cmd.K] = cmd
+	}
+	lcConf := c.lcMgr.GetS3BucketLifecycle(name)
+	if lcConf != nil {
+		renamed := &proto.LcConfiguration{VolName: newName, Rules: lcConf.Rules}
+		if err = addLcConfRaftCmds(cmds, lcConf, renamed); err != nil {
+			return
+		}
+		lcConf = renamed
+	}
+	if err = u.renameVolPolicy(name, newName, cmds, c.syncBatchCommitCmd); err != nil {
+		return
+	}
+
+	c.volMutex.Lock()
+	delete(c.vols, name)
+	c.vols[newName] = vol
+	c.volMutex.Unlock()
+	if lcConf != nil {
+		c.lcMgr.DelS3BucketLifecycle(name)
+		c.lcMgr.SetS3BucketLifecycle(lcConf)
+	}
+	vol.renamePartitions(newName)
+	log.LogWarnf("action[renameVol] vol[%v] id[%v] is renamed to [%v], clones[%v]", name, vol.ID, newName, len(clones))
+	return
+}
+
+func addLcConfRaftCmds(cmds map[string]*RaftCmd, lcConf, renamed *proto.LcConfiguration) (err error) {
+	for op, conf := range map[uint32]*proto.LcConfiguration{opSyncDeleteLcConf: lcConf, opSyncAddLcConf: renamed} {
+		cmd := &RaftCmd{Op: op, K: lcConfPrefix + conf.VolName}
+		if cmd.V, err = json.Marshal(conf); err != nil {
+			return
+		}
+		cmds[cmd.K] = cmd
+	}
+	return
+}
+
+// renamePartitions changes the name of the volume kept by its partitions and managers.
+func (vol *Vol) renamePartitions(newName string) {
+	for _, mp := range vol.cloneMetaPartitionMap() {
+		mp.Lock()
+		mp.volName = newName
+		mp.Unlock()
+	}
+	vol.dataPartitions.Lock()
+	vol.dataPartitions.volName = newName
+	vol.dataPartitions.Unlock()
+	for _, dp := range vol.dataPartitions.clonePartitions() {
+		dp.Lock()
+		dp.VolName = newName
+		dp.Unlock()
+	}
+	if vol.uidSpaceManager != nil {
+		vol.uidSpaceManager.Lock()
+		vol.uidSpaceManager.volName = newName
+		vol.uidSpaceManager.Unlock()
+	}
+}
+
+// getPartitionVol returns the volume of a partition loaded from the store. The partitions are
+// persisted with the name of the volume at the time, so a renamed volume is found by its id.
+func (c *Cluster) getPartitionVol(name string, volID uint64) (vol *Vol, err error) {
+	if vol, err = c.getVol(name); err == nil && vol.ID == volID {
+		return
+	}
+	for _, v := range c.copyVols() {
+		if v.ID == volID {
+			return v, nil
+		}
+	}
+	return
+}
+
+// getVolByFormerName returns the volume renamed from name, unless the alias expires.
+func (c *Cluster) getVolByFormerName(name string) (vol *Vol, err error) {
+	now := time.Now().Unix()
+	for _, v := range c.copyVols() {
+		if v.formerName == name && now < v.formerNameExpire {
+			return v, nil
+		}
+	}
+	return nil, proto.ErrVolNotExists
+}
+
+// getClientVol returns the volume of the name used by a client, which may be mounted with the
+// former name of a renamed volume.
+func (c *Cluster) getClientVol(name string) (vol *Vol, err error) {
+	if vol, err = c.getVol(name); err == nil {
+		return
+	}
+	return c.getVolByFormerName(name)
+}
+
+// syncDataReplicaVolName tells the data node the new name of the volumes of the partitions, which
+// is reported with the former name. It is retried by the next heartbeat if the node fails.
+func (c *Cluster) syncDataReplicaVolName(dataNode *DataNode, dps []*DataPartition) {
+	for _, dp := range dps {
+		dp.RLock()
+		volName := dp.VolName
+		dp.RUnlock()
+		task := dp.createTaskToUpdateVolName(dataNode.Addr, volName)
+		if _, err := dataNode.TaskManager.syncSendAdminTask(task); err != nil {
+			log.LogWarnf("action[syncDataReplicaVolName] dp[%v] vol[%v] replica[%v] err[%v]",
+				dp.PartitionID, volName, dataNode.Addr, err)
+			return
+		}
+		log.LogInfof("action[syncDataReplicaVolName] dp[%v] replica[%v] is told the vol name[%v]",
+			dp.PartitionID, dataNode.Addr, volName)
+	}
+}
+
+// getReportedVol returns the volume of a partition reported by a node, which reports the former
+// name of a renamed volume until it is told the new one.
+func (c *Cluster) getReportedVol(name string, hasPartition func(vol *Vol) bool) (vol *Vol, err error) {
+	if vol, err = c.getVol(name); err == nil && hasPartition(vol) {
+		return
+	}
+	for _, v := range c.copyVols() {
+		if v.Name != name && hasPartition(v) {
+			return v, nil
+		}
+	}
+	return
+}
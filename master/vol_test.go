@@ -11,6 +11,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/cubefs/cubefs/master/mocktest"
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/log"
@@ -313,6 +314,160 @@ func statVol(name string, t *testing.T) {
 	process(reqURL, t)
 }
 
+func TestVolClone(t *testing.T) {
+	name := "cloneSrc"
+	cloneName := "cloneDst"
+	createVol(map[string]interface{}{nameKey: name}, t)
+	src, err := server.cluster.getVol(name)
+	assert.Nil(t, err)
+
+	// take a snapshot of the initial version
+	verSeq := src.VersionMgr.getLatestVer()
+	src.VersionMgr.Lock()
+	src.VersionMgr.multiVersionList = append(src.VersionMgr.multiVersionList,
+		&proto.VolVersionInfo{Ver: verSeq + 100, Status: proto.VersionNormal})
+	src.VersionMgr.Unlock()
+
+	req := map[string]interface{}{nameKey: name, verSeqKey: verSeq + 100, cloneNameKey: cloneName}
+	processWithFatalV2(proto.AdminCloneVol, false, req, t)
+	req[verSeqKey] = verSeq + 1
+	processWithFatalV2(proto.AdminCloneVol, false, req, t)
+	req[verSeqKey] = verSeq
+	processWithFatalV2(proto.AdminCloneVol, true, req, t)
+
+	view := getSimpleVol(cloneName, true, t)
+	assert.Equal(t, name, view.CloneSrc)
+	assert.Equal(t, verSeq, view.CloneVerSeq)
+	clone, err := server.cluster.getVol(cloneName)
+	assert.Nil(t, err)
+	assert.Equal(t, len(src.MetaPartitions), len(clone.MetaPartitions))
+	for _, mp := range clone.MetaPartitions {
+		assert.Equal(t, proto.ReadWrite, int(mp.Status))
+	}
+
+	// the data partitions of the source are read by the clone
+	for id := range src.dataPartitions.partitionMap {
+		dp, err := server.cluster.getCloneSourceDataPartition(clone, id)
+		assert.Nil(t, err)
+		assert.Equal(t, id, dp.PartitionID)
+		break
+	}
+
+	// the version and the source are kept by the clone
+	assert.True(t, server.cluster.isClonedVer(name, verSeq))
+	processWithFatalV2(proto.AdminDelVersion, false, map[string]interface{}{nameKey: name, verSeqKey: verSeq}, t)
+	assert.NotNil(t, server.cluster.markDeleteVol(name, buildAuthKey(testOwner), false, true))
+}
+
+func TestVolRename(t *testing.T) {
+	name := "renameSrc"
+	newName := "renameDst"
+	createVol(map[string]interface{}{nameKey: name}, t)
+	vol, err := server.cluster.getVol(name)
+	assert.Nil(t, err)
+	lcConf := &proto.LcConfiguration{VolName: name}
+	assert.Nil(t, server.cluster.syncAddLcConf(lcConf))
+	assert.Nil(t, server.cluster.lcMgr.SetS3BucketLifecycle(lcConf))
+
+	processWithFatalV2(proto.AdminRenameVol, false, map[string]interface{}{nameKey: name, newNameKey: "bad_name_"}, t)
+	processWithFatalV2(proto.AdminRenameVol, false, map[string]interface{}{nameKey: name, newNameKey: commonVolName}, t)
+	processWithFatalV2(proto.AdminRenameVol, true, map[string]interface{}{nameKey: name, newNameKey: newName}, t)
+
+	_, err = server.cluster.getVol(name)
+	assert.NotNil(t, err)
+	renamed, err := server.cluster.getVol(newName)
+	assert.Nil(t, err)
+	assert.Equal(t, vol.ID, renamed.ID)
+	for _, mp := range renamed.cloneMetaPartitionMap() {
+		assert.Equal(t, newName, mp.volName)
+	}
+	for _, dp := range renamed.dataPartitions.clonePartitions() {
+		assert.Equal(t, newName, dp.VolName)
+	}
+	assert.Nil(t, server.cluster.lcMgr.GetS3BucketLifecycle(name))
+	assert.NotNil(t, server.cluster.lcMgr.GetS3BucketLifecycle(newName))
+
+	// the policies of the users follow the volume
+	userInfo, err := server.user.getUserInfo(testOwner)
+	assert.Nil(t, err)
+	assert.True(t, userInfo.Policy.IsOwn(newName))
+	assert.False(t, userInfo.Policy.IsOwn(name))
+	userIDs, err := server.user.getUsersOfVol(newName)
+	assert.Nil(t, err)
+	assert.Contains(t, userIDs, testOwner)
+	_, err = server.user.getUsersOfVol(name)
+	assert.NotNil(t, err)
+
+	// the partitions persisted or reported with the former name are found by the volume id
+	found, err := server.cluster.getPartitionVol(name, vol.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, newName, found.Name)
+	mp := renamed.MetaPartitions[renamed.maxPartitionID()]
+	found, err = server.cluster.getReportedVol(name, func(vol *Vol) bool {
+		_, err := vol.metaPartition(mp.PartitionID)
+		return err == nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, newName, found.Name)
+
+	// the data nodes are told the new name by the heartbeats
+	renamedOnDataNodes := false
+	for i := 0; i < volPartitionCheckTimeout && !renamedOnDataNodes; i++ {
+		server.cluster.checkDataNodeHeartbeat()
+		time.Sleep(time.Second)
+		renamedOnDataNodes = true
+		rangeMockDataServers(func(mds *mocktest.MockDataServer) bool {
+			renamedOnDataNodes = mds.CheckVolPartition(name, func(*mocktest.MockDataPartition) bool { return false })
+			return renamedOnDataNodes
+		})
+	}
+	assert.True(t, renamedOnDataNodes)
+
+	// the clients mounted with the former name are served until the alias expires, and the name
+	// is not taken by another volume meanwhile
+	view := getSimpleVol(name, true, t)
+	assert.Equal(t, newName, view.Name)
+	statVol(name, t)
+	processWithFatalV2(proto.AdminCreateVol, false, map[string]interface{}{
+		nameKey: name, volTypeKey: proto.VolumeTypeHot, volOwnerKey: testOwner,
+		zoneNameKey: testZone2, volCapacityKey: 300, replicaNumKey: 3,
+	}, t)
+	processWithFatalV2(proto.AdminRenameVol, false, map[string]interface{}{nameKey: commonVolName, newNameKey: name}, t)
+	renamed.formerNameExpire = time.Now().Unix() - 1
+	_, err = server.cluster.getClientVol(name)
+	assert.NotNil(t, err)
+	getSimpleVol(name, false, t)
+}
+
+func TestVolPromote(t *testing.T) {
+	name := "promoteSrc"
+	createVol(map[string]interface{}{nameKey: name}, t)
+	src, err := server.cluster.getVol(name)
+	assert.Nil(t, err)
+
+	req := map[string]interface{}{nameKey: name, newNameKey: "promoteDst"}
+	processWithFatalV2(proto.AdminPromoteVol, false, req, t)
+	req[fullPathKey] = "/"
+	processWithFatalV2(proto.AdminPromoteVol, false, req, t)
+	_, err = server.cluster.promoteVol(src, "/dir", commonVolName)
+	assert.NotNil(t, err)
+
+	// the promoted volume is a clone of the version taken
+	verSeq := src.VersionMgr.getLatestVer()
+	src.VersionMgr.Lock()
+	src.VersionMgr.multiVersionList = append(src.VersionMgr.multiVersionList,
+		&proto.VolVersionInfo{Ver: verSeq + 100, Status: proto.VersionNormal})
+	src.VersionMgr.Unlock()
+	cloneReq, err := newCloneVolReq(src, verSeq, "promoteDst")
+	assert.Nil(t, err)
+	cloneReq.promotePath = "/dir"
+	_, err = server.cluster.createVol(cloneReq)
+	assert.Nil(t, err)
+	view := getSimpleVol("promoteDst", true, t)
+	assert.Equal(t, name, view.CloneSrc)
+	assert.Equal(t, "/dir", view.PromotePath)
+}
+
 func TestVolMpsLock(t *testing.T) {
 	name := "TestVolMpsLock"
 	var volID uint64 = 1
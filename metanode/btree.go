@@ -16,6 +16,7 @@ package metanode
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/cubefs/cubefs/util/btree"
 )
@@ -27,10 +28,165 @@ type (
 	BtreeItem = btree.Item
 )
 
+// Tree is the ordered collection of the items of a meta partition. BTree keeps all the items in
+// memory, RocksTree keeps them in RocksDB and only the recently used ones in memory.
+type Tree interface {
+	Get(key BtreeItem) BtreeItem
+	CopyGet(key BtreeItem) BtreeItem
+	Find(key BtreeItem, fn func(i BtreeItem))
+	CopyFind(key BtreeItem, fn func(i BtreeItem))
+	Has(key BtreeItem) bool
+	Delete(key BtreeItem) BtreeItem
+	ReplaceOrInsert(key BtreeItem, replace bool) (BtreeItem, bool)
+	Ascend(fn func(i BtreeItem) bool)
+	AscendRange(greaterOrEqual, lessThan BtreeItem, iterator func(i BtreeItem) bool)
+	AscendGreaterOrEqual(pivot BtreeItem, iterator func(i BtreeItem) bool)
+	GetTree() Tree
+	Reset()
+	Len() int
+}
+
 // BTree is the wrapper of Google's btree.
 type BTree struct {
 	sync.RWMutex
-	tree *btree.BTree
+	tree  *btree.BTree
+	delta *treeDelta // the keys changed since the tree is stored in full, nil if not tracked
+}
+
+// dirtyKey is the key of an item changed in the epoch. The keys are replaced instead of changed,
+// so they are shared by the delta and its snapshots.
+type dirtyKey struct {
+	key   BtreeItem
+	epoch uint64
+}
+
+func (k *dirtyKey) Less(than btree.Item) bool {
+	return k.key.Less(than.(*dirtyKey).key)
+}
+
+func (k *dirtyKey) Copy() btree.Item {
+	return k
+}
+
+// treeDelta tracks the keys of the items changed since the snapshot of the tree stored in full, so
+// the following snapshots only store the changed items. The epoch is increased by every snapshot of
+// the tree, and the changes are recorded in the epoch they are made in.
+//
+// The items got by Get and Find may be changed in place by the raft logs, so they are recorded while
+// the partition is applying, the other items got then are recorded too but only cost some space.
+type treeDelta struct {
+	sync.Mutex
+	keys     *btree.BTree
+	epoch    uint64
+	base     uint64 // the epoch of the snapshot stored in full
+	stored   bool   // whether the base is stored, the next snapshot is stored in full if not
+	deltas   int    // the number of the snapshots stored as delta since the base
+	resets   uint64 // the number of the times the changes are lost
+	applying *int32
+	live     *treeDelta // the delta this one is the snapshot of
+}
+
+func newTreeDelta(applying *int32) *treeDelta {
+	return &treeDelta{keys: btree.New(defaultBTreeDegree), applying: applying}
+}
+
+func (d *treeDelta) mark(key BtreeItem) {
+	if d.live != nil {
+		// the snapshots are not changed
+		return
+	}
+	d.Lock()
+	// the changes made before the first snapshot are all stored by it
+	if d.epoch > 0 || d.stored {
+		k := &dirtyKey{key: key}
+		if old := d.keys.Get(k); old == nil || old.(*dirtyKey).epoch != d.epoch {
+			k.epoch = d.epoch
+			d.keys.ReplaceOrInsert(k)
+		}
+	}
+	d.Unlock()
+}
+
+func (d *treeDelta) markApplying(key BtreeItem) {
+	if d.applying != nil && atomic.LoadInt32(d.applying) != 0 {
+		d.mark(key)
+	}
+}
+
+func (d *treeDelta) invalidate() {
+	d.Lock()
+	d.keys.Clear(false)
+	d.stored = false
+	d.deltas = 0
+	d.resets++
+	d.Unlock()
+}
+
+func (d *treeDelta) snapshot() *treeDelta {
+	d.Lock()
+	defer d.Unlock()
+	snap := &treeDelta{
+		keys:   d.keys.Clone(),
+		epoch:  d.epoch,
+		base:   d.base,
+		stored: d.stored,
+		deltas: d.deltas,
+		resets: d.resets,
+		live:   d,
+	}
+	d.epoch++
+	return snap
+}
+
+// storedBase marks the current items of the tree as the base, as the tree is just loaded from the
+// snapshot. The keys changed by the delta of the snapshot are marked again, so the following
+// snapshots keep storing them.
+func (d *treeDelta) storedBase(keys []BtreeItem) {
+	d.Lock()
+	d.base, d.stored, d.deltas = d.epoch, true, 0
+	d.epoch++
+	for _, key := range keys {
+		d.keys.ReplaceOrInsert(&dirtyKey{key: key, epoch: d.epoch})
+	}
+	d.Unlock()
+}
+
+// storedSnapshot is called on the delta of the snapshot after it is stored, the keys changed before the
+// snapshot stored in full are in the base now.
+func (d *treeDelta) storedSnapshot(full bool) {
+	live := d.live
+	if live == nil {
+		return
+	}
+	live.Lock()
+	defer live.Unlock()
+	if live.resets != d.resets {
+		return
+	}
+	if !full {
+		if live.stored && live.base == d.base {
+			live.deltas++
+		}
+		return
+	}
+	if live.stored && live.base >= d.epoch {
+		return
+	}
+	keys := btree.New(defaultBTreeDegree)
+	live.keys.Ascend(func(i BtreeItem) bool {
+		if i.(*dirtyKey).epoch > d.epoch {
+			keys.ReplaceOrInsert(i)
+		}
+		return true
+	})
+	live.keys, live.base, live.stored, live.deltas = keys, d.epoch, true, 0
+}
+
+// changed returns the keys changed since the base, they are all changed before the snapshot.
+func (d *treeDelta) changed(fn func(key BtreeItem) bool) {
+	d.keys.Ascend(func(i BtreeItem) bool {
+		return fn(i.(*dirtyKey).key)
+	})
 }
 
 // NewBtree creates a new btree.
@@ -40,10 +196,20 @@ func NewBtree() *BTree {
 	}
 }
 
+// newTrackedBtree creates a new btree tracking the changed items for the delta snapshots.
+func newTrackedBtree(applying *int32) *BTree {
+	b := NewBtree()
+	b.delta = newTreeDelta(applying)
+	return b
+}
+
 // Get returns the object of the given key in the btree.
 func (b *BTree) Get(key BtreeItem) (item BtreeItem) {
 	b.RLock()
 	item = b.tree.Get(key)
+	if item != nil && b.delta != nil {
+		b.delta.markApplying(item)
+	}
 	b.RUnlock()
 	return
 }
@@ -51,6 +217,9 @@ func (b *BTree) Get(key BtreeItem) (item BtreeItem) {
 func (b *BTree) CopyGet(key BtreeItem) (item BtreeItem) {
 	b.Lock()
 	item = b.tree.CopyGet(key)
+	if item != nil && b.delta != nil {
+		b.delta.mark(item)
+	}
 	b.Unlock()
 	return
 }
@@ -59,6 +228,9 @@ func (b *BTree) CopyGet(key BtreeItem) (item BtreeItem) {
 func (b *BTree) Find(key BtreeItem, fn func(i BtreeItem)) {
 	b.RLock()
 	item := b.tree.Get(key)
+	if item != nil && b.delta != nil {
+		b.delta.markApplying(item)
+	}
 	b.RUnlock()
 	if item == nil {
 		return
@@ -69,6 +241,9 @@ func (b *BTree) Find(key BtreeItem, fn func(i BtreeItem)) {
 func (b *BTree) CopyFind(key BtreeItem, fn func(i BtreeItem)) {
 	b.Lock()
 	item := b.tree.CopyGet(key)
+	if item != nil && b.delta != nil {
+		b.delta.mark(item)
+	}
 	fn(item)
 	b.Unlock()
 }
@@ -85,6 +260,9 @@ func (b *BTree) Has(key BtreeItem) (ok bool) {
 func (b *BTree) Delete(key BtreeItem) (item BtreeItem) {
 	b.Lock()
 	item = b.tree.Delete(key)
+	if item != nil && b.delta != nil {
+		b.delta.mark(item)
+	}
 	b.Unlock()
 	return
 }
@@ -92,6 +270,10 @@ func (b *BTree) Delete(key BtreeItem) (item BtreeItem) {
 func (b *BTree) Execute(fn func(tree *btree.BTree) interface{}) interface{} {
 	b.Lock()
 	defer b.Unlock()
+	if b.delta != nil {
+		// the changes made by fn are unknown
+		b.delta.invalidate()
+	}
 	return fn(b.tree)
 }
 
@@ -100,6 +282,9 @@ func (b *BTree) ReplaceOrInsert(key BtreeItem, replace bool) (item BtreeItem, ok
 	b.Lock()
 	if replace {
 		item = b.tree.ReplaceOrInsert(key)
+		if b.delta != nil {
+			b.delta.mark(key)
+		}
 		b.Unlock()
 		ok = true
 		return
@@ -108,10 +293,16 @@ func (b *BTree) ReplaceOrInsert(key BtreeItem, replace bool) (item BtreeItem, ok
 	item = b.tree.Get(key)
 	if item == nil {
 		item = b.tree.ReplaceOrInsert(key)
+		if b.delta != nil {
+			b.delta.mark(key)
+		}
 		b.Unlock()
 		ok = true
 		return
 	}
+	if b.delta != nil {
+		b.delta.markApplying(item)
+	}
 	ok = false
 	b.Unlock()
 	return
@@ -141,12 +332,17 @@ func (b *BTree) AscendGreaterOrEqual(pivot BtreeItem, iterator func(i BtreeItem)
 }
 
 // GetTree returns the snapshot of a btree.
-func (b *BTree) GetTree() *BTree {
+func (b *BTree) GetTree() Tree {
 	b.Lock()
 	t := b.tree.Clone()
+	var delta *treeDelta
+	if b.delta != nil {
+		delta = b.delta.snapshot()
+	}
 	b.Unlock()
 	nb := NewBtree()
 	nb.tree = t
+	nb.delta = delta
 	return nb
 }
 
@@ -154,6 +350,9 @@ func (b *BTree) GetTree() *BTree {
 func (b *BTree) Reset() {
 	b.Lock()
 	b.tree.Clear(true)
+	if b.delta != nil {
+		b.delta.invalidate()
+	}
 	b.Unlock()
 }
 
@@ -187,13 +187,34 @@ const (
 	opFSMStoreTickV1  = 72
 
 	opFSMVerListSnapShot = 73
+
+	// extents sharing
+	opFSMExtentsDel     = 74
+	opFSMExtentsClone   = 75
+	opFSMExtentRefsSnap = 76
+
+	opFSMMetaChangeLogSnap = 77
+
+	// partition split and merge
+	opFSMSplitPartition  = 78
+	opFSMFreezePartition = 79
+	opFSMMergePartition  = 80
+
+	// the items of the snapshot sent in a chunk
+	opFSMSnapBatch = 81
+
+	// trash
+	opFSMTrashInode   = 82
+	opFSMRestoreTrash = 83
+	opFSMPurgeTrash   = 84
 )
 
 var exporterKey string
 
 var (
-	ErrNoLeader   = errors.New("no leader")
-	ErrNotALeader = errors.New("not a leader")
+	ErrNoLeader        = errors.New("no leader")
+	ErrNotALeader      = errors.New("not a leader")
+	ErrPartitionFrozen = errors.New("meta partition is frozen")
 )
 
 // Default configuration
@@ -216,6 +237,8 @@ const (
 	cfgTotalMem                  = "totalMem"
 	cfgMemRatio                  = "memRatio"
 	cfgZoneName                  = "zoneName"
+	cfgRackName                  = "rackName"
+	cfgHostName                  = "hostName"
 	cfgTickInterval              = "tickInterval"
 	cfgRaftRecvBufSize           = "raftRecvBufSize"
 	cfgSmuxPortShift             = "smuxPortShift"             // int
@@ -225,6 +248,8 @@ const (
 	cfgRetainLogs                = "retainLogs"                // string, raft RetainLogs
 	cfgRaftSyncSnapFormatVersion = "raftSyncSnapFormatVersion" // int, format version of snapshot that raft leader sent to follower
 	cfgServiceIDKey              = "serviceIDKey"
+	cfgRocksCacheCount           = "rocksCacheCount"     // int, items of each tree cached by the partitions kept in RocksDB
+	cfgEnableDeltaSnapshot       = "enableDeltaSnapshot" // bool, store the items changed since the last full snapshot
 
 	metaNodeDeleteBatchCountKey = "batchCount"
 	configNameResolveInterval   = "nameResolveInterval" // int
@@ -240,6 +265,13 @@ const (
 	defaultQuotaSwitch           = true
 	DefaultNameResolveInterval   = 1 // minutes
 	DefaultRaftNumOfLogsToRetain = 20000 * 2
+
+	// the metadata changes kept in memory by each partition for the replication
+	defaultMetaChangeLogSize = 64 * 1024
+	maxReadMetaChangesLimit  = 10000
+
+	// the items of each tree cached in memory by the partitions kept in RocksDB
+	defaultRocksCacheCount = 100000
 )
 
 const (
@@ -51,6 +51,7 @@ type Vol struct {
 	sync.RWMutex
 	dataPartitionView map[uint64]*DataPartition
 	volDeleteLockTime int64
+	trashRetention    int64 // min, accessed atomically
 }
 
 // NewVol returns a new volume instance.
@@ -77,6 +77,21 @@ func (e *Extend) GetExtentByVersion(ver uint64) (extend *Extend) {
 	return
 }
 
+// getExtendByVer returns the layer visible at the version ver, or nil if the extend did not exist then.
+func (e *Extend) getExtendByVer(ver uint64) *Extend {
+	e.versionMu.RLock()
+	defer e.versionMu.RUnlock()
+	if e.verSeq <= ver {
+		return e
+	}
+	for _, extend := range e.multiVers {
+		if extend.verSeq <= ver {
+			return extend
+		}
+	}
+	return nil
+}
+
 func NewExtend(inode uint64) *Extend {
 	return &Extend{inode: inode, dataMap: make(map[string][]byte)}
 }
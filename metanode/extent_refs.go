@@ -0,0 +1,175 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"sync"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/errors"
+)
+
+const (
+	extentRefsVersion  = 1
+	extentRefRecordLen = 12
+)
+
+// extentRefs counts the inodes of the partition sharing an extent by cloning, the extents
+// held by a single inode are not recorded. The pieces of an extent in one inode are counted
+// by the ekRefMap of the inode, so an inode is released from the extent once its last piece
+// is deleted. The data of a shared extent is kept until it is released by all the inodes.
+type extentRefs struct {
+	sync.RWMutex
+	refs map[uint64]uint32
+}
+
+func newExtentRefs() *extentRefs {
+	return &extentRefs{refs: make(map[uint64]uint32)}
+}
+
+func extentRefKey(ek *proto.ExtentKey) uint64 {
+	return ek.PartitionId<<32 | ek.ExtentId
+}
+
+func (r *extentRefs) clone() *extentRefs {
+	if r == nil {
+		return newExtentRefs()
+	}
+	r.RLock()
+	defer r.RUnlock()
+	refs := make(map[uint64]uint32, len(r.refs))
+	for key, cnt := range r.refs {
+		refs[key] = cnt
+	}
+	return &extentRefs{refs: refs}
+}
+
+func (r *extentRefs) isShared(ek *proto.ExtentKey) bool {
+	if r == nil {
+		return false
+	}
+	r.RLock()
+	defer r.RUnlock()
+	_, ok := r.refs[extentRefKey(ek)]
+	return ok
+}
+
+func (r *extentRefs) count(ek *proto.ExtentKey) uint32 {
+	r.RLock()
+	defer r.RUnlock()
+	return r.refs[extentRefKey(ek)]
+}
+
+// share records that one more inode holds the extent.
+func (r *extentRefs) share(ek *proto.ExtentKey) {
+	r.Lock()
+	defer r.Unlock()
+	key := extentRefKey(ek)
+	if cnt, ok := r.refs[key]; ok {
+		r.refs[key] = cnt + 1
+		return
+	}
+	r.refs[key] = 2
+}
+
+// release records that an inode no longer holds the extent, it returns false if the
+// extent is not shared, which should be deleted by the inode as usual.
+func (r *extentRefs) release(ek *proto.ExtentKey) (shared bool) {
+	r.Lock()
+	defer r.Unlock()
+	key := extentRefKey(ek)
+	cnt, ok := r.refs[key]
+	if !ok {
+		return false
+	}
+	if cnt <= 2 {
+		delete(r.refs, key)
+	} else {
+		r.refs[key] = cnt - 1
+	}
+	return true
+}
+
+// merge adds the counts of the extents shared by the inodes merged from another partition.
+func (r *extentRefs) merge(other *extentRefs) {
+	other.RLock()
+	defer other.RUnlock()
+	r.Lock()
+	defer r.Unlock()
+	for key, cnt := range other.refs {
+		r.refs[key] += cnt
+	}
+}
+
+func (r *extentRefs) Len() int {
+	if r == nil {
+		return 0
+	}
+	r.RLock()
+	defer r.RUnlock()
+	return len(r.refs)
+}
+
+func (r *extentRefs) Marshal() (buf []byte, crc uint32, err error) {
+	r.RLock()
+	defer r.RUnlock()
+	buffer := bytes.NewBuffer(make([]byte, 0, 4+len(r.refs)*extentRefRecordLen))
+	if err = binary.Write(buffer, binary.BigEndian, int32(extentRefsVersion)); err != nil {
+		return
+	}
+	for key, cnt := range r.refs {
+		if err = binary.Write(buffer, binary.BigEndian, key); err != nil {
+			return
+		}
+		if err = binary.Write(buffer, binary.BigEndian, cnt); err != nil {
+			return
+		}
+	}
+	buf = buffer.Bytes()
+	crc = crc32.ChecksumIEEE(buf)
+	return
+}
+
+func (r *extentRefs) UnMarshal(data []byte) (err error) {
+	if len(data) < 4 || (len(data)-4)%extentRefRecordLen != 0 {
+		return errors.New("invalid extent refs length")
+	}
+	buff := bytes.NewBuffer(data)
+	var version int32
+	if err = binary.Read(buff, binary.BigEndian, &version); err != nil {
+		return
+	}
+	refs := make(map[uint64]uint32, (len(data)-4)/extentRefRecordLen)
+	for buff.Len() > 0 {
+		var (
+			key uint64
+			cnt uint32
+		)
+		if err = binary.Read(buff, binary.BigEndian, &key); err != nil {
+			return
+		}
+		if err = binary.Read(buff, binary.BigEndian, &cnt); err != nil {
+			return
+		}
+		refs[key] = cnt
+	}
+	r.Lock()
+	r.refs = refs
+	r.Unlock()
+	return
+}
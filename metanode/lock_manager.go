@@ -0,0 +1,236 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+var errLockGracePeriod = errors.New("locks are being restored after the leader changed")
+
+type lockSession struct {
+	expire time.Time
+	inodes map[uint64]struct{}
+}
+
+type inodeLocks struct {
+	locks []*proto.FileLock
+	// closed when the locks of the inode change to wake up the waiters
+	changed chan struct{}
+}
+
+func (s *inodeLocks) notify() {
+	if s.changed != nil {
+		close(s.changed)
+		s.changed = nil
+	}
+}
+
+func (s *inodeLocks) waitChan() <-chan struct{} {
+	if s.changed == nil {
+		s.changed = make(chan struct{})
+	}
+	return s.changed
+}
+
+// lockManager keeps the advisory locks of the inodes in the memory of the leader, the locks
+// are not replicated by raft. The locks held by a client session are released once the lease
+// of the session expires. After the leader is changed, the live sessions restore their locks
+// by renewing the leases, and no lock is granted until the grace period ends.
+type lockManager struct {
+	sync.Mutex
+	inodes   map[uint64]*inodeLocks
+	sessions map[uint64]*lockSession
+	graceEnd time.Time
+	now      func() time.Time
+}
+
+func newLockManager() *lockManager {
+	return &lockManager{
+		inodes:   make(map[uint64]*inodeLocks),
+		sessions: make(map[uint64]*lockSession),
+		now:      time.Now,
+	}
+}
+
+// reset drops all the locks and starts the grace period, it is called when the leader changes.
+func (m *lockManager) reset() {
+	m.Lock()
+	defer m.Unlock()
+	for _, state := range m.inodes {
+		state.notify()
+	}
+	m.inodes = make(map[uint64]*inodeLocks)
+	m.sessions = make(map[uint64]*lockSession)
+	m.graceEnd = m.now().Add(proto.LockLeaseTimeout)
+}
+
+func (m *lockManager) expireSessions(now time.Time) {
+	for sid, session := range m.sessions {
+		if now.Before(session.expire) {
+			continue
+		}
+		delete(m.sessions, sid)
+		for ino := range session.inodes {
+			state, ok := m.inodes[ino]
+			if !ok {
+				continue
+			}
+			locks := state.locks[:0]
+			for _, l := range state.locks {
+				if l.Session != sid {
+					locks = append(locks, l)
+				}
+			}
+			state.locks = locks
+			m.updateInode(ino, state)
+		}
+	}
+}
+
+func (m *lockManager) renewSession(sid uint64, now time.Time) *lockSession {
+	session, ok := m.sessions[sid]
+	if !ok {
+		session = &lockSession{inodes: make(map[uint64]struct{})}
+		m.sessions[sid] = session
+	}
+	session.expire = now.Add(proto.LockLeaseTimeout)
+	return session
+}
+
+func (m *lockManager) updateInode(ino uint64, state *inodeLocks) {
+	state.notify()
+	if len(state.locks) == 0 {
+		delete(m.inodes, ino)
+	}
+}
+
+// trySetLock returns the conflicting lock if the lock is not able to be acquired,
+// and the channel to wait for the conflicting lock to be released.
+func (m *lockManager) trySetLock(ino uint64, lk *proto.FileLock) (conflict *proto.FileLock, wait <-chan struct{}, err error) {
+	m.Lock()
+	defer m.Unlock()
+	now := m.now()
+	m.expireSessions(now)
+
+	state, ok := m.inodes[ino]
+	if lk.Type == proto.FileLockUnlock {
+		if ok {
+			state.locks = proto.SetFileLock(state.locks, lk)
+			m.updateInode(ino, state)
+		}
+		return
+	}
+	if now.Before(m.graceEnd) {
+		err = errLockGracePeriod
+		return
+	}
+	if !ok {
+		state = &inodeLocks{}
+		m.inodes[ino] = state
+	}
+	if c := proto.ConflictFileLock(state.locks, lk); c != nil {
+		copied := *c
+		return &copied, state.waitChan(), nil
+	}
+	m.renewSession(lk.Session, now).inodes[ino] = struct{}{}
+	state.locks = proto.SetFileLock(state.locks, lk)
+	// the lock may be downgraded, the waiters should check again
+	m.updateInode(ino, state)
+	return
+}
+
+// setLock acquires or releases the lock, the conflicting lock is returned if it is not acquired.
+// If timeout is positive, it waits for the conflicting locks to be released until timeout or stop.
+func (m *lockManager) setLock(ino uint64, lk *proto.FileLock, timeout time.Duration, stop <-chan bool) (conflict *proto.FileLock, err error) {
+	var wait <-chan struct{}
+	if conflict, wait, err = m.trySetLock(ino, lk); conflict == nil || err != nil || timeout <= 0 {
+		return
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-wait:
+		case <-timer.C:
+			return
+		case <-stop:
+			return
+		}
+		if conflict, wait, err = m.trySetLock(ino, lk); conflict == nil || err != nil {
+			return
+		}
+	}
+}
+
+// getLock returns the lock conflicting with lk, nil is returned if lk is able to be acquired.
+func (m *lockManager) getLock(ino uint64, lk *proto.FileLock) (conflict *proto.FileLock, err error) {
+	m.Lock()
+	defer m.Unlock()
+	now := m.now()
+	m.expireSessions(now)
+	if now.Before(m.graceEnd) {
+		return nil, errLockGracePeriod
+	}
+	if state, ok := m.inodes[ino]; ok {
+		if c := proto.ConflictFileLock(state.locks, lk); c != nil {
+			copied := *c
+			conflict = &copied
+		}
+	}
+	return
+}
+
+// renewLease renews the lease of the session. The locks held by the session are restored
+// if the session is unknown, the inodes whose locks are acquired by others are returned.
+func (m *lockManager) renewLease(sid uint64, held map[uint64][]*proto.FileLock) (lost []uint64) {
+	m.Lock()
+	defer m.Unlock()
+	now := m.now()
+	m.expireSessions(now)
+	_, known := m.sessions[sid]
+	session := m.renewSession(sid, now)
+	if known {
+		return
+	}
+	for ino, locks := range held {
+		state, ok := m.inodes[ino]
+		if !ok {
+			state = &inodeLocks{}
+			m.inodes[ino] = state
+		}
+		restored, conflicted := state.locks, false
+		for _, lk := range locks {
+			lk.Session = sid
+			if !lk.Valid() || lk.Type == proto.FileLockUnlock || proto.ConflictFileLock(restored, lk) != nil {
+				conflicted = true
+				break
+			}
+			restored = proto.SetFileLock(restored, lk)
+		}
+		if conflicted {
+			lost = append(lost, ino)
+		} else {
+			state.locks = restored
+			session.inodes[ino] = struct{}{}
+		}
+		m.updateInode(ino, state)
+	}
+	return
+}
@@ -0,0 +1,138 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLockManager() (*lockManager, *time.Time) {
+	now := time.Now()
+	m := newLockManager()
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func writeLock(sid uint64) *proto.FileLock {
+	return &proto.FileLock{Session: sid, Owner: 1, Type: proto.FileLockWrite, End: math.MaxUint64}
+}
+
+func TestLockManagerSetLock(t *testing.T) {
+	m, _ := newTestLockManager()
+	conflict, err := m.setLock(1, writeLock(1), 0, nil)
+	require.NoError(t, err)
+	require.Nil(t, conflict)
+
+	conflict, err = m.setLock(1, writeLock(2), 0, nil)
+	require.NoError(t, err)
+	require.Equal(t, writeLock(1), conflict)
+	conflict, err = m.getLock(1, writeLock(2))
+	require.NoError(t, err)
+	require.Equal(t, writeLock(1), conflict)
+	// the other inodes are not affected
+	conflict, err = m.setLock(2, writeLock(2), 0, nil)
+	require.NoError(t, err)
+	require.Nil(t, conflict)
+
+	unlock := writeLock(1)
+	unlock.Type = proto.FileLockUnlock
+	_, err = m.setLock(1, unlock, 0, nil)
+	require.NoError(t, err)
+	conflict, err = m.getLock(1, writeLock(2))
+	require.NoError(t, err)
+	require.Nil(t, conflict)
+	require.Len(t, m.inodes, 1)
+}
+
+func TestLockManagerWait(t *testing.T) {
+	m := newLockManager()
+	_, err := m.setLock(1, writeLock(1), 0, nil)
+	require.NoError(t, err)
+
+	done := make(chan *proto.FileLock)
+	go func() {
+		conflict, _ := m.setLock(1, writeLock(2), 10*time.Second, nil)
+		done <- conflict
+	}()
+	time.Sleep(50 * time.Millisecond)
+	unlock := writeLock(1)
+	unlock.Type = proto.FileLockUnlock
+	_, err = m.setLock(1, unlock, 0, nil)
+	require.NoError(t, err)
+	select {
+	case conflict := <-done:
+		require.Nil(t, conflict)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the waiter is not woken up")
+	}
+
+	// the waiter gives up after timeout
+	start := time.Now()
+	conflict, err := m.setLock(1, writeLock(3), 100*time.Millisecond, nil)
+	require.NoError(t, err)
+	require.Equal(t, writeLock(2), conflict)
+	require.True(t, time.Since(start) >= 100*time.Millisecond)
+}
+
+func TestLockManagerLease(t *testing.T) {
+	m, now := newTestLockManager()
+	_, err := m.setLock(1, writeLock(1), 0, nil)
+	require.NoError(t, err)
+
+	*now = now.Add(proto.LockLeaseTimeout / 2)
+	require.Empty(t, m.renewLease(1, nil))
+	*now = now.Add(proto.LockLeaseTimeout / 2)
+	conflict, err := m.getLock(1, writeLock(2))
+	require.NoError(t, err)
+	require.NotNil(t, conflict)
+
+	// the locks of the expired session are released
+	*now = now.Add(proto.LockLeaseTimeout)
+	conflict, err = m.setLock(1, writeLock(2), 0, nil)
+	require.NoError(t, err)
+	require.Nil(t, conflict)
+	require.NotContains(t, m.sessions, uint64(1))
+}
+
+func TestLockManagerRestore(t *testing.T) {
+	m, now := newTestLockManager()
+	m.reset()
+	_, err := m.setLock(1, writeLock(1), 0, nil)
+	require.Equal(t, errLockGracePeriod, err)
+	_, err = m.getLock(1, writeLock(1))
+	require.Equal(t, errLockGracePeriod, err)
+
+	// the live sessions restore their locks in the grace period
+	lost := m.renewLease(1, map[uint64][]*proto.FileLock{1: {writeLock(0)}, 2: {writeLock(0)}})
+	require.Empty(t, lost)
+	lost = m.renewLease(2, map[uint64][]*proto.FileLock{2: {writeLock(0)}, 3: {writeLock(0)}})
+	require.Equal(t, []uint64{2}, lost)
+
+	*now = now.Add(proto.LockLeaseTimeout / 2)
+	require.Empty(t, m.renewLease(1, nil))
+	require.Empty(t, m.renewLease(2, nil))
+	*now = now.Add(proto.LockLeaseTimeout / 2)
+	conflict, err := m.getLock(1, writeLock(3))
+	require.NoError(t, err)
+	require.Equal(t, writeLock(1), conflict)
+	conflict, err = m.getLock(3, writeLock(3))
+	require.NoError(t, err)
+	require.Equal(t, writeLock(2), conflict)
+}
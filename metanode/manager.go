@@ -186,6 +186,19 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 		err = m.opMetaObjExtentsList(conn, p, remoteAddr)
 	case proto.OpMetaExtentsDel:
 		err = m.opMetaExtentsDel(conn, p, remoteAddr)
+	case proto.OpMetaExtentsClone:
+		err = m.opMetaExtentsClone(conn, p, remoteAddr)
+	case proto.OpMetaReadChanges:
+		err = m.opMetaReadChanges(conn, p, remoteAddr)
+	// operations for the trash
+	case proto.OpMetaTrashInode:
+		err = m.opMetaTrashInode(conn, p, remoteAddr)
+	case proto.OpMetaListTrash:
+		err = m.opMetaListTrash(conn, p, remoteAddr)
+	case proto.OpMetaRestoreTrash:
+		err = m.opMetaRestoreTrash(conn, p, remoteAddr)
+	case proto.OpMetaPurgeTrash:
+		err = m.opMetaPurgeTrash(conn, p, remoteAddr)
 	case proto.OpMetaTruncate:
 		err = m.opMetaExtentsTruncate(conn, p, remoteAddr)
 	case proto.OpMetaLookup:
@@ -202,8 +215,16 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 		err = m.opAddMetaPartitionRaftMember(conn, p, remoteAddr)
 	case proto.OpRemoveMetaPartitionRaftMember:
 		err = m.opRemoveMetaPartitionRaftMember(conn, p, remoteAddr)
+	case proto.OpPromoteMetaPartitionLearner:
+		err = m.opPromoteMetaPartitionLearner(conn, p, remoteAddr)
 	case proto.OpMetaPartitionTryToLeader:
 		err = m.opMetaPartitionTryToLeader(conn, p, remoteAddr)
+	case proto.OpSplitMetaPartition:
+		err = m.opSplitMetaPartition(conn, p, remoteAddr)
+	case proto.OpFreezeMetaPartition:
+		err = m.opFreezeMetaPartition(conn, p, remoteAddr)
+	case proto.OpMergeMetaPartition:
+		err = m.opMergeMetaPartition(conn, p, remoteAddr)
 	case proto.OpMetaBatchInodeGet:
 		err = m.opMetaBatchInodeGet(conn, p, remoteAddr)
 	case proto.OpMetaDeleteInode:
@@ -233,6 +254,13 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 		err = m.opMetaListXAttr(conn, p, remoteAddr)
 	case proto.OpMetaUpdateXAttr:
 		err = m.opMetaUpdateXAttr(conn, p, remoteAddr)
+	// operations for advisory locks
+	case proto.OpMetaSetLock:
+		err = m.opMetaSetLock(conn, p, remoteAddr)
+	case proto.OpMetaGetLock:
+		err = m.opMetaGetLock(conn, p, remoteAddr)
+	case proto.OpMetaRenewLockLease:
+		err = m.opMetaRenewLockLease(conn, p, remoteAddr)
 	// operations for multipart session
 	case proto.OpCreateMultipart:
 		err = m.opCreateMultipart(conn, p, remoteAddr)
@@ -392,6 +420,51 @@ func (m *metadataManager) getPartition(id uint64) (mp MetaPartition, err error)
 	return
 }
 
+// getPartitionByInode returns the partition serving the inode, the requests of the
+// inodes moved by a split or a merge are redirected to the partition they are moved to.
+func (m *metadataManager) getPartitionByInode(id, ino uint64) (mp MetaPartition, err error) {
+	if mp, err = m.getPartition(id); err != nil {
+		return
+	}
+	for i := 0; i < maxPartitionRedirects; i++ {
+		conf := mp.GetBaseConfig()
+		target := conf.redirectTarget(ino)
+		if target == 0 {
+			return
+		}
+		next, getErr := m.getPartition(target)
+		if getErr != nil {
+			// the client retries until the split partition is loaded
+			if conf.MergeTo == 0 {
+				err = fmt.Errorf("inode(%v) is moved to meta partition(%v) not loaded", ino, target)
+			}
+			return
+		}
+		// the frozen partition keeps serving until the merge is applied
+		if nextConf := next.GetBaseConfig(); ino < nextConf.Start || ino > nextConf.End {
+			return
+		}
+		mp = next
+	}
+	return
+}
+
+// getPartitionByInodes redirects the request if all the inodes are moved to the same partition.
+func (m *metadataManager) getPartitionByInodes(id uint64, inos []uint64) (mp MetaPartition, err error) {
+	if len(inos) == 0 {
+		return m.getPartition(id)
+	}
+	if mp, err = m.getPartitionByInode(id, inos[0]); err != nil {
+		return
+	}
+	for _, ino := range inos[1:] {
+		if other, _ := m.getPartitionByInode(id, ino); other != mp {
+			return m.getPartition(id)
+		}
+	}
+	return
+}
+
 func (m *metadataManager) loadPartitions() (err error) {
 	var metaNodeInfo *proto.MetaNodeInfo
 	for i := 0; i < 3; i++ {
@@ -528,6 +601,26 @@ func (m *metadataManager) attachPartition(id uint64, partition MetaPartition) (e
 	return
 }
 
+// attachSplitPartition loads the partition stored by a split and starts its raft group.
+func (m *metadataManager) attachSplitPartition(id uint64) {
+	if _, err := m.getPartition(id); err == nil {
+		return
+	}
+	partitionConfig := &MetaPartitionConfig{
+		PartitionId: id,
+		NodeId:      m.nodeId,
+		RaftStore:   m.raftStore,
+		RootDir:     path.Join(m.rootDir, partitionPrefix+strconv.FormatUint(id, 10)),
+		ConnPool:    m.connPool,
+	}
+	partitionConfig.AfterStop = func() {
+		m.detachPartition(id)
+	}
+	if err := m.attachPartition(id, NewMetaPartition(partitionConfig, m)); err != nil {
+		log.LogErrorf("action[attachSplitPartition] load partition id=%d failed: %s.", id, err.Error())
+	}
+}
+
 func (m *metadataManager) detachPartition(id uint64) (err error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -542,6 +635,9 @@ func (m *metadataManager) detachPartition(id uint64) (err error) {
 func (m *metadataManager) createPartition(request *proto.CreateMetaPartitionRequest) (err error) {
 	partitionId := fmt.Sprintf("%d", request.PartitionID)
 	log.LogInfof("start create meta Partition, partition %s", partitionId)
+	if request.CloneFrom != 0 {
+		return m.createClonePartition(request)
+	}
 
 	mpc := &MetaPartitionConfig{
 		PartitionId: request.PartitionID,
@@ -556,6 +652,7 @@ func (m *metadataManager) createPartition(request *proto.CreateMetaPartitionRequ
 		RootDir:     path.Join(m.rootDir, partitionPrefix+partitionId),
 		ConnPool:    m.connPool,
 		VerSeq:      request.VerSeq,
+		StoreType:   request.StoreType,
 	}
 	mpc.AfterStop = func() {
 		m.detachPartition(request.PartitionID)
@@ -115,6 +115,7 @@ func (m *metadataManager) opMasterHeartbeat(conn net.Conn, p *Packet,
 			partition.SetUidLimit(req.UidLimitInfo)
 			partition.SetTxInfo(req.TxInfo)
 			partition.setQuotaHbInfo(req.QuotaHbInfos)
+			partition.setUserQuotaHbInfo(req.UserQuotaHbInfos)
 			mConf := partition.GetBaseConfig()
 
 			mpr := &proto.MetaPartitionReport{
@@ -130,10 +131,13 @@ func (m *metadataManager) opMasterHeartbeat(conn net.Conn, p *Packet,
 				FreeListLen:      uint64(partition.GetFreeListLen()),
 				UidInfo:          partition.GetUidInfo(),
 				QuotaReportInfos: partition.getQuotaReportInfos(),
+				UserQuotaReports: partition.getUserQuotaReportInfos(),
+				SplitFrom:        mConf.SplitFrom,
+				MergeTo:          mConf.MergeTo,
 			}
 			mpr.TxCnt, mpr.TxRbInoCnt, mpr.TxRbDenCnt = partition.TxGetCnt()
 
-			if mConf.Cursor >= mConf.End {
+			if mConf.Cursor >= mConf.End || mConf.MergeTo != 0 {
 				mpr.Status = proto.ReadOnly
 			}
 			if resp.MemUsed > uint64(float64(resp.Total)*MaxUsedMemFactor) {
@@ -150,6 +154,10 @@ func (m *metadataManager) opMasterHeartbeat(conn net.Conn, p *Packet,
 			return true
 		})
 		resp.ZoneName = m.zoneName
+		if m.metaNode != nil {
+			resp.RackName = m.metaNode.rackName
+			resp.HostName = m.metaNode.hostName
+		}
 		resp.Status = proto.TaskSucceeds
 	end:
 		adminTask.Request = nil
@@ -309,7 +317,7 @@ func (m *metadataManager) opMetaLinkInode(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -577,7 +585,7 @@ func (m *metadataManager) opCreateDentry(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.ParentID)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClient(conn, p)
@@ -610,7 +618,7 @@ func (m *metadataManager) opQuotaCreateDentry(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.ParentID)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClient(conn, p)
@@ -675,7 +683,7 @@ func (m *metadataManager) opDeleteDentry(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.ParentID)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClient(conn, p)
@@ -710,7 +718,7 @@ func (m *metadataManager) opBatchDeleteDentry(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.ParentID)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -776,7 +784,7 @@ func (m *metadataManager) opUpdateDentry(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.ParentID)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -843,7 +851,7 @@ func (m *metadataManager) opMetaUnlinkInode(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -875,7 +883,7 @@ func (m *metadataManager) opMetaBatchUnlinkInode(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInodes(req.PartitionID, req.Inodes)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -907,7 +915,7 @@ func (m *metadataManager) opReadDirOnly(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.ParentID)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClient(conn, p)
@@ -934,7 +942,7 @@ func (m *metadataManager) opReadDir(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.ParentID)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClient(conn, p)
@@ -961,7 +969,7 @@ func (m *metadataManager) opReadDirLimit(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.ParentID)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClient(conn, p)
@@ -989,7 +997,7 @@ func (m *metadataManager) opMetaInodeGet(conn net.Conn, p *Packet,
 		return
 	}
 	log.LogDebugf("action[opMetaInodeGet] request %v", req)
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClient(conn, p)
@@ -1032,7 +1040,7 @@ func (m *metadataManager) opBatchMetaEvictInode(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v] request unmarshal: %v", p.GetOpMsgWithReqAndResult(), err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInodes(req.PartitionID, req.Inodes)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -1066,7 +1074,7 @@ func (m *metadataManager) opMetaEvictInode(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -1101,7 +1109,7 @@ func (m *metadataManager) opSetAttr(conn net.Conn, p *Packet,
 		return
 	}
 
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -1137,7 +1145,7 @@ func (m *metadataManager) opMetaLookup(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.ParentID)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClient(conn, p)
@@ -1171,7 +1179,7 @@ func (m *metadataManager) opMetaExtentsAdd(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -1208,7 +1216,7 @@ func (m *metadataManager) opMetaExtentAddWithCheck(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -1246,7 +1254,7 @@ func (m *metadataManager) opMetaExtentsList(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClient(conn, p)
@@ -1275,7 +1283,7 @@ func (m *metadataManager) opMetaObjExtentsList(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClient(conn, p)
@@ -1295,29 +1303,187 @@ func (m *metadataManager) opMetaObjExtentsList(conn net.Conn, p *Packet,
 
 func (m *metadataManager) opMetaExtentsDel(conn net.Conn, p *Packet,
 	remoteAddr string) (err error) {
-	panic("not implemented yet")
-	// req := &proto.DelExtentKeyRequest{}
-	// if err = json.Unmarshal(p.Data, req); err != nil {
-	// 	p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
-	// 	m.respondToClientWithVer(conn, p)
-	// 	err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
-	// 	return
-	// }
-	// mp, err := m.getPartition(req.PartitionID)
-	// if err != nil {
-	// 	p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
-	// 	m.respondToClientWithVer(conn, p)
-	// 	err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
-	// 	return
-	// }
-	// if !m.serveProxy(conn, mp, p) {
-	// 	return
-	// }
-	// mp.ExtentsDelete(req, p)
-	// m.respondToClientWithVer(conn, p)
-	// log.LogDebugf("%s [OpMetaTruncate] req: %d - %v, resp body: %v, "+
-	// 	"resp body: %s", remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
-	// return
+	req := &proto.DelExtentKeyRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClientWithVer(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartitionByInode(req.PartitionID, req.Inode)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClientWithVer(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	if err = m.checkMultiVersionStatus(mp, p); err != nil {
+		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
+		m.respondToClientWithVer(conn, p)
+		return
+	}
+	mp.ExtentsDelete(req, p)
+	m.updatePackRspSeq(mp, p)
+	m.respondToClientWithVer(conn, p)
+	log.LogDebugf("%s [opMetaExtentsDel] req: %d - %v, resp body: %v, "+
+		"resp body: %s", remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+func (m *metadataManager) opMetaExtentsClone(conn net.Conn, p *Packet,
+	remoteAddr string) (err error) {
+	req := &proto.CloneExtentsRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClientWithVer(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartitionByInodes(req.PartitionID, []uint64{req.SrcInode, req.DstInode})
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClientWithVer(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	if err = m.checkMultiVersionStatus(mp, p); err != nil {
+		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
+		m.respondToClientWithVer(conn, p)
+		return
+	}
+	mp.ExtentsClone(req, p)
+	m.updatePackRspSeq(mp, p)
+	m.respondToClientWithVer(conn, p)
+	log.LogDebugf("%s [opMetaExtentsClone] req: %d - %v, resp body: %v, "+
+		"resp body: %s", remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+func (m *metadataManager) opMetaReadChanges(conn net.Conn, p *Packet,
+	remoteAddr string) (err error) {
+	req := &proto.ReadMetaChangesRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.ReadChanges(req, p)
+	m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaReadChanges] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(), req, p.GetResultMsg())
+	return
+}
+
+func (m *metadataManager) opMetaTrashInode(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.TrashInodeRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartitionByInode(req.PartitionId, req.Inode)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.TrashInode(req, p, remoteAddr)
+	m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaTrashInode] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(), req, p.GetResultMsg())
+	return
+}
+
+func (m *metadataManager) opMetaListTrash(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.ListTrashRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionId)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.ListTrash(req, p)
+	m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaListTrash] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(), req, p.GetResultMsg())
+	return
+}
+
+func (m *metadataManager) opMetaRestoreTrash(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.RestoreTrashRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartitionByInode(req.PartitionId, req.Inode)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.RestoreTrash(req, p)
+	m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaRestoreTrash] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(), req, p.GetResultMsg())
+	return
+}
+
+func (m *metadataManager) opMetaPurgeTrash(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.PurgeTrashRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartitionByInode(req.PartitionId, req.Inode)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.PurgeTrash(req, p)
+	m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaPurgeTrash] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(), req, p.GetResultMsg())
+	return
 }
 
 func (m *metadataManager) opMetaExtentsTruncate(conn net.Conn, p *Packet,
@@ -1329,7 +1495,7 @@ func (m *metadataManager) opMetaExtentsTruncate(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -1361,7 +1527,7 @@ func (m *metadataManager) opMetaClearInodeCache(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -1453,6 +1619,115 @@ func (m *metadataManager) opUpdateMetaPartition(conn net.Conn, p *Packet,
 	return
 }
 
+// respondAdminTaskResult replies the result of a partition task synchronously sent by the master.
+func (m *metadataManager) respondAdminTaskResult(conn net.Conn, p *Packet, resp interface{}, err error) {
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClientWithVer(conn, p)
+		return
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClientWithVer(conn, p)
+		return
+	}
+	p.PacketOkWithBody(data)
+	m.respondToClientWithVer(conn, p)
+}
+
+func (m *metadataManager) opSplitMetaPartition(conn net.Conn, p *Packet,
+	remoteAddr string) (err error) {
+	req := &proto.SplitMetaPartitionRequest{}
+	adminTask := &proto.AdminTask{
+		Request: req,
+	}
+	decode := json.NewDecoder(bytes.NewBuffer(p.Data))
+	decode.UseNumber()
+	if err = decode.Decode(adminTask); err != nil {
+		m.respondAdminTaskResult(conn, p, nil, err)
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		m.respondAdminTaskResult(conn, p, nil, err)
+		return
+	}
+	if _, ok := mp.IsLeader(); !ok {
+		err = ErrNotALeader
+		m.respondAdminTaskResult(conn, p, nil, err)
+		return
+	}
+	resp := &proto.SplitMetaPartitionResponse{}
+	err = mp.SplitPartition(req, resp)
+	m.respondAdminTaskResult(conn, p, resp, err)
+	log.LogInfof("%s [opSplitMetaPartition] req[%v], resp[%v], err[%v]", remoteAddr, req, resp, err)
+	return
+}
+
+func (m *metadataManager) opFreezeMetaPartition(conn net.Conn, p *Packet,
+	remoteAddr string) (err error) {
+	req := &proto.FreezeMetaPartitionRequest{}
+	adminTask := &proto.AdminTask{
+		Request: req,
+	}
+	decode := json.NewDecoder(bytes.NewBuffer(p.Data))
+	decode.UseNumber()
+	if err = decode.Decode(adminTask); err != nil {
+		m.respondAdminTaskResult(conn, p, nil, err)
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		m.respondAdminTaskResult(conn, p, nil, err)
+		return
+	}
+	if _, ok := mp.IsLeader(); !ok {
+		err = ErrNotALeader
+		m.respondAdminTaskResult(conn, p, nil, err)
+		return
+	}
+	resp := &proto.FreezeMetaPartitionResponse{}
+	err = mp.FreezePartition(req, resp)
+	m.respondAdminTaskResult(conn, p, resp, err)
+	log.LogInfof("%s [opFreezeMetaPartition] req[%v], resp[%v], err[%v]", remoteAddr, req, resp, err)
+	return
+}
+
+func (m *metadataManager) opMergeMetaPartition(conn net.Conn, p *Packet,
+	remoteAddr string) (err error) {
+	req := &proto.MergeMetaPartitionRequest{}
+	adminTask := &proto.AdminTask{
+		Request: req,
+	}
+	decode := json.NewDecoder(bytes.NewBuffer(p.Data))
+	decode.UseNumber()
+	if err = decode.Decode(adminTask); err != nil {
+		m.respondAdminTaskResult(conn, p, nil, err)
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		m.respondAdminTaskResult(conn, p, nil, err)
+		return
+	}
+	source, err := m.getPartition(req.SourceID)
+	if err != nil {
+		m.respondAdminTaskResult(conn, p, nil, err)
+		return
+	}
+	if _, ok := mp.IsLeader(); !ok {
+		err = ErrNotALeader
+		m.respondAdminTaskResult(conn, p, nil, err)
+		return
+	}
+	resp := &proto.MergeMetaPartitionResponse{}
+	err = mp.MergePartition(req, source, resp)
+	m.respondAdminTaskResult(conn, p, resp, err)
+	log.LogInfof("%s [opMergeMetaPartition] req[%v], resp[%v], err[%v]", remoteAddr, req, resp, err)
+	return
+}
+
 func (m *metadataManager) opLoadMetaPartition(conn net.Conn, p *Packet,
 	remoteAddr string) (err error) {
 	req := &proto.MetaPartitionLoadRequest{}
@@ -1527,7 +1802,11 @@ func (m *metadataManager) opDecommissionMetaPartition(conn net.Conn,
 		m.respondToClientWithVer(conn, p)
 		return
 	}
-	_, err = mp.ChangeMember(raftProto.ConfAddNode,
+	changeType := raftProto.ConfAddNode
+	if req.AddPeer.IsLearner {
+		changeType = raftProto.ConfAddLearner
+	}
+	_, err = mp.ChangeMember(changeType,
 		raftProto.Peer{ID: req.AddPeer.ID}, reqData)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
@@ -1604,7 +1883,11 @@ func (m *metadataManager) opAddMetaPartitionRaftMember(conn net.Conn,
 		m.respondToClientWithVer(conn, p)
 		return
 	}
-	_, err = mp.ChangeMember(raftProto.ConfAddNode,
+	changeType := raftProto.ConfAddNode
+	if req.AddPeer.IsLearner {
+		changeType = raftProto.ConfAddLearner
+	}
+	_, err = mp.ChangeMember(changeType,
 		raftProto.Peer{ID: req.AddPeer.ID}, reqData)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
@@ -1694,6 +1977,67 @@ func (m *metadataManager) opRemoveMetaPartitionRaftMember(conn net.Conn,
 	return
 }
 
+// opPromoteMetaPartitionLearner turns the learner into a voter once it has caught up with the log.
+func (m *metadataManager) opPromoteMetaPartitionLearner(conn net.Conn,
+	p *Packet, remoteAddr string) (err error) {
+	var reqData []byte
+	req := &proto.PromoteMetaPartitionLearnerRequest{}
+	adminTask := &proto.AdminTask{
+		Request: req,
+	}
+
+	defer func() {
+		if err != nil {
+			log.LogInfof("[%s], remote %s promote learner failed, req %v, err %s", p.String(), remoteAddr, adminTask, err.Error())
+			return
+		}
+		log.LogInfof("[%s], remote %s promote learner success, req %v", p.String(), remoteAddr, adminTask)
+	}()
+
+	decode := json.NewDecoder(bytes.NewBuffer(p.Data))
+	decode.UseNumber()
+	if err = decode.Decode(adminTask); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		return err
+	}
+	mp, err := m.getPartition(req.PartitionId)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpTryOtherAddr, ([]byte)(proto.ErrMetaPartitionNotExists.Error()))
+		m.respondToClient(conn, p)
+		return err
+	}
+	// the leader decides with the raft status, the peers of the local replica may be stale
+	if !m.serveProxy(conn, mp, p) {
+		return nil
+	}
+	if err = mp.CanPromoteLearner(req.PromotePeer); err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		return
+	}
+	if reqData, err = json.Marshal(req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		return
+	}
+	if req.ReplacePeer.ID != 0 {
+		_, err = mp.ReplaceMember(raftProto.Peer{ID: req.PromotePeer.ID},
+			raftProto.Peer{ID: req.ReplacePeer.ID}, reqData)
+	} else {
+		_, err = mp.ChangeMember(raftProto.ConfPromoteLearner,
+			raftProto.Peer{ID: req.PromotePeer.ID}, reqData)
+	}
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		return err
+	}
+	p.PacketOkReply()
+	m.respondToClient(conn, p)
+	return
+}
+
 func (m *metadataManager) opMetaBatchInodeGet(conn net.Conn, p *Packet,
 	remoteAddr string) (err error) {
 	req := &proto.BatchInodeGetRequest{}
@@ -1704,7 +2048,7 @@ func (m *metadataManager) opMetaBatchInodeGet(conn net.Conn, p *Packet,
 		return
 	}
 	log.LogDebugf("action[opMetaBatchInodeGet] req %v", req)
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInodes(req.PartitionID, req.Inodes)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClient(conn, p)
@@ -1748,7 +2092,7 @@ func (m *metadataManager) opMetaDeleteInode(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionId)
+	mp, err := m.getPartitionByInode(req.PartitionId, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -1775,7 +2119,7 @@ func (m *metadataManager) opMetaBatchDeleteInode(conn net.Conn, p *Packet,
 		return
 	}
 
-	mp, err := m.getPartition(req.PartitionId)
+	mp, err := m.getPartitionByInodes(req.PartitionId, req.Inodes)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -1802,7 +2146,7 @@ func (m *metadataManager) opMetaUpdateXAttr(conn net.Conn, p *Packet, remoteAddr
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionId)
+	mp, err := m.getPartitionByInode(req.PartitionId, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -1825,6 +2169,82 @@ func (m *metadataManager) opMetaUpdateXAttr(conn net.Conn, p *Packet, remoteAddr
 	return
 }
 
+func (m *metadataManager) opMetaSetLock(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.SetLockRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartitionByInode(req.PartitionId, req.Inode)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	// the locks are kept by the leader only
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.SetLock(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaSetLock] req: %d - %v, resp: %v, body: %s",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+func (m *metadataManager) opMetaGetLock(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.GetLockRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartitionByInode(req.PartitionId, req.Inode)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.GetLock(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaGetLock] req: %d - %v, resp: %v, body: %s",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+func (m *metadataManager) opMetaRenewLockLease(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.RenewLockLeaseRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionId)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.RenewLockLease(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaRenewLockLease] req: %d - %v, resp: %v, body: %s",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
 func (m *metadataManager) opMetaSetXAttr(conn net.Conn, p *Packet, remoteAddr string) (err error) {
 	req := &proto.SetXAttrRequest{}
 	if err = json.Unmarshal(p.Data, req); err != nil {
@@ -1833,7 +2253,7 @@ func (m *metadataManager) opMetaSetXAttr(conn net.Conn, p *Packet, remoteAddr st
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionId)
+	mp, err := m.getPartitionByInode(req.PartitionId, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -1864,7 +2284,7 @@ func (m *metadataManager) opMetaBatchSetXAttr(conn net.Conn, p *Packet, remoteAd
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionId)
+	mp, err := m.getPartitionByInode(req.PartitionId, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClient(conn, p)
@@ -1895,7 +2315,7 @@ func (m *metadataManager) opMetaGetXAttr(conn net.Conn, p *Packet, remoteAddr st
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionId)
+	mp, err := m.getPartitionByInode(req.PartitionId, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClient(conn, p)
@@ -1920,7 +2340,7 @@ func (m *metadataManager) opMetaGetAllXAttr(conn net.Conn, p *Packet, remoteAddr
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionId)
+	mp, err := m.getPartitionByInode(req.PartitionId, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClient(conn, p)
@@ -1945,7 +2365,7 @@ func (m *metadataManager) opMetaBatchGetXAttr(conn net.Conn, p *Packet, remoteAd
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionId)
+	mp, err := m.getPartitionByInodes(req.PartitionId, req.Inodes)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClient(conn, p)
@@ -1970,7 +2390,7 @@ func (m *metadataManager) opMetaRemoveXAttr(conn net.Conn, p *Packet, remoteAddr
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionId)
+	mp, err := m.getPartitionByInode(req.PartitionId, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -2001,7 +2421,7 @@ func (m *metadataManager) opMetaListXAttr(conn net.Conn, p *Packet, remoteAddr s
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionId)
+	mp, err := m.getPartitionByInode(req.PartitionId, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -2026,7 +2446,7 @@ func (m *metadataManager) opMetaBatchExtentsAdd(conn net.Conn, p *Packet, remote
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionId)
+	mp, err := m.getPartitionByInode(req.PartitionId, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -2057,7 +2477,7 @@ func (m *metadataManager) opMetaBatchObjExtentsAdd(conn net.Conn, p *Packet, rem
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	mp, err := m.getPartition(req.PartitionID)
+	mp, err := m.getPartitionByInode(req.PartitionID, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -2334,7 +2754,7 @@ func (m *metadataManager) opMetaGetInodeQuota(conn net.Conn, p *Packet, remote s
 		return
 	}
 
-	mp, err := m.getPartition(req.PartitionId)
+	mp, err := m.getPartitionByInode(req.PartitionId, req.Inode)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClient(conn, p)
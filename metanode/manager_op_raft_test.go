@@ -0,0 +1,274 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/depends/tiglabs/raft"
+	raftproto "github.com/cubefs/cubefs/depends/tiglabs/raft/proto"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/raftstore"
+	raftstoremock "github.com/cubefs/cubefs/util/mocktest/raftstore"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func newRaftMemberTestManager(ctrl *gomock.Controller) (*metadataManager, *raftstoremock.MockPartition) {
+	conf := &MetaPartitionConfig{
+		PartitionId: 1,
+		NodeId:      1,
+		Peers:       []proto.Peer{{ID: 1, Addr: "127.0.0.1:17210"}},
+	}
+	mp := NewMetaPartition(conf, &metadataManager{}).(*metaPartition)
+	partition := raftstoremock.NewMockPartition(ctrl)
+	partition.EXPECT().LeaderTerm().Return(uint64(1), uint64(1)).AnyTimes()
+	mp.raftPartition = partition
+	return &metadataManager{partitions: map[uint64]MetaPartition{1: mp}}, partition
+}
+
+// handleAdminTask serves the admin task through the handler and returns the result code of the reply.
+func handleAdminTask(t *testing.T, opcode uint8, req interface{},
+	handler func(conn net.Conn, p *Packet, remoteAddr string) error) uint8 {
+	data, err := json.Marshal(&proto.AdminTask{Request: req})
+	require.NoError(t, err)
+	p := &Packet{}
+	p.Magic = proto.ProtoMagic
+	p.Opcode = opcode
+	p.Data = data
+	p.Size = uint32(len(data))
+
+	server, client := net.Pipe()
+	defer client.Close()
+	go func() {
+		defer server.Close()
+		handler(server, p, "127.0.0.1:17210")
+	}()
+	reply := proto.NewPacket()
+	require.NoError(t, reply.ReadFromConn(client, proto.NoReadDeadlineTime))
+	return reply.ResultCode
+}
+
+func TestAddMetaPartitionRaftMember(t *testing.T) {
+	proto.InitBufferPool(int64(32768))
+	for _, isLearner := range []bool{false, true} {
+		ctrl := gomock.NewController(t)
+		m, partition := newRaftMemberTestManager(ctrl)
+		changeType := raftproto.ConfAddNode
+		if isLearner {
+			changeType = raftproto.ConfAddLearner
+		}
+		partition.EXPECT().ChangeMember(changeType, raftproto.Peer{ID: 2}, gomock.Any()).Return(nil, nil).Times(1)
+
+		req := &proto.AddMetaPartitionRaftMemberRequest{
+			PartitionId: 1,
+			AddPeer:     proto.Peer{ID: 2, Addr: "127.0.0.1:17220", IsLearner: isLearner},
+		}
+		code := handleAdminTask(t, proto.OpAddMetaPartitionRaftMember, req, m.opAddMetaPartitionRaftMember)
+		require.Equal(t, proto.OpOk, code)
+		ctrl.Finish()
+	}
+}
+
+// memberTestFsm applies nothing, the test checks the member changes through the raft status.
+type memberTestFsm struct{}
+
+func (memberTestFsm) Apply(command []byte, index uint64) (interface{}, error) {
+	return nil, nil
+}
+
+func (memberTestFsm) ApplyMemberChange(cc *raftproto.ConfChange, index uint64) (interface{}, error) {
+	return nil, nil
+}
+
+func (memberTestFsm) Snapshot() (raftproto.Snapshot, error) {
+	return nil, nil
+}
+
+func (memberTestFsm) ApplySnapshot(peers []raftproto.Peer, iter raftproto.SnapIterator) error {
+	return nil
+}
+
+func (memberTestFsm) HandleLeaderChange(leader uint64) {}
+
+func (memberTestFsm) HandleFatalEvent(err *raft.FatalError) {}
+
+// newReplaceTestManagers starts the raft of the partition on node 1, 2 and 3, node 4 starts
+// the raft as the learner to be added. It returns the managers of node 1, 2 and 3.
+func newReplaceTestManagers(t *testing.T) []*metadataManager {
+	const heartbeatPort, replicaPort = 19130, 19140
+	peers := make([]proto.Peer, 0, 3)
+	raftPeers := make([]raftstore.PeerAddress, 0, 4)
+	for id := uint64(1); id <= 4; id++ {
+		peer := raftstore.PeerAddress{
+			Peer:          raftproto.Peer{ID: id},
+			Address:       "127.0.0.1",
+			HeartbeatPort: heartbeatPort + int(id),
+			ReplicaPort:   replicaPort + int(id),
+		}
+		if id == 4 {
+			peer.Type = raftproto.PeerLearner
+		} else {
+			peers = append(peers, proto.Peer{ID: id, Addr: fmt.Sprintf("127.0.0.1:%d", 17210+id)})
+		}
+		raftPeers = append(raftPeers, peer)
+	}
+
+	managers := make([]*metadataManager, 0, 3)
+	for id := uint64(1); id <= 4; id++ {
+		store, err := raftstore.NewRaftStore(&raftstore.Config{
+			NodeID:        id,
+			RaftPath:      t.TempDir(),
+			IPAddr:        "127.0.0.1",
+			HeartbeatPort: heartbeatPort + int(id),
+			ReplicaPort:   replicaPort + int(id),
+		}, nil)
+		require.NoError(t, err)
+		t.Cleanup(store.Stop)
+		partitionPeers := raftPeers[:3]
+		if id == 4 {
+			partitionPeers = raftPeers
+		}
+		partition, err := store.CreatePartition(&raftstore.PartitionConfig{ID: 1, Peers: partitionPeers, SM: memberTestFsm{}})
+		require.NoError(t, err)
+		// the learner is known by the voters before it is added
+		peer := raftPeers[3]
+		store.AddNodeWithPort(peer.ID, peer.Address, peer.HeartbeatPort, peer.ReplicaPort)
+		if id == 4 {
+			continue
+		}
+
+		conf := &MetaPartitionConfig{
+			PartitionId: 1,
+			NodeId:      id,
+			RootDir:     t.TempDir(),
+			Peers:       peers,
+			RaftStore:   store,
+		}
+		mp := NewMetaPartition(conf, &metadataManager{}).(*metaPartition)
+		mp.raftPartition = partition
+		managers = append(managers, &metadataManager{partitions: map[uint64]MetaPartition{1: mp}})
+	}
+	return managers
+}
+
+func TestReplaceMetaPartitionRaftMember(t *testing.T) {
+	proto.InitBufferPool(int64(32768))
+	managers := newReplaceTestManagers(t)
+	var leader *metadataManager
+	require.Eventually(t, func() bool {
+		for _, m := range managers {
+			if _, ok := m.partitions[1].IsLeader(); ok {
+				leader = m
+				return true
+			}
+		}
+		return false
+	}, 30*time.Second, 100*time.Millisecond)
+	mp := leader.partitions[1].(*metaPartition)
+	// the voters other than the leader
+	var followers []proto.Peer
+	for _, peer := range mp.config.Peers {
+		if peer.ID != mp.config.NodeId {
+			followers = append(followers, peer)
+		}
+	}
+	learner := proto.Peer{ID: 4, Addr: "127.0.0.1:17214", IsLearner: true}
+
+	// the leader decides with the raft status, the peer unknown by the raft is not promoted
+	code := handleAdminTask(t, proto.OpPromoteMetaPartitionLearner, &proto.PromoteMetaPartitionLearnerRequest{
+		PartitionId: 1,
+		PromotePeer: learner,
+	}, leader.opPromoteMetaPartitionLearner)
+	require.NotEqual(t, proto.OpOk, code)
+
+	code = handleAdminTask(t, proto.OpAddMetaPartitionRaftMember, &proto.AddMetaPartitionRaftMemberRequest{
+		PartitionId: 1,
+		AddPeer:     learner,
+	}, leader.opAddMetaPartitionRaftMember)
+	require.Equal(t, proto.OpOk, code)
+	require.True(t, mp.raftPartition.Status().Replicas[4].IsLearner)
+
+	// the voter can not replace another one
+	code = handleAdminTask(t, proto.OpPromoteMetaPartitionLearner, &proto.PromoteMetaPartitionLearnerRequest{
+		PartitionId: 1,
+		PromotePeer: followers[0],
+		ReplacePeer: followers[1],
+	}, leader.opPromoteMetaPartitionLearner)
+	require.Equal(t, proto.OpErr, code)
+
+	// the promotion is refused until the learner catches up
+	require.Eventually(t, func() bool {
+		code = handleAdminTask(t, proto.OpPromoteMetaPartitionLearner, &proto.PromoteMetaPartitionLearnerRequest{
+			PartitionId: 1,
+			PromotePeer: learner,
+			ReplacePeer: followers[0],
+		}, leader.opPromoteMetaPartitionLearner)
+		return code != proto.OpAgain
+	}, 30*time.Second, 100*time.Millisecond)
+	require.Equal(t, proto.OpOk, code)
+	require.False(t, mp.raftPartition.Status().Replicas[4].IsLearner)
+
+	code = handleAdminTask(t, proto.OpRemoveMetaPartitionRaftMember, &proto.RemoveMetaPartitionRaftMemberRequest{
+		PartitionId: 1,
+		RemovePeer:  followers[0],
+	}, leader.opRemoveMetaPartitionRaftMember)
+	require.Equal(t, proto.OpOk, code)
+	replicas := mp.raftPartition.Status().Replicas
+	require.Len(t, replicas, 3)
+	require.Contains(t, replicas, uint64(4))
+	require.NotContains(t, replicas, followers[0].ID)
+}
+
+func TestReplaceMetaPartitionRaftMemberPersisted(t *testing.T) {
+	conf := &MetaPartitionConfig{
+		PartitionId: 1,
+		NodeId:      1,
+		Start:       1,
+		End:         100,
+		RootDir:     t.TempDir(),
+		Peers: []proto.Peer{
+			{ID: 1, Addr: "127.0.0.1:17211"}, {ID: 2, Addr: "127.0.0.1:17212"},
+			{ID: 3, Addr: "127.0.0.1:17213"}, {ID: 4, Addr: "127.0.0.1:17214", IsLearner: true},
+		},
+	}
+	mp := NewMetaPartition(conf, &metadataManager{}).(*metaPartition)
+	data, err := json.Marshal(&proto.PromoteMetaPartitionLearnerRequest{
+		PartitionId: 1,
+		PromotePeer: conf.Peers[3],
+		ReplacePeer: conf.Peers[1],
+	})
+	require.NoError(t, err)
+	_, err = mp.ApplyMemberChange(&raftproto.ConfChange{Type: raftproto.ConfReplaceNode, Peer: raftproto.Peer{ID: 4}, Context: data}, 1)
+	require.NoError(t, err)
+
+	// the partition restarted in the joint consensus loads the sides of the replacement
+	restarted := NewMetaPartition(&MetaPartitionConfig{RootDir: conf.RootDir}, &metadataManager{}).(*metaPartition)
+	require.NoError(t, restarted.loadMetadata())
+	want := []proto.Peer{
+		{ID: 1, Addr: "127.0.0.1:17211"}, {ID: 2, Addr: "127.0.0.1:17212", Joint: proto.PeerJointOutgoing},
+		{ID: 3, Addr: "127.0.0.1:17213"}, {ID: 4, Addr: "127.0.0.1:17214", Joint: proto.PeerJointIncoming},
+	}
+	require.Equal(t, want, restarted.config.Peers)
+
+	// the peers are left alone if the replacement is retried
+	_, err = restarted.ApplyMemberChange(&raftproto.ConfChange{Type: raftproto.ConfReplaceNode, Peer: raftproto.Peer{ID: 4}, Context: data}, 2)
+	require.NoError(t, err)
+	require.Equal(t, want, restarted.config.Peers)
+}
@@ -32,6 +32,11 @@ func (m *metadataManager) IsForbiddenOp(mp MetaPartition, reqOp uint8) bool {
 	if !mp.IsForbidden() {
 		return false
 	}
+	return isWriteOp(reqOp) || reqOp == proto.OpMetaLookup
+}
+
+// isWriteOp returns true if the operation modifies the metadata of the partition.
+func isWriteOp(reqOp uint8) bool {
 	switch reqOp {
 	case
 		// dentry
@@ -56,6 +61,7 @@ func (m *metadataManager) IsForbiddenOp(mp MetaPartition, reqOp uint8) bool {
 		proto.OpMetaBatchObjExtentsAdd,
 		proto.OpMetaBatchExtentsAdd,
 		proto.OpMetaExtentsDel,
+		proto.OpMetaExtentsClone,
 		// inode
 		proto.OpMetaCreateInode,
 		proto.OpQuotaCreateInode,
@@ -70,7 +76,10 @@ func (m *metadataManager) IsForbiddenOp(mp MetaPartition, reqOp uint8) bool {
 		proto.OpMetaBatchDeleteInode,
 		proto.OpMetaClearInodeCache,
 		proto.OpMetaTxCreateInode,
-		proto.OpMetaLookup,
+		// trash
+		proto.OpMetaTrashInode,
+		proto.OpMetaRestoreTrash,
+		proto.OpMetaPurgeTrash,
 		// multipart
 		proto.OpAddMultipartPart,
 		proto.OpRemoveMultipart,
@@ -105,6 +114,13 @@ func (m *metadataManager) serveProxy(conn net.Conn, mp MetaPartition,
 		return false
 	}
 
+	// the client retries until the partition is split or merged
+	if mp.IsFrozen() && isWriteOp(reqOp) {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(ErrPartitionFrozen.Error()))
+		m.respondToClient(conn, p)
+		return false
+	}
+
 	if leaderAddr, ok = mp.IsLeader(); ok {
 		return
 	}
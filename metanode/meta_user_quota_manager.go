@@ -0,0 +1,205 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"sync"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// UserQuotaManager accounts the bytes and files of the inodes by their uid and gid,
+// the master sums the usage of all the partitions and tells which ids are limited.
+type UserQuotaManager struct {
+	statisticTemp        *sync.Map // key proto.UserQuotaKey, value proto.QuotaUsedInfo
+	statisticBase        *sync.Map // key proto.UserQuotaKey, value proto.QuotaUsedInfo
+	statisticRebuildTemp *sync.Map // key proto.UserQuotaKey, value proto.QuotaUsedInfo
+	statisticRebuildBase *sync.Map // key proto.UserQuotaKey, value proto.QuotaUsedInfo
+	limitedMap           *sync.Map // key proto.UserQuotaKey, value proto.QuotaLimitedInfo
+	rbuilding            bool
+	volName              string
+	rwlock               sync.RWMutex
+	mpID                 uint64
+}
+
+func NewUserQuotaManager(volName string, mpId uint64) (uqMgr *UserQuotaManager) {
+	uqMgr = &UserQuotaManager{
+		statisticTemp:        new(sync.Map),
+		statisticBase:        new(sync.Map),
+		statisticRebuildTemp: new(sync.Map),
+		statisticRebuildBase: new(sync.Map),
+		limitedMap:           new(sync.Map),
+		volName:              volName,
+		mpID:                 mpId,
+	}
+	return
+}
+
+func userQuotaKeys(uid, gid uint32) []proto.UserQuotaKey {
+	return []proto.UserQuotaKey{
+		{Type: proto.UserQuotaTypeUser, Id: uid},
+		{Type: proto.UserQuotaTypeGroup, Id: gid},
+	}
+}
+
+func addUserQuotaUsedInfo(statistic *sync.Map, key proto.UserQuotaKey, size int64, files int64) {
+	var usedInfo proto.QuotaUsedInfo
+	if value, isFind := statistic.Load(key); isFind {
+		usedInfo = value.(proto.QuotaUsedInfo)
+	}
+	usedInfo.UsedBytes += size
+	usedInfo.UsedFiles += files
+	statistic.Store(key, usedInfo)
+}
+
+func (uqMgr *UserQuotaManager) setUserQuotaHbInfo(infos []*proto.UserQuotaHeartBeatInfo) {
+	limitedMap := new(sync.Map)
+	for _, info := range infos {
+		if uqMgr.volName != info.VolName {
+			continue
+		}
+		limitedMap.Store(proto.UserQuotaKey{Type: info.Type, Id: info.Id}, info.LimitedInfo)
+		log.LogDebugf("mp[%v] user quota type [%v] id [%v] limitedInfo [%v]", uqMgr.mpID, info.Type, info.Id, info.LimitedInfo)
+	}
+
+	uqMgr.rwlock.Lock()
+	uqMgr.limitedMap = limitedMap
+	uqMgr.rwlock.Unlock()
+}
+
+func (uqMgr *UserQuotaManager) getUserQuotaReportInfos() (infos []*proto.UserQuotaReportInfo) {
+	uqMgr.rwlock.Lock()
+	defer uqMgr.rwlock.Unlock()
+
+	uqMgr.statisticTemp.Range(func(key, value interface{}) bool {
+		usedInfo := value.(proto.QuotaUsedInfo)
+		if value, isFind := uqMgr.statisticBase.Load(key); isFind {
+			baseInfo := value.(proto.QuotaUsedInfo)
+			usedInfo.Add(&baseInfo)
+		}
+		if usedInfo.UsedFiles < 0 || usedInfo.UsedBytes < 0 {
+			log.LogWarnf("[getUserQuotaReportInfos] mp[%v] key [%v] usedInfo [%v]", uqMgr.mpID, key, usedInfo)
+			if usedInfo.UsedFiles < 0 {
+				usedInfo.UsedFiles = 0
+			}
+			if usedInfo.UsedBytes < 0 {
+				usedInfo.UsedBytes = 0
+			}
+		}
+		uqMgr.statisticBase.Store(key, usedInfo)
+		return true
+	})
+	uqMgr.statisticTemp = new(sync.Map)
+
+	uqMgr.statisticBase.Range(func(key, value interface{}) bool {
+		usedInfo := value.(proto.QuotaUsedInfo)
+		if usedInfo.UsedFiles == 0 && usedInfo.UsedBytes == 0 {
+			uqMgr.statisticBase.Delete(key)
+			return true
+		}
+		quotaKey := key.(proto.UserQuotaKey)
+		infos = append(infos, &proto.UserQuotaReportInfo{
+			Type:     quotaKey.Type,
+			Id:       quotaKey.Id,
+			UsedInfo: usedInfo,
+		})
+		return true
+	})
+	return
+}
+
+func (uqMgr *UserQuotaManager) statisticRebuildStart() bool {
+	uqMgr.rwlock.Lock()
+	defer uqMgr.rwlock.Unlock()
+	if uqMgr.rbuilding {
+		return false
+	}
+	uqMgr.rbuilding = true
+	return true
+}
+
+func (uqMgr *UserQuotaManager) statisticRebuildFin(rebuild bool) {
+	uqMgr.rwlock.Lock()
+	defer uqMgr.rwlock.Unlock()
+	uqMgr.rbuilding = false
+	if !rebuild {
+		uqMgr.statisticRebuildBase = new(sync.Map)
+		uqMgr.statisticRebuildTemp = new(sync.Map)
+		return
+	}
+	uqMgr.statisticBase = uqMgr.statisticRebuildBase
+	uqMgr.statisticTemp = uqMgr.statisticRebuildTemp
+	uqMgr.statisticRebuildBase = new(sync.Map)
+	uqMgr.statisticRebuildTemp = new(sync.Map)
+}
+
+// statisticInodeByStore counts the inode of the snapshot being stored into the rebuilt base.
+func (uqMgr *UserQuotaManager) statisticInodeByStore(ino *Inode) {
+	if ino.NLink == 0 {
+		return
+	}
+	uqMgr.rwlock.Lock()
+	defer uqMgr.rwlock.Unlock()
+	for _, key := range userQuotaKeys(ino.Uid, ino.Gid) {
+		addUserQuotaUsedInfo(uqMgr.statisticRebuildBase, key, int64(ino.Size), 1)
+	}
+}
+
+func (uqMgr *UserQuotaManager) IsOverQuota(uid, gid uint32, size bool, files bool) (status uint8) {
+	uqMgr.rwlock.RLock()
+	defer uqMgr.rwlock.RUnlock()
+	for _, key := range userQuotaKeys(uid, gid) {
+		value, isFind := uqMgr.limitedMap.Load(key)
+		if !isFind {
+			continue
+		}
+		limitedInfo := value.(proto.QuotaLimitedInfo)
+		if (size && limitedInfo.LimitedBytes) || (files && limitedInfo.LimitedFiles) {
+			log.LogWarnf("IsOverQuota mp[%v] user quota type [%v] id [%v] limitedInfo [%v]", uqMgr.mpID, key.Type, key.Id, limitedInfo)
+			return proto.OpNoSpaceErr
+		}
+	}
+	return
+}
+
+func (uqMgr *UserQuotaManager) updateUsedInfo(uid, gid uint32, size int64, files int64) {
+	if size == 0 && files == 0 {
+		return
+	}
+	uqMgr.rwlock.Lock()
+	defer uqMgr.rwlock.Unlock()
+	for _, key := range userQuotaKeys(uid, gid) {
+		addUserQuotaUsedInfo(uqMgr.statisticTemp, key, size, files)
+		if uqMgr.rbuilding {
+			addUserQuotaUsedInfo(uqMgr.statisticRebuildTemp, key, size, files)
+		}
+	}
+	log.LogDebugf("updateUserQuotaUsedInfo mp[%v] uid [%v] gid [%v] size [%v] files [%v]", uqMgr.mpID, uid, gid, size, files)
+}
+
+func (uqMgr *UserQuotaManager) getUsedInfoForTest(key proto.UserQuotaKey) (size int64, files int64) {
+	uqMgr.rwlock.Lock()
+	defer uqMgr.rwlock.Unlock()
+	var usedInfo proto.QuotaUsedInfo
+	if value, isFind := uqMgr.statisticTemp.Load(key); isFind {
+		usedInfo = value.(proto.QuotaUsedInfo)
+	}
+	if value, isFind := uqMgr.statisticBase.Load(key); isFind {
+		baseInfo := value.(proto.QuotaUsedInfo)
+		usedInfo.Add(&baseInfo)
+	}
+	return usedInfo.UsedBytes, usedInfo.UsedFiles
+}
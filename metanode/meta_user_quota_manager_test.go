@@ -0,0 +1,85 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserQuotaUsedInfo(t *testing.T) {
+	uqMgr := NewUserQuotaManager(VolNameForTest, PartitionIdForTest)
+	userKey := proto.UserQuotaKey{Type: proto.UserQuotaTypeUser, Id: 1000}
+	groupKey := proto.UserQuotaKey{Type: proto.UserQuotaTypeGroup, Id: 100}
+
+	uqMgr.updateUsedInfo(1000, 100, 300, 2)
+	uqMgr.updateUsedInfo(1000, 200, 100, 1)
+	size, files := uqMgr.getUsedInfoForTest(userKey)
+	require.Equal(t, int64(400), size)
+	require.Equal(t, int64(3), files)
+	size, files = uqMgr.getUsedInfoForTest(groupKey)
+	require.Equal(t, int64(300), size)
+	require.Equal(t, int64(2), files)
+
+	infos := uqMgr.getUserQuotaReportInfos()
+	require.Equal(t, 3, len(infos))
+
+	// an id without usage is not reported any more
+	uqMgr.updateUsedInfo(1000, 200, -100, -1)
+	for _, info := range uqMgr.getUserQuotaReportInfos() {
+		require.False(t, info.Type == proto.UserQuotaTypeGroup && info.Id == 200)
+	}
+	size, files = uqMgr.getUsedInfoForTest(userKey)
+	require.Equal(t, int64(300), size)
+	require.Equal(t, int64(2), files)
+}
+
+func TestUserQuotaRebuild(t *testing.T) {
+	uqMgr := NewUserQuotaManager(VolNameForTest, PartitionIdForTest)
+	userKey := proto.UserQuotaKey{Type: proto.UserQuotaTypeUser, Id: 1000}
+	uqMgr.updateUsedInfo(1000, 100, 1<<20, 10)
+
+	require.True(t, uqMgr.statisticRebuildStart())
+	require.False(t, uqMgr.statisticRebuildStart())
+	ino := NewInode(2, proto.Mode(0o644))
+	ino.Uid, ino.Gid, ino.Size = 1000, 100, 100
+	uqMgr.statisticInodeByStore(ino)
+	unlinked := NewInode(3, proto.Mode(0o644))
+	unlinked.Uid, unlinked.Gid, unlinked.NLink = 1000, 100, 0
+	uqMgr.statisticInodeByStore(unlinked)
+	// the changes during the rebuild are kept
+	uqMgr.updateUsedInfo(1000, 100, 50, 1)
+	uqMgr.statisticRebuildFin(true)
+
+	size, files := uqMgr.getUsedInfoForTest(userKey)
+	require.Equal(t, int64(150), size)
+	require.Equal(t, int64(2), files)
+}
+
+func TestUserQuotaIsOverQuota(t *testing.T) {
+	uqMgr := NewUserQuotaManager(VolNameForTest, PartitionIdForTest)
+	uqMgr.setUserQuotaHbInfo([]*proto.UserQuotaHeartBeatInfo{
+		{VolName: VolNameForTest, Type: proto.UserQuotaTypeUser, Id: 1000, LimitedInfo: proto.QuotaLimitedInfo{LimitedFiles: true}},
+		{VolName: VolNameForTest, Type: proto.UserQuotaTypeGroup, Id: 100, LimitedInfo: proto.QuotaLimitedInfo{LimitedBytes: true}},
+		{VolName: "other", Type: proto.UserQuotaTypeUser, Id: 2000, LimitedInfo: proto.QuotaLimitedInfo{LimitedBytes: true}},
+	})
+
+	require.Equal(t, uint8(proto.OpNoSpaceErr), uqMgr.IsOverQuota(1000, 200, false, true))
+	require.Equal(t, uint8(0), uqMgr.IsOverQuota(1000, 200, true, false))
+	require.Equal(t, uint8(proto.OpNoSpaceErr), uqMgr.IsOverQuota(2000, 100, true, false))
+	require.Equal(t, uint8(0), uqMgr.IsOverQuota(2000, 200, true, true))
+}
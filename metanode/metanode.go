@@ -64,6 +64,8 @@ type MetaNode struct {
 	raftRetainLogs            uint64
 	raftSyncSnapFormatVersion uint32 // format version of snapshot that raft leader sent to follower
 	zoneName                  string
+	rackName                  string // optional labels of the failure domain reported to the master
+	hostName                  string
 	httpStopC                 chan uint8
 	smuxStopC                 chan uint8
 	metrics                   *MetaNodeMetrics
@@ -208,6 +210,8 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 	m.tickInterval = int(cfg.GetFloat(cfgTickInterval))
 	m.raftRecvBufSize = int(cfg.GetInt(cfgRaftRecvBufSize))
 	m.zoneName = cfg.GetString(cfgZoneName)
+	m.rackName = cfg.GetString(cfgRackName)
+	m.hostName = cfg.GetString(cfgHostName)
 
 	deleteBatchCount := cfg.GetInt64(cfgDeleteBatchCount)
 	if deleteBatchCount > 1 {
@@ -271,7 +275,7 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 
 	if cfg.HasKey(cfgRaftSyncSnapFormatVersion) {
 		raftSyncSnapFormatVersion := uint32(cfg.GetInt64(cfgRaftSyncSnapFormatVersion))
-		if raftSyncSnapFormatVersion < 0 || raftSyncSnapFormatVersion > SnapFormatVersion_1 {
+		if raftSyncSnapFormatVersion < 0 || raftSyncSnapFormatVersion > SnapFormatVersion_2 {
 			m.raftSyncSnapFormatVersion = SnapFormatVersion_1
 			log.LogInfof("invalid config raftSyncSnapFormatVersion, using default[%v]", m.raftSyncSnapFormatVersion)
 		} else {
@@ -285,6 +289,15 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 	syslog.Println("conf raftSyncSnapFormatVersion=", m.raftSyncSnapFormatVersion)
 	log.LogInfof("[parseConfig] raftSyncSnapFormatVersion[%v]", m.raftSyncSnapFormatVersion)
 
+	if cfg.HasKey(cfgRocksCacheCount) {
+		if count := cfg.GetInt64(cfgRocksCacheCount); count > 0 {
+			rocksCacheCount = int(count)
+		}
+	}
+	log.LogInfof("[parseConfig] rocksCacheCount[%v]", rocksCacheCount)
+	enableDeltaSnapshot = cfg.GetBoolWithDefault(cfgEnableDeltaSnapshot, false)
+	log.LogInfof("[parseConfig] enableDeltaSnapshot[%v]", enableDeltaSnapshot)
+
 	constCfg := config.ConstConfig{
 		Listen:           m.listen,
 		RaftHeartbetPort: m.raftHeartbeatPort,
@@ -303,6 +316,7 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 	log.LogInfof("[parseConfig] load raftHeartbeatPort[%v].", m.raftHeartbeatPort)
 	log.LogInfof("[parseConfig] load raftReplicatePort[%v].", m.raftReplicatePort)
 	log.LogInfof("[parseConfig] load zoneName[%v].", m.zoneName)
+	log.LogInfof("[parseConfig] load rackName[%v] hostName[%v].", m.rackName, m.hostName)
 
 	if err = m.parseSmuxConfig(cfg); err != nil {
 		return fmt.Errorf("parseSmuxConfig fail err %v", err)
@@ -99,6 +99,10 @@ type MetaPartitionConfig struct {
 	RaftStore     raftstore.RaftStore `json:"-"`
 	ConnPool      *util.ConnectPool   `json:"-"`
 	Forbidden     bool                `json:"-"`
+	SplitFrom     uint64              `json:"split_from,omitempty"` // the partition this one is split from
+	Splits        []*partitionSplit   `json:"splits,omitempty"`     // the ranges split into other partitions
+	MergeTo       uint64              `json:"merge_to,omitempty"`   // the partition this one is frozen to merge into
+	StoreType     proto.MetaStoreType `json:"store_type,omitempty"` // where the inodes, dentries, extends and multiparts are kept
 }
 
 func (c *MetaPartitionConfig) checkMeta() (err error) {
@@ -140,7 +144,7 @@ type OpInode interface {
 	EvictInode(req *EvictInodeReq, p *Packet, remoteAddr string) (err error)
 	EvictInodeBatch(req *BatchEvictInodeReq, p *Packet, remoteAddr string) (err error)
 	SetAttr(req *SetattrRequest, reqData []byte, p *Packet) (err error)
-	GetInodeTree() *BTree
+	GetInodeTree() Tree
 	GetInodeTreeLen() int
 	DeleteInode(req *proto.DeleteInodeRequest, p *Packet, remoteAddr string) (err error)
 	DeleteInodeBatch(req *proto.DeleteInodeBatchRequest, p *Packet, remoteAddr string) (err error)
@@ -162,6 +166,26 @@ type OpExtend interface {
 	UpdateXAttr(req *proto.UpdateXAttrRequest, p *Packet) (err error)
 }
 
+// OpLock defines the interface for the advisory lock operations.
+type OpLock interface {
+	SetLock(req *proto.SetLockRequest, p *Packet) (err error)
+	GetLock(req *proto.GetLockRequest, p *Packet) (err error)
+	RenewLockLease(req *proto.RenewLockLeaseRequest, p *Packet) (err error)
+}
+
+// OpChange defines the interface for reading the metadata changes.
+type OpChange interface {
+	ReadChanges(req *proto.ReadMetaChangesRequest, p *Packet) (err error)
+}
+
+// OpTrash defines the interface for the trash operations.
+type OpTrash interface {
+	TrashInode(req *proto.TrashInodeRequest, p *Packet, remoteAddr string) (err error)
+	ListTrash(req *proto.ListTrashRequest, p *Packet) (err error)
+	RestoreTrash(req *proto.RestoreTrashRequest, p *Packet) (err error)
+	PurgeTrash(req *proto.PurgeTrashRequest, p *Packet) (err error)
+}
+
 // OpDentry defines the interface for the dentry operations.
 type OpDentry interface {
 	CreateDentry(req *CreateDentryReq, p *Packet, remoteAddr string) (err error)
@@ -172,7 +196,7 @@ type OpDentry interface {
 	ReadDirLimit(req *ReadDirLimitReq, p *Packet) (err error)
 	ReadDirOnly(req *ReadDirOnlyReq, p *Packet) (err error)
 	Lookup(req *LookupReq, p *Packet) (err error)
-	GetDentryTree() *BTree
+	GetDentryTree() Tree
 	GetDentryTreeLen() int
 	TxCreateDentry(req *proto.TxCreateDentryRequest, p *Packet, remoteAddr string) (err error)
 	TxDeleteDentry(req *proto.TxDeleteDentryRequest, p *Packet, remoteAddr string) (err error)
@@ -188,7 +212,7 @@ type OpTransaction interface {
 	TxRollback(req *proto.TxApplyRequest, p *Packet, remoteAddr string) (err error)
 	TxGetInfo(req *proto.TxGetInfoRequest, p *Packet) (err error)
 	TxGetCnt() (uint64, uint64, uint64)
-	TxGetTree() (*BTree, *BTree, *BTree)
+	TxGetTree() (Tree, Tree, Tree)
 }
 
 // OpExtent defines the interface for the extent operations.
@@ -200,7 +224,8 @@ type OpExtent interface {
 	ObjExtentsList(req *proto.GetExtentsRequest, p *Packet) (err error)
 	ExtentsTruncate(req *ExtentsTruncateReq, p *Packet, remoteAddr string) (err error)
 	BatchExtentAppend(req *proto.AppendExtentKeysRequest, p *Packet) (err error)
-	// ExtentsDelete(req *proto.DelExtentKeyRequest, p *Packet) (err error)
+	ExtentsDelete(req *proto.DelExtentKeyRequest, p *Packet) (err error)
+	ExtentsClone(req *proto.CloneExtentsRequest, p *Packet) (err error)
 }
 
 type OpMultipart interface {
@@ -236,6 +261,9 @@ type OpMeta interface {
 	OpExtent
 	OpPartition
 	OpExtend
+	OpLock
+	OpChange
+	OpTrash
 	OpMultipart
 	OpTransaction
 	OpQuota
@@ -256,14 +284,21 @@ type OpPartition interface {
 	PersistMetadata() (err error)
 	RenameStaleMetadata() (err error)
 	ChangeMember(changeType raftproto.ConfChangeType, peer raftproto.Peer, context []byte) (resp interface{}, err error)
+	ReplaceMember(peer, removePeer raftproto.Peer, context []byte) (resp interface{}, err error)
 	Reset() (err error)
 	UpdatePartition(req *UpdatePartitionReq, resp *UpdatePartitionResp) (err error)
 	DeleteRaft() error
 	IsExsitPeer(peer proto.Peer) bool
 	TryToLeader(groupID uint64) error
 	CanRemoveRaftMember(peer proto.Peer) error
+	CanPromoteLearner(peer proto.Peer) error
 	IsEquareCreateMetaPartitionRequst(request *proto.CreateMetaPartitionRequest) (err error)
 	GetUniqID(p *Packet, num uint32) (err error)
+	SplitPartition(req *proto.SplitMetaPartitionRequest, resp *proto.SplitMetaPartitionResponse) (err error)
+	FreezePartition(req *proto.FreezeMetaPartitionRequest, resp *proto.FreezeMetaPartitionResponse) (err error)
+	MergePartition(req *proto.MergeMetaPartitionRequest, source MetaPartition, resp *proto.MergeMetaPartitionResponse) (err error)
+	IsFrozen() bool
+	buildMergeRequest(target, applyID uint64) (req *fsmMergeRequest, err error)
 }
 
 // MetaPartition defines the interface for the meta partition operations.
@@ -468,6 +503,8 @@ func (uMgr *UidManager) accumInoUidSize(ino *Inode, accum *sync.Map) {
 type OpQuota interface {
 	setQuotaHbInfo(infos []*proto.QuotaHeartBeatInfo)
 	getQuotaReportInfos() (infos []*proto.QuotaReportInfo)
+	setUserQuotaHbInfo(infos []*proto.UserQuotaHeartBeatInfo)
+	getUserQuotaReportInfos() (infos []*proto.UserQuotaReportInfo)
 	batchSetInodeQuota(req *proto.BatchSetMetaserverQuotaReuqest,
 		resp *proto.BatchSetMetaserverQuotaResponse) (err error)
 	batchDeleteInodeQuota(req *proto.BatchDeleteMetaserverQuotaReuqest,
@@ -487,10 +524,12 @@ type metaPartition struct {
 	size                   uint64                // For partition all file size
 	applyID                uint64                // Inode/Dentry max applyID, this index will be update after restoring from the dumped data.
 	storedApplyId          uint64                // update after store snapshot to disk
-	dentryTree             *BTree                // btree for dentries
-	inodeTree              *BTree                // btree for inodes
-	extendTree             *BTree                // btree for inode extend (XAttr) management
-	multipartTree          *BTree                // collection for multipart management
+	dentryTree             Tree                  // btree for dentries
+	inodeTree              Tree                  // btree for inodes
+	extendTree             Tree                  // btree for inode extend (XAttr) management
+	multipartTree          Tree                  // collection for multipart management
+	rocksStore             *rocksStore           // the RocksDB keeping the trees, nil if they are in memory
+	loadingDelta           snapshotDelta         // the delta of the snapshot being loaded
 	txProcessor            *TransactionProcessor // transction processor
 	raftPartition          raftstore.Partition
 	stopC                  chan bool
@@ -511,13 +550,25 @@ type metaPartition struct {
 	xattrLock              sync.Mutex
 	fileRange              []int64
 	mqMgr                  *MetaQuotaManager
+	uqMgr                  *UserQuotaManager
 	nonIdempotent          sync.Mutex
 	uniqChecker            *uniqChecker
+	extentRefs             *extentRefs
+	locks                  *lockManager
+	changeLog              *metaChangeLog
 	verSeq                 uint64
 	multiVersionList       *proto.VolVersionInfoList
 	versionLock            sync.Mutex
 	verUpdateChan          chan []byte
 	enableAuditLog         bool
+	writeBlocked           int32 // the writes are rejected on the leader while the partition is split or frozen
+	applying               int32 // whether the raft log is being applied, the items got may be changed then
+	trashApplied           int32 // set once a dentry is moved into the trash, checked by the trash worker
+	truncatedIndex         uint64
+	snapSourceLock         sync.Mutex
+	snapSource             *MetaItemIterator // the items captured for the snapshots sent to the followers
+	snapSourceTime         time.Time
+	snapInstall            *snapshotInstall // the snapshot failed to be applied
 }
 
 func (mp *metaPartition) IsForbidden() bool {
@@ -728,12 +779,15 @@ func (mp *metaPartition) onStart(isCreate bool) (err error) {
 	}
 
 	mp.vol.volDeleteLockTime = volumeInfo.DeleteLockTime
+	atomic.StoreInt64(&mp.vol.trashRetention, volumeInfo.TrashRetention)
 
 	go mp.runVersionOp()
 
 	mp.volType = volumeInfo.VolType
 	var ebsClient *blobstore.BlobStoreClient
-	if clusterInfo.EbsAddr != "" && proto.IsCold(mp.volType) {
+	// the inodes of hot volumes may also own blobstore data once they are transitioned
+	// to the cold storage class by the lifecycle rules
+	if clusterInfo.EbsAddr != "" {
 		ebsClient, err = blobstore.NewEbsClient(
 			access.Config{
 				ConnMode: access.NoLimitConnMode,
@@ -796,6 +850,7 @@ func (mp *metaPartition) onStop() {
 		mp.delInodeFp.Sync()
 		mp.delInodeFp.Close()
 	}
+	mp.closeRocksStore()
 }
 
 func (mp *metaPartition) startRaft() (err error) {
@@ -817,6 +872,15 @@ func (mp *metaPartition) startRaft() (err error) {
 			HeartbeatPort: heartbeatPort,
 			ReplicaPort:   replicaPort,
 		}
+		// the joint consensus of the replacement in progress is restored
+		switch {
+		case peer.IsLearner:
+			rp.Type = raftproto.PeerLearner
+		case peer.Joint == proto.PeerJointIncoming:
+			rp.Type = raftproto.PeerIncoming
+		case peer.Joint == proto.PeerJointOutgoing:
+			rp.Type = raftproto.PeerOutgoing
+		}
 		peers = append(peers, rp)
 	}
 	log.LogInfof("start partition id=%d,applyID:%v raft peers: %s",
@@ -881,6 +945,9 @@ func NewMetaPartition(conf *MetaPartitionConfig, manager *metadataManager) MetaP
 		vol:           NewVol(),
 		manager:       manager,
 		uniqChecker:   newUniqChecker(),
+		extentRefs:    newExtentRefs(),
+		locks:         newLockManager(),
+		changeLog:     newMetaChangeLog(defaultMetaChangeLogSize),
 		verSeq:        conf.VerSeq,
 		multiVersionList: &proto.VolVersionInfoList{
 			TemporaryVerMap: make(map[uint64]*proto.VolVersionInfo),
@@ -1010,6 +1077,9 @@ const (
 	CRC_COUNT_TX_STUFF   int = 7
 	CRC_COUNT_UINQ_STUFF int = 8
 	CRC_COUNT_MULTI_VER  int = 9
+	CRC_COUNT_EXT_REFS   int = 10
+	CRC_COUNT_CHANGE_LOG int = 11
+	CRC_COUNT_DELTA      int = 12
 )
 
 func (mp *metaPartition) LoadSnapshot(snapshotPath string) (err error) {
@@ -1026,7 +1096,8 @@ func (mp *metaPartition) LoadSnapshot(snapshotPath string) (err error) {
 	}
 
 	crc_count := len(crcs)
-	if crc_count != CRC_COUNT_BASIC && crc_count != CRC_COUNT_TX_STUFF && crc_count != CRC_COUNT_UINQ_STUFF && crc_count != CRC_COUNT_MULTI_VER {
+	if crc_count != CRC_COUNT_BASIC && crc_count != CRC_COUNT_TX_STUFF && crc_count != CRC_COUNT_UINQ_STUFF && crc_count != CRC_COUNT_MULTI_VER &&
+		crc_count != CRC_COUNT_EXT_REFS && crc_count != CRC_COUNT_CHANGE_LOG && crc_count != CRC_COUNT_DELTA {
 		log.LogErrorf("action[LoadSnapshot] crc array length %d not match", len(crcs))
 		return ErrSnapshotCrcMismatch
 	}
@@ -1045,13 +1116,32 @@ func (mp *metaPartition) LoadSnapshot(snapshotPath string) (err error) {
 		loadFuncs = append(loadFuncs, mp.loadUniqChecker)
 	}
 
-	if crc_count == CRC_COUNT_MULTI_VER {
+	if crc_count >= CRC_COUNT_MULTI_VER {
 		if err = mp.loadMultiVer(snapshotPath, crcs[CRC_COUNT_MULTI_VER-1]); err != nil {
 			return
 		}
 	} else {
 		mp.storeMultiVersion(snapshotPath, &storeMsg{multiVerList: mp.multiVersionList.VerList})
 	}
+	if crc_count >= CRC_COUNT_EXT_REFS {
+		if err = mp.loadExtentRefs(snapshotPath, crcs[CRC_COUNT_EXT_REFS-1]); err != nil {
+			return
+		}
+	}
+	if crc_count >= CRC_COUNT_CHANGE_LOG {
+		if err = mp.loadMetaChangeLog(snapshotPath, crcs[CRC_COUNT_CHANGE_LOG-1]); err != nil {
+			return
+		}
+	}
+	if crc_count >= CRC_COUNT_DELTA {
+		// the trees are loaded from the base files and the delta on them
+		if mp.loadingDelta, err = mp.loadDelta(snapshotPath, crcs[CRC_COUNT_DELTA-1]); err != nil {
+			return
+		}
+		defer func() {
+			mp.loadingDelta = nil
+		}()
+	}
 
 	errs := make([]error, len(loadFuncs))
 	var wg sync.WaitGroup
@@ -1107,6 +1197,11 @@ func (mp *metaPartition) LoadSnapshot(snapshotPath string) (err error) {
 	if err = mp.loadApplyID(snapshotPath); err != nil {
 		return
 	}
+	if crc_count < CRC_COUNT_CHANGE_LOG {
+		// the changes before the snapshot are not kept by the old version
+		mp.changeLog.reset(mp.applyID)
+	}
+	mp.storedDeltaBase(mp.loadingDelta)
 	return
 }
 
@@ -1114,6 +1209,10 @@ func (mp *metaPartition) load(isCreate bool) (err error) {
 	if err = mp.loadMetadata(); err != nil {
 		return
 	}
+	if err = mp.openRocksTrees(); err != nil {
+		return
+	}
+	mp.trackTrees(mp.inodeTree, mp.dentryTree, mp.extendTree, mp.multipartTree)
 	// 1. create new metaPartition, no need to load snapshot
 	// 2. store the snapshot files for new mp, because
 	// mp.load() will check all the snapshot files when mn startup
@@ -1125,6 +1224,9 @@ func (mp *metaPartition) load(isCreate bool) (err error) {
 		return
 	}
 
+	if err = mp.recoverRocksStore(); err != nil {
+		return
+	}
 	snapshotPath := path.Join(mp.config.RootDir, snapshotDir)
 	if _, err = os.Stat(snapshotPath); err != nil {
 		log.LogErrorf("load snapshot failed, err: %s", err.Error())
@@ -1132,6 +1234,9 @@ func (mp *metaPartition) load(isCreate bool) (err error) {
 
 	}
 
+	if mp.rocksStore != nil {
+		return mp.loadRocksSnapshot(snapshotPath)
+	}
 	return mp.LoadSnapshot(snapshotPath)
 }
 
@@ -1147,13 +1252,16 @@ func (mp *metaPartition) store(sm *storeMsg) (err error) {
 		return
 	}
 
+	var rocksTrees []*RocksTree
 	defer func() {
-		if err != nil {
+		// the snapshot flushed to RocksDB is kept to be renamed by the next load
+		if err != nil && len(rocksTrees) == 0 {
 			// TODO Unhandled errors
 			os.RemoveAll(tmpDir)
 		}
 	}()
 	crcBuffer := bytes.NewBuffer(make([]byte, 0, 16))
+	sm.deltaBase = mp.deltaBase(sm)
 	storeFuncs := []func(dir string, sm *storeMsg) (uint32, error){
 		mp.storeInode,
 		mp.storeDentry,
@@ -1164,12 +1272,22 @@ func (mp *metaPartition) store(sm *storeMsg) (err error) {
 		mp.storeTxRbDentry,
 		mp.storeUniqChecker,
 		mp.storeMultiVersion,
+		mp.storeExtentRefs,
+		mp.storeMetaChangeLog,
 	}
-	for _, storeFunc := range storeFuncs {
+	if sm.deltaBase != nil {
+		storeFuncs = append(storeFuncs, mp.storeDelta)
+	}
+	for idx, storeFunc := range storeFuncs {
 		var crc uint32
 		if crc, err = storeFunc(tmpDir, sm); err != nil {
 			return
 		}
+		if idx < len(sm.deltaBase) {
+			if crc, err = mp.linkBaseFile(tmpDir, idx, sm.deltaBase[idx]); err != nil {
+				return
+			}
+		}
 		if crcBuffer.Len() != 0 {
 			crcBuffer.WriteString(" ")
 		}
@@ -1190,6 +1308,10 @@ func (mp *metaPartition) store(sm *storeMsg) (err error) {
 	if err = os.WriteFile(path.Join(tmpDir, SnapshotSign), crcBuffer.Bytes(), 0o775); err != nil {
 		return
 	}
+	// the snapshot is complete once the trees kept in RocksDB are flushed
+	if rocksTrees, err = mp.flushRocksTrees(sm); err != nil {
+		return
+	}
 	snapshotDir := path.Join(mp.config.RootDir, snapshotDir)
 	// check snapshot backup
 	backupDir := path.Join(mp.config.RootDir, snapshotBackup)
@@ -1216,6 +1338,10 @@ func (mp *metaPartition) store(sm *storeMsg) (err error) {
 	if err != nil {
 		return
 	}
+	for _, t := range rocksTrees {
+		t.live.evict(t.epoch)
+	}
+	mp.storedSnapshot(sm)
 
 	mp.storedApplyId = sm.applyIndex
 	return
@@ -1254,6 +1380,12 @@ func (mp *metaPartition) ChangeMember(changeType raftproto.ConfChangeType, peer
 	return
 }
 
+// ReplaceMember replaces the raft member through a joint consensus.
+func (mp *metaPartition) ReplaceMember(peer, removePeer raftproto.Peer, context []byte) (resp interface{}, err error) {
+	resp, err = mp.raftPartition.ReplaceMember(peer, removePeer, context)
+	return
+}
+
 // GetBaseConfig returns the configuration stored in the meta partition. TODO remove? no usage?
 func (mp *metaPartition) GetBaseConfig() MetaPartitionConfig {
 	return *mp.config
@@ -1710,6 +1842,8 @@ func (mp *metaPartition) storeSnapshotFiles() (err error) {
 		txRbDentryTree: NewBtree(),
 		uniqId:         mp.GetUniqId(),
 		uniqChecker:    newUniqChecker(),
+		extentRefs:     newExtentRefs(),
+		changeLog:      newMetaChangeLog(defaultMetaChangeLogSize),
 		multiVerList:   mp.multiVersionList.VerList,
 	}
 
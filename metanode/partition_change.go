@@ -0,0 +1,241 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"sort"
+	"sync"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/errors"
+)
+
+const metaChangeLogVersion = 1
+
+// metaChangeLog keeps the latest metadata changes applied to the partition in the order of the
+// raft index. The changes applied at the same index are always dropped or returned together,
+// so that a reader never resumes from the middle of an index. The log is stored with the
+// snapshot of the partition and sent to the followers by the raft snapshot, so that the
+// cursors of the readers are still valid after the metanode restarts or the leader changes.
+type metaChangeLog struct {
+	sync.RWMutex
+	changes  []*proto.MetaChange
+	since    uint64 // all the changes applied after the index are kept
+	capacity int
+}
+
+func newMetaChangeLog(capacity int) *metaChangeLog {
+	return &metaChangeLog{capacity: capacity}
+}
+
+func (l *metaChangeLog) clone() *metaChangeLog {
+	l.RLock()
+	defer l.RUnlock()
+	changes := make([]*proto.MetaChange, len(l.changes))
+	copy(changes, l.changes)
+	return &metaChangeLog{changes: changes, since: l.since, capacity: l.capacity}
+}
+
+func (l *metaChangeLog) Len() int {
+	l.RLock()
+	defer l.RUnlock()
+	return len(l.changes)
+}
+
+func (l *metaChangeLog) append(change *proto.MetaChange) {
+	l.Lock()
+	defer l.Unlock()
+	l.changes = append(l.changes, change)
+	if len(l.changes) <= l.capacity {
+		return
+	}
+	drop := len(l.changes) - l.capacity
+	for drop < len(l.changes) && l.changes[drop].Index == l.changes[drop-1].Index {
+		drop++
+	}
+	l.since = l.changes[drop-1].Index
+	l.changes = l.changes[drop:]
+}
+
+// reset drops all the changes, the readers behind the index have to resync the partition.
+func (l *metaChangeLog) reset(index uint64) {
+	l.Lock()
+	defer l.Unlock()
+	l.changes = nil
+	l.since = index
+}
+
+// read returns the changes applied after the cursor, applied must be loaded before the changes are read.
+func (l *metaChangeLog) read(from uint64, limit int, applied uint64) (resp *proto.ReadMetaChangesResponse) {
+	l.RLock()
+	defer l.RUnlock()
+	resp = &proto.ReadMetaChangesResponse{Changes: make([]*proto.MetaChange, 0), Last: applied}
+	if from < l.since {
+		resp.Truncated = true
+		resp.Applied = applied
+		return
+	}
+	start := sort.Search(len(l.changes), func(i int) bool {
+		return l.changes[i].Index > from
+	})
+	end := start + limit
+	if end >= len(l.changes) {
+		resp.Changes = append(resp.Changes, l.changes[start:]...)
+		resp.Applied = applied
+		if len(resp.Changes) > 0 && resp.Changes[len(resp.Changes)-1].Index > applied {
+			resp.Applied = resp.Changes[len(resp.Changes)-1].Index
+		}
+		if resp.Applied < from {
+			resp.Applied = from
+		}
+		return
+	}
+	for end < len(l.changes) && l.changes[end].Index == l.changes[end-1].Index {
+		end++
+	}
+	resp.Changes = append(resp.Changes, l.changes[start:end]...)
+	resp.Applied = l.changes[end-1].Index
+	return
+}
+
+// Marshal encodes the log as the version, the index kept since and the changes,
+// each change is encoded as index, type, inode, parent inode, the length of the name and the name.
+func (l *metaChangeLog) Marshal() (buf []byte, crc uint32, err error) {
+	l.RLock()
+	defer l.RUnlock()
+	buffer := bytes.NewBuffer(make([]byte, 0, 12+len(l.changes)*32))
+	if err = binary.Write(buffer, binary.BigEndian, int32(metaChangeLogVersion)); err != nil {
+		return
+	}
+	if err = binary.Write(buffer, binary.BigEndian, l.since); err != nil {
+		return
+	}
+	for _, change := range l.changes {
+		if err = binary.Write(buffer, binary.BigEndian, change.Index); err != nil {
+			return
+		}
+		if err = buffer.WriteByte(change.Type); err != nil {
+			return
+		}
+		if err = binary.Write(buffer, binary.BigEndian, change.Inode); err != nil {
+			return
+		}
+		if err = binary.Write(buffer, binary.BigEndian, change.ParentIno); err != nil {
+			return
+		}
+		if err = binary.Write(buffer, binary.BigEndian, uint32(len(change.Name))); err != nil {
+			return
+		}
+		if _, err = buffer.WriteString(change.Name); err != nil {
+			return
+		}
+	}
+	buf = buffer.Bytes()
+	crc = crc32.ChecksumIEEE(buf)
+	return
+}
+
+func (l *metaChangeLog) UnMarshal(data []byte) (err error) {
+	if len(data) < 12 {
+		return errors.New("invalid meta change log length")
+	}
+	buff := bytes.NewBuffer(data)
+	var (
+		version int32
+		since   uint64
+	)
+	if err = binary.Read(buff, binary.BigEndian, &version); err != nil {
+		return
+	}
+	if err = binary.Read(buff, binary.BigEndian, &since); err != nil {
+		return
+	}
+	changes := make([]*proto.MetaChange, 0)
+	for buff.Len() > 0 {
+		change := &proto.MetaChange{}
+		var nameLen uint32
+		if err = binary.Read(buff, binary.BigEndian, &change.Index); err != nil {
+			return
+		}
+		if change.Type, err = buff.ReadByte(); err != nil {
+			return
+		}
+		if err = binary.Read(buff, binary.BigEndian, &change.Inode); err != nil {
+			return
+		}
+		if err = binary.Read(buff, binary.BigEndian, &change.ParentIno); err != nil {
+			return
+		}
+		if err = binary.Read(buff, binary.BigEndian, &nameLen); err != nil {
+			return
+		}
+		if int(nameLen) > buff.Len() {
+			return errors.New("invalid meta change name length")
+		}
+		change.Name = string(buff.Next(int(nameLen)))
+		changes = append(changes, change)
+	}
+	l.Lock()
+	l.changes = changes
+	l.since = since
+	l.Unlock()
+	return
+}
+
+func (mp *metaPartition) recordChange(index uint64, tp uint8, inode, parentIno uint64, name string) {
+	mp.changeLog.append(&proto.MetaChange{
+		Index:     index,
+		Type:      tp,
+		Inode:     inode,
+		ParentIno: parentIno,
+		Name:      name,
+	})
+}
+
+func (mp *metaPartition) recordDentryChange(index uint64, tp uint8, dentry *Dentry) {
+	mp.recordChange(index, tp, dentry.Inode, dentry.ParentId, dentry.Name)
+}
+
+func (mp *metaPartition) recordInodeChange(index uint64, inode uint64) {
+	mp.recordChange(index, proto.MetaChangeInodeUpdate, inode, 0, "")
+}
+
+func (mp *metaPartition) recordInodeBatchChange(index uint64, tp uint8, inodes InodeBatch, resps []*InodeResponse) {
+	for i, resp := range resps {
+		if resp.Status == proto.OpOk && i < len(inodes) {
+			mp.recordChange(index, tp, inodes[i].Inode, 0, "")
+		}
+	}
+}
+
+// ReadChanges returns the metadata changes applied to the partition after the cursor.
+func (mp *metaPartition) ReadChanges(req *proto.ReadMetaChangesRequest, p *Packet) (err error) {
+	limit := int(req.Limit)
+	if limit <= 0 || limit > maxReadMetaChangesLimit {
+		limit = maxReadMetaChangesLimit
+	}
+	resp := mp.changeLog.read(req.From, limit, mp.getApplyID())
+	reply, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
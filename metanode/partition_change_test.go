@@ -0,0 +1,96 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func appendTestChange(l *metaChangeLog, index, ino uint64) {
+	l.append(&proto.MetaChange{Index: index, Type: proto.MetaChangeInodeUpdate, Inode: ino})
+}
+
+func TestMetaChangeLogRead(t *testing.T) {
+	l := newMetaChangeLog(8)
+	appendTestChange(l, 10, 1)
+	appendTestChange(l, 11, 2)
+	appendTestChange(l, 11, 3)
+	appendTestChange(l, 12, 4)
+
+	resp := l.read(9, 100, 13)
+	require.False(t, resp.Truncated)
+	require.Len(t, resp.Changes, 4)
+	require.Equal(t, uint64(13), resp.Applied)
+
+	// the changes of the same index are never split
+	resp = l.read(9, 2, 13)
+	require.Len(t, resp.Changes, 3)
+	require.Equal(t, uint64(11), resp.Applied)
+	require.Equal(t, uint64(13), resp.Last)
+
+	resp = l.read(11, 100, 13)
+	require.Len(t, resp.Changes, 1)
+	require.Equal(t, uint64(4), resp.Changes[0].Inode)
+
+	resp = l.read(13, 100, 13)
+	require.Empty(t, resp.Changes)
+	require.Equal(t, uint64(13), resp.Applied)
+}
+
+func TestMetaChangeLogTruncate(t *testing.T) {
+	l := newMetaChangeLog(2)
+	appendTestChange(l, 1, 1)
+	appendTestChange(l, 2, 2)
+	appendTestChange(l, 2, 3)
+
+	resp := l.read(0, 100, 2)
+	require.True(t, resp.Truncated)
+	resp = l.read(1, 100, 2)
+	require.False(t, resp.Truncated)
+	require.Len(t, resp.Changes, 2)
+
+	l.reset(20)
+	resp = l.read(2, 100, 20)
+	require.True(t, resp.Truncated)
+	require.Equal(t, uint64(20), resp.Applied)
+	resp = l.read(20, 100, 20)
+	require.False(t, resp.Truncated)
+	require.Empty(t, resp.Changes)
+}
+
+func TestMetaChangeLogMarshal(t *testing.T) {
+	l := newMetaChangeLog(8)
+	l.reset(5)
+	l.append(&proto.MetaChange{Index: 6, Type: proto.MetaChangeDentryCreate, Inode: 10, ParentIno: 1, Name: "a"})
+	l.append(&proto.MetaChange{Index: 7, Type: proto.MetaChangeInodeUnlink, Inode: 10})
+
+	data, crc, err := l.Marshal()
+	require.NoError(t, err)
+	require.NotZero(t, crc)
+
+	loaded := newMetaChangeLog(8)
+	require.NoError(t, loaded.UnMarshal(data))
+	require.Equal(t, 2, loaded.Len())
+	resp := loaded.read(5, 100, 7)
+	require.False(t, resp.Truncated)
+	require.Equal(t, l.changes, resp.Changes)
+	require.True(t, loaded.read(4, 100, 7).Truncated)
+
+	require.Error(t, loaded.UnMarshal(data[:8]))
+	require.Error(t, loaded.UnMarshal(data[:len(data)-1]))
+}
@@ -0,0 +1,206 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// createClonePartition creates the partition of a cloned volume from the items of the source
+// partition at the version of the clone. The source partition must be served by this metanode,
+// every replica builds the same items since the version is not changed any more.
+func (m *metadataManager) createClonePartition(request *proto.CreateMetaPartitionRequest) (err error) {
+	if mp, err := m.getPartition(request.PartitionID); err == nil {
+		return mp.IsEquareCreateMetaPartitionRequst(request)
+	}
+	source, err := m.getPartition(request.CloneFrom)
+	if err != nil {
+		return fmt.Errorf("[createClonePartition] source partition(%v) is not on this metanode", request.CloneFrom)
+	}
+	rootDir := path.Join(m.rootDir, partitionPrefix+strconv.FormatUint(request.PartitionID, 10))
+	if err = source.(*metaPartition).storeClonePartition(request, rootDir); err != nil {
+		return fmt.Errorf("[createClonePartition] store partition(%v) cloned from(%v) err(%v)",
+			request.PartitionID, request.CloneFrom, err)
+	}
+
+	partitionConfig := &MetaPartitionConfig{
+		PartitionId: request.PartitionID,
+		NodeId:      m.nodeId,
+		RaftStore:   m.raftStore,
+		RootDir:     rootDir,
+		ConnPool:    m.connPool,
+	}
+	partitionConfig.AfterStop = func() {
+		m.detachPartition(request.PartitionID)
+	}
+	if err = m.attachPartition(request.PartitionID, NewMetaPartition(partitionConfig, m)); err != nil {
+		os.RemoveAll(rootDir)
+		return
+	}
+	log.LogWarnf("[createClonePartition] partition(%v) of vol(%v) is cloned from partition(%v) at version(%v)",
+		request.PartitionID, request.VolName, request.CloneFrom, request.CloneVerSeq)
+	return
+}
+
+// storeClonePartition stores the snapshot of the partition cloned at the version verSeq.
+func (mp *metaPartition) storeClonePartition(request *proto.CreateMetaPartitionRequest, rootDir string) (err error) {
+	if mp.config.Start != request.Start || mp.config.End != request.End {
+		return fmt.Errorf("partition(%v) range(%v,%v) is not the range(%v,%v) to clone",
+			mp.config.PartitionId, mp.config.Start, mp.config.End, request.Start, request.End)
+	}
+	conf := &MetaPartitionConfig{
+		PartitionId:   request.PartitionID,
+		VolName:       request.VolName,
+		Start:         request.Start,
+		End:           request.End,
+		PartitionType: mp.config.PartitionType,
+		Peers:         request.Members,
+		Cursor:        mp.GetCursor(),
+		RootDir:       rootDir,
+		VerSeq:        request.VerSeq,
+		StoreType:     request.StoreType,
+	}
+	child := NewMetaPartition(conf, mp.manager).(*metaPartition)
+	child.uidManager = NewUidMgr(conf.VolName, conf.PartitionId)
+	child.mqMgr = NewQuotaManager(conf.VolName, conf.PartitionId)
+	child.uqMgr = NewUserQuotaManager(conf.VolName, conf.PartitionId)
+
+	inodeTree, dentryTree, extendTree, refs := mp.cloneItems(conf.PartitionId, request.CloneVerSeq)
+	sm := &storeMsg{
+		command:        opFSMStoreTick,
+		inodeTree:      inodeTree,
+		dentryTree:     dentryTree,
+		extendTree:     extendTree,
+		multipartTree:  NewBtree(),
+		txTree:         NewBtree(),
+		txRbInodeTree:  NewBtree(),
+		txRbDentryTree: NewBtree(),
+		uniqChecker:    newUniqChecker(),
+		extentRefs:     refs,
+		changeLog:      child.changeLog,
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(rootDir)
+		}
+	}()
+	if err = os.MkdirAll(rootDir, 0o755); err != nil {
+		return
+	}
+	if err = child.store(sm); err != nil {
+		return
+	}
+	log.LogWarnf("[storeClonePartition] mp(%v) cloned to mp(%v) at version(%v) inodes(%v) shared extents(%v)",
+		mp.config.PartitionId, conf.PartitionId, request.CloneVerSeq, inodeTree.Len(), refs.Len())
+	return child.PersistMetadata()
+}
+
+// cloneItems returns the items of the partition as they were at the version verSeq, without
+// their history. The extents are still referred by the version of the source volume, so every
+// extent is counted once more than the inodes of the clone holding it and is never deleted by
+// the clone, and the clients write the extents by append.
+func (mp *metaPartition) cloneItems(id, verSeq uint64) (inodeTree, dentryTree, extendTree Tree, refs *extentRefs) {
+	inodeTree, dentryTree, extendTree, refs = NewBtree(), NewBtree(), NewBtree(), newExtentRefs()
+	holders := make(map[uint64]uint32)
+	mp.inodeTree.GetTree().Ascend(func(i BtreeItem) bool {
+		ino := mp.cloneInodeByVer(id, i.(*Inode), verSeq)
+		if ino == nil {
+			return true
+		}
+		held := make(map[uint64]bool)
+		ino.Extents.Range(func(_ int, ek proto.ExtentKey) bool {
+			if key := extentRefKey(&ek); !held[key] {
+				held[key] = true
+				holders[key]++
+			}
+			return true
+		})
+		inodeTree.ReplaceOrInsert(ino, true)
+		return true
+	})
+	for key, cnt := range holders {
+		refs.refs[key] = cnt + 1
+	}
+
+	mp.dentryTree.GetTree().Ascend(func(i BtreeItem) bool {
+		den, _ := i.(*Dentry).getDentryFromVerList(verSeq, false)
+		if den == nil || den.isDeleted() {
+			return true
+		}
+		dentryTree.ReplaceOrInsert(&Dentry{
+			ParentId: den.ParentId,
+			Name:     den.Name,
+			Inode:    den.Inode,
+			Type:     den.Type,
+		}, true)
+		return true
+	})
+
+	mp.extendTree.GetTree().Ascend(func(i BtreeItem) bool {
+		extend := i.(*Extend)
+		if inodeTree.Get(NewInode(extend.GetInode(), 0)) == nil {
+			return true
+		}
+		if extend = extend.getExtendByVer(verSeq); extend == nil {
+			return true
+		}
+		clone := NewExtend(extend.GetInode())
+		extend.Range(func(key, value []byte) bool {
+			clone.Put(key, value, 0)
+			return true
+		})
+		extendTree.ReplaceOrInsert(clone, true)
+		return true
+	})
+	return
+}
+
+// cloneInodeByVer returns a copy of the inode as it was at the version verSeq, or nil if the
+// inode did not exist then. The pieces of an extent held by the copy are counted again.
+func (mp *metaPartition) cloneInodeByVer(id uint64, ino *Inode, verSeq uint64) *Inode {
+	layer, _ := ino.getInoByVer(verSeq, false)
+	if layer == nil || layer.NLink == 0 || layer.ShouldDelete() {
+		return nil
+	}
+	resp := &proto.GetExtentsResponse{}
+	mp.GetExtentByVer(ino, &proto.GetExtentsRequest{VerSeq: verSeq}, resp)
+
+	// the clone starts its own history of versions
+	clone := layer.Copy().(*Inode)
+	clone.multiSnap = nil
+	clone.setVerNoCheck(0)
+	pieces := make(map[uint64]int)
+	for idx := range resp.Extents {
+		pieces[extentRefKey(&resp.Extents[idx])]++
+	}
+	eks := make([]proto.ExtentKey, 0, len(resp.Extents))
+	for _, ek := range resp.Extents {
+		ek.SnapInfo = nil
+		ek.SetSeq(0)
+		if pieces[extentRefKey(&ek)] > 1 {
+			clone.insertEkRefMap(id, &ek)
+		}
+		eks = append(eks, ek)
+	}
+	clone.Extents = NewSortedExtents()
+	clone.Extents.eks = eks
+	return clone
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"fmt"
+	"path"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClonePartitionItems(t *testing.T) {
+	mp := newSplitTestPartition(t, 1, 1, 1000)
+	shared := proto.ExtentKey{FileOffset: 0, PartitionId: 10, ExtentId: 1, Size: 4096}
+	for ino := uint64(1); ino <= 3; ino++ {
+		inode := NewInode(ino, FileModeType)
+		inode.setVer(5)
+		own := proto.ExtentKey{FileOffset: 4096, PartitionId: 10, ExtentId: 100 + ino, Size: 4096}
+		shared.SetSeq(5)
+		own.SetSeq(5)
+		inode.Extents.eks = []proto.ExtentKey{shared, own}
+		mp.inodeTree.ReplaceOrInsert(inode, true)
+		if ino != 3 {
+			mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: fmt.Sprint(ino), Inode: ino, multiSnap: NewDentrySnap(5)}, true)
+		}
+	}
+	// the extents written and the inodes unlinked after the version are not cloned
+	later := proto.ExtentKey{FileOffset: 8192, PartitionId: 10, ExtentId: 200, Size: 4096}
+	later.SetSeq(20)
+	ino1 := mp.inodeTree.Get(NewInode(1, 0)).(*Inode)
+	ino1.Extents.eks = append(ino1.Extents.eks, later)
+	mp.inodeTree.Get(NewInode(3, 0)).(*Inode).NLink = 0
+	extend := NewExtend(2)
+	extend.Put([]byte("user.k"), []byte("v"), 5)
+	mp.extendTree.ReplaceOrInsert(extend, true)
+	mp.config.Cursor = 3
+
+	inodeTree, dentryTree, extendTree, refs := mp.cloneItems(2, 10)
+	require.Equal(t, 2, inodeTree.Len())
+	require.Equal(t, 2, dentryTree.Len())
+	require.Equal(t, 1, extendTree.Len())
+
+	clone := inodeTree.Get(NewInode(1, 0)).(*Inode)
+	require.Equal(t, uint64(0), clone.getVer())
+	require.Equal(t, 2, clone.Extents.Len())
+	for _, ek := range clone.Extents.eks {
+		require.Equal(t, uint64(0), ek.GetSeq())
+	}
+
+	// the shared extent is held by both cloned inodes and by the source version
+	require.Equal(t, 3, refs.Len())
+	require.Equal(t, uint32(3), refs.refs[extentRefKey(&shared)])
+	own := proto.ExtentKey{PartitionId: 10, ExtentId: 101}
+	require.Equal(t, uint32(2), refs.refs[extentRefKey(&own)])
+
+	// the clone is loaded from the stored snapshot
+	rootDir := path.Join(path.Dir(mp.config.RootDir), partitionPrefix+"2")
+	request := &proto.CreateMetaPartitionRequest{
+		PartitionID: 2,
+		VolName:     "cloneVol",
+		Start:       1,
+		End:         1000,
+		Members:     mp.config.Peers,
+		CloneFrom:   1,
+		CloneVerSeq: 10,
+	}
+	require.NoError(t, mp.storeClonePartition(request, rootDir))
+	child := NewMetaPartition(&MetaPartitionConfig{PartitionId: 2, RootDir: rootDir}, &metadataManager{}).(*metaPartition)
+	require.NoError(t, child.load(false))
+	require.Equal(t, "cloneVol", child.config.VolName)
+	require.Equal(t, uint64(3), child.GetCursor())
+	require.Equal(t, 2, child.inodeTree.Len())
+	require.Equal(t, 3, child.extentRefs.Len())
+
+	request.End = 2000
+	require.Error(t, mp.storeClonePartition(request, rootDir))
+}
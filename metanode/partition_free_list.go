@@ -23,6 +23,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cubefs/cubefs/proto"
@@ -61,6 +62,7 @@ func (mp *metaPartition) startFreeList() (err error) {
 	// start vol update ticket
 	go mp.updateVolWorker()
 	go mp.deleteWorker()
+	go mp.trashWorker()
 	mp.startToDeleteExtents()
 	return
 }
@@ -83,6 +85,7 @@ func (mp *metaPartition) updateVolView(convert func(view *proto.DataPartitionsVi
 		return
 	}
 	mp.vol.volDeleteLockTime = volView.DeleteLockTime
+	atomic.StoreInt64(&mp.vol.trashRetention, volView.TrashRetention)
 	return nil
 }
 
@@ -292,13 +295,20 @@ func (mp *metaPartition) deleteMarkedInodes(inoSlice []uint64) {
 			return
 		}
 
+		// the followers release the shared extents while applying the deletion of the inode,
+		// the leader does it here since the extents are cleared once collected
+		released := mp.releaseInodeExtents(inode)
 		extInfo := inode.GetAllExtsOfflineInode(mp.config.PartitionId)
 		for dpID, inodeExts := range extInfo {
 			exts, ok := deleteExtentsByPartition[dpID]
 			if !ok {
 				exts = make([]*proto.ExtentKey, 0)
 			}
-			exts = append(exts, inodeExts...)
+			for _, ek := range inodeExts {
+				if !released[extentRefKey(ek)] {
+					exts = append(exts, ek)
+				}
+			}
 			log.LogWritef("[deleteMarkedInodes] mp[%v] ino(%v) deleteExtent(%v)", mp.config.PartitionId, inode.Inode, len(inodeExts))
 			deleteExtentsByPartition[dpID] = exts
 		}
@@ -306,7 +316,7 @@ func (mp *metaPartition) deleteMarkedInodes(inoSlice []uint64) {
 		allInodes = append(allInodes, inode)
 	}
 
-	if proto.IsCold(mp.volType) {
+	if proto.IsCold(mp.volType) || mp.ebsClient != nil {
 		// delete ebs obj extents
 		shouldCommit, shouldRePushToFreeList = mp.doBatchDeleteObjExtentsInEBS(allInodes)
 		log.LogInfof("[deleteMarkedInodes] metaPartition(%v) deleteInodeCnt(%d) shouldRePush(%d)",
@@ -48,6 +48,8 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 
 	mp.nonIdempotent.Lock()
 	defer mp.nonIdempotent.Unlock()
+	atomic.StoreInt32(&mp.applying, 1)
+	defer atomic.StoreInt32(&mp.applying, 0)
 
 	switch msg.Op {
 	case opFSMCreateInode:
@@ -58,7 +60,11 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		if mp.config.Cursor < ino.Inode {
 			mp.config.Cursor = ino.Inode
 		}
-		resp = mp.fsmCreateInode(ino)
+		status := mp.fsmCreateInode(ino)
+		if status == proto.OpOk {
+			mp.recordChange(index, proto.MetaChangeInodeCreate, ino.Inode, 0, "")
+		}
+		resp = status
 	case opFSMCreateInodeQuota:
 		qinode := &MetaQuotaInode{}
 		if err = qinode.Unmarshal(msg.V); err != nil {
@@ -76,6 +82,7 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			for _, quotaId := range qinode.quotaIds {
 				mp.mqMgr.updateUsedInfo(0, 1, quotaId)
 			}
+			mp.recordChange(index, proto.MetaChangeInodeCreate, ino.Inode, 0, "")
 		}
 	case opFSMUnlinkInode:
 		ino := NewInode(0, 0)
@@ -88,7 +95,11 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			resp = &InodeResponse{Status: status}
 			return
 		}
-		resp = mp.fsmUnlinkInode(ino, 0)
+		unlinkResp := mp.fsmUnlinkInode(ino, 0)
+		if unlinkResp.Status == proto.OpOk {
+			mp.recordChange(index, proto.MetaChangeInodeUnlink, ino.Inode, 0, "")
+		}
+		resp = unlinkResp
 	case opFSMUnlinkInodeOnce:
 		var inoOnce *InodeOnce
 		if inoOnce, err = InodeOnceUnmarshal(msg.V); err != nil {
@@ -96,19 +107,55 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		}
 		ino := NewInode(inoOnce.Inode, 0)
 		ino.setVer(inoOnce.VerSeq)
-		resp = mp.fsmUnlinkInode(ino, inoOnce.UniqID)
+		unlinkResp := mp.fsmUnlinkInode(ino, inoOnce.UniqID)
+		if unlinkResp.Status == proto.OpOk {
+			mp.recordChange(index, proto.MetaChangeInodeUnlink, ino.Inode, 0, "")
+		}
+		resp = unlinkResp
 	case opFSMUnlinkInodeBatch:
 		inodes, err := InodeBatchUnmarshal(msg.V)
 		if err != nil {
 			return nil, err
 		}
-		resp = mp.fsmUnlinkInodeBatch(inodes)
+		unlinkResps := mp.fsmUnlinkInodeBatch(inodes)
+		mp.recordInodeBatchChange(index, proto.MetaChangeInodeUnlink, inodes, unlinkResps)
+		resp = unlinkResps
 	case opFSMExtentTruncate:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmExtentsTruncate(ino)
+		truncResp := mp.fsmExtentsTruncate(ino)
+		if truncResp.Status == proto.OpOk {
+			mp.recordInodeChange(index, ino.Inode)
+		}
+		resp = truncResp
+	case opFSMExtentsDel:
+		req := &fsmExtentsDelRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		status := mp.fsmExtentsDelete(req)
+		if status == proto.OpOk {
+			mp.recordInodeChange(index, req.Inode)
+		}
+		resp = status
+	case opFSMExtentsClone:
+		req := &fsmExtentsCloneRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		cloneResp := mp.fsmExtentsClone(req)
+		if cloneResp.Status == proto.OpOk {
+			mp.recordInodeChange(index, req.DstInode)
+		}
+		resp = cloneResp
+	case opFSMTrashInode, opFSMRestoreTrash, opFSMPurgeTrash:
+		req := &fsmTrashRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp = mp.fsmTrashOp(index, msg.Op, req)
 	case opFSMCreateLinkInode:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
@@ -119,14 +166,22 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			resp = &InodeResponse{Status: status}
 			return
 		}
-		resp = mp.fsmCreateLinkInode(ino, 0)
+		linkResp := mp.fsmCreateLinkInode(ino, 0)
+		if linkResp.Status == proto.OpOk {
+			mp.recordInodeChange(index, ino.Inode)
+		}
+		resp = linkResp
 	case opFSMCreateLinkInodeOnce:
 		var inoOnce *InodeOnce
 		if inoOnce, err = InodeOnceUnmarshal(msg.V); err != nil {
 			return
 		}
 		ino := NewInode(inoOnce.Inode, 0)
-		resp = mp.fsmCreateLinkInode(ino, inoOnce.UniqID)
+		linkResp := mp.fsmCreateLinkInode(ino, inoOnce.UniqID)
+		if linkResp.Status == proto.OpOk {
+			mp.recordInodeChange(index, ino.Inode)
+		}
+		resp = linkResp
 	case opFSMEvictInode:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
@@ -137,20 +192,28 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			resp = &InodeResponse{Status: status}
 			return
 		}
-		resp = mp.fsmEvictInode(ino)
+		evictResp := mp.fsmEvictInode(ino)
+		if evictResp.Status == proto.OpOk {
+			mp.recordChange(index, proto.MetaChangeInodeEvict, ino.Inode, 0, "")
+		}
+		resp = evictResp
 	case opFSMEvictInodeBatch:
 		inodes, err := InodeBatchUnmarshal(msg.V)
 		if err != nil {
 			return nil, err
 		}
-		resp = mp.fsmBatchEvictInode(inodes)
+		evictResps := mp.fsmBatchEvictInode(inodes)
+		mp.recordInodeBatchChange(index, proto.MetaChangeInodeEvict, inodes, evictResps)
+		resp = evictResps
 	case opFSMSetAttr:
 		req := &SetattrRequest{}
 		err = json.Unmarshal(msg.V, req)
 		if err != nil {
 			return
 		}
-		err = mp.fsmSetAttr(req)
+		if err = mp.fsmSetAttr(req); err == nil {
+			mp.recordInodeChange(index, req.Inode)
+		}
 	case opFSMCreateDentry:
 		den := &Dentry{}
 		if err = den.Unmarshal(msg.V); err != nil {
@@ -163,7 +226,11 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			return
 		}
 
-		resp = mp.fsmCreateDentry(den, false)
+		status = mp.fsmCreateDentry(den, false)
+		if status == proto.OpOk {
+			mp.recordDentryChange(index, proto.MetaChangeDentryCreate, den)
+		}
+		resp = status
 	case opFSMDeleteDentry:
 		den := &Dentry{}
 		if err = den.Unmarshal(msg.V); err != nil {
@@ -176,13 +243,23 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			return
 		}
 
-		resp = mp.fsmDeleteDentry(den, false)
+		delResp := mp.fsmDeleteDentry(den, false)
+		if delResp.Status == proto.OpOk {
+			mp.recordDentryChange(index, proto.MetaChangeDentryDelete, delResp.Msg)
+		}
+		resp = delResp
 	case opFSMDeleteDentryBatch:
 		db, err := DentryBatchUnmarshal(msg.V)
 		if err != nil {
 			return nil, err
 		}
-		resp = mp.fsmBatchDeleteDentry(db)
+		delResps := mp.fsmBatchDeleteDentry(db)
+		for _, delResp := range delResps {
+			if delResp.Status == proto.OpOk {
+				mp.recordDentryChange(index, proto.MetaChangeDentryDelete, delResp.Msg)
+			}
+		}
+		resp = delResps
 	case opFSMUpdateDentry:
 		den := &Dentry{}
 		if err = den.Unmarshal(msg.V); err != nil {
@@ -195,43 +272,67 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			return
 		}
 
-		resp = mp.fsmUpdateDentry(den)
+		updateResp := mp.fsmUpdateDentry(den)
+		if updateResp.Status == proto.OpOk {
+			mp.recordDentryChange(index, proto.MetaChangeDentryUpdate, den)
+		}
+		resp = updateResp
 	case opFSMUpdatePartition:
 		req := &UpdatePartitionReq{}
 		if err = json.Unmarshal(msg.V, req); err != nil {
 			return
 		}
-		resp, err = mp.fsmUpdatePartition(req.End)
+		resp, err = mp.fsmUpdatePartition(req.End, req.VolName)
 	case opFSMExtentsAdd:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmAppendExtents(ino)
+		status := mp.fsmAppendExtents(ino)
+		if status == proto.OpOk {
+			mp.recordInodeChange(index, ino.Inode)
+		}
+		resp = status
 	case opFSMExtentsAddWithCheck:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmAppendExtentsWithCheck(ino, false)
+		status := mp.fsmAppendExtentsWithCheck(ino, false)
+		if status == proto.OpOk {
+			mp.recordInodeChange(index, ino.Inode)
+		}
+		resp = status
 	case opFSMExtentSplit:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmAppendExtentsWithCheck(ino, true)
+		status := mp.fsmAppendExtentsWithCheck(ino, true)
+		if status == proto.OpOk {
+			mp.recordInodeChange(index, ino.Inode)
+		}
+		resp = status
 	case opFSMObjExtentsAdd:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmAppendObjExtents(ino)
+		status := mp.fsmAppendObjExtents(ino)
+		if status == proto.OpOk {
+			mp.recordInodeChange(index, ino.Inode)
+		}
+		resp = status
 	case opFSMExtentsEmpty:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmExtentsEmpty(ino)
+		status := mp.fsmExtentsEmpty(ino)
+		if status == proto.OpOk {
+			mp.recordInodeChange(index, ino.Inode)
+		}
+		resp = status
 	case opFSMClearInodeCache:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
@@ -251,22 +352,28 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		txId := mp.txProcessor.txManager.txIdAlloc.getTransactionID()
 		quotaRebuild := mp.mqMgr.statisticRebuildStart()
 		uidRebuild := mp.acucumRebuildStart()
+		userQuotaRebuild := mp.uqMgr != nil && mp.uqMgr.statisticRebuildStart()
 		uniqChecker := mp.uniqChecker.clone()
+		extentRefs := mp.extentRefs.clone()
+		changeLog := mp.changeLog.clone()
 		msg := &storeMsg{
-			command:        opFSMStoreTick,
-			applyIndex:     index,
-			txId:           txId,
-			inodeTree:      inodeTree,
-			dentryTree:     dentryTree,
-			extendTree:     extendTree,
-			multipartTree:  multipartTree,
-			txTree:         txTree,
-			txRbInodeTree:  txRbInodeTree,
-			txRbDentryTree: txRbDentryTree,
-			quotaRebuild:   quotaRebuild,
-			uidRebuild:     uidRebuild,
-			uniqChecker:    uniqChecker,
-			multiVerList:   mp.GetAllVerList(),
+			command:          opFSMStoreTick,
+			applyIndex:       index,
+			txId:             txId,
+			inodeTree:        inodeTree,
+			dentryTree:       dentryTree,
+			extendTree:       extendTree,
+			multipartTree:    multipartTree,
+			txTree:           txTree,
+			txRbInodeTree:    txRbInodeTree,
+			txRbDentryTree:   txRbDentryTree,
+			quotaRebuild:     quotaRebuild,
+			uidRebuild:       uidRebuild,
+			userQuotaRebuild: userQuotaRebuild,
+			uniqChecker:      uniqChecker,
+			extentRefs:       extentRefs,
+			changeLog:        changeLog,
+			multiVerList:     mp.GetAllVerList(),
 		}
 		log.LogDebugf("opFSMStoreTick: quotaRebuild [%v] uidRebuild [%v]", quotaRebuild, uidRebuild)
 		mp.storeChan <- msg
@@ -283,19 +390,25 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		if extend, err = NewExtendFromBytes(msg.V); err != nil {
 			return
 		}
-		err = mp.fsmSetXAttr(extend)
+		if err = mp.fsmSetXAttr(extend); err == nil {
+			mp.recordInodeChange(index, extend.GetInode())
+		}
 	case opFSMRemoveXAttr:
 		var extend *Extend
 		if extend, err = NewExtendFromBytes(msg.V); err != nil {
 			return
 		}
-		err = mp.fsmRemoveXAttr(extend)
+		if err = mp.fsmRemoveXAttr(extend); err == nil {
+			mp.recordInodeChange(index, extend.GetInode())
+		}
 	case opFSMUpdateXAttr:
 		var extend *Extend
 		if extend, err = NewExtendFromBytes(msg.V); err != nil {
 			return
 		}
-		err = mp.fsmSetXAttr(extend)
+		if err = mp.fsmSetXAttr(extend); err == nil {
+			mp.recordInodeChange(index, extend.GetInode())
+		}
 	case opFSMCreateMultipart:
 		var multipart *Multipart
 		multipart = MultipartFromBytes(msg.V)
@@ -334,7 +447,11 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		if mp.config.Cursor < txIno.Inode.Inode {
 			mp.config.Cursor = txIno.Inode.Inode
 		}
-		resp = mp.fsmTxCreateInode(txIno, []uint32{})
+		status := mp.fsmTxCreateInode(txIno, []uint32{})
+		if status == proto.OpOk {
+			mp.recordChange(index, proto.MetaChangeInodeCreate, txIno.Inode.Inode, 0, "")
+		}
+		resp = status
 	case opFSMTxCreateInodeQuota:
 		qinode := &TxMetaQuotaInode{}
 		if err = qinode.Unmarshal(msg.V); err != nil {
@@ -352,13 +469,18 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			for _, quotaId := range qinode.quotaIds {
 				mp.mqMgr.updateUsedInfo(0, 1, quotaId)
 			}
+			mp.recordChange(index, proto.MetaChangeInodeCreate, txIno.Inode.Inode, 0, "")
 		}
 	case opFSMTxCreateDentry:
 		txDen := NewTxDentry(0, "", 0, 0, nil, nil)
 		if err = txDen.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmTxCreateDentry(txDen)
+		status := mp.fsmTxCreateDentry(txDen)
+		if status == proto.OpOk {
+			mp.recordDentryChange(index, proto.MetaChangeDentryCreate, txDen.Dentry)
+		}
+		resp = status
 	case opFSMTxSetState:
 		req := &proto.TxSetStateRequest{}
 		if err = json.Unmarshal(msg.V, req); err != nil {
@@ -400,26 +522,42 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		if err = txDen.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmTxDeleteDentry(txDen)
+		delResp := mp.fsmTxDeleteDentry(txDen)
+		if delResp.Status == proto.OpOk {
+			mp.recordDentryChange(index, proto.MetaChangeDentryDelete, txDen.Dentry)
+		}
+		resp = delResp
 	case opFSMTxUnlinkInode:
 		txIno := NewTxInode(0, 0, nil)
 		if err = txIno.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmTxUnlinkInode(txIno)
+		unlinkResp := mp.fsmTxUnlinkInode(txIno)
+		if unlinkResp.Status == proto.OpOk {
+			mp.recordChange(index, proto.MetaChangeInodeUnlink, txIno.Inode.Inode, 0, "")
+		}
+		resp = unlinkResp
 	case opFSMTxUpdateDentry:
 		// txDen := NewTxDentry(0, "", 0, 0, nil)
 		txUpdateDen := NewTxUpdateDentry(nil, nil, nil)
 		if err = txUpdateDen.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmTxUpdateDentry(txUpdateDen)
+		updateResp := mp.fsmTxUpdateDentry(txUpdateDen)
+		if updateResp.Status == proto.OpOk {
+			mp.recordDentryChange(index, proto.MetaChangeDentryUpdate, txUpdateDen.NewDentry)
+		}
+		resp = updateResp
 	case opFSMTxCreateLinkInode:
 		txIno := NewTxInode(0, 0, nil)
 		if err = txIno.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmTxCreateLinkInode(txIno)
+		linkResp := mp.fsmTxCreateLinkInode(txIno)
+		if linkResp.Status == proto.OpOk {
+			mp.recordInodeChange(index, txIno.Inode.Inode)
+		}
+		resp = linkResp
 	case opFSMSetInodeQuotaBatch:
 		req := &proto.BatchSetMetaserverQuotaReuqest{}
 		if err = json.Unmarshal(msg.V, req); err != nil {
@@ -442,6 +580,24 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		err = mp.fsmUniqCheckerEvict(req)
 	case opFSMVersionOp:
 		err = mp.fsmVersionOp(msg.V)
+	case opFSMSplitPartition:
+		req := &fsmSplitRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp, err = mp.fsmSplitPartition(req)
+	case opFSMFreezePartition:
+		req := &proto.FreezeMetaPartitionRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp, err = mp.fsmFreezePartition(req)
+	case opFSMMergePartition:
+		req := &fsmMergeRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp, err = mp.fsmMergePartition(req)
 	default:
 		// do nothing
 	}
@@ -569,7 +725,7 @@ func (mp *metaPartition) ApplyMemberChange(confChange *raftproto.ConfChange, ind
 		updated bool
 	)
 	switch confChange.Type {
-	case raftproto.ConfAddNode:
+	case raftproto.ConfAddNode, raftproto.ConfAddLearner:
 		req := &proto.AddMetaPartitionRaftMemberRequest{}
 		if err = json.Unmarshal(confChange.Context, req); err != nil {
 			return
@@ -581,6 +737,14 @@ func (mp *metaPartition) ApplyMemberChange(confChange *raftproto.ConfChange, ind
 			return
 		}
 		updated, err = mp.confRemoveNode(req, index)
+	case raftproto.ConfPromoteLearner, raftproto.ConfReplaceNode:
+		// the peer replaced stays in the peers until it is removed, which leaves the joint consensus,
+		// and the sides of the joint consensus are persisted with the peers
+		req := &proto.PromoteMetaPartitionLearnerRequest{}
+		if err = json.Unmarshal(confChange.Context, req); err != nil {
+			return
+		}
+		updated, err = mp.confPromoteLearner(req, index)
 	case raftproto.ConfUpdateNode:
 		// updated, err = mp.confUpdateNode(req, index)
 	default:
@@ -601,34 +765,25 @@ func (mp *metaPartition) ApplyMemberChange(confChange *raftproto.ConfChange, ind
 
 // Snapshot returns the snapshot of the current meta partition.
 func (mp *metaPartition) Snapshot() (snap raftproto.Snapshot, err error) {
+	if mp.manager.metaNode.raftSyncSnapFormatVersion == SnapFormatVersion_2 {
+		return mp.newChunkedSnapshot()
+	}
 	snap, err = newMetaItemIterator(mp)
 	return
 }
 
 func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.SnapIterator) (err error) {
-	var (
-		data           []byte
-		index          int
-		appIndexID     uint64
-		txID           uint64
-		uniqID         uint64
-		cursor         uint64
-		inodeTree      = NewBtree()
-		dentryTree     = NewBtree()
-		extendTree     = NewBtree()
-		multipartTree  = NewBtree()
-		txTree         = NewBtree()
-		txRbInodeTree  = NewBtree()
-		txRbDentryTree = NewBtree()
-		uniqChecker    = newUniqChecker()
-		verList        []*proto.VolVersionInfo
-	)
+	var data []byte
+	in, err := mp.beginSnapshotInstall(iter)
+	if err != nil {
+		return
+	}
 
 	blockUntilStoreSnapshot := func() {
 		ticker := time.NewTicker(5 * time.Second)
 		defer ticker.Stop()
 
-		log.LogWarnf("ApplySnapshot: start to block until store snapshot to disk, mp[%v], appid %d", mp.config.PartitionId, appIndexID)
+		log.LogWarnf("ApplySnapshot: start to block until store snapshot to disk, mp[%v], appid %d", mp.config.PartitionId, in.appIndexID)
 		start := time.Now()
 
 		for {
@@ -636,21 +791,21 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 			case <-ticker.C:
 				if time.Since(start) > time.Minute*20 {
 					msg := fmt.Sprintf("ApplySnapshot: wait store snapshot timeout after 20 minutes, mp %d, appId %d, storeId %d",
-						mp.config.PartitionId, appIndexID, mp.storedApplyId)
+						mp.config.PartitionId, in.appIndexID, mp.storedApplyId)
 					log.LogErrorf(msg)
 					err = fmt.Errorf(msg)
 					return
 				}
 
 				msg := fmt.Sprintf("ApplySnapshot: start check storedApplyId, mp %d appId %d, storeAppId %d, cost %s",
-					mp.config.PartitionId, appIndexID, mp.storedApplyId, time.Since(start).String())
+					mp.config.PartitionId, in.appIndexID, mp.storedApplyId, time.Since(start).String())
 				if time.Since(start) > time.Minute {
 					log.LogWarnf("still block after one minute, msg %s", msg)
 				} else {
 					log.LogInfo(msg)
 				}
 
-				if mp.storedApplyId >= appIndexID {
+				if mp.storedApplyId >= in.appIndexID {
 					log.LogWarnf("ApplySnapshot: store snapshot success, msg %s", msg)
 					return
 				}
@@ -663,21 +818,32 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 	}
 
 	defer func() {
+		if mp.rocksStore != nil {
+			if _, loadErr := mp.endLoadRocksTrees(); loadErr != nil && err == io.EOF {
+				err = loadErr
+			}
+		}
 		if err == io.EOF {
-			mp.applyID = appIndexID
-			mp.config.UniqId = uniqID
-			mp.txProcessor.txManager.txIdAlloc.setTransactionID(txID)
-			mp.inodeTree = inodeTree
-			mp.dentryTree = dentryTree
-			mp.extendTree = extendTree
-			mp.multipartTree = multipartTree
-			mp.config.Cursor = cursor
-			mp.txProcessor.txManager.txTree = txTree
-			mp.txProcessor.txResource.txRbInodeTree = txRbInodeTree
-			mp.txProcessor.txResource.txRbDentryTree = txRbDentryTree
-			mp.uniqChecker = uniqChecker
-			mp.multiVersionList.VerList = make([]*proto.VolVersionInfo, len(verList))
-			copy(mp.multiVersionList.VerList, verList)
+			mp.applyID = in.appIndexID
+			mp.config.UniqId = in.uniqID
+			mp.txProcessor.txManager.txIdAlloc.setTransactionID(in.txID)
+			mp.inodeTree = in.inodeTree
+			mp.dentryTree = in.dentryTree
+			mp.extendTree = in.extendTree
+			mp.multipartTree = in.multipartTree
+			mp.config.Cursor = in.cursor
+			mp.txProcessor.txManager.txTree = in.txTree
+			mp.txProcessor.txResource.txRbInodeTree = in.txRbInodeTree
+			mp.txProcessor.txResource.txRbDentryTree = in.txRbDentryTree
+			mp.uniqChecker = in.uniqChecker
+			mp.extentRefs = in.extentRefs
+			if !in.changeLogSent {
+				// the leader of the old version does not send the changes
+				in.changeLog.reset(in.appIndexID)
+			}
+			mp.changeLog = in.changeLog
+			mp.multiVersionList.VerList = make([]*proto.VolVersionInfo, len(in.verList))
+			copy(mp.multiVersionList.VerList, in.verList)
 			mp.verSeq = mp.multiVersionList.GetLastVer()
 			log.LogInfof("mp[%v] updateVerList (%v) seq [%v]", mp.config.PartitionId, mp.multiVersionList.VerList, mp.verSeq)
 			err = nil
@@ -693,7 +859,9 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 				txTree:         mp.txProcessor.txManager.txTree.GetTree(),
 				txRbInodeTree:  mp.txProcessor.txResource.txRbInodeTree.GetTree(),
 				txRbDentryTree: mp.txProcessor.txResource.txRbDentryTree.GetTree(),
-				uniqChecker:    uniqChecker.clone(),
+				uniqChecker:    in.uniqChecker.clone(),
+				extentRefs:     in.extentRefs.clone(),
+				changeLog:      in.changeLog.clone(),
 				multiVerList:   mp.GetVerList(),
 			}
 			select {
@@ -708,150 +876,172 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 				return
 			}
 		}
+		mp.keepSnapshotInstall(in)
 		log.LogErrorf("ApplySnapshot: stop with error: partitionID(%v) err(%v)", mp.config.PartitionId, err)
 	}()
 
-	var leaderSnapFormatVer uint32
-	leaderSnapFormatVer = math.MaxUint32
-
 	for {
 		data, err = iter.Next()
 		if err != nil {
 			return
 		}
 
-		if index == 0 {
-			appIndexID = binary.BigEndian.Uint64(data)
-			log.LogDebugf("ApplySnapshot: partitionID(%v), temporary uint64 appIndexID:%v", mp.config.PartitionId, appIndexID)
+		if in.index == 0 {
+			in.appIndexID = binary.BigEndian.Uint64(data)
+			log.LogDebugf("ApplySnapshot: partitionID(%v), temporary uint64 appIndexID:%v", mp.config.PartitionId, in.appIndexID)
 		}
 
 		snap := NewMetaItem(0, nil, nil)
 		if err = snap.UnmarshalBinary(data); err != nil {
-			if index == 0 {
+			if in.index == 0 {
 				// for compatibility, if leader send snapshot format int version_0, index=0 is applyId in uint64 and
 				// will cause snap.UnmarshalBinary err, then just skip index=0 and continue with the other fields
 				log.LogInfof("ApplySnapshot: snap.UnmarshalBinary failed in index=0, partitionID(%v), assuming snapshot format version_0",
 					mp.config.PartitionId)
-				index++
-				leaderSnapFormatVer = SnapFormatVersion_0
+				in.index++
+				in.applied = in.index
+				in.leaderSnapFormatVer = SnapFormatVersion_0
 				continue
 			}
 
-			log.LogInfof("ApplySnapshot: snap.UnmarshalBinary failed, partitionID(%v) index(%v)", mp.config.PartitionId, index)
+			log.LogInfof("ApplySnapshot: snap.UnmarshalBinary failed, partitionID(%v) index(%v)", mp.config.PartitionId, in.index)
 			err = errors.New("unmarshal snap data failed")
 			return
 		}
 
-		if index == 0 {
+		if in.index == 0 {
 			if snap.Op != opFSMSnapFormatVersion {
 				// check whether the snapshot format matches, if snap.UnmarshalBinary has no err for index 0, it should be opFSMSnapFormatVersion
 				err = fmt.Errorf("ApplySnapshot: snapshot format not match, partitionID(%v), index:%v, expect snap.Op:%v, actual snap.Op:%v",
-					mp.config.PartitionId, index, opFSMSnapFormatVersion, snap.Op)
+					mp.config.PartitionId, in.index, opFSMSnapFormatVersion, snap.Op)
 				log.LogWarn(err.Error())
 				return
 			}
 
 			// check whether the snapshot format version number matches
-			leaderSnapFormatVer = binary.BigEndian.Uint32(snap.V)
-			if leaderSnapFormatVer != mp.manager.metaNode.raftSyncSnapFormatVersion {
+			in.leaderSnapFormatVer = binary.BigEndian.Uint32(snap.V)
+			if in.leaderSnapFormatVer != mp.manager.metaNode.raftSyncSnapFormatVersion {
 				log.LogWarnf("ApplySnapshot: snapshot format not match, partitionID(%v), index:%v, expect ver:%v, actual ver:%v",
-					mp.config.PartitionId, index, mp.manager.metaNode.raftSyncSnapFormatVersion, leaderSnapFormatVer)
+					mp.config.PartitionId, in.index, mp.manager.metaNode.raftSyncSnapFormatVersion, in.leaderSnapFormatVer)
 			}
 
-			index++
+			in.index++
+			in.applied = in.index
 			continue
 		}
 
-		index++
-		switch snap.Op {
-		case opFSMApplyId:
-			appIndexID = binary.BigEndian.Uint64(snap.V)
-			log.LogDebugf("ApplySnapshot: partitionID(%v) appIndexID:%v", mp.config.PartitionId, appIndexID)
-		case opFSMTxId:
-			txID = binary.BigEndian.Uint64(snap.V)
-			log.LogDebugf("ApplySnapshot: partitionID(%v) txID:%v", mp.config.PartitionId, txID)
-		case opFSMCursor:
-			cursor = binary.BigEndian.Uint64(snap.V)
-			log.LogDebugf("ApplySnapshot: partitionID(%v) cursor:%v", mp.config.PartitionId, cursor)
-		case opFSMUniqIDSnap:
-			uniqID = binary.BigEndian.Uint64(snap.V)
-			log.LogDebugf("ApplySnapshot: partitionID(%v) uniqId:%v", mp.config.PartitionId, uniqID)
-		case opFSMCreateInode:
-			ino := NewInode(0, 0)
-
-			// TODO Unhandled errors
-			ino.UnmarshalKey(snap.K)
-			ino.UnmarshalValue(snap.V)
-			if cursor < ino.Inode {
-				cursor = ino.Inode
-			}
-			inodeTree.ReplaceOrInsert(ino, true)
-			log.LogDebugf("ApplySnapshot: create inode: partitonID(%v) inode[%v].", mp.config.PartitionId, ino)
-		case opFSMCreateDentry:
-			dentry := &Dentry{}
-			if err = dentry.UnmarshalKey(snap.K); err != nil {
-				return
-			}
-			if err = dentry.UnmarshalValue(snap.V); err != nil {
-				return
-			}
-			dentryTree.ReplaceOrInsert(dentry, true)
-			log.LogDebugf("ApplySnapshot: create dentry: partitionID(%v) dentry(%v)", mp.config.PartitionId, dentry)
-		case opFSMSetXAttr:
-			var extend *Extend
-			if extend, err = NewExtendFromBytes(snap.V); err != nil {
-				return
-			}
-			extendTree.ReplaceOrInsert(extend, true)
-			log.LogDebugf("ApplySnapshot: set extend attributes: partitionID(%v) extend(%v)",
-				mp.config.PartitionId, extend)
-		case opFSMCreateMultipart:
-			multipart := MultipartFromBytes(snap.V)
-			multipartTree.ReplaceOrInsert(multipart, true)
-			log.LogDebugf("ApplySnapshot: create multipart: partitionID(%v) multipart(%v)", mp.config.PartitionId, multipart)
-		case opFSMTxSnapshot:
-			txInfo := proto.NewTransactionInfo(0, proto.TxTypeUndefined)
-			txInfo.Unmarshal(snap.V)
-			txTree.ReplaceOrInsert(txInfo, true)
-			log.LogDebugf("ApplySnapshot: create transaction: partitionID(%v) txInfo(%v)", mp.config.PartitionId, txInfo)
-		case opFSMTxRbInodeSnapshot:
-			txRbInode := NewTxRollbackInode(nil, []uint32{}, nil, 0)
-			txRbInode.Unmarshal(snap.V)
-			txRbInodeTree.ReplaceOrInsert(txRbInode, true)
-			log.LogDebugf("ApplySnapshot: create txRbInode: partitionID(%v) txRbinode[%v]", mp.config.PartitionId, txRbInode)
-		case opFSMTxRbDentrySnapshot:
-			txRbDentry := NewTxRollbackDentry(nil, nil, 0)
-			txRbDentry.Unmarshal(snap.V)
-			txRbDentryTree.ReplaceOrInsert(txRbDentry, true)
-			log.LogDebugf("ApplySnapshot: create txRbDentry: partitionID(%v) txRbDentry(%v)", mp.config.PartitionId, txRbDentry)
-		case opFSMVerListSnapShot:
-			json.Unmarshal(snap.V, &verList)
-			log.LogDebugf("ApplySnapshot: create verList: partitionID(%v) snap.V(%v) verList(%v)", mp.config.PartitionId, snap.V, verList)
-		case opExtentFileSnapshot:
-			fileName := string(snap.K)
-			fileName = path.Join(mp.config.RootDir, fileName)
-			if err = os.WriteFile(fileName, snap.V, 0o644); err != nil {
-				log.LogErrorf("ApplySnapshot: write snap extent delete file fail: partitionID(%v) err(%v)",
-					mp.config.PartitionId, err)
-			}
-			log.LogDebugf("ApplySnapshot: write snap extent delete file: partitonID(%v) filename(%v).",
-				mp.config.PartitionId, fileName)
-		case opFSMUniqCheckerSnap:
-			if err = uniqChecker.UnMarshal(snap.V); err != nil {
-				log.LogErrorf("ApplyUniqChecker: write snap uniqChecker fail")
+		in.index++
+		items := []*MetaItem{snap}
+		if snap.Op == opFSMSnapBatch {
+			if items, err = unmarshalSnapBatch(snap.V); err != nil {
 				return
 			}
-			log.LogDebugf("ApplySnapshot: write snap uniqChecker")
-
-		default:
-			if leaderSnapFormatVer != math.MaxUint32 && leaderSnapFormatVer > mp.manager.metaNode.raftSyncSnapFormatVersion {
-				log.LogWarnf("ApplySnapshot: unknown op=%d, leaderSnapFormatVer:%v, mySnapFormatVer:%v, skip it",
-					snap.Op, leaderSnapFormatVer, mp.manager.metaNode.raftSyncSnapFormatVersion)
-			} else {
-				err = fmt.Errorf("unknown Op=%d", snap.Op)
-				return
+		}
+		for _, snap := range items {
+			switch snap.Op {
+			case opFSMApplyId:
+				in.appIndexID = binary.BigEndian.Uint64(snap.V)
+				log.LogDebugf("ApplySnapshot: partitionID(%v) appIndexID:%v", mp.config.PartitionId, in.appIndexID)
+			case opFSMTxId:
+				in.txID = binary.BigEndian.Uint64(snap.V)
+				log.LogDebugf("ApplySnapshot: partitionID(%v) txID:%v", mp.config.PartitionId, in.txID)
+			case opFSMCursor:
+				in.cursor = binary.BigEndian.Uint64(snap.V)
+				log.LogDebugf("ApplySnapshot: partitionID(%v) cursor:%v", mp.config.PartitionId, in.cursor)
+			case opFSMUniqIDSnap:
+				in.uniqID = binary.BigEndian.Uint64(snap.V)
+				log.LogDebugf("ApplySnapshot: partitionID(%v) uniqId:%v", mp.config.PartitionId, in.uniqID)
+			case opFSMCreateInode:
+				ino := NewInode(0, 0)
+
+				// TODO Unhandled errors
+				ino.UnmarshalKey(snap.K)
+				ino.UnmarshalValue(snap.V)
+				if in.cursor < ino.Inode {
+					in.cursor = ino.Inode
+				}
+				in.inodeTree.ReplaceOrInsert(ino, true)
+				log.LogDebugf("ApplySnapshot: create inode: partitonID(%v) inode[%v].", mp.config.PartitionId, ino)
+			case opFSMCreateDentry:
+				dentry := &Dentry{}
+				if err = dentry.UnmarshalKey(snap.K); err != nil {
+					return
+				}
+				if err = dentry.UnmarshalValue(snap.V); err != nil {
+					return
+				}
+				in.dentryTree.ReplaceOrInsert(dentry, true)
+				log.LogDebugf("ApplySnapshot: create dentry: partitionID(%v) dentry(%v)", mp.config.PartitionId, dentry)
+			case opFSMSetXAttr:
+				var extend *Extend
+				if extend, err = NewExtendFromBytes(snap.V); err != nil {
+					return
+				}
+				in.extendTree.ReplaceOrInsert(extend, true)
+				log.LogDebugf("ApplySnapshot: set extend attributes: partitionID(%v) extend(%v)",
+					mp.config.PartitionId, extend)
+			case opFSMCreateMultipart:
+				multipart := MultipartFromBytes(snap.V)
+				in.multipartTree.ReplaceOrInsert(multipart, true)
+				log.LogDebugf("ApplySnapshot: create multipart: partitionID(%v) multipart(%v)", mp.config.PartitionId, multipart)
+			case opFSMTxSnapshot:
+				txInfo := proto.NewTransactionInfo(0, proto.TxTypeUndefined)
+				txInfo.Unmarshal(snap.V)
+				in.txTree.ReplaceOrInsert(txInfo, true)
+				log.LogDebugf("ApplySnapshot: create transaction: partitionID(%v) txInfo(%v)", mp.config.PartitionId, txInfo)
+			case opFSMTxRbInodeSnapshot:
+				txRbInode := NewTxRollbackInode(nil, []uint32{}, nil, 0)
+				txRbInode.Unmarshal(snap.V)
+				in.txRbInodeTree.ReplaceOrInsert(txRbInode, true)
+				log.LogDebugf("ApplySnapshot: create txRbInode: partitionID(%v) txRbinode[%v]", mp.config.PartitionId, txRbInode)
+			case opFSMTxRbDentrySnapshot:
+				txRbDentry := NewTxRollbackDentry(nil, nil, 0)
+				txRbDentry.Unmarshal(snap.V)
+				in.txRbDentryTree.ReplaceOrInsert(txRbDentry, true)
+				log.LogDebugf("ApplySnapshot: create txRbDentry: partitionID(%v) txRbDentry(%v)", mp.config.PartitionId, txRbDentry)
+			case opFSMVerListSnapShot:
+				json.Unmarshal(snap.V, &in.verList)
+				log.LogDebugf("ApplySnapshot: create verList: partitionID(%v) snap.V(%v) verList(%v)", mp.config.PartitionId, snap.V, in.verList)
+			case opExtentFileSnapshot:
+				fileName := string(snap.K)
+				fileName = path.Join(mp.config.RootDir, fileName)
+				if err = os.WriteFile(fileName, snap.V, 0o644); err != nil {
+					log.LogErrorf("ApplySnapshot: write snap extent delete file fail: partitionID(%v) err(%v)",
+						mp.config.PartitionId, err)
+				}
+				log.LogDebugf("ApplySnapshot: write snap extent delete file: partitonID(%v) filename(%v).",
+					mp.config.PartitionId, fileName)
+			case opFSMUniqCheckerSnap:
+				if err = in.uniqChecker.UnMarshal(snap.V); err != nil {
+					log.LogErrorf("ApplyUniqChecker: write snap uniqChecker fail")
+					return
+				}
+				log.LogDebugf("ApplySnapshot: write snap uniqChecker")
+			case opFSMExtentRefsSnap:
+				if err = in.extentRefs.UnMarshal(snap.V); err != nil {
+					log.LogErrorf("ApplySnapshot: unmarshal extent refs fail: partitionID(%v) err(%v)", mp.config.PartitionId, err)
+					return
+				}
+				log.LogDebugf("ApplySnapshot: create extent refs: partitionID(%v) count(%v)", mp.config.PartitionId, in.extentRefs.Len())
+			case opFSMMetaChangeLogSnap:
+				if err = in.changeLog.UnMarshal(snap.V); err != nil {
+					log.LogErrorf("ApplySnapshot: unmarshal meta change log fail: partitionID(%v) err(%v)", mp.config.PartitionId, err)
+					return
+				}
+				in.changeLogSent = true
+				log.LogDebugf("ApplySnapshot: create meta change log: partitionID(%v) count(%v)", mp.config.PartitionId, in.changeLog.Len())
+
+			default:
+				if in.leaderSnapFormatVer != math.MaxUint32 && in.leaderSnapFormatVer > mp.manager.metaNode.raftSyncSnapFormatVersion {
+					log.LogWarnf("ApplySnapshot: unknown op=%d, leaderSnapFormatVer:%v, mySnapFormatVer:%v, skip it",
+						snap.Op, in.leaderSnapFormatVer, mp.manager.metaNode.raftSyncSnapFormatVersion)
+				} else {
+					err = fmt.Errorf("unknown Op=%d", snap.Op)
+					return
+				}
 			}
 		}
+		in.applied = in.index
 	}
 }
 
@@ -866,6 +1056,8 @@ func (mp *metaPartition) HandleFatalEvent(err *raft.FatalError) {
 // HandleLeaderChange handles the leader changes.
 func (mp *metaPartition) HandleLeaderChange(leader uint64) {
 	exporter.Warning(fmt.Sprintf("metaPartition(%v) changeLeader to (%v)", mp.config.PartitionId, leader))
+	// the locks are kept in the memory of the leader, the new leader restores them from the renewals
+	mp.locks.reset()
 	if mp.config.NodeId == leader {
 		localIp := mp.manager.metaNode.localAddr
 		if localIp == "" {
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/raftstore"
 	"github.com/cubefs/cubefs/util/log"
 )
 
@@ -74,10 +75,18 @@ func (mp *metaPartition) decommissionPartition() (err error) {
 	return
 }
 
-func (mp *metaPartition) fsmUpdatePartition(end uint64) (status uint8,
+func (mp *metaPartition) fsmUpdatePartition(end uint64, volName string) (status uint8,
 	err error) {
 	status = proto.OpOk
 	oldEnd := mp.config.End
+	oldVolName := mp.config.VolName
+	// the range split into another partition is never taken back
+	for _, split := range mp.config.Splits {
+		if split.Start > oldEnd && split.Start <= end {
+			status = proto.OpArgMismatchErr
+			return
+		}
+	}
 	mp.config.End = end
 
 	if end < mp.config.Cursor {
@@ -85,9 +94,14 @@ func (mp *metaPartition) fsmUpdatePartition(end uint64) (status uint8,
 		mp.config.End = oldEnd
 		return
 	}
+	// the volume is renamed on the master
+	if volName != "" {
+		mp.config.VolName = volName
+	}
 	if err = mp.PersistMetadata(); err != nil {
 		status = proto.OpDiskErr
 		mp.config.End = oldEnd
+		mp.config.VolName = oldVolName
 	}
 	return
 }
@@ -139,7 +153,9 @@ func (mp *metaPartition) confRemoveNode(req *proto.RemoveMetaPartitionRaftMember
 			req.PartitionId, mp.config.NodeId, string(data))
 		return
 	}
+	removed := mp.config.Peers[peerIndex]
 	mp.config.Peers = append(mp.config.Peers[:peerIndex], mp.config.Peers[peerIndex+1:]...)
+	proto.LeaveJoint(mp.config.Peers, removed)
 	if mp.config.NodeId == req.RemovePeer.ID && !mp.isLoadingMetaPartition && canRemoveSelf {
 		mp.Stop()
 		mp.DeleteRaft()
@@ -205,6 +221,30 @@ func (mp *metaPartition) setExtentDeleteFileCursor(buf []byte) (err error) {
 	return
 }
 
+// confPromoteLearner turns the learner into a voter in the peers, or into the incoming peer of the
+// joint consensus if it replaces the peer ReplacePeer.
+func (mp *metaPartition) confPromoteLearner(req *proto.PromoteMetaPartitionLearnerRequest, index uint64) (updated bool, err error) {
+	if req.ReplacePeer.ID != 0 {
+		updated = proto.EnterJoint(mp.config.Peers, req.PromotePeer.ID, req.ReplacePeer.ID)
+	} else {
+		for i, peer := range mp.config.Peers {
+			if peer.ID == req.PromotePeer.ID && peer.IsLearner {
+				mp.config.Peers[i].IsLearner = false
+				updated = true
+				break
+			}
+		}
+	}
+	log.LogInfof("action[confPromoteLearner] PartitionID(%v) nodeID(%v) promote peer(%v) updated(%v)",
+		req.PartitionId, mp.config.NodeId, req.PromotePeer, updated)
+	return
+}
+
+// CanPromoteLearner checks on the leader whether the learner has caught up with the log.
+func (mp *metaPartition) CanPromoteLearner(peer proto.Peer) error {
+	return raftstore.CheckLearnerCaughtUp(mp.raftPartition, peer.ID)
+}
+
 func (mp *metaPartition) CanRemoveRaftMember(peer proto.Peer) error {
 	downReplicas := mp.config.RaftStore.RaftServer().GetDownReplicas(mp.config.PartitionId)
 	hasExsit := false
@@ -18,7 +18,6 @@ import (
 	"strings"
 
 	"github.com/cubefs/cubefs/proto"
-	"github.com/cubefs/cubefs/util/btree"
 	"github.com/cubefs/cubefs/util/log"
 )
 
@@ -233,23 +232,17 @@ func (mp *metaPartition) fsmDeleteDentry(denParm *Dentry, checkInode bool) (resp
 	)
 	if checkInode {
 		log.LogDebugf("action[fsmDeleteDentry] mp[%v] delete param %v", mp.config.PartitionId, denParm)
-		item = mp.dentryTree.Execute(func(tree *btree.BTree) interface{} {
-			d := tree.CopyGet(denParm)
-			if d == nil {
-				return nil
-			}
+		if d := mp.dentryTree.CopyGet(denParm); d != nil && d.(*Dentry).Inode == denParm.Inode {
 			den := d.(*Dentry)
-			if den.Inode != denParm.Inode {
-				return nil
-			}
 			if mp.verSeq == 0 {
 				log.LogDebugf("action[fsmDeleteDentry] mp[%v] volume snapshot not enabled,delete directly", mp.config.PartitionId)
 				denFound = den
-				return mp.dentryTree.tree.Delete(den)
+				item = mp.dentryTree.Delete(den)
+			} else {
+				denFound, doMore, clean = den.deleteVerSnapshot(denParm.getSeqFiled(), mp.verSeq, mp.GetVerList())
+				item = den
 			}
-			denFound, doMore, clean = den.deleteVerSnapshot(denParm.getSeqFiled(), mp.verSeq, mp.GetVerList())
-			return den
-		})
+		}
 	} else {
 		log.LogDebugf("action[fsmDeleteDentry] mp[%v] denParm dentry %v", mp.config.PartitionId, denParm)
 		if mp.verSeq == 0 {
@@ -384,7 +377,7 @@ func (mp *metaPartition) fsmUpdateDentry(dentry *Dentry) (
 	return
 }
 
-func (mp *metaPartition) getDentryTree() *BTree {
+func (mp *metaPartition) getDentryTree() Tree {
 	return mp.dentryTree.GetTree()
 }
 
@@ -0,0 +1,252 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/storage"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+type fsmExtentsDelRequest struct {
+	Inode      uint64
+	Offset     uint64
+	Size       uint64
+	ModifyTime int64
+}
+
+type fsmExtentsCloneRequest struct {
+	SrcInode   uint64
+	SrcOffset  uint64
+	DstInode   uint64
+	DstOffset  uint64
+	Size       uint64
+	ModifyTime int64
+}
+
+type ExtentsCloneResponse struct {
+	Status uint8
+	Size   uint64
+}
+
+// releaseSharedExtents drops the shared extents from the extents to be deleted, the inode is
+// released from the extent once it holds none of its pieces, and the data is deleted by the last one.
+func (mp *metaPartition) releaseSharedExtents(delExtents []proto.ExtentKey) []proto.ExtentKey {
+	if mp.extentRefs.Len() == 0 {
+		return delExtents
+	}
+	eks := make([]proto.ExtentKey, 0, len(delExtents))
+	for idx := range delExtents {
+		ek := &delExtents[idx]
+		if !mp.extentRefs.isShared(ek) {
+			eks = append(eks, *ek)
+			continue
+		}
+		if !ek.IsSplit() {
+			mp.extentRefs.release(ek)
+		}
+		log.LogDebugf("releaseSharedExtents: mp[%v] keep shared extent %v", mp.config.PartitionId, ek)
+	}
+	return eks
+}
+
+// releaseInodeExtents releases the inode from all the shared extents it holds, the released
+// extents are still used by other inodes and must not be deleted with the inode.
+func (mp *metaPartition) releaseInodeExtents(ino *Inode) (released map[uint64]bool) {
+	released = make(map[uint64]bool)
+	if mp.extentRefs.Len() == 0 {
+		return
+	}
+	ino.Extents.Range(func(_ int, ek proto.ExtentKey) bool {
+		key := extentRefKey(&ek)
+		if !released[key] && mp.extentRefs.release(&ek) {
+			released[key] = true
+		}
+		return true
+	})
+	return
+}
+
+// fsmExtentsDelete punches a hole in the inode, the size of the inode is not changed.
+func (mp *metaPartition) fsmExtentsDelete(req *fsmExtentsDelRequest) (status uint8) {
+	status = proto.OpOk
+	item := mp.inodeTree.Get(NewInode(req.Inode, 0))
+	if item == nil {
+		return proto.OpNotExistErr
+	}
+	i := item.(*Inode)
+	if i.ShouldDelete() {
+		return proto.OpNotExistErr
+	}
+	if proto.IsDir(i.Type) {
+		return proto.OpArgMismatchErr
+	}
+
+	if i.getVer() != mp.verSeq {
+		i.CreateVer(mp.verSeq)
+	}
+	i.Lock()
+	defer i.Unlock()
+
+	if err := i.CreateLowerVersion(i.getVer(), mp.multiVersionList); err != nil {
+		return proto.OpErr
+	}
+	mp.punchInode(i, req.Offset, req.Size)
+	if i.ModifyTime < req.ModifyTime {
+		i.ModifyTime = req.ModifyTime
+	}
+	i.Generation++
+	return
+}
+
+// punchInode removes the range from the extents of the inode which is locked by the caller.
+func (mp *metaPartition) punchInode(i *Inode, offset, size uint64) {
+	insertSplitKey := func(ek *proto.ExtentKey) {
+		i.insertEkRefMap(mp.config.PartitionId, ek)
+	}
+	delExtents := i.Extents.Punch(offset, size, insertSplitKey)
+	if len(delExtents) == 0 {
+		return
+	}
+
+	var err error
+	if delExtents, err = i.RestoreExts2NextLayer(mp.config.PartitionId, delExtents, mp.verSeq, 0); err != nil {
+		panic("RestoreExts2NextLayer should not be error")
+	}
+	log.LogInfof("punchInode.mp (%v) inode[%v] offset %v size %v DecSplitExts exts(%v)",
+		mp.config.PartitionId, i.Inode, offset, size, delExtents)
+	i.DecSplitExts(mp.config.PartitionId, delExtents)
+	mp.uidManager.minusUidSpace(i.Uid, i.Inode, delExtents)
+	mp.extDelCh <- mp.releaseSharedExtents(delExtents)
+}
+
+// fsmExtentsClone shares the extents of the range of the source inode with the destination inode,
+// the range of the destination inode is replaced.
+func (mp *metaPartition) fsmExtentsClone(req *fsmExtentsCloneRequest) (resp *ExtentsCloneResponse) {
+	resp = &ExtentsCloneResponse{Status: proto.OpOk}
+	srcItem := mp.inodeTree.Get(NewInode(req.SrcInode, 0))
+	dstItem := mp.inodeTree.Get(NewInode(req.DstInode, 0))
+	if srcItem == nil || dstItem == nil {
+		resp.Status = proto.OpNotExistErr
+		return
+	}
+	src, dst := srcItem.(*Inode), dstItem.(*Inode)
+	if src.ShouldDelete() || dst.ShouldDelete() {
+		resp.Status = proto.OpNotExistErr
+		return
+	}
+	if !proto.IsRegular(src.Type) || !proto.IsRegular(dst.Type) {
+		resp.Status = proto.OpArgMismatchErr
+		return
+	}
+
+	size := req.Size
+	src.RLock()
+	if req.SrcOffset >= src.Size {
+		size = 0
+	} else if req.SrcOffset+size > src.Size {
+		size = src.Size - req.SrcOffset
+	}
+	src.RUnlock()
+	if size == 0 {
+		return
+	}
+	if src.Inode == dst.Inode && req.SrcOffset < req.DstOffset+size && req.DstOffset < req.SrcOffset+size {
+		resp.Status = proto.OpArgMismatchErr
+		return
+	}
+
+	eks := src.Extents.Slice(req.SrcOffset, size)
+	for idx := range eks {
+		ek := &eks[idx]
+		if storage.IsTinyExtent(ek.ExtentId) {
+			log.LogWarnf("fsmExtentsClone: mp[%v] inode[%v] tiny extent %v can not be shared",
+				mp.config.PartitionId, src.Inode, ek)
+			resp.Status = proto.OpArgMismatchErr
+			return
+		}
+		ek.FileOffset = ek.FileOffset - req.SrcOffset + req.DstOffset
+		ek.SnapInfo = &proto.ExtSnapInfo{VerSeq: mp.verSeq}
+	}
+	if resp.Status = mp.uidManager.addUidSpace(dst.Uid, dst.Inode, eks); resp.Status != proto.OpOk {
+		return
+	}
+
+	if dst.getVer() != mp.verSeq {
+		dst.CreateVer(mp.verSeq)
+	}
+	dst.Lock()
+	defer dst.Unlock()
+
+	if err := dst.CreateLowerVersion(dst.getVer(), mp.multiVersionList); err != nil {
+		resp.Status = proto.OpErr
+		return
+	}
+	oldSize := int64(dst.Size)
+	mp.punchInode(dst, req.DstOffset, size)
+
+	// the pieces of an extent in one inode are counted in the ref map of the inode
+	pieces := make(map[uint64]int)
+	for idx := range eks {
+		pieces[extentRefKey(&eks[idx])]++
+	}
+	held := make(map[uint64]bool)
+	dst.Extents.Lock()
+	for idx := range dst.Extents.eks {
+		ek := &dst.Extents.eks[idx]
+		key := extentRefKey(ek)
+		if _, ok := pieces[key]; !ok {
+			continue
+		}
+		held[key] = true
+		pieces[key]++
+		if !ek.IsSplit() {
+			if ek.SnapInfo != nil {
+				info := *ek.SnapInfo
+				ek.SnapInfo = &info
+			}
+			dst.insertEkRefMap(mp.config.PartitionId, ek)
+		}
+	}
+	dst.Extents.Unlock()
+
+	shared := make(map[uint64]bool)
+	for idx := range eks {
+		ek := eks[idx]
+		key := extentRefKey(&ek)
+		if pieces[key] > 1 {
+			dst.insertEkRefMap(mp.config.PartitionId, &ek)
+		}
+		if !held[key] && !shared[key] {
+			mp.extentRefs.share(&ek)
+			shared[key] = true
+		}
+		dst.Extents.Insert(ek)
+	}
+
+	if end := req.DstOffset + size; end > dst.Size {
+		dst.Size = end
+	}
+	if dst.ModifyTime < req.ModifyTime {
+		dst.ModifyTime = req.ModifyTime
+	}
+	dst.Generation++
+	mp.updateUsedInfo(int64(dst.Size)-oldSize, 0, dst.Inode)
+	mp.updateUserQuotaUsedInfo(dst, int64(dst.Size)-oldSize, 0)
+	resp.Size = size
+	log.LogInfof("fsmExtentsClone: mp[%v] src inode[%v] offset %v dst inode[%v] offset %v size %v eks %v",
+		mp.config.PartitionId, src.Inode, req.SrcOffset, dst.Inode, req.DstOffset, size, eks)
+	return
+}
@@ -77,8 +77,11 @@ func (mp *metaPartition) fsmCreateInode(ino *Inode) (status uint8) {
 	status = proto.OpOk
 	if _, ok := mp.inodeTree.ReplaceOrInsert(ino, false); !ok {
 		status = proto.OpExistErr
+		return
+	}
+	if ino.NLink > 0 {
+		mp.updateUserQuotaUsedInfo(ino, int64(ino.Size), 1)
 	}
-
 	return
 }
 
@@ -333,11 +336,13 @@ func (mp *metaPartition) fsmUnlinkInode(ino *Inode, uniqID uint64) (resp *InodeR
 			log.LogDebugf("action[fsmUnlinkInode] mp[%v] ino[%v] really be deleted, empty dir", mp.config.PartitionId, inode)
 			mp.inodeTree.Delete(inode)
 			mp.updateUsedInfo(0, -1, inode.Inode)
+			mp.updateUserQuotaUsedInfo(inode, 0, -1)
 		}
 	} else if inode.IsTempFile() {
 		// all snapshot between create to last deletion cleaned
 		if inode.NLink == 0 && inode.getLayerLen() == 0 {
 			mp.updateUsedInfo(-1*int64(inode.Size), -1, inode.Inode)
+			mp.updateUserQuotaUsedInfo(inode, -1*int64(inode.Size), -1)
 			log.LogDebugf("action[fsmUnlinkInode] mp[%v] unlink inode[%v] and push to freeList", mp.config.PartitionId, inode)
 			inode.AccessTime = time.Now().Unix()
 			mp.freeList.Push(inode.Inode)
@@ -349,7 +354,7 @@ func (mp *metaPartition) fsmUnlinkInode(ino *Inode, uniqID uint64) (resp *InodeR
 	if len(ext2Del) > 0 {
 		log.LogDebugf("action[fsmUnlinkInode] mp[%v] ino[%v] DecSplitExts ext2Del %v", mp.config.PartitionId, ino, ext2Del)
 		inode.DecSplitExts(mp.config.PartitionId, ext2Del)
-		mp.extDelCh <- ext2Del
+		mp.extDelCh <- mp.releaseSharedExtents(ext2Del)
 	}
 	log.LogDebugf("action[fsmUnlinkInode] mp[%v] ino[%v] left", mp.config.PartitionId, inode)
 	return
@@ -413,6 +418,9 @@ func (mp *metaPartition) internalDeleteBatch(val []byte) error {
 
 func (mp *metaPartition) internalDeleteInode(ino *Inode) {
 	log.LogDebugf("action[internalDeleteInode] ino[%v] really be deleted", ino)
+	if item := mp.inodeTree.Get(ino); item != nil {
+		mp.releaseInodeExtents(item.(*Inode))
+	}
 	mp.inodeTree.Delete(ino)
 	mp.freeList.Remove(ino.Inode)
 	mp.extendTree.Delete(&Extend{inode: ino.Inode}) // Also delete extend attribute.
@@ -438,12 +446,13 @@ func (mp *metaPartition) fsmAppendExtents(ino *Inode) (status uint8) {
 	}
 	delExtents := ino2.AppendExtents(eks, ino.ModifyTime, mp.volType)
 	mp.updateUsedInfo(int64(ino2.Size)-oldSize, 0, ino2.Inode)
+	mp.updateUserQuotaUsedInfo(ino2, int64(ino2.Size)-oldSize, 0)
 	log.LogInfof("fsmAppendExtents mpId[%v].inode[%v] deleteExtents(%v)", mp.config.PartitionId, ino2.Inode, delExtents)
 	mp.uidManager.minusUidSpace(ino2.Uid, ino2.Inode, delExtents)
 
 	log.LogInfof("fsmAppendExtents mpId[%v].inode[%v] DecSplitExts deleteExtents(%v)", mp.config.PartitionId, ino2.Inode, delExtents)
 	ino2.DecSplitExts(mp.config.PartitionId, delExtents)
-	mp.extDelCh <- delExtents
+	mp.extDelCh <- mp.releaseSharedExtents(delExtents)
 	return
 }
 
@@ -505,7 +514,7 @@ func (mp *metaPartition) fsmAppendExtentsWithCheck(ino *Inode, isSplit bool) (st
 		if status == proto.OpOk {
 			log.LogInfof("action[fsmAppendExtentsWithCheck] mp[%v] DecSplitExts delExtents [%v]", mp.config.PartitionId, delExtents)
 			fsmIno.DecSplitExts(appendExtParam.mpId, delExtents)
-			mp.extDelCh <- delExtents
+			mp.extDelCh <- mp.releaseSharedExtents(delExtents)
 		}
 		// conflict need delete eks[0], to clear garbage data
 		if status == proto.OpConflictExtentsErr {
@@ -522,7 +531,7 @@ func (mp *metaPartition) fsmAppendExtentsWithCheck(ino *Inode, isSplit bool) (st
 		delExtents, status = fsmIno.SplitExtentWithCheck(appendExtParam)
 		log.LogInfof("action[fsmAppendExtentsWithCheck] mp[%v] DecSplitExts delExtents [%v]", mp.config.PartitionId, delExtents)
 		fsmIno.DecSplitExts(mp.config.PartitionId, delExtents)
-		mp.extDelCh <- delExtents
+		mp.extDelCh <- mp.releaseSharedExtents(delExtents)
 		mp.uidManager.minusUidSpace(fsmIno.Uid, fsmIno.Inode, delExtents)
 	}
 
@@ -534,6 +543,7 @@ func (mp *metaPartition) fsmAppendExtentsWithCheck(ino *Inode, isSplit bool) (st
 	}
 
 	mp.updateUsedInfo(int64(fsmIno.Size)-oldSize, 0, fsmIno.Inode)
+	mp.updateUserQuotaUsedInfo(fsmIno, int64(fsmIno.Size)-oldSize, 0)
 	log.LogInfof("fsmAppendExtentWithCheck mp[%v] inode[%v] ek(%v) deleteExtents(%v) discardExtents(%v) status(%v)",
 		mp.config.PartitionId, fsmIno.Inode, eks[0], delExtents, discardExtentKey, status)
 
@@ -605,6 +615,7 @@ func (mp *metaPartition) fsmExtentsTruncate(ino *Inode) (resp *InodeResponse) {
 	}
 	oldSize := int64(i.Size)
 	delExtents := i.ExtentsTruncate(ino.Size, ino.ModifyTime, doOnLastKey, insertSplitKey)
+	mp.updateUserQuotaUsedInfo(i, int64(i.Size)-oldSize, 0)
 
 	if len(delExtents) == 0 {
 		return
@@ -618,7 +629,7 @@ func (mp *metaPartition) fsmExtentsTruncate(ino *Inode) (resp *InodeResponse) {
 	// now we should delete the extent
 	log.LogInfof("fsmExtentsTruncate.mp (%v) inode[%v] DecSplitExts exts(%v)", mp.config.PartitionId, i.Inode, delExtents)
 	i.DecSplitExts(mp.config.PartitionId, delExtents)
-	mp.extDelCh <- delExtents
+	mp.extDelCh <- mp.releaseSharedExtents(delExtents)
 	mp.uidManager.minusUidSpace(i.Uid, i.Inode, delExtents)
 	return
 }
@@ -689,7 +700,13 @@ func (mp *metaPartition) fsmSetAttr(req *SetattrRequest) (err error) {
 	if ino.ShouldDelete() {
 		return
 	}
+	uid, gid := ino.Uid, ino.Gid
 	ino.SetAttr(req)
+	if mp.uqMgr != nil && (ino.Uid != uid || ino.Gid != gid) && ino.NLink > 0 {
+		// the usage follows the inode to its new owner
+		mp.uqMgr.updateUsedInfo(uid, gid, -1*int64(ino.Size), -1)
+		mp.uqMgr.updateUsedInfo(ino.Uid, ino.Gid, int64(ino.Size), 1)
+	}
 	return
 }
 
@@ -772,7 +789,7 @@ func (mp *metaPartition) fsmClearInodeCache(ino *Inode) (status uint8) {
 	log.LogInfof("fsmClearInodeCache.mp[%v] inode[%v] DecSplitExts delExtents(%v)", mp.config.PartitionId, ino2.Inode, delExtents)
 	if len(delExtents) > 0 {
 		ino2.DecSplitExts(mp.config.PartitionId, delExtents)
-		mp.extDelCh <- delExtents
+		mp.extDelCh <- mp.releaseSharedExtents(delExtents)
 	}
 	return
 }
@@ -0,0 +1,149 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+type fsmTrashRequest struct {
+	Uid       uint32 `json:",omitempty"` // the user restoring or purging the dentry, 0 for the root user
+	Inode     uint64
+	ParentIno uint64
+	Name      string
+	Time      int64 // the time the dentry is moved into the trash
+}
+
+func (mp *metaPartition) fsmTrashOp(index uint64, op uint32, req *fsmTrashRequest) (resp *InodeResponse) {
+	if status := mp.inodeInTx(req.Inode); status != proto.OpOk {
+		return &InodeResponse{Status: status}
+	}
+	if status := mp.trashOwnerCheck(op, req); status != proto.OpOk {
+		return &InodeResponse{Status: status}
+	}
+	switch op {
+	case opFSMTrashInode:
+		resp = mp.fsmTrashInode(req)
+	case opFSMRestoreTrash:
+		resp = mp.fsmRestoreTrash(req)
+	default:
+		resp = mp.fsmPurgeTrash(req)
+	}
+	if resp.Status != proto.OpOk {
+		return
+	}
+	if op == opFSMPurgeTrash {
+		mp.recordChange(index, proto.MetaChangeInodeUnlink, req.Inode, 0, "")
+	} else {
+		mp.recordInodeChange(index, req.Inode)
+	}
+	return
+}
+
+// trashOwnerCheck checks the user restoring or purging the dentry owns the inode in the trash.
+func (mp *metaPartition) trashOwnerCheck(op uint32, req *fsmTrashRequest) uint8 {
+	if op == opFSMTrashInode || req.Uid == 0 {
+		return proto.OpOk
+	}
+	item := mp.inodeTree.Get(NewInode(req.Inode, 0))
+	if item == nil {
+		return proto.OpNotExistErr
+	}
+	if item.(*Inode).Uid != req.Uid {
+		return proto.OpNotPerm
+	}
+	return proto.OpOk
+}
+
+// fsmTrashInode keeps the dentry in the extend of the inode instead of unlinking the inode.
+func (mp *metaPartition) fsmTrashInode(req *fsmTrashRequest) (resp *InodeResponse) {
+	resp = NewInodeResponse()
+	resp.Status = proto.OpOk
+	item := mp.inodeTree.CopyGet(NewInode(req.Inode, 0))
+	if item == nil || item.(*Inode).ShouldDelete() {
+		resp.Status = proto.OpNotExistErr
+		return
+	}
+	resp.Msg = item.(*Inode)
+	atomic.StoreInt32(&mp.trashApplied, 1)
+
+	key := trashKey(req.ParentIno, req.Name)
+	value := []byte(strconv.FormatInt(req.Time, 10))
+	treeItem := mp.extendTree.CopyGet(NewExtend(req.Inode))
+	if treeItem == nil {
+		extend := NewExtend(req.Inode)
+		extend.Put(key, value, mp.GetVerSeq())
+		mp.extendTree.ReplaceOrInsert(extend, true)
+		return
+	}
+	extend := treeItem.(*Extend)
+	if _, ok := extend.Get(key); ok {
+		// the inode is linked by the name again and unlinked before the dentry in the trash is
+		// restored, the link of the former one is released
+		log.LogWarnf("fsmTrashInode: mp[%v] ino[%v] replace dentry(%v, %v) in the trash",
+			mp.config.PartitionId, req.Inode, req.ParentIno, req.Name)
+		if unlinkResp := mp.fsmUnlinkInode(NewInode(req.Inode, 0), 0); unlinkResp.Status == proto.OpOk {
+			resp.Msg = unlinkResp.Msg
+		}
+	}
+	extend.Put(key, value, extend.verSeq)
+	return
+}
+
+func (mp *metaPartition) fsmRestoreTrash(req *fsmTrashRequest) (resp *InodeResponse) {
+	resp = NewInodeResponse()
+	resp.Status = proto.OpOk
+	if !mp.removeTrashKey(req) {
+		resp.Status = proto.OpNotExistErr
+		return
+	}
+	if item := mp.inodeTree.Get(NewInode(req.Inode, 0)); item != nil {
+		resp.Msg = item.(*Inode)
+	}
+	return
+}
+
+// fsmPurgeTrash releases the link of the dentry in the trash, the inode is put into the free list
+// once it has no links.
+func (mp *metaPartition) fsmPurgeTrash(req *fsmTrashRequest) (resp *InodeResponse) {
+	if !mp.removeTrashKey(req) {
+		resp = NewInodeResponse()
+		resp.Status = proto.OpNotExistErr
+		return
+	}
+	ino := NewInode(req.Inode, 0)
+	if resp = mp.fsmUnlinkInode(ino, 0); resp.Status == proto.OpOk {
+		mp.fsmEvictInode(ino)
+	}
+	return
+}
+
+func (mp *metaPartition) removeTrashKey(req *fsmTrashRequest) bool {
+	treeItem := mp.extendTree.CopyGet(NewExtend(req.Inode))
+	if treeItem == nil {
+		return false
+	}
+	extend := treeItem.(*Extend)
+	key := trashKey(req.ParentIno, req.Name)
+	if _, ok := extend.Get(key); !ok {
+		return false
+	}
+	extend.Remove(key)
+	return true
+}
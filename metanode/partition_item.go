@@ -129,6 +129,9 @@ const (
 
 	// version since transaction feature, added formatVersion, txId and cursor in MetaItemIterator struct
 	SnapFormatVersion_1
+
+	// version sending the items of version_1 in chunks, which can be resumed by the followers
+	SnapFormatVersion_2
 )
 
 // MetaItemIterator defines the iterator of the MetaItem.
@@ -139,15 +142,18 @@ type MetaItemIterator struct {
 	uniqID            uint64
 	txId              uint64
 	cursor            uint64
-	inodeTree         *BTree
-	dentryTree        *BTree
-	extendTree        *BTree
-	multipartTree     *BTree
-	txTree            *BTree
-	txRbInodeTree     *BTree
-	txRbDentryTree    *BTree
+	inodeTree         Tree
+	dentryTree        Tree
+	extendTree        Tree
+	multipartTree     Tree
+	txTree            Tree
+	txRbInodeTree     Tree
+	txRbDentryTree    Tree
 	uniqChecker       *uniqChecker
+	extentRefs        *extentRefs
+	changeLog         *metaChangeLog
 	verList           []*proto.VolVersionInfo
+	releases          [4]func()
 
 	filenames []string
 
@@ -194,17 +200,52 @@ func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 	si.txId = mp.txProcessor.txManager.txIdAlloc.getTransactionID()
 	si.cursor = mp.GetCursor()
 	si.uniqID = mp.GetUniqId()
-	si.inodeTree = mp.inodeTree.GetTree()
-	si.dentryTree = mp.dentryTree.GetTree()
-	si.extendTree = mp.extendTree.GetTree()
-	si.multipartTree = mp.multipartTree.GetTree()
+	// the trees kept in RocksDB are read at the time of the snapshot until the producer ends
+	si.inodeTree, si.releases[0] = snapshotTree(mp.inodeTree)
+	si.dentryTree, si.releases[1] = snapshotTree(mp.dentryTree)
+	si.extendTree, si.releases[2] = snapshotTree(mp.extendTree)
+	si.multipartTree, si.releases[3] = snapshotTree(mp.multipartTree)
 	si.txTree = mp.txProcessor.txManager.txTree.GetTree()
 	si.txRbInodeTree = mp.txProcessor.txResource.txRbInodeTree.GetTree()
 	si.txRbDentryTree = mp.txProcessor.txResource.txRbDentryTree.GetTree()
 	si.uniqChecker = mp.uniqChecker.clone()
+	si.extentRefs = mp.extentRefs.clone()
+	si.changeLog = mp.changeLog.clone()
 	si.verList = mp.GetAllVerList()
 	mp.nonIdempotent.Unlock()
+	err = si.start(mp)
+	return
+}
+
+// reuse returns a new iterator of the items captured by si, the trees kept in memory are not
+// released by the iterators.
+func (si *MetaItemIterator) reuse(mp *metaPartition) (*MetaItemIterator, error) {
+	n := &MetaItemIterator{
+		fileRootDir:       si.fileRootDir,
+		SnapFormatVersion: si.SnapFormatVersion,
+		applyID:           si.applyID,
+		uniqID:            si.uniqID,
+		txId:              si.txId,
+		cursor:            si.cursor,
+		inodeTree:         si.inodeTree,
+		dentryTree:        si.dentryTree,
+		extendTree:        si.extendTree,
+		multipartTree:     si.multipartTree,
+		txTree:            si.txTree,
+		txRbInodeTree:     si.txRbInodeTree,
+		txRbDentryTree:    si.txRbDentryTree,
+		uniqChecker:       si.uniqChecker,
+		extentRefs:        si.extentRefs,
+		changeLog:         si.changeLog,
+		verList:           si.verList,
+		releases:          si.releases,
+	}
+	return n, n.start(mp)
+}
 
+// start collects the extent del files and starts the producer of the items.
+func (si *MetaItemIterator) start(mp *metaPartition) (err error) {
+	releases := si.releases
 	si.dataCh = make(chan interface{})
 	si.errorCh = make(chan error, 1)
 	si.closeCh = make(chan struct{})
@@ -213,6 +254,9 @@ func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 	filenames := make([]string, 0)
 	var fileInfos []os.DirEntry
 	if fileInfos, err = os.ReadDir(mp.config.RootDir); err != nil {
+		for _, release := range releases {
+			release()
+		}
 		return
 	}
 
@@ -229,6 +273,9 @@ func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 	// start data producer
 	go func(iter *MetaItemIterator) {
 		defer func() {
+			for _, release := range releases {
+				release()
+			}
 			close(iter.dataCh)
 			close(iter.errorCh)
 		}()
@@ -260,7 +307,7 @@ func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 			produceItem(si.applyID)
 			log.LogDebugf("newMetaItemIterator: SnapFormatVersion_0, partitionId(%v), applyID(%v)",
 				mp.config.PartitionId, si.applyID)
-		} else if si.SnapFormatVersion == SnapFormatVersion_1 {
+		} else if si.SnapFormatVersion == SnapFormatVersion_1 || si.SnapFormatVersion == SnapFormatVersion_2 {
 			// process snapshot format version
 			snapFormatVerWrapper := SnapItemWrapper{SiwKeySnapFormatVer, si.SnapFormatVersion}
 			produceItem(snapFormatVerWrapper)
@@ -321,7 +368,7 @@ func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 			return
 		}
 
-		if si.SnapFormatVersion == SnapFormatVersion_1 {
+		if si.SnapFormatVersion >= SnapFormatVersion_1 {
 			iter.txTree.Ascend(func(i BtreeItem) bool {
 				return produceItem(i)
 			})
@@ -349,6 +396,18 @@ func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 					return
 				}
 			}
+
+			if si.extentRefs.Len() > 0 {
+				produceItem(si.extentRefs)
+				if checkClose() {
+					return
+				}
+			}
+
+			produceItem(si.changeLog)
+			if checkClose() {
+				return
+			}
 		}
 
 		// process extent del files
@@ -483,6 +542,22 @@ func (si *MetaItemIterator) Next() (data []byte, err error) {
 			return
 		}
 		snap = NewMetaItem(opFSMUniqCheckerSnap, nil, raw)
+	case *extentRefs:
+		var raw []byte
+		if raw, _, err = typedItem.Marshal(); err != nil {
+			si.err = err
+			si.Close()
+			return
+		}
+		snap = NewMetaItem(opFSMExtentRefsSnap, nil, raw)
+	case *metaChangeLog:
+		var raw []byte
+		if raw, _, err = typedItem.Marshal(); err != nil {
+			si.err = err
+			si.Close()
+			return
+		}
+		snap = NewMetaItem(opFSMMetaChangeLogSnap, nil, raw)
 	default:
 		panic(fmt.Sprintf("unknown item type: %v", reflect.TypeOf(item).Name()))
 	}
@@ -581,7 +581,7 @@ func (mp *metaPartition) Lookup(req *LookupReq, p *Packet) (err error) {
 }
 
 // GetDentryTree returns the dentry tree stored in the meta partition.
-func (mp *metaPartition) GetDentryTree() *BTree {
+func (mp *metaPartition) GetDentryTree() Tree {
 	return mp.dentryTree.GetTree()
 }
 
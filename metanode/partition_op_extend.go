@@ -25,6 +25,10 @@ import (
 )
 
 func (mp *metaPartition) UpdateXAttr(req *proto.UpdateXAttrRequest, p *Packet) (err error) {
+	if isTrashKey(req.Key) {
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte(errTrashKeyNotPerm.Error()))
+		return
+	}
 	newValueList := strings.Split(req.Value, ",")
 	if len(newValueList) < 3 {
 		err = errors.New("Wrong number of parameters")
@@ -97,6 +101,10 @@ func (mp *metaPartition) UpdateXAttr(req *proto.UpdateXAttrRequest, p *Packet) (
 }
 
 func (mp *metaPartition) SetXAttr(req *proto.SetXAttrRequest, p *Packet) (err error) {
+	if isTrashKey(req.Key) {
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte(errTrashKeyNotPerm.Error()))
+		return
+	}
 	extend := NewExtend(req.Inode)
 	extend.Put([]byte(req.Key), []byte(req.Value), mp.verSeq)
 	if _, err = mp.putExtend(opFSMSetXAttr, extend); err != nil {
@@ -110,6 +118,10 @@ func (mp *metaPartition) SetXAttr(req *proto.SetXAttrRequest, p *Packet) (err er
 func (mp *metaPartition) BatchSetXAttr(req *proto.BatchSetXAttrRequest, p *Packet) (err error) {
 	extend := NewExtend(req.Inode)
 	for key, val := range req.Attrs {
+		if isTrashKey(key) {
+			p.PacketErrorWithBody(proto.OpNotPerm, []byte(errTrashKeyNotPerm.Error()))
+			return
+		}
 		extend.Put([]byte(key), []byte(val), mp.verSeq)
 	}
 
@@ -131,7 +143,7 @@ func (mp *metaPartition) GetXAttr(req *proto.GetXAttrRequest, p *Packet) (err er
 	treeItem := mp.extendTree.Get(NewExtend(req.Inode))
 	if treeItem != nil {
 		if extend := treeItem.(*Extend).GetExtentByVersion(req.VerSeq); extend != nil {
-			if value, exist := extend.Get([]byte(req.Key)); exist {
+			if value, exist := extend.Get([]byte(req.Key)); exist && !isTrashKey(req.Key) {
 				response.Value = string(value)
 			}
 		}
@@ -157,6 +169,9 @@ func (mp *metaPartition) GetAllXAttr(req *proto.GetAllXAttrRequest, p *Packet) (
 	if treeItem != nil {
 		if extend := treeItem.(*Extend).GetExtentByVersion(req.VerSeq); extend != nil {
 			for key, val := range extend.dataMap {
+				if isTrashKey(key) {
+					continue
+				}
 				response.Attrs[key] = string(val)
 			}
 		}
@@ -188,7 +203,7 @@ func (mp *metaPartition) BatchGetXAttr(req *proto.BatchGetXAttrRequest, p *Packe
 			var extend *Extend
 			if extend = treeItem.(*Extend).GetExtentByVersion(req.VerSeq); extend != nil {
 				for _, key := range req.Keys {
-					if val, exist := extend.Get([]byte(key)); exist {
+					if val, exist := extend.Get([]byte(key)); exist && !isTrashKey(key) {
 						info.XAttrs[key] = string(val)
 					}
 				}
@@ -206,6 +221,10 @@ func (mp *metaPartition) BatchGetXAttr(req *proto.BatchGetXAttrRequest, p *Packe
 }
 
 func (mp *metaPartition) RemoveXAttr(req *proto.RemoveXAttrRequest, p *Packet) (err error) {
+	if isTrashKey(req.Key) {
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte(errTrashKeyNotPerm.Error()))
+		return
+	}
 	extend := NewExtend(req.Inode)
 	extend.Put([]byte(req.Key), nil, req.VerSeq)
 	if _, err = mp.putExtend(opFSMRemoveXAttr, extend); err != nil {
@@ -227,6 +246,9 @@ func (mp *metaPartition) ListXAttr(req *proto.ListXAttrRequest, p *Packet) (err
 	if treeItem != nil {
 		if extend := treeItem.(*Extend).GetExtentByVersion(req.VerSeq); extend != nil {
 			extend.Range(func(key, value []byte) bool {
+				if isTrashKey(string(key)) {
+					return true
+				}
 				response.XAttrs = append(response.XAttrs, string(key))
 				return true
 			})
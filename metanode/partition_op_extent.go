@@ -26,6 +26,7 @@ import (
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/timeutil"
 )
 
 func (mp *metaPartition) CheckQuota(inodeId uint64, p *Packet) (iParm *Inode, inode *Inode, err error) {
@@ -57,6 +58,11 @@ func (mp *metaPartition) CheckQuota(inodeId uint64, p *Packet) (iParm *Inode, in
 		return
 	}
 	mp.uidManager.acLock.Unlock()
+	if status = mp.isOverUserQuota(inode.Uid, inode.Gid, true, false); status != 0 {
+		err = errors.New("CheckQuota user quota is over quota")
+		p.PacketErrorWithBody(status, []byte(err.Error()))
+		return
+	}
 	return
 }
 
@@ -410,6 +416,10 @@ func (mp *metaPartition) ExtentsList(req *proto.GetExtentsRequest, p *Packet) (e
 				resp.Generation = ino.Generation
 				resp.Size = ino.Size
 				ino.Extents.Range(func(_ int, ek proto.ExtentKey) bool {
+					// the client must not overwrite the shared extents in place
+					if mp.extentRefs.isShared(&ek) {
+						ek.SetShared(true)
+					}
 					resp.Extents = append(resp.Extents, ek)
 					log.LogInfof("action[ExtentsList] append ek [%v]", ek)
 					return true
@@ -487,6 +497,9 @@ func (mp *metaPartition) ExtentsTruncate(req *ExtentsTruncateReq, p *Packet, rem
 	}
 	i := item.(*Inode)
 	status := mp.isOverQuota(req.Inode, req.Size > i.Size, false)
+	if status == 0 && req.Size > i.Size {
+		status = mp.isOverUserQuota(i.Uid, i.Gid, true, false)
+	}
 	if status != 0 {
 		log.LogErrorf("ExtentsTruncate fail status [%v]", status)
 		err = errors.New("ExtentsTruncate is over quota")
@@ -573,27 +586,74 @@ func (mp *metaPartition) BatchObjExtentAppend(req *proto.AppendObjExtentKeysRequ
 	return
 }
 
-// func (mp *metaPartition) ExtentsDelete(req *proto.DelExtentKeyRequest, p *Packet) (err error) {
-// 	ino := NewInode(req.Inode, 0)
-// 	inode := mp.inodeTree.Get(ino).(*Inode)
-// 	inode.Extents.Delete(req.Extents)
-// 	curTime := timeutil.GetCurrentTimeUnix()
-// 	if inode.ModifyTime < curTime {
-// 		inode.ModifyTime = curTime
-// 	}
-// 	val, err := inode.Marshal()
-// 	if err != nil {
-// 		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
-// 		return
-// 	}
-// 	resp, err := mp.submit(opFSMExtentsDel, val)
-// 	if err != nil {
-// 		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
-// 		return
-// 	}
-// 	p.PacketErrorWithBody(resp.(uint8), nil)
-// 	return
-// }
+// ExtentsDelete punches a hole in the inode.
+func (mp *metaPartition) ExtentsDelete(req *proto.DelExtentKeyRequest, p *Packet) (err error) {
+	if !proto.IsHot(mp.volType) {
+		err = fmt.Errorf("only support hot vol")
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	val, err := json.Marshal(&fsmExtentsDelRequest{
+		Inode:      req.Inode,
+		Offset:     req.Offset,
+		Size:       req.Size,
+		ModifyTime: timeutil.GetCurrentTimeUnix(),
+	})
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	resp, err := mp.submit(opFSMExtentsDel, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.PacketErrorWithBody(resp.(uint8), nil)
+	return
+}
+
+// ExtentsClone shares the extents of the source inode with the destination inode.
+func (mp *metaPartition) ExtentsClone(req *proto.CloneExtentsRequest, p *Packet) (err error) {
+	if !proto.IsHot(mp.volType) {
+		err = fmt.Errorf("only support hot vol")
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	if status := mp.isOverQuota(req.DstInode, true, false); status != 0 {
+		err = errors.New("ExtentsClone is over quota")
+		p.PacketErrorWithBody(status, []byte(err.Error()))
+		return
+	}
+	val, err := json.Marshal(&fsmExtentsCloneRequest{
+		SrcInode:   req.SrcInode,
+		SrcOffset:  req.SrcOffset,
+		DstInode:   req.DstInode,
+		DstOffset:  req.DstOffset,
+		Size:       req.Size,
+		ModifyTime: timeutil.GetCurrentTimeUnix(),
+	})
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	r, err := mp.submit(opFSMExtentsClone, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	resp := r.(*ExtentsCloneResponse)
+	if resp.Status != proto.OpOk {
+		p.PacketErrorWithBody(resp.Status, nil)
+		return
+	}
+	reply, err := json.Marshal(&proto.CloneExtentsResponse{Size: resp.Size})
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
 
 // ExtentsEmpty only use in datalake situation
 func (mp *metaPartition) ExtentsOp(p *Packet, ino *Inode, op uint32) (err error) {
@@ -120,6 +120,11 @@ func (mp *metaPartition) CreateInode(req *CreateInoReq, p *Packet, remoteAddr st
 		p.PacketErrorWithBody(proto.OpInodeFullErr, []byte(err.Error()))
 		return
 	}
+	if quotaStatus := mp.isOverUserQuota(req.Uid, req.Gid, false, true); quotaStatus != 0 {
+		err = errors.New("create inode is over user quota")
+		p.PacketErrorWithBody(quotaStatus, []byte(err.Error()))
+		return
+	}
 	ino := NewInode(inoID, req.Mode)
 	ino.Uid = req.Uid
 	ino.Gid = req.Gid
@@ -179,6 +184,11 @@ func (mp *metaPartition) QuotaCreateInode(req *proto.QuotaCreateInodeRequest, p
 	ino.Gid = req.Gid
 	ino.LinkTarget = req.Target
 
+	if quotaStatus := mp.isOverUserQuota(req.Uid, req.Gid, false, true); quotaStatus != 0 {
+		err = errors.New("create inode is over user quota")
+		p.PacketErrorWithBody(quotaStatus, []byte(err.Error()))
+		return
+	}
 	for _, quotaId := range req.QuotaIds {
 		status = mp.mqMgr.IsOverQuota(false, true, quotaId)
 		if status != 0 {
@@ -772,7 +782,7 @@ func (mp *metaPartition) SetAttr(req *SetattrRequest, reqData []byte, p *Packet)
 }
 
 // GetInodeTree returns the inode tree.
-func (mp *metaPartition) GetInodeTree() *BTree {
+func (mp *metaPartition) GetInodeTree() Tree {
 	return mp.inodeTree.GetTree()
 }
 
@@ -879,6 +889,11 @@ func (mp *metaPartition) TxCreateInode(req *proto.TxCreateInodeRequest, p *Packe
 		p.PacketErrorWithBody(proto.OpInodeFullErr, []byte(err.Error()))
 		return
 	}
+	if quotaStatus := mp.isOverUserQuota(req.Uid, req.Gid, false, true); quotaStatus != 0 {
+		err = errors.New("tx create inode is over user quota")
+		p.PacketErrorWithBody(quotaStatus, []byte(err.Error()))
+		return
+	}
 
 	req.TxInfo.SetCreateInodeId(inoID)
 	createTxReq := &proto.TxCreateRequest{
@@ -0,0 +1,75 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+func (mp *metaPartition) SetLock(req *proto.SetLockRequest, p *Packet) (err error) {
+	if !req.Lock.Valid() {
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte("invalid lock"))
+		return
+	}
+	var timeout = proto.LockWaitTimeout
+	if !req.Wait {
+		timeout = 0
+	}
+	conflict, err := mp.locks.setLock(req.Inode, &req.Lock, timeout, mp.stopC)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	if conflict != nil {
+		log.LogDebugf("SetLock: mp(%v) ino(%v) lock(%v) conflicts with lock(%v)",
+			mp.config.PartitionId, req.Inode, &req.Lock, conflict)
+		p.PacketErrorWithBody(proto.OpLockConflictErr, nil)
+		return
+	}
+	p.PacketOkReply()
+	return
+}
+
+func (mp *metaPartition) GetLock(req *proto.GetLockRequest, p *Packet) (err error) {
+	conflict, err := mp.locks.getLock(req.Inode, &req.Lock)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	var encoded []byte
+	if encoded, err = json.Marshal(&proto.GetLockResponse{Lock: conflict}); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(encoded)
+	return
+}
+
+func (mp *metaPartition) RenewLockLease(req *proto.RenewLockLeaseRequest, p *Packet) (err error) {
+	lost := mp.locks.renewLease(req.Session, req.Locks)
+	if len(lost) > 0 {
+		log.LogWarnf("RenewLockLease: mp(%v) session(%v) lost locks of inodes(%v)", mp.config.PartitionId, req.Session, lost)
+	}
+	var encoded []byte
+	if encoded, err = json.Marshal(&proto.RenewLockLeaseResponse{Lost: lost}); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(encoded)
+	return
+}
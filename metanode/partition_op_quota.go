@@ -76,6 +76,28 @@ func (mp *metaPartition) getQuotaReportInfos() (infos []*proto.QuotaReportInfo)
 	return mp.mqMgr.getQuotaReportInfos()
 }
 
+func (mp *metaPartition) setUserQuotaHbInfo(infos []*proto.UserQuotaHeartBeatInfo) {
+	mp.uqMgr.setUserQuotaHbInfo(infos)
+}
+
+func (mp *metaPartition) getUserQuotaReportInfos() (infos []*proto.UserQuotaReportInfo) {
+	return mp.uqMgr.getUserQuotaReportInfos()
+}
+
+func (mp *metaPartition) updateUserQuotaUsedInfo(ino *Inode, size int64, files int64) {
+	if mp.uqMgr == nil {
+		return
+	}
+	mp.uqMgr.updateUsedInfo(ino.Uid, ino.Gid, size, files)
+}
+
+func (mp *metaPartition) isOverUserQuota(uid, gid uint32, size bool, files bool) (status uint8) {
+	if mp.uqMgr == nil {
+		return
+	}
+	return mp.uqMgr.IsOverQuota(uid, gid, size, files)
+}
+
 func (mp *metaPartition) statisticExtendByLoad(extend *Extend) {
 	mqMgr := mp.mqMgr
 	ino := NewInode(extend.GetInode(), 0)
@@ -109,7 +131,7 @@ func (mp *metaPartition) statisticExtendByLoad(extend *Extend) {
 	return
 }
 
-func (mp *metaPartition) statisticExtendByStore(extend *Extend, inodeTree *BTree) {
+func (mp *metaPartition) statisticExtendByStore(extend *Extend, inodeTree Tree) {
 	mqMgr := mp.mqMgr
 	ino := NewInode(extend.GetInode(), 0)
 
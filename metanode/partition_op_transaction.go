@@ -266,7 +266,7 @@ func (mp *metaPartition) TxGetCnt() (uint64, uint64, uint64) {
 	return uint64(txCnt), uint64(rbInoCnt), uint64(rbDenCnt)
 }
 
-func (mp *metaPartition) TxGetTree() (*BTree, *BTree, *BTree) {
+func (mp *metaPartition) TxGetTree() (Tree, Tree, Tree) {
 	tx := mp.txProcessor.txManager.txTree.GetTree()
 	rbIno := mp.txProcessor.txResource.txRbInodeTree.GetTree()
 	rbDen := mp.txProcessor.txResource.txRbDentryTree.GetTree()
@@ -0,0 +1,294 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/auditlog"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	// the dentries in the trash are kept in the extend of their inodes by the keys of the prefix,
+	// the keys are hidden from the xattr operations
+	trashKeyPrefix      = "cfs.trash."
+	trashExpireInterval = 5 * time.Minute
+)
+
+var errTrashKeyNotPerm = errors.New("the xattr is reserved by the trash")
+
+func trashKey(parentIno uint64, name string) []byte {
+	return []byte(trashKeyPrefix + strconv.FormatUint(parentIno, 10) + "/" + name)
+}
+
+func isTrashKey(key string) bool {
+	return strings.HasPrefix(key, trashKeyPrefix)
+}
+
+func parseTrashKey(key string) (parentIno uint64, name string, ok bool) {
+	key = strings.TrimPrefix(key, trashKeyPrefix)
+	idx := strings.IndexByte(key, '/')
+	if idx < 0 {
+		return
+	}
+	parentIno, err := strconv.ParseUint(key[:idx], 10, 64)
+	return parentIno, key[idx+1:], err == nil
+}
+
+// rangeTrash calls fn on the dentries in the trash of the inode, or of all the inodes if ino is 0.
+func (mp *metaPartition) rangeTrash(ino uint64, fn func(entry *proto.TrashEntry) bool) {
+	visit := func(item BtreeItem) bool {
+		extend := item.(*Extend)
+		var entries []*proto.TrashEntry
+		extend.Range(func(key, value []byte) bool {
+			if !isTrashKey(string(key)) {
+				return true
+			}
+			parentIno, name, ok := parseTrashKey(string(key))
+			if !ok {
+				return true
+			}
+			deleteTime, _ := strconv.ParseInt(string(value), 10, 64)
+			entries = append(entries, &proto.TrashEntry{
+				Inode:      extend.inode,
+				ParentIno:  parentIno,
+				Name:       name,
+				DeleteTime: deleteTime,
+			})
+			return true
+		})
+		if len(entries) == 0 {
+			return true
+		}
+		inode, _ := mp.inodeTree.Get(NewInode(extend.inode, 0)).(*Inode)
+		for _, entry := range entries {
+			if inode != nil {
+				entry.Uid, entry.Mode, entry.Size = inode.Uid, inode.Type, inode.Size
+			}
+			if !fn(entry) {
+				return false
+			}
+		}
+		return true
+	}
+	if ino != 0 {
+		if item := mp.extendTree.Get(NewExtend(ino)); item != nil {
+			visit(item)
+		}
+		return
+	}
+	mp.extendTree.GetTree().Ascend(visit)
+}
+
+func (mp *metaPartition) getTrashEntry(ino, parentIno uint64, name string) (found *proto.TrashEntry) {
+	mp.rangeTrash(ino, func(entry *proto.TrashEntry) bool {
+		if entry.ParentIno == parentIno && entry.Name == name {
+			found = entry
+			return false
+		}
+		return true
+	})
+	return
+}
+
+// TrashInode moves the dentry unlinked from the inode into the trash.
+func (mp *metaPartition) TrashInode(req *proto.TrashInodeRequest, p *Packet, remoteAddr string) (err error) {
+	start := time.Now()
+	if mp.IsEnableAuditLog() {
+		defer func() {
+			auditlog.LogInodeOp(remoteAddr, mp.GetVolName(), p.GetOpMsg(), req.FullPath, err, time.Since(start).Milliseconds(), req.Inode, 0)
+		}()
+	}
+	if item := mp.inodeTree.Get(NewInode(req.Inode, 0)); item == nil {
+		err = fmt.Errorf("mp[%v] inode[%v] not found", mp.config.PartitionId, req.Inode)
+		p.PacketErrorWithBody(proto.OpNotExistErr, []byte(err.Error()))
+		return
+	}
+	val, err := json.Marshal(&fsmTrashRequest{
+		Inode:     req.Inode,
+		ParentIno: req.ParentIno,
+		Name:      req.Name,
+		Time:      time.Now().Unix(),
+	})
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	r, err := mp.submit(opFSMTrashInode, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	msg := r.(*InodeResponse)
+	if msg.Status != proto.OpOk {
+		p.PacketErrorWithBody(msg.Status, nil)
+		return
+	}
+	resp := &UnlinkInoResp{Info: &proto.InodeInfo{}}
+	replyInfo(resp.Info, msg.Msg, make(map[uint32]*proto.MetaQuotaInfo))
+	var reply []byte
+	if reply, err = json.Marshal(resp); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+func (mp *metaPartition) ListTrash(req *proto.ListTrashRequest, p *Packet) (err error) {
+	resp := &proto.ListTrashResponse{Entries: make([]*proto.TrashEntry, 0)}
+	mp.rangeTrash(req.Inode, func(entry *proto.TrashEntry) bool {
+		if req.AllUsers || entry.Uid == req.Uid {
+			resp.Entries = append(resp.Entries, entry)
+		}
+		return true
+	})
+	reply, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+// RestoreTrash takes the dentry out of the trash and replies the entry taken.
+func (mp *metaPartition) RestoreTrash(req *proto.RestoreTrashRequest, p *Packet) (err error) {
+	entry, err := mp.submitTrashOp(opFSMRestoreTrash, &fsmTrashRequest{
+		Uid:       req.Uid,
+		Inode:     req.Inode,
+		ParentIno: req.ParentIno,
+		Name:      req.Name,
+	}, p)
+	if err != nil || entry == nil {
+		return
+	}
+	reply, err := json.Marshal(entry)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+func (mp *metaPartition) PurgeTrash(req *proto.PurgeTrashRequest, p *Packet) (err error) {
+	entry, err := mp.submitTrashOp(opFSMPurgeTrash, &fsmTrashRequest{
+		Uid:       req.Uid,
+		Inode:     req.Inode,
+		ParentIno: req.ParentIno,
+		Name:      req.Name,
+	}, p)
+	if err == nil && entry != nil {
+		p.PacketOkReply()
+	}
+	return
+}
+
+// submitTrashOp submits the operation on the dentry in the trash of the user, the root user is
+// allowed to operate the trash of all the users. The owner is checked again when the operation is
+// applied, since the inode may be chowned meanwhile. The packet is replied unless the entry is returned.
+func (mp *metaPartition) submitTrashOp(op uint32, req *fsmTrashRequest, p *Packet) (entry *proto.TrashEntry, err error) {
+	found := mp.getTrashEntry(req.Inode, req.ParentIno, req.Name)
+	if found == nil {
+		p.PacketErrorWithBody(proto.OpNotExistErr, nil)
+		return
+	}
+	if req.Uid != 0 && found.Uid != req.Uid {
+		p.PacketErrorWithBody(proto.OpNotPerm, nil)
+		return
+	}
+	val, err := json.Marshal(req)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	r, err := mp.submit(op, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	if status := r.(*InodeResponse).Status; status != proto.OpOk {
+		p.PacketErrorWithBody(status, nil)
+		return
+	}
+	return found, nil
+}
+
+// trashWorker purges the dentries expired in the trash on the leader, the inodes without links are
+// put into the free list to be deleted.
+func (mp *metaPartition) trashWorker() {
+	t := time.NewTicker(trashExpireInterval)
+	defer t.Stop()
+	// the trash of the partition loaded is checked once even if it is disabled
+	pending := true
+	for {
+		select {
+		case <-mp.stopC:
+			return
+		case <-t.C:
+		}
+		if _, isLeader := mp.IsLeader(); !isLeader {
+			continue
+		}
+		if atomic.SwapInt32(&mp.trashApplied, 0) == 1 {
+			pending = true
+		}
+		retention := atomic.LoadInt64(&mp.vol.trashRetention)
+		if retention <= 0 && !pending {
+			continue
+		}
+		pending = mp.expireTrash(retention) > 0
+	}
+}
+
+// expireTrash purges the dentries kept in the trash for longer than the retention in minutes, or all
+// of them if the trash is disabled. It returns the number of the dentries left in the trash.
+func (mp *metaPartition) expireTrash(retention int64) (left int) {
+	deadline := time.Now().Add(-time.Duration(retention) * time.Minute).Unix()
+	expired := make([]*fsmTrashRequest, 0)
+	mp.rangeTrash(0, func(entry *proto.TrashEntry) bool {
+		if retention > 0 && entry.DeleteTime > deadline {
+			left++
+			return true
+		}
+		expired = append(expired, &fsmTrashRequest{Inode: entry.Inode, ParentIno: entry.ParentIno, Name: entry.Name})
+		return true
+	})
+	for i, req := range expired {
+		val, err := json.Marshal(req)
+		if err == nil {
+			_, err = mp.submit(opFSMPurgeTrash, val)
+		}
+		if err != nil {
+			log.LogWarnf("expireTrash: mp[%v] purge ino[%v] dentry(%v, %v) err(%v)",
+				mp.config.PartitionId, req.Inode, req.ParentIno, req.Name, err)
+			return left + len(expired) - i
+		}
+	}
+	if len(expired) > 0 {
+		log.LogInfof("expireTrash: mp[%v] retention(%vm) purged(%v) left(%v)",
+			mp.config.PartitionId, retention, len(expired), left)
+	}
+	return
+}
@@ -0,0 +1,137 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"os"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashKey(t *testing.T) {
+	key := trashKey(12, "a/b")
+	require.True(t, isTrashKey(string(key)))
+	parentIno, name, ok := parseTrashKey(string(key))
+	require.True(t, ok)
+	require.Equal(t, uint64(12), parentIno)
+	require.Equal(t, "a/b", name)
+
+	_, _, ok = parseTrashKey(trashKeyPrefix + "x/a")
+	require.False(t, ok)
+}
+
+func TestTrashRestoreAndPurge(t *testing.T) {
+	mp := newSplitTestPartition(t, 1, 1, 1000)
+	ino := NewInode(10, FileModeType)
+	ino.Uid = 1000
+	ino.Size = 4096
+	mp.inodeTree.ReplaceOrInsert(ino, true)
+
+	resp := mp.fsmTrashInode(&fsmTrashRequest{Inode: 10, ParentIno: 1, Name: "f", Time: 100})
+	require.Equal(t, proto.OpOk, resp.Status)
+	require.Equal(t, uint32(1), ino.GetNLink())
+	resp = mp.fsmTrashInode(&fsmTrashRequest{Inode: 11, ParentIno: 1, Name: "g", Time: 100})
+	require.Equal(t, proto.OpNotExistErr, resp.Status)
+
+	entry := mp.getTrashEntry(10, 1, "f")
+	require.NotNil(t, entry)
+	require.Equal(t, uint32(1000), entry.Uid)
+	require.Equal(t, uint64(4096), entry.Size)
+	require.Equal(t, int64(100), entry.DeleteTime)
+
+	// the dentry restored is taken out of the trash, and the inode keeps its link
+	resp = mp.fsmRestoreTrash(&fsmTrashRequest{Inode: 10, ParentIno: 1, Name: "f"})
+	require.Equal(t, proto.OpOk, resp.Status)
+	require.Nil(t, mp.getTrashEntry(10, 1, "f"))
+	require.Equal(t, uint32(1), ino.GetNLink())
+	resp = mp.fsmRestoreTrash(&fsmTrashRequest{Inode: 10, ParentIno: 1, Name: "f"})
+	require.Equal(t, proto.OpNotExistErr, resp.Status)
+
+	// the inode purged from the trash is put into the free list
+	mp.fsmTrashInode(&fsmTrashRequest{Inode: 10, ParentIno: 1, Name: "f", Time: 200})
+	resp = mp.fsmPurgeTrash(&fsmTrashRequest{Inode: 10, ParentIno: 1, Name: "f"})
+	require.Equal(t, proto.OpOk, resp.Status)
+	require.Nil(t, mp.getTrashEntry(10, 1, "f"))
+	require.Equal(t, 1, mp.freeList.Len())
+}
+
+func TestTrashOwner(t *testing.T) {
+	mp := newSplitTestPartition(t, 1, 1, 1000)
+	ino := NewInode(10, FileModeType)
+	ino.Uid = 1000
+	ino.IncNLink(0)
+	mp.inodeTree.ReplaceOrInsert(ino, true)
+	mp.fsmTrashInode(&fsmTrashRequest{Inode: 10, ParentIno: 1, Name: "f", Time: 100})
+	mp.fsmTrashInode(&fsmTrashRequest{Inode: 10, ParentIno: 1, Name: "g", Time: 100})
+
+	// the trash of others is refused before it is submitted
+	p := &Packet{}
+	require.NoError(t, mp.RestoreTrash(&proto.RestoreTrashRequest{Uid: 1001, Inode: 10, ParentIno: 1, Name: "f"}, p))
+	require.Equal(t, proto.OpNotPerm, p.ResultCode)
+	p = &Packet{}
+	require.NoError(t, mp.PurgeTrash(&proto.PurgeTrashRequest{Uid: 1001, Inode: 10, ParentIno: 1, Name: "f"}, p))
+	require.Equal(t, proto.OpNotPerm, p.ResultCode)
+
+	// and when it is applied, the inode may be chowned after it is checked
+	resp := mp.fsmTrashOp(1, opFSMRestoreTrash, &fsmTrashRequest{Uid: 1001, Inode: 10, ParentIno: 1, Name: "f"})
+	require.Equal(t, proto.OpNotPerm, resp.Status)
+	resp = mp.fsmTrashOp(2, opFSMPurgeTrash, &fsmTrashRequest{Uid: 1001, Inode: 10, ParentIno: 1, Name: "f"})
+	require.Equal(t, proto.OpNotPerm, resp.Status)
+	require.NotNil(t, mp.getTrashEntry(10, 1, "f"))
+
+	// the owner and the root user operate it
+	resp = mp.fsmTrashOp(3, opFSMRestoreTrash, &fsmTrashRequest{Uid: 1000, Inode: 10, ParentIno: 1, Name: "f"})
+	require.Equal(t, proto.OpOk, resp.Status)
+	require.Nil(t, mp.getTrashEntry(10, 1, "f"))
+	resp = mp.fsmTrashOp(4, opFSMPurgeTrash, &fsmTrashRequest{Inode: 10, ParentIno: 1, Name: "g"})
+	require.Equal(t, proto.OpOk, resp.Status)
+	require.Nil(t, mp.getTrashEntry(10, 1, "g"))
+}
+
+func TestTrashReplaceDentry(t *testing.T) {
+	mp := newSplitTestPartition(t, 1, 1, 1000)
+	ino := NewInode(10, FileModeType)
+	ino.IncNLink(0)
+	mp.inodeTree.ReplaceOrInsert(ino, true)
+
+	// the same name trashed twice keeps the latest one, the link of the former is released
+	mp.fsmTrashInode(&fsmTrashRequest{Inode: 10, ParentIno: 1, Name: "f", Time: 100})
+	mp.fsmTrashInode(&fsmTrashRequest{Inode: 10, ParentIno: 1, Name: "f", Time: 200})
+	require.Equal(t, uint32(1), ino.GetNLink())
+
+	count := 0
+	mp.rangeTrash(0, func(entry *proto.TrashEntry) bool {
+		count++
+		require.Equal(t, int64(200), entry.DeleteTime)
+		return true
+	})
+	require.Equal(t, 1, count)
+}
+
+func TestTrashPurgeDir(t *testing.T) {
+	mp := newSplitTestPartition(t, 1, 1, 1000)
+	mp.inodeTree.ReplaceOrInsert(NewInode(10, proto.Mode(os.ModeDir)), true)
+
+	resp := mp.fsmTrashInode(&fsmTrashRequest{Inode: 10, ParentIno: 1, Name: "d", Time: 100})
+	require.Equal(t, proto.OpOk, resp.Status)
+	require.NotNil(t, mp.inodeTree.Get(NewInode(10, 0)))
+
+	// the empty directory purged is deleted at once
+	resp = mp.fsmPurgeTrash(&fsmTrashRequest{Inode: 10, ParentIno: 1, Name: "d"})
+	require.Equal(t, proto.OpOk, resp.Status)
+	require.Nil(t, mp.inodeTree.Get(NewInode(10, 0)))
+}
@@ -0,0 +1,239 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sync/atomic"
+	"time"
+
+	raftproto "github.com/cubefs/cubefs/depends/tiglabs/raft/proto"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	// the chunks are cut by the number of the items, so the chunks of the same source are the same
+	// even if the items are changed in place
+	snapshotChunkItems = 4096
+	// the items captured for the snapshot are kept for the followers to resume it during the time
+	snapshotSourceKeepTime = 10 * time.Minute
+)
+
+// chunkedSnapshot sends the items of the iterator in chunks of SnapFormatVersion_2, each chunk is a
+// MetaItem of opFSMSnapBatch. The first chunk is the item of the format version alone, so the
+// followers know the format by it.
+type chunkedSnapshot struct {
+	*MetaItemIterator
+	chunks uint64
+}
+
+func (s *chunkedSnapshot) Next() (data []byte, err error) {
+	if s.chunks == 0 {
+		if data, err = s.MetaItemIterator.Next(); err == nil {
+			s.chunks++
+		}
+		return
+	}
+	var (
+		batch []byte
+		count int
+	)
+	lenBuf := make([]byte, 4)
+	for count < snapshotChunkItems {
+		var item []byte
+		if item, err = s.MetaItemIterator.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			return
+		}
+		binary.BigEndian.PutUint32(lenBuf, uint32(len(item)))
+		batch = append(batch, lenBuf...)
+		batch = append(batch, item...)
+		count++
+	}
+	if count == 0 {
+		return nil, io.EOF
+	}
+	s.chunks++
+	return NewMetaItem(opFSMSnapBatch, nil, batch).MarshalBinary()
+}
+
+// resumableSnapshot is the chunkedSnapshot of the source kept by the leader, so the follower failed
+// to apply it can resume it from the chunks applied.
+type resumableSnapshot struct {
+	*chunkedSnapshot
+}
+
+func (s *resumableSnapshot) Skip(n uint64) error {
+	for s.chunks < n {
+		if _, err := s.Next(); err == io.EOF {
+			return fmt.Errorf("skip %v chunks of the snapshot of %v chunks", n, s.chunks)
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unmarshalSnapBatch(raw []byte) (items []*MetaItem, err error) {
+	for len(raw) > 0 {
+		if len(raw) < 4 {
+			return nil, fmt.Errorf("unmarshal snap batch: truncated length")
+		}
+		size := binary.BigEndian.Uint32(raw)
+		if uint64(len(raw)-4) < uint64(size) {
+			return nil, fmt.Errorf("unmarshal snap batch: truncated item")
+		}
+		item := NewMetaItem(0, nil, nil)
+		if err = item.UnmarshalBinary(raw[4 : 4+size]); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		raw = raw[4+size:]
+	}
+	return
+}
+
+// newChunkedSnapshot returns the snapshot of SnapFormatVersion_2. The items captured for the
+// partitions kept in memory are reused until the raft log after them is truncated, or they are kept
+// for snapshotSourceKeepTime.
+func (mp *metaPartition) newChunkedSnapshot() (raftproto.Snapshot, error) {
+	mp.snapSourceLock.Lock()
+	defer mp.snapSourceLock.Unlock()
+	if mp.snapSourceReusable() {
+		si, err := mp.snapSource.reuse(mp)
+		if err != nil {
+			return nil, err
+		}
+		return &resumableSnapshot{&chunkedSnapshot{MetaItemIterator: si}}, nil
+	}
+	mp.snapSource = nil
+	si, err := newMetaItemIterator(mp)
+	if err != nil {
+		return nil, err
+	}
+	if mp.rocksStore != nil {
+		// the trees kept in RocksDB are released once they are sent
+		return &chunkedSnapshot{MetaItemIterator: si}, nil
+	}
+	mp.snapSource, mp.snapSourceTime = si, time.Now()
+	return &resumableSnapshot{&chunkedSnapshot{MetaItemIterator: si}}, nil
+}
+
+func (mp *metaPartition) snapSourceReusable() bool {
+	return mp.snapSource != nil && time.Since(mp.snapSourceTime) < snapshotSourceKeepTime &&
+		mp.snapSource.applyID >= atomic.LoadUint64(&mp.truncatedIndex)
+}
+
+// expireSnapSource releases the items captured for the snapshots once they can't be reused.
+func (mp *metaPartition) expireSnapSource() {
+	mp.snapSourceLock.Lock()
+	if !mp.snapSourceReusable() {
+		mp.snapSource = nil
+	}
+	mp.snapSourceLock.Unlock()
+}
+
+// snapshotInstall is the state of the snapshot being applied. It is kept if the snapshot fails to be
+// applied, so the snapshot sent again from the same source is resumed from the blocks applied.
+type snapshotInstall struct {
+	from      uint64 // the leader sending the snapshot
+	snapIndex uint64
+	snapTerm  uint64
+	resumable bool
+	index     int // the blocks received
+	applied   int // the blocks applied
+
+	leaderSnapFormatVer uint32
+	appIndexID          uint64
+	txID                uint64
+	uniqID              uint64
+	cursor              uint64
+	inodeTree           Tree
+	dentryTree          Tree
+	extendTree          Tree
+	multipartTree       Tree
+	txTree              *BTree
+	txRbInodeTree       *BTree
+	txRbDentryTree      *BTree
+	uniqChecker         *uniqChecker
+	extentRefs          *extentRefs
+	changeLog           *metaChangeLog
+	changeLogSent       bool
+	verList             []*proto.VolVersionInfo
+}
+
+// beginSnapshotInstall returns the state to apply the snapshot, it is resumed if the snapshot of the
+// same source failed to be applied.
+func (mp *metaPartition) beginSnapshotInstall(iter raftproto.SnapIterator) (in *snapshotInstall, err error) {
+	var (
+		from uint64
+		meta raftproto.SnapshotMeta
+	)
+	ri, resumable := iter.(raftproto.ResumableSnapIterator)
+	if resumable {
+		from, meta, resumable = ri.Source()
+	}
+	// the trees kept in RocksDB are replaced in place, so they are applied from the start
+	resumable = resumable && mp.rocksStore == nil
+
+	in, mp.snapInstall = mp.snapInstall, nil
+	if resumable && in != nil && in.from == from && in.snapIndex == meta.Index && in.snapTerm == meta.Term {
+		if err = ri.Resume(uint64(in.applied)); err != nil {
+			return nil, err
+		}
+		log.LogWarnf("ApplySnapshot: resume snapshot: partitionID(%v) from(%v) index(%v) term(%v) blocks(%v)",
+			mp.config.PartitionId, from, meta.Index, meta.Term, in.applied)
+		in.index = in.applied
+		return in, nil
+	}
+
+	in = &snapshotInstall{
+		from:                from,
+		snapIndex:           meta.Index,
+		snapTerm:            meta.Term,
+		resumable:           resumable,
+		leaderSnapFormatVer: math.MaxUint32,
+		inodeTree:           NewBtree(),
+		dentryTree:          NewBtree(),
+		extendTree:          NewBtree(),
+		multipartTree:       NewBtree(),
+		txTree:              NewBtree(),
+		txRbInodeTree:       NewBtree(),
+		txRbDentryTree:      NewBtree(),
+		uniqChecker:         newUniqChecker(),
+		extentRefs:          newExtentRefs(),
+		changeLog:           newMetaChangeLog(defaultMetaChangeLogSize),
+	}
+	mp.trackTrees(in.inodeTree, in.dentryTree, in.extendTree, in.multipartTree)
+	if mp.rocksStore != nil {
+		if err = mp.beginInstallRocksTrees(); err != nil {
+			return nil, err
+		}
+		in.inodeTree, in.dentryTree, in.extendTree, in.multipartTree = mp.inodeTree, mp.dentryTree, mp.extendTree, mp.multipartTree
+	}
+	return in, nil
+}
+
+// keepSnapshotInstall keeps the state of the snapshot failed to be applied to resume it.
+func (mp *metaPartition) keepSnapshotInstall(in *snapshotInstall) {
+	if in.resumable && in.applied > 0 {
+		mp.snapInstall = in
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	raftproto "github.com/cubefs/cubefs/depends/tiglabs/raft/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func readChunkedSnapshot(t *testing.T, snap raftproto.Snapshot) (chunks int, items []*MetaItem) {
+	defer snap.Close()
+	for {
+		data, err := snap.Next()
+		if err == io.EOF {
+			return
+		}
+		require.NoError(t, err)
+		chunks++
+		item := NewMetaItem(0, nil, nil)
+		require.NoError(t, item.UnmarshalBinary(data))
+		if item.Op != opFSMSnapBatch {
+			items = append(items, item)
+			continue
+		}
+		batch, err := unmarshalSnapBatch(item.V)
+		require.NoError(t, err)
+		items = append(items, batch...)
+	}
+}
+
+func TestChunkedSnapshotResume(t *testing.T) {
+	mp := newSplitTestPartition(t, 1, 1, 100000)
+	mp.manager.metaNode = &MetaNode{raftSyncSnapFormatVersion: SnapFormatVersion_2}
+	require.NoError(t, os.MkdirAll(mp.config.RootDir, 0o755))
+	addSplitTestInodes(mp, 1, 5000)
+
+	snap, err := mp.Snapshot()
+	require.NoError(t, err)
+	_, ok := snap.(raftproto.ResumableSnapshot)
+	require.True(t, ok)
+	chunks, items := readChunkedSnapshot(t, snap)
+	require.Equal(t, uint32(opFSMSnapFormatVersion), items[0].Op)
+	require.Greater(t, chunks, 4)
+
+	// the snapshot sent again is resumed from the items captured
+	mp.inodeTree.ReplaceOrInsert(NewInode(5001, FileModeType), true)
+	snap, err = mp.Snapshot()
+	require.NoError(t, err)
+	require.NoError(t, snap.(raftproto.ResumableSnapshot).Skip(2))
+	restChunks, rest := readChunkedSnapshot(t, snap)
+	require.Equal(t, chunks-2, restChunks)
+	require.Equal(t, items[len(items)-len(rest):], rest)
+
+	// the items are released once the raft log after them is truncated
+	mp.truncatedIndex = mp.applyID + 1
+	mp.expireSnapSource()
+	require.Nil(t, mp.snapSource)
+}
+
+type testResumableSnapIterator struct {
+	from    uint64
+	meta    raftproto.SnapshotMeta
+	resumed uint64
+}
+
+func (i *testResumableSnapIterator) Next() ([]byte, error) {
+	return nil, io.EOF
+}
+
+func (i *testResumableSnapIterator) Source() (uint64, raftproto.SnapshotMeta, bool) {
+	return i.from, i.meta, true
+}
+
+func (i *testResumableSnapIterator) Resume(block uint64) error {
+	i.resumed = block
+	return nil
+}
+
+func TestSnapshotInstallResume(t *testing.T) {
+	mp := newSplitTestPartition(t, 1, 1, 1000)
+	iter := &testResumableSnapIterator{from: 2, meta: raftproto.SnapshotMeta{Index: 10, Term: 1}}
+	in, err := mp.beginSnapshotInstall(iter)
+	require.NoError(t, err)
+	in.inodeTree.ReplaceOrInsert(NewInode(1, FileModeType), true)
+	in.index, in.applied = 4, 3
+	mp.keepSnapshotInstall(in)
+
+	// the snapshot of the same source is resumed from the blocks applied
+	resumed, err := mp.beginSnapshotInstall(iter)
+	require.NoError(t, err)
+	require.True(t, resumed == in)
+	require.Equal(t, uint64(3), iter.resumed)
+	require.Equal(t, 3, resumed.index)
+	require.Equal(t, 1, resumed.inodeTree.Len())
+	mp.keepSnapshotInstall(resumed)
+
+	// the snapshot of another source is applied from the start
+	other := &testResumableSnapIterator{from: 2, meta: raftproto.SnapshotMeta{Index: 20, Term: 1}}
+	in, err = mp.beginSnapshotInstall(other)
+	require.NoError(t, err)
+	require.Equal(t, 0, in.inodeTree.Len())
+	require.Equal(t, 0, in.applied)
+	require.Nil(t, mp.snapInstall)
+}
@@ -0,0 +1,564 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	// the time the leader of the merged partition waits for the source to apply the freeze
+	mergeWaitApplyTimeout = 10 * time.Second
+	// a request is redirected at most the times along the chain of the splits and merges
+	maxPartitionRedirects = 4
+)
+
+var errMergeSourceNotApplied = errors.New("merge source has not applied the freeze")
+
+// partitionSplit records that the inodes from Start to End are split into the partition PartitionID.
+type partitionSplit struct {
+	PartitionID uint64 `json:"partition_id"`
+	Start       uint64 `json:"start"`
+	End         uint64 `json:"end"`
+}
+
+type fsmSplitRequest struct {
+	NewPartitionID uint64 `json:"new_partition_id"`
+	SplitKey       uint64 `json:"split_key"`
+}
+
+// fsmMergeRequest carries all the items of the frozen source partition, which are
+// moved into the partition right before it in a single raft log.
+type fsmMergeRequest struct {
+	SourceID   uint64   `json:"source_id"`
+	Start      uint64   `json:"start"`
+	End        uint64   `json:"end"`
+	Cursor     uint64   `json:"cursor"`
+	Inodes     [][]byte `json:"inodes"`
+	Dentries   [][]byte `json:"dentries"`
+	Extends    [][]byte `json:"extends"`
+	ExtentRefs []byte   `json:"extent_refs"`
+}
+
+// redirectTarget returns the partition the inode is moved to by a split or a merge, 0 is
+// returned if the inode is still served by this partition.
+func (c *MetaPartitionConfig) redirectTarget(ino uint64) (target uint64) {
+	if c.MergeTo != 0 {
+		return c.MergeTo
+	}
+	if ino <= c.End {
+		return 0
+	}
+	var start uint64
+	for _, split := range c.Splits {
+		if split.Start <= ino && split.Start >= start {
+			target, start = split.PartitionID, split.Start
+		}
+	}
+	return
+}
+
+// IsFrozen returns true if the writes of the partition are rejected, which happens
+// when the partition is being split or has been frozen to be merged.
+func (mp *metaPartition) IsFrozen() bool {
+	return atomic.LoadInt32(&mp.writeBlocked) == 1 || mp.config.MergeTo != 0
+}
+
+func (mp *metaPartition) hasPendingTx() bool {
+	return mp.txProcessor.txManager.txTree.Len() > 0 ||
+		mp.txProcessor.txResource.txRbInodeTree.Len() > 0 ||
+		mp.txProcessor.txResource.txRbDentryTree.Len() > 0
+}
+
+// medianInode returns the inode in the middle of the partition, which splits the inodes evenly.
+func (mp *metaPartition) medianInode() (key uint64, err error) {
+	inodeTree := mp.inodeTree.GetTree()
+	cnt := inodeTree.Len()
+	if cnt < 2 {
+		err = fmt.Errorf("too few inodes(%v) to split", cnt)
+		return
+	}
+	i := 0
+	inodeTree.Ascend(func(item BtreeItem) bool {
+		if i == cnt/2 {
+			key = item.(*Inode).Inode
+			return false
+		}
+		i++
+		return true
+	})
+	return
+}
+
+// SplitPartition splits the inodes not less than the split key into a new partition with the
+// same members. The dentries of the moved inodes as parents are moved together.
+func (mp *metaPartition) SplitPartition(req *proto.SplitMetaPartitionRequest, resp *proto.SplitMetaPartitionResponse) (err error) {
+	resp.PartitionID = req.PartitionID
+	resp.NewPartitionID = req.NewPartitionID
+	defer func() {
+		if err != nil {
+			resp.Status = proto.TaskFailed
+			resp.Result = err.Error()
+			return
+		}
+		resp.Status = proto.TaskSucceeds
+	}()
+
+	if mp.config.MergeTo != 0 {
+		err = fmt.Errorf("partition(%v) is frozen to merge into(%v)", mp.config.PartitionId, mp.config.MergeTo)
+		return
+	}
+	key := req.SplitKey
+	if key == 0 {
+		if key, err = mp.medianInode(); err != nil {
+			return
+		}
+	}
+	if key <= mp.config.Start || key > mp.config.End {
+		err = fmt.Errorf("split key(%v) out of range(%v,%v)", key, mp.config.Start, mp.config.End)
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&mp.writeBlocked, 0, 1) {
+		err = fmt.Errorf("partition(%v) is being split or frozen", mp.config.PartitionId)
+		return
+	}
+	defer atomic.StoreInt32(&mp.writeBlocked, 0)
+
+	data, err := json.Marshal(&fsmSplitRequest{NewPartitionID: req.NewPartitionID, SplitKey: key})
+	if err != nil {
+		return
+	}
+	r, err := mp.submit(opFSMSplitPartition, data)
+	if err != nil {
+		return
+	}
+	if status := r.(uint8); status != proto.OpOk {
+		p := &Packet{}
+		p.ResultCode = status
+		err = errors.NewErrorf("[SplitPartition]: %s", p.GetResultMsg())
+		return
+	}
+	resp.SplitKey = key
+	resp.End = mp.config.End
+	log.LogWarnf("[SplitPartition] mp(%v) split into mp(%v) from inode(%v)", mp.config.PartitionId, req.NewPartitionID, key)
+	return
+}
+
+func (mp *metaPartition) fsmSplitPartition(req *fsmSplitRequest) (status uint8, err error) {
+	status = proto.OpOk
+	key, end := req.SplitKey, mp.config.End
+	replay := false
+	for _, split := range mp.config.Splits {
+		if split.PartitionID == req.NewPartitionID {
+			key, end = split.Start, split.End
+			replay = true
+		}
+	}
+	if !replay {
+		if mp.config.MergeTo != 0 || key <= mp.config.Start || key > mp.config.End {
+			log.LogErrorf("[fsmSplitPartition] mp(%v) range(%v,%v) mergeTo(%v) cannot split from(%v)",
+				mp.config.PartitionId, mp.config.Start, mp.config.End, mp.config.MergeTo, key)
+			status = proto.OpArgMismatchErr
+			return
+		}
+		if mp.hasPendingTx() {
+			log.LogWarnf("[fsmSplitPartition] mp(%v) has pending transactions", mp.config.PartitionId)
+			status = proto.OpAgain
+			return
+		}
+	}
+
+	childDir := path.Join(path.Dir(mp.config.RootDir), partitionPrefix+strconv.FormatUint(req.NewPartitionID, 10))
+	created := false
+	if _, statErr := os.Stat(path.Join(childDir, metadataFile)); statErr != nil {
+		// the metadata of the new partition is persisted at last, it is created again
+		// from the same items if the metanode crashed in the middle
+		if err = mp.storeSplitPartition(req.NewPartitionID, key, end, childDir); err != nil {
+			log.LogErrorf("[fsmSplitPartition] mp(%v) store new partition(%v) err(%v)",
+				mp.config.PartitionId, req.NewPartitionID, err)
+			status = proto.OpDiskErr
+			err = nil
+			return
+		}
+		created = true
+	}
+
+	oldEnd, oldSplits := mp.config.End, mp.config.Splits
+	mp.config.End = key - 1
+	if !replay {
+		mp.config.Splits = append(append([]*partitionSplit{}, oldSplits...),
+			&partitionSplit{PartitionID: req.NewPartitionID, Start: key, End: end})
+	}
+	if err = mp.PersistMetadata(); err != nil {
+		log.LogErrorf("[fsmSplitPartition] mp(%v) persist err(%v)", mp.config.PartitionId, err)
+		mp.config.End, mp.config.Splits = oldEnd, oldSplits
+		status = proto.OpDiskErr
+		err = nil
+		return
+	}
+	mp.removeSplitItems(key)
+
+	if created && mp.manager != nil {
+		go mp.manager.attachSplitPartition(req.NewPartitionID)
+	}
+	log.LogWarnf("[fsmSplitPartition] mp(%v) range(%v,%v) split into mp(%v) from(%v) replay(%v)",
+		mp.config.PartitionId, mp.config.Start, end, req.NewPartitionID, key, replay)
+	return
+}
+
+// splitItems returns the items of the inodes not less than key.
+func (mp *metaPartition) splitItems(key uint64) (inodeTree, dentryTree, extendTree *BTree) {
+	inodeTree, dentryTree, extendTree = NewBtree(), NewBtree(), NewBtree()
+	mp.inodeTree.GetTree().AscendGreaterOrEqual(NewInode(key, 0), func(i BtreeItem) bool {
+		inodeTree.ReplaceOrInsert(i, true)
+		return true
+	})
+	mp.dentryTree.GetTree().AscendGreaterOrEqual(&Dentry{ParentId: key}, func(i BtreeItem) bool {
+		dentryTree.ReplaceOrInsert(i, true)
+		return true
+	})
+	mp.extendTree.GetTree().AscendGreaterOrEqual(NewExtend(key), func(i BtreeItem) bool {
+		extendTree.ReplaceOrInsert(i, true)
+		return true
+	})
+	return
+}
+
+// removeSplitItems removes the items moved to the split partition.
+func (mp *metaPartition) removeSplitItems(key uint64) {
+	mp.inodeTree.GetTree().AscendGreaterOrEqual(NewInode(key, 0), func(i BtreeItem) bool {
+		mp.inodeTree.Delete(i)
+		mp.freeList.Remove(i.(*Inode).Inode)
+		return true
+	})
+	mp.dentryTree.GetTree().AscendGreaterOrEqual(&Dentry{ParentId: key}, func(i BtreeItem) bool {
+		mp.dentryTree.Delete(i)
+		return true
+	})
+	mp.extendTree.GetTree().AscendGreaterOrEqual(NewExtend(key), func(i BtreeItem) bool {
+		mp.extendTree.Delete(i)
+		return true
+	})
+}
+
+// storeSplitPartition stores the snapshot of the new partition split from key, which is loaded
+// as a new raft group. The shared extents are counted in both partitions, so they are never
+// deleted by mistake even though they might be kept longer.
+func (mp *metaPartition) storeSplitPartition(id, key, end uint64, rootDir string) (err error) {
+	conf := &MetaPartitionConfig{
+		PartitionId:   id,
+		VolName:       mp.config.VolName,
+		Start:         key,
+		End:           end,
+		PartitionType: mp.config.PartitionType,
+		Peers:         append([]proto.Peer(nil), mp.config.Peers...),
+		Cursor:        mp.GetCursor(),
+		RootDir:       rootDir,
+		VerSeq:        mp.config.VerSeq,
+		SplitFrom:     mp.config.PartitionId,
+		StoreType:     mp.config.StoreType,
+	}
+	if conf.Cursor < key {
+		conf.Cursor = key
+	}
+	child := NewMetaPartition(conf, mp.manager).(*metaPartition)
+	child.uidManager = NewUidMgr(conf.VolName, id)
+	child.mqMgr = NewQuotaManager(conf.VolName, id)
+	child.uqMgr = NewUserQuotaManager(conf.VolName, id)
+
+	inodeTree, dentryTree, extendTree := mp.splitItems(key)
+	sm := &storeMsg{
+		command:        opFSMStoreTick,
+		inodeTree:      inodeTree,
+		dentryTree:     dentryTree,
+		extendTree:     extendTree,
+		multipartTree:  NewBtree(),
+		txTree:         NewBtree(),
+		txRbInodeTree:  NewBtree(),
+		txRbDentryTree: NewBtree(),
+		uniqId:         mp.GetUniqId(),
+		uniqChecker:    mp.uniqChecker.clone(),
+		extentRefs:     mp.extentRefs.clone(),
+		changeLog:      child.changeLog,
+		multiVerList:   mp.GetAllVerList(),
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(rootDir)
+		}
+	}()
+	if err = os.MkdirAll(rootDir, 0o755); err != nil {
+		return
+	}
+	if err = child.store(sm); err != nil {
+		return
+	}
+	return child.PersistMetadata()
+}
+
+// FreezePartition rejects the writes of the partition before it is merged into the partition
+// MergeTo, or accepts the writes again if the merge is given up.
+func (mp *metaPartition) FreezePartition(req *proto.FreezeMetaPartitionRequest, resp *proto.FreezeMetaPartitionResponse) (err error) {
+	resp.PartitionID = req.PartitionID
+	defer func() {
+		if err != nil {
+			resp.Status = proto.TaskFailed
+			resp.Result = err.Error()
+			return
+		}
+		resp.Status = proto.TaskSucceeds
+	}()
+
+	if req.Frozen {
+		// the writes are rejected before the freeze is proposed, so that nothing is
+		// written to the partition after the freeze is applied
+		if !atomic.CompareAndSwapInt32(&mp.writeBlocked, 0, 1) {
+			err = fmt.Errorf("partition(%v) is being split or frozen", mp.config.PartitionId)
+			return
+		}
+		defer atomic.StoreInt32(&mp.writeBlocked, 0)
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return
+	}
+	r, err := mp.submit(opFSMFreezePartition, data)
+	if err != nil {
+		return
+	}
+	if status := r.(uint8); status != proto.OpOk {
+		p := &Packet{}
+		p.ResultCode = status
+		err = errors.NewErrorf("[FreezePartition]: %s", p.GetResultMsg())
+		return
+	}
+	resp.ApplyID = mp.getApplyID()
+	return
+}
+
+func (mp *metaPartition) fsmFreezePartition(req *proto.FreezeMetaPartitionRequest) (status uint8, err error) {
+	status = proto.OpOk
+	oldMergeTo := mp.config.MergeTo
+	if req.Frozen {
+		if oldMergeTo != 0 && oldMergeTo != req.MergeTo {
+			status = proto.OpArgMismatchErr
+			return
+		}
+		mp.config.MergeTo = req.MergeTo
+	} else {
+		mp.config.MergeTo = 0
+	}
+	if err = mp.PersistMetadata(); err != nil {
+		log.LogErrorf("[fsmFreezePartition] mp(%v) persist err(%v)", mp.config.PartitionId, err)
+		mp.config.MergeTo = oldMergeTo
+		status = proto.OpDiskErr
+		err = nil
+		return
+	}
+	log.LogWarnf("[fsmFreezePartition] mp(%v) frozen(%v) mergeTo(%v)", mp.config.PartitionId, req.Frozen, req.MergeTo)
+	return
+}
+
+// buildMergeRequest collects all the items of the partition frozen to merge into target,
+// once the partition has applied the freeze.
+func (mp *metaPartition) buildMergeRequest(target, applyID uint64) (req *fsmMergeRequest, err error) {
+	if mp.getApplyID() < applyID {
+		err = errMergeSourceNotApplied
+		return
+	}
+	if mp.config.MergeTo != target {
+		err = fmt.Errorf("partition(%v) is not frozen to merge into(%v)", mp.config.PartitionId, target)
+		return
+	}
+	if mp.multipartTree.Len() > 0 || mp.hasPendingTx() {
+		err = fmt.Errorf("partition(%v) has multiparts or transactions", mp.config.PartitionId)
+		return
+	}
+	req = &fsmMergeRequest{
+		SourceID: mp.config.PartitionId,
+		Start:    mp.config.Start,
+		End:      mp.config.End,
+		Cursor:   mp.GetCursor(),
+	}
+	mp.inodeTree.GetTree().Ascend(func(i BtreeItem) bool {
+		var data []byte
+		if data, err = i.(*Inode).Marshal(); err != nil {
+			return false
+		}
+		req.Inodes = append(req.Inodes, data)
+		return true
+	})
+	if err != nil {
+		return
+	}
+	mp.dentryTree.GetTree().Ascend(func(i BtreeItem) bool {
+		var data []byte
+		if data, err = i.(*Dentry).Marshal(); err != nil {
+			return false
+		}
+		req.Dentries = append(req.Dentries, data)
+		return true
+	})
+	if err != nil {
+		return
+	}
+	mp.extendTree.GetTree().Ascend(func(i BtreeItem) bool {
+		var data []byte
+		if data, err = i.(*Extend).Bytes(); err != nil {
+			return false
+		}
+		req.Extends = append(req.Extends, data)
+		return true
+	})
+	if err != nil {
+		return
+	}
+	req.ExtentRefs, _, err = mp.extentRefs.Marshal()
+	return
+}
+
+// MergePartition merges the adjacent partition after this one, which must be frozen and
+// served by the same metanodes, so that the source items are read locally.
+func (mp *metaPartition) MergePartition(req *proto.MergeMetaPartitionRequest, source MetaPartition, resp *proto.MergeMetaPartitionResponse) (err error) {
+	resp.PartitionID = req.PartitionID
+	defer func() {
+		if err != nil {
+			resp.Status = proto.TaskFailed
+			resp.Result = err.Error()
+			return
+		}
+		resp.Status = proto.TaskSucceeds
+	}()
+
+	if mp.IsFrozen() {
+		err = fmt.Errorf("partition(%v) is being split or frozen", mp.config.PartitionId)
+		return
+	}
+	var mergeReq *fsmMergeRequest
+	deadline := time.Now().Add(mergeWaitApplyTimeout)
+	for {
+		if mergeReq, err = source.buildMergeRequest(mp.config.PartitionId, req.SourceApplyID); err != errMergeSourceNotApplied {
+			break
+		}
+		if time.Now().After(deadline) {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		return
+	}
+	if mergeReq.Start != mp.config.End+1 {
+		err = fmt.Errorf("partition(%v) range(%v,%v) is not adjacent to(%v,%v)",
+			mp.config.PartitionId, mp.config.Start, mp.config.End, mergeReq.Start, mergeReq.End)
+		return
+	}
+	data, err := json.Marshal(mergeReq)
+	if err != nil {
+		return
+	}
+	r, err := mp.submit(opFSMMergePartition, data)
+	if err != nil {
+		return
+	}
+	if status := r.(uint8); status != proto.OpOk {
+		p := &Packet{}
+		p.ResultCode = status
+		err = errors.NewErrorf("[MergePartition]: %s", p.GetResultMsg())
+		return
+	}
+	resp.End = mp.config.End
+	log.LogWarnf("[MergePartition] mp(%v) merged mp(%v) inodes(%v), end(%v)",
+		mp.config.PartitionId, req.SourceID, len(mergeReq.Inodes), resp.End)
+	return
+}
+
+func (mp *metaPartition) fsmMergePartition(req *fsmMergeRequest) (status uint8, err error) {
+	status = proto.OpOk
+	// the end is persisted before the items are stored, the merge is applied again on restart
+	if mp.config.MergeTo != 0 || (mp.config.End+1 != req.Start && mp.config.End != req.End) {
+		log.LogErrorf("[fsmMergePartition] mp(%v) range(%v,%v) mergeTo(%v) cannot merge mp(%v) range(%v,%v)",
+			mp.config.PartitionId, mp.config.Start, mp.config.End, mp.config.MergeTo, req.SourceID, req.Start, req.End)
+		status = proto.OpArgMismatchErr
+		return
+	}
+	inodes := make([]*Inode, 0, len(req.Inodes))
+	for _, data := range req.Inodes {
+		ino := NewInode(0, 0)
+		if err = ino.Unmarshal(data); err != nil {
+			return
+		}
+		inodes = append(inodes, ino)
+	}
+	dentries := make([]*Dentry, 0, len(req.Dentries))
+	for _, data := range req.Dentries {
+		den := &Dentry{}
+		if err = den.Unmarshal(data); err != nil {
+			return
+		}
+		dentries = append(dentries, den)
+	}
+	extends := make([]*Extend, 0, len(req.Extends))
+	for _, data := range req.Extends {
+		var extend *Extend
+		if extend, err = NewExtendFromBytes(data); err != nil {
+			return
+		}
+		extends = append(extends, extend)
+	}
+	refs := newExtentRefs()
+	if len(req.ExtentRefs) > 0 {
+		if err = refs.UnMarshal(req.ExtentRefs); err != nil {
+			return
+		}
+	}
+
+	oldEnd := mp.config.End
+	mp.config.End = req.End
+	if err = mp.PersistMetadata(); err != nil {
+		log.LogErrorf("[fsmMergePartition] mp(%v) persist err(%v)", mp.config.PartitionId, err)
+		mp.config.End = oldEnd
+		status = proto.OpDiskErr
+		err = nil
+		return
+	}
+	for _, ino := range inodes {
+		mp.inodeTree.ReplaceOrInsert(ino, true)
+		mp.checkAndInsertFreeList(ino)
+	}
+	for _, den := range dentries {
+		mp.dentryTree.ReplaceOrInsert(den, true)
+	}
+	for _, extend := range extends {
+		mp.extendTree.ReplaceOrInsert(extend, true)
+	}
+	mp.extentRefs.merge(refs)
+	if mp.GetCursor() < req.Cursor {
+		atomic.StoreUint64(&mp.config.Cursor, req.Cursor)
+	}
+	log.LogWarnf("[fsmMergePartition] mp(%v) range(%v,%v) merged mp(%v) range(%v,%v) inodes(%v)",
+		mp.config.PartitionId, mp.config.Start, oldEnd, req.SourceID, req.Start, req.End, len(inodes))
+	return
+}
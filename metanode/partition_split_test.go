@@ -0,0 +1,127 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func newSplitTestPartition(t *testing.T, id, start, end uint64) *metaPartition {
+	conf := &MetaPartitionConfig{
+		PartitionId: id,
+		VolName:     "splitVol",
+		Start:       start,
+		End:         end,
+		Peers:       []proto.Peer{{ID: 1, Addr: "127.0.0.1:17210"}},
+		RootDir:     path.Join(t.TempDir(), partitionPrefix+fmt.Sprint(id)),
+	}
+	mp := NewMetaPartition(conf, &metadataManager{}).(*metaPartition)
+	mp.uidManager = NewUidMgr(conf.VolName, id)
+	mp.mqMgr = NewQuotaManager(conf.VolName, id)
+	return mp
+}
+
+func addSplitTestInodes(mp *metaPartition, start, end uint64) {
+	for ino := start; ino <= end; ino++ {
+		mp.inodeTree.ReplaceOrInsert(NewInode(ino, proto.Mode(os.ModeDir)), true)
+		mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: ino, Name: fmt.Sprint(ino), Inode: ino + 100}, true)
+		extend := NewExtend(ino)
+		extend.Put([]byte("k"), []byte("v"), 0)
+		mp.extendTree.ReplaceOrInsert(extend, true)
+	}
+	mp.config.Cursor = end
+}
+
+func TestSplitPartitionItems(t *testing.T) {
+	mp := newSplitTestPartition(t, 1, 1, 1000)
+	addSplitTestInodes(mp, 1, 10)
+
+	key, err := mp.medianInode()
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), key)
+
+	childDir := path.Join(path.Dir(mp.config.RootDir), partitionPrefix+"2")
+	require.NoError(t, mp.storeSplitPartition(2, key, mp.config.End, childDir))
+	mp.removeSplitItems(key)
+	require.Equal(t, 5, mp.inodeTree.Len())
+	require.Equal(t, 5, mp.dentryTree.Len())
+	require.Equal(t, 5, mp.extendTree.Len())
+
+	// the new partition is loaded from the stored snapshot
+	child := NewMetaPartition(&MetaPartitionConfig{PartitionId: 2, RootDir: childDir}, &metadataManager{}).(*metaPartition)
+	require.NoError(t, child.load(false))
+	require.Equal(t, uint64(6), child.config.Start)
+	require.Equal(t, uint64(1000), child.config.End)
+	require.Equal(t, uint64(1), child.config.SplitFrom)
+	require.Equal(t, uint64(10), child.GetCursor())
+	require.Equal(t, 5, child.inodeTree.Len())
+	require.Equal(t, 5, child.dentryTree.Len())
+	require.Equal(t, 5, child.extendTree.Len())
+	require.NotNil(t, child.inodeTree.Get(NewInode(6, 0)))
+
+	mp.config.End = key - 1
+	mp.config.Splits = []*partitionSplit{{PartitionID: 2, Start: key, End: 1000}}
+	require.Zero(t, mp.config.redirectTarget(5))
+	require.Equal(t, uint64(2), mp.config.redirectTarget(6))
+	status, err := mp.fsmUpdatePartition(2000, "")
+	require.NoError(t, err)
+	require.Equal(t, proto.OpArgMismatchErr, status)
+
+	// the name of a renamed volume comes with the end
+	mp.config.Splits = nil
+	status, err = mp.fsmUpdatePartition(2000, "renamedVol")
+	require.NoError(t, err)
+	require.Equal(t, proto.OpOk, status)
+	require.Equal(t, "renamedVol", mp.config.VolName)
+}
+
+func TestMergePartition(t *testing.T) {
+	target := newSplitTestPartition(t, 1, 1, 100)
+	addSplitTestInodes(target, 1, 5)
+	source := newSplitTestPartition(t, 2, 101, 1000)
+	addSplitTestInodes(source, 101, 110)
+
+	_, err := source.buildMergeRequest(1, 0)
+	require.Error(t, err)
+	status, err := source.fsmFreezePartition(&proto.FreezeMetaPartitionRequest{PartitionID: 2, MergeTo: 1, Frozen: true})
+	require.NoError(t, err)
+	require.Equal(t, proto.OpOk, status)
+	require.True(t, source.IsFrozen())
+	require.Equal(t, uint64(1), source.config.redirectTarget(105))
+
+	req, err := source.buildMergeRequest(1, 0)
+	require.NoError(t, err)
+	require.Len(t, req.Inodes, 10)
+	status, err = target.fsmMergePartition(req)
+	require.NoError(t, err)
+	require.Equal(t, proto.OpOk, status)
+	require.Equal(t, uint64(1000), target.config.End)
+	require.Equal(t, uint64(110), target.GetCursor())
+	require.Equal(t, 15, target.inodeTree.Len())
+	require.Equal(t, 15, target.dentryTree.Len())
+	require.Equal(t, 15, target.extendTree.Len())
+
+	// the merge is applied again on restart
+	status, err = target.fsmMergePartition(req)
+	require.NoError(t, err)
+	require.Equal(t, proto.OpOk, status)
+	require.Equal(t, 15, target.inodeTree.Len())
+}
@@ -52,6 +52,9 @@ const (
 	uniqIDFile              = "uniqID"
 	uniqCheckerFile         = "uniqChecker"
 	verdataFile             = "multiVer"
+	extentRefsFile          = "extentRefs"
+	metaChangeLogFile       = "metaChangeLog"
+	deltaFile               = "delta"
 	StaleMetadataSuffix     = ".old"
 	StaleMetadataTimeFormat = "20060102150405.000000000"
 	verdataInitFile         = "multiVerInitFile"
@@ -86,11 +89,16 @@ func (mp *metaPartition) loadMetadata() (err error) {
 	mp.config.Start = mConf.Start
 	mp.config.End = mConf.End
 	mp.config.Peers = mConf.Peers
+	mp.config.SplitFrom = mConf.SplitFrom
+	mp.config.Splits = mConf.Splits
+	mp.config.MergeTo = mConf.MergeTo
+	mp.config.StoreType = mConf.StoreType
 	mp.config.Cursor = mp.config.Start
 	mp.config.UniqId = 0
 
 	mp.uidManager = NewUidMgr(mp.config.VolName, mp.config.PartitionId)
 	mp.mqMgr = NewQuotaManager(mp.config.VolName, mp.config.PartitionId)
+	mp.uqMgr = NewUserQuotaManager(mp.config.VolName, mp.config.PartitionId)
 
 	log.LogInfof("loadMetadata: load complete: partitionID(%v) volume(%v) range(%v,%v) cursor(%v)",
 		mp.config.PartitionId, mp.config.VolName, mp.config.Start, mp.config.End, mp.config.Cursor)
@@ -116,6 +124,17 @@ func (mp *metaPartition) loadInode(rootDir string, crc uint32) (err error) {
 		return
 	}
 	defer fp.Close()
+	loadItem := func(ino *Inode) {
+		mp.acucumUidSizeByLoad(ino)
+		mp.size += ino.Size
+
+		mp.fsmCreateInode(ino)
+		mp.checkAndInsertFreeList(ino)
+		if mp.config.Cursor < ino.Inode {
+			mp.config.Cursor = ino.Inode
+		}
+		numInodes += 1
+	}
 	reader := bufio.NewReaderSize(fp, 4*1024*1024)
 	inoBuf := make([]byte, 4)
 	crcCheck := crc32.NewIEEE()
@@ -130,7 +149,10 @@ func (mp *metaPartition) loadInode(rootDir string, crc uint32) (err error) {
 					log.LogErrorf("[loadInode]: check crc mismatch, expected[%d], actual[%d]", crc, res)
 					return ErrSnapshotCrcMismatch
 				}
-				return
+				return mp.loadingDelta.puts(inodeCodec, func(i BtreeItem) error {
+					loadItem(i.(*Inode))
+					return nil
+				})
 			}
 			err = errors.NewErrorf("[loadInode] ReadHeader: %s", err.Error())
 			return
@@ -158,20 +180,14 @@ func (mp *metaPartition) loadInode(rootDir string, crc uint32) (err error) {
 			err = errors.NewErrorf("[loadInode] Unmarshal: %s", err.Error())
 			return
 		}
-		mp.acucumUidSizeByLoad(ino)
 		// data crc
 		if _, err = crcCheck.Write(inoBuf); err != nil {
 			return err
 		}
-
-		mp.size += ino.Size
-
-		mp.fsmCreateInode(ino)
-		mp.checkAndInsertFreeList(ino)
-		if mp.config.Cursor < ino.Inode {
-			mp.config.Cursor = ino.Inode
+		// the inodes changed since the base are loaded from the delta
+		if !mp.loadingDelta.replaced(inodeCodec, ino) {
+			loadItem(ino)
 		}
-		numInodes += 1
 	}
 }
 
@@ -196,6 +212,13 @@ func (mp *metaPartition) loadDentry(rootDir string, crc uint32) (err error) {
 	}
 
 	defer fp.Close()
+	loadItem := func(dentry *Dentry) error {
+		if status := mp.fsmCreateDentry(dentry, true); status != proto.OpOk {
+			return errors.NewErrorf("[loadDentry] createDentry dentry: %v, resp code: %d", dentry, status)
+		}
+		numDentries += 1
+		return nil
+	}
 	reader := bufio.NewReaderSize(fp, 4*1024*1024)
 	dentryBuf := make([]byte, 4)
 	crcCheck := crc32.NewIEEE()
@@ -210,7 +233,9 @@ func (mp *metaPartition) loadDentry(rootDir string, crc uint32) (err error) {
 					log.LogErrorf("[loadDentry]: check crc mismatch, expected[%d], actual[%d]", crc, res)
 					return ErrSnapshotCrcMismatch
 				}
-				return
+				return mp.loadingDelta.puts(dentryCodec, func(i BtreeItem) error {
+					return loadItem(i.(*Dentry))
+				})
 			}
 			err = errors.NewErrorf("[loadDentry] ReadHeader: %s", err.Error())
 			return
@@ -236,14 +261,15 @@ func (mp *metaPartition) loadDentry(rootDir string, crc uint32) (err error) {
 			err = errors.NewErrorf("[loadDentry] Unmarshal: %s", err.Error())
 			return
 		}
-		if status := mp.fsmCreateDentry(dentry, true); status != proto.OpOk {
-			err = errors.NewErrorf("[loadDentry] createDentry dentry: %v, resp code: %d", dentry, status)
-			return
-		}
 		if _, err = crcCheck.Write(dentryBuf); err != nil {
 			return err
 		}
-		numDentries += 1
+		if mp.loadingDelta.replaced(dentryCodec, dentry) {
+			continue
+		}
+		if err = loadItem(dentry); err != nil {
+			return
+		}
 	}
 }
 
@@ -297,14 +323,23 @@ func (mp *metaPartition) loadExtend(rootDir string, crc uint32) (err error) {
 		}
 		// log.LogDebugf("loadExtend: new extend from bytes: partitionID (%v) volume(%v) inode[%v]",
 		//	mp.config.PartitionId, mp.config.VolName, extend.inode)
-		_ = mp.fsmSetXAttr(extend)
+		if !mp.loadingDelta.replaced(extendCodec, extend) {
+			_ = mp.fsmSetXAttr(extend)
+		}
 
 		if _, err = crcCheck.Write(mem[offset : offset+int(numBytes)]); err != nil {
 			return
 		}
 		offset += int(numBytes)
-		mp.statisticExtendByLoad(extend)
+		if !mp.loadingDelta.replaced(extendCodec, extend) {
+			mp.statisticExtendByLoad(extend)
+		}
 	}
+	_ = mp.loadingDelta.puts(extendCodec, func(i BtreeItem) error {
+		_ = mp.fsmSetXAttr(i.(*Extend))
+		mp.statisticExtendByLoad(i.(*Extend))
+		return nil
+	})
 
 	log.LogInfof("loadExtend: load complete: partitionID(%v) volume(%v) numExtends(%v) filename(%v)",
 		mp.config.PartitionId, mp.config.VolName, numExtends, filename)
@@ -359,12 +394,18 @@ func (mp *metaPartition) loadMultipart(rootDir string, crc uint32) (err error) {
 		var multipart *Multipart
 		multipart = MultipartFromBytes(mem[offset : offset+int(numBytes)])
 		log.LogDebugf("loadMultipart: create multipart from bytes: partitionID（%v) multipartID(%v)", mp.config.PartitionId, multipart.id)
-		mp.fsmCreateMultipart(multipart)
+		if !mp.loadingDelta.replaced(multipartCodec, multipart) {
+			mp.fsmCreateMultipart(multipart)
+		}
 		offset += int(numBytes)
 		if _, err = crcCheck.Write(mem[offset-int(numBytes) : offset]); err != nil {
 			return err
 		}
 	}
+	_ = mp.loadingDelta.puts(multipartCodec, func(i BtreeItem) error {
+		mp.fsmCreateMultipart(i.(*Multipart))
+		return nil
+	})
 	log.LogInfof("loadMultipart: load complete: partitionID(%v) numMultiparts(%v) filename(%v)",
 		mp.config.PartitionId, numMultiparts, filename)
 	if res := crcCheck.Sum32(); res != crc {
@@ -725,6 +766,46 @@ func (mp *metaPartition) loadUniqChecker(rootDir string, crc uint32) (err error)
 	return
 }
 
+func (mp *metaPartition) loadExtentRefs(rootDir string, crc uint32) (err error) {
+	filename := path.Join(rootDir, extentRefsFile)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		err = errors.NewErrorf("[loadExtentRefs] OpenFile: %v", err.Error())
+		return
+	}
+	if res := crc32.ChecksumIEEE(data); res != crc {
+		log.LogErrorf("[loadExtentRefs]: check crc mismatch, expected[%d], actual[%d]", crc, res)
+		return ErrSnapshotCrcMismatch
+	}
+	if err = mp.extentRefs.UnMarshal(data); err != nil {
+		err = errors.NewErrorf("[loadExtentRefs] Unmarshal: %v", err.Error())
+		return
+	}
+	log.LogInfof("loadExtentRefs: load complete: partitionID(%v) volume(%v) sharedExtents(%v)",
+		mp.config.PartitionId, mp.config.VolName, mp.extentRefs.Len())
+	return
+}
+
+func (mp *metaPartition) loadMetaChangeLog(rootDir string, crc uint32) (err error) {
+	filename := path.Join(rootDir, metaChangeLogFile)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		err = errors.NewErrorf("[loadMetaChangeLog] OpenFile: %v", err.Error())
+		return
+	}
+	if res := crc32.ChecksumIEEE(data); res != crc {
+		log.LogErrorf("[loadMetaChangeLog]: check crc mismatch, expected[%d], actual[%d]", crc, res)
+		return ErrSnapshotCrcMismatch
+	}
+	if err = mp.changeLog.UnMarshal(data); err != nil {
+		err = errors.NewErrorf("[loadMetaChangeLog] Unmarshal: %v", err.Error())
+		return
+	}
+	log.LogInfof("loadMetaChangeLog: load complete: partitionID(%v) volume(%v) changes(%v)",
+		mp.config.PartitionId, mp.config.VolName, mp.changeLog.Len())
+	return
+}
+
 func (mp *metaPartition) loadMultiVer(rootDir string, crc uint32) (err error) {
 	filename := path.Join(rootDir, verdataFile)
 	if _, err = os.Stat(filename); err != nil {
@@ -1058,6 +1139,9 @@ func (mp *metaPartition) storeInode(rootDir string,
 	}()
 
 	size := uint64(0)
+	// the inodes kept in RocksDB are flushed by the store and the ones stored as delta are linked to
+	// the base, only the statistics are collected
+	skip := isRocksTree(sm.inodeTree) || sm.deltaBase != nil
 
 	var data []byte
 	lenBuf := make([]byte, 4)
@@ -1067,13 +1151,19 @@ func (mp *metaPartition) storeInode(rootDir string,
 		if sm.uidRebuild {
 			mp.acucumUidSizeByStore(ino)
 		}
-
-		if data, err = ino.Marshal(); err != nil {
-			return false
+		if sm.userQuotaRebuild {
+			mp.uqMgr.statisticInodeByStore(ino)
 		}
 
 		size += ino.Size
 		mp.fileStats(ino)
+		if skip {
+			return true
+		}
+
+		if data, err = ino.Marshal(); err != nil {
+			return false
+		}
 
 		// set length
 		binary.BigEndian.PutUint32(lenBuf, uint32(len(data)))
@@ -1093,6 +1183,9 @@ func (mp *metaPartition) storeInode(rootDir string,
 		return true
 	})
 	mp.acucumRebuildFin(sm.uidRebuild)
+	if sm.userQuotaRebuild {
+		mp.uqMgr.statisticRebuildFin(err == nil)
+	}
 	crc = sign.Sum32()
 	mp.size = size
 
@@ -1104,6 +1197,10 @@ func (mp *metaPartition) storeInode(rootDir string,
 
 func (mp *metaPartition) storeDentry(rootDir string,
 	sm *storeMsg) (crc uint32, err error) {
+	if sm.deltaBase != nil {
+		// linked to the base by the store
+		return
+	}
 	filename := path.Join(rootDir, dentryFile)
 	fp, err := os.OpenFile(filename, os.O_RDWR|os.O_TRUNC|os.O_APPEND|os.
 		O_CREATE, 0o755)
@@ -1118,7 +1215,7 @@ func (mp *metaPartition) storeDentry(rootDir string,
 	var data []byte
 	lenBuf := make([]byte, 4)
 	sign := crc32.NewIEEE()
-	sm.dentryTree.Ascend(func(i BtreeItem) bool {
+	ascendToStore(sm.dentryTree, func(i BtreeItem) bool {
 		dentry := i.(*Dentry)
 		data, err = dentry.Marshal()
 		if err != nil {
@@ -1167,7 +1264,7 @@ func (mp *metaPartition) storeExtend(rootDir string, sm *storeMsg) (crc uint32,
 	varintTmp := make([]byte, binary.MaxVarintLen64)
 	var n int
 	// write number of extends
-	n = binary.PutUvarint(varintTmp, uint64(extendTree.Len()))
+	n = binary.PutUvarint(varintTmp, uint64(storedLen(extendTree)))
 	if _, err = writer.Write(varintTmp[:n]); err != nil {
 		return
 	}
@@ -1181,6 +1278,9 @@ func (mp *metaPartition) storeExtend(rootDir string, sm *storeMsg) (crc uint32,
 		if sm.quotaRebuild {
 			mp.statisticExtendByStore(e, sm.inodeTree)
 		}
+		if isRocksTree(extendTree) || sm.deltaBase != nil {
+			return true
+		}
 		if raw, err = e.Bytes(); err != nil {
 			return false
 		}
@@ -1221,6 +1321,10 @@ func (mp *metaPartition) storeExtend(rootDir string, sm *storeMsg) (crc uint32,
 }
 
 func (mp *metaPartition) storeMultipart(rootDir string, sm *storeMsg) (crc uint32, err error) {
+	if sm.deltaBase != nil {
+		// linked to the base by the store
+		return
+	}
 	multipartTree := sm.multipartTree
 	fp := path.Join(rootDir, multipartFile)
 	var f *os.File
@@ -1239,14 +1343,14 @@ func (mp *metaPartition) storeMultipart(rootDir string, sm *storeMsg) (crc uint3
 	varintTmp := make([]byte, binary.MaxVarintLen64)
 	var n int
 	// write number of extends
-	n = binary.PutUvarint(varintTmp, uint64(multipartTree.Len()))
+	n = binary.PutUvarint(varintTmp, uint64(storedLen(multipartTree)))
 	if _, err = writer.Write(varintTmp[:n]); err != nil {
 		return
 	}
 	if _, err = crc32.Write(varintTmp[:n]); err != nil {
 		return
 	}
-	multipartTree.Ascend(func(i BtreeItem) bool {
+	ascendToStore(multipartTree, func(i BtreeItem) bool {
 		m := i.(*Multipart)
 		var raw []byte
 		if raw, err = m.Bytes(); err != nil {
@@ -1304,6 +1408,62 @@ func (mp *metaPartition) storeUniqID(rootDir string, sm *storeMsg) (err error) {
 	return
 }
 
+func (mp *metaPartition) storeExtentRefs(rootDir string, sm *storeMsg) (crc uint32, err error) {
+	filename := path.Join(rootDir, extentRefsFile)
+	fp, err := os.OpenFile(filename, os.O_RDWR|os.O_TRUNC|os.O_APPEND|os.
+		O_CREATE, 0o755)
+	if err != nil {
+		return
+	}
+	defer func() {
+		err = fp.Sync()
+		fp.Close()
+	}()
+
+	refs := sm.extentRefs
+	if refs == nil {
+		refs = newExtentRefs()
+	}
+	var data []byte
+	if data, crc, err = refs.Marshal(); err != nil {
+		return
+	}
+	if _, err = fp.Write(data); err != nil {
+		return
+	}
+	log.LogInfof("storeExtentRefs: store complete: partitionID(%v) volume(%v) sharedExtents(%v) crc(%v)",
+		mp.config.PartitionId, mp.config.VolName, refs.Len(), crc)
+	return
+}
+
+func (mp *metaPartition) storeMetaChangeLog(rootDir string, sm *storeMsg) (crc uint32, err error) {
+	filename := path.Join(rootDir, metaChangeLogFile)
+	fp, err := os.OpenFile(filename, os.O_RDWR|os.O_TRUNC|os.O_APPEND|os.
+		O_CREATE, 0o755)
+	if err != nil {
+		return
+	}
+	defer func() {
+		err = fp.Sync()
+		fp.Close()
+	}()
+
+	changeLog := sm.changeLog
+	if changeLog == nil {
+		changeLog = mp.changeLog.clone()
+	}
+	var data []byte
+	if data, crc, err = changeLog.Marshal(); err != nil {
+		return
+	}
+	if _, err = fp.Write(data); err != nil {
+		return
+	}
+	log.LogInfof("storeMetaChangeLog: store complete: partitionID(%v) volume(%v) changes(%v) crc(%v)",
+		mp.config.PartitionId, mp.config.VolName, changeLog.Len(), crc)
+	return
+}
+
 func (mp *metaPartition) storeUniqChecker(rootDir string, sm *storeMsg) (crc uint32, err error) {
 	filename := path.Join(rootDir, uniqCheckerFile)
 	fp, err := os.OpenFile(filename, os.O_RDWR|os.O_TRUNC|os.O_APPEND|os.
@@ -0,0 +1,286 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	deltaOpPut    byte = 1
+	deltaOpDelete byte = 2
+
+	// a snapshot is stored in full after the number of the delta snapshots, or once the items
+	// changed since the full one are more than 1/ratio of the items
+	deltaSnapshotMaxCount = 12
+	deltaSnapshotMaxRatio = 4
+)
+
+// enableDeltaSnapshot is whether the partitions store the inodes, dentries, extends and multiparts
+// changed since the last full snapshot instead of all of them, set by the config of the metanode.
+var enableDeltaSnapshot bool
+
+// deltaCodecs are the codecs of the trees stored as delta, in the order of their store functions.
+var deltaCodecs = [...]*treeCodec{inodeCodec, dentryCodec, extendCodec, multipartCodec}
+
+var deltaBaseFiles = [...]string{inodeFile, dentryFile, extendFile, multipartFile}
+
+type deltaItem struct {
+	item    BtreeItem // the key item if deleted
+	deleted bool
+}
+
+// snapshotDelta is the delta file loaded, the items of each tree by their keys.
+type snapshotDelta map[byte]map[string]deltaItem
+
+// replaced returns whether the item of the base is replaced or deleted by the delta.
+func (d snapshotDelta) replaced(c *treeCodec, i BtreeItem) bool {
+	if len(d) == 0 {
+		return false
+	}
+	_, ok := d[c.prefix][string(c.key(i))]
+	return ok
+}
+
+// puts calls fn on the items put by the delta.
+func (d snapshotDelta) puts(c *treeCodec, fn func(i BtreeItem) error) error {
+	for _, di := range d[c.prefix] {
+		if di.deleted {
+			continue
+		}
+		if err := fn(di.item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keys returns the keys changed by the delta.
+func (d snapshotDelta) keys(c *treeCodec) []BtreeItem {
+	items := d[c.prefix]
+	keys := make([]BtreeItem, 0, len(items))
+	for k := range items {
+		keys = append(keys, c.keyItem([]byte(k)))
+	}
+	return keys
+}
+
+func deltaTrees(sm *storeMsg) []Tree {
+	return []Tree{sm.inodeTree, sm.dentryTree, sm.extendTree, sm.multipartTree}
+}
+
+// trackTrees tracks the changed items of the trees kept in memory for the delta snapshots.
+func (mp *metaPartition) trackTrees(trees ...Tree) {
+	if !enableDeltaSnapshot {
+		return
+	}
+	for _, t := range trees {
+		if b, ok := t.(*BTree); ok && b.delta == nil {
+			b.delta = newTreeDelta(&mp.applying)
+		}
+	}
+}
+
+// deltaBase returns the crcs of the base files if the trees of the snapshot are stored as delta,
+// or nil if they are stored in full.
+func (mp *metaPartition) deltaBase(sm *storeMsg) []uint32 {
+	var changed, total int
+	for _, t := range deltaTrees(sm) {
+		b, ok := t.(*BTree)
+		if !ok || b.delta == nil || !b.delta.stored || b.delta.deltas >= deltaSnapshotMaxCount {
+			return nil
+		}
+		changed += b.delta.keys.Len()
+		total += b.Len()
+	}
+	if changed*deltaSnapshotMaxRatio > total {
+		return nil
+	}
+	crcs, err := mp.parseCrcFromFile()
+	if err != nil || len(crcs) < CRC_COUNT_BASIC {
+		log.LogWarnf("deltaBase: partitionID(%v) store in full as the crcs of the base are lost: %v",
+			mp.config.PartitionId, err)
+		return nil
+	}
+	return crcs[:CRC_COUNT_BASIC]
+}
+
+// linkBaseFile links the base file of the tree stored as delta into the snapshot.
+func (mp *metaPartition) linkBaseFile(rootDir string, idx int, crc uint32) (uint32, error) {
+	name := path.Join(rootDir, deltaBaseFiles[idx])
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	if err := os.Link(path.Join(mp.config.RootDir, snapshotDir, deltaBaseFiles[idx]), name); err != nil {
+		return 0, err
+	}
+	return crc, nil
+}
+
+// storedSnapshot updates the tracked trees after the snapshot is stored.
+func (mp *metaPartition) storedSnapshot(sm *storeMsg) {
+	for _, t := range deltaTrees(sm) {
+		if b, ok := t.(*BTree); ok && b.delta != nil {
+			b.delta.storedSnapshot(sm.deltaBase == nil)
+		}
+	}
+}
+
+// storedDeltaBase marks the trees just loaded as stored, the keys changed by the delta loaded are
+// kept to be stored by the next delta snapshots.
+func (mp *metaPartition) storedDeltaBase(delta snapshotDelta) {
+	for idx, t := range []Tree{mp.inodeTree, mp.dentryTree, mp.extendTree, mp.multipartTree} {
+		if b, ok := t.(*BTree); ok && b.delta != nil {
+			b.delta.storedBase(delta.keys(deltaCodecs[idx]))
+		}
+	}
+}
+
+// storeDelta stores the items changed since the base. Each record is the prefix of the tree, the
+// op, the key and the value of the item if it is put, the key and the value are led by their
+// lengths in uvarint.
+func (mp *metaPartition) storeDelta(rootDir string, sm *storeMsg) (crc uint32, err error) {
+	f, err := os.OpenFile(path.Join(rootDir, deltaFile), os.O_RDWR|os.O_TRUNC|os.O_APPEND|os.O_CREATE, 0o755)
+	if err != nil {
+		return
+	}
+	defer func() {
+		closeErr := f.Close()
+		if err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+	writer := bufio.NewWriterSize(f, 4*1024*1024)
+	sign := crc32.NewIEEE()
+	w := io.MultiWriter(writer, sign)
+	varintTmp := make([]byte, binary.MaxVarintLen64)
+	writeBytes := func(b []byte) (err error) {
+		n := binary.PutUvarint(varintTmp, uint64(len(b)))
+		if _, err = w.Write(varintTmp[:n]); err != nil {
+			return
+		}
+		_, err = w.Write(b)
+		return
+	}
+
+	var puts, deletes int
+	for idx, t := range deltaTrees(sm) {
+		tree := t.(*BTree)
+		codec := deltaCodecs[idx]
+		tree.delta.changed(func(key BtreeItem) bool {
+			item := tree.Get(key)
+			op := deltaOpDelete
+			if item != nil {
+				op = deltaOpPut
+			}
+			if _, err = w.Write([]byte{codec.prefix, op}); err != nil {
+				return false
+			}
+			if err = writeBytes(codec.key(key)); err != nil {
+				return false
+			}
+			if item == nil {
+				deletes++
+				return true
+			}
+			var value []byte
+			if value, err = codec.value(item); err != nil {
+				return false
+			}
+			if err = writeBytes(value); err != nil {
+				return false
+			}
+			puts++
+			return true
+		})
+		if err != nil {
+			return
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		return
+	}
+	if err = f.Sync(); err != nil {
+		return
+	}
+	crc = sign.Sum32()
+	log.LogInfof("storeDelta: store complete: partitionID(%v) volume(%v) puts(%v) deletes(%v) crc(%v)",
+		mp.config.PartitionId, mp.config.VolName, puts, deletes, crc)
+	return
+}
+
+func (mp *metaPartition) loadDelta(rootDir string, crc uint32) (delta snapshotDelta, err error) {
+	data, err := os.ReadFile(path.Join(rootDir, deltaFile))
+	if err != nil {
+		return
+	}
+	if res := crc32.ChecksumIEEE(data); res != crc {
+		log.LogErrorf("[loadDelta]: check crc mismatch, expected[%d], actual[%d]", crc, res)
+		return nil, ErrSnapshotCrcMismatch
+	}
+	codecs := make(map[byte]*treeCodec, len(deltaCodecs))
+	delta = make(snapshotDelta, len(deltaCodecs))
+	for _, c := range deltaCodecs {
+		codecs[c.prefix] = c
+		delta[c.prefix] = make(map[string]deltaItem)
+	}
+	readBytes := func() ([]byte, error) {
+		l, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < l {
+			return nil, fmt.Errorf("truncated record")
+		}
+		b := data[n : n+int(l)]
+		data = data[n+int(l):]
+		return b, nil
+	}
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, fmt.Errorf("[loadDelta] truncated record")
+		}
+		c, op := codecs[data[0]], data[1]
+		if c == nil || (op != deltaOpPut && op != deltaOpDelete) {
+			return nil, fmt.Errorf("[loadDelta] unknown record prefix(%v) op(%v)", data[0], op)
+		}
+		data = data[2:]
+		var k, v []byte
+		if k, err = readBytes(); err != nil {
+			return nil, fmt.Errorf("[loadDelta] %v", err)
+		}
+		di := deltaItem{deleted: op == deltaOpDelete}
+		if di.deleted {
+			di.item = c.keyItem(k)
+		} else {
+			if v, err = readBytes(); err != nil {
+				return nil, fmt.Errorf("[loadDelta] %v", err)
+			}
+			if di.item, err = c.decode(k, v); err != nil {
+				return nil, fmt.Errorf("[loadDelta] decode: %v", err)
+			}
+		}
+		delta[c.prefix][string(k)] = di
+	}
+	log.LogInfof("loadDelta: load complete: partitionID(%v) volume(%v) inodes(%v) dentries(%v) extends(%v) multiparts(%v)",
+		mp.config.PartitionId, mp.config.VolName, len(delta[rocksInodePrefix]), len(delta[rocksDentryPrefix]),
+		len(delta[rocksExtendPrefix]), len(delta[rocksMultipartPrefix]))
+	return
+}
@@ -0,0 +1,94 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/cubefs/cubefs/util/btree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeltaSnapshotStoreAndLoad(t *testing.T) {
+	enableDeltaSnapshot = true
+	defer func() {
+		enableDeltaSnapshot = false
+	}()
+	mp := newSplitTestPartition(t, 1, 1, 1000)
+	mp.trackTrees(mp.inodeTree, mp.dentryTree, mp.extendTree, mp.multipartTree)
+	addSplitTestInodes(mp, 1, 20)
+	require.NoError(t, mp.persistMetadata())
+	rootDir := mp.config.RootDir
+	snapshotPath := path.Join(rootDir, snapshotDir)
+
+	loadPartition := func() *metaPartition {
+		p := NewMetaPartition(&MetaPartitionConfig{RootDir: rootDir}, &metadataManager{}).(*metaPartition)
+		require.NoError(t, p.load(false))
+		return p
+	}
+	crcCount := func() int {
+		crcs, err := mp.parseCrcFromFile()
+		require.NoError(t, err)
+		return len(crcs)
+	}
+
+	// the first snapshot is stored in full
+	require.NoError(t, mp.storeMigratedSnapshot())
+	require.Equal(t, CRC_COUNT_CHANGE_LOG, crcCount())
+	_, err := os.Stat(path.Join(snapshotPath, deltaFile))
+	require.True(t, os.IsNotExist(err))
+	base, err := os.Stat(path.Join(snapshotPath, inodeFile))
+	require.NoError(t, err)
+
+	// the inodes changed in place by the raft logs are tracked
+	mp.applying = 1
+	mp.inodeTree.Get(NewInode(5, 0)).(*Inode).Size = 100
+	mp.applying = 0
+	mp.inodeTree.ReplaceOrInsert(NewInode(21, FileModeType), true)
+	mp.dentryTree.Delete(&Dentry{ParentId: 1, Name: "1"})
+	mp.extendTree.Delete(NewExtend(2))
+	require.NoError(t, mp.storeMigratedSnapshot())
+	require.Equal(t, CRC_COUNT_DELTA, crcCount())
+	linked, err := os.Stat(path.Join(snapshotPath, inodeFile))
+	require.NoError(t, err)
+	require.True(t, os.SameFile(base, linked))
+
+	check := func(p *metaPartition, lastIno uint64) {
+		require.Equal(t, int(lastIno), p.inodeTree.Len())
+		require.Equal(t, 19, p.dentryTree.Len())
+		require.Equal(t, 19, p.extendTree.Len())
+		require.Equal(t, uint64(100), p.inodeTree.Get(NewInode(5, 0)).(*Inode).Size)
+		require.Nil(t, p.dentryTree.Get(&Dentry{ParentId: 1, Name: "1"}))
+		require.Nil(t, p.extendTree.Get(NewExtend(2)))
+		require.Equal(t, lastIno, p.GetCursor())
+	}
+	mp = loadPartition()
+	check(mp, 21)
+
+	// the changes of the delta loaded are kept by the next delta
+	mp.inodeTree.ReplaceOrInsert(NewInode(22, FileModeType), true)
+	require.NoError(t, mp.storeMigratedSnapshot())
+	require.Equal(t, CRC_COUNT_DELTA, crcCount())
+	mp = loadPartition()
+	check(mp, 22)
+
+	// the unknown changes store the snapshot in full again
+	mp.inodeTree.(*BTree).Execute(func(tree *btree.BTree) interface{} { return nil })
+	require.NoError(t, mp.storeMigratedSnapshot())
+	require.Equal(t, CRC_COUNT_CHANGE_LOG, crcCount())
+	check(loadPartition(), 22)
+}
@@ -0,0 +1,317 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	rocksStoreDir             = "rocksdb"
+	rocksStoreLruCacheSize    = 64 * util.MB
+	rocksStoreWriteBufferSize = 32 * util.MB
+)
+
+// rocksCacheCount is the number of the items of each tree cached by the partitions kept in
+// RocksDB, set by the config of the metanode.
+var rocksCacheCount = defaultRocksCacheCount
+
+// openRocksTrees replaces the trees of the partition by the ones kept in RocksDB if the partition
+// is created to keep them in RocksDB.
+func (mp *metaPartition) openRocksTrees() (err error) {
+	if mp.config.StoreType != proto.MetaStoreTypeRocksDB || mp.rocksStore != nil {
+		return
+	}
+	store, err := openRocksStore(path.Join(mp.config.RootDir, rocksStoreDir))
+	if err != nil {
+		return fmt.Errorf("open rocksdb of partition(%v) failed: %v", mp.config.PartitionId, err)
+	}
+	var trees [4]*RocksTree
+	for i, codec := range []*treeCodec{inodeCodec, dentryCodec, extendCodec, multipartCodec} {
+		if trees[i], err = newRocksTree(store, codec, rocksCacheCount); err != nil {
+			store.close()
+			return fmt.Errorf("open rocksdb of partition(%v) failed: %v", mp.config.PartitionId, err)
+		}
+	}
+	mp.rocksStore = store
+	mp.inodeTree, mp.dentryTree, mp.extendTree, mp.multipartTree = trees[0], trees[1], trees[2], trees[3]
+	log.LogInfof("openRocksTrees: partition(%v) inodes(%v) dentries(%v) extends(%v) multiparts(%v)",
+		mp.config.PartitionId, trees[0].Len(), trees[1].Len(), trees[2].Len(), trees[3].Len())
+	return
+}
+
+func (mp *metaPartition) closeRocksStore() {
+	if mp.rocksStore != nil {
+		mp.rocksStore.close()
+	}
+}
+
+func (mp *metaPartition) rocksTrees() []*RocksTree {
+	var trees []*RocksTree
+	for _, t := range []Tree{mp.inodeTree, mp.dentryTree, mp.extendTree, mp.multipartTree} {
+		if rt, ok := t.(*RocksTree); ok {
+			trees = append(trees, rt)
+		}
+	}
+	return trees
+}
+
+// recoverRocksStore checks the trees kept in RocksDB are at the apply id of the snapshot. The trees
+// are flushed before the snapshot is renamed, so the snapshot left in the temporary dir by a stop
+// in between is renamed here.
+func (mp *metaPartition) recoverRocksStore() (err error) {
+	if mp.rocksStore == nil {
+		return
+	}
+	applyID, ok, err := mp.rocksStore.applyID()
+	if err != nil || !ok {
+		return
+	}
+	if applyID == rocksApplyIDInstalling {
+		return fmt.Errorf("partition(%v) is stopped when installing the snapshot of the leader, the rocksdb is incomplete",
+			mp.config.PartitionId)
+	}
+	snapshotPath := path.Join(mp.config.RootDir, snapshotDir)
+	if id, e := readSnapshotApplyID(snapshotPath); e == nil && id == applyID {
+		return
+	}
+	tmpDir := path.Join(mp.config.RootDir, snapshotDirTmp)
+	if _, e := os.Stat(path.Join(tmpDir, SnapshotSign)); e == nil {
+		if id, e := readSnapshotApplyID(tmpDir); e == nil && id == applyID {
+			if err = os.RemoveAll(snapshotPath); err != nil {
+				return
+			}
+			if err = os.Rename(tmpDir, snapshotPath); err != nil {
+				return
+			}
+			log.LogWarnf("recoverRocksStore: partition(%v) renamed the snapshot at apply id(%v)", mp.config.PartitionId, applyID)
+			return
+		}
+	}
+	return fmt.Errorf("partition(%v) rocksdb is at apply id(%v) but the snapshot is not", mp.config.PartitionId, applyID)
+}
+
+func readSnapshotApplyID(dir string) (applyID uint64, err error) {
+	data, err := os.ReadFile(path.Join(dir, applyIDFile))
+	if err != nil {
+		return
+	}
+	if i := strings.Index(string(data), "|"); i >= 0 {
+		data = data[:i]
+	}
+	_, err = fmt.Sscanf(string(data), "%d", &applyID)
+	return
+}
+
+// loadRocksSnapshot loads the snapshot of the partition kept in RocksDB. The snapshot files hold
+// the items only if they are stored by a partition in memory, such as the ones split or cloned
+// from another partition, then the items are moved into RocksDB. The statistics of the items
+// kept in RocksDB are rebuilt by scanning them.
+func (mp *metaPartition) loadRocksSnapshot(snapshotPath string) (err error) {
+	for _, t := range mp.rocksTrees() {
+		t.beginLoad()
+	}
+	err = mp.LoadSnapshot(snapshotPath)
+	loaded, loadErr := mp.endLoadRocksTrees()
+	if err != nil {
+		return
+	}
+	if loadErr != nil {
+		return loadErr
+	}
+	if !loaded[0] {
+		mp.inodeTree.Ascend(func(i BtreeItem) bool {
+			ino := i.(*Inode)
+			mp.acucumUidSizeByLoad(ino)
+			mp.size += ino.Size
+			if ino.NLink > 0 {
+				mp.updateUserQuotaUsedInfo(ino, int64(ino.Size), 1)
+			}
+			mp.checkAndInsertFreeList(ino)
+			if mp.config.Cursor < ino.Inode {
+				mp.config.Cursor = ino.Inode
+			}
+			return true
+		})
+	}
+	if !loaded[2] {
+		mp.extendTree.Ascend(func(i BtreeItem) bool {
+			mp.statisticExtendByLoad(i.(*Extend))
+			return true
+		})
+	}
+	log.LogInfof("loadRocksSnapshot: partition(%v) loaded(%v) inodes(%v) dentries(%v) extends(%v) multiparts(%v)",
+		mp.config.PartitionId, loaded, mp.inodeTree.Len(), mp.dentryTree.Len(), mp.extendTree.Len(), mp.multipartTree.Len())
+	return
+}
+
+// endLoadRocksTrees ends the load of the trees kept in RocksDB, loaded tells the inode, dentry,
+// extend and multipart trees replaced by the loaded items.
+func (mp *metaPartition) endLoadRocksTrees() (loaded [4]bool, err error) {
+	for i, t := range mp.rocksTrees() {
+		var e error
+		if loaded[i], e = t.endLoad(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+// beginInstallRocksTrees clears the trees kept in RocksDB to install the snapshot of the leader.
+// The partition refuses to start if it is stopped before the snapshot is stored.
+func (mp *metaPartition) beginInstallRocksTrees() (err error) {
+	if err = mp.rocksStore.setApplyID(rocksApplyIDInstalling); err != nil {
+		return
+	}
+	for _, t := range mp.rocksTrees() {
+		t.Reset()
+		t.beginLoad()
+	}
+	return
+}
+
+// flushRocksTrees writes the changes of the trees kept in RocksDB in the store message, together
+// with its apply id.
+func (mp *metaPartition) flushRocksTrees(sm *storeMsg) (trees []*RocksTree, err error) {
+	for _, t := range []Tree{sm.inodeTree, sm.dentryTree, sm.extendTree, sm.multipartTree} {
+		if rt, ok := t.(*RocksTree); ok {
+			trees = append(trees, rt)
+		}
+	}
+	if len(trees) == 0 {
+		return
+	}
+	deletes := make(map[string]util.Null)
+	puts := make(map[string][]byte)
+	for _, t := range trees {
+		if err = t.collect(deletes, puts); err != nil {
+			return nil, err
+		}
+	}
+	puts[string(rocksApplyIDKey)] = encodeRocksUint64(sm.applyIndex)
+	if err = trees[0].store.write(deletes, puts); err != nil {
+		return nil, err
+	}
+	log.LogInfof("flushRocksTrees: partition(%v) applyID(%v) puts(%v) deletes(%v)",
+		mp.config.PartitionId, sm.applyIndex, len(puts), len(deletes))
+	return
+}
+
+// ascendToStore iterates the items written to the snapshot files, the trees kept in RocksDB are
+// flushed instead.
+func ascendToStore(t Tree, fn func(i BtreeItem) bool) {
+	if !isRocksTree(t) {
+		t.Ascend(fn)
+	}
+}
+
+func storedLen(t Tree) int {
+	if isRocksTree(t) {
+		return 0
+	}
+	return t.Len()
+}
+
+// MigratePartitionStore moves the inodes, dentries, extends and multiparts of the stopped
+// partition in rootDir into memory or RocksDB.
+func MigratePartitionStore(rootDir string, storeType proto.MetaStoreType) (err error) {
+	mp := NewMetaPartition(&MetaPartitionConfig{RootDir: rootDir}, &metadataManager{}).(*metaPartition)
+	if err = mp.load(false); err != nil {
+		return
+	}
+	defer mp.closeRocksStore()
+	if mp.config.StoreType == storeType {
+		return fmt.Errorf("partition(%v) is already kept in %v", mp.config.PartitionId, storeType)
+	}
+
+	src := []Tree{mp.inodeTree, mp.dentryTree, mp.extendTree, mp.multipartTree}
+	switch storeType {
+	case proto.MetaStoreTypeRocksDB:
+		mp.config.StoreType = storeType
+		if err = mp.openRocksTrees(); err != nil {
+			return
+		}
+		for i, t := range mp.rocksTrees() {
+			t.Reset()
+			t.beginLoad()
+			src[i].Ascend(func(item BtreeItem) bool {
+				t.ReplaceOrInsert(item, true)
+				return true
+			})
+		}
+		if _, err = mp.endLoadRocksTrees(); err != nil {
+			return
+		}
+		// the snapshot files are loaded again if the migration stops before they are stored
+		if err = mp.persistMetadata(); err != nil {
+			return
+		}
+		if err = mp.storeMigratedSnapshot(); err != nil {
+			return
+		}
+	case proto.MetaStoreTypeMem:
+		dst := []*BTree{NewBtree(), NewBtree(), NewBtree(), NewBtree()}
+		for i, t := range src {
+			t.Ascend(func(item BtreeItem) bool {
+				dst[i].ReplaceOrInsert(item, true)
+				return true
+			})
+		}
+		mp.inodeTree, mp.dentryTree, mp.extendTree, mp.multipartTree = dst[0], dst[1], dst[2], dst[3]
+		if err = mp.storeMigratedSnapshot(); err != nil {
+			return
+		}
+		mp.config.StoreType = storeType
+		if err = mp.persistMetadata(); err != nil {
+			return
+		}
+		mp.closeRocksStore()
+		if err = os.RemoveAll(path.Join(rootDir, rocksStoreDir)); err != nil {
+			return
+		}
+	default:
+		return fmt.Errorf("unknown store type %v", storeType)
+	}
+	log.LogWarnf("MigratePartitionStore: partition(%v) is moved into %v, inodes(%v) dentries(%v) extends(%v) multiparts(%v)",
+		mp.config.PartitionId, storeType, mp.inodeTree.Len(), mp.dentryTree.Len(), mp.extendTree.Len(), mp.multipartTree.Len())
+	return
+}
+
+func (mp *metaPartition) storeMigratedSnapshot() error {
+	return mp.store(&storeMsg{
+		command:        opFSMStoreTick,
+		applyIndex:     mp.applyID,
+		txId:           mp.txProcessor.txManager.txIdAlloc.getTransactionID(),
+		inodeTree:      mp.inodeTree.GetTree(),
+		dentryTree:     mp.dentryTree.GetTree(),
+		extendTree:     mp.extendTree.GetTree(),
+		multipartTree:  mp.multipartTree.GetTree(),
+		txTree:         mp.txProcessor.txManager.txTree.GetTree(),
+		txRbInodeTree:  mp.txProcessor.txResource.txRbInodeTree.GetTree(),
+		txRbDentryTree: mp.txProcessor.txResource.txRbDentryTree.GetTree(),
+		uniqId:         mp.GetUniqId(),
+		uniqChecker:    mp.uniqChecker.clone(),
+		extentRefs:     mp.extentRefs.clone(),
+		changeLog:      mp.changeLog.clone(),
+		multiVerList:   mp.GetAllVerList(),
+	})
+}
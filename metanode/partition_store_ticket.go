@@ -27,21 +27,25 @@ import (
 )
 
 type storeMsg struct {
-	command        uint32
-	applyIndex     uint64
-	txId           uint64
-	inodeTree      *BTree
-	dentryTree     *BTree
-	extendTree     *BTree
-	multipartTree  *BTree
-	txTree         *BTree
-	txRbInodeTree  *BTree
-	txRbDentryTree *BTree
-	quotaRebuild   bool
-	uidRebuild     bool
-	uniqId         uint64
-	uniqChecker    *uniqChecker
-	multiVerList   []*proto.VolVersionInfo
+	command          uint32
+	applyIndex       uint64
+	txId             uint64
+	inodeTree        Tree
+	dentryTree       Tree
+	extendTree       Tree
+	multipartTree    Tree
+	txTree           Tree
+	txRbInodeTree    Tree
+	txRbDentryTree   Tree
+	quotaRebuild     bool
+	uidRebuild       bool
+	userQuotaRebuild bool
+	uniqId           uint64
+	uniqChecker      *uniqChecker
+	extentRefs       *extentRefs
+	changeLog        *metaChangeLog
+	multiVerList     []*proto.VolVersionInfo
+	deltaBase        []uint32 // the crcs of the base files if the trees are stored as delta
 }
 
 func (mp *metaPartition) startSchedule(curIndex uint64) {
@@ -60,7 +64,9 @@ func (mp *metaPartition) startSchedule(curIndex uint64) {
 				log.LogWarnf("[startSchedule] start trunc, partitionId=%d: nowAppID"+
 					"=%d, applyID=%d", mp.config.PartitionId, curIndex,
 					msg.applyIndex)
+				atomic.StoreUint64(&mp.truncatedIndex, curIndex)
 				mp.raftPartition.Truncate(curIndex)
+				mp.expireSnapSource()
 			} else {
 				// maybe happen when start load dentry
 				log.LogWarnf("[startSchedule] raftPartition is nil so skip" +
@@ -0,0 +1,134 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build rocksdb
+
+package metanode
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"github.com/cubefs/cubefs/raftstore/raftstore_db"
+	"github.com/cubefs/cubefs/util"
+	"github.com/tecbot/gorocksdb"
+)
+
+var errRocksStoreClosed = errors.New("rocksdb store is closed")
+
+type rocksSnapshot = gorocksdb.Snapshot
+
+// rocksStore is the RocksDB keeping the trees of a meta partition.
+type rocksStore struct {
+	sync.RWMutex
+	db     *raftstore_db.RocksDBStore
+	closed bool
+}
+
+func openRocksStore(dir string) (s *rocksStore, err error) {
+	db, err := raftstore_db.NewRocksDBStore(dir, rocksStoreLruCacheSize, rocksStoreWriteBufferSize)
+	if err != nil {
+		return
+	}
+	return &rocksStore{db: db}, nil
+}
+
+func (s *rocksStore) get(key []byte) (value []byte, err error) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.closed {
+		return nil, errRocksStoreClosed
+	}
+	v, err := s.db.Get(string(key))
+	if err != nil {
+		return
+	}
+	value, _ = v.([]byte)
+	return
+}
+
+// scan returns at most limit pairs in [start, end) of the snapshot, or of the latest data if the
+// snapshot is nil.
+func (s *rocksStore) scan(snap *rocksSnapshot, start, end []byte, limit int) (keys, values [][]byte, err error) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.closed {
+		return nil, nil, errRocksStoreClosed
+	}
+	if snap == nil {
+		snap = s.db.RocksDBSnapshot()
+		defer s.db.ReleaseSnapshot(snap)
+	}
+	it := s.db.Iterator(snap)
+	defer it.Close()
+	for it.Seek(start); it.Valid() && len(keys) < limit; it.Next() {
+		k, v := it.Key(), it.Value()
+		key := append([]byte(nil), k.Data()...)
+		value := append([]byte(nil), v.Data()...)
+		k.Free()
+		v.Free()
+		if bytes.Compare(key, end) >= 0 {
+			break
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	err = it.Err()
+	return
+}
+
+func (s *rocksStore) write(deletes map[string]util.Null, puts map[string][]byte) error {
+	s.RLock()
+	defer s.RUnlock()
+	if s.closed {
+		return errRocksStoreClosed
+	}
+	return s.db.BatchDeleteAndPut(deletes, puts, true)
+}
+
+func (s *rocksStore) deleteRange(start, end []byte) error {
+	s.RLock()
+	defer s.RUnlock()
+	if s.closed {
+		return errRocksStoreClosed
+	}
+	return s.db.DeleteRange(start, end, true)
+}
+
+func (s *rocksStore) snapshot() *rocksSnapshot {
+	s.RLock()
+	defer s.RUnlock()
+	if s.closed {
+		return nil
+	}
+	return s.db.RocksDBSnapshot()
+}
+
+func (s *rocksStore) releaseSnapshot(snap *rocksSnapshot) {
+	s.RLock()
+	defer s.RUnlock()
+	if !s.closed && snap != nil {
+		s.db.ReleaseSnapshot(snap)
+	}
+}
+
+func (s *rocksStore) close() {
+	s.Lock()
+	defer s.Unlock()
+	if !s.closed {
+		s.closed = true
+		s.db.Close()
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build !rocksdb
+
+package metanode
+
+import (
+	"errors"
+
+	"github.com/cubefs/cubefs/util"
+)
+
+// The metanode built without the rocksdb tag keeps the trees in memory only, the partitions
+// created to keep them in RocksDB fail to load.
+
+var errRocksStoreUnsupported = errors.New("rocksdb store is not supported, the metanode is built without the rocksdb tag")
+
+type rocksSnapshot struct{}
+
+type rocksStore struct{}
+
+func openRocksStore(dir string) (s *rocksStore, err error) {
+	return nil, errRocksStoreUnsupported
+}
+
+func (s *rocksStore) get(key []byte) (value []byte, err error) {
+	return nil, errRocksStoreUnsupported
+}
+
+func (s *rocksStore) scan(snap *rocksSnapshot, start, end []byte, limit int) (keys, values [][]byte, err error) {
+	return nil, nil, errRocksStoreUnsupported
+}
+
+func (s *rocksStore) write(deletes map[string]util.Null, puts map[string][]byte) error {
+	return errRocksStoreUnsupported
+}
+
+func (s *rocksStore) deleteRange(start, end []byte) error {
+	return errRocksStoreUnsupported
+}
+
+func (s *rocksStore) snapshot() *rocksSnapshot {
+	return nil
+}
+
+func (s *rocksStore) releaseSnapshot(snap *rocksSnapshot) {}
+
+func (s *rocksStore) close() {}
@@ -0,0 +1,680 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/btree"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// the key prefixes of the trees kept in RocksDB
+const (
+	rocksInodePrefix byte = iota + 1
+	rocksDentryPrefix
+	rocksExtendPrefix
+	rocksMultipartPrefix
+	rocksMetaPrefix
+)
+
+const (
+	rocksScanBatch = 1024
+	rocksLoadBatch = 4096
+
+	// rocksApplyIDInstalling is kept as the apply id while a snapshot of the leader is installed,
+	// the trees are incomplete until it is replaced.
+	rocksApplyIDInstalling = math.MaxUint64
+)
+
+var rocksApplyIDKey = []byte{rocksMetaPrefix, 'a'}
+
+// applyID returns the apply id the trees are flushed at, ok is false if they are never flushed.
+func (s *rocksStore) applyID() (applyID uint64, ok bool, err error) {
+	v, err := s.get(rocksApplyIDKey)
+	if err != nil || len(v) != 8 {
+		return
+	}
+	return binary.BigEndian.Uint64(v), true, nil
+}
+
+func (s *rocksStore) setApplyID(applyID uint64) error {
+	return s.write(nil, map[string][]byte{string(rocksApplyIDKey): encodeRocksUint64(applyID)})
+}
+
+func encodeRocksUint64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+// treeCodec encodes the items of a tree as RocksDB pairs, the keys are sorted in the order of the
+// items.
+type treeCodec struct {
+	prefix  byte
+	key     func(i BtreeItem) []byte
+	value   func(i BtreeItem) ([]byte, error)
+	decode  func(k, v []byte) (BtreeItem, error)
+	keyItem func(k []byte) BtreeItem // the item only having the key, for the lookups
+}
+
+func (c *treeCodec) storeKey(i BtreeItem) []byte {
+	return append([]byte{c.prefix}, c.key(i)...)
+}
+
+func (c *treeCodec) countKey() []byte {
+	return []byte{rocksMetaPrefix, 'c', c.prefix}
+}
+
+func (c *treeCodec) start() []byte {
+	return []byte{c.prefix}
+}
+
+func (c *treeCodec) end() []byte {
+	return []byte{c.prefix + 1}
+}
+
+var (
+	inodeCodec = &treeCodec{
+		prefix: rocksInodePrefix,
+		key:    func(i BtreeItem) []byte { return i.(*Inode).MarshalKey() },
+		value:  func(i BtreeItem) ([]byte, error) { return i.(*Inode).MarshalValue(), nil },
+		decode: func(k, v []byte) (BtreeItem, error) {
+			ino := NewInode(0, 0)
+			if err := ino.UnmarshalKey(k); err != nil {
+				return nil, err
+			}
+			if err := ino.UnmarshalValue(v); err != nil {
+				return nil, err
+			}
+			return ino, nil
+		},
+		keyItem: func(k []byte) BtreeItem { return NewInode(binary.BigEndian.Uint64(k), 0) },
+	}
+	dentryCodec = &treeCodec{
+		prefix: rocksDentryPrefix,
+		key:    func(i BtreeItem) []byte { return i.(*Dentry).MarshalKey() },
+		value:  func(i BtreeItem) ([]byte, error) { return i.(*Dentry).MarshalValue(), nil },
+		decode: func(k, v []byte) (BtreeItem, error) {
+			dentry := &Dentry{}
+			if err := dentry.UnmarshalKey(k); err != nil {
+				return nil, err
+			}
+			if err := dentry.UnmarshalValue(v); err != nil {
+				return nil, err
+			}
+			return dentry, nil
+		},
+		keyItem: func(k []byte) BtreeItem {
+			return &Dentry{ParentId: binary.BigEndian.Uint64(k), Name: string(k[8:])}
+		},
+	}
+	extendCodec = &treeCodec{
+		prefix:  rocksExtendPrefix,
+		key:     func(i BtreeItem) []byte { return encodeRocksUint64(i.(*Extend).inode) },
+		value:   func(i BtreeItem) ([]byte, error) { return i.(*Extend).Bytes() },
+		decode:  func(k, v []byte) (BtreeItem, error) { return NewExtendFromBytes(v) },
+		keyItem: func(k []byte) BtreeItem { return NewExtend(binary.BigEndian.Uint64(k)) },
+	}
+	multipartCodec = &treeCodec{
+		prefix: rocksMultipartPrefix,
+		key: func(i BtreeItem) []byte {
+			m := i.(*Multipart)
+			k := make([]byte, 0, len(m.key)+len(m.id)+1)
+			k = append(k, m.key...)
+			k = append(k, 0)
+			return append(k, m.id...)
+		},
+		value:  func(i BtreeItem) ([]byte, error) { return i.(*Multipart).Bytes() },
+		decode: func(k, v []byte) (BtreeItem, error) { return MultipartFromBytes(v), nil },
+		keyItem: func(k []byte) BtreeItem {
+			i := bytes.IndexByte(k, 0)
+			return &Multipart{key: string(k[:i]), id: string(k[i+1:])}
+		},
+	}
+)
+
+// treeEntry is an item cached by a RocksTree. The entries are replaced instead of changed, so they
+// are shared by the tree and its snapshots.
+type treeEntry struct {
+	item    BtreeItem
+	epoch   uint64 // the epoch the item is used in at last
+	owned   bool   // the item is not shared with the snapshots of the earlier epochs
+	deleted bool   // the item is deleted but not flushed yet
+}
+
+func (e *treeEntry) Less(than btree.Item) bool {
+	return e.item.Less(than.(*treeEntry).item)
+}
+
+func (e *treeEntry) Copy() btree.Item {
+	entry := *e
+	return &entry
+}
+
+// RocksTree keeps the items of a tree in RocksDB and caches the recently used ones in memory.
+//
+// The items used by an operation are cached in the current epoch, which is increased by every
+// snapshot of the tree. The snapshots taken for the store of the partition flush the items used
+// since the last flush, then the items used before the flushed epoch are clean and evicted from
+// the cache, the least recently used ones first. The items got by CopyGet are copied the first
+// time they are used in an epoch, so they are changed in place as the items of BTree while the
+// snapshots flush the earlier ones. The items got by Get and passed to the iterators are read only.
+type RocksTree struct {
+	sync.RWMutex
+	store    *rocksStore
+	codec    *treeCodec
+	cache    *btree.BTree
+	count    int
+	epoch    uint64
+	flushed  uint64
+	capacity int
+	live     *RocksTree     // the tree this one is the snapshot of
+	snap     *rocksSnapshot // the data of RocksDB at the time of the snapshot
+
+	// the items loaded from the snapshot files are written to RocksDB directly
+	loading bool
+	reset   bool
+	pending map[string]BtreeItem
+	loadErr error
+}
+
+func newRocksTree(store *rocksStore, codec *treeCodec, capacity int) (t *RocksTree, err error) {
+	t = &RocksTree{
+		store:    store,
+		codec:    codec,
+		cache:    btree.New(defaultBTreeDegree),
+		epoch:    1,
+		capacity: capacity,
+	}
+	v, err := store.get(codec.countKey())
+	if err != nil {
+		return nil, err
+	}
+	if len(v) == 8 {
+		t.count = int(binary.BigEndian.Uint64(v))
+	}
+	return
+}
+
+func (t *RocksTree) Get(key BtreeItem) BtreeItem {
+	t.RLock()
+	i := t.cache.Get(&treeEntry{item: key})
+	epoch := t.epoch
+	t.RUnlock()
+	if i != nil {
+		e := i.(*treeEntry)
+		if e.deleted {
+			return nil
+		}
+		if e.epoch == epoch {
+			return e.item
+		}
+	}
+	t.Lock()
+	defer t.Unlock()
+	return t.get(key, false)
+}
+
+func (t *RocksTree) CopyGet(key BtreeItem) BtreeItem {
+	t.Lock()
+	defer t.Unlock()
+	return t.get(key, true)
+}
+
+func (t *RocksTree) Find(key BtreeItem, fn func(i BtreeItem)) {
+	if item := t.Get(key); item != nil {
+		fn(item)
+	}
+}
+
+func (t *RocksTree) CopyFind(key BtreeItem, fn func(i BtreeItem)) {
+	t.Lock()
+	defer t.Unlock()
+	fn(t.get(key, true))
+}
+
+func (t *RocksTree) Has(key BtreeItem) bool {
+	return t.Get(key) != nil
+}
+
+func (t *RocksTree) Delete(key BtreeItem) BtreeItem {
+	t.Lock()
+	defer t.Unlock()
+	if t.loading {
+		return t.loadDelete(key)
+	}
+	item := t.get(key, false)
+	if item == nil {
+		return nil
+	}
+	t.cache.ReplaceOrInsert(&treeEntry{item: item, epoch: t.epoch, deleted: true})
+	t.count--
+	return item
+}
+
+func (t *RocksTree) ReplaceOrInsert(item BtreeItem, replace bool) (BtreeItem, bool) {
+	t.Lock()
+	defer t.Unlock()
+	if t.loading {
+		return t.loadInsert(item, replace)
+	}
+	old := t.get(item, false)
+	if old != nil && !replace {
+		return old, false
+	}
+	t.cache.ReplaceOrInsert(&treeEntry{item: item, epoch: t.epoch, owned: true})
+	if old == nil {
+		t.count++
+	}
+	return old, true
+}
+
+func (t *RocksTree) Ascend(fn func(i BtreeItem) bool) {
+	t.ascend(nil, nil, fn)
+}
+
+func (t *RocksTree) AscendRange(greaterOrEqual, lessThan BtreeItem, iterator func(i BtreeItem) bool) {
+	t.ascend(greaterOrEqual, lessThan, iterator)
+}
+
+func (t *RocksTree) AscendGreaterOrEqual(pivot BtreeItem, iterator func(i BtreeItem) bool) {
+	t.ascend(pivot, nil, iterator)
+}
+
+// GetTree returns a snapshot of the tree. The items not cached are read from RocksDB when they
+// are iterated, use snapshotTree for the items at the time of the snapshot.
+func (t *RocksTree) GetTree() Tree {
+	t.Lock()
+	defer t.Unlock()
+	return t.snapshot()
+}
+
+func (t *RocksTree) snapshot() *RocksTree {
+	s := &RocksTree{
+		store:    t.store,
+		codec:    t.codec,
+		cache:    t.cache.Clone(),
+		count:    t.count,
+		epoch:    t.epoch,
+		flushed:  t.flushed,
+		capacity: t.capacity,
+		live:     t,
+	}
+	if t.live != nil {
+		s.live = t.live
+	}
+	t.epoch++
+	return s
+}
+
+// Reset removes all the items of the tree.
+func (t *RocksTree) Reset() {
+	t.Lock()
+	defer t.Unlock()
+	t.cache = btree.New(defaultBTreeDegree)
+	t.count = 0
+	t.pending = nil
+	if err := t.store.deleteRange(t.codec.start(), t.codec.end()); err != nil {
+		log.LogErrorf("RocksTree: reset tree(%v) failed: %v", t.codec.prefix, err)
+	}
+}
+
+func (t *RocksTree) Len() int {
+	t.RLock()
+	defer t.RUnlock()
+	return t.count
+}
+
+// get returns the item of the key and uses it in the current epoch, the caller holds the lock.
+// The item to be changed is copied if it may be shared with the snapshots, as mutableFor of
+// util/btree does for the nodes.
+func (t *RocksTree) get(key BtreeItem, forUpdate bool) BtreeItem {
+	if t.loading {
+		return t.loadGet(key)
+	}
+	if i := t.cache.Get(&treeEntry{item: key}); i != nil {
+		e := i.(*treeEntry)
+		if e.deleted {
+			return nil
+		}
+		if e.epoch == t.epoch && (e.owned || !forUpdate) {
+			return e.item
+		}
+		entry := &treeEntry{item: e.item, epoch: t.epoch}
+		if forUpdate {
+			entry.item, entry.owned = e.item.Copy(), true
+		}
+		t.cache.ReplaceOrInsert(entry)
+		return entry.item
+	}
+	item := t.read(key)
+	if item != nil {
+		t.cache.ReplaceOrInsert(&treeEntry{item: item, epoch: t.epoch, owned: true})
+	}
+	return item
+}
+
+func (t *RocksTree) read(key BtreeItem) BtreeItem {
+	k := t.codec.storeKey(key)
+	v, err := t.store.get(k)
+	if err != nil {
+		log.LogErrorf("RocksTree: read key(%v) failed: %v", k, err)
+		return nil
+	}
+	if v == nil {
+		return nil
+	}
+	item, err := t.codec.decode(k[1:], v)
+	if err != nil {
+		log.LogErrorf("RocksTree: decode key(%v) failed: %v", k, err)
+		return nil
+	}
+	return item
+}
+
+// ascend iterates the items in [start, end) by merging the cache with RocksDB. The locks are not
+// held by the iterator, so it is free to change the tree.
+func (t *RocksTree) ascend(start, end BtreeItem, fn func(i BtreeItem) bool) {
+	t.Lock()
+	cache := t.cache.Clone()
+	t.Unlock()
+
+	it := &rocksTreeIterator{store: t.store, codec: t.codec, snap: t.snap, next: t.codec.start(), end: t.codec.end()}
+	if start != nil {
+		it.next = t.codec.storeKey(start)
+	}
+	if end != nil {
+		it.end = t.codec.storeKey(end)
+	}
+	keepOn := true
+	visit := func(i btree.Item) bool {
+		e := i.(*treeEntry)
+		key := t.codec.storeKey(e.item)
+		for ; it.valid() && bytes.Compare(it.key(), key) < 0; it.advance() {
+			if keepOn = fn(it.item()); !keepOn {
+				return false
+			}
+		}
+		if it.valid() && bytes.Equal(it.key(), key) {
+			it.advance()
+		}
+		if e.deleted {
+			return true
+		}
+		keepOn = fn(e.item)
+		return keepOn
+	}
+	switch {
+	case start == nil && end == nil:
+		cache.Ascend(visit)
+	case end == nil:
+		cache.AscendGreaterOrEqual(&treeEntry{item: start}, visit)
+	case start == nil:
+		cache.AscendLessThan(&treeEntry{item: end}, visit)
+	default:
+		cache.AscendRange(&treeEntry{item: start}, &treeEntry{item: end}, visit)
+	}
+	for ; keepOn && it.valid(); it.advance() {
+		keepOn = fn(it.item())
+	}
+}
+
+// collect adds the items of the snapshot changed since the last flush to the batch.
+func (t *RocksTree) collect(deletes map[string]util.Null, puts map[string][]byte) (err error) {
+	t.RLock()
+	defer t.RUnlock()
+	t.cache.Ascend(func(i btree.Item) bool {
+		e := i.(*treeEntry)
+		if e.epoch <= t.flushed {
+			return true
+		}
+		key := string(t.codec.storeKey(e.item))
+		if e.deleted {
+			deletes[key] = util.Null{}
+			return true
+		}
+		var value []byte
+		if value, err = t.codec.value(e.item); err != nil {
+			return false
+		}
+		puts[key] = value
+		return true
+	})
+	puts[string(t.codec.countKey())] = encodeRocksUint64(uint64(t.count))
+	return
+}
+
+// evict marks the items used before the epoch flushed and evicts the clean items out of the
+// capacity of the cache.
+func (t *RocksTree) evict(epoch uint64) {
+	t.Lock()
+	defer t.Unlock()
+	if epoch > t.flushed {
+		t.flushed = epoch
+	}
+	var clean, deleted []*treeEntry
+	t.cache.Ascend(func(i btree.Item) bool {
+		e := i.(*treeEntry)
+		if e.epoch > t.flushed {
+			return true
+		}
+		if e.deleted {
+			deleted = append(deleted, e)
+		} else {
+			clean = append(clean, e)
+		}
+		return true
+	})
+	for _, e := range deleted {
+		t.cache.Delete(e)
+	}
+	n := t.cache.Len() - t.capacity
+	if n <= 0 {
+		return
+	}
+	if n > len(clean) {
+		n = len(clean)
+	}
+	sort.SliceStable(clean, func(i, j int) bool { return clean[i].epoch < clean[j].epoch })
+	for _, e := range clean[:n] {
+		t.cache.Delete(e)
+	}
+}
+
+func (t *RocksTree) release() {
+	if t.snap != nil {
+		t.store.releaseSnapshot(t.snap)
+		t.snap = nil
+	}
+}
+
+// beginLoad starts to load the items from the snapshot files. The items in RocksDB are replaced
+// by the loaded ones if any item is loaded.
+func (t *RocksTree) beginLoad() {
+	t.Lock()
+	defer t.Unlock()
+	t.loading = true
+	t.reset = false
+	t.pending = make(map[string]BtreeItem)
+	t.loadErr = nil
+}
+
+// endLoad ends the load, loaded is true if the items in RocksDB are replaced.
+func (t *RocksTree) endLoad() (loaded bool, err error) {
+	t.Lock()
+	defer t.Unlock()
+	t.writePending()
+	loaded, err = t.reset, t.loadErr
+	t.loading = false
+	t.reset = false
+	t.pending = nil
+	t.loadErr = nil
+	if loaded && err == nil {
+		err = t.store.write(nil, map[string][]byte{string(t.codec.countKey()): encodeRocksUint64(uint64(t.count))})
+	}
+	return
+}
+
+func (t *RocksTree) loadGet(key BtreeItem) BtreeItem {
+	if !t.reset {
+		return nil
+	}
+	if item, ok := t.pending[string(t.codec.storeKey(key))]; ok {
+		return item
+	}
+	return t.read(key)
+}
+
+func (t *RocksTree) loadInsert(item BtreeItem, replace bool) (BtreeItem, bool) {
+	if !t.reset {
+		if err := t.store.deleteRange(t.codec.start(), t.codec.end()); err != nil {
+			t.loadErr = err
+		}
+		t.cache = btree.New(defaultBTreeDegree)
+		t.count = 0
+		t.reset = true
+	}
+	var old BtreeItem
+	if !replace {
+		if old = t.loadGet(item); old != nil {
+			return old, false
+		}
+	}
+	t.pending[string(t.codec.storeKey(item))] = item
+	if old == nil {
+		t.count++
+	}
+	if len(t.pending) >= rocksLoadBatch {
+		t.writePending()
+	}
+	return old, true
+}
+
+func (t *RocksTree) loadDelete(key BtreeItem) BtreeItem {
+	item := t.loadGet(key)
+	if item == nil {
+		return nil
+	}
+	k := string(t.codec.storeKey(key))
+	delete(t.pending, k)
+	if err := t.store.write(map[string]util.Null{k: {}}, nil); err != nil {
+		t.loadErr = err
+	}
+	t.count--
+	return item
+}
+
+func (t *RocksTree) writePending() {
+	if len(t.pending) == 0 {
+		return
+	}
+	puts := make(map[string][]byte, len(t.pending))
+	for k, item := range t.pending {
+		value, err := t.codec.value(item)
+		if err != nil {
+			t.loadErr = err
+			return
+		}
+		puts[k] = value
+	}
+	if err := t.store.write(nil, puts); err != nil {
+		t.loadErr = err
+		return
+	}
+	t.pending = make(map[string]BtreeItem)
+}
+
+// rocksTreeIterator reads the items of a tree from RocksDB batch by batch.
+type rocksTreeIterator struct {
+	store *rocksStore
+	codec *treeCodec
+	snap  *rocksSnapshot
+	next  []byte
+	end   []byte
+	keys  [][]byte
+	items []BtreeItem
+	pos   int
+	done  bool
+}
+
+func (it *rocksTreeIterator) valid() bool {
+	if it.pos < len(it.keys) {
+		return true
+	}
+	for !it.done && it.pos >= len(it.keys) {
+		it.fill()
+	}
+	return it.pos < len(it.keys)
+}
+
+func (it *rocksTreeIterator) key() []byte {
+	return it.keys[it.pos]
+}
+
+func (it *rocksTreeIterator) item() BtreeItem {
+	return it.items[it.pos]
+}
+
+func (it *rocksTreeIterator) advance() {
+	it.pos++
+}
+
+func (it *rocksTreeIterator) fill() {
+	keys, values, err := it.store.scan(it.snap, it.next, it.end, rocksScanBatch)
+	if err != nil {
+		log.LogErrorf("RocksTree: scan tree(%v) from key(%v) failed: %v", it.codec.prefix, it.next, err)
+	}
+	if err != nil || len(keys) < rocksScanBatch {
+		it.done = true
+	}
+	it.keys, it.items, it.pos = it.keys[:0], it.items[:0], 0
+	for i, k := range keys {
+		item, err := it.codec.decode(k[1:], values[i])
+		if err != nil {
+			log.LogErrorf("RocksTree: decode key(%v) failed: %v", k, err)
+			continue
+		}
+		it.keys = append(it.keys, k)
+		it.items = append(it.items, item)
+	}
+	if len(keys) > 0 {
+		it.next = append(append([]byte(nil), keys[len(keys)-1]...), 0)
+	}
+}
+
+// snapshotTree returns a snapshot of the tree with all the items at the time of the snapshot,
+// release is called when it is used up.
+func snapshotTree(t Tree) (s Tree, release func()) {
+	rt, ok := t.(*RocksTree)
+	if !ok {
+		return t.GetTree(), func() {}
+	}
+	rt.Lock()
+	view := rt.snapshot()
+	view.snap = rt.store.snapshot()
+	rt.Unlock()
+	return view, view.release
+}
+
+func isRocksTree(t Tree) bool {
+	_, ok := t.(*RocksTree)
+	return ok
+}
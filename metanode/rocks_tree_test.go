@@ -0,0 +1,177 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build rocksdb
+
+package metanode
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/stretchr/testify/require"
+)
+
+func ascendRocksTestInodes(tree Tree, start, end BtreeItem) (inos []uint64) {
+	tree.AscendRange(start, end, func(i BtreeItem) bool {
+		inos = append(inos, i.(*Inode).Inode)
+		return true
+	})
+	return
+}
+
+func TestRocksTreeFlushAndEvict(t *testing.T) {
+	store, err := openRocksStore(path.Join(t.TempDir(), rocksStoreDir))
+	require.NoError(t, err)
+	defer store.close()
+	tree, err := newRocksTree(store, inodeCodec, 2)
+	require.NoError(t, err)
+
+	for ino := uint64(1); ino <= 10; ino++ {
+		_, ok := tree.ReplaceOrInsert(NewInode(ino, FileModeType), false)
+		require.True(t, ok)
+	}
+	_, ok := tree.ReplaceOrInsert(NewInode(1, FileModeType), false)
+	require.False(t, ok)
+	require.NotNil(t, tree.Delete(NewInode(3, 0)))
+	require.Nil(t, tree.Get(NewInode(3, 0)))
+	require.Equal(t, 9, tree.Len())
+
+	// the changes are flushed and the clean items are evicted
+	snap := tree.GetTree().(*RocksTree)
+	deletes, puts := make(map[string]util.Null), make(map[string][]byte)
+	require.NoError(t, snap.collect(deletes, puts))
+	require.Len(t, deletes, 1)
+	require.NoError(t, store.write(deletes, puts))
+	snap.live.evict(snap.epoch)
+	require.Equal(t, 2, tree.cache.Len())
+
+	// the items changed after the snapshot are kept until the next flush
+	tree.Get(NewInode(5, 0)).(*Inode).Size = 100
+	tree.ReplaceOrInsert(NewInode(11, FileModeType), true)
+	tree.Delete(NewInode(4, 0))
+	snap.live.evict(snap.epoch)
+	require.Equal(t, []uint64{1, 2, 5, 6, 7, 8, 9, 10, 11}, ascendRocksTestInodes(tree, nil, nil))
+	require.Equal(t, []uint64{5, 6}, ascendRocksTestInodes(tree, NewInode(4, 0), NewInode(7, 0)))
+	require.Equal(t, []uint64{1, 2, 4, 5, 6, 7, 8, 9, 10}, ascendRocksTestInodes(snap, nil, nil))
+
+	snap = tree.GetTree().(*RocksTree)
+	deletes, puts = make(map[string]util.Null), make(map[string][]byte)
+	require.NoError(t, snap.collect(deletes, puts))
+	require.NoError(t, store.write(deletes, puts))
+	snap.live.evict(snap.epoch)
+
+	reopened, err := newRocksTree(store, inodeCodec, 2)
+	require.NoError(t, err)
+	require.Equal(t, 9, reopened.Len())
+	require.Equal(t, uint64(100), reopened.Get(NewInode(5, 0)).(*Inode).Size)
+	require.Nil(t, reopened.Get(NewInode(4, 0)))
+}
+
+func TestRocksTreeChangeDuringFlush(t *testing.T) {
+	store, err := openRocksStore(path.Join(t.TempDir(), rocksStoreDir))
+	require.NoError(t, err)
+	defer store.close()
+	tree, err := newRocksTree(store, inodeCodec, 2)
+	require.NoError(t, err)
+	mp := &metaPartition{config: &MetaPartitionConfig{PartitionId: 1}}
+	flush := func(applyID uint64) {
+		snap := tree.GetTree().(*RocksTree)
+		_, err := mp.flushRocksTrees(&storeMsg{applyIndex: applyID, inodeTree: snap})
+		require.NoError(t, err)
+		snap.live.evict(snap.epoch)
+	}
+	// storedSizes returns the sizes of the inodes in RocksDB and the apply id they are flushed at
+	storedSizes := func() (sizes []uint64, applyID uint64) {
+		stored, err := newRocksTree(store, inodeCodec, 2)
+		require.NoError(t, err)
+		for ino := uint64(1); ino <= 2; ino++ {
+			sizes = append(sizes, stored.Get(NewInode(ino, 0)).(*Inode).Size)
+		}
+		applyID, ok, err := store.applyID()
+		require.NoError(t, err)
+		require.True(t, ok)
+		return
+	}
+
+	tree.ReplaceOrInsert(NewInode(1, FileModeType), true)
+	tree.ReplaceOrInsert(NewInode(2, FileModeType), true)
+	flush(1)
+	tree.CopyGet(NewInode(1, 0)).(*Inode).Size = 2
+	tree.CopyFind(NewInode(2, 0), func(i BtreeItem) { i.(*Inode).Size = 2 })
+
+	// the items changed after the snapshot are not flushed with it
+	snap := tree.GetTree().(*RocksTree)
+	tree.CopyGet(NewInode(1, 0)).(*Inode).Size = 3
+	tree.CopyFind(NewInode(2, 0), func(i BtreeItem) { i.(*Inode).Size = 3 })
+	_, err = mp.flushRocksTrees(&storeMsg{applyIndex: 2, inodeTree: snap})
+	require.NoError(t, err)
+	snap.live.evict(snap.epoch)
+	sizes, applyID := storedSizes()
+	require.Equal(t, []uint64{2, 2}, sizes)
+	require.Equal(t, uint64(2), applyID)
+	require.Equal(t, uint64(3), tree.Get(NewInode(1, 0)).(*Inode).Size)
+
+	flush(3)
+	sizes, applyID = storedSizes()
+	require.Equal(t, []uint64{3, 3}, sizes)
+	require.Equal(t, uint64(3), applyID)
+}
+
+func TestMigratePartitionStore(t *testing.T) {
+	mp := newSplitTestPartition(t, 1, 1, 1000)
+	addSplitTestInodes(mp, 1, 10)
+	require.NoError(t, mp.persistMetadata())
+	require.NoError(t, mp.storeMigratedSnapshot())
+	rootDir := mp.config.RootDir
+
+	loadPartition := func() *metaPartition {
+		p := NewMetaPartition(&MetaPartitionConfig{RootDir: rootDir}, &metadataManager{}).(*metaPartition)
+		require.NoError(t, p.load(false))
+		return p
+	}
+
+	require.NoError(t, MigratePartitionStore(rootDir, proto.MetaStoreTypeRocksDB))
+	require.Error(t, MigratePartitionStore(rootDir, proto.MetaStoreTypeRocksDB))
+	mp = loadPartition()
+	require.Equal(t, proto.MetaStoreTypeRocksDB, mp.config.StoreType)
+	require.True(t, isRocksTree(mp.inodeTree))
+	require.Equal(t, 10, mp.inodeTree.Len())
+	require.Equal(t, 10, mp.dentryTree.Len())
+	require.Equal(t, uint64(10), mp.GetCursor())
+	require.NotNil(t, mp.extendTree.Get(NewExtend(5)))
+
+	// the items changed in RocksDB are kept by the store of the partition
+	mp.inodeTree.ReplaceOrInsert(NewInode(11, FileModeType), true)
+	mp.dentryTree.Delete(&Dentry{ParentId: 1, Name: "1"})
+	require.NoError(t, mp.storeMigratedSnapshot())
+	mp.closeRocksStore()
+	mp = loadPartition()
+	require.Equal(t, 11, mp.inodeTree.Len())
+	require.Equal(t, 9, mp.dentryTree.Len())
+	require.Equal(t, uint64(11), mp.GetCursor())
+	mp.closeRocksStore()
+
+	require.NoError(t, MigratePartitionStore(rootDir, proto.MetaStoreTypeMem))
+	_, err := os.Stat(path.Join(rootDir, rocksStoreDir))
+	require.True(t, os.IsNotExist(err))
+	mp = loadPartition()
+	require.Equal(t, proto.MetaStoreTypeMem, mp.config.StoreType)
+	require.False(t, isRocksTree(mp.inodeTree))
+	require.Equal(t, 11, mp.inodeTree.Len())
+	require.Equal(t, 9, mp.dentryTree.Len())
+}
@@ -501,6 +501,113 @@ func (se *SortedExtents) Truncate(offset uint64, doOnLastKey func(*proto.ExtentK
 	return
 }
 
+// Punch removes the range [offset, offset+size) from the extents, the keys across the boundaries
+// are cut and the pieces of the same extent are recorded by insertRefMap, so that only the removed
+// range of the extent is released.
+func (se *SortedExtents) Punch(offset, size uint64, insertRefMap func(ek *proto.ExtentKey)) (deleteExtents []proto.ExtentKey) {
+	end := offset + size
+
+	se.Lock()
+	defer se.Unlock()
+
+	eks := make([]proto.ExtentKey, 0, len(se.eks)+1)
+	for _, key := range se.eks {
+		keyEnd := key.FileOffset + uint64(key.Size)
+		if keyEnd <= offset || key.FileOffset >= end {
+			eks = append(eks, key)
+			continue
+		}
+		if key.FileOffset >= offset && keyEnd <= end {
+			deleteExtents = append(deleteExtents, key)
+			continue
+		}
+
+		pieces := make([]proto.ExtentKey, 0, 3)
+		if key.FileOffset < offset {
+			head := key
+			head.Size = uint32(offset - key.FileOffset)
+			pieces = append(pieces, head)
+		}
+		delStart, delEnd := offset, end
+		if key.FileOffset > delStart {
+			delStart = key.FileOffset
+		}
+		if keyEnd < delEnd {
+			delEnd = keyEnd
+		}
+		delKey := key
+		delKey.FileOffset = delStart
+		delKey.ExtentOffset = key.ExtentOffset + delStart - key.FileOffset
+		delKey.Size = uint32(delEnd - delStart)
+		pieces = append(pieces, delKey)
+		if keyEnd > end {
+			tail := key
+			tail.FileOffset = end
+			tail.ExtentOffset = key.ExtentOffset + end - key.FileOffset
+			tail.Size = uint32(keyEnd - end)
+			pieces = append(pieces, tail)
+		}
+		for i := range pieces {
+			if key.SnapInfo != nil {
+				info := *key.SnapInfo
+				pieces[i].SnapInfo = &info
+			}
+			// the key already counted in the ref map is replaced by its first piece
+			if insertRefMap != nil && (i > 0 || !key.IsSplit()) {
+				insertRefMap(&pieces[i])
+			}
+			if pieces[i].FileOffset == delStart {
+				deleteExtents = append(deleteExtents, pieces[i])
+			} else {
+				eks = append(eks, pieces[i])
+			}
+		}
+	}
+	se.eks = eks
+	log.LogDebugf("SortedExtents.Punch offset %v size %v deleteExtents %v", offset, size, deleteExtents)
+	return
+}
+
+// Slice returns the extents of the range [offset, offset+size), the keys across the boundaries are cut.
+func (se *SortedExtents) Slice(offset, size uint64) (eks []proto.ExtentKey) {
+	end := offset + size
+
+	se.RLock()
+	defer se.RUnlock()
+
+	for _, key := range se.eks {
+		keyEnd := key.FileOffset + uint64(key.Size)
+		if keyEnd <= offset || key.FileOffset >= end {
+			continue
+		}
+		if key.FileOffset < offset {
+			key.ExtentOffset += offset - key.FileOffset
+			key.Size -= uint32(offset - key.FileOffset)
+			key.FileOffset = offset
+		}
+		if keyEnd > end {
+			key.Size -= uint32(keyEnd - end)
+		}
+		eks = append(eks, key)
+	}
+	return
+}
+
+// Insert inserts the key into a hole of the extents.
+func (se *SortedExtents) Insert(ek proto.ExtentKey) {
+	se.Lock()
+	defer se.Unlock()
+
+	idx := len(se.eks)
+	for i, key := range se.eks {
+		if key.FileOffset > ek.FileOffset {
+			idx = i
+			break
+		}
+	}
+	se.insert(ek, idx)
+}
+
 func (se *SortedExtents) insert(ek proto.ExtentKey, startIdx int) {
 	se.eks = append(se.eks, ek)
 	size := len(se.eks)
@@ -137,3 +137,71 @@ func TestSortedMarshal(t *testing.T) {
 		}
 	}
 }
+
+func TestPunch(t *testing.T) {
+	se := NewSortedExtents()
+	se.AppendWithCheck(0, proto.ExtentKey{FileOffset: 0, Size: 1000, ExtentId: 1}, nil, nil)
+	se.AppendWithCheck(0, proto.ExtentKey{FileOffset: 1000, Size: 1000, ExtentId: 2}, nil, nil)
+	se.AppendWithCheck(0, proto.ExtentKey{FileOffset: 2000, Size: 1000, ExtentId: 3}, nil, nil)
+	var refs int
+	delExtents := se.Punch(500, 2000, func(ek *proto.ExtentKey) { refs++ })
+	t.Logf("\ndel: %v\neks: %v", delExtents, se.eks)
+	if len(delExtents) != 3 || delExtents[1].ExtentId != 2 || delExtents[1].Size != 1000 {
+		t.Fatalf("unexpected deleted extents %v", delExtents)
+	}
+	if len(se.eks) != 2 || se.eks[0].Size != 500 || se.eks[1].FileOffset != 2500 ||
+		se.eks[1].ExtentOffset != 500 || se.eks[1].Size != 500 {
+		t.Fatalf("unexpected extents %v", se.eks)
+	}
+	// the head and the tail remain with the deleted pieces of the split extents
+	if refs != 4 || se.Size() != 3000 {
+		t.Fatalf("refs %v size %v", refs, se.Size())
+	}
+}
+
+func TestSliceAndInsert(t *testing.T) {
+	se := NewSortedExtents()
+	se.AppendWithCheck(0, proto.ExtentKey{FileOffset: 0, Size: 1000, ExtentId: 1}, nil, nil)
+	se.AppendWithCheck(0, proto.ExtentKey{FileOffset: 1000, Size: 1000, ExtentId: 2}, nil, nil)
+	eks := se.Slice(500, 1000)
+	t.Logf("\nslice: %v", eks)
+	if len(eks) != 2 || eks[0].FileOffset != 500 || eks[0].ExtentOffset != 500 || eks[0].Size != 500 ||
+		eks[1].FileOffset != 1000 || eks[1].Size != 500 {
+		t.Fatalf("unexpected slice %v", eks)
+	}
+
+	se.Punch(500, 1000, nil)
+	for _, ek := range eks {
+		ek.ExtentId += 10
+		se.Insert(ek)
+	}
+	t.Logf("\neks: %v", se.eks)
+	if len(se.eks) != 4 || se.eks[1].ExtentId != 11 || se.eks[2].ExtentId != 12 || se.Size() != 2000 {
+		t.Fatalf("unexpected extents %v", se.eks)
+	}
+}
+
+func TestExtentRefs(t *testing.T) {
+	refs := newExtentRefs()
+	ek := &proto.ExtentKey{PartitionId: 1, ExtentId: 1025}
+	if refs.release(ek) {
+		t.Fatalf("extent %v is not shared", ek)
+	}
+	refs.share(ek)
+	refs.share(ek)
+	if refs.count(ek) != 3 {
+		t.Fatalf("count %v", refs.count(ek))
+	}
+
+	buf, crc, err := refs.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := newExtentRefs()
+	if err = loaded.UnMarshal(buf); err != nil || crc == 0 || loaded.count(ek) != 3 {
+		t.Fatalf("unmarshal count %v err %v", loaded.count(ek), err)
+	}
+	if !loaded.release(ek) || !loaded.release(ek) || loaded.isShared(ek) || loaded.Len() != 0 {
+		t.Fatalf("extent %v should be released", ek)
+	}
+}
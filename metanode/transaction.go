@@ -1371,6 +1371,9 @@ func (tr *TransactionResource) rollbackInodeInternal(rbInode *TxRollbackInode) (
 			if mp.uidManager != nil {
 				mp.uidManager.addUidSpace(rbInode.inode.Uid, rbInode.inode.Inode, rbInode.inode.Extents.eks)
 			}
+			if rbInode.inode.NLink > 0 {
+				mp.updateUserQuotaUsedInfo(rbInode.inode, int64(rbInode.inode.Size), 1)
+			}
 			if mp.mqMgr != nil && len(rbInode.quotaIds) > 0 && item == nil {
 				mp.setInodeQuota(rbInode.quotaIds, rbInode.inode.Inode)
 				for _, quotaId := range rbInode.quotaIds {
@@ -0,0 +1,78 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/cubefs/cubefs/metanode"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/spf13/cobra"
+)
+
+const partitionPrefix = "partition_"
+
+func newMigrateCmd() *cobra.Command {
+	var (
+		optType    string
+		optMetaDir string
+	)
+	c := &cobra.Command{
+		Use:   "migrate [PARTITION DIR]...",
+		Short: "Move the items of the meta partitions between memory and RocksDB, the metanode must be stopped",
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			var storeType proto.MetaStoreType
+			if storeType, err = proto.ParseMetaStoreType(optType); err != nil {
+				return
+			}
+			dirs := args
+			if optMetaDir != "" {
+				var entries []os.DirEntry
+				if entries, err = os.ReadDir(optMetaDir); err != nil {
+					return
+				}
+				for _, entry := range entries {
+					if entry.IsDir() && strings.HasPrefix(entry.Name(), partitionPrefix) {
+						dirs = append(dirs, path.Join(optMetaDir, entry.Name()))
+					}
+				}
+			}
+			if len(dirs) == 0 {
+				return fmt.Errorf("no partition to migrate")
+			}
+
+			var failed int
+			for _, dir := range dirs {
+				if err = metanode.MigratePartitionStore(dir, storeType); err != nil {
+					failed++
+					fmt.Fprintf(os.Stdout, "%v: %v\n", dir, err)
+					continue
+				}
+				fmt.Fprintf(os.Stdout, "%v: migrated to %v\n", dir, storeType)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%v of %v partitions are not migrated", failed, len(dirs))
+			}
+			return nil
+		},
+	}
+	c.Flags().StringVarP(&optType, "type", "t", "", "the store to move the items into, mem or rocksdb")
+	c.Flags().StringVarP(&optMetaDir, "metadataDir", "d", "", "migrate all the partitions in the metadataDir of the metanode")
+	_ = c.MarkFlagRequired("type")
+	return c
+}
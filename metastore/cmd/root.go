@@ -0,0 +1,47 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/spf13/cobra"
+)
+
+func NewRootCmd() *cobra.Command {
+	var optShowVersion bool
+	c := &cobra.Command{
+		Use:   path.Base(os.Args[0]),
+		Short: "CubeFS meta partition store tool",
+		Args:  cobra.MinimumNArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			if optShowVersion {
+				_, _ = fmt.Fprintf(os.Stdout, proto.DumpVersion("METASTORE"))
+				return
+			}
+		},
+	}
+
+	c.AddCommand(
+		newMigrateCmd(),
+	)
+
+	c.Flags().BoolVarP(&optShowVersion, "version", "v", false, "Show version information")
+
+	return c
+}
@@ -116,6 +116,19 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 			GetRequestID(r), acl, err)
 		return
 	}
+	// Check server-side encryption, all the parts are encrypted with the data key of the upload
+	var sseRequest *SSERequest
+	if sseRequest, err = ParseSSERequest(r.Header); err != nil {
+		log.LogErrorf("createMultipleUploadHandler: parse encryption headers fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	var encryption *ObjectEncryption
+	if encryption, err = o.newObjectEncryption(sseRequest); err != nil {
+		log.LogErrorf("createMultipleUploadHandler: new object encryption fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
 	opt := &PutFileOption{
 		MIMEType:     contentType,
 		Disposition:  contentDisposition,
@@ -124,6 +137,7 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 		CacheControl: cacheControl,
 		Expires:      expires,
 		ACL:          acl,
+		Encryption:   encryption,
 	}
 
 	var uploadID string
@@ -145,6 +159,7 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
+	setSSEResponseHeaders(w, encryption)
 	writeSuccessResponseXML(w, response)
 	return
 }
@@ -243,6 +258,27 @@ func (o *ObjectNode) uploadPartHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
 
+	// The part is encrypted with the data key of the upload, the key of the customer
+	// is required to unseal the data key if the upload is encrypted with SSE-C.
+	var encryption *ObjectEncryption
+	if encryption, err = vol.multipartEncryption(param.Object(), uploadId); err != nil {
+		log.LogErrorf("uploadPartHandler: load multipart encryption fail: requestID(%v) volume(%v) path(%v) uploadId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), uploadId, err)
+		err = handleWritePartErr(err)
+		return
+	}
+	var sseRequest *SSERequest
+	if sseRequest, err = ParseSSERequest(r.Header); err != nil {
+		log.LogErrorf("uploadPartHandler: parse encryption headers fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	if err = o.openObjectEncryption(encryption, sseRequest); err != nil {
+		log.LogErrorf("uploadPartHandler: open multipart encryption fail: requestID(%v) volume(%v) path(%v) uploadId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), uploadId, err)
+		return
+	}
+
 	// Flow Control
 	var reader io.Reader
 	if length > DefaultFlowLimitSize {
@@ -253,7 +289,7 @@ func (o *ObjectNode) uploadPartHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Write Part
 	start := time.Now()
-	fsFileInfo, err := vol.WritePart(param.Object(), uploadId, partNumberInt, reader)
+	fsFileInfo, err := vol.WritePart(param.Object(), uploadId, partNumberInt, reader, encryption)
 	span.AppendTrackLog("part.w", start, err)
 	if err != nil {
 		log.LogErrorf("uploadPartHandler: write part fail: requestID(%v) volume(%v) path(%v) uploadId(%v) part(%v) err(%v)",
@@ -272,6 +308,7 @@ func (o *ObjectNode) uploadPartHandler(w http.ResponseWriter, r *http.Request) {
 
 	// write header to response
 	w.Header()[ETag] = []string{"\"" + fsFileInfo.ETag + "\""}
+	setSSEResponseHeaders(w, encryption)
 	return
 }
 
@@ -364,6 +401,35 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// the encrypted source is decrypted, and the part is encrypted with the data key of the upload
+	var sourceRequest, sseRequest *SSERequest
+	if sourceRequest, err = ParseCopySourceSSERequest(r.Header); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: parse copy source encryption headers fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+	if err = o.openObjectEncryption(srcFileInfo.Encryption, sourceRequest); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: open source encryption fail: requestID(%v) srcVol(%v) path(%v) err(%v)",
+			GetRequestID(r), srcBucket, srcObject, err)
+		return
+	}
+	var encryption *ObjectEncryption
+	if encryption, err = vol.multipartEncryption(param.Object(), uploadId); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: load multipart encryption fail: requestID(%v) volume(%v) path(%v) uploadId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), uploadId, err)
+		err = handleWritePartErr(err)
+		return
+	}
+	if sseRequest, err = ParseSSERequest(r.Header); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: parse encryption headers fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		return
+	}
+	if err = o.openObjectEncryption(encryption, sseRequest); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: open multipart encryption fail: requestID(%v) volume(%v) path(%v) uploadId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), uploadId, err)
+		return
+	}
+
 	// step4: extract range params
 	copyRange := r.Header.Get(XAmzCopySourceRange)
 	firstByte, copyLength, errorCode := determineCopyRange(copyRange, srcFileInfo.Size)
@@ -384,7 +450,11 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 	}
 	reader, writer := io.Pipe()
 	go func() {
-		err = srcVol.readFile(srcFileInfo.Inode, size, srcObject, writer, fb, cl)
+		if srcFileInfo.Encryption != nil {
+			err = srcVol.readEncryptedFile(srcFileInfo, srcObject, writer, fb, cl)
+		} else {
+			err = srcVol.readFile(srcFileInfo.Inode, size, srcFileInfo.StorageClass, srcObject, writer, fb, cl)
+		}
 		if err != nil {
 			log.LogErrorf("uploadPartCopyHandler: read srcObj err(%v): requestId(%v) srcVol(%v) path(%v)",
 				err, GetRequestID(r), srcBucket, srcObject)
@@ -400,7 +470,7 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 		rd = reader
 	}
 	start = time.Now()
-	fsFileInfo, err := vol.WritePart(param.Object(), uploadId, partNumberInt, rd)
+	fsFileInfo, err := vol.WritePart(param.Object(), uploadId, partNumberInt, rd, encryption)
 	span.AppendTrackLog("part.w", start, err)
 	if err != nil {
 		log.LogErrorf("uploadPartCopyHandler: write part fail: requestID(%v) volume(%v) path(%v) uploadId(%v) part(%v) err(%v)",
@@ -421,6 +491,7 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 
 	Etag := "\"" + fsFileInfo.ETag + "\""
 	w.Header()[ETag] = []string{Etag}
+	setSSEResponseHeaders(w, encryption)
 	response := NewS3CopyPartResult(Etag, fsFileInfo.CreateTime.UTC().Format(time.RFC3339)).String()
 
 	writeSuccessResponseXML(w, []byte(response))
@@ -784,7 +855,14 @@ func (o *ObjectNode) completeMultipartUploadHandler(w http.ResponseWriter, r *ht
 			GetRequestID(r), completeResult, ierr)
 	}
 
+	setVersionIdHeader(w, vol, fsFileInfo.VersionId)
+	// the object is encrypted as requested at the initiation of the upload
+	encryption, _ := parseObjectEncryption(multipartInfo.Extend)
+	setSSEResponseHeaders(w, encryption)
 	writeSuccessResponseXML(w, response)
+
+	o.notifyObjectEvent(r, vol, &ObjectEvent{Name: EventObjectCreatedCompleteMultipartUpload, Key: param.Object(),
+		Size: fsFileInfo.Size, ETag: fsFileInfo.ETag, VersionId: fsFileInfo.VersionId})
 	return
 }
 
@@ -155,14 +155,11 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// get object meta
 	start := time.Now()
-	fileInfo, xattr, err := vol.ObjectMeta(param.Object())
+	fileInfo, xattr, errorCode, err := getObjectVersionMeta(w, r, vol, param.Object())
 	span.AppendTrackLog("meta.r", start, err)
-	if err != nil {
-		log.LogErrorf("getObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) err(%v)",
-			GetRequestID(r), vol.Name(), param.Object(), err)
-		if err == syscall.ENOENT {
-			errorCode = NoSuchKey
-		}
+	if err != nil || errorCode != nil {
+		log.LogErrorf("getObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) errorCode(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), errorCode, err)
 		return
 	}
 
@@ -172,6 +169,19 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the data key of an encrypted object is unsealed with the key of the request
+	var sseRequest *SSERequest
+	if sseRequest, err = ParseSSERequest(r.Header); err != nil {
+		log.LogErrorf("getObjectHandler: parse encryption headers fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	if err = o.openObjectEncryption(fileInfo.Encryption, sseRequest); err != nil {
+		log.LogErrorf("getObjectHandler: open object encryption fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+
 	// validate and fix range
 	if isRangeRead && rangeUpper > uint64(fileInfo.Size)-1 {
 		rangeUpper = uint64(fileInfo.Size) - 1
@@ -222,6 +232,10 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set(XAmzObjectLockMode, ComplianceMode)
 		w.Header().Set(XAmzObjectLockRetainUntilDate, fileInfo.RetainUntilDate)
 	}
+	if isTransitioned(fileInfo.StorageClass) {
+		w.Header().Set(XAmzStorageClass, fileInfo.StorageClass)
+	}
+	setSSEResponseHeaders(w, fileInfo.Encryption)
 
 	// check request is whether contain param : partNumber
 	partNumber := r.URL.Query().Get(ParamPartNumber)
@@ -290,7 +304,11 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// read file
 	start = time.Now()
-	err = vol.readFile(fileInfo.Inode, fileSize, param.Object(), writer, offset, size)
+	if fileInfo.Encryption != nil {
+		err = vol.readEncryptedFile(fileInfo, param.Object(), writer, offset, size)
+	} else {
+		err = vol.readFile(fileInfo.Inode, fileSize, fileInfo.StorageClass, param.Object(), writer, offset, size)
+	}
 	span.AppendTrackLog("file.r", start, err)
 	if err != nil {
 		log.LogErrorf("getObjectHandler: read file fail: requestID(%v) volume(%v) path(%v) offset(%v) size(%v) err(%v)",
@@ -400,14 +418,24 @@ func (o *ObjectNode) headObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// get object meta
 	start := time.Now()
-	fileInfo, _, err := vol.ObjectMeta(param.Object())
+	fileInfo, _, errorCode, err := getObjectVersionMeta(w, r, vol, param.Object())
 	span.AppendTrackLog("meta.r", start, err)
-	if err != nil {
-		log.LogErrorf("headObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) err(%v)",
+	if err != nil || errorCode != nil {
+		log.LogErrorf("headObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) errorCode(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), errorCode, err)
+		return
+	}
+
+	// the key of the customer is required to read the metadata of the object encrypted with SSE-C
+	var sseRequest *SSERequest
+	if sseRequest, err = ParseSSERequest(r.Header); err != nil {
+		log.LogErrorf("headObjectHandler: parse encryption headers fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	if err = checkObjectEncryption(fileInfo.Encryption, sseRequest); err != nil {
+		log.LogErrorf("headObjectHandler: check object encryption fail: requestID(%v) volume(%v) path(%v) err(%v)",
 			GetRequestID(r), vol.Name(), param.Object(), err)
-		if err == syscall.ENOENT {
-			errorCode = NoSuchKey
-		}
 		return
 	}
 
@@ -492,6 +520,10 @@ func (o *ObjectNode) headObjectHandler(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set(XAmzObjectLockMode, ComplianceMode)
 		w.Header().Set(XAmzObjectLockRetainUntilDate, fileInfo.RetainUntilDate)
 	}
+	if isTransitioned(fileInfo.StorageClass) {
+		w.Header().Set(XAmzStorageClass, fileInfo.StorageClass)
+	}
+	setSSEResponseHeaders(w, fileInfo.Encryption)
 
 	// check request is whether contain param : partNumber
 	partNumber := r.URL.Query().Get(ParamPartNumber)
@@ -658,16 +690,28 @@ func (o *ObjectNode) deleteObjectsHandler(w http.ResponseWriter, r *http.Request
 		if err = rateLimit.AcquireLimitResource(vol.owner, DELETE_OBJECT); err != nil {
 			return
 		}
-		if err1 := vol.DeletePath(object.Key); err1 != nil {
-			log.LogErrorf("deleteObjectsHandler: delete object failed: requestID(%v) volume(%v) path(%v) err(%v)",
-				GetRequestID(r), vol.Name(), object.Key, err1)
+		if object.VersionId != "" && !isValidVersionId(object.VersionId) {
+			deletedErrors = append(deletedErrors, Error{Key: object.Key, Code: InvalidVersionId.ErrorCode,
+				Message: InvalidVersionId.ErrorMessage})
+		} else if versionId, isDeleteMarker, err1 := vol.DeleteObjectVersion(object.Key, object.VersionId); err1 != nil {
+			log.LogErrorf("deleteObjectsHandler: delete object failed: requestID(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+				GetRequestID(r), vol.Name(), object.Key, object.VersionId, err1)
 			if !strings.Contains(err1.Error(), AccessDenied.ErrorMessage) {
 				deletedErrors = append(deletedErrors, Error{Key: object.Key, Code: "InternalError", Message: err1.Error()})
 			} else {
 				deletedErrors = append(deletedErrors, Error{Key: object.Key, Code: "AccessDenied", Message: err1.Error()})
 			}
 		} else {
-			deletedObjects = append(deletedObjects, Deleted{Key: object.Key})
+			deleted := Deleted{Key: object.Key, VersionId: object.VersionId}
+			if isDeleteMarker {
+				deleted.DeleteMarker = "true"
+				if object.VersionId == "" {
+					deleted.DeleteMarkerVersionId = versionId
+				}
+			}
+			deletedObjects = append(deletedObjects, deleted)
+			o.notifyObjectEvent(r, vol, &ObjectEvent{Name: removedEventName(object.VersionId, isDeleteMarker),
+				Key: object.Key, VersionId: versionId})
 		}
 		rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
 	}
@@ -779,12 +823,16 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// parse x-amz-copy-source header
-	sourceBucket, sourceObject, _, err := extractSrcBucketKey(r)
+	sourceBucket, sourceObject, sourceVersionId, err := extractSrcBucketKey(r)
 	if err != nil {
 		log.LogErrorf("copyObjectHandler: copySource(%v) argument invalid: requestID(%v) volume(%v) err(%v)",
 			r.Header.Get(XAmzCopySource), GetRequestID(r), param.Bucket(), err)
 		return
 	}
+	if sourceVersionId != "" && !isValidVersionId(sourceVersionId) {
+		errorCode = InvalidVersionId
+		return
+	}
 
 	// check ACL
 	userInfo, err := o.getUserInfoByAccessKeyV2(param.AccessKey())
@@ -810,16 +858,28 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// get object meta
 	start := time.Now()
-	fileInfo, _, err := sourceVol.ObjectMeta(sourceObject)
+	var fileInfo *FSFileInfo
+	if sourceVersionId != "" {
+		fileInfo, _, err = sourceVol.ObjectVersionMeta(sourceObject, sourceVersionId)
+	} else {
+		fileInfo, _, err = sourceVol.ObjectMeta(sourceObject)
+	}
 	span.AppendTrackLog("meta.r", start, err)
 	if err != nil {
-		log.LogErrorf("copyObjectHandler: get object meta fail: requestID(%v) srcVolume(%v) srcObject(%v) err(%v)",
-			GetRequestID(r), sourceBucket, sourceObject, err)
+		log.LogErrorf("copyObjectHandler: get object meta fail: requestID(%v) srcVolume(%v) srcObject(%v) srcVersion(%v) err(%v)",
+			GetRequestID(r), sourceBucket, sourceObject, sourceVersionId, err)
 		if err == syscall.ENOENT {
 			errorCode = NoSuchKey
+			if sourceVersionId != "" {
+				errorCode = NoSuchVersion
+			}
 		}
 		return
 	}
+	if fileInfo.IsDeleteMarker {
+		errorCode = CopySourceDeleteMarker
+		return
+	}
 	if fileInfo.Size > SinglePutLimit {
 		errorCode = EntityTooLarge
 		return
@@ -874,6 +934,11 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		errorCode = PreconditionFailed
 		return
 	}
+	if err = checkCopyEncryption(r.Header, fileInfo.Encryption); err != nil {
+		log.LogErrorf("copyObjectHandler: check copy encryption fail: requestID(%v) srcVolume(%v) srcObject(%v) err(%v)",
+			GetRequestID(r), sourceBucket, sourceObject, err)
+		return
+	}
 
 	// ObjectLock  Config
 	objetLock, err := vol.metaLoader.loadObjectLock()
@@ -897,7 +962,7 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		ObjectLock:   objetLock,
 	}
 	start = time.Now()
-	fsFileInfo, err := vol.CopyFile(sourceVol, sourceObject, param.Object(), metadataDirective, opt)
+	fsFileInfo, err := vol.CopyFile(sourceVol, sourceObject, sourceVersionId, param.Object(), metadataDirective, opt)
 	span.AppendTrackLog("file.c", start, err)
 	if err != nil && err != syscall.EINVAL && err != syscall.EFBIG {
 		log.LogErrorf("copyObjectHandler: Volume copy file fail: requestID(%v) Volume(%v) source(%v) target(%v) err(%v)",
@@ -917,6 +982,17 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if sourceVersionId != "" {
+		w.Header().Set(XAmzCopySourceVersionId, sourceVersionId)
+	} else if fileInfo.VersionId != "" {
+		w.Header().Set(XAmzCopySourceVersionId, fileInfo.VersionId)
+	}
+	setVersionIdHeader(w, vol, fsFileInfo.VersionId)
+	setSSEResponseHeaders(w, fileInfo.Encryption)
+
+	o.notifyObjectEvent(r, vol, &ObjectEvent{Name: EventObjectCreatedCopy, Key: param.Object(),
+		Size: fsFileInfo.Size, ETag: fsFileInfo.ETag, VersionId: fsFileInfo.VersionId})
+
 	copyResult := CopyResult{
 		ETag:         "\"" + fsFileInfo.ETag + "\"",
 		LastModified: formatTimeISO(fsFileInfo.ModifyTime),
@@ -1035,7 +1111,7 @@ func (o *ObjectNode) getBucketV1Handler(w http.ResponseWriter, r *http.Request)
 			LastModified: formatTimeISO(file.ModifyTime),
 			ETag:         wrapUnescapedQuot(file.ETag),
 			Size:         int(file.Size),
-			StorageClass: StorageClassStandard,
+			StorageClass: file.storageClass(),
 			Owner:        bucketOwner,
 		}
 		contents = append(contents, content)
@@ -1202,7 +1278,7 @@ func (o *ObjectNode) getBucketV2Handler(w http.ResponseWriter, r *http.Request)
 				LastModified: formatTimeISO(file.ModifyTime),
 				ETag:         wrapUnescapedQuot(file.ETag),
 				Size:         int(file.Size),
-				StorageClass: StorageClassStandard,
+				StorageClass: file.storageClass(),
 				Owner:        bucketOwner,
 			}
 			contents = append(contents, content)
@@ -1333,6 +1409,20 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check server-side encryption
+	var sseRequest *SSERequest
+	if sseRequest, err = ParseSSERequest(r.Header); err != nil {
+		log.LogErrorf("putObjectHandler: parse encryption headers fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	var encryption *ObjectEncryption
+	if encryption, err = o.newObjectEncryption(sseRequest); err != nil {
+		log.LogErrorf("putObjectHandler: new object encryption fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+
 	// Verify ContentLength
 	length := GetContentLength(r)
 	if length > SinglePutLimit {
@@ -1386,6 +1476,7 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 		Expires:      expires,
 		ACL:          acl,
 		ObjectLock:   objetLock,
+		Encryption:   encryption,
 	}
 	start := time.Now()
 	fsFileInfo, err := vol.PutObject(param.Object(), reader, opt)
@@ -1407,6 +1498,11 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// set response header
 	w.Header()[ETag] = []string{wrapUnescapedQuot(fsFileInfo.ETag)}
+	setVersionIdHeader(w, vol, fsFileInfo.VersionId)
+	setSSEResponseHeaders(w, encryption)
+
+	o.notifyObjectEvent(r, vol, &ObjectEvent{Name: EventObjectCreatedPut, Key: param.Object(),
+		Size: fsFileInfo.Size, ETag: fsFileInfo.ETag, VersionId: fsFileInfo.VersionId})
 	return
 }
 
@@ -1637,6 +1733,10 @@ func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
 	// set response header
 	etag := wrapUnescapedQuot(fsFileInfo.ETag)
 	w.Header()[ETag] = []string{etag}
+	setVersionIdHeader(w, vol, fsFileInfo.VersionId)
+
+	o.notifyObjectEvent(r, vol, &ObjectEvent{Name: EventObjectCreatedPost, Key: key,
+		Size: fsFileInfo.Size, ETag: fsFileInfo.ETag, VersionId: fsFileInfo.VersionId})
 
 	// return response depending on success_action_xxx parameter
 	if successRedirectURL != nil {
@@ -1714,23 +1814,38 @@ func (o *ObjectNode) deleteObjectHandler(w http.ResponseWriter, r *http.Request)
 	}
 	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
 
+	versionId := r.URL.Query().Get(ParamVersionId)
+	if versionId != "" && !isValidVersionId(versionId) {
+		errorCode = InvalidVersionId
+		return
+	}
+
 	// Audit deletion
-	log.LogInfof("Audit: delete object: requestID(%v) remote(%v) volume(%v) path(%v)",
-		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object())
+	log.LogInfof("Audit: delete object: requestID(%v) remote(%v) volume(%v) path(%v) versionId(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object(), versionId)
 
 	// Delete file
 	start := time.Now()
-	err = vol.DeletePath(param.Object())
+	deletedVersionId, isDeleteMarker, err := vol.DeleteObjectVersion(param.Object(), versionId)
 	span.AppendTrackLog("file.d", start, err)
 	if err != nil {
 		log.LogErrorf("deleteObjectHandler: Volume delete file fail: "+
-			"requestID(%v) volume(%v) path(%v) err(%v)", GetRequestID(r), vol.Name(), param.Object(), err)
+			"requestID(%v) volume(%v) path(%v) versionId(%v) err(%v)", GetRequestID(r), vol.Name(), param.Object(), versionId, err)
 		if strings.Contains(err.Error(), AccessDenied.ErrorMessage) {
 			err = AccessDenied
 		}
 		return
 	}
 
+	if deletedVersionId != "" {
+		w.Header().Set(XAmzVersionId, deletedVersionId)
+	}
+	if isDeleteMarker {
+		w.Header().Set(XAmzDeleteMarker, "true")
+	}
+
+	o.notifyObjectEvent(r, vol, &ObjectEvent{Name: removedEventName(versionId, isDeleteMarker),
+		Key: param.Object(), VersionId: deletedVersionId})
 	w.WriteHeader(http.StatusNoContent)
 	return
 }
@@ -14,7 +14,11 @@
 
 package objectnode
 
-import "os"
+import (
+	"os"
+
+	"github.com/cubefs/cubefs/proto"
+)
 
 const (
 	MaxRetry = 3
@@ -85,6 +89,17 @@ const (
 	XAmzSecurityToken               = "X-Amz-Security-Token" // #nosec G101
 	XAmzObjectLockMode              = "X-Amz-Object-Lock-Mode"
 	XAmzObjectLockRetainUntilDate   = "X-Amz-Object-Lock-Retain-Until-Date"
+	XAmzVersionId                   = "x-amz-version-id"
+	XAmzDeleteMarker                = "x-amz-delete-marker"
+	XAmzCopySourceVersionId         = "x-amz-copy-source-version-id"
+
+	XAmzServerSideEncryption                            = "x-amz-server-side-encryption"
+	XAmzServerSideEncryptionCustomerAlgorithm           = "x-amz-server-side-encryption-customer-algorithm"
+	XAmzServerSideEncryptionCustomerKey                 = "x-amz-server-side-encryption-customer-key"
+	XAmzServerSideEncryptionCustomerKeyMD5              = "x-amz-server-side-encryption-customer-key-MD5"
+	XAmzCopySourceServerSideEncryptionCustomerAlgorithm = "x-amz-copy-source-server-side-encryption-customer-algorithm"
+	XAmzCopySourceServerSideEncryptionCustomerKey       = "x-amz-copy-source-server-side-encryption-customer-key"
+	XAmzCopySourceServerSideEncryptionCustomerKeyMD5    = "x-amz-copy-source-server-side-encryption-customer-key-MD5"
 
 	HeaderNameXAmzDecodedContentLength = "x-amz-decoded-content-length"
 )
@@ -116,6 +131,9 @@ const (
 	ParamStartAfter = "start-after"
 	ParamKey        = "key"
 
+	ParamVersionId       = "versionId"
+	ParamVersionIdMarker = "version-id-marker"
+
 	ParamMaxParts       = "max-parts"
 	ParamUploadIdMarker = "upload-id-marker"
 	ParamPartNoMarker   = "part-number-marker"
@@ -154,6 +172,16 @@ const (
 	XAttrKeyOSSLock         = "oss:lock"
 	XAttrKeyOSSCacheControl = "oss:cache"
 	XAttrKeyOSSExpires      = "oss:expires"
+	XAttrKeyOSSVersioning   = "oss:versioning"
+	XAttrKeyOSSVersionId    = "oss:version"
+	XAttrKeyOSSDeleteMarker = "oss:deletemarker"
+	XAttrKeyOSSNotification = "oss:notification"
+	XAttrKeyOSSStorageClass = proto.XAttrKeyStorageClass
+	XAttrKeyOSSSSE          = "oss:sse"
+	XAttrKeyOSSSSEKey       = "oss:sse-key"
+	XAttrKeyOSSSSEKeyID     = "oss:sse-key-id"
+	XAttrKeyOSSSSEKeyMD5    = "oss:sse-key-md5"
+	XAttrKeyOSSSSEParts     = "oss:sse-parts"
 
 	// Deprecated
 	XAttrKeyOSSETagDeprecated = "oss:tag"
@@ -0,0 +1,421 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/cubefs/cubefs/blobstore/util/retry"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	eventVersion = "2.1"
+	eventSource  = "cubefs:s3"
+
+	defaultEventQueueLimit    = 100000
+	defaultEventRetryInterval = 3 * time.Second
+)
+
+var EventWebhookUserAgent = "Golang cubefs/objectnode event webhook"
+
+type EventNotifierConfig struct {
+	// Undelivered events are kept in the sub directory of each target under the queue directory.
+	QueueDir string `json:"queue_dir"`
+	// The max number of undelivered events of each target, new events are dropped if it is exceeded.
+	QueueLimit int `json:"queue_limit"`
+	// The key of map is the ID of target, which is a part of the ARN used in bucket configurations.
+	Kafka   map[string]KafkaEventConfig   `json:"kafka,omitempty"`
+	Webhook map[string]WebhookEventConfig `json:"webhook,omitempty"`
+}
+
+type KafkaEventConfig struct {
+	Enable bool `json:"enable"`
+
+	KafkaConfig
+}
+
+type WebhookEventConfig struct {
+	Enable bool `json:"enable"`
+
+	WebhookConfig
+}
+
+// EventTarget is the destination of the bucket event notifications.
+type EventTarget interface {
+	ARN() string
+	Send(data []byte) error
+	Close() error
+}
+
+type KafkaEventTarget struct {
+	arn      string
+	producer sarama.SyncProducer
+
+	KafkaEventConfig
+}
+
+func NewKafkaEventTarget(id string, conf KafkaEventConfig) (*KafkaEventTarget, error) {
+	if err := conf.FixConfig(); err != nil {
+		return nil, err
+	}
+	producer, err := conf.BuildSyncProducer()
+	if err != nil {
+		return nil, err
+	}
+	return &KafkaEventTarget{
+		arn:              eventTargetARN(id, EventTargetKafka),
+		producer:         producer,
+		KafkaEventConfig: conf,
+	}, nil
+}
+
+func (k *KafkaEventTarget) ARN() string {
+	return k.arn
+}
+
+func (k *KafkaEventTarget) Send(data []byte) error {
+	_, _, err := k.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: k.Topic,
+		Value: sarama.ByteEncoder(data),
+	})
+	return err
+}
+
+func (k *KafkaEventTarget) Close() error {
+	return k.producer.Close()
+}
+
+type WebhookEventTarget struct {
+	arn    string
+	client *http.Client
+
+	WebhookEventConfig
+}
+
+func NewWebhookEventTarget(id string, conf WebhookEventConfig) (*WebhookEventTarget, error) {
+	if err := conf.FixConfig(); err != nil {
+		return nil, err
+	}
+	client, err := conf.BuildClient()
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookEventTarget{
+		arn:                eventTargetARN(id, EventTargetWebhook),
+		client:             client,
+		WebhookEventConfig: conf,
+	}, nil
+}
+
+func (w *WebhookEventTarget) ARN() string {
+	return w.arn
+}
+
+func (w *WebhookEventTarget) Send(data []byte) error {
+	return w.Post(w.client, EventWebhookUserAgent, data)
+}
+
+func (w *WebhookEventTarget) Close() error {
+	return nil
+}
+
+// EventMessage is the message delivered to targets, it is compatible with the S3 event message structure.
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/notification-content-structure.html
+type EventMessage struct {
+	EventName string         `json:"EventName"`
+	Key       string         `json:"Key"`
+	Records   []*EventRecord `json:"Records"`
+}
+
+type EventRecord struct {
+	EventVersion      string            `json:"eventVersion"`
+	EventSource       string            `json:"eventSource"`
+	AwsRegion         string            `json:"awsRegion"`
+	EventTime         string            `json:"eventTime"`
+	EventName         string            `json:"eventName"`
+	UserIdentity      EventIdentity     `json:"userIdentity"`
+	RequestParameters map[string]string `json:"requestParameters"`
+	ResponseElements  map[string]string `json:"responseElements"`
+	S3                EventS3           `json:"s3"`
+}
+
+type EventIdentity struct {
+	PrincipalId string `json:"principalId"`
+}
+
+type EventS3 struct {
+	SchemaVersion   string      `json:"s3SchemaVersion"`
+	ConfigurationId string      `json:"configurationId"`
+	Bucket          EventBucket `json:"bucket"`
+	Object          EventObject `json:"object"`
+}
+
+type EventBucket struct {
+	Name          string        `json:"name"`
+	OwnerIdentity EventIdentity `json:"ownerIdentity"`
+	ARN           string        `json:"arn"`
+}
+
+type EventObject struct {
+	Key       string `json:"key"`
+	Size      int64  `json:"size,omitempty"`
+	ETag      string `json:"eTag,omitempty"`
+	VersionId string `json:"versionId,omitempty"`
+	Sequencer string `json:"sequencer"`
+}
+
+// ObjectEvent describes an object operation which has been done.
+type ObjectEvent struct {
+	Name      string
+	Key       string
+	Size      int64
+	ETag      string
+	VersionId string
+}
+
+// EventNotifier delivers the events through the queue of each target asynchronously,
+// the event is kept in the queue and retried until it is sent successfully.
+type EventNotifier struct {
+	workers map[string]*eventWorker
+	stopC   chan struct{}
+	wg      sync.WaitGroup
+}
+
+type eventWorker struct {
+	target        EventTarget
+	queue         *eventQueue
+	retryInterval time.Duration
+}
+
+func NewEventNotifier(conf EventNotifierConfig) (*EventNotifier, error) {
+	if conf.QueueDir == "" {
+		return nil, errors.New("notification: no queue_dir found")
+	}
+	if conf.QueueLimit <= 0 {
+		conf.QueueLimit = defaultEventQueueLimit
+	}
+
+	var targets []EventTarget
+	closeTargets := func() {
+		for _, target := range targets {
+			target.Close()
+		}
+	}
+	for id, cfg := range conf.Kafka {
+		if cfg.Enable {
+			target, err := NewKafkaEventTarget(id, cfg)
+			if err != nil {
+				closeTargets()
+				return nil, err
+			}
+			targets = append(targets, target)
+		}
+	}
+	for id, cfg := range conf.Webhook {
+		if cfg.Enable {
+			target, err := NewWebhookEventTarget(id, cfg)
+			if err != nil {
+				closeTargets()
+				return nil, err
+			}
+			targets = append(targets, target)
+		}
+	}
+
+	n, err := newEventNotifier(conf.QueueDir, conf.QueueLimit, defaultEventRetryInterval, targets...)
+	if err != nil {
+		closeTargets()
+		return nil, err
+	}
+	return n, nil
+}
+
+func newEventNotifier(queueDir string, queueLimit int, retryInterval time.Duration, targets ...EventTarget) (*EventNotifier, error) {
+	n := &EventNotifier{
+		workers: make(map[string]*eventWorker, len(targets)),
+		stopC:   make(chan struct{}),
+	}
+	for _, target := range targets {
+		// ARN is like arn:cubefs:sqs::<id>:<type>, the sub directory is named <type>-<id>
+		items := strings.Split(strings.TrimPrefix(target.ARN(), EventTargetARNPrefix), ":")
+		if len(items) != 2 {
+			return nil, fmt.Errorf("notification: invalid target arn '%s'", target.ARN())
+		}
+		queue, err := newEventQueue(filepath.Join(queueDir, items[1]+"-"+items[0]), queueLimit)
+		if err != nil {
+			return nil, err
+		}
+		n.workers[target.ARN()] = &eventWorker{target: target, queue: queue, retryInterval: retryInterval}
+	}
+	for _, worker := range n.workers {
+		n.wg.Add(1)
+		go func(worker *eventWorker) {
+			defer n.wg.Done()
+			worker.run(n.stopC)
+		}(worker)
+	}
+	return n, nil
+}
+
+// Targets returns the ARNs of all targets which bucket configurations can refer to.
+func (n *EventNotifier) Targets() map[string]bool {
+	targets := make(map[string]bool)
+	if n == nil {
+		return targets
+	}
+	for arn := range n.workers {
+		targets[arn] = true
+	}
+	return targets
+}
+
+// Notify queues the event to the targets of the matched bucket configurations.
+func (n *EventNotifier) Notify(config *NotificationConfiguration, record *EventRecord) {
+	if n == nil || config.IsEmpty() {
+		return
+	}
+	key := record.S3.Bucket.Name + "/" + record.S3.Object.Key
+	for _, qc := range config.QueueConfigurations {
+		if !qc.Match(record.EventName, record.S3.Object.Key) {
+			continue
+		}
+		worker, ok := n.workers[qc.Queue]
+		if !ok {
+			continue
+		}
+		r := *record
+		r.EventName = strings.TrimPrefix(record.EventName, "s3:")
+		r.S3.ConfigurationId = qc.Id
+		data, err := json.Marshal(&EventMessage{EventName: record.EventName, Key: key, Records: []*EventRecord{&r}})
+		if err != nil {
+			log.LogErrorf("notify event: json marshal fail: key(%v) event(%v) err(%v)", key, record.EventName, err)
+			continue
+		}
+		if err = worker.queue.put(data); err != nil {
+			log.LogErrorf("notify event: queue event fail: target(%v) key(%v) event(%v) err(%v)",
+				qc.Queue, key, record.EventName, err)
+		}
+	}
+}
+
+func (n *EventNotifier) Close() {
+	close(n.stopC)
+	n.wg.Wait()
+	for _, worker := range n.workers {
+		worker.target.Close()
+	}
+}
+
+func (w *eventWorker) run(stopC <-chan struct{}) {
+	ticker := time.NewTicker(w.retryInterval)
+	defer ticker.Stop()
+	for {
+		w.deliver(stopC)
+		select {
+		case <-stopC:
+			return
+		case <-w.queue.notifyC:
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliver sends the queued events in order, it stops at the first event failed to send,
+// which is left in the queue to be retried in the next round.
+func (w *eventWorker) deliver(stopC <-chan struct{}) {
+	names, err := w.queue.list()
+	if err != nil {
+		log.LogErrorf("deliver event: list queue fail: target(%v) err(%v)", w.target.ARN(), err)
+		return
+	}
+	for _, name := range names {
+		select {
+		case <-stopC:
+			return
+		default:
+		}
+		data, err := w.queue.get(name)
+		if err != nil {
+			log.LogWarnf("deliver event: read event fail: target(%v) event(%v) err(%v)", w.target.ARN(), name, err)
+			w.queue.remove(name)
+			continue
+		}
+		if err = retry.ExponentialBackoff(3, 100).On(func() error {
+			return w.target.Send(data)
+		}); err != nil {
+			log.LogWarnf("deliver event: send fail and retry later: target(%v) event(%v) queued(%v) err(%v)",
+				w.target.ARN(), name, w.queue.len(), err)
+			return
+		}
+		if err = w.queue.remove(name); err != nil {
+			log.LogErrorf("deliver event: remove event fail: target(%v) event(%v) err(%v)", w.target.ARN(), name, err)
+		}
+	}
+}
+
+// notifyObjectEvent queues the event for the destinations configured on the bucket.
+// The object operation has been done, so failures are only logged.
+func (o *ObjectNode) notifyObjectEvent(r *http.Request, vol *Volume, event *ObjectEvent) {
+	if o.notifier == nil {
+		return
+	}
+	config, err := vol.metaLoader.loadNotification()
+	if err != nil {
+		log.LogErrorf("notifyObjectEvent: load notification fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if config.IsEmpty() {
+		return
+	}
+
+	param := ParseRequestParam(r)
+	now := time.Now()
+	record := &EventRecord{
+		EventVersion:      eventVersion,
+		EventSource:       eventSource,
+		AwsRegion:         o.region,
+		EventTime:         formatTimeISO(now),
+		EventName:         event.Name,
+		UserIdentity:      EventIdentity{PrincipalId: param.Requester()},
+		RequestParameters: map[string]string{"sourceIPAddress": getRequestIP(r)},
+		ResponseElements:  map[string]string{"x-amz-request-id": GetRequestID(r)},
+		S3: EventS3{
+			SchemaVersion: "1.0",
+			Bucket: EventBucket{
+				Name:          vol.Name(),
+				OwnerIdentity: EventIdentity{PrincipalId: vol.owner},
+				ARN:           S3_RESOURCE_PREFIX + vol.Name(),
+			},
+			Object: EventObject{
+				Key:       event.Key,
+				Size:      event.Size,
+				ETag:      event.ETag,
+				VersionId: event.VersionId,
+				Sequencer: fmt.Sprintf("%016X", now.UnixNano()),
+			},
+		},
+	}
+	o.notifier.Notify(config, record)
+}
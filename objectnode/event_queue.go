@@ -0,0 +1,130 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	eventFileExt    = ".event"
+	eventTmpFileExt = ".tmp"
+)
+
+var ErrEventQueueFull = errors.New("event queue is full")
+
+// eventQueue is a bounded queue which keeps every event in a file of the queue directory,
+// so that the undelivered events survive the restart of objectnode. The file names are
+// ordered by the time they are put into the queue.
+type eventQueue struct {
+	dir   string
+	limit int
+	seq   uint64
+
+	mu    sync.Mutex
+	count int
+
+	notifyC chan struct{}
+}
+
+func newEventQueue(dir string, limit int) (*eventQueue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	q := &eventQueue{
+		dir:     dir,
+		limit:   limit,
+		notifyC: make(chan struct{}, 1),
+	}
+	names, err := q.list()
+	if err != nil {
+		return nil, err
+	}
+	q.count = len(names)
+	return q, nil
+}
+
+func (q *eventQueue) put(data []byte) (err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.count >= q.limit {
+		return ErrEventQueueFull
+	}
+	name := fmt.Sprintf("%016x-%08x%s", time.Now().UnixNano(), atomic.AddUint64(&q.seq, 1)&0xffffffff, eventFileExt)
+	tmp := filepath.Join(q.dir, name+eventTmpFileExt)
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		os.Remove(tmp)
+		return
+	}
+	if err = os.Rename(tmp, filepath.Join(q.dir, name)); err != nil {
+		os.Remove(tmp)
+		return
+	}
+	q.count++
+
+	select {
+	case q.notifyC <- struct{}{}:
+	default:
+	}
+	return
+}
+
+// list returns the names of the queued events from the oldest to the newest.
+func (q *eventQueue) list() ([]string, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), eventFileExt) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (q *eventQueue) get(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(q.dir, name))
+}
+
+func (q *eventQueue) remove(name string) error {
+	if err := os.Remove(filepath.Join(q.dir, name)); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	q.mu.Lock()
+	q.count--
+	q.mu.Unlock()
+	return nil
+}
+
+func (q *eventQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.count
+}
@@ -34,6 +34,19 @@ type FSFileInfo struct {
 	Expires         string
 	Metadata        map[string]string `graphql:"-"` // User-defined metadata
 	RetainUntilDate string
+	VersionId       string
+	IsDeleteMarker  bool
+	StorageClass    string
+	Encryption      *ObjectEncryption `graphql:"-"`
+}
+
+// storageClass returns the storage class of the object, the objects are of the standard
+// storage class unless they are transitioned by the lifecycle rules.
+func (info *FSFileInfo) storageClass() string {
+	if isTransitioned(info.StorageClass) {
+		return info.StorageClass
+	}
+	return StorageClassStandard
 }
 
 type Prefixes []string
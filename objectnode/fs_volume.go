@@ -92,6 +92,7 @@ type PutFileOption struct {
 	CacheControl string
 	Expires      string
 	ObjectLock   *ObjectLockConfig
+	Encryption   *ObjectEncryption
 }
 
 type ListFilesV1Option struct {
@@ -198,6 +199,18 @@ func (v *Volume) loadOSSMeta() {
 		return
 	}
 	v.metaLoader.storeObjectLock(objectlock)
+
+	var versioning *VersioningConfiguration
+	if versioning, err = v.loadBucketVersioning(); err != nil {
+		return
+	}
+	v.metaLoader.storeVersioning(versioning)
+
+	var notification *NotificationConfiguration
+	if notification, err = v.loadBucketNotification(); err != nil {
+		return
+	}
+	v.metaLoader.storeNotification(notification)
 	v.metaLoader.setSynced()
 }
 
@@ -277,6 +290,36 @@ func (v *Volume) loadObjectLock() (configuration *ObjectLockConfig, err error) {
 	return configuration, nil
 }
 
+func (v *Volume) loadBucketVersioning() (configuration *VersioningConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSVersioning); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &VersioningConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketNotification() (configuration *NotificationConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSNotification); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &NotificationConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
 func (v *Volume) getInodeFromPath(path string) (inode uint64, err error) {
 	if path == "/" {
 		return volumeRootInode, nil
@@ -671,8 +714,15 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		return
 	}
 
-	// check whether existing object is protected by object lock
-	if oldInode != 0 && opt != nil && opt.ObjectLock != nil {
+	var versioning string
+	if versioning, err = v.versioningStatus(); err != nil {
+		log.LogErrorf("PutObject: load versioning fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+		return
+	}
+
+	// check whether existing object is protected by object lock,
+	// a locked object is kept as a noncurrent version rather than replaced if versioning is enabled.
+	if oldInode != 0 && opt != nil && opt.ObjectLock != nil && versioning != VersioningStatusEnabled {
 		err = isObjectLocked(v, oldInode, lastPathItem.Name, path)
 		if err != nil {
 			return
@@ -712,14 +762,26 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		}
 	}()
 
+	// the data is encrypted before written if server-side encryption is requested,
+	// and the ETag is computed from the plaintext by the encrypter.
+	var h hash.Hash = md5Hash
+	var encrypter *sseEncryptReader
+	if opt != nil && opt.Encryption != nil {
+		if encrypter, err = newSSEEncryptReader(reader, opt.Encryption.dataKey, md5Hash); err != nil {
+			log.LogErrorf("PutObject: new encrypter fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+			return
+		}
+		reader, h = encrypter, nil
+	}
+
 	if proto.IsCold(v.volType) {
-		if _, err = v.ebsWrite(invisibleTempDataInode.Inode, reader, md5Hash); err != nil {
+		if _, err = v.ebsWrite(invisibleTempDataInode.Inode, reader, h); err != nil {
 			log.LogErrorf("PutObject: ebs write fail: volume(%v) path(%v) inode(%v) err(%v)",
 				v.name, path, invisibleTempDataInode.Inode, err)
 			return
 		}
 	} else {
-		if _, err = v.streamWrite(invisibleTempDataInode.Inode, reader, md5Hash); err != nil {
+		if _, err = v.streamWrite(invisibleTempDataInode.Inode, reader, h); err != nil {
 			log.LogErrorf("PutObject: stream write fail: volume(%v) path(%v) inode(%v) err(%v)",
 				v.name, path, invisibleTempDataInode.Inode, err)
 			return
@@ -785,6 +847,15 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		}
 	}
 
+	size := finalInode.Size
+	if encrypter != nil {
+		size = encrypter.Size()
+		opt.Encryption.Parts = []uint64{size}
+		opt.Encryption.setXAttrs(attr.XAttrs)
+	}
+
+	versionId := assignObjectVersion(versioning, attr.XAttrs)
+
 	if err = v.mw.BatchSetXAttr_ll(invisibleTempDataInode.Inode, attr.XAttrs); err != nil {
 		log.LogErrorf("PutObject: BatchSetXAttr_ll fail: volume(%v) path(%v) inode(%v) attrs(%v) err(%v)",
 			v.name, path, invisibleTempDataInode.Inode, attr.XAttrs, err)
@@ -795,12 +866,13 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 	// create file info
 	fsInfo = &FSFileInfo{
 		Path:       path,
-		Size:       int64(finalInode.Size),
+		Size:       int64(size),
 		Mode:       os.FileMode(finalInode.Mode),
 		CreateTime: finalInode.CreateTime,
 		ModifyTime: finalInode.ModifyTime,
 		ETag:       etagValue.ETag(),
 		Inode:      finalInode.Inode,
+		VersionId:  versionId,
 	}
 
 	// apply new inode to dentry
@@ -819,8 +891,24 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 }
 
 func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64, isCompleteMultipart bool, fullPath string) (err error) {
+	var versioning string
+	if versioning, err = v.versioningStatus(); err != nil {
+		log.LogErrorf("applyInodeToDEntry: load versioning fail: volume(%v) path(%v) err(%v)", v.name, fullPath, err)
+		return
+	}
+	// objects written while versioning is suspended take the null version ID,
+	// the noncurrent null version has to give way to it.
+	if versioning == VersioningStatusSuspended {
+		if err = v.removeNullVersion(fullPath); err != nil {
+			log.LogErrorf("applyInodeToDEntry: remove null version fail: volume(%v) path(%v) err(%v)",
+				v.name, fullPath, err)
+			return
+		}
+	}
+
+	var existInode uint64
 	var existMode uint32
-	_, existMode, err = v.mw.Lookup_ll(parentId, name) // exist object inode
+	existInode, existMode, err = v.mw.Lookup_ll(parentId, name) // exist object inode
 	if err != nil && err != syscall.ENOENT {
 		log.LogErrorf("applyInodeToDEntry: meta lookup fail: parentID(%v) name(%v) err(%v)", parentId, name, err)
 		return
@@ -841,9 +929,23 @@ func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64,
 			err = syscall.EINVAL
 			return
 		}
-		// current implementation doesn't support object versioning, so uploading a object with a key already existed in bucket
-		// is implemented with replacing the old one instead.
+		// In a versioned bucket the existing object becomes a noncurrent version unless it is the null version
+		// and versioning is suspended, otherwise uploading an object with a key already existed in bucket is
+		// implemented with replacing the old one.
 		// refer: https://docs.aws.amazon.com/AmazonS3/latest/userguide/upload-objects.html
+		var archived bool
+		if archived, err = v.archiveReplacedObject(parentId, name, existInode, inode, versioning, isCompleteMultipart, fullPath); err != nil {
+			log.LogErrorf("applyInodeToDEntry: archive exist object fail: parentID(%v) name(%v) inode(%v) err(%v)",
+				parentId, name, existInode, err)
+			return
+		}
+		if archived {
+			if err = v.applyInodeToNewDentry(parentId, name, inode, fullPath); err != nil {
+				log.LogErrorf("applyInodeToDEntry: apply inode to new dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
+					parentId, name, inode, err)
+			}
+			return
+		}
 		if err = v.applyInodeToExistDentry(parentId, name, inode, isCompleteMultipart, fullPath); err != nil {
 			log.LogErrorf("applyInodeToDEntry: apply inode to exist dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
 				parentId, name, inode, err)
@@ -963,6 +1065,10 @@ func (v *Volume) InitMultipart(path string, opt *PutFileOption) (multipartID str
 	if opt != nil && opt.ACL != nil {
 		extend[XAttrKeyOSSACL] = opt.ACL.Encode()
 	}
+	// The parts are encrypted with the same data key, the sealed key is kept with the upload.
+	if opt != nil && opt.Encryption != nil {
+		opt.Encryption.setXAttrs(extend)
+	}
 
 	if v.mw.EnableQuota {
 		var parentId uint64
@@ -986,7 +1092,18 @@ func (v *Volume) InitMultipart(path string, opt *PutFileOption) (multipartID str
 	return multipartID, nil
 }
 
-func (v *Volume) WritePart(path string, multipartId string, partId uint16, reader io.Reader) (*FSFileInfo, error) {
+// multipartEncryption loads the encryption of the multipart upload, which is set at the initiation.
+func (v *Volume) multipartEncryption(path, multipartID string) (*ObjectEncryption, error) {
+	info, err := v.mw.GetMultipart_ll(path, multipartID)
+	if err != nil {
+		log.LogErrorf("multipartEncryption: meta get multipart fail: volume(%v) path(%v) multipartID(%v) err(%v)",
+			v.name, path, multipartID, err)
+		return nil, err
+	}
+	return parseObjectEncryption(info.Extend)
+}
+
+func (v *Volume) WritePart(path string, multipartId string, partId uint16, reader io.Reader, encryption *ObjectEncryption) (*FSFileInfo, error) {
 	var exist bool
 	var err error
 	defer func() {
@@ -1046,15 +1163,25 @@ func (v *Volume) WritePart(path string, multipartId string, partId uint16, reade
 				v.name, path, multipartId, partId, tempInodeInfo.Inode, closeErr)
 		}
 	}()
+	var h hash.Hash = md5Hash
+	var encrypter *sseEncryptReader
+	if encryption != nil {
+		if encrypter, err = newSSEEncryptReader(reader, encryption.dataKey, md5Hash); err != nil {
+			log.LogErrorf("WritePart: new encrypter fail: volume(%v) path(%v) multipartID(%v) partID(%v) err(%v)",
+				v.name, path, multipartId, partId, err)
+			return nil, err
+		}
+		reader, h = encrypter, nil
+	}
 	if proto.IsCold(v.volType) {
-		if size, err = v.ebsWrite(tempInodeInfo.Inode, reader, md5Hash); err != nil {
+		if size, err = v.ebsWrite(tempInodeInfo.Inode, reader, h); err != nil {
 			log.LogErrorf("WritePart: ebs write fail: volume(%v) inode(%v) multipartID(%v) partID(%v) err(%v)",
 				v.name, tempInodeInfo.Inode, multipartId, partId, err)
 			return nil, err
 		}
 	} else {
 		// Write data to data node
-		if size, err = v.streamWrite(tempInodeInfo.Inode, reader, md5Hash); err != nil {
+		if size, err = v.streamWrite(tempInodeInfo.Inode, reader, h); err != nil {
 			log.LogErrorf("WritePart: stream write fail: volume(%v) inode(%v) multipartID(%v) partID(%v) err(%v)",
 				v.name, tempInodeInfo.Inode, multipartId, partId, err)
 			return nil, err
@@ -1068,6 +1195,10 @@ func (v *Volume) WritePart(path string, multipartId string, partId uint16, reade
 
 	// compute file md5
 	etag = hex.EncodeToString(md5Hash.Sum(nil))
+	// the size of the part is the size of the plaintext, so is the size of the complete object
+	if encrypter != nil {
+		size = encrypter.Size()
+	}
 
 	// update temp file inode to meta with session, overwrite existing part can result in exist == true
 	oldInode, exist, err = v.mw.AddMultipartPart_ll(path, multipartId, partId, size, etag, tempInodeInfo)
@@ -1162,7 +1293,12 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 		log.LogErrorf("CompleteMultipart: load volume objectLock: volume(%v) err(%v)", v.name, err)
 		return
 	}
-	if oldInode != 0 && objectLock != nil {
+	var versioning string
+	if versioning, err = v.versioningStatus(); err != nil {
+		log.LogErrorf("CompleteMultipart: load versioning fail: volume(%v) err(%v)", v.name, err)
+		return
+	}
+	if oldInode != 0 && objectLock != nil && versioning != VersioningStatusEnabled {
 		err = isObjectLocked(v, oldInode, filename, path)
 		if err != nil {
 			return
@@ -1281,9 +1417,18 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 			attrs[key] = value
 		}
 	}
+	// the data of an encrypted object is made up of the encrypted streams of the parts
+	if _, encrypted := extend[XAttrKeyOSSSSE]; encrypted {
+		partSizes := make([]uint64, 0, len(parts))
+		for _, part := range parts {
+			partSizes = append(partSizes, part.Size)
+		}
+		attrs[XAttrKeyOSSSSEParts] = formatSSEParts(partSizes)
+	}
 	if objectLock != nil && objectLock.ToRetention() != nil {
 		attrs[XAttrKeyOSSLock] = formatRetentionDateStr(finalInode.ModifyTime, objectLock.ToRetention())
 	}
+	versionId := assignObjectVersion(versioning, attrs)
 	if err = v.mw.BatchSetXAttr_ll(finalInode.Inode, attrs); err != nil {
 		log.LogErrorf("CompleteMultipart: store multipart extend fail: volume(%v) multipartID(%v) inode(%v) "+
 			"attrs(%v) err(%v)", v.name, multipartID, finalInode.Inode, attrs, err)
@@ -1341,6 +1486,7 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 		ModifyTime: time.Now(),
 		ETag:       etagValue.ETag(),
 		Inode:      finalInode.Inode,
+		VersionId:  versionId,
 	}
 
 	return fInfo, nil
@@ -1530,7 +1676,7 @@ func (v *Volume) loadUserDefinedMetadata(inode uint64) (metadata map[string]stri
 	return
 }
 
-func (v *Volume) readFile(inode, inodeSize uint64, path string, writer io.Writer, offset, size uint64) (err error) {
+func (v *Volume) readFile(inode, inodeSize uint64, storageClass, path string, writer io.Writer, offset, size uint64) (err error) {
 	if err = v.ec.OpenStream(inode); err != nil {
 		log.LogErrorf("readFile: data open stream fail, Inode(%v) err(%v)", inode, err)
 		return err
@@ -1541,13 +1687,24 @@ func (v *Volume) readFile(inode, inodeSize uint64, path string, writer io.Writer
 		}
 	}()
 
-	if proto.IsHot(v.volType) {
+	if proto.IsHot(v.volType) && !isTransitioned(storageClass) {
 		return v.read(inode, inodeSize, path, writer, offset, size)
 	} else {
 		return v.readEbs(inode, inodeSize, path, writer, offset, size)
 	}
 }
 
+// readEncryptedFile reads the plaintext of the object encrypted by the server,
+// the data key must be unsealed before.
+func (v *Volume) readEncryptedFile(info *FSFileInfo, path string, writer io.Writer, offset, size uint64) error {
+	enc := info.Encryption
+	inodeSize := enc.encryptedSize()
+	read := func(w io.Writer, off, n uint64) error {
+		return v.readFile(info.Inode, inodeSize, info.StorageClass, path, w, off, n)
+	}
+	return readSSERange(read, enc.dataKey, enc.Parts, writer, offset, size)
+}
+
 func (v *Volume) readEbs(inode, inodeSize uint64, path string, writer io.Writer, offset, size uint64) error {
 	upper := size + offset
 	if upper > inodeSize {
@@ -1657,7 +1814,20 @@ func (v *Volume) ReadFile(path string, writer io.Writer, offset, size uint64) er
 		return err
 	}
 
-	return v.readFile(ino, inoInfo.Size, path, writer, offset, size)
+	var storageClass string
+	if storageClass, err = v.inodeStorageClass(ino); err != nil {
+		return err
+	}
+
+	return v.readFile(ino, inoInfo.Size, storageClass, path, writer, offset, size)
+}
+
+func (v *Volume) inodeStorageClass(inode uint64) (string, error) {
+	xattr, err := v.mw.XAttrGet_ll(inode, XAttrKeyOSSStorageClass)
+	if err != nil {
+		return "", err
+	}
+	return string(xattr.Get(XAttrKeyOSSStorageClass)), nil
 }
 
 func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, xattr *proto.XAttrInfo, err error) {
@@ -1686,7 +1856,12 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, xattr *proto.XAttrIn
 		}
 		break
 	}
+	return v.inodeObjectMeta(path, mode, inoInfo)
+}
 
+// inodeObjectMeta builds the object meta of the path from the inode and its extended attributes.
+func (v *Volume) inodeObjectMeta(path string, mode os.FileMode, inoInfo *proto.InodeInfo) (info *FSFileInfo, xattr *proto.XAttrInfo, err error) {
+	var inode = inoInfo.Inode
 	var (
 		etagValue    ETagValue
 		mimeType     string
@@ -1765,6 +1940,17 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, xattr *proto.XAttrIn
 		}
 	}
 
+	// The size of an encrypted object is the size of its plaintext.
+	size := inoInfo.Size
+	var encryption *ObjectEncryption
+	if encryption, err = parseObjectEncryption(xattr.XAttrs); err != nil {
+		log.LogErrorf("ObjectMeta: parse encryption fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+		return
+	}
+	if encryption != nil {
+		size = encryption.Size()
+	}
+
 	// Validating ETag value.
 	if !mode.IsDir() && (!etagValue.Valid() || etagValue.TS.Before(inoInfo.ModifyTime)) {
 		log.LogWarnf("ObjectMeta: etag invalid or before inode modTime: volume(%v) path(%v) inoInfo(%v) etagVal(%v)",
@@ -1773,7 +1959,7 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, xattr *proto.XAttrIn
 
 	info = &FSFileInfo{
 		Path:            path,
-		Size:            int64(inoInfo.Size),
+		Size:            int64(size),
 		Mode:            os.FileMode(inoInfo.Mode),
 		CreateTime:      inoInfo.CreateTime,
 		ModifyTime:      inoInfo.ModifyTime,
@@ -1785,6 +1971,9 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, xattr *proto.XAttrIn
 		Expires:         expires,
 		Metadata:        metadata,
 		RetainUntilDate: retainUntilDate,
+		VersionId:       string(xattr.Get(XAttrKeyOSSVersionId)),
+		StorageClass:    string(xattr.Get(XAttrKeyOSSStorageClass)),
+		Encryption:      encryption,
 	}
 	return
 }
@@ -1809,7 +1998,7 @@ func (v *Volume) Close() error {
 func (v *Volume) recursiveLookupTarget(path string, notUseCache bool) (parent uint64, ino uint64, name string, mode os.FileMode, err error) {
 	parent = rootIno
 	pathIterator := NewPathIterator(path)
-	if !pathIterator.HasNext() {
+	if !pathIterator.HasNext() || isReservedObjectPath(path) {
 		err = syscall.ENOENT
 		return
 	}
@@ -1982,6 +2171,11 @@ func (v *Volume) recursiveMakeDirectory(path string) (partentIno uint64, err err
 		err = syscall.ENOENT
 		return
 	}
+	// the version tree is maintained by the object node itself
+	if isReservedObjectPath(path) {
+		err = syscall.EINVAL
+		return
+	}
 	for pathIterator.HasNext() {
 		pathItem := pathIterator.Next()
 		if !pathItem.IsDirectory {
@@ -2302,6 +2496,10 @@ readDir:
 		if child.Name == lastKey {
 			continue
 		}
+		// the version tree is invisible to object listing
+		if len(dirs) == 0 && child.Name == ObjectVersionRootDir {
+			continue
+		}
 		path := strings.Join(append(dirs, child.Name), pathSep)
 		if os.FileMode(child.Type).IsDir() {
 			path += pathSep
@@ -2412,7 +2610,7 @@ func (v *Volume) supplyListFileInfo(fileInfos []*FSFileInfo) (err error) {
 	}
 
 	// Get MD5 information in batches, then update to fileInfos
-	keys := []string{XAttrKeyOSSETag, XAttrKeyOSSETagDeprecated}
+	keys := []string{XAttrKeyOSSETag, XAttrKeyOSSETagDeprecated, XAttrKeyOSSStorageClass, XAttrKeyOSSSSEParts}
 	xattrs, err := v.mw.BatchGetXAttr(inodes, keys)
 	if err != nil {
 		log.LogErrorf("supplyListFileInfo: batch get xattr fail, inodes(%v), err(%v)", inodes, err)
@@ -2430,6 +2628,7 @@ func (v *Volume) supplyListFileInfo(fileInfos []*FSFileInfo) (err error) {
 			return xattrs[i].Inode >= fileInfo.Inode
 		})
 		var etagValue ETagValue
+		var encrypted bool
 		if i >= 0 && i < len(xattrs) && xattrs[i].Inode == fileInfo.Inode {
 			xattr := xattrs[i]
 			rawETag := string(xattr.Get(XAttrKeyOSSETag))
@@ -2439,8 +2638,20 @@ func (v *Volume) supplyListFileInfo(fileInfos []*FSFileInfo) (err error) {
 			if len(rawETag) > 0 {
 				etagValue = ParseETagValue(rawETag)
 			}
+			fileInfo.StorageClass = string(xattr.Get(XAttrKeyOSSStorageClass))
+			if rawParts := string(xattr.Get(XAttrKeyOSSSSEParts)); len(rawParts) > 0 {
+				parts, parseErr := parseSSEParts(rawParts)
+				if parseErr != nil {
+					log.LogErrorf("supplyListFileInfo: parse encrypted parts fail: volume(%v) path(%v) inode(%v) err(%v)",
+						v.name, fileInfo.Path, fileInfo.Inode, parseErr)
+					return parseErr
+				}
+				encryption := &ObjectEncryption{Parts: parts}
+				fileInfo.Size, encrypted = int64(encryption.Size()), true
+			}
 		}
-		if !etagValue.Valid() || etagValue.TS.Before(fileInfo.ModifyTime) {
+		// the ETag of an encrypted object can not be computed from the encrypted data
+		if !encrypted && (!etagValue.Valid() || etagValue.TS.Before(fileInfo.ModifyTime)) {
 			// The ETag is invalid or outdated then generate a new ETag and make update.
 			if etagValue, err = v.updateETag(fileInfo.Inode, fileInfo.Size, fileInfo.ModifyTime); err != nil {
 				log.LogErrorf("supplyListFileInfo: update ETag fail: volume(%v) path(%v) inode(%v) err(%v)",
@@ -2574,10 +2785,10 @@ func (v *Volume) ListParts(path, uploadId string, maxParts, partNumberMarker uin
 	return parts, nextMarker, isTruncated, nil
 }
 
-func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective string, opt *PutFileOption) (info *FSFileInfo, err error) {
+func (v *Volume) CopyFile(sv *Volume, sourcePath, sourceVersionId, targetPath, metaDirective string, opt *PutFileOption) (info *FSFileInfo, err error) {
 	defer func() {
-		log.LogInfof("Audit: copy file: source path(%v) target path(%v) err(%v)",
-			sourcePath, targetPath, err)
+		log.LogInfof("Audit: copy file: source path(%v) source version(%v) target path(%v) err(%v)",
+			sourcePath, sourceVersionId, targetPath, err)
 	}()
 
 	// operation at source object
@@ -2586,9 +2797,23 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		sName      string
 		sMode      os.FileMode
 		sInodeInfo *proto.InodeInfo
+		sIsCurrent = true
 	)
 
-	if _, sInode, sName, sMode, err = sv.recursiveLookupTarget(sourcePath, false); err != nil {
+	if sourceVersionId != "" {
+		var version *objectVersion
+		if version, err = sv.lookupObjectVersion(sourcePath, sourceVersionId); err != nil {
+			log.LogErrorf("CopyFile: look up source version fail, source path(%v) version(%v) err(%v)",
+				sourcePath, sourceVersionId, err)
+			return
+		}
+		if version.isDeleteMarker {
+			log.LogErrorf("CopyFile: source version is a delete marker, source path(%v) version(%v)",
+				sourcePath, sourceVersionId)
+			return nil, syscall.ENOENT
+		}
+		sInode, sName, sMode, sIsCurrent = version.inode, version.name, DefaultFileMode, version.isCurrent
+	} else if _, sInode, sName, sMode, err = sv.recursiveLookupTarget(sourcePath, false); err != nil {
 		log.LogErrorf("CopyFile: look up source path fail, source path(%v) err(%v)", sourcePath, err)
 		return
 	}
@@ -2615,7 +2840,7 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 	var xattr *proto.XAttrInfo
 	// if source path is same with target path, just reset file metadata
 	// source path is same with target path, and metadata directive is not 'REPLACE', objectNode does nothing
-	if targetPath == sourcePath && v.name == sv.name {
+	if targetPath == sourcePath && v.name == sv.name && sIsCurrent {
 		if metaDirective != MetadataDirectiveReplace {
 			log.LogInfof("CopyFile: targetPath(%v) is equal with sourcePath(%v),but metaDirective(%v) is not REPLACE",
 				targetPath, sourcePath, metaDirective)
@@ -2739,8 +2964,14 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 	}
 	tLastName = pathItems[len(pathItems)-1].Name
 
+	var versioning string
+	if versioning, err = v.versioningStatus(); err != nil {
+		log.LogErrorf("CopyFile: load versioning fail: volume(%v) err(%v)", v.name, err)
+		return
+	}
+
 	// check whether existing object is protected by object lock
-	if oldtInode != 0 && opt != nil && opt.ObjectLock != nil {
+	if oldtInode != 0 && opt != nil && opt.ObjectLock != nil && versioning != VersioningStatusEnabled {
 		err = isObjectLocked(v, oldtInode, tLastName, targetPath)
 		if err != nil {
 			return
@@ -2791,9 +3022,30 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 	var ebsReader *blobstore.Reader
 	var tctx context.Context
 	var ebsWriter *blobstore.Writer
-	if proto.IsCold(sv.volType) {
+	var sStorageClass string
+	if sStorageClass, err = sv.inodeStorageClass(sInode); err != nil {
+		log.LogErrorf("CopyFile: get source storage class fail, volume(%v) path(%v) inode(%v) err(%v)",
+			sv.name, sourcePath, sInode, err)
+		return
+	}
+	// The data of an encrypted source is copied as is, the target is encrypted with
+	// the same data key and keeps the ETag of the source.
+	var sXAttr *proto.XAttrInfo
+	if sXAttr, err = sv.mw.XAttrGetAll_ll(sInode); err != nil {
+		log.LogErrorf("CopyFile: get source xattr fail, volume(%v) path(%v) inode(%v) err(%v)",
+			sv.name, sourcePath, sInode, err)
+		return
+	}
+	var sEncryption *ObjectEncryption
+	if sEncryption, err = parseObjectEncryption(sXAttr.XAttrs); err != nil {
+		log.LogErrorf("CopyFile: parse source encryption fail, volume(%v) path(%v) inode(%v) err(%v)",
+			sv.name, sourcePath, sInode, err)
+		return
+	}
+	sourceInEbs := proto.IsCold(sv.volType) || isTransitioned(sStorageClass)
+	if sourceInEbs {
 		sctx = context.Background()
-		ebsReader = v.getEbsReader(sInode)
+		ebsReader = sv.getEbsReader(sInode)
 	}
 	if proto.IsCold(v.volType) {
 		tctx = context.Background()
@@ -2809,7 +3061,7 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			readSize = rest
 		}
 		buf = buf[:readSize]
-		if proto.IsCold(sv.volType) {
+		if sourceInEbs {
 			readN, err = ebsReader.Read(sctx, buf, readOffset, readSize)
 		} else {
 			readN, err = sv.ec.Read(sInode, buf, readOffset, readSize)
@@ -2864,6 +3116,11 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		PartNum: 0,
 		TS:      finalInode.ModifyTime,
 	}
+	if sEncryption != nil {
+		etagValue = ParseETagValue(string(sXAttr.Get(XAttrKeyOSSETag)))
+		etagValue.TS = finalInode.ModifyTime
+		fileSize = sEncryption.Size()
+	}
 
 	targetAttr := &AttrItem{
 		XAttrInfo: proto.XAttrInfo{
@@ -2872,6 +3129,10 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		},
 	}
 	targetAttr.XAttrs[XAttrKeyOSSETag] = etagValue.Encode()
+	if sEncryption != nil {
+		sEncryption.setXAttrs(targetAttr.XAttrs)
+	}
+	versionId := assignObjectVersion(versioning, targetAttr.XAttrs)
 
 	// copy source file metadata to write target file metadata
 	if metaDirective != MetadataDirectiveReplace {
@@ -2880,7 +3141,8 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			return
 		}
 		for key, val := range xattr.XAttrs {
-			if key == XAttrKeyOSSETag {
+			// the data of the target is always written in the storage class of the volume
+			if key == XAttrKeyOSSETag || key == XAttrKeyOSSVersionId || key == XAttrKeyOSSStorageClass {
 				continue
 			}
 			targetAttr.XAttrs[key] = val
@@ -2949,8 +3211,9 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		Mode:       sMode,
 		ModifyTime: tInodeInfo.ModifyTime,
 		CreateTime: tInodeInfo.CreateTime,
-		ETag:       md5Value,
+		ETag:       etagValue.ETag(),
 		Inode:      tInodeInfo.Inode,
+		VersionId:  versionId,
 	}
 
 	// apply new inode to dentry
@@ -26,10 +26,14 @@ type ossMetaLoader interface {
 	loadACL() (p *AccessControlPolicy, err error)
 	loadCORS() (cors *CORSConfiguration, err error)
 	loadObjectLock() (config *ObjectLockConfig, err error)
+	loadVersioning() (config *VersioningConfiguration, err error)
+	loadNotification() (config *NotificationConfiguration, err error)
 	storePolicy(p *Policy)
 	storeACL(p *AccessControlPolicy)
 	storeCORS(cors *CORSConfiguration)
 	storeObjectLock(config *ObjectLockConfig)
+	storeVersioning(config *VersioningConfiguration)
+	storeNotification(config *NotificationConfiguration)
 	setSynced()
 }
 
@@ -50,10 +54,14 @@ type OSSMeta struct {
 	acl        *AccessControlPolicy
 	corsConfig *CORSConfiguration
 	lockConfig *ObjectLockConfig
+	versioning *VersioningConfiguration
+	notify     *NotificationConfiguration
 	policyLock sync.RWMutex
 	aclLock    sync.RWMutex
 	corsLock   sync.RWMutex
 	objectLock sync.RWMutex
+	verLock    sync.RWMutex
+	notifyLock sync.RWMutex
 }
 
 func (c *cacheMetaLoader) loadPolicy() (p *Policy, err error) {
@@ -156,6 +164,56 @@ func (c *cacheMetaLoader) storeObjectLock(config *ObjectLockConfig) {
 	return
 }
 
+func (c *cacheMetaLoader) loadVersioning() (config *VersioningConfiguration, err error) {
+	c.om.verLock.RLock()
+	config = c.om.versioning
+	c.om.verLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSVersioning, func() (interface{}, error) {
+			vc, err := c.sml.loadVersioning()
+			return vc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*VersioningConfiguration)
+		c.storeVersioning(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeVersioning(config *VersioningConfiguration) {
+	c.om.verLock.Lock()
+	c.om.versioning = config
+	c.om.verLock.Unlock()
+	return
+}
+
+func (c *cacheMetaLoader) loadNotification() (config *NotificationConfiguration, err error) {
+	c.om.notifyLock.RLock()
+	config = c.om.notify
+	c.om.notifyLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSNotification, func() (interface{}, error) {
+			nc, err := c.sml.loadNotification()
+			return nc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*NotificationConfiguration)
+		c.storeNotification(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeNotification(config *NotificationConfiguration) {
+	c.om.notifyLock.Lock()
+	c.om.notify = config
+	c.om.notifyLock.Unlock()
+	return
+}
+
 func (c *cacheMetaLoader) setSynced() {
 	atomic.StoreInt32(c.synced, 1)
 }
@@ -192,6 +250,22 @@ func (s *strictMetaLoader) storeObjectLock(cors *ObjectLockConfig) {
 	// do nothing
 }
 
+func (s *strictMetaLoader) loadVersioning() (config *VersioningConfiguration, err error) {
+	return s.v.loadBucketVersioning()
+}
+
+func (s *strictMetaLoader) storeVersioning(config *VersioningConfiguration) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadNotification() (config *NotificationConfiguration, err error) {
+	return s.v.loadBucketNotification()
+}
+
+func (s *strictMetaLoader) storeNotification(config *NotificationConfiguration) {
+	// do nothing
+}
+
 func (s *strictMetaLoader) setSynced() {
 	// do nothing
 }
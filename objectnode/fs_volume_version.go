@@ -0,0 +1,895 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+type ListObjectVersionsOption struct {
+	Prefix          string
+	Delimiter       string
+	KeyMarker       string
+	VersionIdMarker string
+	MaxKeys         uint64
+}
+
+type ListObjectVersionsResult struct {
+	Versions            []*FSObjectVersion
+	CommonPrefixes      []string
+	NextKeyMarker       string
+	NextVersionIdMarker string
+	Truncated           bool
+}
+
+type FSObjectVersion struct {
+	*FSFileInfo
+	IsLatest bool
+}
+
+// objectVersion locates a version of an object, which is either referred by the
+// dentry of the object key or by an entry of the version tree.
+type objectVersion struct {
+	parentId       uint64
+	name           string
+	inode          uint64
+	versionId      string
+	isCurrent      bool
+	isDeleteMarker bool
+}
+
+// versioningStatus returns the versioning status of the bucket,
+// it is empty if versioning has never been configured for the bucket.
+func (v *Volume) versioningStatus() (status string, err error) {
+	var config *VersioningConfiguration
+	if config, err = v.metaLoader.loadVersioning(); err != nil {
+		log.LogErrorf("versioningStatus: load versioning fail: volume(%v) err(%v)", v.name, err)
+		return
+	}
+	if config != nil {
+		status = config.Status
+	}
+	return
+}
+
+func (v *Volume) objectVersionId(inode uint64) (versionId string, err error) {
+	var info *proto.XAttrInfo
+	if info, err = v.mw.XAttrGet_ll(inode, XAttrKeyOSSVersionId); err != nil {
+		log.LogErrorf("objectVersionId: meta get xattr fail: volume(%v) inode(%v) err(%v)", v.name, inode, err)
+		return
+	}
+	if versionId = string(info.Get(XAttrKeyOSSVersionId)); versionId == "" {
+		versionId = NullVersionId
+	}
+	return
+}
+
+func (v *Volume) isDeleteMarker(inode uint64) (marker bool, err error) {
+	var info *proto.XAttrInfo
+	if info, err = v.mw.XAttrGet_ll(inode, XAttrKeyOSSDeleteMarker); err != nil {
+		log.LogErrorf("isDeleteMarker: meta get xattr fail: volume(%v) inode(%v) err(%v)", v.name, inode, err)
+		return
+	}
+	return len(info.Get(XAttrKeyOSSDeleteMarker)) > 0, nil
+}
+
+// lookupVersionDirs looks up the directory which keeps the versions of the object
+// in the version tree, the inodes of all directories from the version tree root are returned.
+func (v *Volume) lookupVersionDirs(path string, autoCreate bool) (inodes []uint64, err error) {
+	names := versionDirNames(path)
+	parentId := rootIno
+	for i, name := range names {
+		var curIno uint64
+		var curMode uint32
+		curIno, curMode, err = v.mw.Lookup_ll(parentId, name)
+		if err == syscall.ENOENT && autoCreate {
+			var info *proto.InodeInfo
+			dirPath := strings.Join(names[:i+1], pathSep)
+			if info, err = v.mw.Create_ll(parentId, name, uint32(DefaultDirMode), 0, 0, nil, dirPath); err == nil {
+				curIno, curMode = info.Inode, info.Mode
+			} else if err == syscall.EEXIST {
+				curIno, curMode, err = v.mw.Lookup_ll(parentId, name)
+			}
+		}
+		if err != nil {
+			if err != syscall.ENOENT {
+				log.LogErrorf("lookupVersionDirs: lookup fail: volume(%v) path(%v) parentID(%v) name(%v) err(%v)",
+					v.name, path, parentId, name, err)
+			}
+			return
+		}
+		if !os.FileMode(curMode).IsDir() {
+			log.LogErrorf("lookupVersionDirs: version dir is not a directory: volume(%v) path(%v) parentID(%v) name(%v)",
+				v.name, path, parentId, name)
+			return nil, syscall.ENOTDIR
+		}
+		inodes = append(inodes, curIno)
+		parentId = curIno
+	}
+	return
+}
+
+func (v *Volume) lookupVersionDir(path string, autoCreate bool) (inode uint64, err error) {
+	var inodes []uint64
+	if inodes, err = v.lookupVersionDirs(path, autoCreate); err != nil {
+		return
+	}
+	return inodes[len(inodes)-1], nil
+}
+
+// readVersionDir reads all entries of a directory in the version tree,
+// regular entries are versions of the object and directories belong to other object keys.
+func (v *Volume) readVersionDir(inode uint64) (versions, subDirs []proto.Dentry, err error) {
+	var from string
+	for {
+		var children []proto.Dentry
+		if children, err = v.mw.ReadDirLimit_ll(inode, from, versionReadDirLimit); err != nil {
+			if err == syscall.ENOENT {
+				err = nil
+			}
+			return
+		}
+		for _, child := range children {
+			if from != "" && child.Name == from {
+				continue
+			}
+			if os.FileMode(child.Type).IsDir() {
+				subDirs = append(subDirs, child)
+			} else {
+				versions = append(versions, child)
+			}
+		}
+		if uint64(len(children)) < versionReadDirLimit {
+			return
+		}
+		from = children[len(children)-1].Name
+	}
+}
+
+// lookupVersionEntry finds the entry of the version in the version directory of an object.
+func (v *Volume) lookupVersionEntry(dirIno uint64, versionId string) (entry *proto.Dentry, err error) {
+	if versionId != NullVersionId {
+		var inode uint64
+		var mode uint32
+		if inode, mode, err = v.mw.Lookup_ll(dirIno, versionId); err != nil {
+			return
+		}
+		if os.FileMode(mode).IsDir() {
+			return nil, syscall.ENOENT
+		}
+		return &proto.Dentry{Name: versionId, Inode: inode, Type: mode}, nil
+	}
+	var versions []proto.Dentry
+	if versions, _, err = v.readVersionDir(dirIno); err != nil {
+		return
+	}
+	for i := range versions {
+		if versionIdFromEntryName(versions[i].Name) == NullVersionId {
+			return &versions[i], nil
+		}
+	}
+	return nil, syscall.ENOENT
+}
+
+// lookupObjectVersion finds the specified version of an object, the current object is
+// checked at first and then the version tree.
+func (v *Volume) lookupObjectVersion(path, versionId string) (version *objectVersion, err error) {
+	if strings.HasSuffix(path, pathSep) {
+		return nil, syscall.ENOENT
+	}
+	var (
+		parentId uint64
+		inode    uint64
+		name     string
+		mode     os.FileMode
+	)
+	parentId, inode, name, mode, err = v.recursiveLookupTarget(path, false)
+	if err != nil && err != syscall.ENOENT {
+		return
+	}
+	if err == nil && mode.IsRegular() {
+		var current string
+		if current, err = v.objectVersionId(inode); err != nil {
+			return
+		}
+		if current == versionId {
+			version = &objectVersion{
+				parentId:  parentId,
+				name:      name,
+				inode:     inode,
+				versionId: versionId,
+				isCurrent: true,
+			}
+			return
+		}
+	}
+
+	var dirIno uint64
+	if dirIno, err = v.lookupVersionDir(path, false); err != nil {
+		if err == syscall.ENOTDIR {
+			err = syscall.ENOENT
+		}
+		return
+	}
+	var entry *proto.Dentry
+	if entry, err = v.lookupVersionEntry(dirIno, versionId); err != nil {
+		return
+	}
+	version = &objectVersion{
+		parentId:  dirIno,
+		name:      entry.Name,
+		inode:     entry.Inode,
+		versionId: versionId,
+	}
+	if version.isDeleteMarker, err = v.isDeleteMarker(entry.Inode); err != nil {
+		return
+	}
+	return
+}
+
+// archiveReplacedObject moves the object which is going to be replaced into the version tree
+// and reports whether it has been moved. The null version is replaced rather than archived
+// when versioning is suspended.
+func (v *Volume) archiveReplacedObject(parentId uint64, name string, existInode, inode uint64, versioning string,
+	isCompleteMultipart bool, path string) (archived bool, err error) {
+	if versioning == "" {
+		return
+	}
+	// concurrent completeMultipart request, the dentry has been applied already.
+	if isCompleteMultipart {
+		var isSameExtent bool
+		if isSameExtent, err = v.referenceExtentKey(existInode, inode); err != nil || isSameExtent {
+			return
+		}
+	}
+	var versionId string
+	if versionId, err = v.objectVersionId(existInode); err != nil {
+		return
+	}
+	if versioning == VersioningStatusSuspended && versionId == NullVersionId {
+		return
+	}
+	if err = v.archiveObject(parentId, name, existInode, versionId, path); err != nil {
+		return
+	}
+	return true, nil
+}
+
+// archiveObject turns the current object into a noncurrent version.
+func (v *Volume) archiveObject(parentId uint64, name string, inode uint64, versionId, path string) (err error) {
+	var info *proto.InodeInfo
+	if info, err = v.mw.InodeGet_ll(inode); err != nil {
+		log.LogErrorf("archiveObject: meta get inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		return
+	}
+	// there is at most one null version of an object
+	if versionId == NullVersionId {
+		if err = v.removeNullVersion(path); err != nil {
+			return
+		}
+	}
+	var dirIno uint64
+	if dirIno, err = v.lookupVersionDir(path, true); err != nil {
+		return
+	}
+	entryName := versionEntryName(versionId, info.ModifyTime)
+	if err = v.mw.Rename_ll(parentId, name, dirIno, entryName, path, versionEntryPath(path, entryName), false); err != nil {
+		log.LogErrorf("archiveObject: meta rename fail: volume(%v) path(%v) inode(%v) version(%v) err(%v)",
+			v.name, path, inode, versionId, err)
+		return
+	}
+	deleteDentryCache(parentId, name, v.name)
+	log.LogDebugf("archiveObject: volume(%v) path(%v) inode(%v) version(%v)", v.name, path, inode, versionId)
+	return
+}
+
+// removeNullVersion permanently removes the noncurrent null version of the object if it exists.
+func (v *Volume) removeNullVersion(path string) (err error) {
+	var dirIno uint64
+	if dirIno, err = v.lookupVersionDir(path, false); err != nil {
+		if err == syscall.ENOENT || err == syscall.ENOTDIR {
+			err = nil
+		}
+		return
+	}
+	var entry *proto.Dentry
+	if entry, err = v.lookupVersionEntry(dirIno, NullVersionId); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+	return v.removeObjectEntry(dirIno, entry.Name, entry.Inode, versionEntryPath(path, entry.Name))
+}
+
+// removeObjectEntry deletes the dentry and releases the data of an object version,
+// the object lock of the version is respected.
+func (v *Volume) removeObjectEntry(parentId uint64, name string, inode uint64, path string) (err error) {
+	var objectLock *ObjectLockConfig
+	if objectLock, err = v.metaLoader.loadObjectLock(); err != nil {
+		log.LogErrorf("removeObjectEntry: load volume objectLock: volume(%v) err(%v)", v.name, err)
+		return
+	}
+	if objectLock != nil {
+		_, err = v.mw.DeleteWithCond_ll(parentId, inode, name, false, path)
+	} else {
+		_, err = v.mw.Delete_ll(parentId, name, false, path)
+	}
+	if err != nil {
+		log.LogErrorf("removeObjectEntry: meta delete fail: volume(%v) path(%v) parentID(%v) name(%v) err(%v)",
+			v.name, path, parentId, name, err)
+		return
+	}
+	if err = v.ec.EvictStream(inode); err != nil {
+		log.LogWarnf("removeObjectEntry: evict stream fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+	}
+	deleteDentryCache(parentId, name, v.name)
+	deleteAttrCache(inode, v.name)
+	if err = v.mw.Evict(inode, path); err != nil {
+		log.LogWarnf("removeObjectEntry: evict inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+	}
+	return nil
+}
+
+// createDeleteMarker places a delete marker as the latest version of the object.
+func (v *Volume) createDeleteMarker(path, versionId string) (err error) {
+	var dirIno uint64
+	if dirIno, err = v.lookupVersionDir(path, true); err != nil {
+		return
+	}
+	var info *proto.InodeInfo
+	if info, err = v.mw.InodeCreate_ll(dirIno, DefaultFileMode, 0, 0, nil, make([]uint64, 0), path); err != nil {
+		log.LogErrorf("createDeleteMarker: meta inode create fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+		return
+	}
+	defer func() {
+		if err != nil {
+			_, _ = v.mw.InodeUnlink_ll(info.Inode, path)
+			_ = v.mw.Evict(info.Inode, path)
+		}
+	}()
+	attrs := map[string]string{XAttrKeyOSSDeleteMarker: "true"}
+	if versionId != NullVersionId {
+		attrs[XAttrKeyOSSVersionId] = versionId
+	}
+	if err = v.mw.BatchSetXAttr_ll(info.Inode, attrs); err != nil {
+		log.LogErrorf("createDeleteMarker: meta set xattr fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, info.Inode, err)
+		return
+	}
+	entryName := versionEntryName(versionId, info.ModifyTime)
+	if err = v.mw.DentryCreate_ll(dirIno, entryName, info.Inode, DefaultFileMode, versionEntryPath(path, entryName)); err != nil {
+		log.LogErrorf("createDeleteMarker: meta dentry create fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, info.Inode, err)
+		return
+	}
+	return
+}
+
+// restoreLatestVersion makes the latest noncurrent version the current object
+// if the object has no current version and the latest version isn't a delete marker.
+// The version directories left empty are cleaned up.
+func (v *Volume) restoreLatestVersion(path string) (err error) {
+	var dirInodes []uint64
+	if dirInodes, err = v.lookupVersionDirs(path, false); err != nil {
+		if err == syscall.ENOENT || err == syscall.ENOTDIR {
+			err = nil
+		}
+		return
+	}
+	dirIno := dirInodes[len(dirInodes)-1]
+	var versions []proto.Dentry
+	if versions, _, err = v.readVersionDir(dirIno); err != nil {
+		return
+	}
+	if len(versions) == 0 {
+		v.cleanVersionDirs(path, dirInodes)
+		return
+	}
+	latest := versions[0]
+	var marker bool
+	if marker, err = v.isDeleteMarker(latest.Inode); err != nil || marker {
+		return
+	}
+
+	var parentId uint64
+	if parentId, err = v.recursiveMakeDirectory(path); err != nil {
+		return
+	}
+	pathItems := NewPathIterator(path).ToSlice()
+	name := pathItems[len(pathItems)-1].Name
+	if _, _, err = v.mw.Lookup_ll(parentId, name); err != syscall.ENOENT {
+		// the current object exists or an unexpected error occurred
+		return
+	}
+	if err = v.mw.Rename_ll(dirIno, latest.Name, parentId, name, versionEntryPath(path, latest.Name), path, false); err != nil {
+		log.LogErrorf("restoreLatestVersion: meta rename fail: volume(%v) path(%v) entry(%v) err(%v)",
+			v.name, path, latest.Name, err)
+		return
+	}
+	updateDentryCache(parentId, latest.Inode, DefaultFileMode, name, v.name)
+	if len(versions) == 1 {
+		v.cleanVersionDirs(path, dirInodes)
+	}
+	return
+}
+
+// cleanVersionDirs removes the empty version directories of the object from bottom to top,
+// it stops at the first directory which isn't empty.
+func (v *Volume) cleanVersionDirs(path string, dirInodes []uint64) {
+	names := versionDirNames(path)
+	for i := len(dirInodes) - 1; i > 0; i-- {
+		dirPath := strings.Join(names[:i+1], pathSep)
+		if _, err := v.mw.Delete_ll(dirInodes[i-1], names[i], true, dirPath); err != nil {
+			log.LogDebugf("cleanVersionDirs: stop: volume(%v) path(%v) dir(%v) err(%v)", v.name, path, dirPath, err)
+			return
+		}
+	}
+}
+
+// DeleteObjectVersion deletes an object in a bucket with versioning configured.
+//
+// Without version ID, the current object becomes a noncurrent version and a delete marker is placed
+// as the latest version, the version ID of the delete marker is returned. If versioning is suspended,
+// the null version is removed and the delete marker takes the null version ID.
+//
+// With version ID, the specified version is permanently removed, and the latest noncurrent version
+// becomes the current object if the removed version was the latest one.
+// Deleting a version which does not exist returns success.
+//
+// Buckets which have never been configured with versioning fall back to DeletePath.
+func (v *Volume) DeleteObjectVersion(path, versionId string) (deletedVersionId string, isDeleteMarker bool, err error) {
+	defer func() {
+		log.LogInfof("Audit: DeleteObjectVersion: volume(%v) path(%v) version(%v) deleted(%v) deleteMarker(%v) err(%v)",
+			v.name, path, versionId, deletedVersionId, isDeleteMarker, err)
+	}()
+	var versioning string
+	if versioning, err = v.versioningStatus(); err != nil {
+		return
+	}
+	if strings.HasSuffix(path, pathSep) || versioning == "" && versionId == "" {
+		err = v.DeletePath(path)
+		return
+	}
+	if versionId == "" {
+		return v.deleteWithMarker(path, versioning)
+	}
+
+	var version *objectVersion
+	if version, err = v.lookupObjectVersion(path, versionId); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return versionId, false, err
+	}
+	entryPath := path
+	if !version.isCurrent {
+		entryPath = versionEntryPath(path, version.name)
+	}
+	if err = v.removeObjectEntry(version.parentId, version.name, version.inode, entryPath); err != nil {
+		return
+	}
+	if err = v.restoreLatestVersion(path); err != nil {
+		log.LogErrorf("DeleteObjectVersion: restore latest version fail: volume(%v) path(%v) err(%v)",
+			v.name, path, err)
+		return
+	}
+	return versionId, version.isDeleteMarker, nil
+}
+
+func (v *Volume) deleteWithMarker(path, versioning string) (versionId string, isDeleteMarker bool, err error) {
+	var (
+		parentId uint64
+		inode    uint64
+		name     string
+		mode     os.FileMode
+	)
+	parentId, inode, name, mode, err = v.recursiveLookupTarget(path, false)
+	if err != nil && err != syscall.ENOENT {
+		return
+	}
+	if err == nil && mode.IsRegular() {
+		var current string
+		if current, err = v.objectVersionId(inode); err != nil {
+			return
+		}
+		if versioning == VersioningStatusSuspended && current == NullVersionId {
+			err = v.removeObjectEntry(parentId, name, inode, path)
+		} else {
+			err = v.archiveObject(parentId, name, inode, current, path)
+		}
+		if err != nil {
+			return
+		}
+	}
+	err = nil
+
+	versionId = newObjectVersionId(time.Now())
+	if versioning == VersioningStatusSuspended {
+		versionId = NullVersionId
+		if err = v.removeNullVersion(path); err != nil {
+			return
+		}
+	}
+	if err = v.createDeleteMarker(path, versionId); err != nil {
+		return
+	}
+	return versionId, true, nil
+}
+
+// ObjectVersionMeta returns the meta of the specified version of an object.
+// The returned info of a delete marker has IsDeleteMarker set and no xattr.
+func (v *Volume) ObjectVersionMeta(path, versionId string) (info *FSFileInfo, xattr *proto.XAttrInfo, err error) {
+	var version *objectVersion
+	if version, err = v.lookupObjectVersion(path, versionId); err != nil {
+		return
+	}
+	var inoInfo *proto.InodeInfo
+	if inoInfo, err = v.mw.InodeGet_ll(version.inode); err != nil {
+		log.LogErrorf("ObjectVersionMeta: get inode fail: volume(%v) path(%v) version(%v) inode(%v) err(%v)",
+			v.name, path, versionId, version.inode, err)
+		return
+	}
+	if version.isDeleteMarker {
+		info = &FSFileInfo{
+			Path:           path,
+			Mode:           os.FileMode(inoInfo.Mode),
+			CreateTime:     inoInfo.CreateTime,
+			ModifyTime:     inoInfo.ModifyTime,
+			Inode:          inoInfo.Inode,
+			VersionId:      versionId,
+			IsDeleteMarker: true,
+		}
+		return
+	}
+	if info, xattr, err = v.inodeObjectMeta(path, os.FileMode(inoInfo.Mode), inoInfo); err != nil {
+		return
+	}
+	info.VersionId = versionId
+	return
+}
+
+// LatestDeleteMarker returns the version ID of the delete marker if it is the latest version of the object.
+func (v *Volume) LatestDeleteMarker(path string) (versionId string, ok bool) {
+	if strings.HasSuffix(path, pathSep) {
+		return
+	}
+	dirIno, err := v.lookupVersionDir(path, false)
+	if err != nil {
+		return
+	}
+	versions, _, err := v.readVersionDir(dirIno)
+	if err != nil || len(versions) == 0 {
+		return
+	}
+	if marker, err := v.isDeleteMarker(versions[0].Inode); err != nil || !marker {
+		return
+	}
+	return versionIdFromEntryName(versions[0].Name), true
+}
+
+// ListObjectVersions lists the versions of objects in the order of object key, and from the newest
+// to the oldest for the versions of the same key.
+// The keys of current objects and the keys in the version tree are listed separately and merged.
+func (v *Volume) ListObjectVersions(opt *ListObjectVersionsOption) (result *ListObjectVersionsResult, err error) {
+	result = &ListObjectVersionsResult{}
+	if opt.MaxKeys == 0 {
+		return
+	}
+
+	var (
+		current     []*FSFileInfo
+		curPrefixes Prefixes
+		curNext     string
+	)
+	current, curPrefixes, curNext, err = v.listFilesV1(opt.Prefix, opt.KeyMarker, opt.Delimiter, opt.MaxKeys, true)
+	if err != nil {
+		log.LogErrorf("ListObjectVersions: list current objects fail: volume(%v) opt(%+v) err(%v)", v.name, opt, err)
+		return
+	}
+	var (
+		verKeys     []string
+		verPrefixes []string
+		verNext     string
+	)
+	verKeys, verPrefixes, verNext, err = v.listVersionedKeys(opt.Prefix, opt.KeyMarker, opt.Delimiter, opt.MaxKeys)
+	if err != nil {
+		log.LogErrorf("ListObjectVersions: list versioned keys fail: volume(%v) opt(%+v) err(%v)", v.name, opt, err)
+		return
+	}
+	if err = v.supplyVersionInfo(current); err != nil {
+		return
+	}
+
+	// Both listings are limited by max keys, keys after the first unlisted key
+	// of either one are left to the next page.
+	boundary := curNext
+	if verNext != "" && (boundary == "" || objectKeyLess(verNext, boundary)) {
+		boundary = verNext
+	}
+	currentMap := make(map[string]*FSFileInfo, len(current))
+	items := make(map[string]bool) // key -> is common prefix
+	for _, info := range current {
+		currentMap[info.Path] = info
+		items[info.Path] = false
+	}
+	for _, key := range verKeys {
+		items[key] = false
+	}
+	for _, prefix := range append(curPrefixes, verPrefixes...) {
+		items[prefix] = true
+	}
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		if opt.KeyMarker != "" && !objectKeyLess(opt.KeyMarker, key) {
+			continue
+		}
+		if boundary != "" && !objectKeyLess(key, boundary) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return objectKeyLess(keys[i], keys[j]) })
+
+	var count uint64
+	var lastKey, lastVersionId string
+	emit := func(key string, version *FSObjectVersion) bool {
+		if count >= opt.MaxKeys {
+			result.Truncated = true
+			result.NextKeyMarker = lastKey
+			result.NextVersionIdMarker = lastVersionId
+			return false
+		}
+		if version != nil {
+			result.Versions = append(result.Versions, version)
+			lastVersionId = version.VersionId
+		} else {
+			result.CommonPrefixes = append(result.CommonPrefixes, key)
+			lastVersionId = ""
+		}
+		lastKey = key
+		count++
+		return true
+	}
+
+	// the remaining versions of the key marker
+	if opt.KeyMarker != "" && opt.VersionIdMarker != "" {
+		var versions []*FSObjectVersion
+		if versions, err = v.objectVersions(opt.KeyMarker, nil, true); err != nil {
+			return
+		}
+		for i, version := range versions {
+			if version.VersionId != opt.VersionIdMarker {
+				continue
+			}
+			for _, rest := range versions[i+1:] {
+				if !emit(opt.KeyMarker, rest) {
+					return
+				}
+			}
+			break
+		}
+	}
+	for _, key := range keys {
+		if items[key] {
+			if !emit(key, nil) {
+				return
+			}
+			continue
+		}
+		var versions []*FSObjectVersion
+		if versions, err = v.objectVersions(key, currentMap[key], false); err != nil {
+			return
+		}
+		for _, version := range versions {
+			if !emit(key, version) {
+				return
+			}
+		}
+	}
+	if boundary != "" {
+		result.Truncated = true
+		result.NextKeyMarker = lastKey
+		result.NextVersionIdMarker = lastVersionId
+	}
+	return
+}
+
+// objectVersions returns all versions of the object from the newest to the oldest.
+func (v *Volume) objectVersions(key string, current *FSFileInfo, lookupCurrent bool) (versions []*FSObjectVersion, err error) {
+	if current == nil && lookupCurrent {
+		_, inode, _, mode, lookupErr := v.recursiveLookupTarget(key, false)
+		if lookupErr != nil && lookupErr != syscall.ENOENT {
+			return nil, lookupErr
+		}
+		if lookupErr == nil && mode.IsRegular() {
+			current = &FSFileInfo{Path: key, Inode: inode}
+			if err = v.supplyListFileInfo([]*FSFileInfo{current}); err != nil {
+				return
+			}
+			if err = v.supplyVersionInfo([]*FSFileInfo{current}); err != nil {
+				return
+			}
+		}
+	}
+	if current != nil {
+		versions = append(versions, &FSObjectVersion{FSFileInfo: current, IsLatest: true})
+	}
+
+	var dirIno uint64
+	if dirIno, err = v.lookupVersionDir(key, false); err != nil {
+		if err == syscall.ENOENT || err == syscall.ENOTDIR {
+			err = nil
+		}
+		return
+	}
+	var entries []proto.Dentry
+	if entries, _, err = v.readVersionDir(dirIno); err != nil || len(entries) == 0 {
+		return
+	}
+	infos := make([]*FSFileInfo, 0, len(entries))
+	for _, entry := range entries {
+		infos = append(infos, &FSFileInfo{Path: key, Inode: entry.Inode})
+	}
+	if err = v.supplyVersionInfo(infos); err != nil {
+		return
+	}
+	objects := make([]*FSFileInfo, 0, len(infos))
+	markers := make([]uint64, 0)
+	for _, info := range infos {
+		if info.IsDeleteMarker {
+			markers = append(markers, info.Inode)
+		} else {
+			objects = append(objects, info)
+		}
+	}
+	if err = v.supplyListFileInfo(objects); err != nil {
+		return
+	}
+	if len(markers) > 0 {
+		modifyTimes := make(map[uint64]time.Time, len(markers))
+		for _, inoInfo := range v.mw.BatchInodeGet(markers) {
+			modifyTimes[inoInfo.Inode] = inoInfo.ModifyTime
+		}
+		for _, info := range infos {
+			if info.IsDeleteMarker {
+				info.ModifyTime = modifyTimes[info.Inode]
+			}
+		}
+	}
+	for i, info := range infos {
+		versions = append(versions, &FSObjectVersion{FSFileInfo: info, IsLatest: current == nil && i == 0})
+	}
+	return
+}
+
+// supplyVersionInfo supplements the version ID and delete marker flag of the file infos.
+func (v *Volume) supplyVersionInfo(fileInfos []*FSFileInfo) (err error) {
+	if len(fileInfos) == 0 {
+		return
+	}
+	inodes := make([]uint64, 0, len(fileInfos))
+	for _, fileInfo := range fileInfos {
+		inodes = append(inodes, fileInfo.Inode)
+	}
+	var xattrs []*proto.XAttrInfo
+	if xattrs, err = v.mw.BatchGetXAttr(inodes, []string{XAttrKeyOSSVersionId, XAttrKeyOSSDeleteMarker}); err != nil {
+		log.LogErrorf("supplyVersionInfo: batch get xattr fail: volume(%v) inodes(%v) err(%v)", v.name, inodes, err)
+		return
+	}
+	xattrMap := make(map[uint64]*proto.XAttrInfo, len(xattrs))
+	for _, xattr := range xattrs {
+		xattrMap[xattr.Inode] = xattr
+	}
+	for _, fileInfo := range fileInfos {
+		fileInfo.VersionId = NullVersionId
+		if xattr, ok := xattrMap[fileInfo.Inode]; ok {
+			if versionId := string(xattr.Get(XAttrKeyOSSVersionId)); versionId != "" {
+				fileInfo.VersionId = versionId
+			}
+			fileInfo.IsDeleteMarker = len(xattr.Get(XAttrKeyOSSDeleteMarker)) > 0
+		}
+	}
+	return
+}
+
+// listVersionedKeys walks the version tree and lists the object keys which own versions,
+// in the same order as listFilesV1. The next key is returned if the listing is truncated.
+func (v *Volume) listVersionedKeys(prefix, marker, delimiter string, maxKeys uint64) (keys, prefixes []string,
+	next string, err error) {
+	var rootId uint64
+	if rootId, _, err = v.mw.Lookup_ll(rootIno, ObjectVersionRootDir); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+
+	prefixMap := PrefixMap(make(map[string]struct{}))
+	add := func(key string) bool {
+		if uint64(len(keys)+len(prefixMap)) >= maxKeys {
+			next = key
+			return false
+		}
+		if commonPrefix := objectCommonPrefix(key, prefix, delimiter); commonPrefix != "" {
+			prefixMap.AddPrefix(commonPrefix)
+		} else {
+			keys = append(keys, key)
+		}
+		return true
+	}
+
+	// walk returns false when the listing reaches max keys
+	var walk func(inode uint64, key, collapsed string) (bool, error)
+	walk = func(inode uint64, key, collapsed string) (bool, error) {
+		versions, subDirs, err := v.readVersionDir(inode)
+		if err != nil {
+			return false, err
+		}
+		if key != "" && len(versions) > 0 && strings.HasPrefix(key, prefix) && (marker == "" || objectKeyLess(marker, key)) {
+			if commonPrefix := objectCommonPrefix(key, prefix, delimiter); commonPrefix == "" || !prefixMap.contain(commonPrefix) {
+				if !add(key) {
+					return false, nil
+				}
+			}
+		}
+		for _, dir := range subDirs {
+			// all keys in a collapsed subtree share the same common prefix
+			if collapsed != "" && prefixMap.contain(collapsed) {
+				return true, nil
+			}
+			childKey := dir.Name
+			if key != "" {
+				childKey = key + pathSep + dir.Name
+			}
+			if !strings.HasPrefix(childKey, prefix) && !strings.HasPrefix(prefix, childKey) {
+				continue
+			}
+			// the keys of a subtree are contiguous in order
+			if marker != "" && objectKeyLess(childKey, marker) && !strings.HasPrefix(marker, childKey+pathSep) {
+				continue
+			}
+			childCollapsed := collapsed
+			if childCollapsed == "" {
+				childCollapsed = objectCommonPrefix(childKey, prefix, delimiter)
+			}
+			if childCollapsed != "" && prefixMap.contain(childCollapsed) {
+				continue
+			}
+			ok, err := walk(dir.Inode, childKey, childCollapsed)
+			if err != nil || !ok {
+				return ok, err
+			}
+		}
+		return true, nil
+	}
+	if _, err = walk(rootId, "", ""); err != nil {
+		log.LogErrorf("listVersionedKeys: walk version tree fail: volume(%v) prefix(%v) marker(%v) err(%v)",
+			v.name, prefix, marker, err)
+		return
+	}
+	prefixes = prefixMap.Prefixes()
+	return
+}
@@ -18,6 +18,8 @@ import (
 	"encoding/xml"
 	"net/http"
 	"time"
+
+	"github.com/cubefs/cubefs/proto"
 )
 
 const (
@@ -39,6 +41,11 @@ var (
 	LifeCycleErrSameRuleID       = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "Rule ID must be unique. Found same ID for more than one rule.", StatusCode: http.StatusBadRequest}
 	LifeCycleErrDateType         = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "'Date' must be at midnight GMT.", StatusCode: http.StatusBadRequest}
 	LifeCycleErrDaysType         = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "'Days' for Expiration action must be a positive integer.", StatusCode: http.StatusBadRequest}
+	LifeCycleErrTransitionDays   = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "'Days' for Transition action must be a nonnegative integer.", StatusCode: http.StatusBadRequest}
+	LifeCycleErrStorageClass     = &ErrorCode{ErrorCode: "InvalidStorageClass", ErrorMessage: "The storage class you specified is not valid.", StatusCode: http.StatusBadRequest}
+	LifeCycleErrSameStorageClass = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "'StorageClass' must be different for 'Transition' actions in same 'Rule'.", StatusCode: http.StatusBadRequest}
+	LifeCycleErrMixedDateDays    = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "Found mixed 'Date' and 'Days' based Expiration and Transition actions in lifecycle rule.", StatusCode: http.StatusBadRequest}
+	LifeCycleErrTransitionAfter  = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "'Days' or 'Date' in the Expiration action must be greater than that in the Transition action.", StatusCode: http.StatusBadRequest}
 	LifeCycleErrMalformedXML     = &ErrorCode{ErrorCode: "MalformedXML", ErrorMessage: "The XML you provided was not well-formed or did not validate against our published schema.", StatusCode: http.StatusBadRequest}
 	NoSuchLifecycleConfiguration = &ErrorCode{ErrorCode: "NoSuchLifecycleConfiguration", ErrorMessage: "The lifecycle configuration does not exist.", StatusCode: http.StatusNotFound}
 )
@@ -50,11 +57,12 @@ type LifeCycle struct {
 }
 
 type Rule struct {
-	XMLName xml.Name    `xml:"Rule"`
-	Expire  *Expiration `xml:"Expiration"`
-	Filter  *Filter     `xml:"Filter"`
-	ID      string      `xml:"ID"`
-	Status  string      `xml:"Status"`
+	XMLName     xml.Name      `xml:"Rule"`
+	Expire      *Expiration   `xml:"Expiration"`
+	Transitions []*Transition `xml:"Transition,omitempty"`
+	Filter      *Filter       `xml:"Filter"`
+	ID          string        `xml:"ID"`
+	Status      string        `xml:"Status"`
 }
 
 type Expiration struct {
@@ -63,6 +71,13 @@ type Expiration struct {
 	Days    *int       `xml:"Days,omitempty"`
 }
 
+type Transition struct {
+	XMLName      xml.Name   `xml:"Transition"`
+	Date         *time.Time `xml:"Date,omitempty"`
+	Days         *int       `xml:"Days,omitempty"`
+	StorageClass string     `xml:"StorageClass"`
+}
+
 type Filter struct {
 	XMLName xml.Name `xml:"Filter"`
 	Prefix  string   `xml:"Prefix,omitempty"`
@@ -109,12 +124,38 @@ func (r *Rule) valid() *ErrorCode {
 		return LifeCycleErrMalformedXML
 	}
 
-	if r.Expire == nil {
+	if r.Expire == nil && len(r.Transitions) == 0 {
 		return LifeCycleErrMissingActions
 	}
 
-	if err := r.Expire.validExpiration(); err != nil {
-		return err
+	if r.Expire != nil {
+		if err := r.Expire.validExpiration(); err != nil {
+			return err
+		}
+	}
+
+	storageClasses := make(map[string]bool)
+	for _, t := range r.Transitions {
+		if err := t.validTransition(); err != nil {
+			return err
+		}
+		if storageClasses[t.StorageClass] {
+			return LifeCycleErrSameStorageClass
+		}
+		storageClasses[t.StorageClass] = true
+		if r.Expire == nil {
+			continue
+		}
+		// objects must be transitioned before they expire
+		if (r.Expire.Date != nil) != (t.Date != nil) {
+			return LifeCycleErrMixedDateDays
+		}
+		if t.Date != nil && !r.Expire.Date.After(*t.Date) {
+			return LifeCycleErrTransitionAfter
+		}
+		if t.Days != nil && *r.Expire.Days <= *t.Days {
+			return LifeCycleErrTransitionAfter
+		}
 	}
 
 	return nil
@@ -144,3 +185,30 @@ func (e *Expiration) validExpiration() *ErrorCode {
 
 	return nil
 }
+
+// isTransitioned checks whether the data of the object has been moved to the blobstore.
+func isTransitioned(storageClass string) bool {
+	return storageClass != "" && storageClass != StorageClassStandard
+}
+
+func (t *Transition) validTransition() *ErrorCode {
+	if t.Date != nil && t.Days != nil {
+		return LifeCycleErrMalformedXML
+	}
+	if t.Date == nil && t.Days == nil {
+		return LifeCycleErrMalformedXML
+	}
+	if t.Date != nil {
+		date := t.Date.In(time.UTC)
+		if !(date.Hour() == 0 && date.Minute() == 0 && date.Second() == 0 && date.Nanosecond() == 0) {
+			return LifeCycleErrDateType
+		}
+	} else if *t.Days < 0 {
+		return LifeCycleErrTransitionDays
+	}
+	if !proto.IsValidTransitionStorageClass(t.StorageClass) {
+		return LifeCycleErrStorageClass
+	}
+
+	return nil
+}
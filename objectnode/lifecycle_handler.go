@@ -65,6 +65,17 @@ func (o *ObjectNode) getBucketLifecycleConfigurationHandler(w http.ResponseWrite
 				rule.Expire.Days = &lc.Expire.Days
 			}
 		}
+		for _, lt := range lc.Transitions {
+			transition := &Transition{
+				Date:         lt.Date,
+				StorageClass: lt.StorageClass,
+			}
+			if lt.Date == nil {
+				days := lt.Days
+				transition.Days = &days
+			}
+			rule.Transitions = append(rule.Transitions, transition)
+		}
 		if lc.Filter != nil {
 			rule.Filter = &Filter{
 				Prefix: lc.Filter.Prefix,
@@ -151,6 +162,16 @@ func (o *ObjectNode) putBucketLifecycleConfigurationHandler(w http.ResponseWrite
 				rule.Expire.Days = *lr.Expire.Days
 			}
 		}
+		for _, t := range lr.Transitions {
+			transition := &proto.TransitionConfig{
+				Date:         t.Date,
+				StorageClass: t.StorageClass,
+			}
+			if t.Days != nil {
+				transition.Days = *t.Days
+			}
+			rule.Transitions = append(rule.Transitions, transition)
+		}
 		if lr.Filter != nil {
 			rule.Filter = &proto.FilterConfig{
 				Prefix: lr.Filter.Prefix,
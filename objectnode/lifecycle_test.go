@@ -124,3 +124,74 @@ func TestLifecycleConfiguration(t *testing.T) {
 	_, err = l1.Validate()
 	require.Equal(t, err, LifeCycleErrMissingRules)
 }
+
+func TestLifecycleTransition(t *testing.T) {
+	LifecycleXml := `
+<LifecycleConfiguration>
+    <Rule>
+        <ID>id1</ID>
+        <Status>Enabled</Status>
+        <Transition>
+           <Days>30</Days>
+           <StorageClass>GLACIER_IR</StorageClass>
+        </Transition>
+    </Rule>
+</LifecycleConfiguration>
+`
+
+	l1 := NewLifeCycle()
+	err := xml.Unmarshal([]byte(LifecycleXml), l1)
+	require.NoError(t, err)
+	require.Len(t, l1.Rules[0].Transitions, 1)
+
+	// transition only
+	ok, _ := l1.Validate()
+	require.Equal(t, true, ok)
+
+	// invalid storage class
+	l1.Rules[0].Transitions[0].StorageClass = "STANDARD"
+	_, err = l1.Validate()
+	require.Equal(t, err, LifeCycleErrStorageClass)
+	l1.Rules[0].Transitions[0].StorageClass = "GLACIER_IR"
+
+	// days < 0
+	day := -1
+	l1.Rules[0].Transitions[0].Days = &day
+	_, err = l1.Validate()
+	require.Equal(t, err, LifeCycleErrTransitionDays)
+	day = 0
+	ok, _ = l1.Validate()
+	require.Equal(t, true, ok)
+
+	// same storage class
+	l1.Rules[0].Transitions = append(l1.Rules[0].Transitions, &Transition{Days: &day, StorageClass: "GLACIER_IR"})
+	_, err = l1.Validate()
+	require.Equal(t, err, LifeCycleErrSameStorageClass)
+	l1.Rules[0].Transitions = l1.Rules[0].Transitions[:1]
+
+	// expiration must be after transition
+	day = 30
+	expireDay := 30
+	l1.Rules[0].Expire = &Expiration{Days: &expireDay}
+	_, err = l1.Validate()
+	require.Equal(t, err, LifeCycleErrTransitionAfter)
+	expireDay = 31
+	ok, _ = l1.Validate()
+	require.Equal(t, true, ok)
+
+	// mixed date and days
+	now := time.Now().In(time.UTC)
+	ti := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	l1.Rules[0].Expire = &Expiration{Date: &ti}
+	_, err = l1.Validate()
+	require.Equal(t, err, LifeCycleErrMixedDateDays)
+
+	l1.Rules[0].Transitions[0].Days = nil
+	l1.Rules[0].Transitions[0].Date = &ti
+	_, err = l1.Validate()
+	require.Equal(t, err, LifeCycleErrTransitionAfter)
+	expireDate := ti.AddDate(0, 0, 1)
+	l1.Rules[0].Expire.Date = &expireDate
+	ok, _ = l1.Validate()
+	require.Equal(t, true, ok)
+}
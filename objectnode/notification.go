@@ -0,0 +1,250 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/NotificationHowTo.html
+
+const (
+	EventObjectCreatedAll                     = "s3:ObjectCreated:*"
+	EventObjectCreatedPut                     = "s3:ObjectCreated:Put"
+	EventObjectCreatedPost                    = "s3:ObjectCreated:Post"
+	EventObjectCreatedCopy                    = "s3:ObjectCreated:Copy"
+	EventObjectCreatedCompleteMultipartUpload = "s3:ObjectCreated:CompleteMultipartUpload"
+	EventObjectRemovedAll                     = "s3:ObjectRemoved:*"
+	EventObjectRemovedDelete                  = "s3:ObjectRemoved:Delete"
+	EventObjectRemovedDeleteMarkerCreated     = "s3:ObjectRemoved:DeleteMarkerCreated"
+
+	FilterRuleNamePrefix = "prefix"
+	FilterRuleNameSuffix = "suffix"
+
+	// The ARN of a notification destination is made up of the ID and the type of the target
+	// configured on the objectnode, e.g. arn:cubefs:sqs::ingest:kafka.
+	EventTargetARNPrefix = "arn:cubefs:sqs::"
+	EventTargetKafka     = "kafka"
+	EventTargetWebhook   = "webhook"
+
+	MaxNotificationConfigSize = 1 << 20 // 1MB
+	MaxFilterRuleValueLength  = 1024
+	MaxNotificationIdLength   = 255
+)
+
+var (
+	InvalidNotificationDestination = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "Unable to validate the following destination configurations.", StatusCode: http.StatusBadRequest}
+	InvalidNotificationEvent       = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The event is not supported for notifications.", StatusCode: http.StatusBadRequest}
+	InvalidNotificationFilter      = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The filter rules of the notification configuration are invalid.", StatusCode: http.StatusBadRequest}
+	InvalidNotificationId          = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The ID of the notification configuration is invalid or duplicated.", StatusCode: http.StatusBadRequest}
+	OverlappingNotification        = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "Configurations overlap. Configurations on the same bucket cannot share a common event type.", StatusCode: http.StatusBadRequest}
+)
+
+var supportedEvents = map[string]bool{
+	EventObjectCreatedAll:                     true,
+	EventObjectCreatedPut:                     true,
+	EventObjectCreatedPost:                    true,
+	EventObjectCreatedCopy:                    true,
+	EventObjectCreatedCompleteMultipartUpload: true,
+	EventObjectRemovedAll:                     true,
+	EventObjectRemovedDelete:                  true,
+	EventObjectRemovedDeleteMarkerCreated:     true,
+}
+
+type NotificationConfiguration struct {
+	XMLNS   string   `xml:"xmlns,attr,omitempty" json:"-"`
+	XMLName xml.Name `xml:"NotificationConfiguration" json:"-"`
+
+	QueueConfigurations []*QueueConfiguration `xml:"QueueConfiguration,omitempty" json:"queues,omitempty"`
+
+	// Only queue destinations are supported, topic and lambda configurations are
+	// parsed to be rejected rather than ignored silently.
+	TopicConfigurations    []struct{} `xml:"TopicConfiguration" json:"-"`
+	FunctionConfigurations []struct{} `xml:"CloudFunctionConfiguration" json:"-"`
+}
+
+type QueueConfiguration struct {
+	Id     string              `xml:"Id" json:"id"`
+	Queue  string              `xml:"Queue" json:"queue"`
+	Events []string            `xml:"Event" json:"events"`
+	Filter *NotificationFilter `xml:"Filter,omitempty" json:"filter,omitempty"`
+}
+
+type NotificationFilter struct {
+	S3Key struct {
+		FilterRules []*FilterRule `xml:"FilterRule" json:"rules"`
+	} `xml:"S3Key" json:"key"`
+}
+
+type FilterRule struct {
+	Name  string `xml:"Name" json:"name"`
+	Value string `xml:"Value" json:"value"`
+}
+
+func (c *NotificationConfiguration) IsEmpty() bool {
+	return c == nil || len(c.QueueConfigurations) == 0
+}
+
+// Validate checks the configuration, the destinations must exist in the targets.
+func (c *NotificationConfiguration) Validate(targets map[string]bool) error {
+	if len(c.TopicConfigurations) > 0 || len(c.FunctionConfigurations) > 0 {
+		return InvalidNotificationDestination
+	}
+	ids := make(map[string]bool)
+	for _, qc := range c.QueueConfigurations {
+		if qc.Id == "" {
+			qc.Id = uuid.New().String()
+		}
+		if len(qc.Id) > MaxNotificationIdLength || ids[qc.Id] {
+			return InvalidNotificationId
+		}
+		ids[qc.Id] = true
+		if !targets[qc.Queue] {
+			return InvalidNotificationDestination
+		}
+		if len(qc.Events) == 0 {
+			return InvalidNotificationEvent
+		}
+		for _, event := range qc.Events {
+			if !supportedEvents[event] {
+				return InvalidNotificationEvent
+			}
+		}
+		if err := qc.Filter.validate(); err != nil {
+			return err
+		}
+	}
+
+	// an event of an object must not be delivered to the same destination twice
+	for i, a := range c.QueueConfigurations {
+		for _, b := range c.QueueConfigurations[i+1:] {
+			if a.Queue == b.Queue && a.overlaps(b) {
+				return OverlappingNotification
+			}
+		}
+	}
+	return nil
+}
+
+// Match checks whether the event of the object key should be delivered.
+func (qc *QueueConfiguration) Match(eventName, key string) bool {
+	matched := false
+	for _, event := range qc.Events {
+		if eventMatch(event, eventName) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+	prefix, suffix := qc.Filter.rules()
+	return strings.HasPrefix(key, prefix) && strings.HasSuffix(key, suffix)
+}
+
+func (qc *QueueConfiguration) overlaps(other *QueueConfiguration) bool {
+	eventOverlaps := false
+	for _, a := range qc.Events {
+		for _, b := range other.Events {
+			if eventMatch(a, b) || eventMatch(b, a) {
+				eventOverlaps = true
+			}
+		}
+	}
+	if !eventOverlaps {
+		return false
+	}
+	// the key ranges overlap if either prefix contains the other, and so do the suffixes
+	p1, s1 := qc.Filter.rules()
+	p2, s2 := other.Filter.rules()
+	return (strings.HasPrefix(p1, p2) || strings.HasPrefix(p2, p1)) &&
+		(strings.HasSuffix(s1, s2) || strings.HasSuffix(s2, s1))
+}
+
+func (f *NotificationFilter) validate() error {
+	if f == nil {
+		return nil
+	}
+	names := make(map[string]bool)
+	for _, rule := range f.S3Key.FilterRules {
+		rule.Name = strings.ToLower(rule.Name)
+		if rule.Name != FilterRuleNamePrefix && rule.Name != FilterRuleNameSuffix {
+			return InvalidNotificationFilter
+		}
+		if names[rule.Name] || len(rule.Value) > MaxFilterRuleValueLength {
+			return InvalidNotificationFilter
+		}
+		names[rule.Name] = true
+	}
+	return nil
+}
+
+func (f *NotificationFilter) rules() (prefix, suffix string) {
+	if f == nil {
+		return
+	}
+	for _, rule := range f.S3Key.FilterRules {
+		switch rule.Name {
+		case FilterRuleNamePrefix:
+			prefix = rule.Value
+		case FilterRuleNameSuffix:
+			suffix = rule.Value
+		}
+	}
+	return
+}
+
+// eventMatch checks whether the configured event covers the event name, wildcards
+// only appear at the end of the configured event.
+func eventMatch(configured, eventName string) bool {
+	if strings.HasSuffix(configured, "*") {
+		return strings.HasPrefix(eventName, strings.TrimSuffix(configured, "*"))
+	}
+	return configured == eventName
+}
+
+func ParseNotificationConfigFromXML(data []byte) (*NotificationConfiguration, error) {
+	config := &NotificationConfiguration{}
+	if err := xml.Unmarshal(data, config); err != nil {
+		return nil, MalformedXML
+	}
+	return config, nil
+}
+
+func storeBucketNotification(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSNotification, bytes)
+}
+
+func deleteBucketNotification(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSNotification)
+}
+
+func eventTargetARN(id, targetType string) string {
+	return fmt.Sprintf("%s%s:%s", EventTargetARNPrefix, id, targetType)
+}
+
+// removedEventName returns the event of deleting an object, a delete marker is created
+// only if the version is not specified in a versioned bucket.
+func removedEventName(versionId string, isDeleteMarker bool) string {
+	if versionId == "" && isDeleteMarker {
+		return EventObjectRemovedDeleteMarkerCreated
+	}
+	return EventObjectRemovedDelete
+}
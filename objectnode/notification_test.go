@@ -0,0 +1,249 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNotificationConfig(t *testing.T) {
+	arn := eventTargetARN("ingest", EventTargetKafka)
+	targets := map[string]bool{arn: true}
+	tests := []struct {
+		value       string
+		expectedErr error
+	}{
+		{
+			value: `<NotificationConfiguration>
+						<QueueConfiguration>
+							<Id>images</Id>
+							<Queue>arn:cubefs:sqs::ingest:kafka</Queue>
+							<Event>s3:ObjectCreated:*</Event>
+							<Filter><S3Key>
+								<FilterRule><Name>Prefix</Name><Value>images/</Value></FilterRule>
+								<FilterRule><Name>suffix</Name><Value>.jpg</Value></FilterRule>
+							</S3Key></Filter>
+						</QueueConfiguration>
+						<QueueConfiguration>
+							<Queue>arn:cubefs:sqs::ingest:kafka</Queue>
+							<Event>s3:ObjectCreated:Put</Event>
+							<Filter><S3Key>
+								<FilterRule><Name>prefix</Name><Value>videos/</Value></FilterRule>
+							</S3Key></Filter>
+						</QueueConfiguration>
+					</NotificationConfiguration>`,
+		},
+		{
+			value:       `<NotificationConfiguration></NotificationConfiguration>`,
+			expectedErr: nil,
+		},
+		{
+			value: `<NotificationConfiguration><QueueConfiguration>
+						<Queue>arn:cubefs:sqs::other:kafka</Queue>
+						<Event>s3:ObjectCreated:*</Event>
+					</QueueConfiguration></NotificationConfiguration>`,
+			expectedErr: InvalidNotificationDestination,
+		},
+		{
+			value: `<NotificationConfiguration><TopicConfiguration>
+						<Topic>arn:aws:sns:us-east-1:123456789012:topic</Topic>
+						<Event>s3:ObjectCreated:*</Event>
+					</TopicConfiguration></NotificationConfiguration>`,
+			expectedErr: InvalidNotificationDestination,
+		},
+		{
+			value: `<NotificationConfiguration><QueueConfiguration>
+						<Queue>arn:cubefs:sqs::ingest:kafka</Queue>
+						<Event>s3:ObjectRestore:*</Event>
+					</QueueConfiguration></NotificationConfiguration>`,
+			expectedErr: InvalidNotificationEvent,
+		},
+		{
+			value: `<NotificationConfiguration><QueueConfiguration>
+						<Queue>arn:cubefs:sqs::ingest:kafka</Queue>
+						<Event>s3:ObjectCreated:*</Event>
+						<Filter><S3Key>
+							<FilterRule><Name>prefix</Name><Value>a/</Value></FilterRule>
+							<FilterRule><Name>prefix</Name><Value>b/</Value></FilterRule>
+						</S3Key></Filter>
+					</QueueConfiguration></NotificationConfiguration>`,
+			expectedErr: InvalidNotificationFilter,
+		},
+		{
+			value: `<NotificationConfiguration>
+						<QueueConfiguration>
+							<Id>a</Id>
+							<Queue>arn:cubefs:sqs::ingest:kafka</Queue>
+							<Event>s3:ObjectRemoved:*</Event>
+						</QueueConfiguration>
+						<QueueConfiguration>
+							<Id>a</Id>
+							<Queue>arn:cubefs:sqs::ingest:kafka</Queue>
+							<Event>s3:ObjectCreated:*</Event>
+						</QueueConfiguration>
+					</NotificationConfiguration>`,
+			expectedErr: InvalidNotificationId,
+		},
+		{
+			value: `<NotificationConfiguration>
+						<QueueConfiguration>
+							<Queue>arn:cubefs:sqs::ingest:kafka</Queue>
+							<Event>s3:ObjectCreated:*</Event>
+							<Filter><S3Key><FilterRule><Name>prefix</Name><Value>a/</Value></FilterRule></S3Key></Filter>
+						</QueueConfiguration>
+						<QueueConfiguration>
+							<Queue>arn:cubefs:sqs::ingest:kafka</Queue>
+							<Event>s3:ObjectCreated:Copy</Event>
+							<Filter><S3Key><FilterRule><Name>prefix</Name><Value>a/b/</Value></FilterRule></S3Key></Filter>
+						</QueueConfiguration>
+					</NotificationConfiguration>`,
+			expectedErr: OverlappingNotification,
+		},
+		{
+			value:       `<NotificationConfiguration><QueueConfiguration>`,
+			expectedErr: MalformedXML,
+		},
+	}
+	for _, tt := range tests {
+		config, err := ParseNotificationConfigFromXML([]byte(tt.value))
+		if err == nil {
+			err = config.Validate(targets)
+		}
+		require.Equal(t, tt.expectedErr, err)
+	}
+}
+
+func TestNotificationMatch(t *testing.T) {
+	config, err := ParseNotificationConfigFromXML([]byte(`<NotificationConfiguration><QueueConfiguration>
+				<Queue>arn:cubefs:sqs::ingest:webhook</Queue>
+				<Event>s3:ObjectCreated:*</Event>
+				<Event>s3:ObjectRemoved:DeleteMarkerCreated</Event>
+				<Filter><S3Key>
+					<FilterRule><Name>prefix</Name><Value>images/</Value></FilterRule>
+					<FilterRule><Name>suffix</Name><Value>.jpg</Value></FilterRule>
+				</S3Key></Filter>
+			</QueueConfiguration></NotificationConfiguration>`))
+	require.NoError(t, err)
+	require.NoError(t, config.Validate(map[string]bool{eventTargetARN("ingest", EventTargetWebhook): true}))
+	qc := config.QueueConfigurations[0]
+	require.NotEmpty(t, qc.Id)
+
+	require.True(t, qc.Match(EventObjectCreatedPut, "images/a.jpg"))
+	require.True(t, qc.Match(EventObjectCreatedCompleteMultipartUpload, "images/b/c.jpg"))
+	require.True(t, qc.Match(EventObjectRemovedDeleteMarkerCreated, "images/a.jpg"))
+	require.False(t, qc.Match(EventObjectRemovedDelete, "images/a.jpg"))
+	require.False(t, qc.Match(EventObjectCreatedPut, "videos/a.jpg"))
+	require.False(t, qc.Match(EventObjectCreatedPut, "images/a.png"))
+
+	require.Equal(t, EventObjectRemovedDeleteMarkerCreated, removedEventName("", true))
+	require.Equal(t, EventObjectRemovedDelete, removedEventName("", false))
+	require.Equal(t, EventObjectRemovedDelete, removedEventName(NullVersionId, true))
+}
+
+func TestEventQueue(t *testing.T) {
+	dir := t.TempDir()
+	q, err := newEventQueue(dir, 3)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, q.put([]byte{byte(i)}))
+	}
+	require.Equal(t, ErrEventQueueFull, q.put([]byte{3}))
+
+	names, err := q.list()
+	require.NoError(t, err)
+	require.Len(t, names, 3)
+	for i, name := range names {
+		data, err := q.get(name)
+		require.NoError(t, err)
+		require.Equal(t, []byte{byte(i)}, data)
+	}
+	require.NoError(t, q.remove(names[0]))
+	require.NoError(t, q.remove(names[0]))
+	require.Equal(t, 2, q.len())
+
+	// the queued events are loaded after restart
+	q, err = newEventQueue(dir, 3)
+	require.NoError(t, err)
+	require.Equal(t, 2, q.len())
+	require.NoError(t, q.put([]byte{3}))
+	require.Equal(t, ErrEventQueueFull, q.put([]byte{4}))
+}
+
+type mockEventTarget struct {
+	arn      string
+	failures int
+
+	mu       sync.Mutex
+	received [][]byte
+}
+
+func (m *mockEventTarget) ARN() string {
+	return m.arn
+}
+
+func (m *mockEventTarget) Send(data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failures > 0 {
+		m.failures--
+		return errors.New("mock send failure")
+	}
+	m.received = append(m.received, data)
+	return nil
+}
+
+func (m *mockEventTarget) Close() error {
+	return nil
+}
+
+func (m *mockEventTarget) messages() [][]byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.received
+}
+
+func TestEventNotifier(t *testing.T) {
+	// the first round of retries fails, the event is delivered in the next round
+	target := &mockEventTarget{arn: eventTargetARN("ingest", EventTargetWebhook), failures: 3}
+	notifier, err := newEventNotifier(t.TempDir(), 10, 100*time.Millisecond, target)
+	require.NoError(t, err)
+	defer notifier.Close()
+	require.Equal(t, map[string]bool{target.arn: true}, notifier.Targets())
+
+	config := &NotificationConfiguration{QueueConfigurations: []*QueueConfiguration{
+		{Id: "all", Queue: target.arn, Events: []string{EventObjectCreatedAll}},
+	}}
+	record := &EventRecord{EventName: EventObjectCreatedPut}
+	record.S3.Bucket.Name = "bucket"
+	record.S3.Object.Key = "a/b"
+	notifier.Notify(config, record)
+	record.EventName = EventObjectRemovedDelete
+	notifier.Notify(config, record)
+
+	require.Eventually(t, func() bool { return len(target.messages()) == 1 }, 5*time.Second, 50*time.Millisecond)
+	message := &EventMessage{}
+	require.NoError(t, json.Unmarshal(target.messages()[0], message))
+	require.Equal(t, EventObjectCreatedPut, message.EventName)
+	require.Equal(t, "bucket/a/b", message.Key)
+	require.Len(t, message.Records, 1)
+	require.Equal(t, "ObjectCreated:Put", message.Records[0].EventName)
+	require.Equal(t, "all", message.Records[0].S3.ConfigurationId)
+}
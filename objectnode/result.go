@@ -190,6 +190,35 @@ type ListBucketResult struct {
 	CommonPrefixes []*CommonPrefix `xml:"CommonPrefixes"`
 }
 
+// ObjectVersion is marshaled as a Version element or a DeleteMarker element by the XMLName.
+type ObjectVersion struct {
+	XMLName      xml.Name
+	Key          string       `xml:"Key"`
+	VersionId    string       `xml:"VersionId"`
+	IsLatest     bool         `xml:"IsLatest"`
+	LastModified string       `xml:"LastModified"`
+	ETag         string       `xml:"ETag,omitempty"`
+	Size         *int64       `xml:"Size,omitempty"`
+	StorageClass string       `xml:"StorageClass,omitempty"`
+	Owner        *BucketOwner `xml:"Owner,omitempty"`
+}
+
+type ListVersionsResult struct {
+	XMLName             xml.Name `xml:"ListVersionsResult"`
+	Bucket              string   `xml:"Name"`
+	Prefix              string   `xml:"Prefix"`
+	KeyMarker           string   `xml:"KeyMarker"`
+	VersionIdMarker     string   `xml:"VersionIdMarker"`
+	NextKeyMarker       string   `xml:"NextKeyMarker,omitempty"`
+	NextVersionIdMarker string   `xml:"NextVersionIdMarker,omitempty"`
+	MaxKeys             int      `xml:"MaxKeys"`
+	Delimiter           string   `xml:"Delimiter,omitempty"`
+	EncodingType        string   `xml:"EncodingType,omitempty"`
+	IsTruncated         bool     `xml:"IsTruncated"`
+	Versions            []*ObjectVersion
+	CommonPrefixes      []*CommonPrefix `xml:"CommonPrefixes"`
+}
+
 func NewParts(fsParts []*FSPart) []*Part {
 	parts := make([]*Part, 0)
 	for _, fsPart := range fsParts {
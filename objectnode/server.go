@@ -70,6 +70,17 @@ const (
 	//		}
 	configMasterAddr = proto.MasterAddr
 
+	// String configuration items, used to configure the access key and secret key of a cluster admin user
+	// signing the requests to the master. They are needed once RBAC is enabled on the master, which replies
+	// the secret keys of the users and takes the bucket changes from the signed requests only.
+	// Example:
+	//		{
+	//			"masterAccessKey": "nzD8hXq1yfzXHZ0m",
+	//			"masterSecretKey": "7A5hCgBh4D9X7fzq6R1Uo9zX0gr6bkE3"
+	//		}
+	configMasterAccessKey = "masterAccessKey"
+	configMasterSecretKey = "masterSecretKey"
+
 	// A bool type configuration is used to ensure that the topology information is consistent with the cluster
 	// in real time during the compatibility test. If true, the object node will not cache user information and
 	// volume topology. This configuration will cause a drastic decrease in performance after being turned on,
@@ -128,6 +139,39 @@ const (
 	// 		}
 	configAuditLog = "auditLog"
 
+	// Map type configuration item, used to configure the destinations of bucket event notifications.
+	// The ARN of each destination is arn:cubefs:sqs::<id>:<kafka|webhook>. For detailed parameters,
+	// see the EventNotifierConfig structure.
+	// Example:
+	// 		{
+	// 			"notification": {
+	// 				"queue_dir": "./run/notification/",
+	// 				"queue_limit": 100000,
+	// 				"webhook": {
+	// 					"ingest": {
+	//						"enable": true,
+	// 						"endpoint": "http://192.168.80.130:8080/events"
+	// 					}
+	// 				},
+	//				...
+	// 			}
+	// 		}
+	configNotification = "notification"
+
+	// Map type configuration item, used to enable SSE-S3. The data keys of the objects are sealed by the
+	// master key derived from the key of master_key_id in the keystore of authnode, the client must be
+	// allowed to get the key. For detailed parameters, see the SSEConfig structure.
+	// Example:
+	// 		{
+	// 			"sse": {
+	// 				"auth_nodes": ["192.168.0.11:8080", "192.168.0.12:8080"],
+	// 				"client_id": "objectnode",
+	// 				"client_key": "xxxx",
+	// 				"master_key_id": "objectnode-sse"
+	// 			}
+	// 		}
+	configSSE = "sse"
+
 	// ObjMetaCache takes each path hierarchy of the path-like S3 object key as the cache key,
 	// and map it to the corresponding posix-compatible inode
 	// when enabled, the maxDentryCacheNum must at least be the minimum of defaultMaxDentryCacheNum
@@ -194,6 +238,8 @@ type ObjectNode struct {
 
 	localAuditHandler rpc.ProgressHandler
 	externalAudit     *ExternalAudit
+	notifier          *EventNotifier
+	sseKeys           SSEKeyManager
 
 	closes []func() // close other resources after http server closed
 
@@ -286,14 +332,38 @@ func (o *ObjectNode) loadConfig(cfg *config.Config) (err error) {
 		log.LogInfof("loadConfig: setup config: %v(%v)", configAuditLog, rawAuditLog)
 	}
 
+	// parse notification config
+	if rawNotification := cfg.GetValue(configNotification); rawNotification != nil {
+		if err = o.setNotification(rawNotification); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configNotification, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configNotification, rawNotification)
+	}
+
+	// parse server-side encryption config
+	if rawSSE := cfg.GetValue(configSSE); rawSSE != nil {
+		var conf SSEConfig
+		if err = ParseJSONEntity(rawSSE, &conf); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configSSE, err)
+			return
+		}
+		if o.sseKeys, err = NewAuthKeyManager(conf); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configSSE, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(master key %v)", configSSE, conf.MasterKeyID)
+	}
+
 	// parse strict config
 	strict := cfg.GetBool(configStrict)
 	log.LogInfof("loadConfig: strict: %v", strict)
 	o.disableCreateBucketByS3 = cfg.GetBool(disableCreateBucketByS3)
 
 	o.mc = master.NewMasterClient(masters, false)
+	o.mc.SetCredential(cfg.GetString(configMasterAccessKey), cfg.GetString(configMasterSecretKey))
 	o.vm = NewVolumeManager(masters, strict)
-	o.userStore = NewUserInfoStore(masters, strict)
+	o.userStore = NewUserInfoStore(o.mc, strict)
 
 	// parse inode cache
 	cacheEnable := cfg.GetBool(configObjMetaCache)
@@ -329,6 +399,21 @@ func (o *ObjectNode) updateRegion(region string) {
 	o.region = region
 }
 
+func (o *ObjectNode) setNotification(raw interface{}) error {
+	var conf EventNotifierConfig
+	if err := ParseJSONEntity(raw, &conf); err != nil {
+		return err
+	}
+	notifier, err := NewEventNotifier(conf)
+	if err != nil {
+		return err
+	}
+	o.notifier = notifier
+	o.closes = append(o.closes, func() { notifier.Close() })
+
+	return nil
+}
+
 func (o *ObjectNode) setAuditLog(raw interface{}) error {
 	var conf AuditLogConfig
 	if err := ParseJSONEntity(raw, &conf); err != nil {
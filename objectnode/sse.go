@@ -0,0 +1,603 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/serv-side-encryption.html
+
+const (
+	SSEAlgorithmAES256 = "AES256"
+
+	SSETypeS3       = "SSE-S3"
+	SSETypeCustomer = "SSE-C"
+
+	sseKeySize   = 32
+	sseNonceSize = 12
+	sseTagSize   = 16
+
+	// The data is encrypted in chunks so that a range can be decrypted without reading the
+	// whole object. Each encrypted stream, which is the data of a PutObject or of a part of
+	// the multipart upload, begins with a random nonce followed by the sealed chunks.
+	sseChunkSize       = 64 << 10
+	sseSealedChunkSize = sseChunkSize + sseTagSize
+)
+
+var (
+	InvalidEncryptionAlgorithm = &ErrorCode{ErrorCode: "InvalidEncryptionAlgorithmError", ErrorMessage: "The encryption request you specified is not valid. The valid value is AES256.", StatusCode: http.StatusBadRequest}
+	InvalidSSECustomerKey      = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The secret key was invalid for the specified algorithm.", StatusCode: http.StatusBadRequest}
+	SSECustomerKeyMD5Mismatch  = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The calculated MD5 hash of the key did not match the hash that was provided.", StatusCode: http.StatusBadRequest}
+	SSEConflictHeaders         = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "Server Side Encryption with Customer provided key is incompatible with the encryption method specified.", StatusCode: http.StatusBadRequest}
+	SSECustomerKeyRequired     = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The object was stored using a form of Server Side Encryption. The correct parameters must be provided to retrieve the object.", StatusCode: http.StatusBadRequest}
+	SSEParametersNotApplicable = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The encryption parameters are not applicable to this object.", StatusCode: http.StatusBadRequest}
+	SSENotConfigured           = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "Server side encryption with managed keys is not configured.", StatusCode: http.StatusNotImplemented}
+)
+
+var errSSEStreamCorrupted = errors.New("encrypted stream is corrupted")
+
+// SSERequest is the server-side encryption specified in the headers of a request.
+type SSERequest struct {
+	Type           string
+	CustomerKey    []byte
+	CustomerKeyMD5 string
+}
+
+// ParseSSERequest parses the encryption headers of the object to write or read,
+// nil is returned if none of the headers is present.
+func ParseSSERequest(header http.Header) (*SSERequest, error) {
+	customer, err := parseSSECustomerHeaders(header, XAmzServerSideEncryptionCustomerAlgorithm,
+		XAmzServerSideEncryptionCustomerKey, XAmzServerSideEncryptionCustomerKeyMD5)
+	if err != nil {
+		return nil, err
+	}
+	algorithm := header.Get(XAmzServerSideEncryption)
+	if algorithm == "" {
+		return customer, nil
+	}
+	if customer != nil {
+		return nil, SSEConflictHeaders
+	}
+	if algorithm != SSEAlgorithmAES256 {
+		return nil, InvalidEncryptionAlgorithm
+	}
+	return &SSERequest{Type: SSETypeS3}, nil
+}
+
+// ParseCopySourceSSERequest parses the customer key headers of the copy source.
+func ParseCopySourceSSERequest(header http.Header) (*SSERequest, error) {
+	return parseSSECustomerHeaders(header, XAmzCopySourceServerSideEncryptionCustomerAlgorithm,
+		XAmzCopySourceServerSideEncryptionCustomerKey, XAmzCopySourceServerSideEncryptionCustomerKeyMD5)
+}
+
+func parseSSECustomerHeaders(header http.Header, algorithmName, keyName, keyMD5Name string) (*SSERequest, error) {
+	algorithm, key, keyMD5 := header.Get(algorithmName), header.Get(keyName), header.Get(keyMD5Name)
+	if algorithm == "" && key == "" && keyMD5 == "" {
+		return nil, nil
+	}
+	if algorithm != SSEAlgorithmAES256 {
+		return nil, InvalidEncryptionAlgorithm
+	}
+	customerKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(customerKey) != sseKeySize {
+		return nil, InvalidSSECustomerKey
+	}
+	sum := md5.Sum(customerKey)
+	if keyMD5 != base64.StdEncoding.EncodeToString(sum[:]) {
+		return nil, SSECustomerKeyMD5Mismatch
+	}
+	return &SSERequest{Type: SSETypeCustomer, CustomerKey: customerKey, CustomerKeyMD5: keyMD5}, nil
+}
+
+// ObjectEncryption is the server-side encryption of an object kept in the object xattrs.
+// The data key of the object is sealed by the master key for SSE-S3, or by the key
+// provided by the customer for SSE-C, and the unsealed data key is never stored.
+type ObjectEncryption struct {
+	Type      string
+	SealedKey string
+	KeyID     string // ID of the master key, SSE-S3 only
+	KeyMD5    string // MD5 of the customer key, SSE-C only
+	// Plaintext sizes of the encrypted streams the object data consists of,
+	// they are known after the data is written.
+	Parts []uint64
+
+	dataKey []byte
+}
+
+// Size returns the plaintext size of the object.
+func (e *ObjectEncryption) Size() (size uint64) {
+	for _, part := range e.Parts {
+		size += part
+	}
+	return
+}
+
+func (e *ObjectEncryption) encryptedSize() (size uint64) {
+	for _, part := range e.Parts {
+		size += sseEncryptedSize(part)
+	}
+	return
+}
+
+func (e *ObjectEncryption) setXAttrs(attrs map[string]string) {
+	attrs[XAttrKeyOSSSSE] = e.Type
+	attrs[XAttrKeyOSSSSEKey] = e.SealedKey
+	if e.KeyID != "" {
+		attrs[XAttrKeyOSSSSEKeyID] = e.KeyID
+	}
+	if e.KeyMD5 != "" {
+		attrs[XAttrKeyOSSSSEKeyMD5] = e.KeyMD5
+	}
+	if len(e.Parts) > 0 {
+		attrs[XAttrKeyOSSSSEParts] = formatSSEParts(e.Parts)
+	}
+}
+
+// parseObjectEncryption loads the encryption from the xattrs, nil is returned if the
+// object is not encrypted.
+func parseObjectEncryption(attrs map[string]string) (enc *ObjectEncryption, err error) {
+	sseType, ok := attrs[XAttrKeyOSSSSE]
+	if !ok {
+		return nil, nil
+	}
+	enc = &ObjectEncryption{
+		Type:      sseType,
+		SealedKey: attrs[XAttrKeyOSSSSEKey],
+		KeyID:     attrs[XAttrKeyOSSSSEKeyID],
+		KeyMD5:    attrs[XAttrKeyOSSSSEKeyMD5],
+	}
+	if raw, ok := attrs[XAttrKeyOSSSSEParts]; ok {
+		if enc.Parts, err = parseSSEParts(raw); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// formatSSEParts encodes the part sizes as comma separated values, the consecutive parts of
+// the same size are encoded as size*count since most parts of an upload are of the same size.
+func formatSSEParts(parts []uint64) string {
+	var sb strings.Builder
+	for i := 0; i < len(parts); {
+		j := i + 1
+		for j < len(parts) && parts[j] == parts[i] {
+			j++
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatUint(parts[i], 10))
+		if j-i > 1 {
+			sb.WriteByte('*')
+			sb.WriteString(strconv.Itoa(j - i))
+		}
+		i = j
+	}
+	return sb.String()
+}
+
+func parseSSEParts(raw string) (parts []uint64, err error) {
+	for _, item := range strings.Split(raw, ",") {
+		count := 1
+		if i := strings.IndexByte(item, '*'); i >= 0 {
+			if count, err = strconv.Atoi(item[i+1:]); err != nil || count <= 0 {
+				return nil, fmt.Errorf("invalid encrypted parts: %v", raw)
+			}
+			item = item[:i]
+		}
+		var size uint64
+		if size, err = strconv.ParseUint(item, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid encrypted parts: %v", raw)
+		}
+		for ; count > 0; count-- {
+			parts = append(parts, size)
+		}
+	}
+	return
+}
+
+// newObjectEncryption generates the data key of the object to write as requested,
+// nil is returned if no encryption is requested.
+func (o *ObjectNode) newObjectEncryption(req *SSERequest) (enc *ObjectEncryption, err error) {
+	if req == nil {
+		return nil, nil
+	}
+	enc = &ObjectEncryption{Type: req.Type}
+	var kek []byte
+	switch req.Type {
+	case SSETypeS3:
+		if o.sseKeys == nil {
+			return nil, SSENotConfigured
+		}
+		enc.KeyID = o.sseKeys.KeyID()
+		if kek, err = o.sseKeys.MasterKey(enc.KeyID); err != nil {
+			return nil, err
+		}
+	case SSETypeCustomer:
+		kek, enc.KeyMD5 = req.CustomerKey, req.CustomerKeyMD5
+	}
+	enc.dataKey = make([]byte, sseKeySize)
+	if _, err = rand.Read(enc.dataKey); err != nil {
+		return nil, err
+	}
+	if enc.SealedKey, err = sealSSEKey(kek, enc.dataKey, enc.Type); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// checkObjectEncryption checks the encryption headers of a request reading the object,
+// the key of the customer must be provided to read the objects encrypted with SSE-C.
+func checkObjectEncryption(enc *ObjectEncryption, req *SSERequest) error {
+	customer := req != nil && req.Type == SSETypeCustomer
+	if enc == nil || enc.Type != SSETypeCustomer {
+		if customer {
+			return SSEParametersNotApplicable
+		}
+		return nil
+	}
+	if !customer {
+		return SSECustomerKeyRequired
+	}
+	if req.CustomerKeyMD5 != enc.KeyMD5 {
+		return AccessDenied
+	}
+	return nil
+}
+
+// checkCopyEncryption checks the encryption headers of copying the object. The encrypted data
+// is copied without decryption, so the target must be encrypted in the same way as the source.
+func checkCopyEncryption(header http.Header, source *ObjectEncryption) error {
+	sourceReq, err := ParseCopySourceSSERequest(header)
+	if err != nil {
+		return err
+	}
+	if err = checkObjectEncryption(source, sourceReq); err != nil {
+		return err
+	}
+	targetReq, err := ParseSSERequest(header)
+	if err != nil {
+		return err
+	}
+	switch {
+	case source == nil:
+		if targetReq != nil {
+			return UnsupportedOperation
+		}
+	case source.Type == SSETypeS3:
+		if targetReq != nil && targetReq.Type != SSETypeS3 {
+			return UnsupportedOperation
+		}
+	case source.Type == SSETypeCustomer:
+		if targetReq == nil || targetReq.CustomerKeyMD5 != source.KeyMD5 {
+			return UnsupportedOperation
+		}
+	}
+	return nil
+}
+
+// openObjectEncryption unseals the data key of the encrypted object to read its data.
+func (o *ObjectNode) openObjectEncryption(enc *ObjectEncryption, req *SSERequest) (err error) {
+	if err = checkObjectEncryption(enc, req); err != nil || enc == nil {
+		return
+	}
+	var kek []byte
+	switch enc.Type {
+	case SSETypeS3:
+		if o.sseKeys == nil {
+			return SSENotConfigured
+		}
+		if kek, err = o.sseKeys.MasterKey(enc.KeyID); err != nil {
+			return
+		}
+	case SSETypeCustomer:
+		kek = req.CustomerKey
+	default:
+		return fmt.Errorf("unknown server side encryption: %v", enc.Type)
+	}
+	if enc.dataKey, err = unsealSSEKey(kek, enc.SealedKey, enc.Type); err != nil && enc.Type == SSETypeCustomer {
+		return AccessDenied
+	}
+	return
+}
+
+func setSSEResponseHeaders(w http.ResponseWriter, enc *ObjectEncryption) {
+	if enc == nil {
+		return
+	}
+	switch enc.Type {
+	case SSETypeS3:
+		w.Header().Set(XAmzServerSideEncryption, SSEAlgorithmAES256)
+	case SSETypeCustomer:
+		w.Header().Set(XAmzServerSideEncryptionCustomerAlgorithm, SSEAlgorithmAES256)
+		w.Header().Set(XAmzServerSideEncryptionCustomerKeyMD5, enc.KeyMD5)
+	}
+}
+
+func newSSECipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func sealSSEKey(kek, dataKey []byte, sseType string) (string, error) {
+	aead, err := newSSECipher(kek)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, sseNonceSize)
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, dataKey, []byte(sseType))), nil
+}
+
+func unsealSSEKey(kek []byte, sealedKey, sseType string) ([]byte, error) {
+	aead, err := newSSECipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(sealedKey)
+	if err != nil || len(sealed) < sseNonceSize {
+		return nil, fmt.Errorf("invalid sealed key: %v", sealedKey)
+	}
+	return aead.Open(nil, sealed[:sseNonceSize], sealed[sseNonceSize:], []byte(sseType))
+}
+
+func sseStreamChunks(size uint64) uint64 {
+	if size == 0 {
+		return 1
+	}
+	return (size + sseChunkSize - 1) / sseChunkSize
+}
+
+// sseEncryptedSize returns the size of the encrypted stream of the plaintext size.
+func sseEncryptedSize(size uint64) uint64 {
+	return sseNonceSize + size + sseStreamChunks(size)*sseTagSize
+}
+
+// sseChunkNonce derives the nonce of each chunk from the nonce of the stream, and the
+// last chunk is sealed with a different additional data to detect truncation.
+func sseChunkNonce(nonce []byte, index uint64) []byte {
+	chunkNonce := make([]byte, sseNonceSize)
+	copy(chunkNonce, nonce)
+	binary.BigEndian.PutUint64(chunkNonce[4:], binary.BigEndian.Uint64(nonce[4:])^index)
+	return chunkNonce
+}
+
+func sseChunkAAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// sseEncryptReader encrypts the data of the source into an encrypted stream,
+// the plaintext is written to the hash to compute the ETag of the object.
+type sseEncryptReader struct {
+	src    *bufio.Reader
+	aead   cipher.AEAD
+	nonce  []byte
+	hash   hash.Hash
+	size   uint64
+	index  uint64
+	plain  []byte
+	sealed []byte
+	out    []byte
+	done   bool
+}
+
+func newSSEEncryptReader(src io.Reader, key []byte, h hash.Hash) (*sseEncryptReader, error) {
+	aead, err := newSSECipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, sseNonceSize)
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &sseEncryptReader{
+		src:   bufio.NewReaderSize(src, sseChunkSize),
+		aead:  aead,
+		nonce: nonce,
+		hash:  h,
+		plain: make([]byte, sseChunkSize),
+		out:   nonce,
+	}, nil
+}
+
+func (r *sseEncryptReader) Read(p []byte) (n int, err error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err = r.sealChunk(); err != nil {
+			return
+		}
+	}
+	n = copy(p, r.out)
+	r.out = r.out[n:]
+	return
+}
+
+// Size returns the size of the plaintext encrypted.
+func (r *sseEncryptReader) Size() uint64 {
+	return r.size
+}
+
+func (r *sseEncryptReader) sealChunk() error {
+	n, err := io.ReadFull(r.src, r.plain)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	final := err != nil
+	if !final {
+		if _, err = r.src.Peek(1); err != nil && err != io.EOF {
+			return err
+		}
+		final = err == io.EOF
+	}
+	if r.hash != nil {
+		r.hash.Write(r.plain[:n])
+	}
+	r.size += uint64(n)
+	r.sealed = r.aead.Seal(r.sealed[:0], sseChunkNonce(r.nonce, r.index), r.plain[:n], sseChunkAAD(final))
+	r.out = r.sealed
+	r.index++
+	r.done = final
+	return nil
+}
+
+// sseDecryptWriter decrypts the chunks of an encrypted stream written in order from the
+// chunk of the index, the plaintext in the range [lower, upper) of the stream is written.
+type sseDecryptWriter struct {
+	w            io.Writer
+	aead         cipher.AEAD
+	nonce        []byte
+	size         uint64
+	index        uint64
+	lower, upper uint64
+	chunk        []byte
+	plain        []byte
+}
+
+func (d *sseDecryptWriter) sealedChunkSize() int {
+	size := d.size - d.index*sseChunkSize
+	if size > sseChunkSize {
+		size = sseChunkSize
+	}
+	return int(size) + sseTagSize
+}
+
+func (d *sseDecryptWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		if d.index >= sseStreamChunks(d.size) {
+			return n, errSSEStreamCorrupted
+		}
+		need := d.sealedChunkSize() - len(d.chunk)
+		if need > len(p) {
+			need = len(p)
+		}
+		d.chunk = append(d.chunk, p[:need]...)
+		p = p[need:]
+		n += need
+		if len(d.chunk) == d.sealedChunkSize() {
+			if err = d.openChunk(); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func (d *sseDecryptWriter) openChunk() (err error) {
+	final := d.index == sseStreamChunks(d.size)-1
+	if d.plain, err = d.aead.Open(d.plain[:0], sseChunkNonce(d.nonce, d.index), d.chunk, sseChunkAAD(final)); err != nil {
+		return errSSEStreamCorrupted
+	}
+	start := d.index * sseChunkSize
+	end := start + uint64(len(d.plain))
+	d.index++
+	d.chunk = d.chunk[:0]
+
+	lower, upper := start, end
+	if d.lower > lower {
+		lower = d.lower
+	}
+	if d.upper < upper {
+		upper = d.upper
+	}
+	if lower < upper {
+		_, err = d.w.Write(d.plain[lower-start : upper-start])
+	}
+	return
+}
+
+// readSSERange writes the plaintext in the range [offset, offset+size) of the object made up of
+// the encrypted streams of the parts, only the chunks covering the range are read and decrypted.
+func readSSERange(read func(writer io.Writer, offset, size uint64) error, key []byte, parts []uint64,
+	writer io.Writer, offset, size uint64) error {
+	aead, err := newSSECipher(key)
+	if err != nil {
+		return err
+	}
+	upper := offset + size
+	var plainOffset, encOffset uint64
+	for _, partSize := range parts {
+		if plainOffset >= upper {
+			break
+		}
+		partEnd, partEncSize := plainOffset+partSize, sseEncryptedSize(partSize)
+		if partEnd <= offset || partSize == 0 {
+			plainOffset, encOffset = partEnd, encOffset+partEncSize
+			continue
+		}
+		lower, partUpper := uint64(0), partSize
+		if offset > plainOffset {
+			lower = offset - plainOffset
+		}
+		if upper < partEnd {
+			partUpper = upper - plainOffset
+		}
+
+		nonce := bytes.NewBuffer(make([]byte, 0, sseNonceSize))
+		if err = read(nonce, encOffset, sseNonceSize); err != nil {
+			return err
+		}
+		if nonce.Len() != sseNonceSize {
+			return io.ErrUnexpectedEOF
+		}
+		first, last := lower/sseChunkSize, (partUpper-1)/sseChunkSize
+		chunkOffset := encOffset + sseNonceSize + first*sseSealedChunkSize
+		chunkEnd := encOffset + sseNonceSize + (last+1)*sseSealedChunkSize
+		if chunkEnd > encOffset+partEncSize {
+			chunkEnd = encOffset + partEncSize
+		}
+		dw := &sseDecryptWriter{
+			w:     writer,
+			aead:  aead,
+			nonce: nonce.Bytes(),
+			size:  partSize,
+			index: first,
+			lower: lower,
+			upper: partUpper,
+		}
+		if err = read(dw, chunkOffset, chunkEnd-chunkOffset); err != nil {
+			return err
+		}
+		if dw.index != last+1 {
+			return io.ErrUnexpectedEOF
+		}
+		plainOffset, encOffset = partEnd, encOffset+partEncSize
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+
+	authSDK "github.com/cubefs/cubefs/sdk/auth"
+	"github.com/cubefs/cubefs/util/keystore"
+)
+
+const sseMasterKeyContext = "cubefs:objectnode:sse"
+
+// SSEKeyManager provides the master keys which seal the data keys of the objects encrypted with SSE-S3.
+type SSEKeyManager interface {
+	// KeyID returns the ID of the master key to seal the data keys of new objects.
+	KeyID() string
+	// MasterKey returns the master key of the ID, the retired master keys are still
+	// required to read the objects written before.
+	MasterKey(keyID string) ([]byte, error)
+}
+
+type SSEConfig struct {
+	AuthNodes   []string `json:"auth_nodes"`
+	EnableHTTPS bool     `json:"enable_https"`
+	CertFile    string   `json:"cert_file"`
+	ClientID    string   `json:"client_id"`
+	ClientKey   string   `json:"client_key"`
+	// The ID of the key in the keystore of authnode, the master key is derived from its auth key.
+	MasterKeyID string `json:"master_key_id"`
+}
+
+// authKeyManager loads the master keys from the keystore of authnode,
+// the keys never change once created so they are cached after loaded.
+type authKeyManager struct {
+	conf SSEConfig
+	load func(keyID string) (*keystore.KeyInfo, error)
+
+	mu   sync.RWMutex
+	keys map[string][]byte
+}
+
+func NewAuthKeyManager(conf SSEConfig) (SSEKeyManager, error) {
+	if len(conf.AuthNodes) == 0 || conf.ClientID == "" || conf.ClientKey == "" || conf.MasterKeyID == "" {
+		return nil, errors.New("auth_nodes, client_id, client_key and master_key_id are required")
+	}
+	m := &authKeyManager{
+		conf: conf,
+		keys: make(map[string][]byte),
+	}
+	m.load = func(keyID string) (*keystore.KeyInfo, error) {
+		// the ticket of an auth client expires, so a new client is used for each loading
+		client := authSDK.NewAuthClient(conf.AuthNodes, conf.EnableHTTPS, conf.CertFile)
+		return client.API().AdminGetKey(conf.ClientID, conf.ClientKey, keyID)
+	}
+	// the objectnode should not start if the master key is not accessible
+	if _, err := m.MasterKey(conf.MasterKeyID); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *authKeyManager) KeyID() string {
+	return m.conf.MasterKeyID
+}
+
+func (m *authKeyManager) MasterKey(keyID string) ([]byte, error) {
+	m.mu.RLock()
+	key, ok := m.keys[keyID]
+	m.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	info, err := m.load(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("load master key(%v) fail: %v", keyID, err)
+	}
+	if len(info.AuthKey) == 0 {
+		return nil, fmt.Errorf("master key(%v) is empty", keyID)
+	}
+	// the auth key is used to authenticate the key owner in authnode,
+	// so a dedicated key is derived from it instead of using it directly
+	mac := hmac.New(sha256.New, info.AuthKey)
+	mac.Write([]byte(sseMasterKeyContext))
+	key = mac.Sum(nil)
+
+	m.mu.Lock()
+	m.keys[keyID] = key
+	m.mu.Unlock()
+	return key, nil
+}
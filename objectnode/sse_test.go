@@ -0,0 +1,336 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/cubefs/cubefs/util/keystore"
+	"github.com/stretchr/testify/require"
+)
+
+func customerKeyHeaders(key []byte) http.Header {
+	sum := md5.Sum(key)
+	header := make(http.Header)
+	header.Set(XAmzServerSideEncryptionCustomerAlgorithm, SSEAlgorithmAES256)
+	header.Set(XAmzServerSideEncryptionCustomerKey, base64.StdEncoding.EncodeToString(key))
+	header.Set(XAmzServerSideEncryptionCustomerKeyMD5, base64.StdEncoding.EncodeToString(sum[:]))
+	return header
+}
+
+func TestParseSSERequest(t *testing.T) {
+	req, err := ParseSSERequest(make(http.Header))
+	require.NoError(t, err)
+	require.Nil(t, req)
+
+	header := make(http.Header)
+	header.Set(XAmzServerSideEncryption, SSEAlgorithmAES256)
+	req, err = ParseSSERequest(header)
+	require.NoError(t, err)
+	require.Equal(t, SSETypeS3, req.Type)
+	header.Set(XAmzServerSideEncryption, "aws:kms")
+	_, err = ParseSSERequest(header)
+	require.Equal(t, InvalidEncryptionAlgorithm, err)
+
+	key := bytes.Repeat([]byte{1}, sseKeySize)
+	header = customerKeyHeaders(key)
+	req, err = ParseSSERequest(header)
+	require.NoError(t, err)
+	require.Equal(t, SSETypeCustomer, req.Type)
+	require.Equal(t, key, req.CustomerKey)
+
+	header.Set(XAmzServerSideEncryption, SSEAlgorithmAES256)
+	_, err = ParseSSERequest(header)
+	require.Equal(t, SSEConflictHeaders, err)
+
+	header = customerKeyHeaders(key)
+	header.Set(XAmzServerSideEncryptionCustomerKeyMD5, base64.StdEncoding.EncodeToString(key[:16]))
+	_, err = ParseSSERequest(header)
+	require.Equal(t, SSECustomerKeyMD5Mismatch, err)
+
+	header = customerKeyHeaders(key[:16])
+	_, err = ParseSSERequest(header)
+	require.Equal(t, InvalidSSECustomerKey, err)
+
+	header = customerKeyHeaders(key)
+	header.Del(XAmzServerSideEncryptionCustomerAlgorithm)
+	_, err = ParseSSERequest(header)
+	require.Equal(t, InvalidEncryptionAlgorithm, err)
+
+	// the customer key of the copy source is in the different headers
+	req, err = ParseCopySourceSSERequest(customerKeyHeaders(key))
+	require.NoError(t, err)
+	require.Nil(t, req)
+}
+
+func TestSSEParts(t *testing.T) {
+	tests := []struct {
+		parts []uint64
+		raw   string
+	}{
+		{parts: []uint64{0}, raw: "0"},
+		{parts: []uint64{100}, raw: "100"},
+		{parts: []uint64{5242880, 5242880, 5242880, 100}, raw: "5242880*3,100"},
+		{parts: []uint64{1, 2, 2, 1}, raw: "1,2*2,1"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.raw, formatSSEParts(tt.parts))
+		parts, err := parseSSEParts(tt.raw)
+		require.NoError(t, err)
+		require.Equal(t, tt.parts, parts)
+	}
+	for _, raw := range []string{"", "a", "1*0", "1*", "-1"} {
+		_, err := parseSSEParts(raw)
+		require.Error(t, err, raw)
+	}
+
+	enc := &ObjectEncryption{Type: SSETypeS3, SealedKey: "sealed", KeyID: "master", Parts: []uint64{10, 20}}
+	attrs := make(map[string]string)
+	enc.setXAttrs(attrs)
+	parsed, err := parseObjectEncryption(attrs)
+	require.NoError(t, err)
+	require.Equal(t, enc, parsed)
+	require.Equal(t, uint64(30), parsed.Size())
+	require.Equal(t, sseEncryptedSize(10)+sseEncryptedSize(20), parsed.encryptedSize())
+
+	parsed, err = parseObjectEncryption(map[string]string{})
+	require.NoError(t, err)
+	require.Nil(t, parsed)
+}
+
+func TestSSEKeySeal(t *testing.T) {
+	kek, dataKey := make([]byte, sseKeySize), make([]byte, sseKeySize)
+	rand.Read(kek)
+	rand.Read(dataKey)
+	sealed, err := sealSSEKey(kek, dataKey, SSETypeCustomer)
+	require.NoError(t, err)
+	unsealed, err := unsealSSEKey(kek, sealed, SSETypeCustomer)
+	require.NoError(t, err)
+	require.Equal(t, dataKey, unsealed)
+
+	_, err = unsealSSEKey(kek, sealed, SSETypeS3)
+	require.Error(t, err)
+	kek[0]++
+	_, err = unsealSSEKey(kek, sealed, SSETypeCustomer)
+	require.Error(t, err)
+}
+
+// encryptParts encrypts the parts as they are written by PutObject or WritePart.
+func encryptParts(t *testing.T, key []byte, parts [][]byte) (data []byte, sizes []uint64) {
+	for _, part := range parts {
+		h := md5.New()
+		r, err := newSSEEncryptReader(bytes.NewReader(part), key, h)
+		require.NoError(t, err)
+		encrypted, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, uint64(len(part)), r.Size())
+		require.Equal(t, sseEncryptedSize(r.Size()), uint64(len(encrypted)))
+		sum := md5.Sum(part)
+		require.Equal(t, sum[:], h.Sum(nil))
+		data = append(data, encrypted...)
+		sizes = append(sizes, r.Size())
+	}
+	return
+}
+
+func TestSSEReadRange(t *testing.T) {
+	key := make([]byte, sseKeySize)
+	rand.Read(key)
+	random := func(n int) []byte {
+		b := make([]byte, n)
+		rand.Read(b)
+		return b
+	}
+	parts := [][]byte{random(3*sseChunkSize + 100), {}, random(sseChunkSize), random(10)}
+	data, sizes := encryptParts(t, key, parts)
+	plain := bytes.Join(parts, nil)
+
+	var reads int
+	read := func(w io.Writer, offset, size uint64) error {
+		reads++
+		require.LessOrEqual(t, offset+size, uint64(len(data)))
+		_, err := w.Write(data[offset : offset+size])
+		return err
+	}
+
+	ranges := [][2]uint64{
+		{0, uint64(len(plain))},
+		{0, 1},
+		{sseChunkSize - 1, 2},
+		{sseChunkSize, sseChunkSize},
+		{3*sseChunkSize + 50, sseChunkSize + 60},
+		{3*sseChunkSize + 100, 1},
+		{uint64(len(plain)) - 5, 5},
+		{10, 0},
+	}
+	for _, rg := range ranges {
+		buf := new(bytes.Buffer)
+		require.NoError(t, readSSERange(read, key, sizes, buf, rg[0], rg[1]))
+		require.Equal(t, string(plain[rg[0]:rg[0]+rg[1]]), buf.String(), rg)
+	}
+
+	// only the chunks covering the range are read
+	reads = 0
+	buf := new(bytes.Buffer)
+	require.NoError(t, readSSERange(read, key, sizes, buf, 2*sseChunkSize+1, 1))
+	require.Equal(t, 2, reads)
+
+	// the modified data can not be decrypted
+	data[sseNonceSize+1]++
+	require.Error(t, readSSERange(read, key, sizes, new(bytes.Buffer), 0, 1))
+	data[sseNonceSize+1]--
+	rand.Read(key)
+	require.Error(t, readSSERange(read, key, sizes, new(bytes.Buffer), 0, 1))
+}
+
+func TestSSEEmptyStream(t *testing.T) {
+	key := make([]byte, sseKeySize)
+	rand.Read(key)
+	data, sizes := encryptParts(t, key, [][]byte{{}})
+	require.Equal(t, []uint64{0}, sizes)
+	require.Equal(t, sseNonceSize+sseTagSize, len(data))
+}
+
+func TestCheckObjectEncryption(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, sseKeySize)
+	customer, err := ParseSSERequest(customerKeyHeaders(key))
+	require.NoError(t, err)
+	other, err := ParseSSERequest(customerKeyHeaders(bytes.Repeat([]byte{2}, sseKeySize)))
+	require.NoError(t, err)
+	s3 := &SSERequest{Type: SSETypeS3}
+
+	customerEnc := &ObjectEncryption{Type: SSETypeCustomer, KeyMD5: customer.CustomerKeyMD5}
+	s3Enc := &ObjectEncryption{Type: SSETypeS3}
+	require.NoError(t, checkObjectEncryption(nil, nil))
+	require.NoError(t, checkObjectEncryption(s3Enc, nil))
+	require.NoError(t, checkObjectEncryption(s3Enc, s3))
+	require.NoError(t, checkObjectEncryption(customerEnc, customer))
+	require.Equal(t, SSEParametersNotApplicable, checkObjectEncryption(nil, customer))
+	require.Equal(t, SSEParametersNotApplicable, checkObjectEncryption(s3Enc, customer))
+	require.Equal(t, SSECustomerKeyRequired, checkObjectEncryption(customerEnc, nil))
+	require.Equal(t, AccessDenied, checkObjectEncryption(customerEnc, other))
+
+	copyHeaders := func(source, target []byte) http.Header {
+		header := make(http.Header)
+		if source != nil {
+			for name, values := range customerKeyHeaders(source) {
+				header[name[:len("X-Amz-")]+"Copy-Source-"+name[len("X-Amz-"):]] = values
+			}
+		}
+		if target != nil {
+			for name, values := range customerKeyHeaders(target) {
+				header[name] = values
+			}
+		}
+		return header
+	}
+	require.NoError(t, checkCopyEncryption(copyHeaders(nil, nil), nil))
+	require.NoError(t, checkCopyEncryption(copyHeaders(nil, nil), s3Enc))
+	require.NoError(t, checkCopyEncryption(copyHeaders(key, key), customerEnc))
+	require.Equal(t, UnsupportedOperation, checkCopyEncryption(copyHeaders(nil, key), nil))
+	require.Equal(t, UnsupportedOperation, checkCopyEncryption(copyHeaders(nil, key), s3Enc))
+	require.Equal(t, UnsupportedOperation, checkCopyEncryption(copyHeaders(key, nil), customerEnc))
+	require.Equal(t, SSECustomerKeyRequired, checkCopyEncryption(copyHeaders(nil, key), customerEnc))
+}
+
+type mockSSEKeyManager struct {
+	keyID string
+	keys  map[string][]byte
+}
+
+func (m *mockSSEKeyManager) KeyID() string {
+	return m.keyID
+}
+
+func (m *mockSSEKeyManager) MasterKey(keyID string) ([]byte, error) {
+	if key, ok := m.keys[keyID]; ok {
+		return key, nil
+	}
+	return nil, errors.New("master key not found")
+}
+
+func TestObjectEncryptionKeys(t *testing.T) {
+	o := &ObjectNode{}
+	_, err := o.newObjectEncryption(&SSERequest{Type: SSETypeS3})
+	require.Equal(t, SSENotConfigured, err)
+
+	keys := &mockSSEKeyManager{keyID: "v1", keys: map[string][]byte{"v1": bytes.Repeat([]byte{1}, sseKeySize)}}
+	o.sseKeys = keys
+	enc, err := o.newObjectEncryption(&SSERequest{Type: SSETypeS3})
+	require.NoError(t, err)
+	require.Equal(t, "v1", enc.KeyID)
+	dataKey := enc.dataKey
+
+	// the objects sealed by the retired master key are still readable
+	keys.keyID, keys.keys["v2"] = "v2", bytes.Repeat([]byte{2}, sseKeySize)
+	attrs := make(map[string]string)
+	enc.setXAttrs(attrs)
+	enc, err = parseObjectEncryption(attrs)
+	require.NoError(t, err)
+	require.NoError(t, o.openObjectEncryption(enc, nil))
+	require.Equal(t, dataKey, enc.dataKey)
+
+	key := bytes.Repeat([]byte{1}, sseKeySize)
+	customer, err := ParseSSERequest(customerKeyHeaders(key))
+	require.NoError(t, err)
+	enc, err = o.newObjectEncryption(customer)
+	require.NoError(t, err)
+	require.Equal(t, customer.CustomerKeyMD5, enc.KeyMD5)
+	dataKey, enc.dataKey = enc.dataKey, nil
+	require.NoError(t, o.openObjectEncryption(enc, customer))
+	require.Equal(t, dataKey, enc.dataKey)
+
+	// the key of the customer is not stored, a different key with the same MD5 can not unseal the data key
+	forged := &SSERequest{Type: SSETypeCustomer, CustomerKey: bytes.Repeat([]byte{2}, sseKeySize), CustomerKeyMD5: enc.KeyMD5}
+	require.Equal(t, AccessDenied, o.openObjectEncryption(enc, forged))
+}
+
+func TestAuthKeyManager(t *testing.T) {
+	_, err := NewAuthKeyManager(SSEConfig{AuthNodes: []string{"127.0.0.1:8080"}})
+	require.Error(t, err)
+
+	var loads int
+	m := &authKeyManager{
+		conf: SSEConfig{MasterKeyID: "master"},
+		keys: make(map[string][]byte),
+		load: func(keyID string) (*keystore.KeyInfo, error) {
+			loads++
+			if keyID != "master" {
+				return nil, errors.New("key not found")
+			}
+			return &keystore.KeyInfo{ID: keyID, AuthKey: []byte("auth key")}, nil
+		},
+	}
+	require.Equal(t, "master", m.KeyID())
+	key, err := m.MasterKey("master")
+	require.NoError(t, err)
+	require.Len(t, key, sha256.Size)
+	require.NotContains(t, string(key), "auth key")
+	cached, err := m.MasterKey("master")
+	require.NoError(t, err)
+	require.Equal(t, key, cached)
+	require.Equal(t, 1, loads)
+
+	_, err = m.MasterKey("other")
+	require.Error(t, err)
+}
@@ -0,0 +1,185 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/Versioning.html
+
+const (
+	VersioningStatusEnabled   = "Enabled"
+	VersioningStatusSuspended = "Suspended"
+
+	// NullVersionId is the version ID of objects written while versioning is not enabled.
+	NullVersionId = "null"
+
+	// ObjectVersionRootDir is the hidden directory under the volume root which keeps
+	// the noncurrent versions and delete markers of all objects. The version tree mirrors
+	// the object key, each object key owns a directory and every entry in it is a version.
+	ObjectVersionRootDir = ".oss_versions"
+
+	MaxVersioningConfigSize = 1 << 10 // 1KB
+
+	versionTimeLen      = 16
+	versionRandomLen    = 8
+	versionReadDirLimit = 1000
+)
+
+var (
+	NoSuchVersion            = &ErrorCode{ErrorCode: "NoSuchVersion", ErrorMessage: "The specified version does not exist.", StatusCode: http.StatusNotFound}
+	InvalidVersionId         = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "Invalid version id specified.", StatusCode: http.StatusBadRequest}
+	IllegalVersioningConfig  = &ErrorCode{ErrorCode: "IllegalVersioningConfigurationException", ErrorMessage: "The Versioning element must be specified.", StatusCode: http.StatusBadRequest}
+	DeleteMarkerNotAllowed   = &ErrorCode{ErrorCode: "MethodNotAllowed", ErrorMessage: "The specified method is not allowed against this resource.", StatusCode: http.StatusMethodNotAllowed}
+	InvalidVersionIdMarker   = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "A version-id marker cannot be specified without a key marker.", StatusCode: http.StatusBadRequest}
+	VersioningNotSuspendable = &ErrorCode{ErrorCode: "InvalidBucketState", ErrorMessage: "An Object Lock configuration is present on this bucket, so the versioning state cannot be changed.", StatusCode: http.StatusConflict}
+	CopySourceDeleteMarker   = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The source of a copy request may not specifically refer to a delete marker by version id.", StatusCode: http.StatusBadRequest}
+)
+
+type VersioningConfiguration struct {
+	XMLNS   string   `xml:"xmlns,attr,omitempty" json:"-"`
+	XMLName xml.Name `xml:"VersioningConfiguration" json:"-"`
+	Status  string   `xml:"Status,omitempty" json:"status"`
+}
+
+func (c *VersioningConfiguration) IsEnabled() bool {
+	return c != nil && c.Status == VersioningStatusEnabled
+}
+
+func (c *VersioningConfiguration) IsSuspended() bool {
+	return c != nil && c.Status == VersioningStatusSuspended
+}
+
+// parse VersioningConfiguration from xml
+func ParseVersioningConfigFromXML(data []byte) (*VersioningConfiguration, error) {
+	config := &VersioningConfiguration{}
+	if err := xml.Unmarshal(data, config); err != nil {
+		return nil, MalformedXML
+	}
+	switch config.Status {
+	case VersioningStatusEnabled, VersioningStatusSuspended:
+	default:
+		return nil, IllegalVersioningConfig
+	}
+	return config, nil
+}
+
+func storeBucketVersioning(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSVersioning, bytes)
+}
+
+// assignObjectVersion returns the version ID of a new object according to the versioning status of the bucket,
+// the version ID is recorded in the attrs to be stored. Objects have no version ID if versioning has never been
+// configured for the bucket.
+func assignObjectVersion(status string, attrs map[string]string) (versionId string) {
+	switch status {
+	case VersioningStatusEnabled:
+		versionId = newObjectVersionId(time.Now())
+		attrs[XAttrKeyOSSVersionId] = versionId
+	case VersioningStatusSuspended:
+		versionId = NullVersionId
+		delete(attrs, XAttrKeyOSSVersionId)
+	}
+	return
+}
+
+// Version IDs are made up of the reversed creation time and a random suffix, so that
+// the version entries of an object are listed from the newest to the oldest by readdir.
+func newObjectVersionId(t time.Time) string {
+	suffix := make([]byte, versionRandomLen/2)
+	_, _ = rand.Read(suffix)
+	return versionTimePrefix(t) + hex.EncodeToString(suffix)
+}
+
+func versionTimePrefix(t time.Time) string {
+	return fmt.Sprintf("%016x", uint64(math.MaxInt64-t.UnixNano()))
+}
+
+// versionEntryName returns the dentry name of a version in the version tree.
+// Null versions have no time order in their ID, the modify time of the object is used instead.
+func versionEntryName(versionId string, modifyTime time.Time) string {
+	if versionId == NullVersionId {
+		return versionTimePrefix(modifyTime) + NullVersionId
+	}
+	return versionId
+}
+
+// versionIdFromEntryName is the reverse of versionEntryName.
+func versionIdFromEntryName(name string) string {
+	if len(name) == versionTimeLen+len(NullVersionId) && strings.HasSuffix(name, NullVersionId) {
+		return NullVersionId
+	}
+	return name
+}
+
+func isValidVersionId(versionId string) bool {
+	if versionId == NullVersionId {
+		return true
+	}
+	if len(versionId) != versionTimeLen+versionRandomLen {
+		return false
+	}
+	_, err := hex.DecodeString(versionId)
+	return err == nil
+}
+
+// versionDirNames returns the names of the directories from the volume root to the version directory of the object.
+func versionDirNames(path string) []string {
+	names := []string{ObjectVersionRootDir}
+	for _, item := range NewPathIterator(path).ToSlice() {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+func versionEntryPath(path, entryName string) string {
+	return strings.Join(append(versionDirNames(path), entryName), pathSep)
+}
+
+// objectKeyLess compares object keys component by component, which is the order of listing
+// objects by walking the directory tree.
+func objectKeyLess(a, b string) bool {
+	as, bs := strings.Split(a, pathSep), strings.Split(b, pathSep)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return as[i] < bs[i]
+		}
+	}
+	return len(as) < len(bs)
+}
+
+func objectCommonPrefix(key, prefix, delimiter string) string {
+	if delimiter == "" || !strings.HasPrefix(key, prefix) {
+		return ""
+	}
+	if idx := strings.Index(key[len(prefix):], delimiter); idx >= 0 {
+		return key[:len(prefix)+idx+len(delimiter)]
+	}
+	return ""
+}
+
+// isReservedObjectPath checks whether the path belongs to the hidden version tree.
+func isReservedObjectPath(path string) bool {
+	path = strings.TrimPrefix(path, pathSep)
+	return path == ObjectVersionRootDir || strings.HasPrefix(path, ObjectVersionRootDir+pathSep)
+}
@@ -0,0 +1,324 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strconv"
+	"syscall"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketVersioning.html
+func (o *ObjectNode) getBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketVersioningHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var config *VersioningConfiguration
+	if config, err = vol.metaLoader.loadVersioning(); err != nil {
+		log.LogErrorf("getBucketVersioningHandler: load versioning fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	// a bucket never configured with versioning returns an empty configuration
+	output := &VersioningConfiguration{XMLNS: XMLNS}
+	if config != nil {
+		output.Status = config.Status
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(output); err != nil {
+		log.LogErrorf("getBucketVersioningHandler: xml marshal fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), output, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketVersioning.html
+func (o *ObjectNode) putBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketVersioningHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxVersioningConfigSize+1)); err != nil {
+		log.LogErrorf("putBucketVersioningHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxVersioningConfigSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var config *VersioningConfiguration
+	if config, err = ParseVersioningConfigFromXML(body); err != nil {
+		log.LogErrorf("putBucketVersioningHandler: parse versioning config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+
+	// objects protected by object lock must not be overwritten, so versioning can't be suspended.
+	if config.IsSuspended() {
+		var objectLock *ObjectLockConfig
+		if objectLock, err = vol.metaLoader.loadObjectLock(); err != nil {
+			log.LogErrorf("putBucketVersioningHandler: load object lock fail: requestID(%v) volume(%v) err(%v)",
+				GetRequestID(r), vol.Name(), err)
+			return
+		}
+		if objectLock != nil && !objectLock.IsEmpty() {
+			errorCode = VersioningNotSuspendable
+			return
+		}
+	}
+
+	if body, err = json.Marshal(config); err != nil {
+		log.LogErrorf("putBucketVersioningHandler: json marshal versioning config fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+	if err = storeBucketVersioning(body, vol); err != nil {
+		log.LogErrorf("putBucketVersioningHandler: store versioning config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	vol.metaLoader.storeVersioning(config)
+	log.LogInfof("Audit: put bucket versioning: requestID(%v) volume(%v) status(%v)",
+		GetRequestID(r), vol.Name(), config.Status)
+
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListObjectVersions.html
+func (o *ObjectNode) listObjectVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("listObjectVersionsHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+
+	// get options
+	prefix := r.URL.Query().Get(ParamPrefix)
+	delimiter := r.URL.Query().Get(ParamPartDelimiter)
+	keyMarker := r.URL.Query().Get(ParamKeyMarker)
+	versionIdMarker := r.URL.Query().Get(ParamVersionIdMarker)
+	maxKeys := r.URL.Query().Get(ParamMaxKeys)
+	encodingType := r.URL.Query().Get(ParamEncodingType)
+
+	var maxKeysInt uint64
+	if maxKeys != "" {
+		if maxKeysInt, err = strconv.ParseUint(maxKeys, 10, 16); err != nil {
+			log.LogErrorf("listObjectVersionsHandler: parse max keys fail: requestID(%v) volume(%v) maxKeys(%v) err(%v)",
+				GetRequestID(r), vol.Name(), maxKeys, err)
+			errorCode = InvalidArgument
+			return
+		}
+		if maxKeysInt > MaxKeys {
+			maxKeysInt = MaxKeys
+		}
+	} else {
+		maxKeysInt = MaxKeys
+	}
+	if encodingType != "" && encodingType != "url" {
+		errorCode = InvalidArgument
+		return
+	}
+	if versionIdMarker != "" {
+		if keyMarker == "" {
+			errorCode = InvalidVersionIdMarker
+			return
+		}
+		if !isValidVersionId(versionIdMarker) {
+			errorCode = InvalidVersionId
+			return
+		}
+	}
+
+	opt := &ListObjectVersionsOption{
+		Prefix:          prefix,
+		Delimiter:       delimiter,
+		KeyMarker:       keyMarker,
+		VersionIdMarker: versionIdMarker,
+		MaxKeys:         maxKeysInt,
+	}
+	var result *ListObjectVersionsResult
+	if result, err = vol.ListObjectVersions(opt); err != nil {
+		log.LogErrorf("listObjectVersionsHandler: list object versions fail: requestID(%v) volume(%v) opt(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), opt, err)
+		return
+	}
+
+	bucketOwner := NewBucketOwner(vol)
+	versions := make([]*ObjectVersion, 0, len(result.Versions))
+	for _, version := range result.Versions {
+		output := &ObjectVersion{
+			Key:          encodeKey(version.Path, encodingType),
+			VersionId:    version.VersionId,
+			IsLatest:     version.IsLatest,
+			LastModified: formatTimeISO(version.ModifyTime),
+			Owner:        bucketOwner,
+		}
+		if version.IsDeleteMarker {
+			output.XMLName = xml.Name{Local: "DeleteMarker"}
+		} else {
+			size := version.Size
+			output.XMLName = xml.Name{Local: "Version"}
+			output.ETag = wrapUnescapedQuot(version.ETag)
+			output.Size = &size
+			output.StorageClass = StorageClassStandard
+		}
+		versions = append(versions, output)
+	}
+	commonPrefixes := make([]*CommonPrefix, 0, len(result.CommonPrefixes))
+	for _, commonPrefix := range result.CommonPrefixes {
+		commonPrefixes = append(commonPrefixes, &CommonPrefix{Prefix: encodeKey(commonPrefix, encodingType)})
+	}
+
+	listVersionsResult := &ListVersionsResult{
+		Bucket:              param.Bucket(),
+		Prefix:              encodeKey(prefix, encodingType),
+		KeyMarker:           encodeKey(keyMarker, encodingType),
+		VersionIdMarker:     versionIdMarker,
+		NextKeyMarker:       encodeKey(result.NextKeyMarker, encodingType),
+		NextVersionIdMarker: result.NextVersionIdMarker,
+		MaxKeys:             int(maxKeysInt),
+		Delimiter:           encodeKey(delimiter, encodingType),
+		EncodingType:        encodingType,
+		IsTruncated:         result.Truncated,
+		Versions:            versions,
+		CommonPrefixes:      commonPrefixes,
+	}
+	var response []byte
+	if response, err = MarshalXMLEntity(listVersionsResult); err != nil {
+		log.LogErrorf("listObjectVersionsHandler: xml marshal result fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+
+	writeSuccessResponseXML(w, response)
+	return
+}
+
+// getObjectVersionMeta gets the meta of the object version specified by the versionId parameter,
+// or the current object if the parameter is absent. The version headers are set in the response,
+// and the error code tells a missing key or version and a delete marker.
+func getObjectVersionMeta(w http.ResponseWriter, r *http.Request, vol *Volume, key string) (info *FSFileInfo,
+	xattr *proto.XAttrInfo, errorCode *ErrorCode, err error) {
+	versionId := r.URL.Query().Get(ParamVersionId)
+	if versionId == "" {
+		if info, xattr, err = vol.ObjectMeta(key); err == syscall.ENOENT {
+			err, errorCode = nil, NoSuchKey
+			if markerId, ok := vol.LatestDeleteMarker(key); ok {
+				w.Header().Set(XAmzDeleteMarker, "true")
+				w.Header().Set(XAmzVersionId, markerId)
+			}
+			return
+		}
+		if err == nil {
+			setVersionIdHeader(w, vol, info.VersionId)
+		}
+		return
+	}
+
+	if !isValidVersionId(versionId) {
+		errorCode = InvalidVersionId
+		return
+	}
+	if info, xattr, err = vol.ObjectVersionMeta(key, versionId); err == syscall.ENOENT {
+		err, errorCode = nil, NoSuchVersion
+		return
+	}
+	if err != nil {
+		return
+	}
+	w.Header().Set(XAmzVersionId, versionId)
+	if info.IsDeleteMarker {
+		w.Header().Set(XAmzDeleteMarker, "true")
+		w.Header().Set(LastModified, formatTimeRFC1123(info.ModifyTime))
+		errorCode = DeleteMarkerNotAllowed
+	}
+	return
+}
+
+// setVersionIdHeader sets the version ID of the object in the response if versioning
+// has been configured for the bucket, objects without version ID are the null version.
+func setVersionIdHeader(w http.ResponseWriter, vol *Volume, versionId string) {
+	if versionId == "" {
+		if status, err := vol.versioningStatus(); err != nil || status == "" {
+			return
+		}
+		versionId = NullVersionId
+	}
+	w.Header().Set(XAmzVersionId, versionId)
+}
@@ -0,0 +1,120 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersioningConfig(t *testing.T) {
+	tests := []struct {
+		value       string
+		status      string
+		expectedErr error
+	}{
+		{
+			value: `<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Status>Enabled</Status>
+					</VersioningConfiguration>`,
+			status: VersioningStatusEnabled,
+		},
+		{
+			value: `<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Status>Suspended</Status>
+					</VersioningConfiguration>`,
+			status: VersioningStatusSuspended,
+		},
+		{
+			value: `<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Status>Disabled</Status>
+					</VersioningConfiguration>`,
+			expectedErr: IllegalVersioningConfig,
+		},
+		{
+			value:       `<VersioningConfiguration></VersioningConfiguration>`,
+			expectedErr: IllegalVersioningConfig,
+		},
+		{
+			value:       `<VersioningConfiguration><Status>Enabled</Status>`,
+			expectedErr: MalformedXML,
+		},
+	}
+	for _, tt := range tests {
+		config, err := ParseVersioningConfigFromXML([]byte(tt.value))
+		require.Equal(t, tt.expectedErr, err)
+		if err == nil {
+			require.Equal(t, tt.status, config.Status)
+		}
+	}
+}
+
+func TestObjectVersionId(t *testing.T) {
+	now := time.Now()
+	ids := []string{
+		newObjectVersionId(now.Add(-time.Hour)),
+		newObjectVersionId(now),
+		newObjectVersionId(now.Add(time.Hour)),
+	}
+	for _, id := range ids {
+		require.True(t, isValidVersionId(id))
+		require.Equal(t, id, versionIdFromEntryName(versionEntryName(id, now)))
+	}
+	// newer versions are listed first
+	require.True(t, sort.SliceIsSorted(ids, func(i, j int) bool { return ids[i] > ids[j] }))
+
+	entry := versionEntryName(NullVersionId, now)
+	require.Equal(t, versionTimePrefix(now)+NullVersionId, entry)
+	require.Equal(t, NullVersionId, versionIdFromEntryName(entry))
+	require.True(t, entry > ids[2] && entry < ids[0])
+
+	require.True(t, isValidVersionId(NullVersionId))
+	require.False(t, isValidVersionId(""))
+	require.False(t, isValidVersionId("0123456789abcdef0123456z"))
+	require.False(t, isValidVersionId("0123456789abcdef"))
+}
+
+func TestAssignObjectVersion(t *testing.T) {
+	attrs := make(map[string]string)
+	require.Equal(t, "", assignObjectVersion("", attrs))
+	require.NotContains(t, attrs, XAttrKeyOSSVersionId)
+
+	versionId := assignObjectVersion(VersioningStatusEnabled, attrs)
+	require.True(t, isValidVersionId(versionId))
+	require.Equal(t, versionId, attrs[XAttrKeyOSSVersionId])
+
+	require.Equal(t, NullVersionId, assignObjectVersion(VersioningStatusSuspended, attrs))
+	require.NotContains(t, attrs, XAttrKeyOSSVersionId)
+}
+
+func TestObjectKeyOrder(t *testing.T) {
+	keys := []string{"a/b", "a-1", "a", "a/", "b", "a/b/c", "a/b-1"}
+	sort.Slice(keys, func(i, j int) bool { return objectKeyLess(keys[i], keys[j]) })
+	require.Equal(t, []string{"a", "a/", "a/b", "a/b/c", "a/b-1", "a-1", "b"}, keys)
+
+	require.Equal(t, "a/", objectCommonPrefix("a/b/c", "", "/"))
+	require.Equal(t, "a/b/", objectCommonPrefix("a/b/c", "a/", "/"))
+	require.Equal(t, "", objectCommonPrefix("a/b", "a/", "/"))
+	require.Equal(t, "", objectCommonPrefix("a/b", "", ""))
+	require.Equal(t, "", objectCommonPrefix("b/c", "a/", "/"))
+
+	require.True(t, isReservedObjectPath(ObjectVersionRootDir))
+	require.True(t, isReservedObjectPath("/"+ObjectVersionRootDir+"/a"))
+	require.False(t, isReservedObjectPath(ObjectVersionRootDir+"-a"))
+	require.Equal(t, ObjectVersionRootDir+"/a/b/v1", versionEntryPath("a/b", "v1"))
+}
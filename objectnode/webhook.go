@@ -15,11 +15,15 @@
 package objectnode
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 type WebhookConfig struct {
@@ -68,3 +72,29 @@ func (c *WebhookConfig) BuildClient() (*http.Client, error) {
 
 	return &http.Client{Transport: transport}, nil
 }
+
+// Post sends the data to the endpoint in a JSON request, the non 2xx status code is returned as an error.
+func (c *WebhookConfig) Post(client *http.Client, userAgent string, data []byte) error {
+	req, err := http.NewRequest(http.MethodPost, c.Endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(ContentType, ValueContentTypeJSON)
+	req.Header.Set(UserAgent, userAgent)
+	if c.Authorization != "" {
+		req.Header.Set(Authorization, c.Authorization)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	resp, err := client.Do(req.WithContext(ctx))
+	if resp != nil && resp.Body != nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	if err != nil || resp.StatusCode/100 == 2 {
+		return err
+	}
+
+	return fmt.Errorf("%s returns '%s' statuscode", c.Endpoint, resp.Status)
+}
@@ -49,6 +49,12 @@ const (
 	AdminVolExpand                            = "/vol/expand"
 	AdminVolForbidden                         = "/vol/forbidden"
 	AdminVolEnableAuditLog                    = "/vol/auditlog"
+	AdminSetVolReplication                    = "/vol/replication/set"
+	AdminGetVolReplication                    = "/vol/replication/get"
+	AdminDelVolReplication                    = "/vol/replication/delete"
+	AdminCloneVol                             = "/vol/clone"
+	AdminRenameVol                            = "/vol/rename"
+	AdminPromoteVol                           = "/vol/promote"
 	AdminCreateVol                            = "/admin/createVol"
 	AdminGetVol                               = "/admin/getVol"
 	AdminClusterFreeze                        = "/cluster/freeze"
@@ -99,6 +105,8 @@ const (
 	AdminQueryDecommissionDiskLimit   = "/admin/queryDecommissionDiskLimit"
 	AdminEnableAutoDecommissionDisk   = "/admin/enableAutoDecommissionDisk"
 	AdminQueryAutoDecommissionDisk    = "/admin/queryAutoDecommissionDisk"
+
+	AdminListAudit = "/admin/audit/list"
 	// graphql master api
 	AdminClusterAPI = "/api/cluster"
 	AdminUserAPI    = "/api/user"
@@ -150,6 +158,7 @@ const (
 	MigrateDataNode                    = "/dataNode/migrate"
 	PauseDecommissionDataNode          = "/dataNode/pauseDecommission"
 	CancelDecommissionDataNode         = "/dataNode/cancelDecommission"
+	SetDataNodeMaintenance             = "/dataNode/maintenance"
 	DecommissionDisk                   = "/disk/decommission"
 	RecommissionDisk                   = "/disk/recommission"
 	QueryDiskDecoProgress              = "/disk/queryDecommissionProgress"
@@ -166,6 +175,7 @@ const (
 	DecommissionMetaNode               = "/metaNode/decommission"
 	MigrateMetaNode                    = "/metaNode/migrate"
 	GetMetaNode                        = "/metaNode/get"
+	SetMetaNodeMaintenance             = "/metaNode/maintenance"
 	AdminUpdateMetaNode                = "/metaNode/update"
 	AdminUpdateDataNode                = "/dataNode/update"
 	AdminGetInvalidNodes               = "/invalid/nodes"
@@ -174,6 +184,11 @@ const (
 	AdminDecommissionMetaPartition     = "/metaPartition/decommission"
 	AdminChangeMetaPartitionLeader     = "/metaPartition/changeleader"
 	AdminBalanceMetaPartitionLeader    = "/metaPartition/balanceLeader"
+	AdminSplitMetaPartition            = "/metaPartition/split"
+	AdminMergeMetaPartition            = "/metaPartition/merge"
+	AdminStartDataRebalance            = "/dataPartition/rebalance/start"
+	AdminStopDataRebalance             = "/dataPartition/rebalance/stop"
+	AdminGetDataRebalance              = "/dataPartition/rebalance/status"
 	AdminAddMetaReplica                = "/metaReplica/add"
 	AdminDeleteMetaReplica             = "/metaReplica/delete"
 	AdminPutDataPartitions             = "/dataPartitions/set"
@@ -183,6 +198,9 @@ const (
 	AdminDelVersion        = "/multiVer/del"
 	AdminGetVersionInfo    = "/multiVer/get"
 	AdminGetAllVersionInfo = "/multiVer/getAll"
+	AdminCreateDirSnapshot = "/multiVer/dirSnapshot/create"
+	AdminDelDirSnapshot    = "/multiVer/dirSnapshot/del"
+	AdminListDirSnapshot   = "/multiVer/dirSnapshot/list"
 	AdminGetVolVer         = "/vol/getVer"
 	AdminSetVerStrategy    = "/vol/SetVerStrategy"
 
@@ -236,6 +254,10 @@ const (
 	// QuotaBatchModifyPath = "/quota/batchModifyPath"
 	QuotaListAll = "/quota/listAll"
 
+	UserQuotaSet    = "/quota/user/set"
+	UserQuotaDelete = "/quota/user/delete"
+	UserQuotaReport = "/quota/user/report"
+
 	// s3 qos api
 	S3QoSSet    = "/s3/qos/set"
 	S3QoSGet    = "/s3/qos/get"
@@ -247,6 +269,7 @@ var GApiInfo map[string]string = map[string]string{
 	"adminsetapiqpslimit":                AdminSetApiQpsLimit,
 	"admingetcluster":                    AdminGetCluster,
 	"adminsetclusterinfo":                AdminSetClusterInfo,
+	"adminlistaudit":                     AdminListAudit,
 	"admingetdatapartition":              AdminGetDataPartition,
 	"adminloaddatapartition":             AdminLoadDataPartition,
 	"admincreatedatapartition":           AdminCreateDataPartition,
@@ -260,6 +283,9 @@ var GApiInfo map[string]string = map[string]string{
 	"adminvolshrink":                     AdminVolShrink,
 	"adminvolexpand":                     AdminVolExpand,
 	"admincreatevol":                     AdminCreateVol,
+	"adminclonevol":                      AdminCloneVol,
+	"adminrenamevol":                     AdminRenameVol,
+	"adminpromotevol":                    AdminPromoteVol,
 	"admingetvol":                        AdminGetVol,
 	"adminclusterfreeze":                 AdminClusterFreeze,
 	"adminclusterforbidmpdecommission":   AdminClusterForbidMpDecommission,
@@ -314,12 +340,14 @@ var GApiInfo map[string]string = map[string]string{
 	"decommissiondatanode":            DecommissionDataNode,
 	"migratedatanode":                 MigrateDataNode,
 	"canceldecommissiondatanode":      PauseDecommissionDataNode,
+	"setdatanodemaintenance":          SetDataNodeMaintenance,
 	"decommissiondisk":                DecommissionDisk,
 	"getdatanode":                     GetDataNode,
 	"addmetanode":                     AddMetaNode,
 	"decommissionmetanode":            DecommissionMetaNode,
 	"migratemetanode":                 MigrateMetaNode,
 	"getmetanode":                     GetMetaNode,
+	"setmetanodemaintenance":          SetMetaNodeMaintenance,
 	"adminupdatemetanode":             AdminUpdateMetaNode,
 	"adminupdatedatanode":             AdminUpdateDataNode,
 	"admingetinvalidnodes":            AdminGetInvalidNodes,
@@ -328,6 +356,8 @@ var GApiInfo map[string]string = map[string]string{
 	"admindecommissionmetapartition":  AdminDecommissionMetaPartition,
 	"adminchangemetapartitionleader":  AdminChangeMetaPartitionLeader,
 	"adminbalancemetapartitionleader": AdminBalanceMetaPartitionLeader,
+	"adminsplitmetapartition":         AdminSplitMetaPartition,
+	"adminmergemetapartition":         AdminMergeMetaPartition,
 	"adminaddmetareplica":             AdminAddMetaReplica,
 	"admindeletemetareplica":          AdminDeleteMetaReplica,
 	"getmetanodetaskresponse":         GetMetaNodeTaskResponse,
@@ -523,6 +553,7 @@ type CreateDataPartitionRequest struct {
 	DecommissionedDisks []string
 	IsMultiVer          bool
 	VerSeq              uint64
+	MediaType           uint32 // the media class of the disk to place the partition, unspecified means any
 }
 
 // CreateDataPartitionResponse defines the response to the request of creating a data partition.
@@ -578,6 +609,20 @@ type RemoveMetaPartitionRaftMemberRequest struct {
 	RemovePeer  Peer
 }
 
+// PromoteDataPartitionLearnerRequest defines the request of promoting the learner of a data partition.
+type PromoteDataPartitionLearnerRequest struct {
+	PartitionId uint64
+	PromotePeer Peer
+	ReplacePeer Peer // the voter replaced by the learner through a joint consensus, if its ID is set
+}
+
+// PromoteMetaPartitionLearnerRequest defines the request of promoting the learner of a meta partition.
+type PromoteMetaPartitionLearnerRequest struct {
+	PartitionId uint64
+	PromotePeer Peer
+	ReplacePeer Peer // the voter replaced by the learner through a joint consensus, if its ID is set
+}
+
 // LoadDataPartitionRequest defines the request of loading a data partition.
 type LoadDataPartitionRequest struct {
 	PartitionId uint64
@@ -606,6 +651,12 @@ type StopDataPartitionRepairResponse struct {
 	PartitionId uint64
 }
 
+// UpdateDataPartitionVolNameRequest tells the replica the new name of its volume renamed.
+type UpdateDataPartitionVolNameRequest struct {
+	PartitionId uint64
+	VolName     string
+}
+
 // File defines the file struct.
 type File struct {
 	Name     string
@@ -685,6 +736,7 @@ type HeartBeatRequest struct {
 	FileStatsEnable bool
 	UidLimitToMetaNode
 	QuotaHeartBeatInfos
+	UserQuotaHbInfos []*UserQuotaHeartBeatInfo
 	TxInfos
 	ForbiddenVols     []string
 	DisableAuditVols  []string
@@ -698,11 +750,13 @@ type DataPartitionReport struct {
 	PartitionStatus            int
 	Total                      uint64
 	Used                       uint64
+	PhysicalUsed               uint64 // used space excluding the space saved by the compression
 	DiskPath                   string
 	IsLeader                   bool
 	ExtentCount                int
 	NeedCompare                bool
 	DecommissionRepairProgress float64
+	MediaType                  uint32 // media class of the disk holding the partition
 }
 
 type DataNodeQosResponse struct {
@@ -726,6 +780,8 @@ type DiskStat struct {
 	TotalPartitionCnt int
 
 	DiskErrPartitionList []uint64
+
+	MediaType uint32
 }
 
 // DataNodeHeartbeatResponse defines the response to the data node heartbeat.
@@ -739,6 +795,8 @@ type DataNodeHeartbeatResponse struct {
 	MaxCapacity         uint64 // maximum capacity to create partition
 	StartTime           int64
 	ZoneName            string
+	RackName            string
+	HostName            string
 	PartitionReports    []*DataPartitionReport
 	Status              uint8
 	Result              string
@@ -746,6 +804,8 @@ type DataNodeHeartbeatResponse struct {
 	DiskStats           []DiskStat         // key: disk path
 	CpuUtil             float64            `json:"cpuUtil"`
 	IoUtils             map[string]float64 `json:"ioUtil"`
+	EffectiveUsed       uint64             // space used by the partitions before the compression
+	PhysicalUsed        uint64             // space used by the partitions on the disks
 }
 
 // MetaPartitionReport defines the meta partition report.
@@ -766,11 +826,16 @@ type MetaPartitionReport struct {
 	FreeListLen      uint64
 	UidInfo          []*UidReportSpaceInfo
 	QuotaReportInfos []*QuotaReportInfo
+	UserQuotaReports []*UserQuotaReportInfo
+	SplitFrom        uint64 // the partition this one is split from
+	MergeTo          uint64 // the partition this one is being merged into
 }
 
 // MetaNodeHeartbeatResponse defines the response to the meta node heartbeat request.
 type MetaNodeHeartbeatResponse struct {
 	ZoneName             string
+	RackName             string
+	HostName             string
 	Total                uint64
 	MemUsed              uint64
 	MetaPartitionReports []*MetaPartitionReport
@@ -786,6 +851,7 @@ type LcNodeHeartbeatResponse struct {
 	LcTaskCountLimit      int
 	LcScanningTasks       map[string]*LcNodeRuleTaskResponse
 	SnapshotScanningTasks map[string]*SnapshotVerDelTaskResponse
+	ReplicationTasks      map[string]*ReplicationTaskResponse
 }
 
 // DeleteFileRequest defines the request to delete a file.
@@ -831,6 +897,59 @@ type UpdateMetaPartitionResponse struct {
 	Result      string
 }
 
+// SplitMetaPartitionRequest defines the request to split the inodes not less than SplitKey of a meta
+// partition into a new partition. The median of the inodes is taken if SplitKey is 0.
+type SplitMetaPartitionRequest struct {
+	PartitionID    uint64
+	NewPartitionID uint64
+	VolName        string
+	SplitKey       uint64
+}
+
+// SplitMetaPartitionResponse defines the response to the request of splitting a meta partition.
+type SplitMetaPartitionResponse struct {
+	PartitionID    uint64
+	NewPartitionID uint64
+	SplitKey       uint64
+	End            uint64
+	Status         uint8
+	Result         string
+}
+
+// FreezeMetaPartitionRequest defines the request to stop or resume the writes of a meta partition
+// which is going to be merged into the partition MergeTo.
+type FreezeMetaPartitionRequest struct {
+	PartitionID uint64
+	VolName     string
+	MergeTo     uint64
+	Frozen      bool
+}
+
+// FreezeMetaPartitionResponse defines the response to the request of freezing a meta partition.
+type FreezeMetaPartitionResponse struct {
+	PartitionID uint64
+	ApplyID     uint64
+	Status      uint8
+	Result      string
+}
+
+// MergeMetaPartitionRequest defines the request to merge the frozen partition SourceID into the
+// adjacent partition PartitionID, the source is merged once it has applied SourceApplyID.
+type MergeMetaPartitionRequest struct {
+	PartitionID   uint64
+	VolName       string
+	SourceID      uint64
+	SourceApplyID uint64
+}
+
+// MergeMetaPartitionResponse defines the response to the request of merging meta partitions.
+type MergeMetaPartitionResponse struct {
+	PartitionID uint64
+	End         uint64
+	Status      uint8
+	Result      string
+}
+
 // MetaPartitionDecommissionRequest defines the request of decommissioning a meta partition.
 type MetaPartitionDecommissionRequest struct {
 	PartitionID uint64
@@ -876,6 +995,7 @@ type DataPartitionResponse struct {
 	IsRecover     bool
 	PartitionTTL  int64
 	IsDiscard     bool
+	MediaType     uint32
 }
 
 // DataPartitionsView defines the view of a data partition
@@ -932,6 +1052,7 @@ type VolView struct {
 	OSSSecure      *OSSSecure
 	CreateTime     int64
 	DeleteLockTime int64
+	TrashRetention int64 // minutes the unlinked files are kept in the trash, 0 disables the trash
 	CacheTTL       int
 	VolType        int
 }
@@ -1076,6 +1197,14 @@ type SimpleVolView struct {
 	EnableToken             bool
 	EnablePosixAcl          bool
 	EnableQuota             bool
+	Compression             string // block compression of the datanode extents
+	MediaType               string // preferred media class of the data partitions
+	MediaFallback           bool   // whether the partitions are placed on the other media if the preferred one is full
+	TrashRetention          int64  // minutes the unlinked files are kept in the trash, 0 disables the trash
+	CloneSrc                string // the volume this one is cloned from
+	CloneVerSeq             uint64 // the version of CloneSrc this one is cloned from
+	PromotePath             string // the directory of CloneSrc this one is promoted from
+	MetaStoreType           string // engine keeping the items of the meta partitions
 	EnableTransaction       string
 	TxTimeout               int64
 	TxConflictRetryNum      int64
@@ -1187,7 +1316,8 @@ type NodeSetView struct {
 
 // TopologyView provides the view of the topology view of the cluster
 type TopologyView struct {
-	Zones []*ZoneView
+	Zones      []*ZoneView
+	Violations []*FailureDomainViolation `json:",omitempty"`
 }
 
 const (
@@ -45,6 +45,8 @@ type ExtSnapInfo struct {
 	VerSeq  uint64
 	IsSplit bool
 	ModGen  uint64
+	// IsShared is set by the metanode if the extent is shared by several inodes, it is not persisted.
+	IsShared bool `json:",omitempty"`
 }
 
 // ExtentKey defines the extent key struct.
@@ -137,6 +139,24 @@ func (k *ExtentKey) SetSplit(split bool) {
 	k.SnapInfo.IsSplit = split
 }
 
+// IsShared returns whether the extent is shared with other inodes, which must not be overwritten in place.
+func (k *ExtentKey) IsShared() bool {
+	if k.SnapInfo == nil {
+		return false
+	}
+	return k.SnapInfo.IsShared
+}
+
+// SetShared marks the extent as shared, the snap info is copied since it may be referred by other keys.
+func (k *ExtentKey) SetShared(shared bool) {
+	info := ExtSnapInfo{}
+	if k.SnapInfo != nil {
+		info = *k.SnapInfo
+	}
+	info.IsShared = shared
+	k.SnapInfo = &info
+}
+
 func (k *ExtentKey) GenerateId() uint64 {
 	if k.PartitionId > math.MaxUint32 || k.ExtentId > math.MaxUint32 {
 		log.LogFatalf("ext %v abnormal", k)
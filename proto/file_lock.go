@@ -0,0 +1,113 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	FileLockRead uint8 = iota + 1
+	FileLockWrite
+	FileLockUnlock
+)
+
+const (
+	// LockLeaseTimeout is the lease of the locks held by a client session, the locks are
+	// released by the metanode if the session is not renewed in time, e.g. the client crashes.
+	LockLeaseTimeout       = 15 * time.Second
+	LockLeaseRenewInterval = 5 * time.Second
+	// LockWaitTimeout is the max time of a blocking lock request waiting in the metanode,
+	// which must be less than the read deadline of the meta requests.
+	LockWaitTimeout = 3 * time.Second
+)
+
+// FileLock is an advisory lock of a range of the inode, the End is inclusive.
+// A lock is owned by the lock owner assigned by the kernel in the client session.
+// The flock and POSIX locks are independent and never conflict with each other.
+type FileLock struct {
+	Session uint64 `json:"sid"`
+	Owner   uint64 `json:"owner"`
+	Flock   bool   `json:"flock"`
+	Type    uint8  `json:"type"`
+	Start   uint64 `json:"start"`
+	End     uint64 `json:"end"`
+	Pid     uint32 `json:"pid"`
+}
+
+func (l *FileLock) String() string {
+	return fmt.Sprintf("FileLock{sid(%v) owner(%v) flock(%v) type(%v) range(%v-%v) pid(%v)}",
+		l.Session, l.Owner, l.Flock, l.Type, l.Start, l.End, l.Pid)
+}
+
+func (l *FileLock) Valid() bool {
+	return l.Type >= FileLockRead && l.Type <= FileLockUnlock && l.Start <= l.End
+}
+
+func (l *FileLock) sameOwner(o *FileLock) bool {
+	return l.Session == o.Session && l.Owner == o.Owner && l.Flock == o.Flock
+}
+
+func (l *FileLock) overlaps(o *FileLock) bool {
+	return l.Start <= o.End && o.Start <= l.End
+}
+
+// Conflicts returns whether the locks are not allowed to be held at the same time.
+func (l *FileLock) Conflicts(o *FileLock) bool {
+	if l.Flock != o.Flock || l.sameOwner(o) || !l.overlaps(o) {
+		return false
+	}
+	return l.Type == FileLockWrite || o.Type == FileLockWrite
+}
+
+// ConflictFileLock returns the first one of the locks conflicting with lk, nil is returned if none.
+func ConflictFileLock(locks []*FileLock, lk *FileLock) *FileLock {
+	for _, l := range locks {
+		if l.Conflicts(lk) {
+			return l
+		}
+	}
+	return nil
+}
+
+// SetFileLock applies lk to the locks of the same owner and returns the new locks, the range of lk
+// replaces the overlapped ranges of the owner, and is released if the type is FileLockUnlock.
+// The conflicts with the other owners should be checked before.
+func SetFileLock(locks []*FileLock, lk *FileLock) []*FileLock {
+	result := make([]*FileLock, 0, len(locks)+2)
+	for _, l := range locks {
+		if !l.sameOwner(lk) || !l.overlaps(lk) {
+			result = append(result, l)
+			continue
+		}
+		// keep the parts of the old lock out of the range
+		if l.Start < lk.Start {
+			head := *l
+			head.End = lk.Start - 1
+			result = append(result, &head)
+		}
+		if l.End > lk.End {
+			tail := *l
+			tail.Start = lk.End + 1
+			result = append(result, &tail)
+		}
+	}
+	if lk.Type != FileLockUnlock {
+		newLock := *lk
+		result = append(result, &newLock)
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileLockConflicts(t *testing.T) {
+	read := &FileLock{Session: 1, Owner: 1, Type: FileLockRead, Start: 0, End: 9}
+	require.False(t, read.Conflicts(&FileLock{Session: 2, Owner: 1, Type: FileLockRead, Start: 5, End: 5}))
+	require.True(t, read.Conflicts(&FileLock{Session: 2, Owner: 1, Type: FileLockWrite, Start: 9, End: 20}))
+	require.False(t, read.Conflicts(&FileLock{Session: 2, Owner: 1, Type: FileLockWrite, Start: 10, End: 20}))
+	// the same owner never conflicts, the owners of the different sessions are different
+	require.False(t, read.Conflicts(&FileLock{Session: 1, Owner: 1, Type: FileLockWrite, Start: 0, End: 9}))
+	require.True(t, read.Conflicts(&FileLock{Session: 1, Owner: 2, Type: FileLockWrite, Start: 0, End: 9}))
+	// flock and POSIX locks are independent
+	require.False(t, read.Conflicts(&FileLock{Session: 2, Owner: 1, Flock: true, Type: FileLockWrite, End: math.MaxUint64}))
+
+	require.True(t, read.Valid())
+	require.False(t, (&FileLock{Type: FileLockRead, Start: 2, End: 1}).Valid())
+	require.False(t, (&FileLock{Type: 0}).Valid())
+}
+
+func TestSetFileLock(t *testing.T) {
+	other := &FileLock{Session: 2, Owner: 1, Type: FileLockRead, Start: 0, End: 100}
+	locks := []*FileLock{other}
+	locks = SetFileLock(locks, &FileLock{Session: 1, Owner: 1, Type: FileLockRead, Start: 0, End: 99})
+	require.Len(t, locks, 2)
+
+	// upgrade the middle of the range
+	locks = SetFileLock(locks, &FileLock{Session: 1, Owner: 1, Type: FileLockWrite, Start: 10, End: 19})
+	require.Len(t, locks, 4)
+	require.Equal(t, other, locks[0])
+	require.Equal(t, FileLock{Session: 1, Owner: 1, Type: FileLockRead, Start: 0, End: 9}, *locks[1])
+	require.Equal(t, FileLock{Session: 1, Owner: 1, Type: FileLockRead, Start: 20, End: 99}, *locks[2])
+	require.Equal(t, FileLock{Session: 1, Owner: 1, Type: FileLockWrite, Start: 10, End: 19}, *locks[3])
+
+	// unlock to the end of file
+	locks = SetFileLock(locks, &FileLock{Session: 1, Owner: 1, Type: FileLockUnlock, Start: 5, End: math.MaxUint64})
+	require.Len(t, locks, 2)
+	require.Equal(t, FileLock{Session: 1, Owner: 1, Type: FileLockRead, Start: 0, End: 4}, *locks[1])
+
+	locks = SetFileLock(locks, &FileLock{Session: 1, Owner: 1, Type: FileLockUnlock, Start: 0, End: math.MaxUint64})
+	require.Equal(t, []*FileLock{other}, locks)
+}
@@ -727,11 +727,73 @@ type DelVerRequest struct {
 	VerSeq      uint64 `json:"ver"`
 }
 
+// DelExtentKeyRequest defines the request to punch a hole in the range of the inode,
+// the data of the range is released and reads as zeros.
 type DelExtentKeyRequest struct {
-	VolName     string      `json:"vol"`
-	PartitionID uint64      `json:"pid"`
-	Inode       uint64      `json:"ino"`
-	Extents     []ExtentKey `json:"ek"`
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	Inode       uint64 `json:"ino"`
+	Offset      uint64 `json:"off"`
+	Size        uint64 `json:"sz"`
+}
+
+// CloneExtentsRequest defines the request to share the extents of a range of the source inode
+// with the destination inode, both of which must be in the same meta partition.
+type CloneExtentsRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	SrcInode    uint64 `json:"src"`
+	SrcOffset   uint64 `json:"srcOff"`
+	DstInode    uint64 `json:"dst"`
+	DstOffset   uint64 `json:"dstOff"`
+	Size        uint64 `json:"sz"`
+}
+
+// CloneExtentsResponse defines the response to the request of cloning extents,
+// the range is cut at the end of the source inode.
+type CloneExtentsResponse struct {
+	Size uint64 `json:"sz"`
+}
+
+// The types of the metadata changes, the inode changes cover the attributes, the xattrs and the data.
+const (
+	MetaChangeDentryCreate uint8 = iota + 1
+	MetaChangeDentryDelete
+	MetaChangeDentryUpdate
+	MetaChangeInodeUpdate
+	MetaChangeInodeCreate
+	MetaChangeInodeUnlink
+	MetaChangeInodeEvict
+)
+
+// MetaChange defines a metadata mutation applied to a meta partition, Index is the raft index it is applied at.
+// PartitionID is filled by the reader of the changes of a volume.
+type MetaChange struct {
+	PartitionID uint64 `json:"pid,omitempty"`
+	Index       uint64 `json:"idx"`
+	Type        uint8  `json:"tp"`
+	Inode       uint64 `json:"ino"`
+	ParentIno   uint64 `json:"pino,omitempty"`
+	Name        string `json:"name,omitempty"`
+}
+
+// ReadMetaChangesRequest defines the request to read the changes applied after the cursor From.
+type ReadMetaChangesRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	From        uint64 `json:"from"`
+	Limit       uint32 `json:"limit"`
+}
+
+// ReadMetaChangesResponse defines the response to the request of reading the metadata changes.
+// Truncated is set if some changes after the cursor are not kept any more, the reader has to
+// resync the partition in full. Applied is the cursor to continue with once all the changes are read,
+// and Last is the latest raft index applied to the partition.
+type ReadMetaChangesResponse struct {
+	Changes   []*MetaChange `json:"changes"`
+	Applied   uint64        `json:"applied"`
+	Last      uint64        `json:"last"`
+	Truncated bool          `json:"truncated"`
 }
 
 // SetAttrRequest defines the request to set attribute.
@@ -869,6 +931,100 @@ type UpdateXAttrRequest struct {
 	Value       string `json:"val"`
 }
 
+// SetLockRequest acquires the lock of the inode, or releases it if the type of the lock is FileLockUnlock.
+type SetLockRequest struct {
+	VolName     string   `json:"vol"`
+	PartitionId uint64   `json:"pid"`
+	Inode       uint64   `json:"ino"`
+	Lock        FileLock `json:"lock"`
+	// Wait for the conflicting locks to be released, the request fails if they are
+	// still held after LockWaitTimeout so that the client is able to be interrupted.
+	Wait bool `json:"wait"`
+}
+
+// GetLockRequest queries the lock conflicting with the given one.
+type GetLockRequest struct {
+	VolName     string   `json:"vol"`
+	PartitionId uint64   `json:"pid"`
+	Inode       uint64   `json:"ino"`
+	Lock        FileLock `json:"lock"`
+}
+
+type GetLockResponse struct {
+	Lock *FileLock `json:"lock"` // nil if there is no conflicting lock
+}
+
+// RenewLockLeaseRequest renews the lease of the session, the locks held by the session are
+// restored if they are lost, e.g. the leader of the meta partition is changed.
+type RenewLockLeaseRequest struct {
+	VolName     string                 `json:"vol"`
+	PartitionId uint64                 `json:"pid"`
+	Session     uint64                 `json:"sid"`
+	Locks       map[uint64][]*FileLock `json:"locks"`
+}
+
+type RenewLockLeaseResponse struct {
+	// Inodes whose locks are not able to be restored since they are acquired by others.
+	Lost []uint64 `json:"lost"`
+}
+
+// TrashInodeRequest moves the dentry unlinked from the inode into the trash instead of unlinking the
+// inode, the inode keeps the link of the dentry until it is restored or purged.
+type TrashInodeRequest struct {
+	VolName     string `json:"vol"`
+	PartitionId uint64 `json:"pid"`
+	Inode       uint64 `json:"ino"`
+	ParentIno   uint64 `json:"pino"`
+	Name        string `json:"name"`
+	FullPath    string `json:"fullPath"`
+}
+
+// TrashEntry is a dentry kept in the trash, it belongs to the trash of the owner of the inode.
+type TrashEntry struct {
+	Inode      uint64 `json:"ino"`
+	ParentIno  uint64 `json:"pino"`
+	Name       string `json:"name"`
+	Uid        uint32 `json:"uid"`
+	Mode       uint32 `json:"mode"`
+	Size       uint64 `json:"sz"`
+	DeleteTime int64  `json:"dt"`
+}
+
+// ListTrashRequest lists the trash of the user, or the trash of all the users if AllUsers is set.
+// Only the dentries of the inode are listed if Inode is set.
+type ListTrashRequest struct {
+	VolName     string `json:"vol"`
+	PartitionId uint64 `json:"pid"`
+	Uid         uint32 `json:"uid"`
+	AllUsers    bool   `json:"all"`
+	Inode       uint64 `json:"ino"`
+}
+
+type ListTrashResponse struct {
+	Entries []*TrashEntry `json:"entries"`
+}
+
+// RestoreTrashRequest takes the dentry out of the trash, the client links it back to the parent.
+// The dentry is only taken by its owner or the root user.
+type RestoreTrashRequest struct {
+	VolName     string `json:"vol"`
+	PartitionId uint64 `json:"pid"`
+	Uid         uint32 `json:"uid"`
+	Inode       uint64 `json:"ino"`
+	ParentIno   uint64 `json:"pino"`
+	Name        string `json:"name"`
+}
+
+// PurgeTrashRequest drops the dentry in the trash and unlinks the inode.
+type PurgeTrashRequest struct {
+	VolName     string `json:"vol"`
+	PartitionId uint64 `json:"pid"`
+	Uid         uint32 `json:"uid"`
+	Inode       uint64 `json:"ino"`
+	ParentIno   uint64 `json:"pino"`
+	Name        string `json:"name"`
+}
+
 type MultipartInfo struct {
 	ID       string               `json:"id"`
 	Path     string               `json:"path"`
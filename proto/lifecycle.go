@@ -28,10 +28,11 @@ type LcConfiguration struct {
 }
 
 type Rule struct {
-	Expire *ExpirationConfig
-	Filter *FilterConfig
-	ID     string
-	Status string
+	Expire      *ExpirationConfig
+	Transitions []*TransitionConfig
+	Filter      *FilterConfig
+	ID          string
+	Status      string
 }
 
 type ExpirationConfig struct {
@@ -39,6 +40,12 @@ type ExpirationConfig struct {
 	Days int
 }
 
+type TransitionConfig struct {
+	Date         *time.Time
+	Days         int
+	StorageClass string
+}
+
 type FilterConfig struct {
 	Prefix string
 }
@@ -48,6 +55,20 @@ const (
 	RuleDisabled string = "Disabled"
 )
 
+// Objects of the standard storage class are stored in the replica data partitions,
+// while the objects transitioned to the cold storage class are stored in the blobstore.
+const (
+	StorageClassStandard string = "STANDARD"
+	StorageClassCold     string = "GLACIER_IR"
+)
+
+// The storage class of the transitioned object is recorded in the xattr of the inode.
+const XAttrKeyStorageClass = "oss:storage-class"
+
+func IsValidTransitionStorageClass(class string) bool {
+	return class == StorageClassCold
+}
+
 func (lcConf *LcConfiguration) GenEnabledRuleTasks() []*RuleTask {
 	tasks := make([]*RuleTask, 0)
 	for _, r := range lcConf.Rules {
@@ -101,6 +122,7 @@ type LcNodeRuleTaskStatistics struct {
 	FileScannedNum       int64
 	DirScannedNum        int64
 	ExpiredNum           int64
+	TransitionNum        int64
 	ErrorSkippedNum      int64
 }
 
@@ -0,0 +1,81 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The media classes of the datanode disks. The data partitions of a volume are placed on the disks
+// of its preferred media class, MediaTypeUnspecified means any disk is acceptable.
+const (
+	MediaTypeUnspecified uint32 = iota
+	MediaTypeSSD
+	MediaTypeHDD
+)
+
+const (
+	MediaTypeNameSSD = "ssd"
+	MediaTypeNameHDD = "hdd"
+)
+
+// The preferred media class of the files created in a directory is recorded in the xattr of the directory,
+// and it is inherited by the sub directories.
+const XAttrKeyMediaType = "user.cubefs.media-type"
+
+// MediaTypes lists the known media classes in the order they are displayed.
+var MediaTypes = []uint32{MediaTypeSSD, MediaTypeHDD}
+
+func MediaTypeString(mediaType uint32) string {
+	switch mediaType {
+	case MediaTypeUnspecified:
+		return ""
+	case MediaTypeSSD:
+		return MediaTypeNameSSD
+	case MediaTypeHDD:
+		return MediaTypeNameHDD
+	default:
+		return fmt.Sprintf("unknown(%v)", mediaType)
+	}
+}
+
+// ParseMediaType parses the name of the media class, the empty name means MediaTypeUnspecified.
+func ParseMediaType(name string) (mediaType uint32, err error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "":
+		return MediaTypeUnspecified, nil
+	case MediaTypeNameSSD:
+		return MediaTypeSSD, nil
+	case MediaTypeNameHDD:
+		return MediaTypeHDD, nil
+	default:
+		return MediaTypeUnspecified, fmt.Errorf("unknown media type [%v], should be %v or %v",
+			name, MediaTypeNameSSD, MediaTypeNameHDD)
+	}
+}
+
+// MatchMediaType returns whether the media class satisfies the wanted one.
+func MatchMediaType(want, mediaType uint32) bool {
+	return want == MediaTypeUnspecified || want == mediaType
+}
+
+// MediaUsage defines the data partitions of a volume placed on a media class.
+type MediaUsage struct {
+	MediaType      string
+	UsedSize       uint64
+	PartitionCnt   int
+	RwPartitionCnt int
+}
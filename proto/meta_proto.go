@@ -14,7 +14,10 @@
 
 package proto
 
-import "sync"
+import (
+	"fmt"
+	"sync"
+)
 
 // CreateNameSpaceRequest defines the request to create a name space.
 type CreateNameSpaceRequest struct {
@@ -29,8 +32,51 @@ type CreateNameSpaceResponse struct {
 
 // Peer defines the peer of the node id and address.
 type Peer struct {
-	ID   uint64 `json:"id"`
-	Addr string `json:"addr"`
+	ID        uint64 `json:"id"`
+	Addr      string `json:"addr"`
+	IsLearner bool   `json:"isLearner,omitempty"` // receives the raft log without voting until it is promoted
+	Joint     uint8  `json:"joint,omitempty"`     // the side of the peer in the replacement in progress
+}
+
+// The sides of the peers in the joint consensus of a replacement, which are persisted by the
+// partitions to restore the joint consensus of the raft after a restart.
+const (
+	PeerJointIncoming uint8 = 1 // votes in the new configuration only
+	PeerJointOutgoing uint8 = 2 // votes in the old configuration only, until it is removed
+)
+
+// EnterJoint marks the peers of the replacement applied by the raft, the incoming peer is
+// promoted if it is a learner. It tells whether the peers are changed.
+func EnterJoint(peers []Peer, incoming, outgoing uint64) (changed bool) {
+	for i := range peers {
+		peer := &peers[i]
+		switch peer.ID {
+		case incoming:
+			if peer.IsLearner || peer.Joint != PeerJointIncoming {
+				peer.IsLearner, peer.Joint = false, PeerJointIncoming
+				changed = true
+			}
+		case outgoing:
+			if peer.Joint != PeerJointOutgoing {
+				peer.Joint = PeerJointOutgoing
+				changed = true
+			}
+		}
+	}
+	return
+}
+
+// LeaveJoint leaves the joint consensus once the outgoing or the incoming peer is removed from
+// the peers, the latter one aborts the replacement.
+func LeaveJoint(peers []Peer, removed Peer) {
+	if removed.Joint == 0 {
+		return
+	}
+	for i := range peers {
+		if peers[i].Joint != 0 && peers[i].Joint != removed.Joint {
+			peers[i].Joint = 0
+		}
+	}
 }
 
 // CreateMetaPartitionRequest defines the request to create a meta partition.
@@ -42,6 +88,42 @@ type CreateMetaPartitionRequest struct {
 	PartitionID uint64
 	Members     []Peer
 	VerSeq      uint64
+	CloneFrom   uint64 // the partition of the source volume to clone the items from
+	CloneVerSeq uint64 // the version of the source volume to clone
+	StoreType   MetaStoreType
+}
+
+// MetaStoreType is the engine keeping the inodes, dentries, extends and multiparts of a meta partition.
+// MetaStoreTypeMem keeps them all in memory and dumps them to the snapshot files, MetaStoreTypeRocksDB
+// keeps them in RocksDB and caches the recently used ones in memory.
+type MetaStoreType uint8
+
+const (
+	MetaStoreTypeMem MetaStoreType = iota
+	MetaStoreTypeRocksDB
+)
+
+func (t MetaStoreType) String() string {
+	switch t {
+	case MetaStoreTypeMem:
+		return "mem"
+	case MetaStoreTypeRocksDB:
+		return "rocksdb"
+	default:
+	}
+	return "unknown"
+}
+
+func ParseMetaStoreType(s string) (t MetaStoreType, err error) {
+	switch s {
+	case "", "mem", "memory":
+		t = MetaStoreTypeMem
+	case "rocksdb", "rocks":
+		t = MetaStoreTypeRocksDB
+	default:
+		err = fmt.Errorf("invalid meta store type %v, should be mem or rocksdb", s)
+	}
+	return
 }
 
 // CreateMetaPartitionResponse defines the response to the request of creating a meta partition.
@@ -141,3 +223,98 @@ func (quotaInfo *QuotaInfo) IsOverQuotaBytes() (isOver bool) {
 	}
 	return
 }
+
+// UserQuotaType tells whether a user quota limits the inodes of a uid or of a gid.
+type UserQuotaType uint8
+
+const (
+	UserQuotaTypeUser UserQuotaType = iota
+	UserQuotaTypeGroup
+)
+
+func (t UserQuotaType) String() string {
+	switch t {
+	case UserQuotaTypeUser:
+		return "user"
+	case UserQuotaTypeGroup:
+		return "group"
+	default:
+	}
+	return "unknown"
+}
+
+func ParseUserQuotaType(s string) (t UserQuotaType, err error) {
+	switch s {
+	case "user", "uid":
+		t = UserQuotaTypeUser
+	case "group", "gid":
+		t = UserQuotaTypeGroup
+	default:
+		err = fmt.Errorf("invalid user quota type %v, should be user or group", s)
+	}
+	return
+}
+
+type UserQuotaKey struct {
+	Type UserQuotaType
+	Id   uint32
+}
+
+// UserQuotaInfo limits the bytes and files of the inodes owned by a uid or a gid, a zero limit is unlimited.
+// The soft limits may be exceeded for GracePeriod seconds before they are enforced like the hard limits.
+type UserQuotaInfo struct {
+	VolName          string
+	Type             UserQuotaType
+	Id               uint32
+	CTime            int64
+	SoftBytes        uint64
+	HardBytes        uint64
+	SoftFiles        uint64
+	HardFiles        uint64
+	GracePeriod      int64
+	BytesGraceExpire int64 // unix time the bytes grace ends, 0 if under the soft limit
+	FilesGraceExpire int64
+	LimitedInfo      QuotaLimitedInfo
+	UsedInfo         QuotaUsedInfo
+}
+
+type UserQuotaReportInfo struct {
+	Type     UserQuotaType
+	Id       uint32
+	UsedInfo QuotaUsedInfo
+}
+
+type UserQuotaHeartBeatInfo struct {
+	VolName     string
+	Type        UserQuotaType
+	Id          uint32
+	LimitedInfo QuotaLimitedInfo
+}
+
+type UserQuotaReportResponse struct {
+	Quotas []*UserQuotaInfo
+}
+
+func (info *UserQuotaInfo) Key() UserQuotaKey {
+	return UserQuotaKey{Type: info.Type, Id: info.Id}
+}
+
+func (info *UserQuotaInfo) IsOverSoftBytes() bool {
+	return info.SoftBytes != 0 && uint64(info.UsedInfo.UsedBytes) > info.SoftBytes
+}
+
+func (info *UserQuotaInfo) IsOverHardBytes() bool {
+	return info.HardBytes != 0 && uint64(info.UsedInfo.UsedBytes) > info.HardBytes
+}
+
+func (info *UserQuotaInfo) IsOverSoftFiles() bool {
+	return info.SoftFiles != 0 && uint64(info.UsedInfo.UsedFiles) > info.SoftFiles
+}
+
+func (info *UserQuotaInfo) IsOverHardFiles() bool {
+	return info.HardFiles != 0 && uint64(info.UsedInfo.UsedFiles) > info.HardFiles
+}
+
+func (info *UserQuotaInfo) HasLimit() bool {
+	return info.SoftBytes != 0 || info.HardBytes != 0 || info.SoftFiles != 0 || info.HardFiles != 0
+}
@@ -0,0 +1,27 @@
+package proto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerJoint(t *testing.T) {
+	newPeers := func() []Peer {
+		return []Peer{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4, IsLearner: true}}
+	}
+	peers := newPeers()
+	require.True(t, EnterJoint(peers, 4, 2))
+	require.False(t, EnterJoint(peers, 4, 2))
+	require.Equal(t, []Peer{{ID: 1}, {ID: 2, Joint: PeerJointOutgoing}, {ID: 3}, {ID: 4, Joint: PeerJointIncoming}}, peers)
+
+	// the outgoing peer removed completes the replacement
+	LeaveJoint(peers, peers[1])
+	require.Equal(t, []Peer{{ID: 1}, {ID: 2, Joint: PeerJointOutgoing}, {ID: 3}, {ID: 4}}, peers)
+
+	// the incoming peer removed aborts it
+	peers = newPeers()
+	EnterJoint(peers, 4, 2)
+	LeaveJoint(peers[:3], peers[3])
+	require.Equal(t, []Peer{{ID: 1}, {ID: 2}, {ID: 3}}, peers[:3])
+}
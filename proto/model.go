@@ -34,6 +34,9 @@ type MetaNodeInfo struct {
 	IsActive                  bool
 	IsWriteAble               bool
 	ZoneName                  string `json:"Zone"`
+	RackName                  string `json:",omitempty"`
+	HostName                  string `json:",omitempty"`
+	MaintenanceExpire         int64  `json:",omitempty"`
 	MaxMemAvailWeight         uint64 `json:"MaxMemAvailWeight"`
 	Total                     uint64 `json:"TotalWeight"`
 	Used                      uint64 `json:"UsedWeight"`
@@ -55,6 +58,9 @@ type DataNodeInfo struct {
 	AvailableSpace            uint64
 	ID                        uint64
 	ZoneName                  string `json:"Zone"`
+	RackName                  string `json:",omitempty"`
+	HostName                  string `json:",omitempty"`
+	MaintenanceExpire         int64  `json:",omitempty"`
 	Addr                      string
 	DomainAddr                string
 	ReportTime                time.Time
@@ -154,11 +160,46 @@ type ClusterIP struct {
 
 // NodeView provides the view of the data or meta node.
 type NodeView struct {
-	Addr       string
-	IsActive   bool
-	DomainAddr string
-	ID         uint64
-	IsWritable bool
+	Addr          string
+	IsActive      bool
+	DomainAddr    string
+	ID            uint64
+	IsWritable    bool
+	RackName      string `json:",omitempty"`
+	HostName      string `json:",omitempty"`
+	InMaintenance bool   `json:",omitempty"`
+}
+
+// NodeMaintenanceView reports the maintenance of a node and the partitions degraded by design during it.
+type NodeMaintenanceView struct {
+	Addr              string
+	MaintenanceExpire int64 // unix time the maintenance ends, zero if the node is not in maintenance
+	PartitionIDs      []uint64
+}
+
+// AdminAuditRecord records a mutating admin api call served by the master.
+type AdminAuditRecord struct {
+	ID       uint64 // unix nano time of the call, the records are ordered by it
+	Time     int64
+	Op       string // api path
+	ClientIP string
+	User     string            `json:",omitempty"`
+	AuthKey  string            `json:",omitempty"` // masked
+	Vol      string            `json:",omitempty"`
+	Params   map[string]string `json:",omitempty"` // secrets are redacted
+	Status   int               // http status
+	Code     int32
+	Msg      string `json:",omitempty"`
+	Master   string // master which served the call
+}
+
+// FailureDomainViolation reports the replicas of a partition placed in the same rack or host.
+type FailureDomainViolation struct {
+	PartitionID   uint64
+	PartitionType string // data or meta
+	VolName       string
+	FailureDomain string   // the rack or host shared by the replicas
+	Hosts         []string // the replicas in the failure domain
 }
 
 type DpRepairInfo struct {
@@ -231,6 +272,9 @@ type NodeStatInfo struct {
 	IncreasedGB int64
 	UsedRatio   string
 	AvailGB     uint64
+	// the used space of the data partitions before and after the compression
+	EffectiveUsedGB uint64
+	PhysicalUsedGB  uint64
 }
 
 type VolStatInfo struct {
@@ -241,6 +285,8 @@ type VolStatInfo struct {
 	CacheTotalSize        uint64
 	CacheUsedSize         uint64
 	CacheUsedRatio        string
+	PhysicalUsedSize      uint64        // used size excluding the space saved by the compression
+	MediaUsages           []*MediaUsage `json:",omitempty"`
 	EnableToken           bool
 	InodeCount            uint64
 	TxCnt                 uint64
@@ -302,6 +348,7 @@ type DataReplica struct {
 	HasLoadResponse            bool   // if there is any response when loading
 	Total                      uint64 `json:"TotalSize"`
 	Used                       uint64 `json:"UsedSize"`
+	PhysicalUsed               uint64 `json:"PhysicalUsedSize"`
 	IsLeader                   bool
 	NeedsToCompare             bool
 	DiskPath                   string
@@ -319,6 +366,9 @@ type DataPartitionDiagnosis struct {
 	// BadDataPartitionIDs         []BadPartitionView
 	BadDataPartitionInfos      []BadPartitionRepairView
 	BadReplicaDataPartitionIDs []uint64
+	// the partitions with replicas on nodes in maintenance are not repaired until it ends
+	MaintenanceDataNodes  []string `json:",omitempty"`
+	DegradedByDesignDpIDs []uint64 `json:",omitempty"`
 }
 
 // meta partition diagnosis represents the inactive meta nodes, corrupt meta partitions, and meta partitions lack of replicas
@@ -332,6 +382,8 @@ type MetaPartitionDiagnosis struct {
 	InodeCountNotEqualReplicaMetaPartitionIDs  []uint64
 	MaxInodeNotEqualReplicaMetaPartitionIDs    []uint64
 	DentryCountNotEqualReplicaMetaPartitionIDs []uint64
+	MaintenanceMetaNodes                       []string `json:",omitempty"`
+	DegradedByDesignMetaPartitionIDs           []uint64 `json:",omitempty"`
 }
 
 type DecommissionProgress struct {
@@ -381,6 +433,20 @@ type VolVersionInfoList struct {
 	RWLock          sync.RWMutex
 }
 
+// DirSnapshotInfo is a named snapshot of a directory, which is kept by a volume version.
+type DirSnapshotInfo struct {
+	Name       string
+	Path       string // path of the directory in the volume
+	Inode      uint64 // inode of the directory
+	VerSeq     uint64 // the version keeping the snapshot
+	ReadSeq    uint64 `json:",omitempty"` // the sequence to read the snapshot with, filled when listed
+	CreateTime int64
+}
+
+func (info *DirSnapshotInfo) String() string {
+	return fmt.Sprintf("Name:%v|Path:%v|Inode:%v|Ver:%v", info.Name, info.Path, info.Inode, info.VerSeq)
+}
+
 func (v *VolVersionInfoList) GetNextOlderVer(ver uint64) (verSeq uint64, err error) {
 	v.RWLock.RLock()
 	defer v.RWLock.RUnlock()
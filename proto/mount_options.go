@@ -49,6 +49,7 @@ const (
 	EnableSummary
 	EnableUnixPermission
 	RequestTimeout
+	EnableFileLock
 
 	// adls
 	VolType
@@ -143,6 +144,7 @@ func InitMountOptions(opts []MountOption) {
 	opts[EnablePosixACL] = MountOption{"enablePosixACL", "Enable posix ACL support", "", false}
 	opts[EnableSummary] = MountOption{"enableSummary", "Enable content summary", "", false}
 	opts[EnableUnixPermission] = MountOption{"enableUnixPermission", "Enable unix permission check(e.g: 777/755)", "", false}
+	opts[EnableFileLock] = MountOption{"enableFileLock", "Enable advisory locks (flock/fcntl) shared by all clients", "", false}
 
 	opts[VolType] = MountOption{"volType", "volume type", "", int64(0)}
 	opts[EbsEndpoint] = MountOption{"ebsEndpoint", "Ebs service address", "", ""}
@@ -320,6 +322,7 @@ type MountOptions struct {
 	WriteThreads                 int64
 	EnableSummary                bool
 	EnableUnixPermission         bool
+	EnableFileLock               bool
 	NeedRestoreFuse              bool
 	MetaSendTimeout              int64
 	BuffersTotalLimit            int64
@@ -104,6 +104,15 @@ const (
 	OpMetaBatchGetXAttr      uint8 = 0x39
 	OpMetaExtentAddWithCheck uint8 = 0x3A // Append extent key with discard extents check
 	OpMetaReadDirLimit       uint8 = 0x3D
+	OpMetaSetLock            uint8 = 0x3E // acquire or release an advisory lock of the inode
+	OpMetaGetLock            uint8 = 0x3F // query the advisory lock conflicting with the given one
+	OpMetaRenewLockLease     uint8 = 0xD4 // renew the lease of the advisory locks held by a client session
+	OpMetaExtentsClone       uint8 = 0xD8 // share the extents of a range of an inode with another inode
+	OpMetaReadChanges        uint8 = 0xDA // read the metadata changes applied to the partition after a cursor
+	OpMetaTrashInode         uint8 = 0xDB // move the dentry unlinked from the inode into the trash
+	OpMetaListTrash          uint8 = 0xDC // list the dentries in the trash
+	OpMetaRestoreTrash       uint8 = 0xAD // take the dentry out of the trash to link it back
+	OpMetaPurgeTrash         uint8 = 0xAE // drop the dentry in the trash and unlink the inode
 
 	// Operations: Master -> MetaNode
 	OpCreateMetaPartition           uint8 = 0x40
@@ -115,6 +124,10 @@ const (
 	OpAddMetaPartitionRaftMember    uint8 = 0x46
 	OpRemoveMetaPartitionRaftMember uint8 = 0x47
 	OpMetaPartitionTryToLeader      uint8 = 0x48
+	OpSplitMetaPartition            uint8 = 0x49
+	OpFreezeMetaPartition           uint8 = 0x4A
+	OpMergeMetaPartition            uint8 = 0x4B
+	OpPromoteMetaPartitionLearner   uint8 = 0x4C // turn the learner caught up into a voter
 
 	// Quota
 	OpMetaBatchSetInodeQuota    uint8 = 0x50
@@ -128,6 +141,7 @@ const (
 	OpLcNodeHeartbeat      uint8 = 0x55
 	OpLcNodeScan           uint8 = 0x56
 	OpLcNodeSnapshotVerDel uint8 = 0x57
+	OpLcNodeReplicate      uint8 = 0x58
 
 	// Operations: Master -> DataNode
 	OpCreateDataPartition           uint8 = 0x60
@@ -142,6 +156,8 @@ const (
 	OpDataPartitionTryToLeader      uint8 = 0x69
 	OpQos                           uint8 = 0x6A
 	OpStopDataPartitionRepair       uint8 = 0x6B
+	OpPromoteDataPartitionLearner   uint8 = 0x6C // turn the learner caught up into a voter
+	OpUpdateDataPartitionVolName    uint8 = 0x6D // tell the replica the new name of the volume renamed
 
 	// Operations: MultipartInfo
 	OpCreateMultipart  uint8 = 0x70
@@ -213,6 +229,7 @@ const (
 	OpMetaUpdateXAttr       uint8 = 0x3B
 	OpMetaReadDirOnly       uint8 = 0x3C
 	OpUploadPartConflictErr uint8 = 0x3D
+	OpLockConflictErr       uint8 = 0xD9
 
 	// ebs obj meta
 	OpMetaObjExtentAdd       uint8 = 0xDD
@@ -526,8 +543,18 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpAddMetaPartitionRaftMember"
 	case OpRemoveMetaPartitionRaftMember:
 		m = "OpRemoveMetaPartitionRaftMember"
+	case OpPromoteDataPartitionLearner:
+		m = "OpPromoteDataPartitionLearner"
+	case OpPromoteMetaPartitionLearner:
+		m = "OpPromoteMetaPartitionLearner"
 	case OpMetaPartitionTryToLeader:
 		m = "OpMetaPartitionTryToLeader"
+	case OpSplitMetaPartition:
+		m = "OpSplitMetaPartition"
+	case OpFreezeMetaPartition:
+		m = "OpFreezeMetaPartition"
+	case OpMergeMetaPartition:
+		m = "OpMergeMetaPartition"
 	case OpDataPartitionTryToLeader:
 		m = "OpDataPartitionTryToLeader"
 	case OpMetaDeleteInode:
@@ -550,6 +577,24 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpMetaBatchGetXAttr"
 	case OpMetaUpdateXAttr:
 		m = "OpMetaUpdateXAttr"
+	case OpMetaSetLock:
+		m = "OpMetaSetLock"
+	case OpMetaGetLock:
+		m = "OpMetaGetLock"
+	case OpMetaRenewLockLease:
+		m = "OpMetaRenewLockLease"
+	case OpMetaExtentsClone:
+		m = "OpMetaExtentsClone"
+	case OpMetaReadChanges:
+		m = "OpMetaReadChanges"
+	case OpMetaTrashInode:
+		m = "OpMetaTrashInode"
+	case OpMetaListTrash:
+		m = "OpMetaListTrash"
+	case OpMetaRestoreTrash:
+		m = "OpMetaRestoreTrash"
+	case OpMetaPurgeTrash:
+		m = "OpMetaPurgeTrash"
 	case OpCreateMultipart:
 		m = "OpCreateMultipart"
 	case OpGetMultipart:
@@ -596,12 +641,16 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpMetaGetInodeQuota"
 	case OpStopDataPartitionRepair:
 		m = "OpStopDataPartitionRepair"
+	case OpUpdateDataPartitionVolName:
+		m = "OpUpdateDataPartitionVolName"
 	case OpLcNodeHeartbeat:
 		m = "OpLcNodeHeartbeat"
 	case OpLcNodeScan:
 		m = "OpLcNodeScan"
 	case OpLcNodeSnapshotVerDel:
 		m = "OpLcNodeSnapshotVerDel"
+	case OpLcNodeReplicate:
+		m = "OpLcNodeReplicate"
 	case OpMetaReadDirOnly:
 		m = "OpMetaReadDirOnly"
 	default:
@@ -687,6 +736,8 @@ func (p *Packet) GetResultMsg() (m string) {
 		m = "OpUploadPartConflictErr"
 	case OpForbidErr:
 		m = "OpForbidErr"
+	case OpLockConflictErr:
+		m = "OpLockConflictErr"
 	default:
 		return fmt.Sprintf("Unknown ResultCode(%v)", p.ResultCode)
 	}
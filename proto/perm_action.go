@@ -128,6 +128,10 @@ const (
 	OSSPutBucketVersioningAction Action = OSSActionPrefix + "PutBucketVersioning" // unsupported
 	OSSListObjectVersionsAction  Action = OSSActionPrefix + "ListObjectVersions"  // unsupported
 
+	// Object storage bucket notification actions
+	OSSGetBucketNotificationAction Action = OSSActionPrefix + "GetBucketNotification"
+	OSSPutBucketNotificationAction Action = OSSActionPrefix + "PutBucketNotification"
+
 	// Object legal hold actions
 	OSSGetObjectLegalHoldAction Action = OSSActionPrefix + "GetObjectLegalHold" // unsupported
 	OSSPutObjectLegalHoldAction Action = OSSActionPrefix + "PutObjectLegalHold" // unsupported
@@ -227,6 +231,8 @@ var AllActions = []Action{
 	OSSGetBucketVersioningAction,
 	OSSPutBucketVersioningAction,
 	OSSListObjectVersionsAction,
+	OSSGetBucketNotificationAction,
+	OSSPutBucketNotificationAction,
 	OSSGetObjectLegalHoldAction,
 	OSSPutObjectLegalHoldAction,
 	OSSGetObjectRetentionAction,
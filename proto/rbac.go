@@ -0,0 +1,102 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Role grants a user the access to the master admin apis.
+type Role string
+
+const (
+	RoleNone         Role = ""
+	RoleReadOnly     Role = "read-only"
+	RoleOperator     Role = "operator"
+	RoleVolumeAdmin  Role = "volume-admin"
+	RoleClusterAdmin Role = "cluster-admin"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleNone, RoleReadOnly, RoleOperator, RoleVolumeAdmin, RoleClusterAdmin:
+		return true
+	default:
+	}
+	return false
+}
+
+// Allows returns whether the role grants the access, the volume access of a
+// volume-admin is further limited to the volumes it owns.
+func (r Role) Allows(access AdminAccess) bool {
+	switch r {
+	case RoleClusterAdmin:
+		return true
+	case RoleOperator:
+		return access == AdminAccessRead || access == AdminAccessOperate
+	case RoleVolumeAdmin:
+		return access == AdminAccessRead || access == AdminAccessVolume
+	case RoleReadOnly:
+		return access == AdminAccessRead
+	default:
+	}
+	return false
+}
+
+// AdminAccess classifies the master admin apis.
+type AdminAccess uint8
+
+const (
+	AdminAccessRead    AdminAccess = iota // query the cluster
+	AdminAccessOperate                    // move data around: decommission, migrate, maintenance, rebalance
+	AdminAccessVolume                     // manage the volumes
+	AdminAccessCluster                    // change the cluster config and manage the users
+)
+
+func (a AdminAccess) String() string {
+	switch a {
+	case AdminAccessRead:
+		return "read"
+	case AdminAccessOperate:
+		return "operate"
+	case AdminAccessVolume:
+		return "volume"
+	case AdminAccessCluster:
+		return "cluster"
+	default:
+	}
+	return "unknown"
+}
+
+// the headers of a request signed with the access key and secret key of a user
+const (
+	HeaderAccessKey = "x-cfs-Access-Key"
+	HeaderDate      = "x-cfs-Date"
+	HeaderNonce     = "x-cfs-Nonce"
+	HeaderSignature = "x-cfs-Signature"
+)
+
+// SignAdminRequest returns the hex encoded HMAC-SHA256 of the request, date is the unix time in seconds
+// and nonce is unique to the request.
+func SignAdminRequest(secretKey, method, path, rawQuery, date, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	stringToSign := strings.Join([]string{method, path, rawQuery, date, nonce, hex.EncodeToString(bodyHash[:])}, "\n")
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(stringToSign))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import "time"
+
+// The levels the datanodes are grouped at to be rebalanced. The replicas are only moved between
+// the datanodes of the same nodeset by default, or the datanodes of the same zone if the level is zone.
+const (
+	RebalanceLevelNodeSet = "nodeset"
+	RebalanceLevelZone    = "zone"
+)
+
+// RebalanceConfig defines how the data partitions are moved to level the disk usage of the datanodes.
+// The replicas are moved from the most used datanode to the least used one of a group until the
+// difference of their usage ratios is within Threshold. At most Concurrency partitions are moved at
+// the same time, and the partitions are started to move at the pace of Bandwidth (MB/s) if it is set.
+type RebalanceConfig struct {
+	Enable      bool      `json:"enable"`
+	Zones       []string  `json:"zones,omitempty"`
+	NodeSets    []uint64  `json:"nodeSets,omitempty"`
+	Level       string    `json:"level"`
+	Threshold   float64   `json:"threshold"`
+	Concurrency int       `json:"concurrency"`
+	Bandwidth   uint64    `json:"bandwidth"`
+	StartTime   time.Time `json:"startTime"`
+}
+
+// RebalanceMigration defines a replica of a data partition being moved by the rebalancer.
+type RebalanceMigration struct {
+	PartitionID uint64    `json:"partitionId"`
+	VolName     string    `json:"volName"`
+	SrcAddr     string    `json:"srcAddr"`
+	SrcDisk     string    `json:"srcDisk"`
+	DstAddr     string    `json:"dstAddr"`
+	Size        uint64    `json:"size"`
+	Recovering  bool      `json:"recovering"`
+	StartTime   time.Time `json:"startTime"`
+}
+
+// RebalanceView defines the state of the data partition rebalancer.
+type RebalanceView struct {
+	Config        RebalanceConfig       `json:"config"`
+	Migrations    []*RebalanceMigration `json:"migrations"`
+	MigratedCount uint64                `json:"migratedCount"`
+	MigratedBytes uint64                `json:"migratedBytes"`
+	FailedCount   uint64                `json:"failedCount"`
+	LastError     string                `json:"lastError,omitempty"`
+	LastCheckTime time.Time             `json:"lastCheckTime"`
+	Balanced      bool                  `json:"balanced"`
+	NextStartTime time.Time             `json:"nextStartTime"`
+}
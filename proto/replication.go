@@ -0,0 +1,72 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"time"
+)
+
+const (
+	ReplicationPhaseFull        = "full"
+	ReplicationPhaseIncremental = "incremental"
+)
+
+// VolReplication is the configuration to replicate a volume to a volume of another cluster.
+type VolReplication struct {
+	TargetMasters string // the master addresses of the target cluster, separated by comma
+	TargetVolume  string
+	Enable        bool
+	CreateTime    int64
+}
+
+type ReplicationTaskRequest struct {
+	MasterAddr string
+	LcNodeAddr string
+	Task       *ReplicationTask
+}
+
+// ReplicationTask asks the lcnode to start replicating the volume, or to stop it if Stop is set.
+type ReplicationTask struct {
+	Id      string
+	VolName string
+	Config  *VolReplication
+	Stop    bool
+}
+
+type ReplicationTaskResponse struct {
+	ID         string
+	LcNode     string
+	StartTime  *time.Time
+	UpdateTime *time.Time
+	Status     uint8
+	Result     string
+	ReplicationStatistics
+}
+
+type ReplicationStatistics struct {
+	VolName         string
+	Phase           string
+	SyncedEntries   int64
+	SyncedBytes     int64
+	ErrorSkippedNum int64
+	PendingChanges  uint64 // the raft indexes the target is behind the source, summed by partitions
+	LagSeconds      int64  // the seconds since the target is caught up with the source
+}
+
+// VolReplicationView is the replication configuration of the volume and the state of its task.
+type VolReplicationView struct {
+	Config *VolReplication
+	Task   *ReplicationTaskResponse
+}
@@ -103,6 +103,7 @@ type UserInfo struct {
 	SecretKey   string       `json:"secret_key" graphql:"secret_key"`
 	Policy      *UserPolicy  `json:"policy" graphql:"policy"`
 	UserType    UserType     `json:"user_type" graphql:"user_type"`
+	Role        Role         `json:"role,omitempty" graphql:"role"`
 	CreateTime  string       `json:"create_time" graphql:"create_time"`
 	Description string       `json:"description" graphql:"description"`
 	Mu          sync.RWMutex `json:"-" graphql:"-"`
@@ -247,6 +248,21 @@ func (policy *UserPolicy) RemoveAuthorizedVol(volume string) {
 	delete(policy.AuthorizedVols, volume)
 }
 
+// RenameVol moves the ownership and the authorization of the volume to its new name.
+func (policy *UserPolicy) RenameVol(volume, newName string) {
+	policy.mu.Lock()
+	defer policy.mu.Unlock()
+	for i, ownVol := range policy.OwnVols {
+		if ownVol == volume {
+			policy.OwnVols[i] = newName
+		}
+	}
+	if values, exist := policy.AuthorizedVols[volume]; exist {
+		delete(policy.AuthorizedVols, volume)
+		policy.AuthorizedVols[newName] = values
+	}
+}
+
 func (policy *UserPolicy) SetPerm(volume string, perm Permission) {
 	policy.mu.Lock()
 	defer policy.mu.Unlock()
@@ -333,6 +349,7 @@ type UserCreateParam struct {
 	AccessKey   string   `json:"ak"`
 	SecretKey   string   `json:"sk"`
 	Type        UserType `json:"type"`
+	Role        Role     `json:"role,omitempty"`
 	Description string   `json:"description"`
 }
 
@@ -372,6 +389,7 @@ type UserUpdateParam struct {
 	AccessKey   string   `json:"access_key"`
 	SecretKey   string   `json:"secret_key"`
 	Type        UserType `json:"type"`
+	Role        *Role    `json:"role,omitempty"`
 	Password    string   `json:"password"`
 	Description string   `json:"description"`
 }
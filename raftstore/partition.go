@@ -15,6 +15,7 @@
 package raftstore
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/cubefs/cubefs/depends/tiglabs/raft"
@@ -40,6 +41,10 @@ type Partition interface {
 	// ChangeMember submits member change event and information to raft log.
 	ChangeMember(changeType proto.ConfChangeType, peer proto.Peer, context []byte) (resp interface{}, err error)
 
+	// ReplaceMember replaces removePeer with peer through a joint consensus, which is left
+	// by removing removePeer with ChangeMember.
+	ReplaceMember(peer, removePeer proto.Peer, context []byte) (resp interface{}, err error)
+
 	// Stop removes the raft partition from raft server and shuts down this partition.
 	Stop() error
 
@@ -90,6 +95,17 @@ func (p *partition) ChangeMember(changeType proto.ConfChangeType, peer proto.Pee
 	return
 }
 
+// ReplaceMember submits the member replacement to raft log.
+func (p *partition) ReplaceMember(peer, removePeer proto.Peer, context []byte) (resp interface{}, err error) {
+	if !p.IsRaftLeader() {
+		err = raft.ErrNotLeader
+		return
+	}
+	future := p.raft.ReplaceMember(p.id, peer, removePeer, context)
+	resp, err = future.Response()
+	return
+}
+
 // Stop removes the raft partition from raft server and shuts down this partition.
 func (p *partition) Stop() (err error) {
 	err = p.raft.RemoveRaft(p.id)
@@ -136,6 +152,9 @@ func (p *partition) IsOfflinePeer() bool {
 	active := 0
 	sumPeers := 0
 	for _, peer := range status.Replicas {
+		if peer.IsLearner {
+			continue
+		}
 		if peer.Active {
 			active++
 		}
@@ -181,6 +200,29 @@ func (p *partition) Truncate(index uint64) {
 	}
 }
 
+// LearnerCatchUpLag is the number of the log entries a learner may fall behind the commit when it is promoted.
+const LearnerCatchUpLag = 128
+
+// CheckLearnerCaughtUp checks on the leader whether the learner has received the log to be promoted.
+func CheckLearnerCaughtUp(p Partition, id uint64) error {
+	status := p.Status()
+	if status == nil || status.Leader != status.NodeID {
+		return raft.ErrNotLeader
+	}
+	replica, ok := status.Replicas[id]
+	if !ok {
+		return fmt.Errorf("peer %v not found", id)
+	}
+	if !replica.IsLearner {
+		return nil
+	}
+	if replica.Snapshoting || replica.Match+LearnerCatchUpLag < status.Commit {
+		return fmt.Errorf("learner %v is catching up, match %v commit %v snapshoting %v",
+			id, replica.Match, status.Commit, replica.Snapshoting)
+	}
+	return nil
+}
+
 func newPartition(cfg *PartitionConfig, raft *raft.RaftServer, walPath string) Partition {
 	return &partition{
 		id:      cfg.ID,
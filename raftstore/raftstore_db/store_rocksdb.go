@@ -287,6 +287,22 @@ func (rs *RocksDBStore) Iterator(snapshot *gorocksdb.Snapshot) *gorocksdb.Iterat
 	return rs.db.NewIterator(ro)
 }
 
+// DeleteRange deletes the keys in the range [start, end).
+func (rs *RocksDBStore) DeleteRange(start, end []byte, isSync bool) (err error) {
+	wo := gorocksdb.NewDefaultWriteOptions()
+	wo.SetSync(isSync)
+	wb := gorocksdb.NewWriteBatch()
+	defer func() {
+		wo.Destroy()
+		wb.Destroy()
+	}()
+	wb.DeleteRange(start, end)
+	if err = rs.db.Write(wo, wb); err != nil {
+		err = fmt.Errorf("action[deleteRangeFromRocksDB],err:%v", err)
+	}
+	return
+}
+
 func (rs *RocksDBStore) Clear() (err error) {
 	wo := gorocksdb.NewDefaultWriteOptions()
 	wo.SetSync(true)
@@ -42,6 +42,8 @@ type (
 	AppendExtentKeyFunc func(parentInode, inode uint64, key proto.ExtentKey, discard []proto.ExtentKey) (int, error)
 	GetExtentsFunc      func(inode uint64) (uint64, uint64, []proto.ExtentKey, error)
 	TruncateFunc        func(inode, size uint64, fullPath string) error
+	PunchHoleFunc       func(inode, offset, size uint64) error
+	CloneExtentsFunc    func(srcIno, srcOff, dstIno, dstOff, size uint64) (uint64, error)
 	EvictIcacheFunc     func(inode uint64)
 	LoadBcacheFunc      func(key string, buf []byte, offset uint64, size uint32) (int, error)
 	CacheBcacheFunc     func(key string, buf []byte) error
@@ -114,6 +116,8 @@ type ExtentConfig struct {
 	OnSplitExtentKey  SplitExtentKeyFunc
 	OnGetExtents      GetExtentsFunc
 	OnTruncate        TruncateFunc
+	OnPunchHole       PunchHoleFunc    // optional
+	OnCloneExtents    CloneExtentsFunc // optional
 	OnEvictIcache     EvictIcacheFunc
 	OnLoadBcache      LoadBcacheFunc
 	OnCacheBcache     CacheBcacheFunc
@@ -151,7 +155,9 @@ type ExtentClient struct {
 	splitExtentKey     SplitExtentKeyFunc
 	getExtents         GetExtentsFunc
 	truncate           TruncateFunc
-	evictIcache        EvictIcacheFunc // May be null, must check before using
+	punchHole          PunchHoleFunc    // May be null, must check before using
+	cloneExtents       CloneExtentsFunc // May be null, must check before using
+	evictIcache        EvictIcacheFunc  // May be null, must check before using
 	loadBcache         LoadBcacheFunc
 	cacheBcache        CacheBcacheFunc
 	evictBcache        EvictBacheFunc
@@ -260,6 +266,8 @@ retry:
 	client.splitExtentKey = config.OnSplitExtentKey
 	client.getExtents = config.OnGetExtents
 	client.truncate = config.OnTruncate
+	client.punchHole = config.OnPunchHole
+	client.cloneExtents = config.OnCloneExtents
 	client.evictIcache = config.OnEvictIcache
 	client.dataWrapper.InitFollowerRead(config.FollowerRead)
 	client.dataWrapper.SetNearRead(config.NearRead)
@@ -554,6 +562,50 @@ func (client *ExtentClient) Truncate(mw *meta.MetaWrapper, parentIno uint64, ino
 	return err
 }
 
+// PunchHole releases the data of the range of the inode, the size of the inode is not changed.
+func (client *ExtentClient) PunchHole(inode uint64, offset, size int) error {
+	s := client.GetStreamer(inode)
+	if s == nil {
+		log.LogErrorf("PunchHole: stream is not opened yet, ino(%v)", inode)
+		return syscall.EBADF
+	}
+	if client.punchHole == nil {
+		return syscall.EOPNOTSUPP
+	}
+	err := s.IssuePunchRequest(offset, size)
+	if err != nil {
+		log.LogErrorf("PunchHole: ino(%v) offset(%v) size(%v) err(%v)", inode, offset, size, err)
+	}
+	return err
+}
+
+// CloneExtents shares the data of the range of the source inode with the destination inode,
+// both of which must be opened. It returns the size cloned which is cut at the end of the source.
+func (client *ExtentClient) CloneExtents(srcIno uint64, srcOff int, dstIno uint64, dstOff int, size int) (cloned int, err error) {
+	src := client.GetStreamer(srcIno)
+	dst := client.GetStreamer(dstIno)
+	if src == nil || dst == nil {
+		log.LogErrorf("CloneExtents: stream is not opened yet, src ino(%v) dst ino(%v)", srcIno, dstIno)
+		return 0, syscall.EBADF
+	}
+	if client.cloneExtents == nil {
+		return 0, syscall.EOPNOTSUPP
+	}
+	if err = src.IssueFlushRequest(); err != nil {
+		return
+	}
+	if cloned, err = dst.IssueCloneRequest(srcIno, srcOff, dstOff, size); err != nil {
+		log.LogErrorf("CloneExtents: src ino(%v) offset(%v) dst ino(%v) offset(%v) size(%v) err(%v)",
+			srcIno, srcOff, dstIno, dstOff, size, err)
+		return
+	}
+	// the cloned extents of the source are shared now, which must not be overwritten in place
+	if err = src.GetExtentsForce(); err != nil {
+		log.LogErrorf("CloneExtents: refresh extents of src ino(%v) err(%v)", srcIno, err)
+	}
+	return
+}
+
 func (client *ExtentClient) Flush(inode uint64) error {
 	s := client.GetStreamer(inode)
 	if s == nil {
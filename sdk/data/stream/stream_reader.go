@@ -36,6 +36,7 @@ type Streamer struct {
 	client               *ExtentClient
 	inode                uint64
 	parentInode          uint64
+	mediaType            uint32 // preferred media class of the data partitions to write
 	status               int32
 	refcnt               int
 	idle                 int // how long there is no new request
@@ -84,6 +85,10 @@ func (s *Streamer) SetParentInode(inode uint64) {
 	s.parentInode = inode
 }
 
+func (s *Streamer) SetMediaType(mediaType uint32) {
+	s.mediaType = mediaType
+}
+
 // String returns the string format of the streamer.
 func (s *Streamer) String() string {
 	return fmt.Sprintf("Streamer{ino(%v)}", s.inode)
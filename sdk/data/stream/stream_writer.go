@@ -93,6 +93,25 @@ type TruncRequest struct {
 	done     chan struct{}
 }
 
+// PunchRequest defines a request to punch a hole.
+type PunchRequest struct {
+	offset int
+	size   int
+	err    error
+	done   chan struct{}
+}
+
+// CloneRequest defines a request to clone the extents of another inode into the streamer.
+type CloneRequest struct {
+	srcInode  uint64
+	srcOffset int
+	offset    int
+	size      int
+	cloned    int
+	err       error
+	done      chan struct{}
+}
+
 // EvictRequest defines an evict request.
 type EvictRequest struct {
 	err  error
@@ -167,6 +186,30 @@ func (s *Streamer) IssueTruncRequest(size int, fullPath string) error {
 	return err
 }
 
+func (s *Streamer) IssuePunchRequest(offset, size int) error {
+	request := &PunchRequest{
+		offset: offset,
+		size:   size,
+		done:   make(chan struct{}, 1),
+	}
+	s.request <- request
+	<-request.done
+	return request.err
+}
+
+func (s *Streamer) IssueCloneRequest(srcInode uint64, srcOffset, offset, size int) (int, error) {
+	request := &CloneRequest{
+		srcInode:  srcInode,
+		srcOffset: srcOffset,
+		offset:    offset,
+		size:      size,
+		done:      make(chan struct{}, 1),
+	}
+	s.request <- request
+	<-request.done
+	return request.cloned, request.err
+}
+
 func (s *Streamer) IssueEvictRequest() error {
 	request := evictRequestPool.Get().(*EvictRequest)
 	request.done = make(chan struct{}, 1)
@@ -256,6 +299,12 @@ func (s *Streamer) abortRequest(request interface{}) {
 	case *TruncRequest:
 		request.err = syscall.EAGAIN
 		request.done <- struct{}{}
+	case *PunchRequest:
+		request.err = syscall.EAGAIN
+		request.done <- struct{}{}
+	case *CloneRequest:
+		request.err = syscall.EAGAIN
+		request.done <- struct{}{}
 	case *FlushRequest:
 		request.err = syscall.EAGAIN
 		request.done <- struct{}{}
@@ -285,6 +334,12 @@ func (s *Streamer) handleRequest(request interface{}) {
 	case *TruncRequest:
 		request.err = s.truncate(request.size, request.fullPath)
 		request.done <- struct{}{}
+	case *PunchRequest:
+		request.err = s.punch(request.offset, request.size)
+		request.done <- struct{}{}
+	case *CloneRequest:
+		request.cloned, request.err = s.clone(request.srcInode, request.srcOffset, request.offset, request.size)
+		request.done <- struct{}{}
 	case *FlushRequest:
 		request.err = s.flush()
 		request.done <- struct{}{}
@@ -357,7 +412,8 @@ begin:
 			}
 			log.LogDebugf("action[streamer.write] inode [%v] latest seq [%v] extentkey seq [%v]  info [%v] before compare seq",
 				s.inode, s.verSeq, req.ExtentKey.GetSeq(), req.ExtentKey)
-			if req.ExtentKey.GetSeq() == s.verSeq {
+			// the shared extents are written by append since the data is referred by other inodes
+			if req.ExtentKey.GetSeq() == s.verSeq && !req.ExtentKey.IsShared() {
 				writeSize, err = s.doOverwrite(req, direct)
 				if err == proto.ErrCodeVersionOp {
 					log.LogDebugf("action[streamer.write] write need version update")
@@ -377,7 +433,7 @@ begin:
 				}
 				log.LogDebugf("action[streamer.write] err %v retryTimes %v", err, retryTimes)
 			} else {
-				log.LogDebugf("action[streamer.write] ino %v do OverWriteByAppend extent key (%v) because seq not equal", s.inode, req.ExtentKey)
+				log.LogDebugf("action[streamer.write] ino %v do OverWriteByAppend extent key (%v) because seq not equal or shared", s.inode, req.ExtentKey)
 				writeSize, _, err, _ = s.doOverWriteByAppend(req, direct)
 			}
 			if s.client.bcacheEnable {
@@ -1033,6 +1089,33 @@ func (s *Streamer) truncate(size int, fullPath string) error {
 	return s.GetExtentsForce()
 }
 
+func (s *Streamer) punch(offset, size int) error {
+	s.closeOpenHandler()
+	err := s.flush()
+	if err != nil {
+		return err
+	}
+
+	if err = s.client.punchHole(s.inode, uint64(offset), uint64(size)); err != nil {
+		return err
+	}
+	return s.GetExtentsForce()
+}
+
+func (s *Streamer) clone(srcInode uint64, srcOffset, offset, size int) (int, error) {
+	s.closeOpenHandler()
+	err := s.flush()
+	if err != nil {
+		return 0, err
+	}
+
+	cloned, err := s.client.cloneExtents(srcInode, uint64(srcOffset), s.inode, uint64(offset), uint64(size))
+	if err != nil {
+		return 0, err
+	}
+	return int(cloned), s.GetExtentsForce()
+}
+
 func (s *Streamer) updateVer(verSeq uint64) (err error) {
 	log.LogInfof("action[stream.updateVer] ver %v update to %v", s.verSeq, verSeq)
 	if s.verSeq != verSeq {
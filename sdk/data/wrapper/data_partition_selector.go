@@ -18,6 +18,7 @@ import (
 	"errors"
 	"strings"
 
+	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/log"
 )
 
@@ -115,6 +116,27 @@ func (w *Wrapper) refreshDpSelector(partitions []*DataPartition) {
 	}
 
 	_ = dpSelector.Refresh(partitions)
+	w.refreshMediaDpSelectors(partitions)
+}
+
+// refreshMediaDpSelectors groups the writable partitions by the media class for the directories
+// preferring a media, the partitions whose media is not known yet are only picked by the fallback.
+func (w *Wrapper) refreshMediaDpSelectors(partitions []*DataPartition) {
+	mediaPartitions := make(map[uint32][]*DataPartition)
+	for _, dp := range partitions {
+		if dp.MediaType != proto.MediaTypeUnspecified {
+			mediaPartitions[dp.MediaType] = append(mediaPartitions[dp.MediaType], dp)
+		}
+	}
+	selectors := make(map[uint32]DataPartitionSelector, len(mediaPartitions))
+	for mediaType, dps := range mediaPartitions {
+		selector, _ := newDefaultRandomSelector("")
+		_ = selector.Refresh(dps)
+		selectors[mediaType] = selector
+	}
+	w.Lock.Lock()
+	w.mediaDpSelectors = selectors
+	w.Lock.Unlock()
 }
 
 // getDataPartitionForWrite returns an available data partition for write.
@@ -126,11 +148,38 @@ func (w *Wrapper) GetDataPartitionForWrite(exclude map[string]struct{}) (*DataPa
 	return dpSelector.Select(exclude)
 }
 
+// GetDataPartitionForWriteOnMedia returns an available data partition on the media class for write,
+// it falls back to any data partition if there is none of the media.
+func (w *Wrapper) GetDataPartitionForWriteOnMedia(mediaType uint32, exclude map[string]struct{}) (*DataPartition, error) {
+	if mediaType == proto.MediaTypeUnspecified {
+		return w.GetDataPartitionForWrite(exclude)
+	}
+	w.Lock.RLock()
+	selector := w.mediaDpSelectors[mediaType]
+	w.Lock.RUnlock()
+
+	if selector != nil {
+		if dp, err := selector.Select(exclude); err == nil {
+			return dp, nil
+		}
+	}
+	log.LogDebugf("GetDataPartitionForWriteOnMedia: no writable %v data partition, fall back to any",
+		proto.MediaTypeString(mediaType))
+	return w.GetDataPartitionForWrite(exclude)
+}
+
 func (w *Wrapper) RemoveDataPartitionForWrite(partitionID uint64) {
 	w.Lock.RLock()
 	dpSelector := w.dpSelector
+	mediaDpSelectors := w.mediaDpSelectors
 	w.Lock.RUnlock()
 
+	for _, selector := range mediaDpSelectors {
+		if selector.Count() > 1 {
+			selector.RemoveDP(partitionID)
+		}
+	}
+
 	if dpSelector.Count() <= 1 {
 		return
 	}
@@ -70,7 +70,8 @@ type Wrapper struct {
 	stopOnce              sync.Once
 	stopC                 chan struct{}
 
-	dpSelector DataPartitionSelector
+	dpSelector       DataPartitionSelector
+	mediaDpSelectors map[uint32]DataPartitionSelector // selectors of the writable partitions per media class
 
 	HostsStatus map[string]bool
 	Uids        map[uint32]*proto.UidSimpleInfo
@@ -17,6 +17,7 @@ package master
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util"
@@ -273,6 +274,18 @@ func (api *AdminAPI) UpdateVolume(
 	request.addParam("dpReadOnlyWhenVolFull", strconv.FormatBool(vv.DpReadOnlyWhenVolFull))
 	request.addParam("replicaNum", strconv.FormatUint(uint64(vv.DpReplicaNum), 10))
 	request.addParam("enableQuota", strconv.FormatBool(vv.EnableQuota))
+	if vv.Compression == "" {
+		request.addParam("compression", "none")
+	} else {
+		request.addParam("compression", vv.Compression)
+	}
+	if vv.MediaType == "" {
+		request.addParam("mediaType", "any")
+	} else {
+		request.addParam("mediaType", vv.MediaType)
+	}
+	request.addParam("mediaFallback", strconv.FormatBool(vv.MediaFallback))
+	request.addParam("trashRetention", strconv.FormatInt(vv.TrashRetention, 10))
 	request.addParam("deleteLockTime", strconv.FormatInt(vv.DeleteLockTime, 10))
 	request.addParam("clientIDKey", clientIDKey)
 	if txMask != "" {
@@ -400,6 +413,33 @@ func (api *AdminAPI) SetVolumeAuditLog(volName string, enable bool) (err error)
 	return
 }
 
+// SetVolReplication replicates the volume to the target volume of another cluster, the target
+// volume is of the same name if it is empty.
+func (api *AdminAPI) SetVolReplication(volName, targetMasters, targetVolume string, enable bool) (err error) {
+	request := newRequest(post, proto.AdminSetVolReplication).Header(api.h)
+	request.addParam("name", volName)
+	request.addParam("targetMasters", targetMasters)
+	if targetVolume != "" {
+		request.addParam("targetVolume", targetVolume)
+	}
+	request.addParam("enable", strconv.FormatBool(enable))
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+func (api *AdminAPI) GetVolReplication(volName string) (view *proto.VolReplicationView, err error) {
+	view = &proto.VolReplicationView{}
+	err = api.mc.requestWith(view, newRequest(get, proto.AdminGetVolReplication).Header(api.h).addParam("name", volName))
+	return
+}
+
+func (api *AdminAPI) DeleteVolReplication(volName string) (err error) {
+	request := newRequest(post, proto.AdminDelVolReplication).Header(api.h)
+	request.addParam("name", volName)
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
 func (api *AdminAPI) GetMonitorPushAddr() (addr string, err error) {
 	err = api.mc.requestWith(&addr, newRequest(get, proto.AdminGetMonitorPushAddr).Header(api.h))
 	return
@@ -452,6 +492,25 @@ func (api *AdminAPI) CreateMetaPartition(volName string, count int, clientIDKey
 	return
 }
 
+// SplitMetaPartition splits the meta partition online at the inode, or its median inode if inode is 0.
+func (api *AdminAPI) SplitMetaPartition(metaPartitionID uint64, inode uint64) (err error) {
+	request := newRequest(get, proto.AdminSplitMetaPartition).Header(api.h)
+	request.addParam("id", strconv.FormatUint(metaPartitionID, 10))
+	if inode != 0 {
+		request.addParam("inode", strconv.FormatUint(inode, 10))
+	}
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+// MergeMetaPartition merges the adjacent meta partition of the higher inode range into the meta partition.
+func (api *AdminAPI) MergeMetaPartition(metaPartitionID uint64) (err error) {
+	request := newRequest(get, proto.AdminMergeMetaPartition).Header(api.h)
+	request.addParam("id", strconv.FormatUint(metaPartitionID, 10))
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
 func (api *AdminAPI) ListVols(keywords string) (volsInfo []*proto.VolInfo, err error) {
 	volsInfo = make([]*proto.VolInfo, 0)
 	err = api.mc.requestWith(&volsInfo, newRequest(get, proto.AdminListVols).
@@ -474,6 +533,43 @@ func (api *AdminAPI) SetForbidMpDecommission(disable bool) (err error) {
 	return
 }
 
+func (api *AdminAPI) StartDataRebalance(config *proto.RebalanceConfig) (err error) {
+	request := newRequest(post, proto.AdminStartDataRebalance).Header(api.h)
+	if len(config.Zones) > 0 {
+		request.addParam("zoneName", strings.Join(config.Zones, ","))
+	}
+	if len(config.NodeSets) > 0 {
+		ids := make([]string, 0, len(config.NodeSets))
+		for _, id := range config.NodeSets {
+			ids = append(ids, strconv.FormatUint(id, 10))
+		}
+		request.addParam("nodesetId", strings.Join(ids, ","))
+	}
+	if config.Level != "" {
+		request.addParam("level", config.Level)
+	}
+	if config.Threshold > 0 {
+		request.addParam("threshold", strconv.FormatFloat(config.Threshold, 'f', 6, 64))
+	}
+	if config.Concurrency > 0 {
+		request.addParam("concurrency", strconv.Itoa(config.Concurrency))
+	}
+	request.addParam("bandwidth", strconv.FormatUint(config.Bandwidth, 10))
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+func (api *AdminAPI) StopDataRebalance() (err error) {
+	_, err = api.mc.serveRequest(newRequest(post, proto.AdminStopDataRebalance).Header(api.h))
+	return
+}
+
+func (api *AdminAPI) GetDataRebalance() (view *proto.RebalanceView, err error) {
+	view = &proto.RebalanceView{}
+	err = api.mc.requestWith(view, newRequest(get, proto.AdminGetDataRebalance).Header(api.h))
+	return
+}
+
 func (api *AdminAPI) SetMetaNodeThreshold(threshold float64, clientIDKey string) (err error) {
 	request := newRequest(get, proto.AdminSetMetaNodeThreshold).Header(api.h)
 	request.addParam("threshold", strconv.FormatFloat(threshold, 'f', 6, 64))
@@ -594,6 +690,51 @@ func (api *AdminAPI) GetQuota(volName string, quotaId string) (quotaInfo *proto.
 	return quotaInfo, err
 }
 
+func (api *AdminAPI) SetUserQuota(volName string, quota *proto.UserQuotaInfo) (err error) {
+	request := newRequest(get, proto.UserQuotaSet).Header(api.h)
+	request.addParam("name", volName)
+	request.addParam("type", quota.Type.String())
+	request.addParam("id", strconv.FormatUint(uint64(quota.Id), 10))
+	request.addParam("softBytes", strconv.FormatUint(quota.SoftBytes, 10))
+	request.addParam("hardBytes", strconv.FormatUint(quota.HardBytes, 10))
+	request.addParam("softFiles", strconv.FormatUint(quota.SoftFiles, 10))
+	request.addParam("hardFiles", strconv.FormatUint(quota.HardFiles, 10))
+	request.addParam("gracePeriod", strconv.FormatInt(quota.GracePeriod, 10))
+	if _, err = api.mc.serveRequest(request); err != nil {
+		log.LogErrorf("action[SetUserQuota] fail. %v", err)
+		return
+	}
+	log.LogInfof("action[SetUserQuota] success.")
+	return nil
+}
+
+func (api *AdminAPI) DeleteUserQuota(volName string, quotaType proto.UserQuotaType, id uint32) (err error) {
+	request := newRequest(get, proto.UserQuotaDelete).Header(api.h)
+	request.addParam("name", volName)
+	request.addParam("type", quotaType.String())
+	request.addParam("id", strconv.FormatUint(uint64(id), 10))
+	if _, err = api.mc.serveRequest(request); err != nil {
+		log.LogErrorf("action[DeleteUserQuota] fail. %v", err)
+		return
+	}
+	log.LogInfof("action[DeleteUserQuota] success.")
+	return nil
+}
+
+// ReportUserQuota returns the user and group quotas of the volume, all types if quotaType is empty.
+func (api *AdminAPI) ReportUserQuota(volName string, quotaType string) (quotas []*proto.UserQuotaInfo, err error) {
+	resp := &proto.UserQuotaReportResponse{}
+	request := newRequest(get, proto.UserQuotaReport).Header(api.h).addParam("name", volName)
+	if quotaType != "" {
+		request.addParam("type", quotaType)
+	}
+	if err = api.mc.requestWith(resp, request); err != nil {
+		log.LogErrorf("action[ReportUserQuota] fail. %v", err)
+		return
+	}
+	return resp.Quotas, nil
+}
+
 func (api *AdminAPI) QueryBadDisks() (badDisks *proto.DiskInfos, err error) {
 	badDisks = &proto.DiskInfos{}
 	err = api.mc.requestWith(badDisks, newRequest(get, proto.QueryBadDisks).Header(api.h))
@@ -662,6 +803,32 @@ func (api *AdminAPI) DeleteVersion(volName string, verSeq string) (err error) {
 	return
 }
 
+func (api *AdminAPI) CloneVolume(volName string, verSeq uint64, cloneName string) (err error) {
+	request := newRequest(get, proto.AdminCloneVol).Header(api.h)
+	request.addParam("name", volName)
+	request.addParam("verSeq", strconv.FormatUint(verSeq, 10))
+	request.addParam("cloneName", cloneName)
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+func (api *AdminAPI) RenameVolume(volName string, newName string) (err error) {
+	request := newRequest(get, proto.AdminRenameVol).Header(api.h)
+	request.addParam("name", volName)
+	request.addParam("newName", newName)
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+func (api *AdminAPI) PromoteVolume(volName string, dirPath string, newName string) (err error) {
+	request := newRequest(get, proto.AdminPromoteVol).Header(api.h)
+	request.addParam("name", volName)
+	request.addParam("fullPath", dirPath)
+	request.addParam("newName", newName)
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
 func (api *AdminAPI) SetStrategy(volName string, periodic string, count string, enable string, force string) (err error) {
 	request := newRequest(get, proto.AdminSetVerStrategy).Header(api.h)
 	request.addParam("name", volName)
@@ -698,6 +865,31 @@ func (api *AdminAPI) GetVerList(volName string) (verList *proto.VolVersionInfoLi
 	return
 }
 
+func (api *AdminAPI) CreateDirSnapshot(volName, snapshotName, fullPath string, ino uint64) (info *proto.DirSnapshotInfo, err error) {
+	info = &proto.DirSnapshotInfo{}
+	err = api.mc.requestWith(info, newRequest(get, proto.AdminCreateDirSnapshot).
+		Header(api.h).addParam("name", volName).addParam("snapshotName", snapshotName).
+		addParam("fullPath", fullPath).addParam("inode", strconv.FormatUint(ino, 10)))
+	return
+}
+
+func (api *AdminAPI) DeleteDirSnapshot(volName, snapshotName string, ino uint64) (err error) {
+	return api.mc.request(newRequest(get, proto.AdminDelDirSnapshot).
+		Header(api.h).addParam("name", volName).addParam("snapshotName", snapshotName).
+		addParam("inode", strconv.FormatUint(ino, 10)))
+}
+
+// ListDirSnapshots lists the snapshots of the directory, or all the snapshots of the volume if ino is zero.
+func (api *AdminAPI) ListDirSnapshots(volName string, ino uint64) (infos []*proto.DirSnapshotInfo, err error) {
+	infos = make([]*proto.DirSnapshotInfo, 0)
+	request := newRequest(get, proto.AdminListDirSnapshot).Header(api.h).addParam("name", volName)
+	if ino != 0 {
+		request.addParam("inode", strconv.FormatUint(ino, 10))
+	}
+	err = api.mc.requestWith(&infos, request)
+	return
+}
+
 func (api *AdminAPI) SetBucketLifecycle(req *proto.LcConfiguration) (err error) {
 	return api.mc.request(newRequest(post, proto.SetBucketLifecycle).Header(api.h).Body(req))
 }
@@ -719,3 +911,25 @@ func (api *AdminAPI) DelBucketLifecycle(volume string) (err error) {
 func (api *AdminAPI) GetS3QoSInfo() (data []byte, err error) {
 	return api.mc.serveRequest(newRequest(get, proto.S3QoSGet).Header(api.h))
 }
+
+func (api *AdminAPI) ListAdminAudits(start, end int64, op, volName string, limit int) (records []*proto.AdminAuditRecord, err error) {
+	request := newRequest(get, proto.AdminListAudit).Header(api.h)
+	if start > 0 {
+		request.addParam("start", strconv.FormatInt(start, 10))
+	}
+	if end > 0 {
+		request.addParam("end", strconv.FormatInt(end, 10))
+	}
+	if op != "" {
+		request.addParam("op", op)
+	}
+	if volName != "" {
+		request.addParam("name", volName)
+	}
+	if limit > 0 {
+		request.addParam("limit", strconv.Itoa(limit))
+	}
+	records = make([]*proto.AdminAuditRecord, 0)
+	err = api.mc.requestWith(&records, request)
+	return
+}
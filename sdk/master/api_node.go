@@ -16,6 +16,7 @@ package master
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/cubefs/cubefs/proto"
 )
@@ -99,6 +100,22 @@ func (api *NodeAPI) GetMetaNode(serverHost string) (node *proto.MetaNodeInfo, er
 	return
 }
 
+// SetDataNodeMaintenance puts the datanode in maintenance for ttl, a zero ttl ends the maintenance.
+func (api *NodeAPI) SetDataNodeMaintenance(nodeAddr string, ttl time.Duration) (view *proto.NodeMaintenanceView, err error) {
+	view = &proto.NodeMaintenanceView{}
+	err = api.mc.requestWith(view, newRequest(post, proto.SetDataNodeMaintenance).Header(api.h).
+		addParam("addr", nodeAddr).addParam("ttl", ttl.String()))
+	return
+}
+
+// SetMetaNodeMaintenance puts the metanode in maintenance for ttl, a zero ttl ends the maintenance.
+func (api *NodeAPI) SetMetaNodeMaintenance(nodeAddr string, ttl time.Duration) (view *proto.NodeMaintenanceView, err error) {
+	view = &proto.NodeMaintenanceView{}
+	err = api.mc.requestWith(view, newRequest(post, proto.SetMetaNodeMaintenance).Header(api.h).
+		addParam("addr", nodeAddr).addParam("ttl", ttl.String()))
+	return
+}
+
 func (api *NodeAPI) ResponseMetaNodeTask(task *proto.AdminTask) (err error) {
 	return api.mc.request(newRequest(post, proto.GetMetaNodeTaskResponse).Header(api.h).Body(task))
 }
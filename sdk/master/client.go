@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -28,6 +29,7 @@ import (
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/compressor"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/google/uuid"
 )
 
 // TODO: re-use response body.
@@ -59,6 +61,8 @@ type MasterClient struct {
 	leaderAddr  string
 	timeout     time.Duration
 	clientIDKey string
+	accessKey   string
+	secretKey   string
 
 	adminAPI  *AdminAPI
 	clientAPI *ClientAPI
@@ -136,6 +140,15 @@ func (c *MasterClient) SetClientIDKey(clientIDKey string) {
 	c.Unlock()
 }
 
+// SetCredential makes the requests signed with the access key and secret key of the user,
+// required by the master admin apis once RBAC is enabled.
+func (c *MasterClient) SetCredential(accessKey, secretKey string) {
+	c.Lock()
+	c.accessKey = accessKey
+	c.secretKey = secretKey
+	c.Unlock()
+}
+
 func (c *MasterClient) serveRequest(r *request) (repsData []byte, err error) {
 	leaderAddr, nodes := c.prepareRequest()
 	host := leaderAddr
@@ -263,6 +276,17 @@ func (c *MasterClient) httpRequest(method, url string, r *request) (resp *http.R
 	for k, v := range r.header {
 		req.Header.Set(k, v)
 	}
+	c.RLock()
+	accessKey, secretKey := c.accessKey, c.secretKey
+	c.RUnlock()
+	if accessKey != "" {
+		date := strconv.FormatInt(time.Now().Unix(), 10)
+		nonce := uuid.NewString()
+		req.Header.Set(proto.HeaderAccessKey, accessKey)
+		req.Header.Set(proto.HeaderDate, date)
+		req.Header.Set(proto.HeaderNonce, nonce)
+		req.Header.Set(proto.HeaderSignature, proto.SignAdminRequest(secretKey, method, req.URL.Path, req.URL.RawQuery, date, nonce, r.body))
+	}
 	resp, err = client.Do(req)
 	return
 }
@@ -38,6 +38,7 @@ const (
 	OpenRetryInterval = 5 * time.Millisecond
 	OpenRetryLimit    = 1000
 	maxUniqID         = 5000
+	readDirVerLimit   = 1024
 )
 
 const (
@@ -356,6 +357,21 @@ func (mw *MetaWrapper) Lookup_ll(parentID uint64, name string) (inode uint64, mo
 	return inode, mode, nil
 }
 
+// LookupVer_ll looks up the dentry as it was at the read sequence of a snapshot.
+func (mw *MetaWrapper) LookupVer_ll(parentID uint64, name string, verSeq uint64) (inode uint64, mode uint32, err error) {
+	parentMP := mw.getPartitionByInode(parentID)
+	if parentMP == nil {
+		log.LogErrorf("LookupVer_ll: No parent partition, parentID(%v) name(%v)", parentID, name)
+		return 0, 0, syscall.ENOENT
+	}
+
+	status, inode, mode, err := mw.lookup(parentMP, parentID, name, verSeq)
+	if err != nil || status != statusOK {
+		return 0, 0, statusToErrno(status)
+	}
+	return inode, mode, nil
+}
+
 func (mw *MetaWrapper) BatchGetExpiredMultipart(prefix string, days int) (expiredIds []*proto.ExpiredMultipartInfo, err error) {
 	partitions := mw.partitions
 	var mp *MetaPartition
@@ -419,6 +435,21 @@ func (mw *MetaWrapper) InodeGet_ll(inode uint64) (*proto.InodeInfo, error) {
 	return info, nil
 }
 
+func (mw *MetaWrapper) InodeGetVer_ll(inode uint64, verSeq uint64) (*proto.InodeInfo, error) {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("InodeGetVer_ll: No such partition, ino(%v)", inode)
+		return nil, syscall.ENOENT
+	}
+
+	status, info, err := mw.iget(mp, inode, verSeq)
+	if err != nil || status != statusOK {
+		return nil, statusToErrno(status)
+	}
+	log.LogDebugf("InodeGetVer_ll: info(%v) verSeq(%v)", info, verSeq)
+	return info, nil
+}
+
 // Just like InodeGet but without retry
 func (mw *MetaWrapper) doInodeGet(inode uint64) (*proto.InodeInfo, error) {
 	mp := mw.getPartitionByInode(inode)
@@ -549,7 +580,7 @@ func (mw *MetaWrapper) BatchGetXAttr(inodes []uint64, keys []string) ([]*proto.X
 }
 
 func (mw *MetaWrapper) Delete_ll(parentID uint64, name string, isDir bool, fullPath string) (*proto.InodeInfo, error) {
-	if mw.enableTx(proto.TxOpMaskRemove) {
+	if mw.enableTx(proto.TxOpMaskRemove) && !mw.TrashEnabled() {
 		return mw.txDelete_ll(parentID, name, isDir, fullPath)
 	} else {
 		return mw.Delete_ll_EX(parentID, name, isDir, 0, fullPath)
@@ -790,7 +821,12 @@ func (mw *MetaWrapper) Delete_ll_EX(parentID uint64, name string, isDir bool, ve
 		return nil, nil
 	}
 	log.LogDebugf("action[Delete_ll] parentID %v name %v verSeq %v", parentID, name, verSeq)
-	status, info, err = mw.iunlink(mp, inode, verSeq, denVer, fullPath)
+	if verSeq == 0 && mw.TrashEnabled() {
+		// the inode is kept in the trash until it is restored, purged or expired
+		status, info, err = mw.itrash(mp, inode, parentID, name, fullPath)
+	} else {
+		status, info, err = mw.iunlink(mp, inode, verSeq, denVer, fullPath)
+	}
 	if err != nil || status != statusOK {
 		log.LogDebugf("action[Delete_ll] parentID %v inode %v name %v verSeq %v err %v", parentID, inode, name, verSeq, err)
 		return nil, nil
@@ -1336,6 +1372,31 @@ func (mw *MetaWrapper) ReadDirLimit_ll(parentID uint64, from string, limit uint6
 	return children, nil
 }
 
+// ReadDirVer_ll reads all the dentries of the directory as it was at the read sequence of a snapshot.
+func (mw *MetaWrapper) ReadDirVer_ll(parentID uint64, verSeq uint64) (children []proto.Dentry, err error) {
+	parentMP := mw.getPartitionByInode(parentID)
+	if parentMP == nil {
+		return nil, syscall.ENOENT
+	}
+
+	from := ""
+	for {
+		status, batches, err := mw.readDirLimit(parentMP, parentID, from, readDirVerLimit, verSeq, 0)
+		if err != nil || status != statusOK {
+			return nil, statusToErrno(status)
+		}
+		batchNr := uint64(len(batches))
+		if from != "" && batchNr > 0 {
+			batches = batches[1:]
+		}
+		children = append(children, batches...)
+		if batchNr < readDirVerLimit || len(batches) == 0 {
+			return children, nil
+		}
+		from = batches[len(batches)-1].Name
+	}
+}
+
 func (mw *MetaWrapper) DentryCreate_ll(parentID uint64, name string, inode uint64, mode uint32, fullPath string) error {
 	parentMP := mw.getPartitionByInode(parentID)
 	if parentMP == nil {
@@ -1465,7 +1526,7 @@ func (mw *MetaWrapper) GetExtents(inode uint64) (gen uint64, size uint64, extent
 		return 0, 0, nil, syscall.ENOENT
 	}
 
-	resp, err := mw.getExtents(mp, inode)
+	resp, err := mw.getExtents(mp, inode, mw.VerReadSeq)
 	if err != nil {
 		if resp != nil {
 			err = statusToErrno(resp.Status)
@@ -1482,6 +1543,23 @@ func (mw *MetaWrapper) GetExtents(inode uint64) (gen uint64, size uint64, extent
 	return gen, size, extents, nil
 }
 
+func (mw *MetaWrapper) GetExtentsVer(inode uint64, verSeq uint64) (gen uint64, size uint64, extents []proto.ExtentKey, err error) {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		return 0, 0, nil, syscall.ENOENT
+	}
+
+	resp, err := mw.getExtents(mp, inode, verSeq)
+	if err != nil {
+		if resp != nil {
+			err = statusToErrno(resp.Status)
+		}
+		log.LogErrorf("GetExtentsVer: ino(%v) verSeq(%v) err(%v)", inode, verSeq, err)
+		return 0, 0, nil, err
+	}
+	return resp.Generation, resp.Size, resp.Extents, nil
+}
+
 func (mw *MetaWrapper) GetObjExtents(inode uint64) (gen uint64, size uint64, extents []proto.ExtentKey, objExtents []proto.ObjExtentKey, err error) {
 	mp := mw.getPartitionByInode(inode)
 	if mp == nil {
@@ -1511,6 +1589,58 @@ func (mw *MetaWrapper) Truncate(inode, size uint64, fullPath string) error {
 	return nil
 }
 
+// PunchHole releases the data of the range of the inode, the range reads as zeros.
+func (mw *MetaWrapper) PunchHole(inode, offset, size uint64) error {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("PunchHole: No inode partition, ino(%v)", inode)
+		return syscall.ENOENT
+	}
+
+	status, err := mw.delExtentKey(mp, inode, offset, size)
+	if err != nil || status != statusOK {
+		return statusToErrno(status)
+	}
+	return nil
+}
+
+// CloneExtents shares the data of the range of the source inode with the destination inode without
+// copying, it returns the size cloned which is cut at the end of the source inode. The inodes must
+// be in the same meta partition, otherwise EOPNOTSUPP is returned and the data should be copied.
+func (mw *MetaWrapper) CloneExtents(srcIno, srcOff, dstIno, dstOff, size uint64) (uint64, error) {
+	mp := mw.getPartitionByInode(srcIno)
+	if mp == nil {
+		log.LogErrorf("CloneExtents: No inode partition, ino(%v)", srcIno)
+		return 0, syscall.ENOENT
+	}
+	if dstMp := mw.getPartitionByInode(dstIno); dstMp == nil || dstMp.PartitionID != mp.PartitionID {
+		return 0, syscall.EOPNOTSUPP
+	}
+
+	status, cloned, err := mw.cloneExtents(mp, srcIno, srcOff, dstIno, dstOff, size)
+	if err != nil || status != statusOK {
+		if status == statusInval {
+			return 0, syscall.EOPNOTSUPP
+		}
+		return 0, statusToErrno(status)
+	}
+	return cloned, nil
+}
+
+// ReadMetaChanges returns the metadata changes applied to the meta partition after the cursor.
+func (mw *MetaWrapper) ReadMetaChanges(mpID, from uint64, limit uint32) (*proto.ReadMetaChangesResponse, error) {
+	mp := mw.getPartitionByID(mpID)
+	if mp == nil {
+		log.LogErrorf("ReadMetaChanges: No such partition, mpID(%v)", mpID)
+		return nil, syscall.ENOENT
+	}
+	status, resp, err := mw.readChanges(mp, from, limit)
+	if err != nil || status != statusOK {
+		return nil, statusToErrno(status)
+	}
+	return resp, nil
+}
+
 func (mw *MetaWrapper) Link(parentID uint64, name string, ino uint64, fullPath string) (*proto.InodeInfo, error) {
 	// if mw.EnableTransaction {
 	if mw.EnableTransaction&proto.TxOpMaskLink > 0 {
@@ -2021,6 +2151,61 @@ func (mw *MetaWrapper) XAttrSet_ll(inode uint64, name, value []byte) error {
 	return nil
 }
 
+// SetLock_ll acquires the advisory lock of the inode, or releases it if the type is
+// proto.FileLockUnlock. syscall.EAGAIN is returned if a conflicting lock is held by others,
+// after waiting for it to be released for proto.LockWaitTimeout at most if wait is true.
+func (mw *MetaWrapper) SetLock_ll(inode uint64, lock *proto.FileLock, wait bool) error {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("SetLock_ll: no such partition, inode(%v)", inode)
+		return syscall.ENOENT
+	}
+	lock.Session = mw.locks.id
+	status, err := mw.setLock(mp, inode, lock, wait)
+	switch {
+	case err != nil && status == statusInval:
+		return syscall.EINVAL
+	case err != nil:
+		return syscall.EIO
+	case status == statusLockConflict:
+		return syscall.EAGAIN
+	}
+	mw.locks.update(inode, lock)
+	if lock.Type != proto.FileLockUnlock {
+		mw.startLockRenewal()
+	}
+	log.LogDebugf("SetLock_ll: volume(%v) inode(%v) lock(%v)", mw.volname, inode, lock)
+	return nil
+}
+
+// GetLock_ll returns the lock conflicting with the given one, nil is returned if there is none.
+func (mw *MetaWrapper) GetLock_ll(inode uint64, lock *proto.FileLock) (*proto.FileLock, error) {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("GetLock_ll: no such partition, inode(%v)", inode)
+		return nil, syscall.ENOENT
+	}
+	lock.Session = mw.locks.id
+	conflict, status, err := mw.getLock(mp, inode, lock)
+	if err != nil || status != statusOK {
+		return nil, syscall.EIO
+	}
+	return conflict, nil
+}
+
+// ReleaseLocks_ll releases all the locks of the owner if it holds any lock of the inode.
+func (mw *MetaWrapper) ReleaseLocks_ll(inode uint64, owner uint64, flock bool) error {
+	if !mw.locks.holds(inode, owner, flock) {
+		return nil
+	}
+	return mw.SetLock_ll(inode, &proto.FileLock{
+		Owner: owner,
+		Flock: flock,
+		Type:  proto.FileLockUnlock,
+		End:   math.MaxUint64,
+	}, false)
+}
+
 func (mw *MetaWrapper) BatchSetXAttr_ll(inode uint64, attrs map[string]string) error {
 	var err error
 	mp := mw.getPartitionByInode(inode)
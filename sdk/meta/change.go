@@ -0,0 +1,141 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// ErrMetaChangesTruncated is returned if the changes after the cursor of some meta partitions are not
+// kept by the metanodes any more, the partitions returned by Truncated have to be scanned in full.
+var ErrMetaChangesTruncated = errors.New("meta changes truncated")
+
+type metaChangeReader interface {
+	GetPartitionIDs() []uint64
+	ReadMetaChanges(mpID, from uint64, limit uint32) (*proto.ReadMetaChangesResponse, error)
+}
+
+// MetaChangeIterator reads the metadata changes of a volume. It keeps a cursor for each meta
+// partition, which is the raft index the changes of the partition are read up to.
+type MetaChangeIterator struct {
+	reader    metaChangeReader
+	limit     uint32
+	cursors   map[uint64]uint64
+	pending   map[uint64]uint64
+	truncated []uint64
+}
+
+// NewMetaChangeIterator returns an iterator which reads the changes applied after the cursors,
+// at most limit changes are read from a partition each time. The partitions without a cursor
+// are read from the beginning.
+func (mw *MetaWrapper) NewMetaChangeIterator(cursors map[uint64]uint64, limit uint32) *MetaChangeIterator {
+	return newMetaChangeIterator(mw, cursors, limit)
+}
+
+func newMetaChangeIterator(reader metaChangeReader, cursors map[uint64]uint64, limit uint32) *MetaChangeIterator {
+	it := &MetaChangeIterator{
+		reader:  reader,
+		limit:   limit,
+		cursors: make(map[uint64]uint64, len(cursors)),
+		pending: make(map[uint64]uint64),
+	}
+	for mpID, cursor := range cursors {
+		it.cursors[mpID] = cursor
+	}
+	return it
+}
+
+// SeekToLatest moves the cursors of all the partitions to the latest changes, so that only
+// the changes applied afterwards are read.
+func (it *MetaChangeIterator) SeekToLatest() (err error) {
+	for _, mpID := range it.reader.GetPartitionIDs() {
+		var resp *proto.ReadMetaChangesResponse
+		if resp, err = it.reader.ReadMetaChanges(mpID, 0, 1); err != nil {
+			return
+		}
+		it.cursors[mpID] = resp.Last
+		delete(it.pending, mpID)
+	}
+	return
+}
+
+// Next reads the changes after the cursors from each partition once and moves the cursors forward.
+// The changes of a partition are in the order they are applied, the changes of different partitions
+// are not ordered. The changes read are returned even if err is not nil, the cursors of the partitions
+// failed to read are not moved. ErrMetaChangesTruncated is returned if some partitions are truncated,
+// whose cursors are moved to the latest changes.
+func (it *MetaChangeIterator) Next() (changes []*proto.MetaChange, err error) {
+	it.truncated = it.truncated[:0]
+	changes = make([]*proto.MetaChange, 0)
+	mpIDs := it.reader.GetPartitionIDs()
+	sort.Slice(mpIDs, func(i, j int) bool { return mpIDs[i] < mpIDs[j] })
+	for _, mpID := range mpIDs {
+		cursor := it.cursors[mpID]
+		resp, readErr := it.reader.ReadMetaChanges(mpID, cursor, it.limit)
+		if readErr != nil {
+			log.LogWarnf("MetaChangeIterator: read changes of mp(%v) from(%v) err(%v)", mpID, cursor, readErr)
+			err = readErr
+			continue
+		}
+		if resp.Truncated {
+			log.LogWarnf("MetaChangeIterator: changes of mp(%v) from(%v) are truncated, latest(%v)", mpID, cursor, resp.Applied)
+			it.truncated = append(it.truncated, mpID)
+			it.cursors[mpID] = resp.Applied
+			delete(it.pending, mpID)
+			continue
+		}
+		for _, change := range resp.Changes {
+			change.PartitionID = mpID
+		}
+		changes = append(changes, resp.Changes...)
+		it.cursors[mpID] = resp.Applied
+		if resp.Last > resp.Applied {
+			it.pending[mpID] = resp.Last - resp.Applied
+		} else {
+			delete(it.pending, mpID)
+		}
+	}
+	if err == nil && len(it.truncated) > 0 {
+		err = ErrMetaChangesTruncated
+	}
+	return
+}
+
+// Truncated returns the partitions truncated at the last call of Next.
+func (it *MetaChangeIterator) Truncated() []uint64 {
+	return append([]uint64(nil), it.truncated...)
+}
+
+// Cursors returns the cursors of the partitions, which are saved to resume the iteration later.
+func (it *MetaChangeIterator) Cursors() map[uint64]uint64 {
+	cursors := make(map[uint64]uint64, len(it.cursors))
+	for mpID, cursor := range it.cursors {
+		cursors[mpID] = cursor
+	}
+	return cursors
+}
+
+// Pending returns the number of the raft entries left to read after the last call of Next,
+// it is zero if the iterator has caught up with all the partitions.
+func (it *MetaChangeIterator) Pending() (pending uint64) {
+	for _, n := range it.pending {
+		pending += n
+	}
+	return
+}
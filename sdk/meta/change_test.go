@@ -0,0 +1,101 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+type testChangePartition struct {
+	since   uint64
+	applied uint64
+	changes []*proto.MetaChange
+}
+
+type testChangeReader map[uint64]*testChangePartition
+
+func (r testChangeReader) GetPartitionIDs() []uint64 {
+	ids := make([]uint64, 0, len(r))
+	for id := range r {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (r testChangeReader) ReadMetaChanges(mpID, from uint64, limit uint32) (*proto.ReadMetaChangesResponse, error) {
+	mp := r[mpID]
+	resp := &proto.ReadMetaChangesResponse{Applied: mp.applied, Last: mp.applied}
+	if from < mp.since {
+		resp.Truncated = true
+		return resp, nil
+	}
+	for _, change := range mp.changes {
+		if change.Index <= from {
+			continue
+		}
+		if uint32(len(resp.Changes)) == limit {
+			resp.Applied = resp.Changes[len(resp.Changes)-1].Index
+			break
+		}
+		c := *change
+		resp.Changes = append(resp.Changes, &c)
+	}
+	return resp, nil
+}
+
+func TestMetaChangeIterator(t *testing.T) {
+	reader := testChangeReader{
+		1: {applied: 3, changes: []*proto.MetaChange{
+			{Index: 1, Type: proto.MetaChangeInodeCreate, Inode: 2},
+			{Index: 2, Type: proto.MetaChangeDentryCreate, Inode: 2, ParentIno: 1, Name: "a"},
+			{Index: 3, Type: proto.MetaChangeInodeUpdate, Inode: 2},
+		}},
+		2: {since: 5, applied: 8},
+	}
+	it := newMetaChangeIterator(reader, map[uint64]uint64{2: 6}, 2)
+
+	changes, err := it.Next()
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, uint64(1), changes[0].PartitionID)
+	assert.Equal(t, uint64(1), it.Pending())
+	assert.Equal(t, map[uint64]uint64{1: 2, 2: 8}, it.Cursors())
+
+	changes, err = it.Next()
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, proto.MetaChangeInodeUpdate, changes[0].Type)
+	assert.Zero(t, it.Pending())
+
+	// the partition is rebuilt from a snapshot after the cursor
+	reader[1].since = 10
+	reader[1].applied = 12
+	changes, err = it.Next()
+	assert.Equal(t, ErrMetaChangesTruncated, err)
+	assert.Empty(t, changes)
+	assert.Equal(t, []uint64{1}, it.Truncated())
+	assert.Equal(t, uint64(12), it.Cursors()[1])
+
+	_, err = it.Next()
+	assert.NoError(t, err)
+	assert.Empty(t, it.Truncated())
+
+	it = newMetaChangeIterator(reader, nil, 10)
+	assert.NoError(t, it.SeekToLatest())
+	assert.Equal(t, map[uint64]uint64{1: 12, 2: 8}, it.Cursors())
+}
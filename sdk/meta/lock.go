@@ -0,0 +1,137 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// lockSession tracks the advisory locks held by the client. The metanodes release the locks of the
+// session if its lease is not renewed, and the held locks are sent with the renewals so that the
+// new leader of a meta partition is able to restore them.
+type lockSession struct {
+	id uint64
+
+	sync.Mutex
+	held    map[uint64][]*proto.FileLock
+	started bool
+}
+
+func newLockSession() *lockSession {
+	var buf [8]byte
+	for {
+		if _, err := rand.Read(buf[:]); err != nil {
+			log.LogWarnf("newLockSession: read random session id failed: err(%v)", err)
+			binary.BigEndian.PutUint64(buf[:], uint64(time.Now().UnixNano()))
+		}
+		if id := binary.BigEndian.Uint64(buf[:]); id != 0 {
+			return &lockSession{id: id, held: make(map[uint64][]*proto.FileLock)}
+		}
+	}
+}
+
+func (s *lockSession) update(inode uint64, lock *proto.FileLock) {
+	s.Lock()
+	defer s.Unlock()
+	locks := proto.SetFileLock(s.held[inode], lock)
+	if len(locks) == 0 {
+		delete(s.held, inode)
+		return
+	}
+	s.held[inode] = locks
+}
+
+// holds returns whether the owner holds any lock of the inode.
+func (s *lockSession) holds(inode, owner uint64, flock bool) bool {
+	s.Lock()
+	defer s.Unlock()
+	for _, l := range s.held[inode] {
+		if l.Owner == owner && l.Flock == flock {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *lockSession) snapshot() map[uint64][]*proto.FileLock {
+	s.Lock()
+	defer s.Unlock()
+	held := make(map[uint64][]*proto.FileLock, len(s.held))
+	for inode, locks := range s.held {
+		held[inode] = append([]*proto.FileLock(nil), locks...)
+	}
+	return held
+}
+
+func (s *lockSession) drop(inodes []uint64) {
+	s.Lock()
+	defer s.Unlock()
+	for _, inode := range inodes {
+		delete(s.held, inode)
+	}
+}
+
+// startLockRenewal starts renewing the leases when the first lock is acquired.
+func (mw *MetaWrapper) startLockRenewal() {
+	mw.locks.Lock()
+	defer mw.locks.Unlock()
+	if mw.locks.started {
+		return
+	}
+	mw.locks.started = true
+	go mw.renewLockLeases()
+}
+
+func (mw *MetaWrapper) renewLockLeases() {
+	t := time.NewTicker(proto.LockLeaseRenewInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-mw.closeCh:
+			return
+		case <-t.C:
+		}
+
+		partitions := make(map[*MetaPartition]map[uint64][]*proto.FileLock)
+		for inode, locks := range mw.locks.snapshot() {
+			mp := mw.getPartitionByInode(inode)
+			if mp == nil {
+				continue
+			}
+			if partitions[mp] == nil {
+				partitions[mp] = make(map[uint64][]*proto.FileLock)
+			}
+			partitions[mp][inode] = locks
+		}
+		for mp, held := range partitions {
+			lost, err := mw.renewLockLease(mp, held)
+			if err != nil {
+				log.LogWarnf("renewLockLeases: renew failed: mp(%v) session(%v) err(%v)", mp.PartitionID, mw.locks.id, err)
+				continue
+			}
+			if len(lost) > 0 {
+				log.LogErrorf("renewLockLeases: locks are acquired by others after the lease expired: mp(%v) session(%v) inodes(%v)",
+					mp.PartitionID, mw.locks.id, lost)
+				mw.locks.drop(lost)
+			}
+		}
+	}
+}
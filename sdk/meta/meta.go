@@ -59,6 +59,7 @@ const (
 	statusTxTimeout
 	statusUploadPartConflict
 	statusNotEmpty
+	statusLockConflict
 )
 
 const (
@@ -106,6 +107,7 @@ type MetaWrapper struct {
 	ossSecure         *OSSSecure
 	volCreateTime     int64
 	volDeleteLockTime int64
+	volTrashRetention int64 // minutes the unlinked files are kept in the trash, 0 disables the trash
 	owner             string
 	ownerValidation   bool
 	mc                *masterSDK.MasterClient
@@ -164,6 +166,9 @@ type MetaWrapper struct {
 	uniqidRangeMap   map[uint64]*uniqidRange
 	uniqidRangeMutex sync.Mutex
 
+	// advisory locks held by the client
+	locks *lockSession
+
 	qc *QuotaCache
 
 	VerReadSeq uint64
@@ -220,6 +225,7 @@ func NewMetaWrapper(config *MetaConfig) (*MetaWrapper, error) {
 	mw.EnableSummary = config.EnableSummary
 	mw.DirChildrenNumLimit = proto.DefaultDirChildrenNumLimit
 	mw.uniqidRangeMap = make(map[uint64]*uniqidRange, 0)
+	mw.locks = newLockSession()
 	mw.qc = NewQuotaCache(DefaultQuotaExpiration, MaxQuotaCache)
 	mw.VerReadSeq = config.VerReadSeq
 
@@ -338,6 +344,8 @@ func parseStatus(result uint8) (status int) {
 		status = statusUploadPartConflict
 	case proto.OpForbidErr:
 		status = statusForbid
+	case proto.OpLockConflictErr:
+		status = statusLockConflict
 	default:
 		status = statusError
 	}
@@ -389,6 +397,8 @@ func statusToErrno(status int) error {
 		return syscall.EEXIST
 	case statusForbid:
 		return syscall.EPERM
+	case statusLockConflict:
+		return syscall.EAGAIN
 	default:
 	}
 	return syscall.EIO
@@ -1262,7 +1262,7 @@ func (mw *MetaWrapper) appendExtentKey(mp *MetaPartition, inode uint64, extent p
 	return status, err
 }
 
-func (mw *MetaWrapper) getExtents(mp *MetaPartition, inode uint64) (resp *proto.GetExtentsResponse, err error) {
+func (mw *MetaWrapper) getExtents(mp *MetaPartition, inode uint64, verSeq uint64) (resp *proto.GetExtentsResponse, err error) {
 	bgTime := stat.BeginStat()
 	defer func() {
 		stat.EndStat("getExtents", err, bgTime, 1)
@@ -1272,7 +1272,7 @@ func (mw *MetaWrapper) getExtents(mp *MetaPartition, inode uint64) (resp *proto.
 		VolName:     mw.volname,
 		PartitionID: mp.PartitionID,
 		Inode:       inode,
-		VerSeq:      mw.VerReadSeq,
+		VerSeq:      verSeq,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -1360,40 +1360,347 @@ func (mw *MetaWrapper) getObjExtents(mp *MetaPartition, inode uint64) (status in
 	return statusOK, resp.Generation, resp.Size, resp.Extents, resp.ObjExtents, nil
 }
 
-// func (mw *MetaWrapper) delExtentKey(mp *MetaPartition, inode uint64, extents []proto.ExtentKey) (status int, err error) {
-// 	req := &proto.DelExtentKeyRequest{
-// 		VolName:     mw.volname,
-// 		PartitionID: mp.PartitionID,
-// 		Inode:       inode,
-// 		Extents:     extents,
-// 	}
-
-// 	packet := proto.NewPacketReqID()
-// 	packet.Opcode = proto.OpMetaExtentsDel
-// 	packet.PartitionID = mp.PartitionID
-// 	err = packet.MarshalData(req)
-// 	if err != nil {
-// 		log.LogErrorf("delExtentKey: req(%v) err(%v)", *req, err)
-// 		return
-// 	}
-
-// 	metric := exporter.NewTPCnt(packet.GetOpMsg())
-// 	defer func() {
-// 		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
-// 	}()
-
-// 	packet, err = mw.sendToMetaPartition(mp, packet)
-// 	if err != nil {
-// 		log.LogErrorf("delExtentKey: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
-// 		return
-// 	}
-
-// 	status = parseStatus(packet.ResultCode)
-// 	if status != statusOK {
-// 		log.LogErrorf("delExtentKey: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
-// 	}
-// 	return status, nil
-// }
+func (mw *MetaWrapper) delExtentKey(mp *MetaPartition, inode, offset, size uint64) (status int, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("delExtentKey", err, bgTime, 1)
+	}()
+
+	req := &proto.DelExtentKeyRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Inode:       inode,
+		Offset:      offset,
+		Size:        size,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaExtentsDel
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("delExtentKey: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("delExtentKey: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		log.LogErrorf("delExtentKey: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+	log.LogDebugf("delExtentKey: packet(%v) mp(%v) req(%v)", packet, mp, *req)
+	return statusOK, nil
+}
+
+func (mw *MetaWrapper) cloneExtents(mp *MetaPartition, srcIno, srcOff, dstIno, dstOff, size uint64) (status int, cloned uint64, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("cloneExtents", err, bgTime, 1)
+	}()
+
+	req := &proto.CloneExtentsRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		SrcInode:    srcIno,
+		SrcOffset:   srcOff,
+		DstInode:    dstIno,
+		DstOffset:   dstOff,
+		Size:        size,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaExtentsClone
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("cloneExtents: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("cloneExtents: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		log.LogErrorf("cloneExtents: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.CloneExtentsResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("cloneExtents: packet(%v) mp(%v) req(%v) err(%v) PacketData(%v)", packet, mp, *req, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("cloneExtents: packet(%v) mp(%v) req(%v) cloned(%v)", packet, mp, *req, resp.Size)
+	return statusOK, resp.Size, nil
+}
+
+func (mw *MetaWrapper) readChanges(mp *MetaPartition, from uint64, limit uint32) (status int, resp *proto.ReadMetaChangesResponse, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("readChanges", err, bgTime, 1)
+	}()
+
+	req := &proto.ReadMetaChangesRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		From:        from,
+		Limit:       limit,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaReadChanges
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("readChanges: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("readChanges: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		log.LogErrorf("readChanges: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp = new(proto.ReadMetaChangesResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("readChanges: packet(%v) mp(%v) req(%v) err(%v) PacketData(%v)", packet, mp, *req, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("readChanges: packet(%v) mp(%v) req(%v) changes(%v) applied(%v)", packet, mp, *req, len(resp.Changes), resp.Applied)
+	return
+}
+
+func (mw *MetaWrapper) itrash(mp *MetaPartition, inode, parentID uint64, name, fullPath string) (status int, info *proto.InodeInfo, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("itrash", err, bgTime, 1)
+	}()
+
+	req := &proto.TrashInodeRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		Inode:       inode,
+		ParentIno:   parentID,
+		Name:        name,
+		FullPath:    fullPath,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaTrashInode
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("itrash: ino(%v) err(%v)", inode, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("itrash: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		log.LogErrorf("itrash: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.UnlinkInodeResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("itrash: packet(%v) mp(%v) req(%v) err(%v) PacketData(%v)", packet, mp, *req, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("itrash: packet(%v) mp(%v) req(%v)", packet, mp, *req)
+	return statusOK, resp.Info, nil
+}
+
+func (mw *MetaWrapper) listTrash(mp *MetaPartition, uid uint32, allUsers bool, inode uint64) (status int, entries []*proto.TrashEntry, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("listTrash", err, bgTime, 1)
+	}()
+
+	req := &proto.ListTrashRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		Uid:         uid,
+		AllUsers:    allUsers,
+		Inode:       inode,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaListTrash
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("listTrash: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("listTrash: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		log.LogErrorf("listTrash: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.ListTrashResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("listTrash: packet(%v) mp(%v) req(%v) err(%v) PacketData(%v)", packet, mp, *req, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("listTrash: packet(%v) mp(%v) req(%v) entries(%v)", packet, mp, *req, len(resp.Entries))
+	return statusOK, resp.Entries, nil
+}
+
+func (mw *MetaWrapper) restoreTrash(mp *MetaPartition, uid uint32, inode, parentID uint64, name string) (status int, entry *proto.TrashEntry, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("restoreTrash", err, bgTime, 1)
+	}()
+
+	req := &proto.RestoreTrashRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		Uid:         uid,
+		Inode:       inode,
+		ParentIno:   parentID,
+		Name:        name,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaRestoreTrash
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("restoreTrash: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("restoreTrash: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		log.LogErrorf("restoreTrash: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	entry = new(proto.TrashEntry)
+	if err = packet.UnmarshalData(entry); err != nil {
+		log.LogErrorf("restoreTrash: packet(%v) mp(%v) req(%v) err(%v) PacketData(%v)", packet, mp, *req, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("restoreTrash: packet(%v) mp(%v) req(%v)", packet, mp, *req)
+	return statusOK, entry, nil
+}
+
+func (mw *MetaWrapper) purgeTrash(mp *MetaPartition, uid uint32, inode, parentID uint64, name string) (status int, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("purgeTrash", err, bgTime, 1)
+	}()
+
+	req := &proto.PurgeTrashRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		Uid:         uid,
+		Inode:       inode,
+		ParentIno:   parentID,
+		Name:        name,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaPurgeTrash
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("purgeTrash: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("purgeTrash: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		log.LogErrorf("purgeTrash: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+	log.LogDebugf("purgeTrash: packet(%v) mp(%v) req(%v)", packet, mp, *req)
+	return
+}
 
 func (mw *MetaWrapper) truncate(mp *MetaPartition, inode, size uint64, fullPath string) (status int, err error) {
 	bgTime := stat.BeginStat()
@@ -2079,6 +2386,135 @@ func (mw *MetaWrapper) setXAttr(mp *MetaPartition, inode uint64, name []byte, va
 	return
 }
 
+func (mw *MetaWrapper) setLock(mp *MetaPartition, inode uint64, lock *proto.FileLock, wait bool) (status int, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("setLock", err, bgTime, 1)
+	}()
+
+	req := &proto.SetLockRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		Inode:       inode,
+		Lock:        *lock,
+		Wait:        wait,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaSetLock
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("setLock: marshal packet fail, err(%v)", err)
+		return
+	}
+	log.LogDebugf("setLock: packet(%v) mp(%v) req(%v)", packet, mp, *req)
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("setLock: send to partition fail, packet(%v) mp(%v) req(%v) err(%v)",
+			packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK && status != statusLockConflict {
+		err = errors.New(packet.GetResultMsg())
+		log.LogErrorf("setLock: received fail status, packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	log.LogDebugf("setLock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+	return
+}
+
+func (mw *MetaWrapper) getLock(mp *MetaPartition, inode uint64, lock *proto.FileLock) (conflict *proto.FileLock, status int, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("getLock", err, bgTime, 1)
+	}()
+
+	req := &proto.GetLockRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		Inode:       inode,
+		Lock:        *lock,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaGetLock
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("getLock: marshal packet fail, err(%v)", err)
+		return
+	}
+	log.LogDebugf("getLock: packet(%v) mp(%v) req(%v)", packet, mp, *req)
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("getLock: send to partition fail, packet(%v) mp(%v) req(%v) err(%v)",
+			packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		log.LogErrorf("getLock: received fail status, packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.GetLockResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("getLock: unmarshal fail, packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+	log.LogDebugf("getLock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+	return resp.Lock, status, nil
+}
+
+func (mw *MetaWrapper) renewLockLease(mp *MetaPartition, held map[uint64][]*proto.FileLock) (lost []uint64, err error) {
+	req := &proto.RenewLockLeaseRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		Session:     mw.locks.id,
+		Locks:       held,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaRenewLockLease
+	packet.PartitionID = mp.PartitionID
+	if err = packet.MarshalData(req); err != nil {
+		return
+	}
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		return
+	}
+	if status := parseStatus(packet.ResultCode); status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.RenewLockLeaseResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		return
+	}
+	return resp.Lost, nil
+}
+
 func (mw *MetaWrapper) getAllXAttr(mp *MetaPartition, inode uint64) (attrs map[string]string, status int, err error) {
 	bgTime := stat.BeginStat()
 	defer func() {
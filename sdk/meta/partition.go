@@ -18,6 +18,7 @@ import (
 	"fmt"
 
 	"github.com/cubefs/cubefs/util/btree"
+	"github.com/cubefs/cubefs/util/log"
 )
 
 type MetaPartition struct {
@@ -68,6 +69,36 @@ func (mw *MetaWrapper) replaceOrInsertPartition(mp *MetaPartition) {
 	return
 }
 
+// replacePartitions replaces the partitions with the ones of the latest view at once, so that the
+// ranges split or merged are never missing in between. The partitions not in the view are removed.
+func (mw *MetaWrapper) replacePartitions(partitions []*MetaPartition) {
+	mw.Lock()
+	defer mw.Unlock()
+
+	ids := make(map[uint64]struct{}, len(partitions))
+	for _, mp := range partitions {
+		ids[mp.PartitionID] = struct{}{}
+		if found, ok := mw.partitions[mp.PartitionID]; ok {
+			mw.deletePartition(found)
+		}
+		mw.addPartition(mp)
+	}
+	if len(partitions) == 0 {
+		return
+	}
+	for id, mp := range mw.partitions {
+		if _, ok := ids[id]; ok {
+			continue
+		}
+		log.LogInfof("replacePartitions: remove stale mp(%v)", mp)
+		delete(mw.partitions, id)
+		// the range may have been taken by a partition of the same start
+		if found, ok := mw.ranges.Get(mp).(*MetaPartition); ok && found.PartitionID == id {
+			mw.ranges.Delete(mp)
+		}
+	}
+}
+
 func (mw *MetaWrapper) getPartitionByID(id uint64) *MetaPartition {
 	mw.RLock()
 	defer mw.RUnlock()
@@ -78,6 +109,17 @@ func (mw *MetaWrapper) getPartitionByID(id uint64) *MetaPartition {
 	return mp
 }
 
+// GetPartitionIDs returns the ids of all the meta partitions of the volume.
+func (mw *MetaWrapper) GetPartitionIDs() []uint64 {
+	mw.RLock()
+	defer mw.RUnlock()
+	ids := make([]uint64, 0, len(mw.partitions))
+	for id := range mw.partitions {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 func (mw *MetaWrapper) getPartitionByInode(ino uint64) *MetaPartition {
 	var mp *MetaPartition
 	mw.RLock()
@@ -0,0 +1,59 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/util/btree"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplacePartitions(t *testing.T) {
+	mw := &MetaWrapper{partitions: make(map[uint64]*MetaPartition), ranges: btree.New(32)}
+	mw.replacePartitions([]*MetaPartition{
+		{PartitionID: 1, Start: 1, End: 100},
+		{PartitionID: 2, Start: 101, End: 200},
+		{PartitionID: 3, Start: 201, End: 1000},
+	})
+	assert.Equal(t, uint64(2), mw.getPartitionByInode(150).PartitionID)
+
+	// partition 1 is split at 51 to partition 4, and partition 3 is merged into partition 2
+	mw.replacePartitions([]*MetaPartition{
+		{PartitionID: 1, Start: 1, End: 50},
+		{PartitionID: 4, Start: 51, End: 100},
+		{PartitionID: 2, Start: 101, End: 1000},
+	})
+	assert.Equal(t, uint64(1), mw.getPartitionByInode(50).PartitionID)
+	assert.Equal(t, uint64(4), mw.getPartitionByInode(51).PartitionID)
+	assert.Equal(t, uint64(2), mw.getPartitionByInode(500).PartitionID)
+	assert.Nil(t, mw.getPartitionByID(3))
+	assert.Equal(t, 3, mw.ranges.Len())
+
+	// partition 2 is split at the start of the merged partition 3 before the view is refreshed
+	mw.partitions[3] = &MetaPartition{PartitionID: 3, Start: 201, End: 1000}
+	mw.replacePartitions([]*MetaPartition{
+		{PartitionID: 1, Start: 1, End: 50},
+		{PartitionID: 4, Start: 51, End: 100},
+		{PartitionID: 2, Start: 101, End: 200},
+		{PartitionID: 5, Start: 201, End: 1000},
+	})
+	assert.Equal(t, uint64(5), mw.getPartitionByInode(500).PartitionID)
+	assert.Nil(t, mw.getPartitionByID(3))
+
+	// an empty view keeps the partitions
+	mw.replacePartitions(nil)
+	assert.Equal(t, 4, mw.ranges.Len())
+}
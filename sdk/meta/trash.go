@@ -0,0 +1,111 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"sort"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// TrashEnabled tells whether the files unlinked are kept in the trash of the volume.
+func (mw *MetaWrapper) TrashEnabled() bool {
+	return atomic.LoadInt64(&mw.volTrashRetention) > 0
+}
+
+// TrashRetention returns the minutes the files are kept in the trash.
+func (mw *MetaWrapper) TrashRetention() int64 {
+	return atomic.LoadInt64(&mw.volTrashRetention)
+}
+
+// ListTrash returns the entries in the trash of the user, or of all the users if allUsers is set,
+// the latest deleted ones first.
+func (mw *MetaWrapper) ListTrash(uid uint32, allUsers bool) ([]*proto.TrashEntry, error) {
+	entries := make([]*proto.TrashEntry, 0)
+	for _, id := range mw.GetPartitionIDs() {
+		mp := mw.getPartitionByID(id)
+		if mp == nil {
+			continue
+		}
+		status, partEntries, err := mw.listTrash(mp, uid, allUsers, 0)
+		if err != nil || status != statusOK {
+			log.LogErrorf("ListTrash: mp(%v) uid(%v) status(%v) err(%v)", id, uid, status, err)
+			return nil, statusErrToErrno(status, err)
+		}
+		entries = append(entries, partEntries...)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DeleteTime > entries[j].DeleteTime
+	})
+	return entries, nil
+}
+
+// RestoreTrash links the inode in the trash back to the directory it is deleted from.
+func (mw *MetaWrapper) RestoreTrash(uid uint32, inode, parentID uint64, name string) error {
+	mp := mw.getPartitionByInode(inode)
+	parentMP := mw.getPartitionByInode(parentID)
+	if mp == nil || parentMP == nil {
+		log.LogErrorf("RestoreTrash: no partition, ino(%v) parentID(%v)", inode, parentID)
+		return syscall.ENOENT
+	}
+	// the directory removed is restored before the files in it
+	status, _, err := mw.iget(parentMP, parentID, 0)
+	if err != nil || status != statusOK {
+		return statusErrToErrno(status, err)
+	}
+	status, parentEntries, err := mw.listTrash(parentMP, 0, true, parentID)
+	if err != nil || status != statusOK {
+		return statusErrToErrno(status, err)
+	}
+	if len(parentEntries) > 0 {
+		log.LogWarnf("RestoreTrash: parentID(%v) of ino(%v) is in the trash", parentID, inode)
+		return syscall.ENOENT
+	}
+
+	status, entry, err := mw.restoreTrash(mp, uid, inode, parentID, name)
+	if err != nil || status != statusOK {
+		return statusErrToErrno(status, err)
+	}
+
+	status, err = mw.dcreate(parentMP, parentID, name, inode, entry.Mode, "")
+	if err == nil && status == statusOK {
+		return nil
+	}
+	log.LogWarnf("RestoreTrash: dcreate ino(%v) parentID(%v) name(%v) status(%v) err(%v), move it back to the trash",
+		inode, parentID, name, status, err)
+	// the link taken from the trash is kept until the inode is purged
+	if trashStatus, _, trashErr := mw.itrash(mp, inode, parentID, name, ""); trashErr != nil || trashStatus != statusOK {
+		log.LogErrorf("RestoreTrash: move ino(%v) parentID(%v) name(%v) back to the trash failed, status(%v) err(%v)",
+			inode, parentID, name, trashStatus, trashErr)
+	}
+	return statusErrToErrno(status, err)
+}
+
+// PurgeTrash deletes the inode in the trash before it expires.
+func (mw *MetaWrapper) PurgeTrash(uid uint32, inode, parentID uint64, name string) error {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("PurgeTrash: no partition, ino(%v)", inode)
+		return syscall.ENOENT
+	}
+	status, err := mw.purgeTrash(mp, uid, inode, parentID, name)
+	if err != nil || status != statusOK {
+		return statusErrToErrno(status, err)
+	}
+	return nil
+}
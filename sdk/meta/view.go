@@ -44,6 +44,7 @@ type VolumeView struct {
 	OSSSecure      *OSSSecure
 	CreateTime     int64
 	DeleteLockTime int64
+	TrashRetention int64
 }
 
 type OSSSecure struct {
@@ -99,6 +100,7 @@ func (mw *MetaWrapper) fetchVolumeView() (view *VolumeView, err error) {
 			OSSSecure:      &OSSSecure{},
 			CreateTime:     volView.CreateTime,
 			DeleteLockTime: volView.DeleteLockTime,
+			TrashRetention: volView.TrashRetention,
 		}
 		if volView.OSSSecure != nil {
 			result.OSSSecure.AccessKey = volView.OSSSecure.AccessKey
@@ -198,8 +200,8 @@ func (mw *MetaWrapper) updateMetaPartitions() error {
 	}
 
 	rwPartitions := make([]*MetaPartition, 0)
+	mw.replacePartitions(view.MetaPartitions)
 	for _, mp := range view.MetaPartitions {
-		mw.replaceOrInsertPartition(mp)
 		log.LogInfof("updateMetaPartition: mp(%v)", mp)
 		if mp.Status == proto.ReadWrite {
 			rwPartitions = append(rwPartitions, mp)
@@ -208,6 +210,7 @@ func (mw *MetaWrapper) updateMetaPartitions() error {
 	mw.ossSecure = view.OSSSecure
 	mw.volCreateTime = view.CreateTime
 	mw.volDeleteLockTime = view.DeleteLockTime
+	atomic.StoreInt64(&mw.volTrashRetention, view.TrashRetention)
 
 	if len(rwPartitions) == 0 {
 		log.LogInfof("updateMetaPartition: no rw partitions")
@@ -468,3 +471,11 @@ func (mw *MetaWrapper) IsQuotaLimitedById(inodeId uint64, size bool, files bool)
 
 	return false
 }
+
+// ListDirSnapshots returns the readable snapshots taken on the directory.
+func (mw *MetaWrapper) ListDirSnapshots(ino uint64) (infos []*proto.DirSnapshotInfo, err error) {
+	if infos, err = mw.mc.AdminAPI().ListDirSnapshots(mw.volname, ino); err != nil {
+		log.LogWarnf("ListDirSnapshots: vol [%v] ino [%v] err [%v]", mw.volname, ino, err)
+	}
+	return
+}
@@ -54,6 +54,7 @@ type ExtentInfo struct {
 	SnapshotDataOff     uint64 `json:"snapSize"`
 	SnapPreAllocDataOff uint64 `json:"snapPreAllocSize"`
 	ApplyID             uint64 `json:"applyID"`
+	SavedSize           int64  `json:"savedSize,omitempty"` // space saved by the block compression
 }
 
 func (ei *ExtentInfo) TotalSize() uint64 {
@@ -99,6 +100,10 @@ type Extent struct {
 	hasClose        int32
 	header          []byte
 	snapshotDataOff uint64
+	bc              *blockCompression
+	blockIndex      []byte
+	blockMu         sync.RWMutex
+	savedSize       int64
 	sync.Mutex
 }
 
@@ -336,7 +341,7 @@ func (e *Extent) Write(data []byte, offset, size int64, crc uint32, writeType in
 			return
 		}
 	} else {
-		if _, err = e.file.WriteAt(data[:size], int64(offset)); err != nil {
+		if err = e.writeAt(data[:size], offset); err != nil {
 			log.LogErrorf("action[Extent.Write] offset %v size %v writeType %v err %v", offset, size, writeType, err)
 			return
 		}
@@ -396,7 +401,7 @@ func (e *Extent) Read(data []byte, offset, size int64, isRepairRead bool) (crc u
 	}
 
 	var rSize int
-	if rSize, err = e.file.ReadAt(data[:size], offset); err != nil {
+	if rSize, err = e.readAt(data[:size], offset); err != nil {
 		log.LogErrorf("action[Extent.Read] offset %v size %v err %v realsize %v", offset, size, err, rSize)
 		return
 	}
@@ -471,7 +476,7 @@ func (e *Extent) autoComputeExtentCrc(crcFunc UpdateCrcFunc) (crc uint32, err er
 		}
 		bdata := make([]byte, util.BlockSize)
 		offset := int64(blockNo * util.BlockSize)
-		readN, err := e.file.ReadAt(bdata[:util.BlockSize], offset)
+		readN, err := e.readAt(bdata[:util.BlockSize], offset)
 		if readN == 0 && err != nil {
 			log.LogErrorf("autoComputeExtentCrc. path %v extent %v blockNo %v, readN %v err %v", e.filePath, e.extentID, blockNo, readN, err)
 			break
@@ -499,6 +504,10 @@ func (e *Extent) punchDelete(offset, size int64) (hasDelete bool, err error) {
 	if int(size)%util.PageSize != 0 {
 		size += int64(util.PageSize - int(size)%util.PageSize)
 	}
+	if e.rangeCompressed(offset, size) {
+		err = e.punchCompressed(offset, size)
+		return
+	}
 
 	newOffset, err := e.file.Seek(offset, SEEK_DATA)
 	if err != nil {
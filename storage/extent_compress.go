@@ -0,0 +1,501 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/compressor"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	ExtCompressIndexFileName   = "EXTENT_COMPRESS"
+	ExtCompressJournalFileName = "EXTENT_COMPRESS_JOURNAL"
+
+	blockIndexEntrySize  = 4
+	blockJournalHeadSize = 28 // crc, extent id, block number, index entry and data size
+	blockEncodingShift   = 24
+	blockSizeMask        = 1<<blockEncodingShift - 1
+)
+
+// The encodings are recorded in the block index by their positions, so new encodings must be appended.
+var blockEncodings = []string{"", compressor.EncodingLZ4, compressor.EncodingZstd}
+
+func blockEncodingID(encoding string) (id uint32, ok bool) {
+	for i, e := range blockEncodings {
+		if e == encoding {
+			return uint32(i), true
+		}
+	}
+	return 0, false
+}
+
+// IsValidCompression checks if the blocks of extents can be compressed with the encoding,
+// the empty encoding disables the compression.
+func IsValidCompression(encoding string) bool {
+	_, ok := blockEncodingID(encoding)
+	return ok
+}
+
+// blockCompression is shared by the normal extents of a store to compress their blocks.
+// The full blocks in the first ExtentSize bytes of an extent are compressed in place, the
+// space left in the block is punched, and the compressed size and encoding of the block are
+// recorded in the block index file, which has the same layout as the crc file.
+// A block compressed or decompressed in place is journaled first, so the change is redone if
+// the node crashes before both the block and its index entry are synced.
+type blockCompression struct {
+	encoding  atomic.Value // the encoding to compress the blocks written, empty if disabled
+	indexFp   *os.File
+	journalMu sync.Mutex
+	journalFp *os.File
+}
+
+func newBlockCompression(indexFp, journalFp *os.File) *blockCompression {
+	bc := &blockCompression{indexFp: indexFp, journalFp: journalFp}
+	bc.encoding.Store("")
+	return bc
+}
+
+// blockChange is the change of a block in place, the data is written at the start of the block
+// and the rest of the block is punched, then the index entry of the block is set.
+type blockChange struct {
+	extentID uint64
+	blockNo  int64
+	entry    uint32
+	data     []byte
+}
+
+func (c *blockChange) apply(file *os.File, bc *blockCompression) (err error) {
+	blockStart := c.blockNo * util.BlockSize
+	if len(c.data) > 0 {
+		if _, err = file.WriteAt(c.data, blockStart); err != nil {
+			return
+		}
+	}
+	if holeStart := roundUpToPage(int64(len(c.data))); holeStart < util.BlockSize {
+		if err = fallocate(int(file.Fd()), util.FallocFLPunchHole|util.FallocFLKeepSize,
+			blockStart+holeStart, util.BlockSize-holeStart); err != nil {
+			return
+		}
+	}
+	// the block is synced before its entry, the entry of the old layout is never used for the new one
+	if err = file.Sync(); err != nil {
+		return
+	}
+	if err = bc.persistEntry(c.extentID, c.blockNo, c.entry); err != nil {
+		return
+	}
+	return bc.indexFp.Sync()
+}
+
+// changeBlock changes the block of the extent file through the journal.
+func (bc *blockCompression) changeBlock(file *os.File, c *blockChange) (err error) {
+	bc.journalMu.Lock()
+	defer bc.journalMu.Unlock()
+	if err = bc.writeJournal(c); err != nil {
+		return
+	}
+	if err = c.apply(file, bc); err != nil {
+		return
+	}
+	return bc.clearJournal()
+}
+
+// writeJournal writes the block change to the journal synced.
+func (bc *blockCompression) writeJournal(c *blockChange) (err error) {
+	buf := make([]byte, blockJournalHeadSize+len(c.data))
+	binary.BigEndian.PutUint64(buf[4:], c.extentID)
+	binary.BigEndian.PutUint64(buf[12:], uint64(c.blockNo))
+	binary.BigEndian.PutUint32(buf[20:], c.entry)
+	binary.BigEndian.PutUint32(buf[24:], uint32(len(c.data)))
+	copy(buf[blockJournalHeadSize:], c.data)
+	binary.BigEndian.PutUint32(buf, crc32.ChecksumIEEE(buf[4:]))
+	if _, err = bc.journalFp.WriteAt(buf, 0); err != nil {
+		return
+	}
+	return bc.journalFp.Sync()
+}
+
+// clearJournal clears the journal synced, the blocks written raw later are not journaled, so the
+// change done must never be redone over them.
+func (bc *blockCompression) clearJournal() (err error) {
+	if _, err = bc.journalFp.WriteAt(make([]byte, blockJournalHeadSize), 0); err != nil {
+		return
+	}
+	return bc.journalFp.Sync()
+}
+
+// redoJournal redoes the block change journaled, which may be interrupted by a crash. The journal
+// written partially is ignored, since the block is changed after the journal is synced.
+func (bc *blockCompression) redoJournal(dataPath string) (err error) {
+	head := make([]byte, blockJournalHeadSize)
+	if _, err = bc.journalFp.ReadAt(head, 0); err != nil {
+		if err == io.EOF {
+			err = nil
+		}
+		return
+	}
+	size := binary.BigEndian.Uint32(head[24:])
+	if binary.BigEndian.Uint32(head) == 0 || size > util.BlockSize {
+		return
+	}
+	buf := make([]byte, blockJournalHeadSize+int(size))
+	if _, err = bc.journalFp.ReadAt(buf, 0); err != nil {
+		if err == io.EOF {
+			err = nil
+		}
+		return
+	}
+	if crc32.ChecksumIEEE(buf[4:]) != binary.BigEndian.Uint32(buf) {
+		return
+	}
+	c := &blockChange{
+		extentID: binary.BigEndian.Uint64(buf[4:]),
+		blockNo:  int64(binary.BigEndian.Uint64(buf[12:])),
+		entry:    binary.BigEndian.Uint32(buf[20:]),
+		data:     buf[blockJournalHeadSize:],
+	}
+	file, err := os.OpenFile(path.Join(dataPath, strconv.FormatUint(c.extentID, 10)), os.O_RDWR, 0o666)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return
+		}
+		// the extent is deleted
+		err = nil
+	} else {
+		defer file.Close()
+		if err = c.apply(file, bc); err != nil {
+			return
+		}
+		log.LogWarnf("action[redoJournal] extent %v block %v entry %v is redone", c.extentID, c.blockNo, c.entry)
+	}
+	return bc.clearJournal()
+}
+
+func (bc *blockCompression) Encoding() string {
+	return bc.encoding.Load().(string)
+}
+
+func (bc *blockCompression) loadIndex(extentID uint64) (index []byte, err error) {
+	index = make([]byte, util.BlockHeaderSize)
+	if _, err = bc.indexFp.ReadAt(index, int64(extentID*util.BlockHeaderSize)); err != nil && err != io.EOF {
+		return nil, err
+	}
+	err = nil
+	for _, b := range index {
+		if b != 0 {
+			return
+		}
+	}
+	return nil, nil
+}
+
+func (bc *blockCompression) persistEntry(extentID uint64, blockNo int64, entry uint32) (err error) {
+	data := make([]byte, blockIndexEntrySize)
+	binary.BigEndian.PutUint32(data, entry)
+	_, err = bc.indexFp.WriteAt(data, int64(extentID*util.BlockHeaderSize)+blockNo*blockIndexEntrySize)
+	return
+}
+
+func (bc *blockCompression) deleteIndex(extentID uint64) error {
+	return fallocate(int(bc.indexFp.Fd()), util.FallocFLPunchHole|util.FallocFLKeepSize,
+		int64(util.BlockHeaderSize*extentID), util.BlockHeaderSize)
+}
+
+// SetCompression sets the encoding to compress the blocks written later, the blocks
+// compressed before are still readable after the encoding is changed or disabled.
+func (s *ExtentStore) SetCompression(encoding string) (err error) {
+	if s.compression == nil {
+		return
+	}
+	if !IsValidCompression(encoding) {
+		return fmt.Errorf("invalid compression %v", encoding)
+	}
+	if old := s.compression.Encoding(); old != encoding {
+		s.compression.encoding.Store(encoding)
+		log.LogInfof("action[SetCompression] partition(%v) compression changed from [%v] to [%v]",
+			s.partitionID, old, encoding)
+	}
+	return
+}
+
+// Compression returns the encoding to compress the blocks written.
+func (s *ExtentStore) Compression() string {
+	if s.compression == nil {
+		return ""
+	}
+	return s.compression.Encoding()
+}
+
+// loadBlockIndex loads the block index of the extent restored from the filesystem. The space
+// after the compressed data of the last block is a hole, so the data size is corrected here.
+func (s *ExtentStore) loadBlockIndex(e *Extent) (err error) {
+	if s.compression == nil || IsTinyExtent(e.extentID) {
+		return
+	}
+	e.bc = s.compression
+	if e.blockIndex, err = s.compression.loadIndex(e.extentID); err != nil || e.blockIndex == nil {
+		return
+	}
+	for blockNo := int64(0); blockNo < util.BlockCount; blockNo++ {
+		e.savedSize += blockSavedSize(e.blockEntry(blockNo))
+	}
+	if e.dataSize > 0 {
+		lastBlock := (e.dataSize - 1) / util.BlockSize
+		if e.blockEntry(lastBlock) != 0 {
+			e.dataSize = (lastBlock + 1) * util.BlockSize
+		}
+	}
+	return
+}
+
+func blockSavedSize(entry uint32) int64 {
+	size := int64(entry & blockSizeMask)
+	if size == 0 {
+		return 0
+	}
+	return util.BlockSize - roundUpToPage(size)
+}
+
+func roundUpToPage(size int64) int64 {
+	if size%util.PageSize != 0 {
+		size += util.PageSize - size%util.PageSize
+	}
+	return size
+}
+
+// blockEntry returns the index entry of the block, the caller must hold the blockMu.
+func (e *Extent) blockEntry(blockNo int64) uint32 {
+	if e.blockIndex == nil || blockNo >= util.BlockCount {
+		return 0
+	}
+	return binary.BigEndian.Uint32(e.blockIndex[blockNo*blockIndexEntrySize:])
+}
+
+// setBlockEntry sets the index entry of the block persisted, the caller must hold the blockMu.
+func (e *Extent) setBlockEntry(blockNo int64, entry uint32) {
+	old := e.blockEntry(blockNo)
+	if old == entry {
+		return
+	}
+	if e.blockIndex == nil {
+		e.blockIndex = make([]byte, util.BlockHeaderSize)
+	}
+	binary.BigEndian.PutUint32(e.blockIndex[blockNo*blockIndexEntrySize:], entry)
+	atomic.AddInt64(&e.savedSize, blockSavedSize(entry)-blockSavedSize(old))
+}
+
+// rangeCompressed checks if any block in the range is compressed.
+func (e *Extent) rangeCompressed(offset, size int64) bool {
+	if e.bc == nil || offset >= util.ExtentSize || size <= 0 {
+		return false
+	}
+	e.blockMu.RLock()
+	defer e.blockMu.RUnlock()
+	if e.blockIndex == nil {
+		return false
+	}
+	for blockNo := offset / util.BlockSize; blockNo*util.BlockSize < offset+size; blockNo++ {
+		if e.blockEntry(blockNo) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// SavedSize returns the size of the space saved by the compression.
+func (e *Extent) SavedSize() int64 {
+	return atomic.LoadInt64(&e.savedSize)
+}
+
+func (e *Extent) decompressBlock(blockNo int64) (raw []byte, err error) {
+	entry := e.blockEntry(blockNo)
+	encoding := blockEncodings[entry>>blockEncodingShift]
+	data := make([]byte, entry&blockSizeMask)
+	if _, err = e.file.ReadAt(data, blockNo*util.BlockSize); err != nil {
+		return
+	}
+	if raw, err = compressor.New(encoding).Decompress(data); err != nil {
+		return nil, fmt.Errorf("decompress block %v of extent %v: %v", blockNo, e.extentID, err)
+	}
+	if len(raw) != util.BlockSize {
+		return nil, fmt.Errorf("decompress block %v of extent %v: size %v mismatch", blockNo, e.extentID, len(raw))
+	}
+	return
+}
+
+// storeBlock stores the full block compressed with the encoding, or raw if it is not worth
+// compressing, the caller must hold the blockMu.
+func (e *Extent) storeBlock(blockNo int64, raw []byte, encoding string) (err error) {
+	c := &blockChange{extentID: e.extentID, blockNo: blockNo, data: raw}
+	if id, ok := blockEncodingID(encoding); ok && id != 0 {
+		var data []byte
+		if data, err = compressor.New(encoding).Compress(raw); err != nil {
+			log.LogWarnf("action[storeBlock] extent %v block %v compress with %v err %v", e, blockNo, encoding, err)
+			data, err = nil, nil
+		}
+		// keep the block raw unless a page is saved at least
+		if data != nil && roundUpToPage(int64(len(data))) < util.BlockSize {
+			c.entry, c.data = id<<blockEncodingShift|uint32(len(data)), data
+		}
+	}
+	// the raw block stays raw as the blocks not compressed
+	if c.entry == 0 && e.blockEntry(blockNo) == 0 {
+		_, err = e.file.WriteAt(raw, blockNo*util.BlockSize)
+		return
+	}
+	if err = e.bc.changeBlock(e.file, c); err != nil {
+		return
+	}
+	if c.entry != 0 {
+		log.LogDebugf("action[storeBlock] extent %v block %v compressed size %v encoding %v", e, blockNo, len(c.data), encoding)
+	}
+	e.setBlockEntry(blockNo, c.entry)
+	return
+}
+
+// readAt reads the data of the extent, the compressed blocks are decompressed.
+func (e *Extent) readAt(data []byte, offset int64) (n int, err error) {
+	if e.bc == nil || offset >= util.ExtentSize {
+		return e.file.ReadAt(data, offset)
+	}
+	// hold the lock during the read, the block may be compressed in place concurrently
+	e.blockMu.RLock()
+	defer e.blockMu.RUnlock()
+	if e.blockIndex == nil {
+		return e.file.ReadAt(data, offset)
+	}
+	var rn int
+	for n < len(data) {
+		off := offset + int64(n)
+		blockNo, blockOff := off/util.BlockSize, off%util.BlockSize
+		piece := util.Min(int(util.BlockSize-blockOff), len(data)-n)
+		if e.blockEntry(blockNo) == 0 {
+			rn, err = e.file.ReadAt(data[n:n+piece], off)
+			n += rn
+			if err != nil {
+				return
+			}
+			continue
+		}
+		var raw []byte
+		if raw, err = e.decompressBlock(blockNo); err != nil {
+			return
+		}
+		n += copy(data[n:n+piece], raw[blockOff:])
+	}
+	return
+}
+
+// writeAt writes the data to the extent, the compressed blocks are rewritten, and the
+// blocks become full are compressed if the compression is enabled.
+func (e *Extent) writeAt(data []byte, offset int64) (err error) {
+	encoding := ""
+	if e.bc != nil && offset < util.ExtentSize {
+		encoding = e.bc.Encoding()
+	}
+	if encoding == "" && !e.rangeCompressed(offset, int64(len(data))) {
+		_, err = e.file.WriteAt(data, offset)
+		return
+	}
+	e.blockMu.Lock()
+	defer e.blockMu.Unlock()
+	for written := 0; written < len(data); {
+		off := offset + int64(written)
+		blockNo, blockOff := off/util.BlockSize, off%util.BlockSize
+		piece := util.Min(int(util.BlockSize-blockOff), len(data)-written)
+		if off >= util.ExtentSize {
+			_, err = e.file.WriteAt(data[written:], off)
+			return
+		}
+
+		var raw []byte
+		if e.blockEntry(blockNo) != 0 {
+			if raw, err = e.decompressBlock(blockNo); err != nil {
+				return
+			}
+			copy(raw[blockOff:], data[written:written+piece])
+		} else if blockOff == 0 && piece == util.BlockSize {
+			raw = data[written : written+piece]
+		} else {
+			if _, err = e.file.WriteAt(data[written:written+piece], off); err != nil {
+				return
+			}
+			blockEnd := (blockNo + 1) * util.BlockSize
+			if encoding != "" && (off+int64(piece) == blockEnd || blockEnd <= e.dataSize) {
+				raw = make([]byte, util.BlockSize)
+				if _, err = e.file.ReadAt(raw, blockNo*util.BlockSize); err != nil {
+					return
+				}
+			}
+		}
+		if raw != nil {
+			if err = e.storeBlock(blockNo, raw, encoding); err != nil {
+				return
+			}
+		}
+		written += piece
+	}
+	return
+}
+
+// punchCompressed punches the range of the extent in which some blocks are compressed,
+// the compressed blocks covered partially are rewritten with zeros in the range.
+func (e *Extent) punchCompressed(offset, size int64) (err error) {
+	e.blockMu.Lock()
+	defer e.blockMu.Unlock()
+	end := offset + size
+	for off := offset; off < end; {
+		blockNo := off / util.BlockSize
+		blockStart := blockNo * util.BlockSize
+		pieceEnd := blockStart + util.BlockSize
+		if pieceEnd > end {
+			pieceEnd = end
+		}
+		entry := e.blockEntry(blockNo)
+		switch {
+		case entry == 0:
+			err = fallocate(int(e.file.Fd()), util.FallocFLPunchHole|util.FallocFLKeepSize, off, pieceEnd-off)
+		case off == blockStart && pieceEnd == blockStart+util.BlockSize:
+			if err = e.bc.changeBlock(e.file, &blockChange{extentID: e.extentID, blockNo: blockNo}); err == nil {
+				e.setBlockEntry(blockNo, 0)
+			}
+		default:
+			var raw []byte
+			if raw, err = e.decompressBlock(blockNo); err != nil {
+				return
+			}
+			for i := off - blockStart; i < pieceEnd-blockStart; i++ {
+				raw[i] = 0
+			}
+			err = e.storeBlock(blockNo, raw, blockEncodings[entry>>blockEncodingShift])
+		}
+		if err != nil {
+			return
+		}
+		off = pieceEnd
+	}
+	return
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"hash/crc32"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/compressor"
+	"github.com/stretchr/testify/require"
+)
+
+func newCompressedBlockChange(t *testing.T, e *Extent, raw []byte) *blockChange {
+	id, _ := blockEncodingID(compressor.EncodingLZ4)
+	data, err := compressor.New(compressor.EncodingLZ4).Compress(raw)
+	require.NoError(t, err)
+	return &blockChange{extentID: e.extentID, entry: id<<blockEncodingShift | uint32(len(data)), data: data}
+}
+
+func readExtent(t *testing.T, s *ExtentStore, id uint64) []byte {
+	buf := make([]byte, util.BlockSize)
+	_, err := s.Read(id, 0, util.BlockSize, buf, false)
+	require.NoError(t, err)
+	return buf
+}
+
+func TestExtentCompressCrashRecovery(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "extents")
+	s, err := NewExtentStore(dir, 0, 1*util.GB, proto.PartitionTypeNormal, true)
+	require.NoError(t, err)
+	id, err := s.NextExtentID()
+	require.NoError(t, err)
+	require.NoError(t, s.Create(id))
+	data := []byte(strings.Repeat("raw block ", util.BlockSize/10+1)[:util.BlockSize])
+	_, err = s.Write(id, 0, util.BlockSize, data, crc32.ChecksumIEEE(data), AppendWriteType, true, false)
+	require.NoError(t, err)
+	e, err := s.extentWithHeaderByExtentID(id)
+	require.NoError(t, err)
+	require.Zero(t, e.blockEntry(0))
+
+	// crash after the raw block is overwritten compressed, before the hole and the index entry
+	rewritten := []byte(strings.Repeat("rewritten in place, ", util.BlockSize/20+1)[:util.BlockSize])
+	c := newCompressedBlockChange(t, e, rewritten)
+	require.NoError(t, s.compression.writeJournal(c))
+	_, err = e.file.WriteAt(c.data, 0)
+	require.NoError(t, err)
+	s.Close()
+
+	s, err = NewExtentStore(dir, 0, 1*util.GB, proto.PartitionTypeNormal, false)
+	require.NoError(t, err)
+	require.Equal(t, rewritten, readExtent(t, s, id))
+	e, err = s.extentWithHeaderByExtentID(id)
+	require.NoError(t, err)
+	require.Equal(t, c.entry, e.blockEntry(0))
+
+	// the journal written partially is ignored, the block is untouched then
+	c = newCompressedBlockChange(t, e, data)
+	require.NoError(t, s.compression.writeJournal(c))
+	_, err = s.compression.journalFp.WriteAt([]byte{0xff}, blockJournalHeadSize+int64(len(c.data))-1)
+	require.NoError(t, err)
+	s.Close()
+
+	s, err = NewExtentStore(dir, 0, 1*util.GB, proto.PartitionTypeNormal, false)
+	require.NoError(t, err)
+	defer s.Close()
+	require.Equal(t, rewritten, readExtent(t, s, id))
+}
@@ -137,6 +137,7 @@ type ExtentStore struct {
 	hasAllocSpaceExtentIDOnVerfiyFile uint64
 	hasDeleteNormalExtentsCache       sync.Map
 	partitionType                     int
+	compression                       *blockCompression
 	ApplyId                           uint64
 	ApplyIdMutex                      sync.RWMutex
 }
@@ -226,6 +227,21 @@ func NewExtentStore(dataDir string, partitionID uint64, storeSize, dpType int, i
 	if s.normalExtentDeleteFp, err = os.OpenFile(path.Join(s.dataPath, NormalExtDeletedFileName), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o666); err != nil {
 		return
 	}
+	if proto.IsNormalDp(s.partitionType) {
+		var indexFp *os.File
+		if indexFp, err = os.OpenFile(path.Join(s.dataPath, ExtCompressIndexFileName), os.O_CREATE|os.O_RDWR, 0o666); err != nil {
+			return
+		}
+		var journalFp *os.File
+		if journalFp, err = os.OpenFile(path.Join(s.dataPath, ExtCompressJournalFileName), os.O_CREATE|os.O_RDWR, 0o666); err != nil {
+			return
+		}
+		s.compression = newBlockCompression(indexFp, journalFp)
+		if err = s.compression.redoJournal(s.dataPath); err != nil {
+			err = fmt.Errorf("redo compression journal: %v", err)
+			return
+		}
+	}
 
 	s.extentInfoMap = make(map[uint64]*ExtentInfo)
 	s.cache = NewExtentCache(100)
@@ -254,6 +270,7 @@ func (ei *ExtentInfo) UpdateExtentInfo(extent *Extent, crc uint32) {
 
 	ei.Size = uint64(extent.dataSize)
 	ei.SnapshotDataOff = extent.snapshotDataOff
+	ei.SavedSize = extent.SavedSize()
 
 	log.LogInfof("action[ExtentInfo.UpdateExtentInfo] ei info [%v]", ei.String())
 
@@ -311,6 +328,9 @@ func (s *ExtentStore) Create(extentID uint64) (err error) {
 
 	e = NewExtentInCore(name, extentID)
 	e.header = make([]byte, util.BlockHeaderSize)
+	if s.compression != nil {
+		e.bc = s.compression
+	}
 	err = e.InitToFS()
 	if err != nil {
 		return err
@@ -534,6 +554,12 @@ func (s *ExtentStore) MarkDelete(extentID uint64, offset, size int64) (err error
 		err = BrokenDiskError
 		return
 	}
+	if s.compression != nil {
+		if err = s.compression.deleteIndex(extentID); err != nil {
+			err = BrokenDiskError
+			return
+		}
+	}
 	s.PutNormalExtentToDeleteCache(extentID)
 
 	s.eiMutex.Lock()
@@ -569,6 +595,11 @@ func (s *ExtentStore) Close() {
 	s.normalExtentDeleteFp.Close()
 	s.verifyExtentFp.Sync()
 	s.verifyExtentFp.Close()
+	if s.compression != nil {
+		s.compression.indexFp.Sync()
+		s.compression.indexFp.Close()
+		s.compression.journalFp.Close()
+	}
 	for _, vFp := range s.verifyExtentFpAppend {
 		if vFp != nil {
 			vFp.Sync()
@@ -656,6 +687,19 @@ func (s *ExtentStore) GetStoreUsedSize() (used int64) {
 	return
 }
 
+// GetStorePhysicalUsedSize returns the used size excluding the space saved by the block compression.
+func (s *ExtentStore) GetStorePhysicalUsedSize() (used int64) {
+	used = s.GetStoreUsedSize()
+	s.eiMutex.RLock()
+	for _, einfo := range s.extentInfoMap {
+		if !einfo.IsDeleted {
+			used -= einfo.SavedSize
+		}
+	}
+	s.eiMutex.RUnlock()
+	return
+}
+
 // GetAllWatermarks returns all the watermarks.
 func (s *ExtentStore) GetAllWatermarks(filter ExtentFilter) (extents []*ExtentInfo, tinyDeleteFileSize int64, err error) {
 	extents = make([]*ExtentInfo, 0, len(s.extentInfoMap))
@@ -1001,6 +1045,10 @@ func (s *ExtentStore) LoadExtentFromDisk(extentID uint64, putCache bool) (e *Ext
 		err = fmt.Errorf("restore from file %v putCache %v system: %v", name, putCache, err)
 		return
 	}
+	if err = s.loadBlockIndex(e); err != nil {
+		err = fmt.Errorf("load block index of extent %v: %v", extentID, err)
+		return
+	}
 
 	if !putCache {
 		return
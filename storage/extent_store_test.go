@@ -25,6 +25,7 @@ import (
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/storage"
 	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/compressor"
 	"github.com/stretchr/testify/require"
 )
 
@@ -256,3 +257,64 @@ func TestExtentStores(t *testing.T) {
 		ExtentStoreTest(t, ty)
 	}
 }
+
+func extentStoreCompressionTest(t *testing.T, encoding string) {
+	path, clean, err := getTestPathExtentStore()
+	require.NoError(t, err)
+	defer clean()
+	s, err := storage.NewExtentStore(path, 0, 1*util.GB, proto.PartitionTypeNormal, true)
+	require.NoError(t, err)
+	defer s.Close()
+	require.NoError(t, s.SetCompression(encoding))
+	require.Equal(t, encoding, s.Compression())
+	id, err := s.NextExtentID()
+	require.NoError(t, err)
+	require.NoError(t, s.Create(id))
+
+	// two full blocks and a partial one
+	data := []byte(strings.Repeat(dataStr, 2*util.BlockSize/len(dataStr)+1))
+	size := int64(len(data))
+	for off := int64(0); off < size; off += util.BlockSize {
+		piece := data[off:]
+		if len(piece) > util.BlockSize {
+			piece = piece[:util.BlockSize]
+		}
+		_, err = s.Write(id, off, int64(len(piece)), piece, crc32.ChecksumIEEE(piece), storage.AppendWriteType, true, false)
+		require.NoError(t, err)
+	}
+	require.Less(t, s.GetStorePhysicalUsedSize(), s.GetStoreUsedSize())
+
+	// random read across the compressed blocks
+	buf := make([]byte, util.BlockSize)
+	_, err = s.Read(id, util.BlockSize/2, util.BlockSize, buf, false)
+	require.NoError(t, err)
+	require.Equal(t, data[util.BlockSize/2:util.BlockSize/2+util.BlockSize], buf)
+
+	// overwrite part of a compressed block
+	patch := []byte("overwrite")
+	copy(data[util.BlockSize+10:], patch)
+	_, err = s.Write(id, util.BlockSize+10, int64(len(patch)), patch, crc32.ChecksumIEEE(patch), storage.RandomWriteType, true, false)
+	require.NoError(t, err)
+
+	// disable the compression, the compressed blocks are still readable after reopen
+	require.NoError(t, s.SetCompression(""))
+	s.Close()
+	s, err = storage.NewExtentStore(path, 0, 1*util.GB, proto.PartitionTypeNormal, false)
+	require.NoError(t, err)
+	ei, err := s.Watermark(id)
+	require.NoError(t, err)
+	require.EqualValues(t, size, ei.Size)
+	require.NotZero(t, ei.SavedSize)
+	buf = make([]byte, size)
+	actualCrc, err := s.Read(id, 0, size, buf, false)
+	require.NoError(t, err)
+	require.Equal(t, data, buf)
+	require.EqualValues(t, crc32.ChecksumIEEE(data), actualCrc)
+	require.Error(t, s.SetCompression("snappy"))
+}
+
+func TestExtentStoreCompression(t *testing.T) {
+	for _, encoding := range []string{compressor.EncodingLZ4, compressor.EncodingZstd} {
+		extentStoreCompressionTest(t, encoding)
+	}
+}
@@ -14,7 +14,11 @@
 
 package compressor
 
-const EncodingGzip = "gzip"
+const (
+	EncodingGzip = "gzip"
+	EncodingLZ4  = "lz4"
+	EncodingZstd = "zstd"
+)
 
 // Compressor bytes compressor.
 // TODO: add stream Compressor.
@@ -33,6 +37,8 @@ var compressors = make(map[string]func() Compressor)
 func init() {
 	compressors[""] = func() Compressor { return none{} }
 	compressors[EncodingGzip] = func() Compressor { return gzipCompressor{} }
+	compressors[EncodingLZ4] = func() Compressor { return lz4Compressor{} }
+	compressors[EncodingZstd] = func() Compressor { return zstdCompressor{} }
 }
 
 func New(encoding string) Compressor {
@@ -149,6 +149,21 @@ func (mr *MockPartitionMockRecorder) LeaderTerm() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LeaderTerm", reflect.TypeOf((*MockPartition)(nil).LeaderTerm))
 }
 
+// ReplaceMember mocks base method.
+func (m *MockPartition) ReplaceMember(peer, removePeer proto.Peer, context []byte) (interface{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceMember", peer, removePeer, context)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplaceMember indicates an expected call of ReplaceMember.
+func (mr *MockPartitionMockRecorder) ReplaceMember(peer, removePeer, context interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceMember", reflect.TypeOf((*MockPartition)(nil).ReplaceMember), peer, removePeer, context)
+}
+
 // Status mocks base method.
 func (m *MockPartition) Status() *raftstore.PartitionStatus {
 	m.ctrl.T.Helper()